@@ -133,9 +133,7 @@ func toIdx(v Value) (idx int64) {
 	if idxVal, ok1 := v.(valueInt); ok1 {
 		idx = int64(idxVal)
 	} else {
-		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
-			idx = i
-		}
+		return strToIdx(v.String())
 	}
 	if idx >= 0 && idx < math.MaxUint32 {
 		return
@@ -143,8 +141,12 @@ func toIdx(v Value) (idx int64) {
 	return -1
 }
 
+// strToIdx returns the array index s is the canonical representation of, or -1.
 func strToIdx(s string) (idx int64) {
 	idx = -1
+	if s == "" || s[0] < '0' || s[0] > '9' || s[0] == '0' && len(s) > 1 {
+		return
+	}
 	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
 		idx = i
 	}
@@ -356,6 +358,7 @@ func (a *arrayObject) expand(idx int64) bool {
 						}
 					}
 				}
+				a.val.runtime.vm.allocate((newcap - int64(cap(a.values))) * valueAllocSize)
 				newValues := make([]Value, targetLen, newcap)
 				copy(newValues, a.values)
 				a.values = newValues
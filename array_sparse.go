@@ -333,6 +333,7 @@ func (a *sparseArrayObject) expand() bool {
 			return false
 		}
 	}
+	a.val.runtime.vm.allocate(sparseItemAllocSize)
 	return true
 }
 
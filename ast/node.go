@@ -38,6 +38,32 @@ type (
 		Value        []Expression
 	}
 
+	// ArrayPattern is the target of an array destructuring assignment or binding. Nil elements are holes, an
+	// element with a default value is an *AssignExpression.
+	ArrayPattern struct {
+		LeftBracket  file.Idx
+		RightBracket file.Idx
+		Elements     []Expression
+		Rest         Expression
+	}
+
+	ArrowFunctionLiteral struct {
+		Start         file.Idx
+		ParameterList *ParameterList
+		Body          ConciseBody
+		End           file.Idx // The index immediately after the body including any closing parentheses
+		Source        string
+		Async         bool
+
+		DeclarationList []Declaration
+	}
+
+	// AwaitExpression is an "await" expression in an async function.
+	AwaitExpression struct {
+		Await    file.Idx
+		Argument Expression
+	}
+
 	AssignExpression struct {
 		Operator token.Token
 		Left     Expression
@@ -76,6 +102,16 @@ type (
 		RightParenthesis file.Idx
 	}
 
+	// ClassLiteral is a class declaration or expression. SuperClass is nil if there is no extends clause.
+	ClassLiteral struct {
+		Class      file.Idx
+		Name       *Identifier
+		SuperClass Expression
+		Body       []*MethodDefinition
+		RightBrace file.Idx
+		Source     string
+	}
+
 	ConditionalExpression struct {
 		Test       Expression
 		Consequent Expression
@@ -93,6 +129,8 @@ type (
 		ParameterList *ParameterList
 		Body          Statement
 		Source        string
+		Generator     bool
+		Async         bool
 
 		DeclarationList []Declaration
 	}
@@ -102,6 +140,21 @@ type (
 		Idx  file.Idx
 	}
 
+	// ImportCall is a dynamic import of a module, it loads the module named by Argument asynchronously.
+	ImportCall struct {
+		Import           file.Idx
+		Argument         Expression
+		RightParenthesis file.Idx
+	}
+
+	// MethodDefinition is a method of a class. Kind is "constructor", "method", "get" or "set".
+	MethodDefinition struct {
+		Key    string
+		Kind   string
+		Static bool
+		Body   *FunctionLiteral
+	}
+
 	NewExpression struct {
 		New              file.Idx
 		Callee           Expression
@@ -127,12 +180,24 @@ type (
 		Value      []Property
 	}
 
+	// ObjectPattern is the target of an object destructuring assignment or binding. The Value of each property
+	// is the target, an element with a default value is an *AssignExpression.
+	ObjectPattern struct {
+		LeftBrace  file.Idx
+		RightBrace file.Idx
+		Properties []Property
+		Rest       Expression
+	}
+
 	ParameterList struct {
 		Opening file.Idx
-		List    []*Identifier
+		List    []*Binding
+		Rest    Expression
 		Closing file.Idx
 	}
 
+	// Property is a property of an object literal or pattern. Kind is "value", "get", "set", "shorthand" for
+	// {a} and {a = b} (the latter is only valid in a pattern) or "spread" for {...a} (Key is then empty).
 	Property struct {
 		Key   string
 		Kind  string
@@ -150,12 +215,42 @@ type (
 		Sequence []Expression
 	}
 
+	// SpreadElement is a "...expression" element of an array literal or an argument list.
+	SpreadElement struct {
+		Idx        file.Idx
+		Expression Expression
+	}
+
 	StringLiteral struct {
 		Idx     file.Idx
 		Literal string
 		Value   string
 	}
 
+	// SuperExpression is the super keyword, it is only valid as the callee of a call or the object of
+	// a member expression.
+	SuperExpression struct {
+		Idx file.Idx
+	}
+
+	// TemplateElement is a string part of a template literal. Literal is the raw source text (with line
+	// terminators normalised), Parsed is the value with escape sequences processed. Valid is false if the raw
+	// text contains an invalid escape sequence, which is only allowed in tagged templates.
+	TemplateElement struct {
+		Idx     file.Idx
+		Literal string
+		Parsed  string
+		Valid   bool
+	}
+
+	TemplateLiteral struct {
+		OpenQuote   file.Idx
+		Tag         Expression // The tag function of a tagged template or nil
+		Elements    []*TemplateElement
+		Expressions []Expression
+		CloseQuote  file.Idx
+	}
+
 	ThisExpression struct {
 		Idx file.Idx
 	}
@@ -167,36 +262,121 @@ type (
 		Postfix  bool
 	}
 
+	// VariableExpression is a single var, let or const declaration. Target is set instead of Name for
+	// destructuring declarations.
 	VariableExpression struct {
 		Name        string
 		Idx         file.Idx
+		Target      Expression
 		Initializer Expression
 	}
+
+	// YieldExpression is a "yield" or a "yield*" (if Delegate is set) expression in a generator function.
+	// Argument is nil if the value to yield is omitted.
+	YieldExpression struct {
+		Yield    file.Idx
+		Argument Expression
+		Delegate bool
+	}
 )
 
 // _expressionNode
 
 func (*ArrayLiteral) _expressionNode()          {}
+func (*ArrayPattern) _expressionNode()          {}
+func (*ArrowFunctionLiteral) _expressionNode()  {}
 func (*AssignExpression) _expressionNode()      {}
+func (*AwaitExpression) _expressionNode()       {}
 func (*BadExpression) _expressionNode()         {}
 func (*BinaryExpression) _expressionNode()      {}
 func (*BooleanLiteral) _expressionNode()        {}
 func (*BracketExpression) _expressionNode()     {}
+func (*ClassLiteral) _expressionNode()          {}
 func (*CallExpression) _expressionNode()        {}
 func (*ConditionalExpression) _expressionNode() {}
 func (*DotExpression) _expressionNode()         {}
 func (*FunctionLiteral) _expressionNode()       {}
 func (*Identifier) _expressionNode()            {}
+func (*ImportCall) _expressionNode()            {}
 func (*NewExpression) _expressionNode()         {}
 func (*NullLiteral) _expressionNode()           {}
 func (*NumberLiteral) _expressionNode()         {}
 func (*ObjectLiteral) _expressionNode()         {}
+func (*ObjectPattern) _expressionNode()         {}
 func (*RegExpLiteral) _expressionNode()         {}
 func (*SequenceExpression) _expressionNode()    {}
+func (*SpreadElement) _expressionNode()         {}
 func (*StringLiteral) _expressionNode()         {}
+func (*SuperExpression) _expressionNode()       {}
+func (*TemplateLiteral) _expressionNode()       {}
 func (*ThisExpression) _expressionNode()        {}
 func (*UnaryExpression) _expressionNode()       {}
 func (*VariableExpression) _expressionNode()    {}
+func (*YieldExpression) _expressionNode()       {}
+
+// ============ //
+// Concise body //
+// ============ //
+
+type (
+	// ConciseBody is the body of an arrow function, either a *BlockStatement or an *ExpressionBody.
+	ConciseBody interface {
+		Node
+		_conciseBody()
+	}
+
+	// ExpressionBody is the body of an arrow function that consists of a single expression.
+	ExpressionBody struct {
+		Expression Expression
+	}
+)
+
+func (*BlockStatement) _conciseBody() {}
+func (*ExpressionBody) _conciseBody() {}
+
+// ======= //
+// Binding //
+// ======= //
+
+// Binding is a formal parameter, Target is either an *Identifier or a pattern.
+type Binding struct {
+	Target      Expression
+	Initializer Expression
+}
+
+func (self *Binding) Idx0() file.Idx { return self.Target.Idx0() }
+func (self *Binding) Idx1() file.Idx {
+	if self.Initializer != nil {
+		return self.Initializer.Idx1()
+	}
+	return self.Target.Idx1()
+}
+
+// BoundNames calls f for each identifier bound by target, which is either an *Identifier or a pattern.
+func BoundNames(target Expression, f func(*Identifier)) {
+	switch target := target.(type) {
+	case *Identifier:
+		f(target)
+	case *AssignExpression:
+		BoundNames(target.Left, f)
+	case *ArrayPattern:
+		for _, elt := range target.Elements {
+			if elt != nil {
+				BoundNames(elt, f)
+			}
+		}
+		if target.Rest != nil {
+			BoundNames(target.Rest, f)
+		}
+	case *ObjectPattern:
+		for _, prop := range target.Properties {
+			BoundNames(prop.Value, f)
+		}
+		if target.Rest != nil {
+			BoundNames(target.Rest, f)
+		}
+	}
+}
 
 // ========= //
 // Statement //
@@ -238,6 +418,11 @@ type (
 		Body      Statement
 	}
 
+	// ClassDeclaration is a class declaration, the class name is bound like a let declaration.
+	ClassDeclaration struct {
+		Class *ClassLiteral
+	}
+
 	DebuggerStatement struct {
 		Debugger file.Idx
 	}
@@ -252,6 +437,29 @@ type (
 		Semicolon file.Idx
 	}
 
+	// ExportDeclaration is an export declaration of a module:
+	//
+	//  - Statement (a var, let, const or class declaration) or Function (a function declaration, which is also in
+	//    the DeclarationList of the program) declares the exported bindings. If Default is set, the single binding
+	//    is exported as default. The binding of an anonymous default function or class and of a default expression
+	//    (declared by a let declaration) is named "default", a keyword which cannot clash with the names used by
+	//    the module.
+	//  - Otherwise NamedExports lists the exported bindings, or the re-exported exports of the module named by
+	//    ModuleSpecifier.
+	//  - If ExportAll is set, all the exports of the module named by ModuleSpecifier are re-exported, or its
+	//    namespace object as Namespace if it is set.
+	ExportDeclaration struct {
+		Export          file.Idx
+		Statement       Statement
+		Function        *FunctionLiteral
+		Default         bool
+		NamedExports    []*ExportSpecifier
+		ExportAll       bool
+		Namespace       *Identifier
+		ModuleSpecifier *StringLiteral
+		End             file.Idx
+	}
+
 	ExpressionStatement struct {
 		Expression Expression
 	}
@@ -261,6 +469,20 @@ type (
 		Into   Expression
 		Source Expression
 		Body   Statement
+
+		// Lexical is set for `for (let x in ...)` and `for (const x in ...)`, Into is then the single
+		// VariableExpression of the declaration.
+		Lexical *LexicalDeclaration
+	}
+
+	ForOfStatement struct {
+		For    file.Idx
+		Into   Expression
+		Source Expression
+		Body   Statement
+
+		// Lexical is set for `for (let x of ...)` and `for (const x of ...)`, see ForInStatement.
+		Lexical *LexicalDeclaration
 	}
 
 	ForStatement struct {
@@ -269,6 +491,9 @@ type (
 		Update      Expression
 		Test        Expression
 		Body        Statement
+
+		// Lexical is set instead of Initializer for `for (let ...; ...; ...)` and `for (const ...; ...; ...)`
+		Lexical *LexicalDeclaration
 	}
 
 	IfStatement struct {
@@ -278,6 +503,24 @@ type (
 		Alternate  Statement
 	}
 
+	// ImportDeclaration is an import declaration of a module. The default binding, the namespace import and the
+	// named imports are all optional, a declaration without any of them only loads the module.
+	ImportDeclaration struct {
+		Import          file.Idx
+		DefaultBinding  *Identifier
+		NamespaceImport *Identifier
+		NamedImports    []*ImportSpecifier
+		ModuleSpecifier *StringLiteral
+		End             file.Idx
+	}
+
+	// LexicalDeclaration is a let or const declaration, Token is either token.LET or token.CONST.
+	LexicalDeclaration struct {
+		Idx   file.Idx
+		Token token.Token
+		List  []*VariableExpression
+	}
+
 	LabelledStatement struct {
 		Label     *Identifier
 		Colon     file.Idx
@@ -333,14 +576,19 @@ func (*BlockStatement) _statementNode()      {}
 func (*BranchStatement) _statementNode()     {}
 func (*CaseStatement) _statementNode()       {}
 func (*CatchStatement) _statementNode()      {}
+func (*ClassDeclaration) _statementNode()    {}
 func (*DebuggerStatement) _statementNode()   {}
 func (*DoWhileStatement) _statementNode()    {}
 func (*EmptyStatement) _statementNode()      {}
+func (*ExportDeclaration) _statementNode()   {}
 func (*ExpressionStatement) _statementNode() {}
 func (*ForInStatement) _statementNode()      {}
+func (*ForOfStatement) _statementNode()      {}
 func (*ForStatement) _statementNode()        {}
 func (*IfStatement) _statementNode()         {}
+func (*ImportDeclaration) _statementNode()   {}
 func (*LabelledStatement) _statementNode()   {}
+func (*LexicalDeclaration) _statementNode()  {}
 func (*ReturnStatement) _statementNode()     {}
 func (*SwitchStatement) _statementNode()     {}
 func (*ThrowStatement) _statementNode()      {}
@@ -349,6 +597,20 @@ func (*VariableStatement) _statementNode()   {}
 func (*WhileStatement) _statementNode()      {}
 func (*WithStatement) _statementNode()       {}
 
+// ImportSpecifier imports the export Name of a module as the binding Alias, which is Name itself if there is no
+// "as" clause.
+type ImportSpecifier struct {
+	Name  *Identifier
+	Alias *Identifier
+}
+
+// ExportSpecifier exports the binding Name (or the export Name of the module the declaration re-exports from) as
+// Alias.
+type ExportSpecifier struct {
+	Name  *Identifier
+	Alias *Identifier
+}
+
 // =========== //
 // Declaration //
 // =========== //
@@ -385,7 +647,8 @@ type Program struct {
 
 	File *file.File
 
-	SourceMap *sourcemap.Consumer
+	SourceMap     *sourcemap.Consumer
+	SourceMapData []byte
 }
 
 // ==== //
@@ -393,40 +656,61 @@ type Program struct {
 // ==== //
 
 func (self *ArrayLiteral) Idx0() file.Idx          { return self.LeftBracket }
+func (self *ArrayPattern) Idx0() file.Idx          { return self.LeftBracket }
+func (self *ArrowFunctionLiteral) Idx0() file.Idx  { return self.Start }
+func (self *ExpressionBody) Idx0() file.Idx        { return self.Expression.Idx0() }
 func (self *AssignExpression) Idx0() file.Idx      { return self.Left.Idx0() }
+func (self *AwaitExpression) Idx0() file.Idx       { return self.Await }
 func (self *BadExpression) Idx0() file.Idx         { return self.From }
 func (self *BinaryExpression) Idx0() file.Idx      { return self.Left.Idx0() }
 func (self *BooleanLiteral) Idx0() file.Idx        { return self.Idx }
 func (self *BracketExpression) Idx0() file.Idx     { return self.Left.Idx0() }
 func (self *CallExpression) Idx0() file.Idx        { return self.Callee.Idx0() }
+func (self *ClassLiteral) Idx0() file.Idx          { return self.Class }
 func (self *ConditionalExpression) Idx0() file.Idx { return self.Test.Idx0() }
 func (self *DotExpression) Idx0() file.Idx         { return self.Left.Idx0() }
 func (self *FunctionLiteral) Idx0() file.Idx       { return self.Function }
 func (self *Identifier) Idx0() file.Idx            { return self.Idx }
+func (self *ImportCall) Idx0() file.Idx            { return self.Import }
 func (self *NewExpression) Idx0() file.Idx         { return self.New }
 func (self *NullLiteral) Idx0() file.Idx           { return self.Idx }
 func (self *NumberLiteral) Idx0() file.Idx         { return self.Idx }
 func (self *ObjectLiteral) Idx0() file.Idx         { return self.LeftBrace }
+func (self *ObjectPattern) Idx0() file.Idx         { return self.LeftBrace }
 func (self *RegExpLiteral) Idx0() file.Idx         { return self.Idx }
 func (self *SequenceExpression) Idx0() file.Idx    { return self.Sequence[0].Idx0() }
+func (self *SpreadElement) Idx0() file.Idx         { return self.Idx }
 func (self *StringLiteral) Idx0() file.Idx         { return self.Idx }
+func (self *SuperExpression) Idx0() file.Idx       { return self.Idx }
 func (self *ThisExpression) Idx0() file.Idx        { return self.Idx }
 func (self *UnaryExpression) Idx0() file.Idx       { return self.Idx }
 func (self *VariableExpression) Idx0() file.Idx    { return self.Idx }
+func (self *YieldExpression) Idx0() file.Idx       { return self.Yield }
+func (self *TemplateLiteral) Idx0() file.Idx {
+	if self.Tag != nil {
+		return self.Tag.Idx0()
+	}
+	return self.OpenQuote
+}
 
 func (self *BadStatement) Idx0() file.Idx        { return self.From }
 func (self *BlockStatement) Idx0() file.Idx      { return self.LeftBrace }
 func (self *BranchStatement) Idx0() file.Idx     { return self.Idx }
 func (self *CaseStatement) Idx0() file.Idx       { return self.Case }
 func (self *CatchStatement) Idx0() file.Idx      { return self.Catch }
+func (self *ClassDeclaration) Idx0() file.Idx    { return self.Class.Idx0() }
 func (self *DebuggerStatement) Idx0() file.Idx   { return self.Debugger }
 func (self *DoWhileStatement) Idx0() file.Idx    { return self.Do }
 func (self *EmptyStatement) Idx0() file.Idx      { return self.Semicolon }
+func (self *ExportDeclaration) Idx0() file.Idx   { return self.Export }
 func (self *ExpressionStatement) Idx0() file.Idx { return self.Expression.Idx0() }
 func (self *ForInStatement) Idx0() file.Idx      { return self.For }
+func (self *ForOfStatement) Idx0() file.Idx      { return self.For }
 func (self *ForStatement) Idx0() file.Idx        { return self.For }
 func (self *IfStatement) Idx0() file.Idx         { return self.If }
+func (self *ImportDeclaration) Idx0() file.Idx   { return self.Import }
 func (self *LabelledStatement) Idx0() file.Idx   { return self.Label.Idx0() }
+func (self *LexicalDeclaration) Idx0() file.Idx  { return self.Idx }
 func (self *Program) Idx0() file.Idx             { return self.Body[0].Idx0() }
 func (self *ReturnStatement) Idx0() file.Idx     { return self.Return }
 func (self *SwitchStatement) Idx0() file.Idx     { return self.Switch }
@@ -441,23 +725,33 @@ func (self *WithStatement) Idx0() file.Idx       { return self.With }
 // ==== //
 
 func (self *ArrayLiteral) Idx1() file.Idx          { return self.RightBracket }
+func (self *ArrayPattern) Idx1() file.Idx          { return self.RightBracket + 1 }
+func (self *ArrowFunctionLiteral) Idx1() file.Idx  { return self.End }
+func (self *ExpressionBody) Idx1() file.Idx        { return self.Expression.Idx1() }
 func (self *AssignExpression) Idx1() file.Idx      { return self.Right.Idx1() }
+func (self *AwaitExpression) Idx1() file.Idx       { return self.Argument.Idx1() }
 func (self *BadExpression) Idx1() file.Idx         { return self.To }
 func (self *BinaryExpression) Idx1() file.Idx      { return self.Right.Idx1() }
 func (self *BooleanLiteral) Idx1() file.Idx        { return file.Idx(int(self.Idx) + len(self.Literal)) }
 func (self *BracketExpression) Idx1() file.Idx     { return self.RightBracket + 1 }
 func (self *CallExpression) Idx1() file.Idx        { return self.RightParenthesis + 1 }
+func (self *ClassLiteral) Idx1() file.Idx          { return self.RightBrace + 1 }
 func (self *ConditionalExpression) Idx1() file.Idx { return self.Test.Idx1() }
 func (self *DotExpression) Idx1() file.Idx         { return self.Identifier.Idx1() }
 func (self *FunctionLiteral) Idx1() file.Idx       { return self.Body.Idx1() }
 func (self *Identifier) Idx1() file.Idx            { return file.Idx(int(self.Idx) + len(self.Name)) }
+func (self *ImportCall) Idx1() file.Idx            { return self.RightParenthesis + 1 }
 func (self *NewExpression) Idx1() file.Idx         { return self.RightParenthesis + 1 }
 func (self *NullLiteral) Idx1() file.Idx           { return file.Idx(int(self.Idx) + 4) } // "null"
 func (self *NumberLiteral) Idx1() file.Idx         { return file.Idx(int(self.Idx) + len(self.Literal)) }
 func (self *ObjectLiteral) Idx1() file.Idx         { return self.RightBrace }
+func (self *ObjectPattern) Idx1() file.Idx         { return self.RightBrace + 1 }
 func (self *RegExpLiteral) Idx1() file.Idx         { return file.Idx(int(self.Idx) + len(self.Literal)) }
 func (self *SequenceExpression) Idx1() file.Idx    { return self.Sequence[0].Idx1() }
+func (self *SpreadElement) Idx1() file.Idx         { return self.Expression.Idx1() }
 func (self *StringLiteral) Idx1() file.Idx         { return file.Idx(int(self.Idx) + len(self.Literal)) }
+func (self *SuperExpression) Idx1() file.Idx       { return self.Idx + 5 } // "super"
+func (self *TemplateLiteral) Idx1() file.Idx       { return self.CloseQuote + 1 }
 func (self *ThisExpression) Idx1() file.Idx        { return self.Idx }
 func (self *UnaryExpression) Idx1() file.Idx {
 	if self.Postfix {
@@ -467,21 +761,33 @@ func (self *UnaryExpression) Idx1() file.Idx {
 }
 func (self *VariableExpression) Idx1() file.Idx {
 	if self.Initializer == nil {
+		if self.Target != nil {
+			return self.Target.Idx1()
+		}
 		return file.Idx(int(self.Idx) + len(self.Name) + 1)
 	}
 	return self.Initializer.Idx1()
 }
+func (self *YieldExpression) Idx1() file.Idx {
+	if self.Argument != nil {
+		return self.Argument.Idx1()
+	}
+	return self.Yield + 5 // "yield"
+}
 
 func (self *BadStatement) Idx1() file.Idx        { return self.To }
 func (self *BlockStatement) Idx1() file.Idx      { return self.RightBrace + 1 }
 func (self *BranchStatement) Idx1() file.Idx     { return self.Idx }
 func (self *CaseStatement) Idx1() file.Idx       { return self.Consequent[len(self.Consequent)-1].Idx1() }
 func (self *CatchStatement) Idx1() file.Idx      { return self.Body.Idx1() }
+func (self *ClassDeclaration) Idx1() file.Idx    { return self.Class.Idx1() }
 func (self *DebuggerStatement) Idx1() file.Idx   { return self.Debugger + 8 }
 func (self *DoWhileStatement) Idx1() file.Idx    { return self.Test.Idx1() }
 func (self *EmptyStatement) Idx1() file.Idx      { return self.Semicolon + 1 }
+func (self *ExportDeclaration) Idx1() file.Idx   { return self.End }
 func (self *ExpressionStatement) Idx1() file.Idx { return self.Expression.Idx1() }
 func (self *ForInStatement) Idx1() file.Idx      { return self.Body.Idx1() }
+func (self *ForOfStatement) Idx1() file.Idx      { return self.Body.Idx1() }
 func (self *ForStatement) Idx1() file.Idx        { return self.Body.Idx1() }
 func (self *IfStatement) Idx1() file.Idx {
 	if self.Alternate != nil {
@@ -489,7 +795,11 @@ func (self *IfStatement) Idx1() file.Idx {
 	}
 	return self.Consequent.Idx1()
 }
+func (self *ImportDeclaration) Idx1() file.Idx { return self.End }
 func (self *LabelledStatement) Idx1() file.Idx { return self.Colon + 1 }
+func (self *LexicalDeclaration) Idx1() file.Idx {
+	return self.List[len(self.List)-1].Idx1()
+}
 func (self *Program) Idx1() file.Idx           { return self.Body[len(self.Body)-1].Idx1() }
 func (self *ReturnStatement) Idx1() file.Idx   { return self.Return }
 func (self *SwitchStatement) Idx1() file.Idx   { return self.Body[len(self.Body)-1].Idx1() }
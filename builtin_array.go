@@ -107,15 +107,20 @@ func (r *Runtime) arrayproto_join(call FunctionCall) Value {
 
 	element0 := o.self.get(intToValue(0))
 	if element0 != nil && element0 != _undefined && element0 != _null {
-		buf.WriteString(element0.String())
+		s := element0.String()
+		r.vm.allocate(int64(len(s)))
+		buf.WriteString(s)
 	}
 
 	for i := 1; i < l; i++ {
 		buf.WriteString(sep)
 		element := o.self.get(intToValue(int64(i)))
 		if element != nil && element != _undefined && element != _null {
-			buf.WriteString(element.String())
+			s := element.String()
+			r.vm.allocate(int64(len(s)))
+			buf.WriteString(s)
 		}
+		r.vm.allocate(int64(len(sep)))
 	}
 
 	return newStringValue(buf.String())
@@ -202,6 +207,7 @@ func (r *Runtime) arrayproto_concat_append(a *Object, item Value) {
 	if obj, ok := item.(*Object); ok {
 		if isArray(obj) {
 			length := toLength(obj.self.getStr("length"))
+			r.vm.allocate(length * valueAllocSize)
 			for i := int64(0); i < length; i++ {
 				v := obj.self.get(intToValue(i))
 				if v != nil {
@@ -229,6 +235,10 @@ func (r *Runtime) arrayproto_concat(call FunctionCall) Value {
 	return a
 }
 
+// maxArrayFromPrealloc is the maximum number of values preallocated by Array.from() for an array-like object, whose
+// length is not backed by any storage.
+const maxArrayFromPrealloc = 1 << 16
+
 func max(a, b int64) int64 {
 	if a > b {
 		return a
@@ -536,6 +546,7 @@ func (r *Runtime) arrayproto_map(call FunctionCall) Value {
 		}
 		a := r.newArrayObject()
 		a._setLengthInt(length, true)
+		r.vm.allocate(length * valueAllocSize)
 		a.values = make([]Value, length)
 		for k := int64(0); k < length; k++ {
 			idx := intToValue(k)
@@ -758,6 +769,370 @@ func (r *Runtime) arrayproto_shift(call FunctionCall) Value {
 	return first
 }
 
+// isFastArray reports whether the elements of o, which must be an arrayObject or a sparseArrayObject, can be read
+// directly from its values or items: none of them is an accessor or has non-default attributes and the holes read
+// as undefined, because the prototype chain is the default one and does not have indexed properties.
+func (r *Runtime) isFastArray(o *Object) bool {
+	var proto *Object
+	switch a := o.self.(type) {
+	case *arrayObject:
+		if a.propValueCount > 0 {
+			return false
+		}
+		proto = a.prototype
+	case *sparseArrayObject:
+		if a.propValueCount > 0 {
+			return false
+		}
+		proto = a.prototype
+	default:
+		return false
+	}
+	if proto != r.global.ArrayPrototype {
+		return false
+	}
+	switch p := proto.self.(type) {
+	case *lazyObject:
+	case *arrayObject:
+		if len(p.values) > 0 || p.prototype != r.global.ObjectPrototype {
+			return false
+		}
+	default:
+		return false
+	}
+	op, ok := r.global.ObjectPrototype.self.(*baseObject)
+	if !ok || op.prototype != nil {
+		return false
+	}
+	for _, name := range op.propNames {
+		if strToIdx(name) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Runtime) arrayproto_find(call FunctionCall) Value {
+	o := call.This.ToObject(r)
+	length := toLength(o.self.getStr("length"))
+	predicate := call.Argument(0).ToObject(r)
+	if predicate, ok := predicate.self.assertCallable(); ok {
+		fc := FunctionCall{
+			This:      call.Argument(1),
+			Arguments: []Value{nil, nil, o},
+		}
+		for k := int64(0); k < length; k++ {
+			idx := intToValue(k)
+			val := nilSafe(o.self.get(idx))
+			fc.Arguments[0] = val
+			fc.Arguments[1] = idx
+			if predicate(fc).ToBoolean() {
+				return val
+			}
+		}
+	} else {
+		r.typeErrorResult(true, "%s is not a function", call.Argument(0))
+	}
+	return _undefined
+}
+
+func (r *Runtime) arrayproto_findIndex(call FunctionCall) Value {
+	o := call.This.ToObject(r)
+	length := toLength(o.self.getStr("length"))
+	predicate := call.Argument(0).ToObject(r)
+	if predicate, ok := predicate.self.assertCallable(); ok {
+		fc := FunctionCall{
+			This:      call.Argument(1),
+			Arguments: []Value{nil, nil, o},
+		}
+		for k := int64(0); k < length; k++ {
+			idx := intToValue(k)
+			fc.Arguments[0] = nilSafe(o.self.get(idx))
+			fc.Arguments[1] = idx
+			if predicate(fc).ToBoolean() {
+				return idx
+			}
+		}
+	} else {
+		r.typeErrorResult(true, "%s is not a function", call.Argument(0))
+	}
+	return intToValue(-1)
+}
+
+func (r *Runtime) arrayproto_includes(call FunctionCall) Value {
+	o := call.This.ToObject(r)
+	length := toLength(o.self.getStr("length"))
+	if length == 0 {
+		return valueFalse
+	}
+
+	n := call.Argument(1).ToInteger()
+	if n >= length {
+		return valueFalse
+	}
+	if n < 0 {
+		n = max(length+n, 0)
+	}
+
+	searchElement := call.Argument(0)
+
+	if r.isFastArray(o) {
+		switch a := o.self.(type) {
+		case *arrayObject:
+			for ; n < int64(len(a.values)); n++ {
+				val := a.values[n]
+				if val == nil {
+					val = _undefined
+				}
+				if sameValueZero(val, searchElement) {
+					return valueTrue
+				}
+			}
+			// the elements past the end of values are holes
+			return r.toBoolean(n < length && searchElement == _undefined)
+		case *sparseArrayObject:
+			i := a.findIdx(n)
+			if searchElement == _undefined && length-n > int64(len(a.items)-i) {
+				return valueTrue
+			}
+			for ; i < len(a.items); i++ {
+				if sameValueZero(a.items[i].value, searchElement) {
+					return valueTrue
+				}
+			}
+			return valueFalse
+		}
+	}
+
+	for ; n < length; n++ {
+		if sameValueZero(nilSafe(o.self.get(intToValue(n))), searchElement) {
+			return valueTrue
+		}
+	}
+	return valueFalse
+}
+
+func (r *Runtime) arrayproto_fill(call FunctionCall) Value {
+	o := call.This.ToObject(r)
+	length := toLength(o.self.getStr("length"))
+	value := call.Argument(0)
+	k := relToIdx(call.Argument(1).ToInteger(), length)
+	final := length
+	if end := call.Argument(2); end != _undefined {
+		final = relToIdx(end.ToInteger(), length)
+	}
+
+	if a, ok := o.self.(*arrayObject); ok && a.extensible && final <= int64(len(a.values)) && r.isFastArray(o) {
+		for ; k < final; k++ {
+			if a.values[k] == nil {
+				a.objCount++
+			}
+			a.values[k] = value
+		}
+		return o
+	}
+
+	for ; k < final; k++ {
+		o.self.put(intToValue(k), value, true)
+	}
+	return o
+}
+
+func (r *Runtime) arrayproto_copyWithin(call FunctionCall) Value {
+	o := call.This.ToObject(r)
+	length := toLength(o.self.getStr("length"))
+	to := relToIdx(call.Argument(0).ToInteger(), length)
+	from := relToIdx(call.Argument(1).ToInteger(), length)
+	final := length
+	if end := call.Argument(2); end != _undefined {
+		final = relToIdx(end.ToInteger(), length)
+	}
+	count := min(final-from, length-to)
+	if count <= 0 {
+		return o
+	}
+
+	if a, ok := o.self.(*arrayObject); ok && a.extensible && max(from, to)+count <= int64(len(a.values)) && r.isFastArray(o) {
+		// a hole copied over an element deletes it, like in the generic algorithm
+		copy(a.values[to:to+count], a.values[from:from+count])
+		return o
+	}
+
+	dir := int64(1)
+	if from < to && to < from+count {
+		from += count - 1
+		to += count - 1
+		dir = -1
+	}
+	for ; count > 0; count-- {
+		fromIdx, toIdx := intToValue(from), intToValue(to)
+		if o.self.hasProperty(fromIdx) {
+			o.self.put(toIdx, nilSafe(o.self.get(fromIdx)), true)
+		} else {
+			o.self.delete(toIdx, true)
+		}
+		from += dir
+		to += dir
+	}
+	return o
+}
+
+// flattenIntoArray appends the elements of source to target, flattening the elements which are arrays up to the
+// given depth. If mapper is not nil the elements are mapped before they are flattened (see flatMap()).
+func (r *Runtime) flattenIntoArray(target []Value, source *Object, depth int64, mapper func(FunctionCall) Value, thisArg Value) []Value {
+	if mapper == nil && r.isFastArray(source) {
+		switch a := source.self.(type) {
+		case *arrayObject:
+			for _, val := range a.values {
+				if val != nil {
+					target = r.flattenElement(target, val, depth)
+				}
+			}
+			return target
+		case *sparseArrayObject:
+			for _, item := range a.items {
+				target = r.flattenElement(target, item.value, depth)
+			}
+			return target
+		}
+	}
+
+	length := toLength(source.self.getStr("length"))
+	for k := int64(0); k < length; k++ {
+		idx := intToValue(k)
+		if !source.self.hasProperty(idx) {
+			continue
+		}
+		val := nilSafe(source.self.get(idx))
+		if mapper != nil {
+			val = mapper(FunctionCall{
+				This:      thisArg,
+				Arguments: []Value{val, idx, source},
+			})
+		}
+		target = r.flattenElement(target, val, depth)
+	}
+	return target
+}
+
+func (r *Runtime) flattenElement(target []Value, val Value, depth int64) []Value {
+	if depth > 0 {
+		if o, ok := val.(*Object); ok && isArray(o) {
+			return r.flattenIntoArray(target, o, depth-1, nil, nil)
+		}
+	}
+	return append(target, val)
+}
+
+func (r *Runtime) arrayproto_flat(call FunctionCall) Value {
+	o := call.This.ToObject(r)
+	depth := int64(1)
+	if arg := call.Argument(0); arg != _undefined {
+		depth = max(arg.ToInteger(), 0)
+	}
+	return r.newArrayValues(r.flattenIntoArray(nil, o, depth, nil, nil))
+}
+
+func (r *Runtime) arrayproto_flatMap(call FunctionCall) Value {
+	o := call.This.ToObject(r)
+	mapper := call.Argument(0).ToObject(r)
+	if mapper, ok := mapper.self.assertCallable(); ok {
+		return r.newArrayValues(r.flattenIntoArray(nil, o, 1, mapper, call.Argument(1)))
+	}
+	r.typeErrorResult(true, "%s is not a function", call.Argument(0))
+	panic("unreachable")
+}
+
+// newArrayFromCtor creates the result of Array.from() and Array.of() when they are called on a constructor other
+// than Array.
+func (r *Runtime) newArrayFromCtor(ctor func(args []Value) *Object, args, values []Value) *Object {
+	a := ctor(args)
+	descr := PropertyDescriptor{
+		Writable:     FLAG_TRUE,
+		Enumerable:   FLAG_TRUE,
+		Configurable: FLAG_TRUE,
+	}
+	for i, val := range values {
+		descr.Value = val
+		a.self.defineOwnProperty(intToValue(int64(i)), descr, true)
+	}
+	a.self.putStr("length", intToValue(int64(len(values))), true)
+	return a
+}
+
+func (r *Runtime) array_from(call FunctionCall) Value {
+	items := call.Argument(0)
+	var mapFn func(FunctionCall) Value
+	if arg := call.Argument(1); arg != _undefined {
+		mapFn = r.toCallable(arg)
+	}
+	thisArg := call.Argument(2)
+	var ctor func(args []Value) *Object
+	if call.This != r.global.Array {
+		ctor = r.toConstructor(call.This)
+	}
+
+	var values []Value
+	mapValue := func(val Value) {
+		if mapFn != nil {
+			val = mapFn(FunctionCall{
+				This:      thisArg,
+				Arguments: []Value{val, intToValue(int64(len(values)))},
+			})
+		}
+		r.vm.allocate(valueAllocSize)
+		values = append(values, val)
+	}
+
+	if items == _undefined || items == _null {
+		r.typeErrorResult(true, "%s is not iterable", items.String())
+	}
+	iterMethod := r.getV(items, SymIterator)
+	if usingIterator := toMethod(iterMethod); usingIterator != nil {
+		if o, ok := items.(*Object); ok && mapFn == nil && iterMethod == r.global.arrayValues && r.isFastArray(o) {
+			// the length may exceed the values of an array with trailing holes, such arrays go through the iterator
+			if a, ok := o.self.(*arrayObject); ok && a.length == int64(len(a.values)) {
+				r.vm.allocate(a.length * valueAllocSize)
+				values = make([]Value, a.length)
+				copy(values, a.values)
+				for i, val := range values {
+					if val == nil {
+						values[i] = _undefined
+					}
+				}
+			}
+		}
+		if values == nil {
+			r.getIterator(items).iterate(mapValue)
+		}
+		if ctor != nil {
+			return r.newArrayFromCtor(ctor, nil, values)
+		}
+	} else {
+		arrayLike := items.ToObject(r)
+		length := toLength(arrayLike.self.getStr("length"))
+		values = make([]Value, 0, min(length, maxArrayFromPrealloc))
+		for k := int64(0); k < length; k++ {
+			mapValue(nilSafe(arrayLike.self.get(intToValue(k))))
+		}
+		if ctor != nil {
+			return r.newArrayFromCtor(ctor, []Value{intToValue(length)}, values)
+		}
+	}
+	return r.newArrayValues(values)
+}
+
+func (r *Runtime) array_of(call FunctionCall) Value {
+	values := make([]Value, len(call.Arguments))
+	copy(values, call.Arguments)
+	if call.This != r.global.Array {
+		if ctor := r.toConstructor(call.This); ctor != nil {
+			return r.newArrayFromCtor(ctor, []Value{intToValue(int64(len(values)))}, values)
+		}
+	}
+	return r.newArrayValues(values)
+}
+
 func (r *Runtime) array_isArray(call FunctionCall) Value {
 	if o, ok := call.Argument(0).(*Object); ok {
 		if isArray(o) {
@@ -767,6 +1142,18 @@ func (r *Runtime) array_isArray(call FunctionCall) Value {
 	return valueFalse
 }
 
+func (r *Runtime) arrayproto_keys(call FunctionCall) Value {
+	return r.createArrayIterator(call.This.ToObject(r), iterationKindKey)
+}
+
+func (r *Runtime) arrayproto_values(call FunctionCall) Value {
+	return r.createArrayIterator(call.This.ToObject(r), iterationKindValue)
+}
+
+func (r *Runtime) arrayproto_entries(call FunctionCall) Value {
+	return r.createArrayIterator(call.This.ToObject(r), iterationKindKeyValue)
+}
+
 func (r *Runtime) createArrayProto(val *Object) objectImpl {
 	o := &arrayObject{
 		baseObject: baseObject{
@@ -800,6 +1187,17 @@ func (r *Runtime) createArrayProto(val *Object) objectImpl {
 	o._putProp("filter", r.newNativeFunc(r.arrayproto_filter, nil, "filter", nil, 1), true, false, true)
 	o._putProp("reduce", r.newNativeFunc(r.arrayproto_reduce, nil, "reduce", nil, 1), true, false, true)
 	o._putProp("reduceRight", r.newNativeFunc(r.arrayproto_reduceRight, nil, "reduceRight", nil, 1), true, false, true)
+	o._putProp("find", r.newNativeFunc(r.arrayproto_find, nil, "find", nil, 1), true, false, true)
+	o._putProp("findIndex", r.newNativeFunc(r.arrayproto_findIndex, nil, "findIndex", nil, 1), true, false, true)
+	o._putProp("includes", r.newNativeFunc(r.arrayproto_includes, nil, "includes", nil, 1), true, false, true)
+	o._putProp("fill", r.newNativeFunc(r.arrayproto_fill, nil, "fill", nil, 1), true, false, true)
+	o._putProp("copyWithin", r.newNativeFunc(r.arrayproto_copyWithin, nil, "copyWithin", nil, 2), true, false, true)
+	o._putProp("flat", r.newNativeFunc(r.arrayproto_flat, nil, "flat", nil, 0), true, false, true)
+	o._putProp("flatMap", r.newNativeFunc(r.arrayproto_flatMap, nil, "flatMap", nil, 1), true, false, true)
+	o._putProp("keys", r.newNativeFunc(r.arrayproto_keys, nil, "keys", nil, 0), true, false, true)
+	o._putProp("values", r.global.arrayValues, true, false, true)
+	o._putProp("entries", r.newNativeFunc(r.arrayproto_entries, nil, "entries", nil, 0), true, false, true)
+	o._putSym(SymIterator, r.global.arrayValues, true, false, true)
 
 	return o
 }
@@ -807,12 +1205,15 @@ func (r *Runtime) createArrayProto(val *Object) objectImpl {
 func (r *Runtime) createArray(val *Object) objectImpl {
 	o := r.newNativeFuncConstructObj(val, r.builtin_newArray, "Array", r.global.ArrayPrototype, 1)
 	o._putProp("isArray", r.newNativeFunc(r.array_isArray, nil, "isArray", nil, 1), true, false, true)
+	o._putProp("from", r.newNativeFunc(r.array_from, nil, "from", nil, 1), true, false, true)
+	o._putProp("of", r.newNativeFunc(r.array_of, nil, "of", nil, 0), true, false, true)
 	return o
 }
 
 func (r *Runtime) initArray() {
 	//r.global.ArrayPrototype = r.newArray(r.global.ObjectPrototype).val
 	//o := r.global.ArrayPrototype.self
+	r.global.arrayValues = r.newNativeFunc(r.arrayproto_values, nil, "values", nil, 0)
 	r.global.ArrayPrototype = r.newLazyObject(r.createArrayProto)
 
 	//r.global.Array = r.newNativeFuncConstruct(r.builtin_newArray, "Array", r.global.ArrayPrototype, 1)
@@ -0,0 +1,104 @@
+package goja
+
+import "testing"
+
+func TestArrayFromOf(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(Array.from("abc").join(), "a,b,c", "string");
+	assert.sameValue(Array.from([1, 2, 3], function(x, i) { return x * 2 + i; }).join(), "2,5,8", "mapFn");
+	assert.sameValue(Array.from({length: 2, 0: "x"}).join(), "x,", "array-like");
+	assert.sameValue(Array.from(new Set([1, 1, 2])).join(), "1,2", "Set");
+	assert.sameValue(1 in Array.from([1, , 3]), true, "holes");
+	var trailing = [1];
+	trailing.length = 3;
+	var copy = Array.from(trailing);
+	assert.sameValue(copy.length, 3, "trailing holes: length");
+	assert.sameValue(2 in copy, true, "trailing holes");
+	assert.sameValue(Array.of(7, 8).join(), "7,8", "of");
+
+	function C(n) {
+		this.n = n;
+	}
+	var c = Array.of.call(C, 1, 2);
+	assert.sameValue(c instanceof C, true, "of: instanceof");
+	assert.sameValue(c.n, 2, "of: length argument");
+	assert.sameValue(c.length, 2, "of: length");
+	c = Array.from.call(C, {length: 1, 0: "a"});
+	assert.sameValue(c[0] + c.n + c.length, "a11", "from: array-like");
+	c = Array.from.call(C, ["a", "b"]);
+	assert.sameValue(c[1] + c.n + c.length, "bundefined2", "from: iterable");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestArraySearch(t *testing.T) {
+	const SCRIPT = `
+	var a = [1, NaN, , 4];
+	assert.sameValue(a.find(function(x) { return x > 1; }), 4, "find");
+	assert.sameValue(a.findIndex(function(x) { return x === undefined; }), 2, "findIndex");
+	assert.sameValue(a.findIndex(function(x) { return x > 5; }), -1, "findIndex: not found");
+	assert.sameValue(a.includes(NaN), true, "includes NaN");
+	assert.sameValue(a.includes(undefined), true, "includes hole");
+	assert.sameValue(a.includes(1, -3), false, "includes fromIndex");
+
+	var sparse = [];
+	sparse[100000] = NaN;
+	assert.sameValue(sparse.includes(NaN), true, "sparse: includes NaN");
+	assert.sameValue(sparse.includes(undefined), true, "sparse: includes hole");
+	assert.sameValue(sparse.includes(1), false, "sparse: not found");
+
+	Array.prototype[1] = "proto";
+	try {
+		assert.sameValue([0, , 2].includes("proto"), true, "inherited element");
+		assert.sameValue([0, , 2].find(function(x) { return x === "proto"; }), "proto", "find: inherited element");
+	} finally {
+		delete Array.prototype[1];
+	}
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestArrayFillCopyWithin(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue([1, 2, 3, 4, 5].fill(0, 1, -1).join(), "1,0,0,0,5", "fill");
+	assert.sameValue(new Array(3).fill(7).join(), "7,7,7", "fill holes");
+	assert.sameValue(Array.prototype.fill.call({length: 2}, 1)[1], 1, "fill: generic");
+
+	assert.sameValue([1, 2, 3, 4, 5].copyWithin(0, 3).join(), "4,5,3,4,5", "copyWithin");
+	assert.sameValue([1, 2, 3, 4, 5].copyWithin(1, 0, 3).join(), "1,1,2,3,5", "copyWithin: overlap");
+	assert.sameValue([1, 2, 3, 4, 5].copyWithin(-2, -3, -1).join(), "1,2,3,3,4", "copyWithin: negative");
+	var a = [1, , 3].copyWithin(0, 1);
+	assert.sameValue(0 in a, false, "copyWithin: hole");
+	assert.sameValue(a[1], 3, "copyWithin: a[1]");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestArrayFlat(t *testing.T) {
+	const SCRIPT = `
+	var a = [1, [2, [3, [4]]], , 5];
+	assert.sameValue(a.flat().length, 4, "flat");
+	assert.sameValue(a.flat(Infinity).join(), "1,2,3,4,5", "flat(Infinity)");
+	assert.sameValue(a.flat(0).length, 3, "flat(0)");
+	assert.sameValue([1, 2].flatMap(function(x) { return [x, [x * 2]]; }).length, 4, "flatMap");
+	assert.sameValue(Array.from(["a", "b"].keys()).join(), "0,1", "keys");
+	assert.sameValue(Array.from(["a"].entries())[0].join(), "0,a", "entries");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestArrayNonCanonicalIndex(t *testing.T) {
+	const SCRIPT = `
+	var a = [];
+	a["01"] = 1;
+	a["+1"] = 2;
+	a["-0"] = 3;
+	[a.length, Object.keys(a).join()].join("|");
+	`
+
+	testScript1(SCRIPT, asciiString("0|01,+1,-0"), t)
+}
@@ -0,0 +1,67 @@
+package goja
+
+// asyncStep resumes the frame of an async function with the values (see resumeFrame) and runs it until it awaits
+// or completes. When it awaits, the function is resumed by a reaction to the awaited value. When it completes, the
+// promise returned by the function is resolved with its result or rejected with the exception it has thrown.
+func (r *Runtime) asyncStep(p *Promise, f *suspendedFrame, values ...Value) {
+	var res Value
+	ex := r.vm.try(func() {
+		res, f = r.vm.resumeFrame(f, values...)
+	})
+	if ex != nil {
+		p.reject(ex.val)
+		return
+	}
+	if f == nil {
+		p.resolve(res)
+		return
+	}
+
+	var awaited *Object
+	if ex := r.vm.try(func() {
+		awaited = r.promiseResolve(r.global.Promise, res)
+	}); ex != nil {
+		r.asyncStep(p, f, ex.val, intToValue(resumeThrow))
+		return
+	}
+	onFulfilled := r.newNativeFunc(func(call FunctionCall) Value {
+		r.asyncStep(p, f, call.Argument(0), intToValue(resumeNext))
+		return _undefined
+	}, nil, "", nil, 1)
+	onRejected := r.newNativeFunc(func(call FunctionCall) Value {
+		r.asyncStep(p, f, call.Argument(0), intToValue(resumeThrow))
+		return _undefined
+	}, nil, "", nil, 1)
+	r.performPromiseThen(awaited.self.(*Promise), onFulfilled, onRejected, nil)
+}
+
+func (r *Runtime) builtin_AsyncFunction(args []Value, proto *Object) *Object {
+	return r.newDynamicFunction("async function", args)
+}
+
+func (r *Runtime) createAsyncFunctionProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.FunctionPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.AsyncFunction, false, false, true)
+	o._putSym(SymToStringTag, asciiString("AsyncFunction"), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) createAsyncFunction(val *Object) objectImpl {
+	o := r.newNativeFuncConstructObj(val, r.builtin_AsyncFunction, "AsyncFunction", r.global.AsyncFunctionPrototype, 1)
+	o.prototype = r.global.Function
+	return o
+}
+
+// initAsync creates the intrinsics of the async functions. Like GeneratorFunction, AsyncFunction is not a global.
+func (r *Runtime) initAsync() {
+	r.global.AsyncFunctionPrototype = r.newLazyObject(r.createAsyncFunctionProto)
+	r.global.AsyncFunction = r.newLazyObject(r.createAsyncFunction)
+}
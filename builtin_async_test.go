@@ -0,0 +1,168 @@
+package goja
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAsyncFunction(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	async function f(x) {
+		log.push("start");
+		var y = await x;
+		log.push("got " + y);
+		return y * 2;
+	}
+	f(Promise.resolve(21)).then(function(v) {
+		log.push("result " + v);
+	});
+	log.push("sync");
+	var result;
+	Promise.resolve().then(function() {}).then(function() {}).then(function() {}).then(function() {
+		result = log.join();
+	});
+	`
+
+	testScriptWithJobs(SCRIPT, asciiString("start,sync,got 21,result 42"), t)
+}
+
+func TestAsyncFunctionErrors(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	async function f() {
+		try {
+			await Promise.reject(new Error("boom"));
+		} catch (e) {
+			log.push("caught " + e.message);
+		} finally {
+			log.push("finally");
+		}
+		throw 1;
+	}
+	f().catch(function(v) {
+		log.push("rejected " + v);
+	});
+	async function g(a = x.y) {}
+	g().catch(function(e) {
+		log.push(e.name);
+	});
+	`
+
+	vm := New()
+	_, err := vm.RunString(SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vm.RunJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := vm.Get("log").String(); res != "caught boom,finally,ReferenceError,rejected 1" {
+		t.Fatalf("Unexpected result: %s", res)
+	}
+}
+
+func TestAsyncFunctionObjects(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	var AsyncFunction = Object.getPrototypeOf(async function() {}).constructor;
+	class A {
+		async m() {
+			return await this.v;
+		}
+		static async s() {
+			return 5;
+		}
+	}
+	var a = new A();
+	a.v = 3;
+	var arrow = async x => x + await a.m();
+	arrow(1).then(function(v) {
+		log.push(v);
+	});
+	A.s().then(function(v) {
+		log.push(v);
+	});
+	new AsyncFunction("a", "return await a + 1")(1).then(function(v) {
+		log.push(v);
+	});
+	var thenable = {
+		then: function(resolve) {
+			resolve(42);
+		}
+	};
+	(async function() {
+		log.push(await thenable);
+	})();
+	var thrown = false;
+	try {
+		new arrow();
+	} catch (e) {
+		thrown = e instanceof TypeError;
+	}
+	var async = function(x) {
+		return x;
+	};
+	log.push(thrown, AsyncFunction.name, async(0), Object.prototype.toString.call(arrow), arrow.hasOwnProperty("prototype"));
+	`
+
+	vm := New()
+	_, err := vm.RunString(SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vm.RunJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := vm.Get("log").String(); res != "true,AsyncFunction,0,[object AsyncFunction],false,5,2,42,4" {
+		t.Fatalf("Unexpected result: %s", res)
+	}
+
+	_, err = New().RunString("async function f(a = await 1) {}")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
+func TestRunEventLoop(t *testing.T) {
+	vm := New()
+	release := make(chan struct{})
+	vm.Set("sleep", func(call FunctionCall) Value {
+		p, resolve, _ := vm.NewPromise()
+		callback := vm.RegisterCallback()
+		v := call.Argument(0).Export()
+		go func() {
+			<-release
+			callback(func() {
+				resolve(v)
+			})
+		}()
+		return vm.ToValue(p)
+	})
+	_, err := vm.RunString(`
+	var result = [];
+	async function f() {
+		result.push(await sleep(1));
+		result.push(await sleep(2));
+	}
+	f();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = vm.RunEventLoop(time.Now().Add(10 * time.Millisecond))
+	if err != ErrEventLoopDeadline {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	close(release)
+	err = vm.RunEventLoop(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := vm.Get("result").String(); res != "1,2" {
+		t.Fatalf("Unexpected result: %s", res)
+	}
+}
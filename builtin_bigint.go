@@ -0,0 +1,207 @@
+package goja
+
+import (
+	"math"
+	"math/big"
+)
+
+// maxBigIntBits limits the size of the BigInt values produced by the operations which can create arbitrarily large
+// numbers from small operands (such as shifts and BigInt.asUintN()).
+const maxBigIntBits = 1 << 30
+
+// stringToBigInt implements StringToBigInt: the string is trimmed and parsed as an optionally signed decimal integer
+// or as a binary, octal or hexadecimal integer with a 0b, 0o or 0x prefix. An empty string is 0n.
+func stringToBigInt(s valueString) (*big.Int, bool) {
+	str := s.toTrimmedUTF8()
+	if str == "" {
+		return new(big.Int), true
+	}
+	base := 10
+	if len(str) > 2 && str[0] == '0' {
+		switch str[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			str = str[2:]
+			if str[0] == '+' || str[0] == '-' {
+				return nil, false
+			}
+		}
+	}
+	return new(big.Int).SetString(str, base)
+}
+
+var bigUint64Mask = new(big.Int).SetUint64(math.MaxUint64)
+
+// bigIntToUint64 returns the lowest 64 bits of the two's complement representation of x.
+func bigIntToUint64(x *big.Int) uint64 {
+	if x.IsUint64() {
+		return x.Uint64()
+	}
+	return new(big.Int).And(x, bigUint64Mask).Uint64()
+}
+
+func (r *Runtime) numberToBigInt(v Value) *valueBigInt {
+	if i, ok := v.assertInt(); ok {
+		return (*valueBigInt)(big.NewInt(i))
+	}
+	f := v.ToFloat()
+	if math.IsNaN(f) || math.IsInf(f, 0) || math.Trunc(f) != f {
+		panic(r.newError(r.global.RangeError, "The number %s cannot be converted to a BigInt because it is not an integer", v.String()))
+	}
+	i, _ := new(big.Float).SetFloat64(f).Int(nil)
+	return (*valueBigInt)(i)
+}
+
+// toBigInt implements ToBigInt. Unlike BigInt() it does not accept Numbers.
+func (r *Runtime) toBigInt(v Value) *valueBigInt {
+	switch v := toPrimitiveNumber(v).(type) {
+	case *valueBigInt:
+		return v
+	case valueBool:
+		if v {
+			return (*valueBigInt)(big.NewInt(1))
+		}
+		return (*valueBigInt)(new(big.Int))
+	case valueString:
+		if b, ok := stringToBigInt(v); ok {
+			return (*valueBigInt)(b)
+		}
+		panic(r.newError(r.global.SyntaxError, "Cannot convert %s to a BigInt", v.String()))
+	case *Symbol:
+		r.typeErrorResult(true, "Cannot convert a Symbol value to a BigInt")
+	default:
+		r.typeErrorResult(true, "Cannot convert %s to a BigInt", v.String())
+	}
+	panic("unreachable")
+}
+
+func (r *Runtime) builtin_BigInt(call FunctionCall) Value {
+	v := toPrimitiveNumber(call.Argument(0))
+	if _, ok := v.assertInt(); ok {
+		return r.numberToBigInt(v)
+	}
+	if _, ok := v.assertFloat(); ok {
+		return r.numberToBigInt(v)
+	}
+	return r.toBigInt(v)
+}
+
+func (r *Runtime) builtin_newBigInt(args []Value) *Object {
+	r.typeErrorResult(true, "BigInt is not a constructor")
+	panic("unreachable")
+}
+
+func (r *Runtime) toBigIntBits(v Value) int64 {
+	bits := v.ToInteger()
+	if bits < 0 || bits >= maxInt {
+		panic(r.newError(r.global.RangeError, "Invalid value: not (convertible to) a safe integer"))
+	}
+	return bits
+}
+
+// bigIntAsUintN returns x modulo 2^bits.
+func (r *Runtime) bigIntAsUintN(bits int64, x *big.Int) *big.Int {
+	if x.Sign() >= 0 && int64(x.BitLen()) <= bits {
+		return x
+	}
+	if bits > maxBigIntBits {
+		panic(r.newError(r.global.RangeError, "Maximum BigInt size exceeded"))
+	}
+	mask := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	mask.Sub(mask, big.NewInt(1))
+	// big.Int bitwise operations use the two's complement representation for negative values
+	return mask.And(x, mask)
+}
+
+func (r *Runtime) bigint_asIntN(call FunctionCall) Value {
+	bits := r.toBigIntBits(call.Argument(0))
+	x := r.toBigInt(call.Argument(1)).toBig()
+	if bits == 0 {
+		return (*valueBigInt)(new(big.Int))
+	}
+	if int64(x.BitLen()) < bits {
+		return (*valueBigInt)(x)
+	}
+	res := r.bigIntAsUintN(bits, x)
+	if res.Bit(int(bits-1)) == 1 {
+		res = new(big.Int).Sub(res, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+	}
+	return (*valueBigInt)(res)
+}
+
+func (r *Runtime) bigint_asUintN(call FunctionCall) Value {
+	bits := r.toBigIntBits(call.Argument(0))
+	x := r.toBigInt(call.Argument(1)).toBig()
+	return (*valueBigInt)(r.bigIntAsUintN(bits, x))
+}
+
+func (r *Runtime) thisBigIntValue(v Value) *valueBigInt {
+	switch t := v.(type) {
+	case *valueBigInt:
+		return t
+	case *Object:
+		if pVal, ok := t.self.(*primitiveValueObject); ok {
+			if b, ok := pVal.pValue.(*valueBigInt); ok {
+				return b
+			}
+		}
+	}
+	r.typeErrorResult(true, "Value is not a BigInt")
+	return nil
+}
+
+func (r *Runtime) bigintproto_toString(call FunctionCall) Value {
+	b := r.thisBigIntValue(call.This)
+	radix := int64(10)
+	if arg := call.Argument(0); arg != _undefined {
+		radix = arg.ToInteger()
+		if radix < 2 || radix > 36 {
+			panic(r.newError(r.global.RangeError, "toString() radix must be between 2 and 36"))
+		}
+	}
+	return asciiString(b.toBig().Text(int(radix)))
+}
+
+func (r *Runtime) bigintproto_valueOf(call FunctionCall) Value {
+	return r.thisBigIntValue(call.This)
+}
+
+func (r *Runtime) createBigIntProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.BigInt, true, false, true)
+	o._putProp("toLocaleString", r.newNativeFunc(r.bigintproto_toString, nil, "toLocaleString", nil, 0), true, false, true)
+	o._putProp("toString", r.newNativeFunc(r.bigintproto_toString, nil, "toString", nil, 0), true, false, true)
+	o._putProp("valueOf", r.newNativeFunc(r.bigintproto_valueOf, nil, "valueOf", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, asciiString(classBigInt), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) createBigInt(val *Object) objectImpl {
+	o := r.newNativeFuncObj(val, r.builtin_BigInt, r.builtin_newBigInt, "BigInt", r.global.BigIntPrototype, 1)
+
+	o._putProp("asIntN", r.newNativeFunc(r.bigint_asIntN, nil, "asIntN", nil, 2), true, false, true)
+	o._putProp("asUintN", r.newNativeFunc(r.bigint_asUintN, nil, "asUintN", nil, 2), true, false, true)
+
+	return o
+}
+
+func (r *Runtime) initBigInt() {
+	r.global.BigIntPrototype = r.newLazyObject(r.createBigIntProto)
+
+	r.global.BigInt = r.newLazyObject(r.createBigInt)
+	r.addToGlobal("BigInt", r.global.BigInt)
+}
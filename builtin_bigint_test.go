@@ -0,0 +1,164 @@
+package goja
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigIntArithmetic(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(typeof 1n, "bigint", "typeof");
+	assert.sameValue(9007199254740993n + 2n, 9007199254740995n, "add");
+	assert.sameValue(0x10n - 0n, 16n, "hex literal");
+	assert.sameValue(3n * -4n, -12n, "mul");
+	assert.sameValue(-7n / 2n, -3n, "div rounds towards zero");
+	assert.sameValue(-7n % 2n, -1n, "mod");
+	assert.sameValue(1n << 64n, 18446744073709551616n, "shl");
+	assert.sameValue(-9n >> 1n, -5n, "sar");
+	assert.sameValue(1n << -1n, 0n, "negative shift");
+	assert.sameValue(-6n & 0xffn, 250n, "and");
+	assert.sameValue(5n | 2n, 7n, "or");
+	assert.sameValue(5n ^ 1n, 4n, "xor");
+	assert.sameValue(~0n, -1n, "not");
+	assert.sameValue(-(-3n), 3n, "neg");
+	var x = 10n;
+	x++;
+	x += 1n;
+	assert.sameValue(x, 12n, "inc");
+	assert.sameValue(--x, 11n, "dec");
+	assert.sameValue("a" + 1n, "a1", "concatenation");
+	assert.sameValue(0n ? 1 : 2, 2, "ToBoolean");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestBigIntComparison(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(1n == 1, true, "1n == 1");
+	assert.sameValue(1n === 1, false, "1n === 1");
+	assert.sameValue(1n == "1", true, "1n == '1'");
+	assert.sameValue(1n == 1.5, false, "1n == 1.5");
+	assert.sameValue(0n == false, true, "0n == false");
+	assert.sameValue(Object(2n) == 2n, true, "wrapper");
+	assert.sameValue(2n > 1, true, "2n > 1");
+	assert.sameValue(1n < 1.5, true, "1n < 1.5");
+	assert.sameValue(2n >= "2", true, "2n >= '2'");
+	assert.sameValue(1n < NaN, false, "NaN");
+	assert.sameValue(1n < "x", false, "invalid string");
+	assert.sameValue([1n, 2n].indexOf(2n), 1, "indexOf");
+	assert.sameValue(new Set([1n, 1n, 1]).size, 2, "Set");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestBigIntBuiltins(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(BigInt(10), 10n, "Number");
+	assert.sameValue(BigInt(" 0x1f "), 31n, "hex string");
+	assert.sameValue(BigInt("-12"), -12n, "negative string");
+	assert.sameValue(BigInt(""), 0n, "empty string");
+	assert.sameValue(BigInt(true), 1n, "boolean");
+	assert.sameValue(BigInt.asIntN(8, 255n), -1n, "asIntN");
+	assert.sameValue(BigInt.asIntN(64, 9223372036854775808n), -9223372036854775808n, "asIntN(64)");
+	assert.sameValue(BigInt.asUintN(8, -1n), 255n, "asUintN");
+	assert.sameValue(BigInt.asUintN(0, 5n), 0n, "asUintN(0)");
+	assert.sameValue((255n).toString(16), "ff", "toString");
+	assert.sameValue(Object(5n).valueOf(), 5n, "valueOf");
+	assert.sameValue(Object.prototype.toString.call(1n), "[object BigInt]", "toStringTag");
+	assert.sameValue(Number(12345678901234567890n), 12345678901234567000, "Number()");
+	assert.sameValue(String(-1n), "-1", "String()");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestBigIntErrors(t *testing.T) {
+	const SCRIPT = `
+	var errors = [];
+	function check(f) {
+		try {
+			f();
+			errors.push("no error");
+		} catch (e) {
+			errors.push(e.name);
+		}
+	}
+	check(function() { return 1n + 1; });
+	check(function() { return +1n; });
+	check(function() { return 1n >>> 0n; });
+	check(function() { return 1n / 0n; });
+	check(function() { return BigInt(1.5); });
+	check(function() { return BigInt("1.5"); });
+	check(function() { return new BigInt(1); });
+	check(function() { return JSON.stringify([1n]); });
+	check(function() { return Math.abs(1n); });
+	errors.join();
+	`
+
+	testScript1(SCRIPT, asciiString("TypeError,TypeError,TypeError,RangeError,RangeError,SyntaxError,TypeError,TypeError,TypeError"), t)
+}
+
+func TestBigIntConstantErrors(t *testing.T) {
+	for _, src := range []string{"var x = 1n % 0n", "1n / 0n", "1n << 100000000000n"} {
+		if _, err := Compile("x.js", src, false); err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+	}
+
+	const SCRIPT = `
+	var errors = [];
+	function check(f) {
+		try {
+			f();
+			errors.push("no error");
+		} catch (e) {
+			errors.push(e.name);
+		}
+	}
+	check(function() { return eval("1n / 0n"); });
+	check(function() { return eval("1n % 0n"); });
+	check(function() { return new Function("return 1n << 100000000000n")(); });
+	check(function() { return 1n % 0n; });
+	errors.join();
+	`
+
+	testScript1(SCRIPT, asciiString("RangeError,RangeError,RangeError,RangeError"), t)
+}
+
+func TestBigIntGo(t *testing.T) {
+	r := New()
+	n, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	r.Set("n", n)
+	v, err := r.RunString(`typeof n + " " + (n * 2n)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "bigint 246913578024691357802469135780" {
+		t.Fatalf("Unexpected result: %s", s)
+	}
+
+	v, err = r.RunString(`n + 1n`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, ok := v.Export().(*big.Int)
+	if !ok {
+		t.Fatalf("Unexpected export type: %T", v.Export())
+	}
+	if exp.Cmp(new(big.Int).Add(n, big.NewInt(1))) != 0 {
+		t.Fatalf("Unexpected value: %v", exp)
+	}
+	var b big.Int
+	if err := r.ExportTo(v, &b); err != nil || b.Cmp(exp) != 0 {
+		t.Fatalf("ExportTo big.Int: %v, %v", &b, err)
+	}
+	var i int64
+	if err := r.ExportTo(r.ToValue(big.NewInt(-3)), &i); err != nil || i != -3 {
+		t.Fatalf("ExportTo int64: %d, %v", i, err)
+	}
+	if n.String() != "123456789012345678901234567890" {
+		t.Fatal("the original value has been modified")
+	}
+}
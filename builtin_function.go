@@ -5,7 +5,13 @@ import (
 )
 
 func (r *Runtime) builtin_Function(args []Value, proto *Object) *Object {
-	src := "(function anonymous("
+	return r.newDynamicFunction("function", args)
+}
+
+// newDynamicFunction compiles a function from the parameters and the body passed to a function constructor such as
+// Function or GeneratorFunction.
+func (r *Runtime) newDynamicFunction(keyword string, args []Value) *Object {
+	src := "(" + keyword + " anonymous("
 	if len(args) > 1 {
 		for _, arg := range args[:len(args)-1] {
 			src += arg.String() + ","
@@ -60,6 +66,7 @@ repeat:
 func (r *Runtime) toValueArray(a Value) []Value {
 	obj := r.toObject(a)
 	l := toUInt32(obj.self.getStr("length"))
+	r.vm.allocate(int64(l) * valueAllocSize)
 	ret := make([]Value, l)
 	for i := uint32(0); i < l; i++ {
 		ret[i] = obj.self.get(valueInt(i))
@@ -181,6 +188,16 @@ repeat:
 	return v
 }
 
+func (r *Runtime) functionproto_hasInstance(call FunctionCall) Value {
+	if o, ok := call.This.(*Object); ok {
+		if _, ok = o.self.assertCallable(); ok {
+			return r.toBoolean(o.self.hasInstance(call.Argument(0)))
+		}
+	}
+
+	return valueFalse
+}
+
 func (r *Runtime) initFunction() {
 	o := r.global.FunctionPrototype.self
 	o.(*nativeFuncObject).prototype = r.global.ObjectPrototype
@@ -188,6 +205,7 @@ func (r *Runtime) initFunction() {
 	o._putProp("apply", r.newNativeFunc(r.functionproto_apply, nil, "apply", nil, 2), true, false, true)
 	o._putProp("call", r.newNativeFunc(r.functionproto_call, nil, "call", nil, 1), true, false, true)
 	o._putProp("bind", r.newNativeFunc(r.functionproto_bind, nil, "bind", nil, 1), true, false, true)
+	o._putSym(SymHasInstance, r.newNativeFunc(r.functionproto_hasInstance, nil, "[Symbol.hasInstance]", nil, 1), false, false, false)
 
 	r.global.Function = r.newNativeFuncConstruct(r.builtin_Function, "Function", r.global.FunctionPrototype, 1)
 	r.addToGlobal("Function", r.global.Function)
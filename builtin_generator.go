@@ -0,0 +1,210 @@
+package goja
+
+import (
+	"reflect"
+)
+
+type generatorState int
+
+const (
+	generatorStateSuspendedStart generatorState = iota
+	generatorStateSuspendedYield
+	generatorStateExecuting
+	generatorStateCompleted
+)
+
+// Generator is a Go wrapper around a generator object created by calling a generator function. Calling Export() on
+// a generator object returns a Generator, which can be used to step through the generator from Go.
+type Generator struct {
+	baseObject
+	state generatorState
+	// the frame of the generator function, nil once it has completed
+	frame *suspendedFrame
+}
+
+func (g *Generator) export() interface{} {
+	return g
+}
+
+func (g *Generator) exportType() reflect.Type {
+	return reflect.TypeOf(g)
+}
+
+func (r *Runtime) newGenerator(proto *Object, frame *suspendedFrame) *Generator {
+	v := &Object{runtime: r}
+	g := &Generator{
+		frame: frame,
+	}
+	g.class = classGenerator
+	g.val = v
+	g.extensible = true
+	v.self = g
+	g.prototype = proto
+	g.init()
+	return g
+}
+
+// resume continues the execution of the generator with a completion of the given kind (see resumeNext) and returns
+// the iterator result.
+func (g *Generator) resume(kind int, v Value) Value {
+	r := g.val.runtime
+	switch g.state {
+	case generatorStateExecuting:
+		r.typeErrorResult(true, "Generator is already running")
+	case generatorStateSuspendedStart:
+		if kind != resumeNext {
+			g.complete()
+		}
+	}
+	if g.state == generatorStateCompleted {
+		switch kind {
+		case resumeNext:
+			return r.createIterResultObject(_undefined, true)
+		case resumeReturn:
+			return r.createIterResultObject(v, true)
+		default:
+			panic(v)
+		}
+	}
+
+	var values []Value
+	if g.state == generatorStateSuspendedYield {
+		values = []Value{v, intToValue(int64(kind))}
+	}
+	g.state = generatorStateExecuting
+	completed := true
+	defer func() {
+		if completed {
+			g.complete()
+		}
+	}()
+	res, frame := r.vm.resumeFrame(g.frame, values...)
+	if frame == nil {
+		return r.createIterResultObject(res, true)
+	}
+	completed = false
+	g.frame = frame
+	g.state = generatorStateSuspendedYield
+	return res
+}
+
+func (g *Generator) complete() {
+	g.state = generatorStateCompleted
+	g.frame = nil
+}
+
+func (g *Generator) step(kind int, v Value) (value Value, done bool, err error) {
+	r := g.val.runtime
+	defer func() {
+		if x := recover(); x != nil {
+			if ex, ok := x.(uncatchableError); ok {
+				err = ex
+			} else {
+				panic(x)
+			}
+		}
+	}()
+	ex := r.vm.try(func() {
+		res := r.toObject(g.resume(kind, v))
+		value = nilSafe(res.self.getStr("value"))
+		done = nilSafe(res.self.getStr("done")).ToBoolean()
+	})
+	if ex != nil {
+		err = ex
+	}
+	r.vm.clearStack()
+	return
+}
+
+// Next resumes the generator, v becomes the result of the yield expression the generator is suspended at. It
+// returns the next value yielded by the generator, or the value it has returned if done is true. If the generator
+// throws, err is an *Exception; if it is interrupted or exceeds a limit, err is an *InterruptedError or a
+// *LimitExceededError.
+func (g *Generator) Next(v Value) (value Value, done bool, err error) {
+	return g.step(resumeNext, v)
+}
+
+// Return resumes the generator as if the yield expression it is suspended at was a return statement, so that its
+// finally blocks are run.
+func (g *Generator) Return(v Value) (value Value, done bool, err error) {
+	return g.step(resumeReturn, v)
+}
+
+// Throw resumes the generator as if the yield expression it is suspended at has thrown v.
+func (g *Generator) Throw(v Value) (value Value, done bool, err error) {
+	return g.step(resumeThrow, v)
+}
+
+func (r *Runtime) toGenerator(v Value, method string) *Generator {
+	if obj, ok := v.(*Object); ok {
+		if g, ok := obj.self.(*Generator); ok {
+			return g
+		}
+	}
+	r.typeErrorResult(true, "Method [Generator].prototype.%s called on incompatible receiver %s", method, v.String())
+	panic("unreachable")
+}
+
+func (r *Runtime) generatorProto_next(call FunctionCall) Value {
+	return r.toGenerator(call.This, "next").resume(resumeNext, call.Argument(0))
+}
+
+func (r *Runtime) generatorProto_return(call FunctionCall) Value {
+	return r.toGenerator(call.This, "return").resume(resumeReturn, call.Argument(0))
+}
+
+func (r *Runtime) generatorProto_throw(call FunctionCall) Value {
+	return r.toGenerator(call.This, "throw").resume(resumeThrow, call.Argument(0))
+}
+
+func (r *Runtime) builtin_GeneratorFunction(args []Value, proto *Object) *Object {
+	return r.newDynamicFunction("function*", args)
+}
+
+func (r *Runtime) createGeneratorProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.IteratorPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.GeneratorFunctionPrototype, false, false, true)
+	o._putProp("next", r.newNativeFunc(r.generatorProto_next, nil, "next", nil, 1), true, false, true)
+	o._putProp("return", r.newNativeFunc(r.generatorProto_return, nil, "return", nil, 1), true, false, true)
+	o._putProp("throw", r.newNativeFunc(r.generatorProto_throw, nil, "throw", nil, 1), true, false, true)
+	o._putSym(SymToStringTag, asciiString(classGenerator), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) createGeneratorFunctionProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.FunctionPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.GeneratorFunction, false, false, true)
+	o._putProp("prototype", r.global.GeneratorPrototype, false, false, true)
+	o._putSym(SymToStringTag, asciiString("GeneratorFunction"), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) createGeneratorFunction(val *Object) objectImpl {
+	o := r.newNativeFuncConstructObj(val, r.builtin_GeneratorFunction, "GeneratorFunction", r.global.GeneratorFunctionPrototype, 1)
+	o.prototype = r.global.Function
+	return o
+}
+
+// initGenerator creates the intrinsics of the generators. GeneratorFunction is not a global, it is reachable as the
+// constructor of a generator function.
+func (r *Runtime) initGenerator() {
+	r.global.GeneratorPrototype = r.newLazyObject(r.createGeneratorProto)
+	r.global.GeneratorFunctionPrototype = r.newLazyObject(r.createGeneratorFunctionProto)
+	r.global.GeneratorFunction = r.newLazyObject(r.createGeneratorFunction)
+}
@@ -0,0 +1,253 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestGeneratorBasic(t *testing.T) {
+	const SCRIPT = `
+	function* g(a) {
+		var x = yield a;
+		var y = yield x + 1;
+		return x + y;
+	}
+	var it = g(10);
+	var res = [it.next(), it.next(5), it.next(7), it.next()];
+	JSON.stringify(res);
+	`
+
+	testScript1(SCRIPT, asciiString(`[{"value":10,"done":false},{"value":6,"done":false},{"value":12,"done":true},{"done":true}]`), t)
+}
+
+func TestGeneratorReturnThrow(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	function* g() {
+		try {
+			yield 1;
+			yield 2;
+		} catch (e) {
+			log.push("caught " + e);
+			yield 3;
+		} finally {
+			log.push("finally");
+		}
+	}
+	var it = g();
+	it.next();
+	log.push(it.throw("x").value);
+	log.push(JSON.stringify(it.return(42)));
+	log.push(JSON.stringify(it.next()));
+
+	it = g();
+	log.push(JSON.stringify(it.return(1)));
+	var thrown;
+	try {
+		g().throw("y");
+	} catch (e) {
+		thrown = e;
+	}
+	log.push(thrown);
+	log.join();
+	`
+
+	testScript1(SCRIPT, asciiString(`caught x,3,finally,{"value":42,"done":true},{"done":true},{"value":1,"done":true},y`), t)
+}
+
+func TestGeneratorDelegate(t *testing.T) {
+	const SCRIPT = `
+	function* inner() {
+		var x = yield 1;
+		try {
+			yield x;
+		} catch (e) {
+			yield "inner " + e;
+		}
+		return "done";
+	}
+	function* g() {
+		yield* [0];
+		var r = yield* inner();
+		yield r;
+	}
+	var it = g();
+	var res = [it.next().value, it.next().value, it.next(2).value, it.throw("e").value, it.next().value];
+
+	var closed = 0;
+	var src = {};
+	src[Symbol.iterator] = function() {
+		return {
+			next: function() {
+				return {value: 1, done: false};
+			},
+			return: function(v) {
+				closed++;
+				return {value: v, done: true};
+			}
+		};
+	};
+	function* g1() {
+		yield* src;
+	}
+	it = g1();
+	it.next();
+	res.push(it.return(5).value, closed);
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("0,1,2,inner e,done,5,1"), t)
+}
+
+func TestGeneratorForOf(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	function* fib() {
+		var a = 0, b = 1;
+		try {
+			for (;;) {
+				yield a;
+				var t = a;
+				a = b;
+				b = t + b;
+			}
+		} finally {
+			log.push("closed");
+		}
+	}
+	for (var v of fib()) {
+		if (v > 10) {
+			break;
+		}
+		log.push(v);
+	}
+	function* g() {
+		for (var x of [1, 2, 3]) {
+			yield x;
+		}
+	}
+	log.push([...g()].join("-"));
+	log.join();
+	`
+
+	testScript1(SCRIPT, asciiString("0,1,1,2,3,5,8,closed,1-2-3"), t)
+}
+
+func TestGeneratorObjects(t *testing.T) {
+	const SCRIPT = `
+	function* g() {
+		yield 1;
+	}
+	var GeneratorFunction = Object.getPrototypeOf(g).constructor;
+	var GeneratorPrototype = Object.getPrototypeOf(g.prototype);
+	var it = g();
+
+	class A {
+		*m() {
+			yield this.v;
+		}
+		static *s() {
+			yield 2;
+		}
+	}
+	var a = new A();
+	a.v = 1;
+
+	var thrown = false;
+	try {
+		new g();
+	} catch (e) {
+		thrown = e instanceof TypeError;
+	}
+
+	thrown && Object.getPrototypeOf(it) === g.prototype &&
+		!g.prototype.hasOwnProperty("constructor") &&
+		GeneratorPrototype === Object.getPrototypeOf(function*() {}.prototype) &&
+		it[Symbol.iterator]() === it &&
+		Object.prototype.toString.call(it) === "[object Generator]" &&
+		GeneratorFunction.name === "GeneratorFunction" &&
+		new GeneratorFunction("a", "yield a; yield a * 2")(3).next().value === 3 &&
+		[...a.m(), ...A.s()].join() === "1,2";
+	`
+
+	testScript1(SCRIPT, valueTrue, t)
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	const SCRIPT = `
+	function* g() {
+		var self = yield;
+		self.next();
+	}
+	var it = g();
+	it.next();
+	var res = [];
+	try {
+		it.next(it);
+	} catch (e) {
+		res.push(e.message);
+	}
+	res.push(JSON.stringify(it.next()));
+	try {
+		g.prototype.next.call({});
+	} catch (e) {
+		res.push(e instanceof TypeError);
+	}
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString(`Generator is already running,{"done":true},true`), t)
+
+	_, err := New().RunString("function* g(a = yield) {}")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
+func TestGeneratorGoAPI(t *testing.T) {
+	vm := New()
+	v, err := vm.RunString(`
+	function* g() {
+		var x = yield 1;
+		try {
+			yield x * 2;
+		} finally {
+			cleanup = true;
+		}
+	}
+	var cleanup = false;
+	g();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, ok := v.Export().(*Generator)
+	if !ok {
+		t.Fatalf("Unexpected export: %T", v.Export())
+	}
+	value, done, err := gen.Next(nil)
+	if err != nil || done || value.ToInteger() != 1 {
+		t.Fatalf("Unexpected result: %v, %v, %v", value, done, err)
+	}
+	value, done, err = gen.Next(vm.ToValue(21))
+	if err != nil || done || value.ToInteger() != 42 {
+		t.Fatalf("Unexpected result: %v, %v, %v", value, done, err)
+	}
+	value, done, err = gen.Return(vm.ToValue("end"))
+	if err != nil || !done || value.String() != "end" {
+		t.Fatalf("Unexpected result: %v, %v, %v", value, done, err)
+	}
+	if !vm.Get("cleanup").ToBoolean() {
+		t.Fatal("The finally block has not run")
+	}
+
+	v, err = vm.RunString("g()")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen = v.Export().(*Generator)
+	gen.Next(nil)
+	_, _, err = gen.Throw(vm.ToValue("failed"))
+	if ex, ok := err.(*Exception); !ok || ex.Value().String() != "failed" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
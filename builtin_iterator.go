@@ -0,0 +1,225 @@
+package goja
+
+type iterationKind int
+
+const (
+	iterationKindKey iterationKind = iota
+	iterationKindValue
+	iterationKindKeyValue
+)
+
+// iteratorRecord is an iterator obtained from an iterable value.
+type iteratorRecord struct {
+	iterator *Object
+	next     Value
+}
+
+// getV returns the value of the property p of v, which may be a primitive value.
+func (r *Runtime) getV(v Value, p Value) Value {
+	obj := v.baseObject(r)
+	if obj == nil {
+		r.typeErrorResult(true, "Cannot read property '%s' of %s", p.String(), v.String())
+	}
+	prop := obj.self.getProp(p)
+	if prop, ok := prop.(*valueProperty); ok {
+		return prop.get(v)
+	}
+	return nilSafe(prop)
+}
+
+// getIterator calls the @@iterator method of v. It throws a TypeError if v is not iterable.
+func (r *Runtime) getIterator(v Value) *iteratorRecord {
+	var method func(FunctionCall) Value
+	if v != _undefined && v != _null {
+		method = toMethod(r.getV(v, SymIterator))
+	}
+	if method == nil {
+		r.typeErrorResult(true, "%s is not iterable", v.String())
+	}
+	iter, ok := method(FunctionCall{This: v}).(*Object)
+	if !ok {
+		r.typeErrorResult(true, "Result of the Symbol.iterator method is not an object")
+	}
+	return &iteratorRecord{
+		iterator: iter,
+		next:     nilSafe(iter.self.getStr("next")),
+	}
+}
+
+// step calls the next() method of the iterator. It returns false when the iterator is done.
+func (ir *iteratorRecord) step() (Value, bool) {
+	r := ir.iterator.runtime
+	res, ok := r.toCallable(ir.next)(FunctionCall{This: ir.iterator}).(*Object)
+	if !ok {
+		r.typeErrorResult(true, "Iterator result is not an object")
+	}
+	if nilSafe(res.self.getStr("done")).ToBoolean() {
+		return nil, false
+	}
+	return nilSafe(res.self.getStr("value")), true
+}
+
+// close calls the return() method of the iterator, if it has one, to let it release its resources when the
+// iteration stops before the iterator is done.
+func (ir *iteratorRecord) close() {
+	ret := toMethod(ir.iterator.self.getStr("return"))
+	if ret == nil {
+		return
+	}
+	if _, ok := ret(FunctionCall{This: ir.iterator}).(*Object); !ok {
+		ir.iterator.runtime.typeErrorResult(true, "Iterator result is not an object")
+	}
+}
+
+// iterate calls f for every value produced by the iterator. If f throws, the iterator is closed before the exception
+// is propagated, errors thrown by return() are suppressed in this case.
+func (ir *iteratorRecord) iterate(f func(Value)) {
+	for {
+		v, ok := ir.step()
+		if !ok {
+			return
+		}
+		ir.call(f, v)
+	}
+}
+
+func (ir *iteratorRecord) call(f func(Value), v Value) {
+	defer func() {
+		if x := recover(); x != nil {
+			if _, uncatchable := x.(uncatchableError); !uncatchable {
+				ir.iterator.runtime.vm.try(ir.close)
+			}
+			panic(x)
+		}
+	}()
+	f(v)
+}
+
+func (r *Runtime) createIterResultObject(value Value, done bool) Value {
+	o := r.NewObject()
+	o.self.putStr("value", value, false)
+	o.self.putStr("done", r.toBoolean(done), false)
+	return o
+}
+
+// iteratorObject is a built-in iterator, such as an Array or a String Iterator. It produces the values by calling
+// a Go function, so that arrays and Go maps and slices can be iterated without copying them.
+type iteratorObject struct {
+	baseObject
+	next func() (Value, bool)
+}
+
+func (r *Runtime) newIteratorObject(proto *Object, class string, next func() (Value, bool)) *Object {
+	v := &Object{runtime: r}
+	o := &iteratorObject{
+		next: next,
+	}
+	o.class = class
+	o.val = v
+	o.extensible = true
+	v.self = o
+	o.prototype = proto
+	o.init()
+	return v
+}
+
+func (o *iteratorObject) step() Value {
+	r := o.val.runtime
+	if o.next != nil {
+		if v, ok := o.next(); ok {
+			return r.createIterResultObject(v, false)
+		}
+		o.next = nil
+	}
+	return r.createIterResultObject(_undefined, true)
+}
+
+func (r *Runtime) createArrayIterator(obj *Object, kind iterationKind) Value {
+	var idx int64
+	return r.newIteratorObject(r.global.ArrayIteratorPrototype, classArrayIterator, func() (Value, bool) {
+		if idx >= toLength(obj.self.getStr("length")) {
+			return nil, false
+		}
+		key := intToValue(idx)
+		idx++
+		switch kind {
+		case iterationKindKey:
+			return key, true
+		case iterationKindValue:
+			return nilSafe(obj.self.get(key)), true
+		}
+		return r.newArrayValues([]Value{key, nilSafe(obj.self.get(key))}), true
+	})
+}
+
+func (r *Runtime) createStringIterator(s valueString) Value {
+	var pos int64
+	return r.newIteratorObject(r.global.StringIteratorPrototype, classStringIterator, func() (Value, bool) {
+		l := s.length()
+		if pos >= l {
+			return nil, false
+		}
+		n := int64(1)
+		if first := s.charAt(pos); first >= 0xD800 && first <= 0xDBFF && pos+1 < l {
+			if second := s.charAt(pos + 1); second >= 0xDC00 && second <= 0xDFFF {
+				n = 2
+			}
+		}
+		v := s.substring(pos, pos+n)
+		pos += n
+		return v, true
+	})
+}
+
+func (r *Runtime) iteratorproto_iterator(call FunctionCall) Value {
+	return call.This
+}
+
+// iteratorproto_next returns the next() method of the built-in iterators of the given class.
+func (r *Runtime) iteratorproto_next(class string) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		if obj, ok := call.This.(*Object); ok {
+			if iter, ok := obj.self.(*iteratorObject); ok && iter.class == class {
+				return iter.step()
+			}
+		}
+		r.typeErrorResult(true, "Method %s.prototype.next called on incompatible receiver %s", class, call.This.String())
+		return nil
+	}
+}
+
+func (r *Runtime) createIteratorProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putSym(SymIterator, r.newNativeFunc(r.iteratorproto_iterator, nil, "[Symbol.iterator]", nil, 0), true, false, true)
+	return o
+}
+
+// builtinIteratorProto returns a function creating the prototype of the built-in iterators of the given class.
+func (r *Runtime) builtinIteratorProto(class string) func(val *Object) objectImpl {
+	return func(val *Object) objectImpl {
+		o := &baseObject{
+			class:      classObject,
+			val:        val,
+			extensible: true,
+			prototype:  r.global.IteratorPrototype,
+		}
+		o.init()
+
+		o._putProp("next", r.newNativeFunc(r.iteratorproto_next(class), nil, "next", nil, 0), true, false, true)
+		o._putSym(SymToStringTag, asciiString(class), false, false, true)
+		return o
+	}
+}
+
+func (r *Runtime) initIterators() {
+	r.global.IteratorPrototype = r.newLazyObject(r.createIteratorProto)
+	r.global.ArrayIteratorPrototype = r.newLazyObject(r.builtinIteratorProto(classArrayIterator))
+	r.global.StringIteratorPrototype = r.newLazyObject(r.builtinIteratorProto(classStringIterator))
+}
@@ -189,6 +189,7 @@ type _builtinJSON_stringifyContext struct {
 	replacerFunction func(FunctionCall) Value
 	gap, indent      string
 	buf              bytes.Buffer
+	allocated        int
 }
 
 func (r *Runtime) builtinJSON_stringify(call FunctionCall) Value {
@@ -268,6 +269,7 @@ func (r *Runtime) builtinJSON_stringify(call FunctionCall) Value {
 	}
 
 	if ctx.do(call.Argument(0)) {
+		ctx.allocate()
 		return newStringValue(ctx.buf.String())
 	}
 	return _undefined
@@ -279,14 +281,29 @@ func (ctx *_builtinJSON_stringifyContext) do(v Value) bool {
 	return ctx.str(stringEmpty, holder)
 }
 
+// allocate accounts for the output written since the last call, see Limits.MaxAllocation.
+func (ctx *_builtinJSON_stringifyContext) allocate() {
+	l := ctx.buf.Len()
+	ctx.r.vm.allocate(int64(l - ctx.allocated))
+	ctx.allocated = l
+}
+
 func (ctx *_builtinJSON_stringifyContext) str(key Value, holder *Object) bool {
+	ctx.allocate()
 	value := holder.self.get(key)
 	if value == nil {
 		value = _undefined
 	}
 
-	if object, ok := value.(*Object); ok {
-		if toJSON, ok := object.self.getStr("toJSON").(*Object); ok {
+	var toJSONHolder *Object
+	switch v := value.(type) {
+	case *Object:
+		toJSONHolder = v
+	case *valueBigInt:
+		toJSONHolder = ctx.r.global.BigIntPrototype
+	}
+	if toJSONHolder != nil {
+		if toJSON, ok := toJSONHolder.self.getStr("toJSON").(*Object); ok {
 			if c, ok := toJSON.self.assertCallable(); ok {
 				value = c(FunctionCall{
 					This:      value,
@@ -355,6 +372,8 @@ func (ctx *_builtinJSON_stringifyContext) str(key Value, holder *Object) bool {
 		}
 	case valueNull:
 		ctx.buf.WriteString("null")
+	case *valueBigInt:
+		ctx.r.typeErrorResult(true, "Do not know how to serialize a BigInt")
 	case *Object:
 		for _, object := range ctx.stack {
 			if value1 == object {
@@ -515,6 +534,7 @@ func (r *Runtime) initJSON() {
 	JSON := r.newBaseObject(r.global.ObjectPrototype, "JSON")
 	JSON._putProp("parse", r.newNativeFunc(r.builtinJSON_parse, nil, "parse", nil, 2), true, false, true)
 	JSON._putProp("stringify", r.newNativeFunc(r.builtinJSON_stringify, nil, "stringify", nil, 3), true, false, true)
+	JSON._putSym(SymToStringTag, asciiString("JSON"), false, false, true)
 
 	r.addToGlobal("JSON", JSON.val)
 }
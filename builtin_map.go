@@ -0,0 +1,212 @@
+package goja
+
+import "reflect"
+
+var typeMapExport = reflect.TypeOf(map[interface{}]interface{}(nil))
+
+type mapObject struct {
+	baseObject
+	m *orderedMap
+}
+
+func (mo *mapObject) init() {
+	mo.baseObject.init()
+	mo.m = newOrderedMap()
+}
+
+// export returns the contents of the Map as map[interface{}]interface{}. Keys that export to a value which cannot be
+// used as a Go map key (such as objects exported as maps) are kept as Values.
+func (mo *mapObject) export() interface{} {
+	m := make(map[interface{}]interface{}, mo.m.size)
+	for e := mo.m.first; e != nil; e = e.next {
+		m[exportMapKey(e.key)] = exportValue(e.value)
+	}
+	return m
+}
+
+func (mo *mapObject) exportType() reflect.Type {
+	return typeMapExport
+}
+
+func exportMapKey(v Value) interface{} {
+	k := v.Export()
+	if k != nil && !reflect.TypeOf(k).Comparable() {
+		return v
+	}
+	return k
+}
+
+func exportValue(v Value) interface{} {
+	if v == nil {
+		return nil
+	}
+	return v.Export()
+}
+
+func (r *Runtime) newMapObject(proto *Object) *mapObject {
+	v := &Object{runtime: r}
+	mo := &mapObject{}
+	mo.class = classMap
+	mo.val = v
+	mo.extensible = true
+	v.self = mo
+	mo.prototype = proto
+	mo.init()
+	return mo
+}
+
+func (r *Runtime) toMapObject(v Value, method string) *mapObject {
+	if obj, ok := v.(*Object); ok {
+		if mo, ok := obj.self.(*mapObject); ok {
+			return mo
+		}
+	}
+	r.typeErrorResult(true, "Method Map.prototype.%s called on incompatible receiver %s", method, v.String())
+	panic("unreachable")
+}
+
+func (r *Runtime) mapProto_clear(call FunctionCall) Value {
+	r.toMapObject(call.This, "clear").m.clear()
+	return _undefined
+}
+
+func (r *Runtime) mapProto_delete(call FunctionCall) Value {
+	return r.toBoolean(r.toMapObject(call.This, "delete").m.remove(call.Argument(0)))
+}
+
+func (r *Runtime) mapProto_get(call FunctionCall) Value {
+	return nilSafe(r.toMapObject(call.This, "get").m.get(call.Argument(0)))
+}
+
+func (r *Runtime) mapProto_has(call FunctionCall) Value {
+	return r.toBoolean(r.toMapObject(call.This, "has").m.has(call.Argument(0)))
+}
+
+func (r *Runtime) mapProto_set(call FunctionCall) Value {
+	mo := r.toMapObject(call.This, "set")
+	key := call.Argument(0)
+	if !mo.m.has(key) {
+		r.vm.allocate(mapEntryAllocSize)
+	}
+	mo.m.set(key, call.Argument(1))
+	return call.This
+}
+
+func (r *Runtime) mapProto_forEach(call FunctionCall) Value {
+	mo := r.toMapObject(call.This, "forEach")
+	callbackFn := r.toCallable(call.Argument(0))
+	fc := FunctionCall{This: call.Argument(1), Arguments: []Value{nil, nil, mo.val}}
+	iter := mo.m.newIter()
+	for e := iter.next(); e != nil; e = iter.next() {
+		fc.Arguments[0], fc.Arguments[1] = e.value, e.key
+		callbackFn(fc)
+	}
+	return _undefined
+}
+
+func (r *Runtime) mapProto_entries(call FunctionCall) Value {
+	return r.createMapIterator(r.toMapObject(call.This, "entries"), iterationKindKeyValue)
+}
+
+func (r *Runtime) mapProto_keys(call FunctionCall) Value {
+	return r.createMapIterator(r.toMapObject(call.This, "keys"), iterationKindKey)
+}
+
+func (r *Runtime) mapProto_values(call FunctionCall) Value {
+	return r.createMapIterator(r.toMapObject(call.This, "values"), iterationKindValue)
+}
+
+func (r *Runtime) mapProto_getSize(call FunctionCall) Value {
+	return intToValue(int64(r.toMapObject(call.This, "size").m.size))
+}
+
+func (r *Runtime) createMapIterator(mo *mapObject, kind iterationKind) Value {
+	iter := mo.m.newIter()
+	return r.newIteratorObject(r.global.MapIteratorPrototype, classMapIterator, func() (Value, bool) {
+		e := iter.next()
+		if e == nil {
+			return nil, false
+		}
+		switch kind {
+		case iterationKindKey:
+			return e.key, true
+		case iterationKindValue:
+			return e.value, true
+		}
+		return r.newArrayValues([]Value{e.key, e.value}), true
+	})
+}
+
+func (r *Runtime) builtin_newMap(args []Value, proto *Object) *Object {
+	mo := r.newMapObject(proto)
+	if len(args) > 0 {
+		if arg := args[0]; arg != _undefined && arg != _null {
+			adder := r.toCallable(mo.getStr("set"))
+			r.getIterator(arg).iterate(func(item Value) {
+				entry, ok := item.(*Object)
+				if !ok {
+					r.typeErrorResult(true, "Iterator value %s is not an entry object", item.String())
+				}
+				k := nilSafe(entry.self.get(intToValue(0)))
+				v := nilSafe(entry.self.get(intToValue(1)))
+				adder(FunctionCall{This: mo.val, Arguments: []Value{k, v}})
+			})
+		}
+	}
+	return mo.val
+}
+
+// newMapFromGo copies the entries of a Go map into a new Map, converting the keys and the values with ToValue.
+func (r *Runtime) newMapFromGo(m reflect.Value) *Object {
+	mo := r.newMapObject(r.global.MapPrototype)
+	iter := m.MapRange()
+	for iter.Next() {
+		mo.m.set(r.ToValue(iter.Key().Interface()), r.ToValue(iter.Value().Interface()))
+	}
+	return mo.val
+}
+
+func (r *Runtime) createMapProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.Map, true, false, true)
+	o._putProp("clear", r.newNativeFunc(r.mapProto_clear, nil, "clear", nil, 0), true, false, true)
+	o._putProp("delete", r.newNativeFunc(r.mapProto_delete, nil, "delete", nil, 1), true, false, true)
+	entries := r.newNativeFunc(r.mapProto_entries, nil, "entries", nil, 0)
+	o._putProp("entries", entries, true, false, true)
+	o._putProp("forEach", r.newNativeFunc(r.mapProto_forEach, nil, "forEach", nil, 1), true, false, true)
+	o._putProp("get", r.newNativeFunc(r.mapProto_get, nil, "get", nil, 1), true, false, true)
+	o._putProp("has", r.newNativeFunc(r.mapProto_has, nil, "has", nil, 1), true, false, true)
+	o._putProp("keys", r.newNativeFunc(r.mapProto_keys, nil, "keys", nil, 0), true, false, true)
+	o._putProp("set", r.newNativeFunc(r.mapProto_set, nil, "set", nil, 2), true, false, true)
+	o._put("size", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.mapProto_getSize, nil, "get size", nil, 0),
+	})
+	o._putProp("values", r.newNativeFunc(r.mapProto_values, nil, "values", nil, 0), true, false, true)
+	o._putSym(SymIterator, entries, true, false, true)
+	o._putSym(SymToStringTag, asciiString(classMap), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) createMap(val *Object) objectImpl {
+	return r.newNativeFuncObj(val, r.constructorRequiresNew("Map"), func(args []Value) *Object {
+		return r.builtin_newMap(args, r.global.MapPrototype)
+	}, "Map", r.global.MapPrototype, 0)
+}
+
+func (r *Runtime) initMap() {
+	r.global.MapIteratorPrototype = r.newLazyObject(r.builtinIteratorProto(classMapIterator))
+
+	r.global.MapPrototype = r.newLazyObject(r.createMapProto)
+	r.global.Map = r.newLazyObject(r.createMap)
+	r.addToGlobal("Map", r.global.Map)
+}
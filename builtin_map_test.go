@@ -0,0 +1,167 @@
+package goja
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapBasic(t *testing.T) {
+	const SCRIPT = `
+	var m = new Map([[1, "a"], ["1", "b"]]);
+	var o = {};
+	m.set(o, "c").set(NaN, "d").set(-0, "e");
+	var res = [m.size, m.get(1), m.get("1"), m.get(o), m.get({}), m.get(NaN), m.get(0), m.has(+0), m.has(2)];
+	res.push(m.delete(1), m.delete(1), m.size);
+	res.push(Object.prototype.toString.call(m), typeof Map.prototype[Symbol.iterator]);
+	m.clear();
+	res.push(m.size, m.get(o));
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("5,a,b,c,,d,e,true,false,true,false,4,[object Map],function,0,"), t)
+}
+
+func TestMapIteration(t *testing.T) {
+	const SCRIPT = `
+	var m = new Map([["a", 1], ["b", 2], ["c", 3]]);
+	var res = [];
+	for (var e of m) {
+		res.push(e[0] + e[1]);
+		if (e[0] === "a") {
+			m.delete("b");
+			m.set("d", 4);
+		}
+		if (e[0] === "d") {
+			m.clear();
+			m.set("e", 5);
+		}
+	}
+	m.forEach(function(v, k, map) {
+		res.push(k + v, map === m);
+	});
+	res.push(m.keys().next().value, m.values().next().value);
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("a1,c3,d4,e5,e5,true,e,5"), t)
+}
+
+func TestSetBasic(t *testing.T) {
+	const SCRIPT = `
+	var s = new Set("abca");
+	s.add(NaN).add(NaN).add(-0);
+	var res = [s.size, s.has("a"), s.has(NaN), s.has(0), s.has("d")];
+	s.delete("b");
+	for (var v of s) {
+		res.push(v);
+	}
+	for (var e of s.entries()) {
+		res.push(e[0] === e[1] || e[0] !== e[0]);
+	}
+	res.push(Set.prototype.keys === Set.prototype.values, Object.prototype.toString.call(s));
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("5,true,true,true,false,a,c,NaN,0,true,true,true,true,true,[object Set]"), t)
+}
+
+func TestMapSetErrors(t *testing.T) {
+	const SCRIPT = `
+	function throwsTypeError(f) {
+		try {
+			f();
+		} catch (e) {
+			return e instanceof TypeError;
+		}
+		return false;
+	}
+	var closed = false;
+	var iterable = {};
+	iterable[Symbol.iterator] = function() {
+		return {
+			next: function() {
+				return {value: 1, done: false};
+			},
+			"return": function() {
+				closed = true;
+				return {};
+			}
+		};
+	};
+	throwsTypeError(function() { Map() }) &&
+		throwsTypeError(function() { Set() }) &&
+		throwsTypeError(function() { new Map([1]) }) &&
+		throwsTypeError(function() { Map.prototype.get.call({}, 1) }) &&
+		throwsTypeError(function() { Set.prototype.add.call(new Map(), 1) }) &&
+		throwsTypeError(function() { new Map(iterable) }) && closed;
+	`
+
+	testScript1(SCRIPT, valueTrue, t)
+}
+
+func TestMapExport(t *testing.T) {
+	vm := New()
+	v, err := vm.RunString(`new Map([[1, "a"], ["b", 2], [true, null]])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := v.Export()
+	if !reflect.DeepEqual(exp, map[interface{}]interface{}{int64(1): "a", "b": int64(2), true: nil}) {
+		t.Fatalf("Unexpected export: %#v", exp)
+	}
+
+	var pairs [][2]interface{}
+	err = vm.ExportTo(v, &pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pairs, [][2]interface{}{{int64(1), "a"}, {"b", int64(2)}, {true, nil}}) {
+		t.Fatalf("Unexpected pairs: %#v", pairs)
+	}
+
+	v, err = vm.RunString(`new Map([["x", 1], ["y", 2]])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]int
+	err = vm.ExportTo(v, &m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m, map[string]int{"x": 1, "y": 2}) {
+		t.Fatalf("Unexpected map: %#v", m)
+	}
+
+	v, err = vm.RunString(`new Set([3, 1, 2])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s []int
+	err = vm.ExportTo(v, &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s, []int{3, 1, 2}) {
+		t.Fatalf("Unexpected set: %#v", s)
+	}
+}
+
+func TestGoMapsAsMaps(t *testing.T) {
+	type key struct {
+		a, b int
+	}
+	vm := New()
+	vm.SetGoMapsAsMaps(true)
+	vm.Set("m", map[key]string{{1, 2}: "x"})
+	vm.Set("m1", map[string]interface{}{"test": map[int]int{1: 2}})
+	v, err := vm.RunString(`
+	m instanceof Map && m.size === 1 && m.values().next().value === "x" &&
+		m1 instanceof Map && m1.get("test") instanceof Map && m1.get("test").get(1) === 2;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != valueTrue {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+}
@@ -0,0 +1,68 @@
+package goja
+
+import "testing"
+
+func TestMathES6(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(Math.trunc(-4.7), -4, "trunc");
+	assert.sameValue(1 / Math.trunc(-0.5), -Infinity, "trunc -0");
+	assert.sameValue(Math.sign(-3), -1, "sign");
+	assert.sameValue(Math.sign(2.5), 1, "sign float");
+	assert.sameValue(1 / Math.sign(-0), -Infinity, "sign -0");
+	assert.sameValue(Math.sign("x"), NaN, "sign NaN");
+	assert.sameValue(Math.cbrt(-27), -3, "cbrt");
+	assert.sameValue(Math.log2(1024), 10, "log2");
+	assert.sameValue(Math.log2(0), -Infinity, "log2 0");
+	assert.sameValue(Math.log10(1000), 3, "log10");
+	assert.sameValue(Math.log1p(0), 0, "log1p");
+	assert.sameValue(Math.expm1(0), 0, "expm1");
+	assert.sameValue(Math.hypot(3, 4), 5, "hypot");
+	assert.sameValue(Math.hypot(NaN, -Infinity), Infinity, "hypot Infinity");
+	assert.sameValue(Math.hypot(1, NaN), NaN, "hypot NaN");
+	assert.sameValue(Math.hypot(), 0, "hypot()");
+	assert.sameValue(Math.abs(Math.hypot(1e200, 1e200) / 1e200 - Math.SQRT2) < 1e-15, true, "hypot overflow");
+	assert.sameValue(Math.fround(5.5), 5.5, "fround");
+	assert.sameValue(Math.fround(5.05), 5.050000190734863, "fround rounding");
+	assert.sameValue(Math.fround(16777217), 16777216, "fround int");
+	assert.sameValue(Math.imul(0xffffffff, 5), -5, "imul");
+	assert.sameValue(Math.imul(0x7fffffff, 2), -2, "imul overflow");
+	assert.sameValue(Math.clz32(1), 31, "clz32");
+	assert.sameValue(Math.clz32(-1), 0, "clz32 negative");
+	assert.sameValue(Math.clz32(0), 32, "clz32 0");
+	assert.sameValue(Math.sinh(0), 0, "sinh");
+	assert.sameValue(Math.cosh(0), 1, "cosh");
+	assert.sameValue(Math.tanh(Infinity), 1, "tanh");
+	assert.sameValue(Math.asinh(0), 0, "asinh");
+	assert.sameValue(Math.acosh(1), 0, "acosh");
+	assert.sameValue(Math.atanh(1), Infinity, "atanh");
+	assert.sameValue(Math.hypot.length, 2, "hypot.length");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestNumberES6(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue(Number.isInteger(5), true, "isInteger");
+	assert.sameValue(Number.isInteger(5.0), true, "isInteger 5.0");
+	assert.sameValue(Number.isInteger(5.5), false, "isInteger 5.5");
+	assert.sameValue(Number.isInteger(Infinity), false, "isInteger Infinity");
+	assert.sameValue(Number.isInteger("5"), false, "isInteger string");
+	assert.sameValue(Number.isSafeInteger(Number.MAX_SAFE_INTEGER), true, "isSafeInteger max");
+	assert.sameValue(Number.isSafeInteger(Number.MAX_SAFE_INTEGER + 1), false, "isSafeInteger max + 1");
+	assert.sameValue(Number.isSafeInteger(-9007199254740991), true, "isSafeInteger min");
+	assert.sameValue(Number.isSafeInteger(1e300), false, "isSafeInteger 1e300");
+	assert.sameValue(Number.isFinite(1), true, "isFinite");
+	assert.sameValue(Number.isFinite("1"), false, "isFinite string");
+	assert.sameValue(Number.isFinite(-Infinity), false, "isFinite Infinity");
+	assert.sameValue(Number.isNaN(NaN), true, "isNaN");
+	assert.sameValue(Number.isNaN("x"), false, "isNaN string");
+	assert.sameValue(Number.MAX_SAFE_INTEGER, 9007199254740991, "MAX_SAFE_INTEGER");
+	assert.sameValue(Number.MIN_SAFE_INTEGER, -9007199254740991, "MIN_SAFE_INTEGER");
+	assert.sameValue(Number.parseFloat, parseFloat, "parseFloat");
+	assert.sameValue(Number.parseInt, parseInt, "parseInt");
+	assert.sameValue(Number.parseInt("ff", 16), 255, "parseInt radix");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
@@ -131,6 +131,50 @@ func (r *Runtime) numberproto_toPrecision(call FunctionCall) Value {
 	return asciiString(strconv.FormatFloat(num, 'g', int(prec), 64))
 }
 
+func (r *Runtime) number_isFinite(call FunctionCall) Value {
+	switch arg := call.Argument(0).(type) {
+	case valueInt:
+		return valueTrue
+	case valueFloat:
+		f := float64(arg)
+		return r.toBoolean(!math.IsInf(f, 0) && !math.IsNaN(f))
+	default:
+		return valueFalse
+	}
+}
+
+func (r *Runtime) number_isInteger(call FunctionCall) Value {
+	switch arg := call.Argument(0).(type) {
+	case valueInt:
+		return valueTrue
+	case valueFloat:
+		f := float64(arg)
+		return r.toBoolean(!math.IsNaN(f) && !math.IsInf(f, 0) && math.Trunc(f) == f)
+	default:
+		return valueFalse
+	}
+}
+
+func (r *Runtime) number_isNaN(call FunctionCall) Value {
+	if f, ok := call.Argument(0).(valueFloat); ok && math.IsNaN(float64(f)) {
+		return valueTrue
+	}
+	return valueFalse
+}
+
+func (r *Runtime) number_isSafeInteger(call FunctionCall) Value {
+	switch arg := call.Argument(0).(type) {
+	case valueInt:
+		i := int64(arg)
+		return r.toBoolean(i >= -(maxInt-1) && i <= maxInt-1)
+	case valueFloat:
+		f := float64(arg)
+		return r.toBoolean(math.Trunc(f) == f && math.Abs(f) <= maxInt-1)
+	default:
+		return valueFalse
+	}
+}
+
 func (r *Runtime) initNumber() {
 	r.global.NumberPrototype = r.newPrimitiveObject(valueInt(0), r.global.ObjectPrototype, classNumber)
 	o := r.global.NumberPrototype.self
@@ -149,6 +193,17 @@ func (r *Runtime) initNumber() {
 	o._putProp("NEGATIVE_INFINITY", _negativeInf, false, false, false)
 	o._putProp("POSITIVE_INFINITY", _positiveInf, false, false, false)
 	o._putProp("EPSILON", _epsilon, false, false, false)
+	o._putProp("MAX_SAFE_INTEGER", valueInt(maxInt-1), false, false, false)
+	o._putProp("MIN_SAFE_INTEGER", valueInt(-(maxInt - 1)), false, false, false)
+	o._putProp("isFinite", r.newNativeFunc(r.number_isFinite, nil, "isFinite", nil, 1), true, false, true)
+	o._putProp("isInteger", r.newNativeFunc(r.number_isInteger, nil, "isInteger", nil, 1), true, false, true)
+	o._putProp("isNaN", r.newNativeFunc(r.number_isNaN, nil, "isNaN", nil, 1), true, false, true)
+	o._putProp("isSafeInteger", r.newNativeFunc(r.number_isSafeInteger, nil, "isSafeInteger", nil, 1), true, false, true)
+	// Number.parseFloat and Number.parseInt are the same function objects as the global ones
+	r.global.parseFloat = r.newNativeFunc(r.builtin_parseFloat, nil, "parseFloat", nil, 1)
+	r.global.parseInt = r.newNativeFunc(r.builtin_parseInt, nil, "parseInt", nil, 2)
+	o._putProp("parseFloat", r.global.parseFloat, true, false, true)
+	o._putProp("parseInt", r.global.parseInt, true, false, true)
 	r.addToGlobal("Number", r.global.Number)
 
 }
@@ -25,8 +25,11 @@ func (r *Runtime) object_getPrototypeOf(call FunctionCall) Value {
 
 func (r *Runtime) object_getOwnPropertyDescriptor(call FunctionCall) Value {
 	obj := call.Argument(0).ToObject(r)
-	propName := call.Argument(1).String()
-	return obj.self.getOwnPropertyDescriptor(propName)
+	propName := toPropertyKey(call.Argument(1))
+	if s, ok := propName.(*Symbol); ok {
+		return r.propToDescriptorObject(obj.self.getOwnPropSym(s))
+	}
+	return obj.self.getOwnPropertyDescriptor(propName.String())
 }
 
 func (r *Runtime) object_getOwnPropertyNames(call FunctionCall) Value {
@@ -129,7 +132,7 @@ func (r *Runtime) object_create(call FunctionCall) Value {
 func (r *Runtime) object_defineProperty(call FunctionCall) (ret Value) {
 	if obj, ok := call.Argument(0).(*Object); ok {
 		descr := r.toPropertyDescriptor(call.Argument(2))
-		obj.self.defineOwnProperty(call.Argument(1), descr, true)
+		obj.self.defineOwnProperty(toPropertyKey(call.Argument(1)), descr, true)
 		ret = call.Argument(0)
 	} else {
 		r.typeErrorResult(true, "Object.defineProperty called on non-object")
@@ -291,10 +294,114 @@ func (r *Runtime) object_keys(call FunctionCall) Value {
 	//return nil
 }
 
+// forEachEnumerableOwn calls f for every own enumerable string-keyed property of o, in the order of Object.keys().
+func (r *Runtime) forEachEnumerableOwn(o *Object, f func(name string, value Value)) {
+	if r.isProxy(o) {
+		keys := r.toObject(r.getProxy(o).ownKeys(false, false))
+		for _, key := range r.toValueArray(keys) {
+			name := key.String()
+			f(name, nilSafe(o.self.getStr(name)))
+		}
+		return
+	}
+	for item, next := o.self.enumerate(false, false)(); next != nil; item, next = next() {
+		f(item.name, nilSafe(o.self.getStr(item.name)))
+	}
+}
+
+func (r *Runtime) object_values(call FunctionCall) Value {
+	var values []Value
+	r.forEachEnumerableOwn(call.Argument(0).ToObject(r), func(name string, value Value) {
+		values = append(values, value)
+	})
+	return r.newArrayValues(values)
+}
+
+func (r *Runtime) object_entries(call FunctionCall) Value {
+	var entries []Value
+	r.forEachEnumerableOwn(call.Argument(0).ToObject(r), func(name string, value Value) {
+		entries = append(entries, r.newArrayValues([]Value{newStringValue(name), value}))
+	})
+	return r.newArrayValues(entries)
+}
+
+func (r *Runtime) object_assign(call FunctionCall) Value {
+	to := call.Argument(0).ToObject(r)
+	if len(call.Arguments) > 1 {
+		for _, source := range call.Arguments[1:] {
+			if source == _undefined || source == _null {
+				continue
+			}
+			from := source.ToObject(r)
+			r.forEachEnumerableOwn(from, func(name string, value Value) {
+				to.self.putStr(name, value, true)
+			})
+			for _, s := range from.self.ownSymbols() {
+				if prop := from.self.getOwnPropSym(s); prop != nil {
+					if prop, ok := prop.(*valueProperty); ok && !prop.enumerable {
+						continue
+					}
+					to.self.put(s, nilSafe(from.self.get(s)), true)
+				}
+			}
+		}
+	}
+	return to
+}
+
+func (r *Runtime) object_fromEntries(call FunctionCall) Value {
+	o := r.NewObject()
+	r.getIterator(call.Argument(0)).iterate(func(item Value) {
+		entry, ok := item.(*Object)
+		if !ok {
+			r.typeErrorResult(true, "Iterator value %s is not an entry object", item.String())
+		}
+		key := toPropertyKey(nilSafe(entry.self.get(intToValue(0))))
+		value := nilSafe(entry.self.get(intToValue(1)))
+		if s, ok := key.(*Symbol); ok {
+			o.self._putSym(s, value, true, true, true)
+		} else {
+			o.self._putProp(key.String(), value, true, true, true)
+		}
+	})
+	return o
+}
+
+func (r *Runtime) object_is(call FunctionCall) Value {
+	return r.toBoolean(call.Argument(0).SameAs(call.Argument(1)))
+}
+
+func (r *Runtime) object_getOwnPropertySymbols(call FunctionCall) Value {
+	obj := call.Argument(0).ToObject(r)
+	symbols := obj.self.ownSymbols()
+	values := make([]Value, len(symbols))
+	for i, s := range symbols {
+		values[i] = s
+	}
+	return r.newArrayValues(values)
+}
+
+// copyDataProperties copies the own enumerable properties of source, except the excluded ones, to target. It is used by
+// the spread and rest properties of object literals and patterns.
+func (r *Runtime) copyDataProperties(target *Object, source Value, excluded map[string]bool) {
+	if source == _undefined || source == _null {
+		return
+	}
+	from := source.ToObject(r)
+	for item, f := from.self.enumerate(false, false)(); f != nil; item, f = f() {
+		if !excluded[item.name] {
+			target.self._putProp(item.name, nilSafe(from.self.getStr(item.name)), true, true, true)
+		}
+	}
+}
+
 func (r *Runtime) objectproto_hasOwnProperty(call FunctionCall) Value {
-	p := call.Argument(0).String()
+	p := toPropertyKey(call.Argument(0))
 	o := call.This.ToObject(r)
-	if o.self.hasOwnPropertyStr(p) {
+	if s, ok := p.(*Symbol); ok {
+		return r.toBoolean(o.self.hasOwnProperty(s))
+	}
+	if o.self.hasOwnPropertyStr(p.String()) {
 		return valueTrue
 	} else {
 		return valueFalse
@@ -318,9 +425,14 @@ func (r *Runtime) objectproto_isPrototypeOf(call FunctionCall) Value {
 }
 
 func (r *Runtime) objectproto_propertyIsEnumerable(call FunctionCall) Value {
-	p := call.Argument(0).ToString()
+	p := toPropertyKey(call.Argument(0))
 	o := call.This.ToObject(r)
-	pv := o.self.getOwnProp(p.String())
+	var pv Value
+	if s, ok := p.(*Symbol); ok {
+		pv = o.self.getOwnPropSym(s)
+	} else {
+		pv = o.self.getOwnProp(p.String())
+	}
 	if pv == nil {
 		return valueFalse
 	}
@@ -339,11 +451,21 @@ func (r *Runtime) objectproto_toString(call FunctionCall) Value {
 	case valueUndefined:
 		return stringObjectUndefined
 	case *Object:
-		return newStringValue(fmt.Sprintf("[object %s]", o.self.className()))
+		return r.objectToStringTag(o)
 	default:
-		obj := call.This.ToObject(r)
-		return newStringValue(fmt.Sprintf("[object %s]", obj.self.className()))
+		return r.objectToStringTag(call.This.ToObject(r))
+	}
+}
+
+// objectToStringTag returns "[object <tag>]" where the tag is the value of the @@toStringTag property if it is a
+// string, or the class name of the object otherwise.
+func (r *Runtime) objectToStringTag(o *Object) Value {
+	if tag := o.self.get(SymToStringTag); tag != nil {
+		if tag, ok := tag.assertString(); ok {
+			return asciiString("[object ").concat(tag).concat(asciiString("]"))
+		}
 	}
+	return newStringValue(fmt.Sprintf("[object %s]", o.self.className()))
 }
 
 func (r *Runtime) objectproto_toLocaleString(call FunctionCall) Value {
@@ -378,6 +500,12 @@ func (r *Runtime) initObject() {
 	o._putProp("isFrozen", r.newNativeFunc(r.object_isFrozen, nil, "isFrozen", nil, 1), true, false, true)
 	o._putProp("isExtensible", r.newNativeFunc(r.object_isExtensible, nil, "isExtensible", nil, 1), true, false, true)
 	o._putProp("keys", r.newNativeFunc(r.object_keys, nil, "keys", nil, 1), true, false, true)
+	o._putProp("values", r.newNativeFunc(r.object_values, nil, "values", nil, 1), true, false, true)
+	o._putProp("entries", r.newNativeFunc(r.object_entries, nil, "entries", nil, 1), true, false, true)
+	o._putProp("assign", r.newNativeFunc(r.object_assign, nil, "assign", nil, 2), true, false, true)
+	o._putProp("fromEntries", r.newNativeFunc(r.object_fromEntries, nil, "fromEntries", nil, 1), true, false, true)
+	o._putProp("is", r.newNativeFunc(r.object_is, nil, "is", nil, 2), true, false, true)
+	o._putProp("getOwnPropertySymbols", r.newNativeFunc(r.object_getOwnPropertySymbols, nil, "getOwnPropertySymbols", nil, 1), true, false, true)
 
 	r.addToGlobal("Object", r.global.Object)
 }
@@ -0,0 +1,54 @@
+package goja
+
+import "testing"
+
+func TestObjectAssign(t *testing.T) {
+	const SCRIPT = `
+	var s = Symbol("s");
+	var src = {b: 2};
+	src[s] = 3;
+	Object.defineProperty(src, "hidden", {value: 4, enumerable: false});
+	var o = Object.assign({a: 1}, src, null, undefined, "xy");
+	assert.sameValue(Object.keys(o).join(), "a,b,0,1", "keys");
+	assert.sameValue(o[s], 3, "symbol");
+	assert.sameValue(o.hidden, undefined, "non-enumerable");
+
+	var frozen = Object.freeze({b: 1});
+	var thrown = false;
+	try {
+		Object.assign(frozen, {b: 2});
+	} catch (e) {
+		thrown = e instanceof TypeError;
+	}
+	assert.sameValue(thrown, true, "frozen target");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestObjectEntries(t *testing.T) {
+	const SCRIPT = `
+	var o = Object.create({inherited: 1});
+	o.a = 1;
+	o.b = [2];
+	assert.sameValue(JSON.stringify(Object.entries(o)), '[["a",1],["b",[2]]]', "entries");
+	assert.sameValue(Object.values("ab").join(), "a,b", "values");
+	assert.sameValue(JSON.stringify(Object.fromEntries([["a", 1], ["b", 2]])), '{"a":1,"b":2}', "fromEntries");
+	assert.sameValue(Object.fromEntries(new Map([["k", "v"]])).k, "v", "fromEntries: Map");
+
+	var p = new Proxy({x: 1, y: 2}, {
+		ownKeys: function() {
+			return ["y"];
+		}
+	});
+	assert.sameValue(Object.values(p).join(), "2", "proxy");
+
+	var s = Symbol("s");
+	o[s] = 1;
+	assert.sameValue(Object.getOwnPropertySymbols(o)[0], s, "getOwnPropertySymbols");
+	assert.sameValue(Object.is(NaN, NaN), true, "NaN");
+	assert.sameValue(Object.is(0, -0), false, "-0");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
@@ -0,0 +1,695 @@
+package goja
+
+import (
+	gocontext "context"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// ErrEventLoopDeadline is returned by RunEventLoop when the deadline passes before the loop goes quiet.
+var ErrEventLoopDeadline = errors.New("event loop deadline exceeded")
+
+type PromiseState int
+
+const (
+	PromiseStatePending PromiseState = iota
+	PromiseStateFulfilled
+	PromiseStateRejected
+)
+
+type PromiseRejectionOperation int
+
+const (
+	// PromiseRejectionReject means a promise has been rejected while it had no handlers.
+	PromiseRejectionReject PromiseRejectionOperation = iota
+	// PromiseRejectionHandle means a handler has been added to a rejected promise that was previously reported
+	// with PromiseRejectionReject.
+	PromiseRejectionHandle
+)
+
+// PromiseRejectionTracker is called when a promise is rejected without handlers and when a handler is added to such
+// a promise later. It can be used to report unhandled rejections. See SetPromiseRejectionTracker.
+type PromiseRejectionTracker func(p *Promise, operation PromiseRejectionOperation)
+
+type promiseReactionType int
+
+const (
+	promiseReactionFulfill promiseReactionType = iota
+	promiseReactionReject
+)
+
+type promiseCapability struct {
+	promise         *Object
+	resolve, reject func(FunctionCall) Value
+}
+
+type promiseReaction struct {
+	// nil if the reaction does not have a derived promise
+	capability *promiseCapability
+	typ        promiseReactionType
+	// nil for the default behaviour (pass the value through or rethrow the reason)
+	handler func(FunctionCall) Value
+}
+
+// Promise is a Go wrapper around ECMAScript Promise. Calling Runtime.ToValue() on it returns the underlying Object.
+// Calling Export() on a Promise Object returns a Promise.
+//
+// Use Runtime.NewPromise() to create one.
+type Promise struct {
+	baseObject
+	state            PromiseState
+	result           Value
+	fulfillReactions []*promiseReaction
+	rejectReactions  []*promiseReaction
+	handled          bool
+}
+
+func (p *Promise) State() PromiseState {
+	return p.state
+}
+
+// Result returns the value the promise has been fulfilled or rejected with, or nil if it is pending.
+func (p *Promise) Result() Value {
+	return p.result
+}
+
+func (p *Promise) export() interface{} {
+	return p
+}
+
+func (p *Promise) exportType() reflect.Type {
+	return reflect.TypeOf(p)
+}
+
+func (p *Promise) createResolvingFunctions() (resolve, reject *Object) {
+	r := p.val.runtime
+	alreadyResolved := false
+	resolve = r.newNativeFunc(func(call FunctionCall) Value {
+		if alreadyResolved {
+			return _undefined
+		}
+		alreadyResolved = true
+		p.resolve(call.Argument(0))
+		return _undefined
+	}, nil, "", nil, 1)
+	reject = r.newNativeFunc(func(call FunctionCall) Value {
+		if alreadyResolved {
+			return _undefined
+		}
+		alreadyResolved = true
+		p.reject(call.Argument(0))
+		return _undefined
+	}, nil, "", nil, 1)
+	return
+}
+
+func (p *Promise) resolve(resolution Value) {
+	r := p.val.runtime
+	if resolution == p.val {
+		p.reject(r.NewTypeError("Chaining cycle detected for promise #<Promise>"))
+		return
+	}
+	obj, ok := resolution.(*Object)
+	if !ok {
+		p.fulfill(resolution)
+		return
+	}
+	var then Value
+	if ex := r.vm.try(func() {
+		then = obj.self.getStr("then")
+	}); ex != nil {
+		p.reject(ex.val)
+		return
+	}
+	thenFn, ok := assertCallable(then)
+	if !ok {
+		p.fulfill(resolution)
+		return
+	}
+	r.enqueueJob(r.newPromiseResolveThenableJob(p, obj, thenFn))
+}
+
+func (p *Promise) fulfill(value Value) {
+	reactions := p.fulfillReactions
+	p.result = value
+	p.fulfillReactions, p.rejectReactions = nil, nil
+	p.state = PromiseStateFulfilled
+	p.val.runtime.triggerPromiseReactions(reactions, value)
+}
+
+func (p *Promise) reject(reason Value) {
+	reactions := p.rejectReactions
+	p.result = reason
+	p.fulfillReactions, p.rejectReactions = nil, nil
+	p.state = PromiseStateRejected
+	r := p.val.runtime
+	if !p.handled {
+		r.trackPromiseRejection(p, PromiseRejectionReject)
+	}
+	r.triggerPromiseReactions(reactions, reason)
+}
+
+func (r *Runtime) trackPromiseRejection(p *Promise, operation PromiseRejectionOperation) {
+	if r.promiseRejectionTracker != nil {
+		r.promiseRejectionTracker(p, operation)
+	}
+}
+
+func (r *Runtime) triggerPromiseReactions(reactions []*promiseReaction, argument Value) {
+	for _, reaction := range reactions {
+		r.enqueueJob(r.newPromiseReactionJob(reaction, argument))
+	}
+}
+
+func (r *Runtime) newPromiseReactionJob(reaction *promiseReaction, argument Value) func() {
+	return func() {
+		var handlerResult Value
+		fulfill := false
+		if reaction.handler == nil {
+			handlerResult = argument
+			fulfill = reaction.typ == promiseReactionFulfill
+		} else {
+			ex := r.vm.try(func() {
+				handlerResult = reaction.handler(FunctionCall{This: _undefined, Arguments: []Value{argument}})
+				fulfill = true
+			})
+			if ex != nil {
+				handlerResult = ex.val
+			}
+		}
+		if reaction.capability != nil {
+			if fulfill {
+				reaction.capability.resolve(FunctionCall{This: _undefined, Arguments: []Value{handlerResult}})
+			} else {
+				reaction.capability.reject(FunctionCall{This: _undefined, Arguments: []Value{handlerResult}})
+			}
+		}
+	}
+}
+
+func (r *Runtime) newPromiseResolveThenableJob(p *Promise, thenable *Object, then func(FunctionCall) Value) func() {
+	return func() {
+		resolve, reject := p.createResolvingFunctions()
+		ex := r.vm.try(func() {
+			then(FunctionCall{This: thenable, Arguments: []Value{resolve, reject}})
+		})
+		if ex != nil {
+			r.toCallable(reject)(FunctionCall{This: _undefined, Arguments: []Value{ex.val}})
+		}
+	}
+}
+
+func (r *Runtime) enqueueJob(job func()) {
+	r.jobQueue = append(r.jobQueue, job)
+}
+
+func (r *Runtime) newPromise(proto *Object) *Promise {
+	v := &Object{runtime: r}
+	p := &Promise{}
+	p.class = classPromise
+	p.val = v
+	p.extensible = true
+	v.self = p
+	p.prototype = proto
+	p.init()
+	return p
+}
+
+func (r *Runtime) builtin_newPromise(args []Value, proto *Object) *Object {
+	var arg Value = _undefined
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	executor, ok := assertCallable(arg)
+	if !ok {
+		r.typeErrorResult(true, "Promise resolver %s is not a function", arg.String())
+	}
+	p := r.newPromise(proto)
+	resolve, reject := p.createResolvingFunctions()
+	ex := r.vm.try(func() {
+		executor(FunctionCall{This: _undefined, Arguments: []Value{resolve, reject}})
+	})
+	if ex != nil {
+		r.toCallable(reject)(FunctionCall{This: _undefined, Arguments: []Value{ex.val}})
+	}
+	return p.val
+}
+
+// newPromiseCapability creates a new promise using the constructor c along with the functions resolving it.
+func (r *Runtime) newPromiseCapability(c Value) *promiseCapability {
+	if c == r.global.Promise {
+		p := r.newPromise(r.global.PromisePrototype)
+		resolve, reject := p.createResolvingFunctions()
+		return &promiseCapability{
+			promise: p.val,
+			resolve: r.toCallable(resolve),
+			reject:  r.toCallable(reject),
+		}
+	}
+	construct := r.toConstructor(c)
+	if construct == nil {
+		r.typeErrorResult(true, "%s is not a constructor", c.String())
+	}
+	var resolve, reject Value = _undefined, _undefined
+	executor := r.newNativeFunc(func(call FunctionCall) Value {
+		if resolve != _undefined {
+			r.typeErrorResult(true, "Promise executor has already been invoked with non-undefined arguments")
+		}
+		if reject != _undefined {
+			r.typeErrorResult(true, "Promise executor has already been invoked with non-undefined arguments")
+		}
+		resolve, reject = call.Argument(0), call.Argument(1)
+		return _undefined
+	}, nil, "", nil, 2)
+	promise := construct([]Value{executor})
+	resolveFn, ok := assertCallable(resolve)
+	if !ok {
+		r.typeErrorResult(true, "Promise resolve function is not callable")
+	}
+	rejectFn, ok := assertCallable(reject)
+	if !ok {
+		r.typeErrorResult(true, "Promise reject function is not callable")
+	}
+	return &promiseCapability{
+		promise: promise,
+		resolve: resolveFn,
+		reject:  rejectFn,
+	}
+}
+
+// rejectCapability rejects the promise of the capability with the exception thrown by f, if any. It is used by the
+// methods which must return a rejected promise instead of throwing.
+func (r *Runtime) rejectCapability(capability *promiseCapability, f func()) {
+	if ex := r.vm.try(f); ex != nil {
+		capability.reject(FunctionCall{This: _undefined, Arguments: []Value{ex.val}})
+	}
+}
+
+// promiseResolve returns x if it is a promise created by the constructor c, otherwise it returns a new promise
+// resolved with x.
+func (r *Runtime) promiseResolve(c *Object, x Value) *Object {
+	if obj, ok := x.(*Object); ok {
+		if _, ok := obj.self.(*Promise); ok && obj.self.getStr("constructor") == c {
+			return obj
+		}
+	}
+	capability := r.newPromiseCapability(c)
+	capability.resolve(FunctionCall{This: _undefined, Arguments: []Value{x}})
+	return capability.promise
+}
+
+func (r *Runtime) performPromiseThen(p *Promise, onFulfilled, onRejected Value, capability *promiseCapability) Value {
+	fulfillReaction := &promiseReaction{
+		capability: capability,
+		typ:        promiseReactionFulfill,
+	}
+	fulfillReaction.handler, _ = assertCallable(onFulfilled)
+	rejectReaction := &promiseReaction{
+		capability: capability,
+		typ:        promiseReactionReject,
+	}
+	rejectReaction.handler, _ = assertCallable(onRejected)
+	switch p.state {
+	case PromiseStatePending:
+		p.fulfillReactions = append(p.fulfillReactions, fulfillReaction)
+		p.rejectReactions = append(p.rejectReactions, rejectReaction)
+	case PromiseStateFulfilled:
+		r.enqueueJob(r.newPromiseReactionJob(fulfillReaction, p.result))
+	default:
+		if !p.handled {
+			r.trackPromiseRejection(p, PromiseRejectionHandle)
+		}
+		r.enqueueJob(r.newPromiseReactionJob(rejectReaction, p.result))
+	}
+	p.handled = true
+	if capability == nil {
+		return _undefined
+	}
+	return capability.promise
+}
+
+func (r *Runtime) toPromise(v Value, method string) *Promise {
+	if obj, ok := v.(*Object); ok {
+		if p, ok := obj.self.(*Promise); ok {
+			return p
+		}
+	}
+	r.typeErrorResult(true, "Method Promise.prototype.%s called on incompatible receiver %s", method, v.String())
+	panic("unreachable")
+}
+
+// invoke calls the method name of v with the specified arguments.
+func (r *Runtime) invoke(v Value, name string, args ...Value) Value {
+	return r.toCallable(r.getV(v, asciiString(name)))(FunctionCall{This: v, Arguments: args})
+}
+
+func (r *Runtime) promiseProto_then(call FunctionCall) Value {
+	p := r.toPromise(call.This, "then")
+	capability := r.newPromiseCapability(r.global.Promise)
+	return r.performPromiseThen(p, call.Argument(0), call.Argument(1), capability)
+}
+
+func (r *Runtime) promiseProto_catch(call FunctionCall) Value {
+	return r.invoke(call.This, "then", _undefined, call.Argument(0))
+}
+
+func (r *Runtime) promiseProto_finally(call FunctionCall) Value {
+	promise, ok := call.This.(*Object)
+	if !ok {
+		r.typeErrorResult(true, "Method Promise.prototype.finally called on a non-object")
+	}
+	c := r.global.Promise
+	onFinally := call.Argument(0)
+	onFinallyFn, ok := assertCallable(onFinally)
+	if !ok {
+		return r.invoke(promise, "then", onFinally, onFinally)
+	}
+	thenFinally := r.newNativeFunc(func(call FunctionCall) Value {
+		value := call.Argument(0)
+		result := onFinallyFn(FunctionCall{This: _undefined})
+		p := r.promiseResolve(c, result)
+		valueThunk := r.newNativeFunc(func(FunctionCall) Value {
+			return value
+		}, nil, "", nil, 0)
+		return r.invoke(p, "then", valueThunk)
+	}, nil, "", nil, 1)
+	catchFinally := r.newNativeFunc(func(call FunctionCall) Value {
+		reason := call.Argument(0)
+		result := onFinallyFn(FunctionCall{This: _undefined})
+		p := r.promiseResolve(c, result)
+		thrower := r.newNativeFunc(func(FunctionCall) Value {
+			panic(reason)
+		}, nil, "", nil, 0)
+		return r.invoke(p, "then", thrower)
+	}, nil, "", nil, 1)
+	return r.invoke(promise, "then", thenFinally, catchFinally)
+}
+
+// promiseCombinator implements the common parts of Promise.all(), Promise.allSettled() and Promise.race(). The
+// function f is called for every element of the iterable with its index and the promise obtained from it.
+func (r *Runtime) promiseCombinator(call FunctionCall, f func(capability *promiseCapability, idx int, nextPromise Value), done func(capability *promiseCapability, count int)) Value {
+	c := r.toObject(call.This)
+	capability := r.newPromiseCapability(c)
+	r.rejectCapability(capability, func() {
+		promiseResolve := r.toCallable(c.self.getStr("resolve"))
+		idx := 0
+		r.getIterator(call.Argument(0)).iterate(func(value Value) {
+			nextPromise := promiseResolve(FunctionCall{This: c, Arguments: []Value{value}})
+			f(capability, idx, nextPromise)
+			idx++
+		})
+		if done != nil {
+			done(capability, idx)
+		}
+	})
+	return capability.promise
+}
+
+// promiseAll implements Promise.all() and Promise.allSettled(). The values of the fulfilled and the rejected
+// elements are converted using onFulfilled and onRejected; a nil onRejected makes the resulting promise reject with
+// the first rejection.
+func (r *Runtime) promiseAll(call FunctionCall, onFulfilled, onRejected func(Value) Value) Value {
+	var values []Value
+	remaining := 1
+	resolveIfDone := func(capability *promiseCapability) {
+		remaining--
+		if remaining == 0 {
+			capability.resolve(FunctionCall{This: _undefined, Arguments: []Value{r.newArrayValues(values)}})
+		}
+	}
+	elementFunc := func(capability *promiseCapability, idx int, alreadyCalled *bool, conv func(Value) Value) *Object {
+		return r.newNativeFunc(func(call FunctionCall) Value {
+			if *alreadyCalled {
+				return _undefined
+			}
+			*alreadyCalled = true
+			values[idx] = conv(call.Argument(0))
+			resolveIfDone(capability)
+			return _undefined
+		}, nil, "", nil, 1)
+	}
+	return r.promiseCombinator(call, func(capability *promiseCapability, idx int, nextPromise Value) {
+		values = append(values, _undefined)
+		remaining++
+		alreadyCalled := false
+		resolveElement := elementFunc(capability, idx, &alreadyCalled, onFulfilled)
+		var rejectElement Value
+		if onRejected != nil {
+			rejectElement = elementFunc(capability, idx, &alreadyCalled, onRejected)
+		} else {
+			rejectElement = r.newNativeFunc(capability.reject, nil, "", nil, 1)
+		}
+		r.invoke(nextPromise, "then", resolveElement, rejectElement)
+	}, func(capability *promiseCapability, count int) {
+		resolveIfDone(capability)
+	})
+}
+
+func (r *Runtime) promise_all(call FunctionCall) Value {
+	return r.promiseAll(call, func(v Value) Value {
+		return v
+	}, nil)
+}
+
+func (r *Runtime) promise_allSettled(call FunctionCall) Value {
+	return r.promiseAll(call, func(v Value) Value {
+		o := r.NewObject()
+		o.self._putProp("status", asciiString("fulfilled"), true, true, true)
+		o.self._putProp("value", v, true, true, true)
+		return o
+	}, func(v Value) Value {
+		o := r.NewObject()
+		o.self._putProp("status", asciiString("rejected"), true, true, true)
+		o.self._putProp("reason", v, true, true, true)
+		return o
+	})
+}
+
+func (r *Runtime) promise_race(call FunctionCall) Value {
+	var resolve, reject *Object
+	return r.promiseCombinator(call, func(capability *promiseCapability, idx int, nextPromise Value) {
+		if resolve == nil {
+			resolve = r.newNativeFunc(capability.resolve, nil, "", nil, 1)
+			reject = r.newNativeFunc(capability.reject, nil, "", nil, 1)
+		}
+		r.invoke(nextPromise, "then", resolve, reject)
+	}, nil)
+}
+
+func (r *Runtime) promise_reject(call FunctionCall) Value {
+	capability := r.newPromiseCapability(call.This)
+	capability.reject(FunctionCall{This: _undefined, Arguments: []Value{call.Argument(0)}})
+	return capability.promise
+}
+
+func (r *Runtime) promise_resolve(call FunctionCall) Value {
+	c, ok := call.This.(*Object)
+	if !ok {
+		r.typeErrorResult(true, "PromiseResolve called on non-object")
+	}
+	return r.promiseResolve(c, call.Argument(0))
+}
+
+func (r *Runtime) createPromiseProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.Promise, true, false, true)
+	o._putProp("catch", r.newNativeFunc(r.promiseProto_catch, nil, "catch", nil, 1), true, false, true)
+	o._putProp("finally", r.newNativeFunc(r.promiseProto_finally, nil, "finally", nil, 1), true, false, true)
+	o._putProp("then", r.newNativeFunc(r.promiseProto_then, nil, "then", nil, 2), true, false, true)
+	o._putSym(SymToStringTag, asciiString(classPromise), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) createPromise(val *Object) objectImpl {
+	o := r.newNativeFuncObj(val, r.constructorRequiresNew("Promise"), func(args []Value) *Object {
+		return r.builtin_newPromise(args, r.global.PromisePrototype)
+	}, "Promise", r.global.PromisePrototype, 1)
+
+	o._putProp("all", r.newNativeFunc(r.promise_all, nil, "all", nil, 1), true, false, true)
+	o._putProp("allSettled", r.newNativeFunc(r.promise_allSettled, nil, "allSettled", nil, 1), true, false, true)
+	o._putProp("race", r.newNativeFunc(r.promise_race, nil, "race", nil, 1), true, false, true)
+	o._putProp("reject", r.newNativeFunc(r.promise_reject, nil, "reject", nil, 1), true, false, true)
+	o._putProp("resolve", r.newNativeFunc(r.promise_resolve, nil, "resolve", nil, 1), true, false, true)
+
+	return o
+}
+
+func (r *Runtime) initPromise() {
+	r.global.PromisePrototype = r.newLazyObject(r.createPromiseProto)
+	r.global.Promise = r.newLazyObject(r.createPromise)
+	r.addToGlobal("Promise", r.global.Promise)
+}
+
+// NewPromise creates and returns a pending Promise and the functions resolving it. The values passed to resolve and
+// reject are converted with ToValue.
+//
+// A native function can return the promise to the script and settle it later, once the operation it started has
+// completed. Note that the resolving functions, like the rest of the Runtime, are not goroutine-safe: if the
+// operation completes in another goroutine, the call must be passed back to the goroutine running the Runtime, see
+// RegisterCallback. The reactions of the promise are not run immediately, they are queued as jobs, see RunJobs.
+//
+//	vm.Set("delay", func(call goja.FunctionCall) goja.Value {
+//		p, resolve, _ := vm.NewPromise()
+//		callback := vm.RegisterCallback()
+//		go func() {
+//			time.Sleep(time.Second)
+//			callback(func() { resolve("done") }) // run by vm.RunEventLoop()
+//		}()
+//		return vm.ToValue(p)
+//	})
+func (r *Runtime) NewPromise() (promise *Promise, resolve func(result interface{}), reject func(reason interface{})) {
+	p := r.newPromise(r.global.PromisePrototype)
+	resolveF, rejectF := p.createResolvingFunctions()
+	resolveFn := r.toCallable(resolveF)
+	rejectFn := r.toCallable(rejectF)
+	return p, func(result interface{}) {
+			resolveFn(FunctionCall{This: _undefined, Arguments: []Value{r.ToValue(result)}})
+		}, func(reason interface{}) {
+			rejectFn(FunctionCall{This: _undefined, Arguments: []Value{r.ToValue(reason)}})
+		}
+}
+
+// SetPromiseRejectionTracker registers a function that is called when a promise is rejected without handlers, and
+// when a handler is added to such a promise afterwards. A rejection that has not been followed by a
+// PromiseRejectionHandle call by the time the job queue is drained is unhandled.
+func (r *Runtime) SetPromiseRejectionTracker(tracker PromiseRejectionTracker) {
+	r.promiseRejectionTracker = tracker
+}
+
+// RunJobs runs the queued jobs, such as promise reactions, until the queue is empty. The jobs are never run
+// automatically, the embedder decides when to drain the queue, typically after running a script or after resolving
+// a promise with the functions returned by NewPromise. It must not be called while the Runtime is running a script.
+//
+// If a job throws an exception or is interrupted, the error is returned and the remaining jobs stay in the queue.
+func (r *Runtime) RunJobs() error {
+	for len(r.jobQueue) > 0 {
+		job := r.jobQueue[0]
+		r.jobQueue[0] = nil
+		r.jobQueue = r.jobQueue[1:]
+		if err := r.runJob(job); err != nil {
+			return err
+		}
+	}
+	r.jobQueue = nil
+	return nil
+}
+
+// RunJobsContext is like RunJobs() but the jobs are interrupted when ctx is done, see RunProgramContext().
+func (r *Runtime) RunJobsContext(ctx gocontext.Context) error {
+	defer r.withContext(ctx)()
+	return r.RunJobs()
+}
+
+// runJob runs a job or a callback, it returns the exception it throws or the interruption as an error.
+func (r *Runtime) runJob(job func()) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			if intr, ok := x.(uncatchableError); ok {
+				err = intr
+			} else {
+				panic(x)
+			}
+		}
+	}()
+	ex := r.vm.try(job)
+	r.vm.clearStack()
+	if ex != nil {
+		return ex
+	}
+	return nil
+}
+
+// RegisterCallback tells the event loop (see RunEventLoop) to wait for an operation running outside of the Runtime,
+// typically in another goroutine, and returns the function which schedules the continuation of the operation on the
+// goroutine running the loop. Unlike the rest of the Runtime, the returned function is goroutine-safe. It must be
+// called exactly once.
+//
+//	vm.Set("readFile", func(call goja.FunctionCall) goja.Value {
+//		p, resolve, reject := vm.NewPromise()
+//		callback := vm.RegisterCallback()
+//		name := call.Argument(0).String()
+//		go func() {
+//			data, err := ioutil.ReadFile(name)
+//			callback(func() {
+//				if err != nil {
+//					reject(err)
+//				} else {
+//					resolve(string(data))
+//				}
+//			})
+//		}()
+//		return vm.ToValue(p)
+//	})
+func (r *Runtime) RegisterCallback() func(func()) {
+	r.callbackLock.Lock()
+	r.pendingCallbacks++
+	r.callbackLock.Unlock()
+	return func(f func()) {
+		r.callbackLock.Lock()
+		r.callbackQueue = append(r.callbackQueue, f)
+		r.pendingCallbacks--
+		r.callbackLock.Unlock()
+		select {
+		case r.callbackWakeup <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// RunEventLoop runs the queued jobs and the callbacks scheduled through RegisterCallback until the loop goes quiet,
+// i.e. the job queue is empty and no registered callback is outstanding, or until the deadline passes, in which case
+// ErrEventLoopDeadline is returned. A zero deadline means no deadline. The deadline is checked between the jobs,
+// use Interrupt to stop a script which does not return.
+//
+// Like RunJobs, it must not be called while the Runtime is running a script. If a job or a callback throws an
+// exception or is interrupted, the error is returned and the remaining work stays queued.
+func (r *Runtime) RunEventLoop(deadline time.Time) error {
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		if err := r.RunJobs(); err != nil {
+			return err
+		}
+		select {
+		case <-expired:
+			return ErrEventLoopDeadline
+		default:
+		}
+		r.callbackLock.Lock()
+		queue, pending := r.callbackQueue, r.pendingCallbacks
+		r.callbackQueue = nil
+		r.callbackLock.Unlock()
+		if len(queue) == 0 {
+			if pending == 0 {
+				return nil
+			}
+			select {
+			case <-r.callbackWakeup:
+			case <-expired:
+				return ErrEventLoopDeadline
+			}
+			continue
+		}
+		for i, f := range queue {
+			if err := r.runJob(f); err != nil {
+				r.callbackLock.Lock()
+				r.callbackQueue = append(queue[i+1:], r.callbackQueue...)
+				r.callbackLock.Unlock()
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package goja
+
+import (
+	"strings"
+	"testing"
+)
+
+// testScriptWithJobs runs the script, drains the job queue and then compares the value of the global variable
+// 'result' with the expected value.
+func testScriptWithJobs(script string, expectedResult Value, t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vm.RunJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := vm.Get("result"); !res.SameAs(expectedResult) {
+		t.Fatalf("Result: %v, expected: %v", res, expectedResult)
+	}
+}
+
+func TestPromiseOrder(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	var p = new Promise(function(resolve) {
+		log.push("executor");
+		resolve(1);
+	});
+	p.then(function(v) {
+		log.push("then1 " + v);
+		return v + 1;
+	}).then(function(v) {
+		log.push("then2 " + v);
+	});
+	Promise.resolve().then(function() {
+		log.push("other");
+	});
+	log.push("sync");
+	var result;
+	Promise.resolve().then(function() {}).then(function() {}).then(function() {
+		result = log.join();
+	});
+	`
+
+	testScriptWithJobs(SCRIPT, asciiString("executor,sync,then1 1,other,then2 2"), t)
+}
+
+func TestPromiseReject(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	new Promise(function() {
+		throw new Error("boom");
+	}).then(function() {
+		log.push("not reached");
+	}).catch(function(e) {
+		log.push(e.message);
+		return "recovered";
+	}).finally(function() {
+		log.push("finally");
+		return "ignored";
+	}).then(function(v) {
+		log.push(v);
+	});
+	Promise.reject(2).finally(function() {}).catch(function(v) {
+		log.push("reason " + v);
+	});
+	var result;
+	setResult();
+	function setResult() {
+		var p = Promise.resolve();
+		for (var i = 0; i < 10; i++) {
+			p = p.then(function() {});
+		}
+		p.then(function() {
+			result = log.join();
+		});
+	}
+	`
+
+	testScriptWithJobs(SCRIPT, asciiString("boom,finally,reason 2,recovered"), t)
+}
+
+func TestPromiseThenable(t *testing.T) {
+	const SCRIPT = `
+	var result;
+	var thenable = {
+		then: function(resolve) {
+			resolve("from thenable");
+		}
+	};
+	var p = new Promise(function(resolve) {
+		resolve(thenable);
+	});
+	var p1 = Promise.resolve(p);
+	var cycle;
+	cycle = new Promise(function(resolve) {
+		Promise.resolve().then(function() {
+			resolve(cycle);
+		});
+	});
+	cycle.catch(function(e) {
+		p1.then(function(v) {
+			result = [v, p1 === p, e instanceof TypeError].join();
+		});
+	});
+	`
+
+	testScriptWithJobs(SCRIPT, asciiString("from thenable,true,true"), t)
+}
+
+func TestPromiseCombinators(t *testing.T) {
+	const SCRIPT = `
+	var result;
+	var res = [];
+	var resolveLater;
+	var later = new Promise(function(resolve) {
+		resolveLater = resolve;
+	});
+	Promise.all([1, later, Promise.resolve(3)]).then(function(v) {
+		res.push("all " + v.join("|"));
+	});
+	Promise.all([Promise.reject("x"), later]).catch(function(e) {
+		res.push("all rejected " + e);
+	});
+	Promise.allSettled([Promise.reject("y"), 2]).then(function(v) {
+		res.push("allSettled " + v[0].status + " " + v[0].reason + " " + v[1].status + " " + v[1].value);
+	});
+	Promise.race([later, Promise.resolve("first")]).then(function(v) {
+		res.push("race " + v);
+	});
+	Promise.all(new Set(["a", "b"])).then(function(v) {
+		res.push("set " + v.join("|"));
+	});
+	Promise.all(1).catch(function(e) {
+		res.push("not iterable " + (e instanceof TypeError));
+	});
+	Promise.all([]).then(function(v) {
+		res.push("empty " + v.length);
+	});
+	resolveLater(2);
+	var p = Promise.resolve();
+	for (var i = 0; i < 10; i++) {
+		p = p.then(function() {});
+	}
+	p.then(function() {
+		result = res.sort().join();
+	});
+	`
+
+	testScriptWithJobs(SCRIPT, asciiString("all 1|2|3,all rejected x,allSettled rejected y fulfilled 2,empty 0,not iterable true,race first,set a|b"), t)
+}
+
+func TestPromiseErrors(t *testing.T) {
+	const SCRIPT = `
+	function throwsTypeError(f) {
+		try {
+			f();
+		} catch (e) {
+			return e instanceof TypeError;
+		}
+		return false;
+	}
+	var result = throwsTypeError(function() { Promise(function() {}) }) &&
+		throwsTypeError(function() { new Promise(1) }) &&
+		throwsTypeError(function() { Promise.prototype.then.call({}) }) &&
+		throwsTypeError(function() { Promise.resolve.call(1) }) &&
+		Object.prototype.toString.call(Promise.resolve()) === "[object Promise]";
+	`
+
+	testScriptWithJobs(SCRIPT, valueTrue, t)
+}
+
+func TestNewPromise(t *testing.T) {
+	vm := New()
+	var resolveFn func(interface{})
+	vm.Set("delay", vm.NewNamedNativeFunction("delay", func(call FunctionCall) Value {
+		p, resolve, _ := vm.NewPromise()
+		resolveFn = resolve
+		return vm.ToValue(p)
+	}))
+	_, err := vm.RunString(`
+	var result = "pending";
+	var p = delay();
+	p.then(function(v) {
+		result = v;
+	});
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vm.RunJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := vm.Get("result").String(); res != "pending" {
+		t.Fatalf("Unexpected result: %s", res)
+	}
+	p := vm.Get("p").Export().(*Promise)
+	if p.State() != PromiseStatePending {
+		t.Fatalf("Unexpected state: %v", p.State())
+	}
+
+	resolveFn("done")
+	if res := vm.Get("result").String(); res != "pending" {
+		t.Fatalf("The reactions must not run before RunJobs(): %s", res)
+	}
+	err = vm.RunJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := vm.Get("result").String(); res != "done" {
+		t.Fatalf("Unexpected result: %s", res)
+	}
+	if p.State() != PromiseStateFulfilled || p.Result().String() != "done" {
+		t.Fatalf("Unexpected state: %v, %v", p.State(), p.Result())
+	}
+
+	p1, _, reject := vm.NewPromise()
+	reject(vm.NewTypeError("failed"))
+	if p1.State() != PromiseStateRejected || p1.Result().String() != "TypeError: failed" {
+		t.Fatalf("Unexpected state: %v, %v", p1.State(), p1.Result())
+	}
+}
+
+func TestPromiseRejectionTracker(t *testing.T) {
+	vm := New()
+	var ops []string
+	vm.SetPromiseRejectionTracker(func(p *Promise, operation PromiseRejectionOperation) {
+		switch operation {
+		case PromiseRejectionReject:
+			ops = append(ops, "reject "+p.Result().String())
+		case PromiseRejectionHandle:
+			ops = append(ops, "handle "+p.Result().String())
+		}
+	})
+	_, err := vm.RunString(`
+	var p = Promise.reject(1);
+	Promise.resolve().then(function() {
+		p.catch(function() {});
+	});
+	Promise.reject(2).catch(function() {});
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vm.RunJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := strings.Join(ops, ","); res != "reject 1,reject 2,handle 2,handle 1" {
+		t.Fatalf("Unexpected operations: %s", res)
+	}
+}
@@ -0,0 +1,255 @@
+package goja
+
+func (r *Runtime) toReflectTarget(v Value, method string) *Object {
+	if obj, ok := v.(*Object); ok {
+		return obj
+	}
+	r.typeErrorResult(true, "Reflect.%s called on non-object", method)
+	panic("Unreachable")
+}
+
+// getOwnPropValue returns the own property of o with the given key as returned by getOwnProp or getOwnPropSym.
+func getOwnPropValue(o *Object, key Value) Value {
+	if s, ok := key.(*Symbol); ok {
+		return o.self.getOwnPropSym(s)
+	}
+	return o.self.getOwnProp(key.String())
+}
+
+// reflectGet looks up the property along the prototype chain of o like an ordinary [[Get]], a getter is called with
+// the receiver as this. A proxy in the chain handles the lookup itself.
+func (r *Runtime) reflectGet(o *Object, key, receiver Value) Value {
+	for o != nil {
+		if r.isProxy(o) {
+			return nilSafe(o.self.get(key))
+		}
+		if prop := getOwnPropValue(o, key); prop != nil {
+			if prop, ok := prop.(*valueProperty); ok {
+				return prop.get(receiver)
+			}
+			return prop
+		}
+		o = o.self.proto()
+	}
+	return _undefined
+}
+
+// reflectSet implements the ordinary [[Set]]: a setter found along the prototype chain of o is called with the
+// receiver as this, otherwise the value is defined as a data property of the receiver. Unlike put it reports the
+// failure by returning false instead of throwing.
+func (r *Runtime) reflectSet(o *Object, key, val, receiver Value) bool {
+	var prop Value
+	for p := o; p != nil; p = p.self.proto() {
+		if r.isProxy(p) {
+			if p == receiver {
+				p.self.put(key, val, true)
+				return true
+			}
+			break
+		}
+		if prop = getOwnPropValue(p, key); prop != nil {
+			break
+		}
+	}
+
+	if prop, ok := prop.(*valueProperty); ok {
+		if prop.accessor {
+			if prop.setterFunc == nil {
+				return false
+			}
+			call, _ := prop.setterFunc.self.assertCallable()
+			call(FunctionCall{
+				This:      receiver,
+				Arguments: []Value{val},
+			})
+			return true
+		}
+		if !prop.writable {
+			return false
+		}
+	}
+
+	recv, ok := receiver.(*Object)
+	if !ok {
+		return false
+	}
+	if existing := getOwnPropValue(recv, key); existing != nil {
+		if existing, ok := existing.(*valueProperty); ok && (existing.accessor || !existing.writable) {
+			return false
+		}
+		return recv.self.defineOwnProperty(key, PropertyDescriptor{
+			Value: val,
+		}, false)
+	}
+	return recv.self.defineOwnProperty(key, PropertyDescriptor{
+		Value:        val,
+		Writable:     FLAG_TRUE,
+		Enumerable:   FLAG_TRUE,
+		Configurable: FLAG_TRUE,
+	}, false)
+}
+
+func (r *Runtime) builtin_reflect_apply(call FunctionCall) Value {
+	target := call.Argument(0)
+	args := r.toValueArray(call.Argument(2))
+	if r.isProxy(target) {
+		return r.getProxy(target).apply(call.Argument(1), args)
+	}
+	return r.toCallable(target)(FunctionCall{
+		This:      call.Argument(1),
+		Arguments: args,
+	})
+}
+
+func (r *Runtime) builtin_reflect_construct(call FunctionCall) Value {
+	target := call.Argument(0)
+	if r.toConstructor(target) == nil {
+		r.typeErrorResult(true, "%s is not a constructor", target.String())
+	}
+	newTarget := target
+	if len(call.Arguments) > 2 {
+		newTarget = call.Arguments[2]
+		if r.toConstructor(newTarget) == nil {
+			r.typeErrorResult(true, "%s is not a constructor", newTarget.String())
+		}
+	}
+	return r.construct(target.(*Object), r.toValueArray(call.Argument(1)), newTarget.(*Object))
+}
+
+func (r *Runtime) builtin_reflect_defineProperty(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "defineProperty")
+	key := toPropertyKey(call.Argument(1))
+	descr := r.toPropertyDescriptor(call.Argument(2))
+	return r.toBoolean(target.self.defineOwnProperty(key, descr, false))
+}
+
+func (r *Runtime) builtin_reflect_deleteProperty(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "deleteProperty")
+	return r.toBoolean(target.self.delete(toPropertyKey(call.Argument(1)), false))
+}
+
+func (r *Runtime) builtin_reflect_get(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "get")
+	receiver := Value(target)
+	if len(call.Arguments) > 2 {
+		receiver = call.Arguments[2]
+	}
+	return r.reflectGet(target, toPropertyKey(call.Argument(1)), receiver)
+}
+
+func (r *Runtime) builtin_reflect_getOwnPropertyDescriptor(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "getOwnPropertyDescriptor")
+	key := toPropertyKey(call.Argument(1))
+	if s, ok := key.(*Symbol); ok {
+		return r.propToDescriptorObject(target.self.getOwnPropSym(s))
+	}
+	return target.self.getOwnPropertyDescriptor(key.String())
+}
+
+func (r *Runtime) builtin_reflect_getPrototypeOf(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "getPrototypeOf")
+	if p := target.self.proto(); p != nil {
+		return p
+	}
+	return _null
+}
+
+func (r *Runtime) builtin_reflect_has(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "has")
+	return r.toBoolean(target.self.hasProperty(toPropertyKey(call.Argument(1))))
+}
+
+func (r *Runtime) builtin_reflect_isExtensible(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "isExtensible")
+	return r.toBoolean(target.self.isExtensible())
+}
+
+func (r *Runtime) builtin_reflect_ownKeys(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "ownKeys")
+	if r.isProxy(target) {
+		return r.getProxy(target).ownKeys(true, false)
+	}
+	var keys []Value
+	for item, f := target.self.enumerate(true, false)(); f != nil; item, f = f() {
+		keys = append(keys, newStringValue(item.name))
+	}
+	for _, s := range target.self.ownSymbols() {
+		keys = append(keys, s)
+	}
+	return r.newArrayValues(keys)
+}
+
+func (r *Runtime) builtin_reflect_preventExtensions(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "preventExtensions")
+	target.self.preventExtensions()
+	return valueTrue
+}
+
+func (r *Runtime) builtin_reflect_set(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "set")
+	receiver := Value(target)
+	if len(call.Arguments) > 3 {
+		receiver = call.Arguments[3]
+	}
+	return r.toBoolean(r.reflectSet(target, toPropertyKey(call.Argument(1)), call.Argument(2), receiver))
+}
+
+func (r *Runtime) builtin_reflect_setPrototypeOf(call FunctionCall) Value {
+	target := r.toReflectTarget(call.Argument(0), "setPrototypeOf")
+	var proto *Object
+	if arg := call.Argument(1); arg != _null {
+		if o, ok := arg.(*Object); ok {
+			proto = o
+		} else {
+			r.typeErrorResult(true, "Object prototype may only be an Object or null: %s", arg.String())
+		}
+	}
+	if r.isProxy(target) {
+		// there is no setPrototypeOf trap, the prototype of the target is changed
+		target = r.getProxy(target).target
+	}
+	if target.self.proto() == proto {
+		return valueTrue
+	}
+	if !target.self.isExtensible() {
+		return valueFalse
+	}
+	for p := proto; p != nil && !r.isProxy(p); p = p.self.proto() {
+		if p == target {
+			return valueFalse
+		}
+	}
+	target.self.setProto(proto)
+	return valueTrue
+}
+
+func (r *Runtime) createReflect(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("apply", r.newNativeFunc(r.builtin_reflect_apply, nil, "apply", nil, 3), true, false, true)
+	o._putProp("construct", r.newNativeFunc(r.builtin_reflect_construct, nil, "construct", nil, 2), true, false, true)
+	o._putProp("defineProperty", r.newNativeFunc(r.builtin_reflect_defineProperty, nil, "defineProperty", nil, 3), true, false, true)
+	o._putProp("deleteProperty", r.newNativeFunc(r.builtin_reflect_deleteProperty, nil, "deleteProperty", nil, 2), true, false, true)
+	o._putProp("get", r.newNativeFunc(r.builtin_reflect_get, nil, "get", nil, 2), true, false, true)
+	o._putProp("getOwnPropertyDescriptor", r.newNativeFunc(r.builtin_reflect_getOwnPropertyDescriptor, nil, "getOwnPropertyDescriptor", nil, 2), true, false, true)
+	o._putProp("getPrototypeOf", r.newNativeFunc(r.builtin_reflect_getPrototypeOf, nil, "getPrototypeOf", nil, 1), true, false, true)
+	o._putProp("has", r.newNativeFunc(r.builtin_reflect_has, nil, "has", nil, 2), true, false, true)
+	o._putProp("isExtensible", r.newNativeFunc(r.builtin_reflect_isExtensible, nil, "isExtensible", nil, 1), true, false, true)
+	o._putProp("ownKeys", r.newNativeFunc(r.builtin_reflect_ownKeys, nil, "ownKeys", nil, 1), true, false, true)
+	o._putProp("preventExtensions", r.newNativeFunc(r.builtin_reflect_preventExtensions, nil, "preventExtensions", nil, 1), true, false, true)
+	o._putProp("set", r.newNativeFunc(r.builtin_reflect_set, nil, "set", nil, 3), true, false, true)
+	o._putProp("setPrototypeOf", r.newNativeFunc(r.builtin_reflect_setPrototypeOf, nil, "setPrototypeOf", nil, 2), true, false, true)
+	o._putSym(SymToStringTag, asciiString("Reflect"), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) initReflect() {
+	r.addToGlobal("Reflect", r.newLazyObject(r.createReflect))
+}
@@ -0,0 +1,121 @@
+package goja
+
+import "testing"
+
+func TestReflectGetSetReceiver(t *testing.T) {
+	const SCRIPT = `
+	var o = {
+		get x() { return this.v; },
+		set x(v) { this.v = v * 2; },
+		v: 1
+	};
+	var recv = {v: 10};
+	var res = [Reflect.get(o, "x"), Reflect.get(o, "x", recv)];
+	res.push(Reflect.set(o, "x", 5, recv), recv.v, o.v);
+	res.push(Reflect.set(o, "y", 1, recv), recv.y, o.hasOwnProperty("y"));
+
+	var frozen = Object.freeze({a: 1});
+	res.push(Reflect.set(frozen, "a", 2), Reflect.set(frozen, "b", 2), frozen.a);
+	res.push(Reflect.set(Object.create(frozen), "a", 2));
+	res.push(Reflect.set({}, "z", 1, 1));
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("1,10,true,10,1,true,1,false,false,false,1,false,false"), t)
+}
+
+func TestReflectProperties(t *testing.T) {
+	const SCRIPT = `
+	var s = Symbol("s");
+	var o = {b: 1, a: 2};
+	o[s] = 3;
+	var keys = Reflect.ownKeys(o);
+	var res = [keys.length, keys[0], keys[1], keys[2] === s];
+	res.push(Reflect.has(o, "a"), Reflect.has(o, "toString"), Reflect.has(o, s));
+	res.push(Reflect.deleteProperty(o, "a"), Reflect.has(o, "a"));
+	res.push(Reflect.deleteProperty(Object.freeze({a: 1}), "a"));
+	res.push(Reflect.defineProperty(o, "c", {value: 4}), Reflect.getOwnPropertyDescriptor(o, "c").writable);
+	res.push(Reflect.defineProperty(o, "c", {value: 5}), o.c);
+	res.push(Reflect.getOwnPropertyDescriptor(o, s).value, Reflect.getOwnPropertyDescriptor(o, "d"));
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("3,b,a,true,true,true,true,true,false,false,true,false,false,4,3,"), t)
+}
+
+func TestReflectPrototype(t *testing.T) {
+	const SCRIPT = `
+	var p = {};
+	var c = Object.create(p);
+	var res = [Reflect.getPrototypeOf(c) === p, Reflect.setPrototypeOf(p, c), Reflect.setPrototypeOf(c, c)];
+	res.push(Reflect.setPrototypeOf(c, null), Reflect.getPrototypeOf(c));
+	res.push(Reflect.isExtensible(c), Reflect.preventExtensions(c), Reflect.isExtensible(c));
+	res.push(Reflect.setPrototypeOf(c, p), Reflect.setPrototypeOf(c, null));
+	try {
+		Reflect.setPrototypeOf(c, 1);
+	} catch (e) {
+		res.push(e instanceof TypeError);
+	}
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("true,false,false,true,,true,true,false,false,true,true"), t)
+}
+
+func TestReflectApplyConstruct(t *testing.T) {
+	const SCRIPT = `
+	function F(a, b) {
+		this.sum = a + b;
+	}
+	function G() {}
+	var res = [Reflect.apply(Math.max, null, [1, 3, 2]), Reflect.apply(function() { return this; }, "x", []) == "x"];
+	var inst = Reflect.construct(F, [1, 2]);
+	res.push(inst.sum, inst instanceof F);
+	inst = Reflect.construct(F, [3, 4], G);
+	res.push(inst.sum, inst instanceof G);
+	inst = Reflect.construct(Array, [3], G);
+	res.push(inst.length, Object.getPrototypeOf(inst) === G.prototype);
+	try {
+		Reflect.construct(F, [], Math.max);
+	} catch (e) {
+		res.push(e instanceof TypeError);
+	}
+	try {
+		Reflect.apply(1, null, []);
+	} catch (e) {
+		res.push(e instanceof TypeError);
+	}
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("3,true,3,true,7,true,3,true,true,true"), t)
+}
+
+func TestReflectProxyForwarding(t *testing.T) {
+	const SCRIPT = `
+	var log = [];
+	var p = new Proxy({t: 1}, {
+		get: function(target, key, receiver) {
+			log.push("get " + key);
+			return Reflect.get(target, key, receiver);
+		},
+		set: function(target, key, value, receiver) {
+			log.push("set " + key);
+			return Reflect.set(target, key, value);
+		},
+		has: function(target, key) {
+			return Reflect.has(target, key);
+		}
+	});
+	p.q = 3;
+	var res = [p.t, p.q, "q" in p, log.join(), String(Reflect)];
+	try {
+		Reflect.get(1, "a");
+	} catch (e) {
+		res.push(e.message);
+	}
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("1,3,true,set q,get t,get q,[object Reflect],Reflect.get called on non-object"), t)
+}
@@ -0,0 +1,168 @@
+package goja
+
+import "reflect"
+
+var typeSetExport = reflect.TypeOf([]interface{}(nil))
+
+type setObject struct {
+	baseObject
+	m *orderedMap
+}
+
+func (so *setObject) init() {
+	so.baseObject.init()
+	so.m = newOrderedMap()
+}
+
+// export returns the values of the Set as []interface{} in the insertion order.
+func (so *setObject) export() interface{} {
+	a := make([]interface{}, 0, so.m.size)
+	for e := so.m.first; e != nil; e = e.next {
+		a = append(a, exportValue(e.key))
+	}
+	return a
+}
+
+func (so *setObject) exportType() reflect.Type {
+	return typeSetExport
+}
+
+func (r *Runtime) newSetObject(proto *Object) *setObject {
+	v := &Object{runtime: r}
+	so := &setObject{}
+	so.class = classSet
+	so.val = v
+	so.extensible = true
+	v.self = so
+	so.prototype = proto
+	so.init()
+	return so
+}
+
+func (r *Runtime) toSetObject(v Value, method string) *setObject {
+	if obj, ok := v.(*Object); ok {
+		if so, ok := obj.self.(*setObject); ok {
+			return so
+		}
+	}
+	r.typeErrorResult(true, "Method Set.prototype.%s called on incompatible receiver %s", method, v.String())
+	panic("unreachable")
+}
+
+func (r *Runtime) setProto_add(call FunctionCall) Value {
+	so := r.toSetObject(call.This, "add")
+	key := call.Argument(0)
+	if !so.m.has(key) {
+		r.vm.allocate(mapEntryAllocSize)
+	}
+	so.m.set(key, nil)
+	return call.This
+}
+
+func (r *Runtime) setProto_clear(call FunctionCall) Value {
+	r.toSetObject(call.This, "clear").m.clear()
+	return _undefined
+}
+
+func (r *Runtime) setProto_delete(call FunctionCall) Value {
+	return r.toBoolean(r.toSetObject(call.This, "delete").m.remove(call.Argument(0)))
+}
+
+func (r *Runtime) setProto_has(call FunctionCall) Value {
+	return r.toBoolean(r.toSetObject(call.This, "has").m.has(call.Argument(0)))
+}
+
+func (r *Runtime) setProto_forEach(call FunctionCall) Value {
+	so := r.toSetObject(call.This, "forEach")
+	callbackFn := r.toCallable(call.Argument(0))
+	fc := FunctionCall{This: call.Argument(1), Arguments: []Value{nil, nil, so.val}}
+	iter := so.m.newIter()
+	for e := iter.next(); e != nil; e = iter.next() {
+		fc.Arguments[0], fc.Arguments[1] = e.key, e.key
+		callbackFn(fc)
+	}
+	return _undefined
+}
+
+func (r *Runtime) setProto_entries(call FunctionCall) Value {
+	return r.createSetIterator(r.toSetObject(call.This, "entries"), iterationKindKeyValue)
+}
+
+func (r *Runtime) setProto_values(call FunctionCall) Value {
+	return r.createSetIterator(r.toSetObject(call.This, "values"), iterationKindValue)
+}
+
+func (r *Runtime) setProto_getSize(call FunctionCall) Value {
+	return intToValue(int64(r.toSetObject(call.This, "size").m.size))
+}
+
+func (r *Runtime) createSetIterator(so *setObject, kind iterationKind) Value {
+	iter := so.m.newIter()
+	return r.newIteratorObject(r.global.SetIteratorPrototype, classSetIterator, func() (Value, bool) {
+		e := iter.next()
+		if e == nil {
+			return nil, false
+		}
+		if kind == iterationKindKeyValue {
+			return r.newArrayValues([]Value{e.key, e.key}), true
+		}
+		return e.key, true
+	})
+}
+
+func (r *Runtime) builtin_newSet(args []Value, proto *Object) *Object {
+	so := r.newSetObject(proto)
+	if len(args) > 0 {
+		if arg := args[0]; arg != _undefined && arg != _null {
+			adder := r.toCallable(so.getStr("add"))
+			r.getIterator(arg).iterate(func(item Value) {
+				adder(FunctionCall{This: so.val, Arguments: []Value{item}})
+			})
+		}
+	}
+	return so.val
+}
+
+func (r *Runtime) createSetProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.Set, true, false, true)
+	o._putProp("add", r.newNativeFunc(r.setProto_add, nil, "add", nil, 1), true, false, true)
+	o._putProp("clear", r.newNativeFunc(r.setProto_clear, nil, "clear", nil, 0), true, false, true)
+	o._putProp("delete", r.newNativeFunc(r.setProto_delete, nil, "delete", nil, 1), true, false, true)
+	o._putProp("entries", r.newNativeFunc(r.setProto_entries, nil, "entries", nil, 0), true, false, true)
+	o._putProp("forEach", r.newNativeFunc(r.setProto_forEach, nil, "forEach", nil, 1), true, false, true)
+	o._putProp("has", r.newNativeFunc(r.setProto_has, nil, "has", nil, 1), true, false, true)
+	o._put("size", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.setProto_getSize, nil, "get size", nil, 0),
+	})
+	values := r.newNativeFunc(r.setProto_values, nil, "values", nil, 0)
+	o._putProp("values", values, true, false, true)
+	o._putProp("keys", values, true, false, true)
+	o._putSym(SymIterator, values, true, false, true)
+	o._putSym(SymToStringTag, asciiString(classSet), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) createSet(val *Object) objectImpl {
+	return r.newNativeFuncObj(val, r.constructorRequiresNew("Set"), func(args []Value) *Object {
+		return r.builtin_newSet(args, r.global.SetPrototype)
+	}, "Set", r.global.SetPrototype, 0)
+}
+
+func (r *Runtime) initSet() {
+	r.global.SetIteratorPrototype = r.newLazyObject(r.builtinIteratorProto(classSetIterator))
+
+	r.global.SetPrototype = r.newLazyObject(r.createSetProto)
+	r.global.Set = r.newLazyObject(r.createSet)
+	r.addToGlobal("Set", r.global.Set)
+}
@@ -8,6 +8,8 @@ import (
 	"golang.org/x/text/unicode/norm"
 	"math"
 	"strings"
+	"unicode"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
@@ -21,6 +23,9 @@ func (r *Runtime) builtin_String(call FunctionCall) Value {
 		if _, ok := arg.assertString(); ok {
 			return arg
 		}
+		if sym, ok := arg.(*Symbol); ok {
+			return sym.descString()
+		}
 		return arg.ToString()
 	} else {
 		return newStringValue("")
@@ -146,12 +151,14 @@ func (r *Runtime) stringproto_concat(call FunctionCall) Value {
 	}
 
 	if allAscii {
+		r.vm.allocate(totalLen)
 		buf := bytes.NewBuffer(make([]byte, 0, totalLen))
 		for _, s := range strs {
 			buf.WriteString(s.String())
 		}
 		return asciiString(buf.String())
 	} else {
+		r.vm.allocate(totalLen * 2)
 		buf := make([]uint16, totalLen)
 		pos := int64(0)
 		for _, s := range strs {
@@ -301,6 +308,11 @@ func (r *Runtime) stringproto_replace(call FunctionCall) Value {
 
 	var buf bytes.Buffer
 	lastIndex := 0
+	allocated := 0
+	allocate := func() {
+		r.vm.allocate(int64(buf.Len() - allocated))
+		allocated = buf.Len()
+	}
 
 	var rcall func(FunctionCall) Value
 
@@ -337,6 +349,7 @@ func (r *Runtime) stringproto_replace(call FunctionCall) Value {
 			}).String()
 			buf.WriteString(replacement)
 			lastIndex = item[1]
+			allocate()
 		}
 	} else {
 		newstring := replaceValue.String()
@@ -391,12 +404,14 @@ func (r *Runtime) stringproto_replace(call FunctionCall) Value {
 				}
 			}
 			lastIndex = item[1]
+			allocate()
 		}
 	}
 
 	if lastIndex != len(str) {
 		buf.WriteString(str[lastIndex:])
 	}
+	allocate()
 
 	return newStringValue(buf.String())
 }
@@ -619,6 +634,11 @@ func (r *Runtime) stringproto_toUpperCase(call FunctionCall) Value {
 	return s.toUpper()
 }
 
+func (r *Runtime) stringproto_iterator(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	return r.createStringIterator(call.This.ToString())
+}
+
 func (r *Runtime) stringproto_trim(call FunctionCall) Value {
 	r.checkObjectCoercible(call.This)
 	s := call.This.ToString()
@@ -649,6 +669,231 @@ func (r *Runtime) stringproto_substr(call FunctionCall) Value {
 	return s.substring(start, start+length)
 }
 
+// isRegExp implements the IsRegExp() check of the methods which do not accept a RegExp argument.
+func (r *Runtime) isRegExp(v Value) bool {
+	if o, ok := v.(*Object); ok {
+		if matcher := o.self.get(SymMatch); matcher != nil && matcher != _undefined {
+			return matcher.ToBoolean()
+		}
+		_, ok := o.self.(*regexpObject)
+		return ok
+	}
+	return false
+}
+
+func (r *Runtime) toSearchString(v Value, funcName string) valueString {
+	if r.isRegExp(v) {
+		r.typeErrorResult(true, "First argument to String.prototype.%s must not be a regular expression", funcName)
+	}
+	return v.ToString()
+}
+
+func (r *Runtime) stringproto_startsWith(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	s := call.This.ToString()
+	search := r.toSearchString(call.Argument(0), "startsWith")
+	l := s.length()
+	pos := min(max(call.Argument(1).ToInteger(), 0), l)
+	sl := search.length()
+	if pos+sl > l {
+		return valueFalse
+	}
+	return r.toBoolean(s.substring(pos, pos+sl).compareTo(search) == 0)
+}
+
+func (r *Runtime) stringproto_endsWith(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	s := call.This.ToString()
+	search := r.toSearchString(call.Argument(0), "endsWith")
+	l := s.length()
+	end := l
+	if pos := call.Argument(1); pos != _undefined {
+		end = min(max(pos.ToInteger(), 0), l)
+	}
+	start := end - search.length()
+	if start < 0 {
+		return valueFalse
+	}
+	return r.toBoolean(s.substring(start, end).compareTo(search) == 0)
+}
+
+func (r *Runtime) stringproto_includes(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	s := call.This.ToString()
+	search := r.toSearchString(call.Argument(0), "includes")
+	pos := min(max(call.Argument(1).ToInteger(), 0), s.length())
+	return r.toBoolean(s.index(search, pos) >= 0)
+}
+
+func (r *Runtime) stringproto_repeat(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	s := call.This.ToString()
+	n := call.Argument(0).ToNumber()
+	if f, ok := n.assertFloat(); ok && (math.IsInf(f, 0) || f < 0) {
+		panic(r.newError(r.global.RangeError, "Invalid count value"))
+	}
+	count := n.ToInteger()
+	if count < 0 {
+		panic(r.newError(r.global.RangeError, "Invalid count value"))
+	}
+	l := s.length()
+	if count == 0 || l == 0 {
+		return stringEmpty
+	}
+	if l > math.MaxInt32/count {
+		panic(r.newError(r.global.RangeError, "Invalid string length"))
+	}
+	r.vm.allocateString(s, l*count)
+	switch s := s.(type) {
+	case asciiString:
+		return asciiString(strings.Repeat(string(s), int(count)))
+	case unicodeString:
+		buf := make(unicodeString, 0, l*count)
+		for i := int64(0); i < count; i++ {
+			buf = append(buf, s...)
+		}
+		return buf
+	}
+	panic("unreachable")
+}
+
+// pad implements padStart() and padEnd(): the string is padded with repetitions of the fill string (a space by
+// default) to the given length.
+func (r *Runtime) pad(call FunctionCall, atStart bool) Value {
+	r.checkObjectCoercible(call.This)
+	s := call.This.ToString()
+	maxLength := toLength(call.Argument(0))
+	l := s.length()
+	if maxLength <= l {
+		return s
+	}
+	var filler valueString = asciiString(" ")
+	if arg := call.Argument(1); arg != _undefined {
+		filler = arg.ToString()
+	}
+	fl := filler.length()
+	if fl == 0 {
+		return s
+	}
+	fillLen := maxLength - l
+	if fillLen > math.MaxInt32 {
+		panic(r.newError(r.global.RangeError, "Invalid string length"))
+	}
+	r.vm.allocateString(filler, maxLength)
+	var fill valueString = stringEmpty
+	for fill.length()+fl <= fillLen {
+		fill = fill.concat(filler)
+	}
+	if rest := fillLen - fill.length(); rest > 0 {
+		fill = fill.concat(filler.substring(0, rest))
+	}
+	if atStart {
+		return fill.concat(s)
+	}
+	return s.concat(fill)
+}
+
+func (r *Runtime) stringproto_padStart(call FunctionCall) Value {
+	return r.pad(call, true)
+}
+
+func (r *Runtime) stringproto_padEnd(call FunctionCall) Value {
+	return r.pad(call, false)
+}
+
+func (r *Runtime) stringproto_codePointAt(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	s := call.This.ToString()
+	pos := call.Argument(0).ToInteger()
+	l := s.length()
+	if pos < 0 || pos >= l {
+		return _undefined
+	}
+	first := s.charAt(pos)
+	if utf16.IsSurrogate(first) && first < 0xDC00 && pos+1 < l {
+		if cp := utf16.DecodeRune(first, s.charAt(pos+1)); cp != utf8.RuneError {
+			return intToValue(int64(cp))
+		}
+	}
+	return intToValue(int64(first))
+}
+
+func (r *Runtime) stringproto_normalize(call FunctionCall) Value {
+	r.checkObjectCoercible(call.This)
+	s := call.This.ToString()
+	var form norm.Form
+	switch f := call.Argument(0); f {
+	case _undefined:
+		form = norm.NFC
+	default:
+		switch f.String() {
+		case "NFC":
+			form = norm.NFC
+		case "NFD":
+			form = norm.NFD
+		case "NFKC":
+			form = norm.NFKC
+		case "NFKD":
+			form = norm.NFKD
+		default:
+			panic(r.newError(r.global.RangeError, "The normalization form should be one of NFC, NFD, NFKC, NFKD."))
+		}
+	}
+	if _, ok := s.(asciiString); ok {
+		// ASCII strings are in all the normalization forms
+		return s
+	}
+	return newStringValue(form.String(s.String()))
+}
+
+func (r *Runtime) string_fromcodepoint(call FunctionCall) Value {
+	var buf []uint16
+	ascii := true
+	for _, arg := range call.Arguments {
+		num := arg.ToNumber()
+		cp := num.ToInteger()
+		if f, ok := num.assertFloat(); ok && float64(cp) != f || cp < 0 || cp > unicode.MaxRune {
+			panic(r.newError(r.global.RangeError, "Invalid code point %s", arg.String()))
+		}
+		if cp >= utf8.RuneSelf {
+			ascii = false
+		}
+		if cp > 0xFFFF {
+			c1, c2 := utf16.EncodeRune(rune(cp))
+			buf = append(buf, uint16(c1), uint16(c2))
+		} else {
+			buf = append(buf, uint16(cp))
+		}
+	}
+	if ascii {
+		b := make([]byte, len(buf))
+		for i, c := range buf {
+			b[i] = byte(c)
+		}
+		return asciiString(b)
+	}
+	return unicodeString(buf)
+}
+
+func (r *Runtime) string_raw(call FunctionCall) Value {
+	cooked := call.Argument(0).ToObject(r)
+	raw := nilSafe(cooked.self.getStr("raw")).ToObject(r)
+	literalSegments := toLength(raw.self.getStr("length"))
+	if literalSegments <= 0 {
+		return stringEmpty
+	}
+	var res valueString = stringEmpty
+	for i := int64(0); ; i++ {
+		res = res.concat(nilSafe(raw.self.get(intToValue(i))).ToString())
+		if i+1 == literalSegments {
+			return res
+		}
+		if i+1 < int64(len(call.Arguments)) {
+			res = res.concat(call.Arguments[i+1].ToString())
+		}
+	}
+}
+
 func (r *Runtime) initString() {
 	r.global.StringPrototype = r.builtin_newString([]Value{stringEmpty})
 
@@ -673,13 +918,24 @@ func (r *Runtime) initString() {
 	o._putProp("toUpperCase", r.newNativeFunc(r.stringproto_toUpperCase, nil, "toUpperCase", nil, 0), true, false, true)
 	o._putProp("toLocaleUpperCase", r.newNativeFunc(r.stringproto_toUpperCase, nil, "toLocaleUpperCase", nil, 0), true, false, true)
 	o._putProp("trim", r.newNativeFunc(r.stringproto_trim, nil, "trim", nil, 0), true, false, true)
+	o._putProp("startsWith", r.newNativeFunc(r.stringproto_startsWith, nil, "startsWith", nil, 1), true, false, true)
+	o._putProp("endsWith", r.newNativeFunc(r.stringproto_endsWith, nil, "endsWith", nil, 1), true, false, true)
+	o._putProp("includes", r.newNativeFunc(r.stringproto_includes, nil, "includes", nil, 1), true, false, true)
+	o._putProp("repeat", r.newNativeFunc(r.stringproto_repeat, nil, "repeat", nil, 1), true, false, true)
+	o._putProp("padStart", r.newNativeFunc(r.stringproto_padStart, nil, "padStart", nil, 1), true, false, true)
+	o._putProp("padEnd", r.newNativeFunc(r.stringproto_padEnd, nil, "padEnd", nil, 1), true, false, true)
+	o._putProp("codePointAt", r.newNativeFunc(r.stringproto_codePointAt, nil, "codePointAt", nil, 1), true, false, true)
+	o._putProp("normalize", r.newNativeFunc(r.stringproto_normalize, nil, "normalize", nil, 0), true, false, true)
 
 	// Annex B
 	o._putProp("substr", r.newNativeFunc(r.stringproto_substr, nil, "substr", nil, 2), true, false, true)
+	o._putSym(SymIterator, r.newNativeFunc(r.stringproto_iterator, nil, "[Symbol.iterator]", nil, 0), true, false, true)
 
 	r.global.String = r.newNativeFunc(r.builtin_String, r.builtin_newString, "String", r.global.StringPrototype, 1)
 	o = r.global.String.self
 	o._putProp("fromCharCode", r.newNativeFunc(r.string_fromcharcode, nil, "fromCharCode", nil, 1), true, false, true)
+	o._putProp("fromCodePoint", r.newNativeFunc(r.string_fromcodepoint, nil, "fromCodePoint", nil, 1), true, false, true)
+	o._putProp("raw", r.newNativeFunc(r.string_raw, nil, "raw", nil, 1), true, false, true)
 
 	r.addToGlobal("String", r.global.String)
 
@@ -88,3 +88,73 @@ assert.sameValue(
 
 	testScript1(TESTLIB+SCRIPT, _undefined, t)
 }
+
+func TestStringSearchPad(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue("abc".startsWith("b", 1), true, "startsWith");
+	assert.sameValue("abc".startsWith("abcd"), false, "startsWith: longer");
+	assert.sameValue("abc".endsWith("b", 2), true, "endsWith");
+	assert.sameValue("abc".endsWith("a", -1), false, "endsWith: negative");
+	assert.sameValue("aЖc".includes("Жc"), true, "includes");
+	assert.sameValue("abc".includes("a", 1), false, "includes: position");
+	var thrown = false;
+	try {
+		"/a/".startsWith(/a/);
+	} catch (e) {
+		thrown = e instanceof TypeError;
+	}
+	assert.sameValue(thrown, true, "RegExp argument");
+
+	assert.sameValue("ab".repeat(3), "ababab", "repeat");
+	assert.sameValue("Ж".repeat(2), "ЖЖ", "repeat: unicode");
+	assert.sameValue("".repeat(1e9), "", "repeat: empty");
+	thrown = false;
+	try {
+		"a".repeat(Infinity);
+	} catch (e) {
+		thrown = e instanceof RangeError;
+	}
+	assert.sameValue(thrown, true, "repeat: Infinity");
+
+	assert.sameValue("5".padStart(3, "0"), "005", "padStart");
+	assert.sameValue("abc".padEnd(8, "12"), "abc12121", "padEnd");
+	assert.sameValue("abc".padStart(5), "  abc", "padStart: default filler");
+	assert.sameValue("abc".padStart(5, ""), "abc", "padStart: empty filler");
+	assert.sameValue("abc".padEnd(2), "abc", "padEnd: shorter");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestStringCodePoints(t *testing.T) {
+	const SCRIPT = `
+	assert.sameValue("a😀".codePointAt(1), 0x1F600, "codePointAt");
+	assert.sameValue("a😀".codePointAt(2), 0xDE00, "codePointAt: low surrogate");
+	assert.sameValue("a".codePointAt(1), undefined, "codePointAt: out of range");
+	assert.sameValue(String.fromCodePoint(0x1F600, 65), "😀A", "fromCodePoint");
+	assert.sameValue(String.fromCodePoint(), "", "fromCodePoint: no arguments");
+	var thrown = false;
+	try {
+		String.fromCodePoint(0x110000);
+	} catch (e) {
+		thrown = e instanceof RangeError;
+	}
+	assert.sameValue(thrown, true, "fromCodePoint: invalid");
+
+	assert.sameValue("Å".normalize(), "Å", "normalize: NFC");
+	assert.sameValue("Å".normalize("NFD"), "Å", "normalize: NFD");
+	assert.sameValue("ﬁ".normalize("NFKC"), "fi", "normalize: NFKC");
+	thrown = false;
+	try {
+		"a".normalize("nfc");
+	} catch (e) {
+		thrown = e instanceof RangeError;
+	}
+	assert.sameValue(thrown, true, "normalize: invalid form");
+
+	assert.sameValue(String.raw({raw: ["a", "b", "c"]}, 1), "a1bc", "raw");
+	assert.sameValue(String.raw({raw: {length: 0}}), "", "raw: empty");
+	`
+
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
@@ -0,0 +1,129 @@
+package goja
+
+func (r *Runtime) builtin_symbol(call FunctionCall) Value {
+	var desc valueString
+	if arg := call.Argument(0); !IsUndefined(arg) {
+		desc = arg.ToString()
+	}
+	return newSymbol(desc)
+}
+
+func (r *Runtime) builtin_newSymbol(args []Value) *Object {
+	r.typeErrorResult(true, "Symbol is not a constructor")
+	panic("unreachable")
+}
+
+func (r *Runtime) thisSymbolValue(v Value) *Symbol {
+	switch t := v.(type) {
+	case *Symbol:
+		return t
+	case *Object:
+		if pVal, ok := t.self.(*primitiveValueObject); ok {
+			if sym, ok := pVal.pValue.(*Symbol); ok {
+				return sym
+			}
+		}
+	}
+	r.typeErrorResult(true, "Value is not a Symbol")
+	return nil
+}
+
+func (r *Runtime) symbolproto_tostring(call FunctionCall) Value {
+	sym := r.thisSymbolValue(call.This)
+	return sym.descString()
+}
+
+func (r *Runtime) symbolproto_valueOf(call FunctionCall) Value {
+	return r.thisSymbolValue(call.This)
+}
+
+func (r *Runtime) symbolproto_getDescription(call FunctionCall) Value {
+	sym := r.thisSymbolValue(call.This)
+	if sym.descriptor == nil {
+		return _undefined
+	}
+	return sym.descriptor
+}
+
+func (r *Runtime) symbol_for(call FunctionCall) Value {
+	key := call.Argument(0).String()
+	if v := r.symbolRegistry[key]; v != nil {
+		return v
+	}
+	if r.symbolRegistry == nil {
+		r.symbolRegistry = make(map[string]*Symbol)
+	}
+	v := NewSymbol(key)
+	r.symbolRegistry[key] = v
+	return v
+}
+
+func (r *Runtime) symbol_keyfor(call FunctionCall) Value {
+	arg := call.Argument(0)
+	sym, ok := arg.(*Symbol)
+	if !ok {
+		r.typeErrorResult(true, "%s is not a symbol", arg.String())
+	}
+	for key, s := range r.symbolRegistry {
+		if s == sym {
+			return r.ToValue(key)
+		}
+	}
+	return _undefined
+}
+
+func (r *Runtime) createSymbolProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.Symbol, true, false, true)
+	o._put("description", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.symbolproto_getDescription, nil, "get description", nil, 0),
+	})
+	o._putProp("toString", r.newNativeFunc(r.symbolproto_tostring, nil, "toString", nil, 0), true, false, true)
+	o._putProp("valueOf", r.newNativeFunc(r.symbolproto_valueOf, nil, "valueOf", nil, 0), true, false, true)
+	o._putSym(SymToPrimitive, r.newNativeFunc(r.symbolproto_valueOf, nil, "[Symbol.toPrimitive]", nil, 1), false, false, true)
+	o._putSym(SymToStringTag, asciiString(classSymbol), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) createSymbol(val *Object) objectImpl {
+	o := r.newNativeFuncObj(val, r.builtin_symbol, r.builtin_newSymbol, "Symbol", r.global.SymbolPrototype, 0)
+
+	o._putProp("for", r.newNativeFunc(r.symbol_for, nil, "for", nil, 1), true, false, true)
+	o._putProp("keyFor", r.newNativeFunc(r.symbol_keyfor, nil, "keyFor", nil, 1), true, false, true)
+
+	for _, s := range []*Symbol{
+		SymHasInstance,
+		SymIsConcatSpreadable,
+		SymIterator,
+		SymMatch,
+		SymReplace,
+		SymSearch,
+		SymSplit,
+		SymToPrimitive,
+		SymToStringTag,
+		SymUnscopables,
+	} {
+		n := s.descriptor.(asciiString)
+		n = n[len("Symbol."):]
+		o._putProp(string(n), s, false, false, false)
+	}
+
+	return o
+}
+
+func (r *Runtime) initSymbol() {
+	r.global.SymbolPrototype = r.newLazyObject(r.createSymbolProto)
+
+	r.global.Symbol = r.newLazyObject(r.createSymbol)
+	r.addToGlobal("Symbol", r.global.Symbol)
+}
@@ -0,0 +1,122 @@
+package goja
+
+import (
+	"testing"
+)
+
+func TestSymbolBasic(t *testing.T) {
+	const SCRIPT = `
+	var s = Symbol("foo");
+	var res = [];
+	res.push(typeof s, String(s), s.toString(), s.description, Symbol().description);
+	res.push(s === s, s !== Symbol("foo"), Object(s) == s, Object(s) instanceof Symbol);
+	res.push(Symbol.for("x") === Symbol.for("x"), Symbol.keyFor(Symbol.for("x")), Symbol.keyFor(s));
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("symbol,Symbol(foo),Symbol(foo),foo,,true,true,true,true,true,x,"), t)
+}
+
+func TestSymbolConversions(t *testing.T) {
+	const SCRIPT = `
+	var s = Symbol("foo");
+	function throwsTypeError(f) {
+		try {
+			f();
+		} catch (e) {
+			return e instanceof TypeError;
+		}
+		return false;
+	}
+	throwsTypeError(function() { return "" + s }) &&
+		throwsTypeError(function() { return +s }) &&
+		throwsTypeError(function() { return new Symbol() }) &&
+		throwsTypeError(function() { return Symbol.keyFor("foo") }) &&
+		!!s && JSON.stringify({a: s, b: 1}) === '{"b":1}';
+	`
+
+	testScript1(SCRIPT, valueTrue, t)
+}
+
+func TestSymbolProperties(t *testing.T) {
+	const SCRIPT = `
+	var s = Symbol("foo");
+	var o = {};
+	o[s] = 1;
+	var a = [1];
+	a[s] = 2;
+	var res = [o[s], s in o, o.hasOwnProperty(s), o.propertyIsEnumerable(s), Object.keys(o).length, a[s], a.length];
+	Object.defineProperty(o, s, {writable: false});
+	o[s] = 42;
+	res.push(o[s], Object.getOwnPropertyDescriptor(o, s).writable, delete o[s], o[s]);
+	var d = Object.getOwnPropertyDescriptor(Symbol, "iterator");
+	res.push(d.writable, d.enumerable, d.configurable);
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("1,true,true,true,0,2,1,1,false,true,,false,false,false"), t)
+}
+
+func TestSymbolToPrimitive(t *testing.T) {
+	const SCRIPT = `
+	var o = {};
+	o[Symbol.toPrimitive] = function(hint) {
+		return hint === "number" ? 42 : "hint:" + hint;
+	};
+	[+o, "" + o, String(o), o == "hint:default"].join();
+	`
+
+	testScript1(SCRIPT, asciiString("42,hint:default,hint:string,true"), t)
+}
+
+func TestSymbolHasInstance(t *testing.T) {
+	const SCRIPT = `
+	function Even() {}
+	Object.defineProperty(Even, Symbol.hasInstance, {
+		value: function(v) {
+			return v % 2 === 0;
+		}
+	});
+	2 instanceof Even && !(3 instanceof Even) && [] instanceof Array &&
+		Function.prototype[Symbol.hasInstance].call(Array, []) &&
+		!Function.prototype[Symbol.hasInstance].call({}, []);
+	`
+
+	testScript1(SCRIPT, valueTrue, t)
+}
+
+func TestSymbolToStringTag(t *testing.T) {
+	const SCRIPT = `
+	var o = {};
+	o[Symbol.toStringTag] = "Custom";
+	var toString = Object.prototype.toString;
+	[toString.call(o), toString.call(Symbol()), toString.call(Math), toString.call(JSON)].join();
+	`
+
+	testScript1(SCRIPT, asciiString("[object Custom],[object Symbol],[object Math],[object JSON]"), t)
+}
+
+func TestSymbolNativeProxy(t *testing.T) {
+	r := New()
+	target := r.NewObject()
+	proxy := r.NewProxy(target, &ProxyTrapConfig{
+		Get: func(target *Object, prop string, receiver *Object) Value {
+			return target.Get(prop)
+		},
+	}, false, false)
+	r.Set("proxy", proxy.proxy)
+	v, err := r.RunString(`
+	var s = Symbol();
+	proxy[s] = 42;
+	proxy[s] === 42 && s in proxy;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != valueTrue {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+	if target.self.get(r.Get("s")) != intToValue(42) {
+		t.Fatal("The symbol-keyed property was not set on the target")
+	}
+}
@@ -1,5 +1,212 @@
 package goja
 
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+const maxArrayBufferLength = math.MaxInt32
+
+// nativeEndian is the byte order of the host. Typed arrays use it so that their contents can be shared with Go slices.
+var nativeEndian binary.ByteOrder = func() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+type typedArrayKind struct {
+	name   string
+	size   int
+	goType reflect.Type
+	get    func(order binary.ByteOrder, b []byte) Value
+	set    func(order binary.ByteOrder, b []byte, v Value)
+	// alias returns a Go slice of n elements located at p
+	alias func(p unsafe.Pointer, n int) interface{}
+}
+
+var (
+	typedArrayInt8 = &typedArrayKind{
+		name:   "Int8Array",
+		size:   1,
+		goType: reflect.TypeOf([]int8(nil)),
+		get: func(_ binary.ByteOrder, b []byte) Value {
+			return intToValue(int64(int8(b[0])))
+		},
+		set: func(_ binary.ByteOrder, b []byte, v Value) {
+			b[0] = byte(toInt32(v))
+		},
+		alias: func(p unsafe.Pointer, n int) interface{} {
+			return (*[maxArrayBufferLength]int8)(p)[:n:n]
+		},
+	}
+	typedArrayUint8 = &typedArrayKind{
+		name:   "Uint8Array",
+		size:   1,
+		goType: reflect.TypeOf([]byte(nil)),
+		get: func(_ binary.ByteOrder, b []byte) Value {
+			return intToValue(int64(b[0]))
+		},
+		set: func(_ binary.ByteOrder, b []byte, v Value) {
+			b[0] = byte(toInt32(v))
+		},
+		alias: func(p unsafe.Pointer, n int) interface{} {
+			return (*[maxArrayBufferLength]byte)(p)[:n:n]
+		},
+	}
+	typedArrayUint8Clamped = &typedArrayKind{
+		name:   "Uint8ClampedArray",
+		size:   1,
+		goType: reflect.TypeOf([]byte(nil)),
+		get: func(_ binary.ByteOrder, b []byte) Value {
+			return intToValue(int64(b[0]))
+		},
+		set: func(_ binary.ByteOrder, b []byte, v Value) {
+			b[0] = toUint8Clamp(v)
+		},
+		alias: func(p unsafe.Pointer, n int) interface{} {
+			return (*[maxArrayBufferLength]byte)(p)[:n:n]
+		},
+	}
+	typedArrayInt16 = &typedArrayKind{
+		name:   "Int16Array",
+		size:   2,
+		goType: reflect.TypeOf([]int16(nil)),
+		get: func(order binary.ByteOrder, b []byte) Value {
+			return intToValue(int64(int16(order.Uint16(b))))
+		},
+		set: func(order binary.ByteOrder, b []byte, v Value) {
+			order.PutUint16(b, uint16(toInt32(v)))
+		},
+		alias: func(p unsafe.Pointer, n int) interface{} {
+			return (*[maxArrayBufferLength / 2]int16)(p)[:n:n]
+		},
+	}
+	typedArrayUint16 = &typedArrayKind{
+		name:   "Uint16Array",
+		size:   2,
+		goType: reflect.TypeOf([]uint16(nil)),
+		get: func(order binary.ByteOrder, b []byte) Value {
+			return intToValue(int64(order.Uint16(b)))
+		},
+		set: func(order binary.ByteOrder, b []byte, v Value) {
+			order.PutUint16(b, toUInt16(v))
+		},
+		alias: func(p unsafe.Pointer, n int) interface{} {
+			return (*[maxArrayBufferLength / 2]uint16)(p)[:n:n]
+		},
+	}
+	typedArrayInt32 = &typedArrayKind{
+		name:   "Int32Array",
+		size:   4,
+		goType: reflect.TypeOf([]int32(nil)),
+		get: func(order binary.ByteOrder, b []byte) Value {
+			return intToValue(int64(int32(order.Uint32(b))))
+		},
+		set: func(order binary.ByteOrder, b []byte, v Value) {
+			order.PutUint32(b, uint32(toInt32(v)))
+		},
+		alias: func(p unsafe.Pointer, n int) interface{} {
+			return (*[maxArrayBufferLength / 4]int32)(p)[:n:n]
+		},
+	}
+	typedArrayUint32 = &typedArrayKind{
+		name:   "Uint32Array",
+		size:   4,
+		goType: reflect.TypeOf([]uint32(nil)),
+		get: func(order binary.ByteOrder, b []byte) Value {
+			return intToValue(int64(order.Uint32(b)))
+		},
+		set: func(order binary.ByteOrder, b []byte, v Value) {
+			order.PutUint32(b, toUInt32(v))
+		},
+		alias: func(p unsafe.Pointer, n int) interface{} {
+			return (*[maxArrayBufferLength / 4]uint32)(p)[:n:n]
+		},
+	}
+	typedArrayFloat32 = &typedArrayKind{
+		name:   "Float32Array",
+		size:   4,
+		goType: reflect.TypeOf([]float32(nil)),
+		get: func(order binary.ByteOrder, b []byte) Value {
+			return floatToValue(float64(math.Float32frombits(order.Uint32(b))))
+		},
+		set: func(order binary.ByteOrder, b []byte, v Value) {
+			order.PutUint32(b, math.Float32bits(float32(v.ToFloat())))
+		},
+		alias: func(p unsafe.Pointer, n int) interface{} {
+			return (*[maxArrayBufferLength / 4]float32)(p)[:n:n]
+		},
+	}
+	typedArrayFloat64 = &typedArrayKind{
+		name:   "Float64Array",
+		size:   8,
+		goType: reflect.TypeOf([]float64(nil)),
+		get: func(order binary.ByteOrder, b []byte) Value {
+			return floatToValue(math.Float64frombits(order.Uint64(b)))
+		},
+		set: func(order binary.ByteOrder, b []byte, v Value) {
+			order.PutUint64(b, math.Float64bits(v.ToFloat()))
+		},
+		alias: func(p unsafe.Pointer, n int) interface{} {
+			return (*[maxArrayBufferLength / 8]float64)(p)[:n:n]
+		},
+	}
+)
+
+func toUint8Clamp(v Value) uint8 {
+	v = v.ToNumber()
+	if i, ok := v.assertInt(); ok {
+		if i < 0 {
+			return 0
+		}
+		if i > 255 {
+			return 255
+		}
+		return uint8(i)
+	}
+	f := v.ToFloat()
+	if math.IsNaN(f) || f <= 0 {
+		return 0
+	}
+	if f >= 255 {
+		return 255
+	}
+	return uint8(math.RoundToEven(f))
+}
+
+// exportSlice returns a Go slice which shares the memory with data. If data is not properly aligned for the element
+// type a copy is returned instead.
+func (k *typedArrayKind) exportSlice(data []byte) interface{} {
+	n := len(data) / k.size
+	if n == 0 {
+		return reflect.MakeSlice(k.goType, 0, 0).Interface()
+	}
+	p := unsafe.Pointer(&data[0])
+	if uintptr(p)%uintptr(k.size) != 0 {
+		s := reflect.MakeSlice(k.goType, n, n).Interface()
+		binary.Read(bytes.NewReader(data), nativeEndian, s)
+		return s
+	}
+	return k.alias(p, n)
+}
+
+// float64SliceBytes returns a byte slice which shares the memory with s.
+func float64SliceBytes(s []float64) []byte {
+	if len(s) == 0 {
+		return []byte{}
+	}
+	n := len(s) * 8
+	return (*[maxArrayBufferLength]byte)(unsafe.Pointer(&s[0]))[:n:n]
+}
+
 type objectArrayBuffer struct {
 	baseObject
 	data []byte
@@ -9,13 +216,298 @@ func (o *objectArrayBuffer) export() interface{} {
 	return o.data
 }
 
+func (o *objectArrayBuffer) exportType() reflect.Type {
+	return typedArrayUint8.goType
+}
+
+type typedArrayObject struct {
+	baseObject
+	kind   *typedArrayKind
+	buffer *objectArrayBuffer
+	offset int // in bytes
+	length int // in elements
+}
+
+type dataViewObject struct {
+	baseObject
+	buffer *objectArrayBuffer
+	offset int
+	length int
+}
+
+// export returns the bytes of the view, they share the memory with the buffer.
+func (d *dataViewObject) export() interface{} {
+	return d.buffer.data[d.offset : d.offset+d.length]
+}
+
+func (d *dataViewObject) exportType() reflect.Type {
+	return typedArrayUint8.goType
+}
+
+func (a *typedArrayObject) elem(idx int) []byte {
+	p := a.offset + idx*a.kind.size
+	return a.buffer.data[p : p+a.kind.size]
+}
+
+func (a *typedArrayObject) getIdx(idx int) Value {
+	return a.kind.get(nativeEndian, a.elem(idx))
+}
+
+func (a *typedArrayObject) setIdx(idx int, v Value) {
+	a.kind.set(nativeEndian, a.elem(idx), v)
+}
+
+func (a *typedArrayObject) bytes() []byte {
+	return a.buffer.data[a.offset : a.offset+a.length*a.kind.size]
+}
+
+// _get returns nil for non-index properties and _undefined for indexes that are out of range
+// (integer indexed properties never come from the prototype chain).
+func (a *typedArrayObject) _get(idx int64) Value {
+	if idx < 0 {
+		return nil
+	}
+	if idx < int64(a.length) {
+		return a.getIdx(int(idx))
+	}
+	return _undefined
+}
+
+func (a *typedArrayObject) get(n Value) Value {
+	if v := a._get(toIdx(n)); v != nil {
+		return v
+	}
+	return a.baseObject.get(n)
+}
+
+func (a *typedArrayObject) getStr(name string) Value {
+	if v := a._get(strToIdx(name)); v != nil {
+		return v
+	}
+	return a.baseObject.getStr(name)
+}
+
+func (a *typedArrayObject) getProp(n Value) Value {
+	if idx := toIdx(n); idx >= 0 {
+		if idx < int64(a.length) {
+			return a.getIdx(int(idx))
+		}
+		return nil
+	}
+	return a.baseObject.getProp(n)
+}
+
+func (a *typedArrayObject) getPropStr(name string) Value {
+	if idx := strToIdx(name); idx >= 0 {
+		if idx < int64(a.length) {
+			return a.getIdx(int(idx))
+		}
+		return nil
+	}
+	return a.baseObject.getPropStr(name)
+}
+
+func (a *typedArrayObject) getOwnProp(name string) Value {
+	if idx := strToIdx(name); idx >= 0 {
+		if idx < int64(a.length) {
+			return &valueProperty{
+				value:      a.getIdx(int(idx)),
+				writable:   true,
+				enumerable: true,
+			}
+		}
+		return nil
+	}
+	return a.baseObject.getOwnProp(name)
+}
+
+func (a *typedArrayObject) getOwnPropertyDescriptor(name string) Value {
+	if idx := strToIdx(name); idx >= 0 {
+		if idx >= int64(a.length) {
+			return _undefined
+		}
+		r := a.val.runtime
+		ret := r.NewObject()
+		obj := ret.self
+		obj.putStr("value", a.getIdx(int(idx)), false)
+		obj.putStr("writable", valueTrue, false)
+		obj.putStr("enumerable", valueTrue, false)
+		obj.putStr("configurable", valueFalse, false)
+		return ret
+	}
+	return a.baseObject.getOwnPropertyDescriptor(name)
+}
+
+func (a *typedArrayObject) putIdx(idx int64, v Value) {
+	if idx < int64(a.length) {
+		a.setIdx(int(idx), v)
+	} else {
+		// the value is still converted because the conversion may have side effects
+		v.ToNumber()
+	}
+}
+
+// isNumericKey reports whether name is a canonical numeric string (see CanonicalNumericIndexString in the
+// specification). Such keys are never stored as ordinary properties of a typed array, even if they are not valid
+// indices.
+func isNumericKey(name string) bool {
+	if name == "" {
+		return false
+	}
+	switch c := name[0]; {
+	case c >= '0' && c <= '9', c == '-', c == 'I', c == 'N':
+	default:
+		return false
+	}
+	return name == "-0" || newStringValue(name).ToNumber().String() == name
+}
+
+func (a *typedArrayObject) put(n Value, val Value, throw bool) {
+	if idx := toIdx(n); idx >= 0 {
+		a.putIdx(idx, val)
+		return
+	}
+	if _, ok := n.(*Symbol); !ok && isNumericKey(n.String()) {
+		val.ToNumber()
+		return
+	}
+	a.baseObject.put(n, val, throw)
+}
+
+func (a *typedArrayObject) putStr(name string, val Value, throw bool) {
+	if idx := strToIdx(name); idx >= 0 {
+		a.putIdx(idx, val)
+		return
+	}
+	if isNumericKey(name) {
+		val.ToNumber()
+		return
+	}
+	a.baseObject.putStr(name, val, throw)
+}
+
+func (a *typedArrayObject) hasProperty(n Value) bool {
+	return a.getProp(n) != nil
+}
+
+func (a *typedArrayObject) hasPropertyStr(name string) bool {
+	return a.getPropStr(name) != nil
+}
+
+func (a *typedArrayObject) hasOwnProperty(n Value) bool {
+	if _, ok := n.(*Symbol); ok {
+		return a.baseObject.hasOwnProperty(n)
+	}
+	return a.hasOwnPropertyStr(n.String())
+}
+
+func (a *typedArrayObject) hasOwnPropertyStr(name string) bool {
+	if idx := strToIdx(name); idx >= 0 {
+		return idx < int64(a.length)
+	}
+	return a.baseObject.hasOwnPropertyStr(name)
+}
+
+func (a *typedArrayObject) defineOwnProperty(n Value, descr PropertyDescriptor, throw bool) bool {
+	if idx := toIdx(n); idx >= 0 {
+		if idx >= int64(a.length) {
+			a.val.runtime.typeErrorResult(throw, "Invalid typed array index")
+			return false
+		}
+		if !a.val.runtime.checkHostObjectPropertyDescr(n.String(), descr, throw) {
+			return false
+		}
+		if descr.Value != nil {
+			a.setIdx(int(idx), descr.Value)
+		}
+		return true
+	}
+	if _, ok := n.(*Symbol); !ok && isNumericKey(n.String()) {
+		a.val.runtime.typeErrorResult(throw, "Invalid typed array index")
+		return false
+	}
+	return a.baseObject.defineOwnProperty(n, descr, throw)
+}
+
+func (a *typedArrayObject) deleteStr(name string, throw bool) bool {
+	if idx := strToIdx(name); idx >= 0 {
+		if idx < int64(a.length) {
+			a.val.runtime.typeErrorResult(throw, "Cannot delete property '%s' of %s", name, a.val.String())
+			return false
+		}
+		return true
+	}
+	return a.baseObject.deleteStr(name, throw)
+}
+
+func (a *typedArrayObject) delete(name Value, throw bool) bool {
+	if _, ok := name.(*Symbol); ok {
+		return a.baseObject.delete(name, throw)
+	}
+	return a.deleteStr(name.String(), throw)
+}
+
+type typedArrayPropIter struct {
+	a         *typedArrayObject
+	recursive bool
+	idx       int
+}
+
+func (i *typedArrayPropIter) next() (propIterItem, iterNextFunc) {
+	if i.idx < i.a.length {
+		name := strconv.Itoa(i.idx)
+		i.idx++
+		return propIterItem{name: name, enumerable: _ENUM_TRUE}, i.next
+	}
+
+	return i.a.baseObject._enumerate(i.recursive)()
+}
+
+func (a *typedArrayObject) enumerate(all, recursive bool) iterNextFunc {
+	return (&propFilterIter{
+		wrapped: a._enumerate(recursive),
+		all:     all,
+		seen:    make(map[string]bool),
+	}).next
+}
+
+func (a *typedArrayObject) _enumerate(recursive bool) iterNextFunc {
+	return (&typedArrayPropIter{
+		a:         a,
+		recursive: recursive,
+	}).next
+}
+
+func (a *typedArrayObject) export() interface{} {
+	return a.kind.exportSlice(a.bytes())
+}
+
+func (a *typedArrayObject) exportType() reflect.Type {
+	return a.kind.goType
+}
+
+func (a *typedArrayObject) sortLen() int64 {
+	return int64(a.length)
+}
+
+func (a *typedArrayObject) sortGet(i int64) Value {
+	return a.getIdx(int(i))
+}
+
+func (a *typedArrayObject) swap(i, j int64) {
+	x, y := a.elem(int(i)), a.elem(int(j))
+	for k := range x {
+		x[k], y[k] = y[k], x[k]
+	}
+}
+
 func (r *Runtime) _newArrayBuffer(proto *Object, o *Object) *objectArrayBuffer {
 	if o == nil {
 		o = &Object{runtime: r}
 	}
 	b := &objectArrayBuffer{
 		baseObject: baseObject{
-			class:      classObject,
+			class:      classArrayBuffer,
 			val:        o,
 			prototype:  proto,
 			extensible: true,
@@ -26,80 +518,828 @@ func (r *Runtime) _newArrayBuffer(proto *Object, o *Object) *objectArrayBuffer {
 	return b
 }
 
-func (r *Runtime) builtin_ArrayBuffer(args []Value, proto *Object) *Object {
-	b := r._newArrayBuffer(proto, nil)
-	if len(args) > 0 {
-		b.data = make([]byte, toLength(args[0]))
+func (r *Runtime) newArrayBufferData(data []byte) *objectArrayBuffer {
+	b := r._newArrayBuffer(r.global.ArrayBufferPrototype, nil)
+	b.data = data
+	return b
+}
+
+func (r *Runtime) newTypedArrayObject(kind *typedArrayKind, buffer *objectArrayBuffer, offset, length int, proto *Object) *typedArrayObject {
+	o := &Object{runtime: r}
+	a := &typedArrayObject{
+		baseObject: baseObject{
+			class:      kind.name,
+			val:        o,
+			prototype:  proto,
+			extensible: true,
+		},
+		kind:   kind,
+		buffer: buffer,
+		offset: offset,
+		length: length,
 	}
-	return b.val
+	o.self = a
+	a.init()
+	return a
 }
 
-func (r *Runtime) arrayBufferProto_getByteLength(call FunctionCall) Value {
-	o := r.toObject(call.This)
-	if b, ok := o.self.(*objectArrayBuffer); ok {
-		return intToValue(int64(len(b.data)))
+func (r *Runtime) typedArrayProto(kind *typedArrayKind) *Object {
+	switch kind {
+	case typedArrayInt8:
+		return r.global.Int8ArrayPrototype
+	case typedArrayUint8:
+		return r.global.Uint8ArrayPrototype
+	case typedArrayUint8Clamped:
+		return r.global.Uint8ClampedArrayPrototype
+	case typedArrayInt16:
+		return r.global.Int16ArrayPrototype
+	case typedArrayUint16:
+		return r.global.Uint16ArrayPrototype
+	case typedArrayInt32:
+		return r.global.Int32ArrayPrototype
+	case typedArrayUint32:
+		return r.global.Uint32ArrayPrototype
+	case typedArrayFloat32:
+		return r.global.Float32ArrayPrototype
+	case typedArrayFloat64:
+		return r.global.Float64ArrayPrototype
+	}
+	panic("Unknown typed array kind: " + kind.name)
+}
+
+func (r *Runtime) toIndex(v Value, msg string) int {
+	i := v.ToInteger()
+	if i < 0 || i > maxArrayBufferLength {
+		panic(r.newError(r.global.RangeError, msg))
+	}
+	return int(i)
+}
+
+func (r *Runtime) allocTypedArray(kind *typedArrayKind, length int, proto *Object) *typedArrayObject {
+	if length > maxArrayBufferLength/kind.size {
+		panic(r.newError(r.global.RangeError, "Invalid typed array length: %d", length))
 	}
-	r.typeErrorResult(true, "Object is not ArrayBuffer: %s", o)
+	r.vm.allocate(int64(length * kind.size))
+	return r.newTypedArrayObject(kind, r.newArrayBufferData(make([]byte, length*kind.size)), 0, length, proto)
+}
+
+func (r *Runtime) toArrayBuffer(v Value) *objectArrayBuffer {
+	if o, ok := v.(*Object); ok {
+		if b, ok := o.self.(*objectArrayBuffer); ok {
+			return b
+		}
+	}
+	r.typeErrorResult(true, "Object is not ArrayBuffer: %s", v)
 	panic("unreachable")
 }
 
-func (r *Runtime) arrayBufferProto_slice(call FunctionCall) Value {
-	o := r.toObject(call.This)
-	if b, ok := o.self.(*objectArrayBuffer); ok {
-		l := int64(len(b.data))
-		start := toLength(call.Argument(0))
-		if start < 0 {
-			start = l + start
-		}
-		if start < 0 {
-			start = 0
-		} else if start > l {
-			start = l
-		}
-		var stop int64
-		if arg := call.Argument(1); arg != _undefined {
-			stop = toLength(arg)
-			if stop < 0 {
-				stop = int64(len(b.data)) + stop
-			}
-			if stop < 0 {
-				stop = 0
-			} else if stop > l {
-				stop = l
-			}
+func (r *Runtime) toTypedArray(v Value) *typedArrayObject {
+	if o, ok := v.(*Object); ok {
+		if a, ok := o.self.(*typedArrayObject); ok {
+			return a
+		}
+	}
+	r.typeErrorResult(true, "Object is not a TypedArray: %s", v)
+	panic("unreachable")
+}
 
-		} else {
-			stop = l
+func (r *Runtime) toDataView(v Value) *dataViewObject {
+	if o, ok := v.(*Object); ok {
+		if d, ok := o.self.(*dataViewObject); ok {
+			return d
 		}
+	}
+	r.typeErrorResult(true, "Object is not DataView: %s", v)
+	panic("unreachable")
+}
 
-		ret := r._newArrayBuffer(r.global.ArrayBufferPrototype, nil)
+func nilSafe(v Value) Value {
+	if v == nil {
+		return _undefined
+	}
+	return v
+}
+
+func relToIdx(rel, l int64) int64 {
+	if rel < 0 {
+		return max(l+rel, 0)
+	}
+	return min(rel, l)
+}
 
-		if stop > start {
-			ret.data = b.data[start:stop]
+func (r *Runtime) builtin_ArrayBuffer(args []Value, proto *Object) *Object {
+	b := r._newArrayBuffer(proto, nil)
+	if len(args) > 0 {
+		length := r.toIndex(args[0], "Invalid array buffer length")
+		r.vm.allocate(int64(length))
+		b.data = make([]byte, length)
+	} else {
+		b.data = []byte{}
+	}
+	return b.val
+}
+
+func (r *Runtime) arrayBuffer_isView(call FunctionCall) Value {
+	if o, ok := call.Argument(0).(*Object); ok {
+		switch o.self.(type) {
+		case *typedArrayObject, *dataViewObject:
+			return valueTrue
 		}
+	}
+	return valueFalse
+}
+
+func (r *Runtime) arrayBufferProto_getByteLength(call FunctionCall) Value {
+	b := r.toArrayBuffer(call.This)
+	return intToValue(int64(len(b.data)))
+}
+
+func (r *Runtime) arrayBufferProto_slice(call FunctionCall) Value {
+	b := r.toArrayBuffer(call.This)
+	l := int64(len(b.data))
+	start := relToIdx(call.Argument(0).ToInteger(), l)
+	stop := l
+	if arg := call.Argument(1); arg != _undefined {
+		stop = relToIdx(arg.ToInteger(), l)
+	}
 
-		return ret.val
+	ret := r._newArrayBuffer(r.global.ArrayBufferPrototype, nil)
+	if stop > start {
+		r.vm.allocate(stop - start)
+		ret.data = make([]byte, stop-start)
+		copy(ret.data, b.data[start:stop])
+	} else {
+		ret.data = []byte{}
 	}
-	r.typeErrorResult(true, "Object is not ArrayBuffer: %s", o)
-	panic("unreachable")
+	return ret.val
 }
 
 func (r *Runtime) createArrayBufferProto(val *Object) objectImpl {
-	b := r._newArrayBuffer(r.global.Object, val)
+	b := r._newArrayBuffer(r.global.ObjectPrototype, val)
 	byteLengthProp := &valueProperty{
 		accessor:     true,
 		configurable: true,
 		getterFunc:   r.newNativeFunc(r.arrayBufferProto_getByteLength, nil, "get byteLength", nil, 0),
 	}
 	b._put("byteLength", byteLengthProp)
+	b._putProp("constructor", r.global.ArrayBuffer, true, false, true)
 	b._putProp("slice", r.newNativeFunc(r.arrayBufferProto_slice, nil, "slice", nil, 2), true, false, true)
 	return b
 }
 
+func (r *Runtime) createArrayBuffer(val *Object) objectImpl {
+	o := r.newNativeFuncConstructObj(val, r.builtin_ArrayBuffer, "ArrayBuffer", r.global.ArrayBufferPrototype, 1)
+	o._putProp("isView", r.newNativeFunc(r.arrayBuffer_isView, nil, "isView", nil, 1), true, false, true)
+	return o
+}
+
+// TypedArray constructors
+
+func (r *Runtime) typedArrayFromBuffer(kind *typedArrayKind, b *objectArrayBuffer, args []Value, proto *Object) *Object {
+	offset := 0
+	if len(args) > 1 {
+		offset = r.toIndex(args[1], "Invalid typed array offset")
+	}
+	if offset%kind.size != 0 {
+		panic(r.newError(r.global.RangeError, "Start offset of %s should be a multiple of %d", kind.name, kind.size))
+	}
+	bufLen := len(b.data)
+	var length int
+	if len(args) > 2 && args[2] != _undefined {
+		length = r.toIndex(args[2], "Invalid typed array length")
+		if offset+length*kind.size > bufLen {
+			panic(r.newError(r.global.RangeError, "Invalid typed array length: %d", length))
+		}
+	} else {
+		if bufLen%kind.size != 0 {
+			panic(r.newError(r.global.RangeError, "Byte length of %s should be a multiple of %d", kind.name, kind.size))
+		}
+		if offset > bufLen {
+			panic(r.newError(r.global.RangeError, "Start offset %d is outside the bounds of the buffer", offset))
+		}
+		length = (bufLen - offset) / kind.size
+	}
+	return r.newTypedArrayObject(kind, b, offset, length, proto).val
+}
+
+func (r *Runtime) newTypedArray(kind *typedArrayKind, args []Value, proto *Object) *Object {
+	if len(args) == 0 {
+		return r.allocTypedArray(kind, 0, proto).val
+	}
+	obj, ok := args[0].(*Object)
+	if !ok {
+		if args[0] == _undefined {
+			return r.allocTypedArray(kind, 0, proto).val
+		}
+		return r.allocTypedArray(kind, r.toIndex(args[0], "Invalid typed array length"), proto).val
+	}
+	switch src := obj.self.(type) {
+	case *objectArrayBuffer:
+		return r.typedArrayFromBuffer(kind, src, args, proto)
+	case *typedArrayObject:
+		a := r.allocTypedArray(kind, src.length, proto)
+		if src.kind == kind {
+			copy(a.bytes(), src.bytes())
+		} else {
+			for i := 0; i < src.length; i++ {
+				a.setIdx(i, src.getIdx(i))
+			}
+		}
+		return a.val
+	}
+	l := toLength(obj.self.getStr("length"))
+	if l > maxArrayBufferLength {
+		panic(r.newError(r.global.RangeError, "Invalid typed array length: %d", l))
+	}
+	a := r.allocTypedArray(kind, int(l), proto)
+	for i := 0; i < a.length; i++ {
+		a.setIdx(i, nilSafe(obj.self.get(intToValue(int64(i)))))
+	}
+	return a.val
+}
+
+func (r *Runtime) typedArray_constructCheck(call FunctionCall) Value {
+	r.typeErrorResult(true, "Constructor requires 'new'")
+	panic("unreachable")
+}
+
+func (r *Runtime) builtin_TypedArray(args []Value) *Object {
+	r.typeErrorResult(true, "Abstract class TypedArray not directly constructable")
+	panic("unreachable")
+}
+
+// newTypedArrayFrom calls the constructor c to create a typed array with the specified length.
+func (r *Runtime) newTypedArrayFrom(c Value, length int) *typedArrayObject {
+	ctor := r.toObject(c)
+	a := r.toTypedArray(r.builtin_new(ctor, []Value{intToValue(int64(length))}))
+	if a.length < length {
+		r.typeErrorResult(true, "Derived TypedArray constructor created an array which was too small")
+	}
+	return a
+}
+
+func (r *Runtime) typedArray_of(call FunctionCall) Value {
+	a := r.newTypedArrayFrom(call.This, len(call.Arguments))
+	for i, v := range call.Arguments {
+		a.setIdx(i, v)
+	}
+	return a.val
+}
+
+func (r *Runtime) typedArray_from(call FunctionCall) Value {
+	src := call.Argument(0).ToObject(r)
+	var mapFn func(FunctionCall) Value
+	if arg := call.Argument(1); arg != _undefined {
+		mapFn = r.toCallable(arg)
+	}
+	var values []Value
+	if s, ok := src.self.(*typedArrayObject); ok {
+		values = make([]Value, s.length)
+		for i := range values {
+			values[i] = s.getIdx(i)
+		}
+	} else {
+		l := toLength(src.self.getStr("length"))
+		if l > maxArrayBufferLength {
+			panic(r.newError(r.global.RangeError, "Invalid typed array length: %d", l))
+		}
+		values = make([]Value, l)
+		for i := range values {
+			values[i] = nilSafe(src.self.get(intToValue(int64(i))))
+		}
+	}
+	a := r.newTypedArrayFrom(call.This, len(values))
+	fc := FunctionCall{
+		This:      call.Argument(2),
+		Arguments: []Value{nil, nil},
+	}
+	for i, v := range values {
+		if mapFn != nil {
+			fc.Arguments[0] = v
+			fc.Arguments[1] = intToValue(int64(i))
+			v = mapFn(fc)
+		}
+		a.setIdx(i, v)
+	}
+	return a.val
+}
+
+// %TypedArray%.prototype
+
+func (r *Runtime) typedArrayProto_getBuffer(call FunctionCall) Value {
+	return r.toTypedArray(call.This).buffer.val
+}
+
+func (r *Runtime) typedArrayProto_getByteLength(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	return intToValue(int64(a.length * a.kind.size))
+}
+
+func (r *Runtime) typedArrayProto_getByteOffset(call FunctionCall) Value {
+	return intToValue(int64(r.toTypedArray(call.This).offset))
+}
+
+func (r *Runtime) typedArrayProto_getLength(call FunctionCall) Value {
+	return intToValue(int64(r.toTypedArray(call.This).length))
+}
+
+// typedArrayProto_generic wraps a generic Array.prototype method making sure it is called on a typed array.
+func (r *Runtime) typedArrayProto_generic(f func(FunctionCall) Value) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		r.toTypedArray(call.This)
+		return f(call)
+	}
+}
+
+func (r *Runtime) typedArrayProto_set(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	offset := call.Argument(1).ToInteger()
+	if offset < 0 {
+		panic(r.newError(r.global.RangeError, "Offset is out of bounds"))
+	}
+	src := call.Argument(0).ToObject(r)
+	if s, ok := src.self.(*typedArrayObject); ok {
+		if int64(s.length)+offset > int64(a.length) {
+			panic(r.newError(r.global.RangeError, "Source is too large"))
+		}
+		if s.kind == a.kind {
+			copy(a.bytes()[int(offset)*a.kind.size:], s.bytes())
+			return _undefined
+		}
+		// the source may share the buffer, so all values are read first
+		values := make([]Value, s.length)
+		for i := range values {
+			values[i] = s.getIdx(i)
+		}
+		for i, v := range values {
+			a.setIdx(int(offset)+i, v)
+		}
+		return _undefined
+	}
+	l := toLength(src.self.getStr("length"))
+	if l+offset > int64(a.length) {
+		panic(r.newError(r.global.RangeError, "Source is too large"))
+	}
+	for i := int64(0); i < l; i++ {
+		a.setIdx(int(offset+i), nilSafe(src.self.get(intToValue(i))))
+	}
+	return _undefined
+}
+
+func (r *Runtime) typedArrayProto_subarray(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	l := int64(a.length)
+	begin := relToIdx(call.Argument(0).ToInteger(), l)
+	end := l
+	if arg := call.Argument(1); arg != _undefined {
+		end = relToIdx(arg.ToInteger(), l)
+	}
+	if end < begin {
+		end = begin
+	}
+	return r.newTypedArrayObject(a.kind, a.buffer, a.offset+int(begin)*a.kind.size, int(end-begin), r.typedArrayProto(a.kind)).val
+}
+
+func (r *Runtime) typedArrayProto_slice(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	l := int64(a.length)
+	start := relToIdx(call.Argument(0).ToInteger(), l)
+	end := l
+	if arg := call.Argument(1); arg != _undefined {
+		end = relToIdx(arg.ToInteger(), l)
+	}
+	if end < start {
+		end = start
+	}
+	ret := r.allocTypedArray(a.kind, int(end-start), r.typedArrayProto(a.kind))
+	copy(ret.bytes(), a.bytes()[int(start)*a.kind.size:int(end)*a.kind.size])
+	return ret.val
+}
+
+func (r *Runtime) typedArrayProto_fill(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	l := int64(a.length)
+	value := call.Argument(0).ToNumber()
+	start := relToIdx(call.Argument(1).ToInteger(), l)
+	end := l
+	if arg := call.Argument(2); arg != _undefined {
+		end = relToIdx(arg.ToInteger(), l)
+	}
+	for i := start; i < end; i++ {
+		a.setIdx(int(i), value)
+	}
+	return a.val
+}
+
+func (r *Runtime) typedArrayProto_copyWithin(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	l := int64(a.length)
+	to := relToIdx(call.Argument(0).ToInteger(), l)
+	from := relToIdx(call.Argument(1).ToInteger(), l)
+	end := l
+	if arg := call.Argument(2); arg != _undefined {
+		end = relToIdx(arg.ToInteger(), l)
+	}
+	if count := min(end-from, l-to); count > 0 {
+		data, size := a.bytes(), int64(a.kind.size)
+		copy(data[to*size:], data[from*size:(from+count)*size])
+	}
+	return a.val
+}
+
+func (r *Runtime) typedArrayProto_includes(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	l := int64(a.length)
+	if l == 0 {
+		return valueFalse
+	}
+	n := relToIdx(call.Argument(1).ToInteger(), l)
+	search := call.Argument(0)
+	searchNaN := false
+	if f, ok := search.assertFloat(); ok && math.IsNaN(f) {
+		searchNaN = true
+	}
+	for ; n < l; n++ {
+		v := a.getIdx(int(n))
+		if searchNaN {
+			if f, ok := v.assertFloat(); ok && math.IsNaN(f) {
+				return valueTrue
+			}
+		} else if search.StrictEquals(v) {
+			return valueTrue
+		}
+	}
+	return valueFalse
+}
+
+func (r *Runtime) typedArrayProto_find(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	callbackFn := r.toCallable(call.Argument(0))
+	fc := FunctionCall{
+		This:      call.Argument(1),
+		Arguments: []Value{nil, nil, a.val},
+	}
+	for k := 0; k < a.length; k++ {
+		val := a.getIdx(k)
+		fc.Arguments[0] = val
+		fc.Arguments[1] = intToValue(int64(k))
+		if callbackFn(fc).ToBoolean() {
+			return val
+		}
+	}
+	return _undefined
+}
+
+func (r *Runtime) typedArrayProto_findIndex(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	callbackFn := r.toCallable(call.Argument(0))
+	fc := FunctionCall{
+		This:      call.Argument(1),
+		Arguments: []Value{nil, nil, a.val},
+	}
+	for k := 0; k < a.length; k++ {
+		fc.Arguments[0] = a.getIdx(k)
+		fc.Arguments[1] = intToValue(int64(k))
+		if callbackFn(fc).ToBoolean() {
+			return fc.Arguments[1]
+		}
+	}
+	return intToValue(-1)
+}
+
+func (r *Runtime) typedArrayProto_map(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	callbackFn := r.toCallable(call.Argument(0))
+	fc := FunctionCall{
+		This:      call.Argument(1),
+		Arguments: []Value{nil, nil, a.val},
+	}
+	ret := r.allocTypedArray(a.kind, a.length, r.typedArrayProto(a.kind))
+	for k := 0; k < a.length; k++ {
+		fc.Arguments[0] = a.getIdx(k)
+		fc.Arguments[1] = intToValue(int64(k))
+		ret.setIdx(k, callbackFn(fc))
+	}
+	return ret.val
+}
+
+func (r *Runtime) typedArrayProto_filter(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	callbackFn := r.toCallable(call.Argument(0))
+	fc := FunctionCall{
+		This:      call.Argument(1),
+		Arguments: []Value{nil, nil, a.val},
+	}
+	var kept []Value
+	for k := 0; k < a.length; k++ {
+		val := a.getIdx(k)
+		fc.Arguments[0] = val
+		fc.Arguments[1] = intToValue(int64(k))
+		if callbackFn(fc).ToBoolean() {
+			kept = append(kept, val)
+		}
+	}
+	ret := r.allocTypedArray(a.kind, len(kept), r.typedArrayProto(a.kind))
+	for i, v := range kept {
+		ret.setIdx(i, v)
+	}
+	return ret.val
+}
+
+func (r *Runtime) typedArrayProto_reverse(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	for i, j := 0, a.length-1; i < j; i, j = i+1, j-1 {
+		a.swap(int64(i), int64(j))
+	}
+	return a.val
+}
+
+type typedArraySortCtx struct {
+	a       *typedArrayObject
+	compare func(FunctionCall) Value
+}
+
+func (ctx *typedArraySortCtx) Len() int {
+	return ctx.a.length
+}
+
+func (ctx *typedArraySortCtx) Less(j, k int) bool {
+	x, y := ctx.a.getIdx(j), ctx.a.getIdx(k)
+	if ctx.compare != nil {
+		return ctx.compare(FunctionCall{
+			This:      _undefined,
+			Arguments: []Value{x, y},
+		}).ToFloat() < 0
+	}
+	xf, yf := x.ToFloat(), y.ToFloat()
+	if math.IsNaN(xf) {
+		return false
+	}
+	if math.IsNaN(yf) {
+		return true
+	}
+	if xf == 0 && yf == 0 {
+		return math.Signbit(xf) && !math.Signbit(yf)
+	}
+	return xf < yf
+}
+
+func (ctx *typedArraySortCtx) Swap(j, k int) {
+	ctx.a.swap(int64(j), int64(k))
+}
+
+func (r *Runtime) typedArrayProto_sort(call FunctionCall) Value {
+	a := r.toTypedArray(call.This)
+	ctx := typedArraySortCtx{
+		a: a,
+	}
+	if arg := call.Argument(0); arg != _undefined {
+		ctx.compare = r.toCallable(arg)
+	}
+	sort.Stable(&ctx)
+	return a.val
+}
+
+func (r *Runtime) createTypedArrayProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.TypedArray, true, false, true)
+	o._put("buffer", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.typedArrayProto_getBuffer, nil, "get buffer", nil, 0),
+	})
+	o._put("byteLength", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.typedArrayProto_getByteLength, nil, "get byteLength", nil, 0),
+	})
+	o._put("byteOffset", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.typedArrayProto_getByteOffset, nil, "get byteOffset", nil, 0),
+	})
+	o._put("length", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.typedArrayProto_getLength, nil, "get length", nil, 0),
+	})
+	o._putProp("copyWithin", r.newNativeFunc(r.typedArrayProto_copyWithin, nil, "copyWithin", nil, 2), true, false, true)
+	o._putProp("entries", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_entries), nil, "entries", nil, 0), true, false, true)
+	o._putProp("every", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_every), nil, "every", nil, 1), true, false, true)
+	o._putProp("fill", r.newNativeFunc(r.typedArrayProto_fill, nil, "fill", nil, 1), true, false, true)
+	o._putProp("filter", r.newNativeFunc(r.typedArrayProto_filter, nil, "filter", nil, 1), true, false, true)
+	o._putProp("find", r.newNativeFunc(r.typedArrayProto_find, nil, "find", nil, 1), true, false, true)
+	o._putProp("findIndex", r.newNativeFunc(r.typedArrayProto_findIndex, nil, "findIndex", nil, 1), true, false, true)
+	o._putProp("forEach", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_forEach), nil, "forEach", nil, 1), true, false, true)
+	o._putProp("includes", r.newNativeFunc(r.typedArrayProto_includes, nil, "includes", nil, 1), true, false, true)
+	o._putProp("indexOf", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_indexOf), nil, "indexOf", nil, 1), true, false, true)
+	o._putProp("join", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_join), nil, "join", nil, 1), true, false, true)
+	o._putProp("keys", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_keys), nil, "keys", nil, 0), true, false, true)
+	o._putProp("lastIndexOf", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_lastIndexOf), nil, "lastIndexOf", nil, 1), true, false, true)
+	o._putProp("map", r.newNativeFunc(r.typedArrayProto_map, nil, "map", nil, 1), true, false, true)
+	o._putProp("reduce", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_reduce), nil, "reduce", nil, 1), true, false, true)
+	o._putProp("reduceRight", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_reduceRight), nil, "reduceRight", nil, 1), true, false, true)
+	o._putProp("reverse", r.newNativeFunc(r.typedArrayProto_reverse, nil, "reverse", nil, 0), true, false, true)
+	o._putProp("set", r.newNativeFunc(r.typedArrayProto_set, nil, "set", nil, 1), true, false, true)
+	o._putProp("slice", r.newNativeFunc(r.typedArrayProto_slice, nil, "slice", nil, 2), true, false, true)
+	o._putProp("some", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_some), nil, "some", nil, 1), true, false, true)
+	o._putProp("sort", r.newNativeFunc(r.typedArrayProto_sort, nil, "sort", nil, 1), true, false, true)
+	o._putProp("subarray", r.newNativeFunc(r.typedArrayProto_subarray, nil, "subarray", nil, 2), true, false, true)
+	o._putProp("toLocaleString", r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_toLocaleString), nil, "toLocaleString", nil, 0), true, false, true)
+	o._putProp("toString", r.global.ArrayPrototype.self.getStr("toString"), true, false, true)
+	values := r.newNativeFunc(r.typedArrayProto_generic(r.arrayproto_values), nil, "values", nil, 0)
+	o._putProp("values", values, true, false, true)
+	o._putSym(SymIterator, values, true, false, true)
+
+	return o
+}
+
+func (r *Runtime) createTypedArray(val *Object) objectImpl {
+	o := r.newNativeFuncObj(val, r.typedArray_constructCheck, r.builtin_TypedArray, "TypedArray", r.global.TypedArrayPrototype, 0)
+	o._putProp("from", r.newNativeFunc(r.typedArray_from, nil, "from", nil, 1), true, false, true)
+	o._putProp("of", r.newNativeFunc(r.typedArray_of, nil, "of", nil, 0), true, false, true)
+	return o
+}
+
+func (r *Runtime) initTypedArrayKind(kind *typedArrayKind) (ctor, proto *Object) {
+	bytesPerElement := intToValue(int64(kind.size))
+	proto = r.newLazyObject(func(val *Object) objectImpl {
+		o := &baseObject{
+			class:      classObject,
+			val:        val,
+			extensible: true,
+			prototype:  r.global.TypedArrayPrototype,
+		}
+		o.init()
+		o._putProp("constructor", ctor, true, false, true)
+		o._putProp("BYTES_PER_ELEMENT", bytesPerElement, false, false, false)
+		return o
+	})
+	ctor = r.newLazyObject(func(val *Object) objectImpl {
+		o := r.newNativeFuncObj(val, r.typedArray_constructCheck, func(args []Value) *Object {
+			return r.newTypedArray(kind, args, proto)
+		}, kind.name, proto, 3)
+		o.prototype = r.global.TypedArray
+		o._putProp("BYTES_PER_ELEMENT", bytesPerElement, false, false, false)
+		return o
+	})
+	r.addToGlobal(kind.name, ctor)
+	return
+}
+
+// DataView
+
+func (r *Runtime) builtin_DataView(args []Value, proto *Object) *Object {
+	var bufArg Value = _undefined
+	if len(args) > 0 {
+		bufArg = args[0]
+	}
+	b := r.toArrayBuffer(bufArg)
+	offset := 0
+	if len(args) > 1 {
+		offset = r.toIndex(args[1], "Start offset is outside the bounds of the buffer")
+	}
+	bufLen := len(b.data)
+	if offset > bufLen {
+		panic(r.newError(r.global.RangeError, "Start offset %d is outside the bounds of the buffer", offset))
+	}
+	length := bufLen - offset
+	if len(args) > 2 && args[2] != _undefined {
+		length = r.toIndex(args[2], "Invalid DataView length")
+		if offset+length > bufLen {
+			panic(r.newError(r.global.RangeError, "Invalid DataView length %d", length))
+		}
+	}
+	o := &Object{runtime: r}
+	d := &dataViewObject{
+		baseObject: baseObject{
+			class:      classDataView,
+			val:        o,
+			prototype:  proto,
+			extensible: true,
+		},
+		buffer: b,
+		offset: offset,
+		length: length,
+	}
+	o.self = d
+	d.init()
+	return o
+}
+
+func (d *dataViewObject) viewBytes(r *Runtime, idx Value, size int) []byte {
+	i := idx.ToInteger()
+	if i < 0 || i+int64(size) > int64(d.length) {
+		panic(r.newError(r.global.RangeError, "Offset is outside the bounds of the DataView"))
+	}
+	p := d.offset + int(i)
+	return d.buffer.data[p : p+size]
+}
+
+func byteOrder(littleEndian Value) binary.ByteOrder {
+	if littleEndian.ToBoolean() {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+func (r *Runtime) dataViewProto_get(kind *typedArrayKind) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		d := r.toDataView(call.This)
+		return kind.get(byteOrder(call.Argument(1)), d.viewBytes(r, call.Argument(0), kind.size))
+	}
+}
+
+func (r *Runtime) dataViewProto_set(kind *typedArrayKind) func(FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		d := r.toDataView(call.This)
+		value := call.Argument(1).ToNumber()
+		kind.set(byteOrder(call.Argument(2)), d.viewBytes(r, call.Argument(0), kind.size), value)
+		return _undefined
+	}
+}
+
+func (r *Runtime) dataViewProto_getBuffer(call FunctionCall) Value {
+	return r.toDataView(call.This).buffer.val
+}
+
+func (r *Runtime) dataViewProto_getByteLength(call FunctionCall) Value {
+	return intToValue(int64(r.toDataView(call.This).length))
+}
+
+func (r *Runtime) dataViewProto_getByteOffset(call FunctionCall) Value {
+	return intToValue(int64(r.toDataView(call.This).offset))
+}
+
+func (r *Runtime) createDataViewProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.DataView, true, false, true)
+	o._put("buffer", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.dataViewProto_getBuffer, nil, "get buffer", nil, 0),
+	})
+	o._put("byteLength", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.dataViewProto_getByteLength, nil, "get byteLength", nil, 0),
+	})
+	o._put("byteOffset", &valueProperty{
+		accessor:     true,
+		configurable: true,
+		getterFunc:   r.newNativeFunc(r.dataViewProto_getByteOffset, nil, "get byteOffset", nil, 0),
+	})
+	for _, kind := range []*typedArrayKind{typedArrayInt8, typedArrayUint8, typedArrayInt16, typedArrayUint16,
+		typedArrayInt32, typedArrayUint32, typedArrayFloat32, typedArrayFloat64} {
+		name := strings.TrimSuffix(kind.name, "Array")
+		o._putProp("get"+name, r.newNativeFunc(r.dataViewProto_get(kind), nil, "get"+name, nil, 1), true, false, true)
+		o._putProp("set"+name, r.newNativeFunc(r.dataViewProto_set(kind), nil, "set"+name, nil, 2), true, false, true)
+	}
+
+	return o
+}
+
+func (r *Runtime) createDataView(val *Object) objectImpl {
+	o := r.newNativeFuncObj(val, r.typedArray_constructCheck, func(args []Value) *Object {
+		return r.builtin_DataView(args, r.global.DataViewPrototype)
+	}, "DataView", r.global.DataViewPrototype, 3)
+	return o
+}
+
 func (r *Runtime) initTypedArrays() {
 
 	r.global.ArrayBufferPrototype = r.newLazyObject(r.createArrayBufferProto)
-
-	r.global.ArrayBuffer = r.newNativeFuncConstruct(r.builtin_ArrayBuffer, "ArrayBuffer", r.global.ArrayBufferPrototype, 1)
+	r.global.ArrayBuffer = r.newLazyObject(r.createArrayBuffer)
 	r.addToGlobal("ArrayBuffer", r.global.ArrayBuffer)
+
+	r.global.DataViewPrototype = r.newLazyObject(r.createDataViewProto)
+	r.global.DataView = r.newLazyObject(r.createDataView)
+	r.addToGlobal("DataView", r.global.DataView)
+
+	r.global.TypedArrayPrototype = r.newLazyObject(r.createTypedArrayProto)
+	r.global.TypedArray = r.newLazyObject(r.createTypedArray)
+
+	r.global.Int8Array, r.global.Int8ArrayPrototype = r.initTypedArrayKind(typedArrayInt8)
+	r.global.Uint8Array, r.global.Uint8ArrayPrototype = r.initTypedArrayKind(typedArrayUint8)
+	r.global.Uint8ClampedArray, r.global.Uint8ClampedArrayPrototype = r.initTypedArrayKind(typedArrayUint8Clamped)
+	r.global.Int16Array, r.global.Int16ArrayPrototype = r.initTypedArrayKind(typedArrayInt16)
+	r.global.Uint16Array, r.global.Uint16ArrayPrototype = r.initTypedArrayKind(typedArrayUint16)
+	r.global.Int32Array, r.global.Int32ArrayPrototype = r.initTypedArrayKind(typedArrayInt32)
+	r.global.Uint32Array, r.global.Uint32ArrayPrototype = r.initTypedArrayKind(typedArrayUint32)
+	r.global.Float32Array, r.global.Float32ArrayPrototype = r.initTypedArrayKind(typedArrayFloat32)
+	r.global.Float64Array, r.global.Float64ArrayPrototype = r.initTypedArrayKind(typedArrayFloat64)
 }
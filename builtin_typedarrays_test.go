@@ -1,6 +1,9 @@
 package goja
 
-/*
+import (
+	"testing"
+)
+
 func TestArrayBufferNew(t *testing.T) {
 	const SCRIPT = `
 	var b = new ArrayBuffer(16);
@@ -9,4 +12,277 @@ func TestArrayBufferNew(t *testing.T) {
 
 	testScript1(SCRIPT, intToValue(16), t)
 }
-*/
+
+func TestArrayBufferSlice(t *testing.T) {
+	const SCRIPT = `
+	var b = new ArrayBuffer(8);
+	var a = new Uint8Array(b);
+	a.set([1, 2, 3, 4, 5, 6, 7, 8]);
+	var s = b.slice(-4, -1);
+	var sa = new Uint8Array(s);
+	sa[0] = 42;
+	s.byteLength === 3 && sa.join() === "42,6,7" && a[4] === 5 && ArrayBuffer.isView(sa) && !ArrayBuffer.isView(b);
+	`
+
+	testScript1(SCRIPT, valueTrue, t)
+}
+
+func TestTypedArrayConversions(t *testing.T) {
+	const SCRIPT = `
+	var res = [];
+	res.push(new Int8Array([127, 128, -129, 1.7]).join());
+	res.push(new Uint8Array([256, -1, 3.9]).join());
+	res.push(new Uint8ClampedArray([300, -5, 1.5, 2.5, NaN]).join());
+	res.push(new Int16Array([32768, -32769]).join());
+	res.push(new Uint16Array([65536, -1]).join());
+	res.push(new Int32Array([2147483648, -1]).join());
+	res.push(new Uint32Array([-1, 4294967296]).join());
+	res.push(new Float32Array([1.1])[0] !== 1.1);
+	res.push(new Float64Array([1.1, NaN]).join());
+	res.join("|");
+	`
+
+	testScript1(SCRIPT, asciiString("127,-128,127,1|0,255,3|255,0,2,2,0|-32768,32767|0,65535|-2147483648,-1|4294967295,0|true|1.1,NaN"), t)
+}
+
+func TestTypedArraySharedBuffer(t *testing.T) {
+	const SCRIPT = `
+	var b = new ArrayBuffer(16);
+	var f = new Float64Array(b);
+	var u = new Uint8Array(b, 8, 4);
+	var i = new Int32Array(b, 4);
+	f[1] = 1;
+	u[0] = 1;
+	var sub = i.subarray(1, -1);
+	sub[0] = 7;
+	[u.byteOffset, u.length, i.length, sub.byteOffset, sub.length, u.buffer === b, f[1] !== 1, Int32Array.BYTES_PER_ELEMENT,
+	 i.BYTES_PER_ELEMENT, u[5], 5 in u, "0" in u, Object.keys(u).join()].join();
+	`
+
+	testScript1(SCRIPT, asciiString("8,4,3,8,1,true,true,4,4,,false,true,0,1,2,3"), t)
+}
+
+func TestTypedArrayMethods(t *testing.T) {
+	const SCRIPT = `
+	var a = new Int16Array([5, 1, 4, 2, 3]);
+	var res = [];
+	res.push(a.map(function(v) { return v * 2; }).join());
+	res.push(a.filter(function(v) { return v % 2; }).join());
+	res.push(a.reduce(function(acc, v) { return acc + v; }, 0));
+	res.push(a.indexOf(4), a.lastIndexOf(4), a.includes(2), a.find(function(v) { return v > 3; }), a.findIndex(function(v) { return v > 5; }));
+	res.push(a.slice(1, 3).join(), a.slice(1, 3) instanceof Int16Array);
+	res.push(a.sort().join(), a.reverse().join());
+	res.push(a.sort(function(x, y) { return y - x; }).join());
+	res.push(new Float64Array([3, NaN, -0, 0, -1]).sort().join());
+	res.push(new Uint8Array(5).fill(9, 1, -1).join());
+	res.push(new Int8Array([1, 2, 3, 4, 5]).copyWithin(0, 3).join());
+	res.push(Uint8Array.of(1, 2, 300).join(), Int8Array.from([1, 2], function(v) { return -v; }).join());
+	res.push(Object.prototype.toString.call(a), String(a));
+	res.join("|");
+	`
+
+	testScript1(SCRIPT, asciiString("10,2,8,4,6|5,1,3|15|2|2|true|5|-1|1,4|true|1,2,3,4,5|5,4,3,2,1|5,4,3,2,1|-1,0,0,3,NaN|0,9,9,9,0|4,5,3,4,5|1,2,44|-1,-2|[object Int16Array]|5,4,3,2,1"), t)
+}
+
+func TestTypedArraySet(t *testing.T) {
+	const SCRIPT = `
+	var b = new ArrayBuffer(8);
+	var u8 = new Uint8Array(b);
+	u8.set([1, 2, 3, 4, 5, 6, 7, 8]);
+	var u16 = new Uint16Array(b);
+	var u8s = new Uint8Array(b, 2, 4);
+	// overlapping source of a different type
+	u16.set(u8s);
+	var res = [u16.join()];
+	try {
+		u8.set([1, 2], 7);
+	} catch (e) {
+		res.push(e instanceof RangeError);
+	}
+	res.join("|");
+	`
+
+	testScript1(SCRIPT, asciiString("3,4,5,6|true"), t)
+}
+
+func TestTypedArrayNumericKeys(t *testing.T) {
+	const SCRIPT = `
+	var a = new Uint8Array(3);
+	a["1.5"] = 3;
+	a["-0"] = 3;
+	a["-1"] = 3;
+	a["NaN"] = 3;
+	a["Infinity"] = 3;
+	a[3] = 3;
+	a["01"] = 3;
+	a.foo = 3;
+	var res = [Object.keys(a).join(), a["1.5"], "1.5" in a, a[1]];
+	res.push(Reflect.defineProperty(a, "1.5", {value: 1}));
+	try {
+		Object.defineProperty(a, "-0", {value: 1});
+		res.push("no error");
+	} catch (e) {
+		res.push(e instanceof TypeError);
+	}
+	res.push(Reflect.defineProperty(a, "1.0", {value: 1}));
+	res.join("|");
+	`
+
+	testScript1(SCRIPT, asciiString("0,1,2,01,foo||false|0|false|true|true"), t)
+}
+
+func TestTypedArrayErrors(t *testing.T) {
+	const SCRIPT = `
+	var res = [];
+	function check(f, errType) {
+		try {
+			f();
+			res.push("no error");
+		} catch (e) {
+			res.push(e instanceof errType);
+		}
+	}
+	check(function() { new Int32Array(new ArrayBuffer(8), 2); }, RangeError);
+	check(function() { new Int32Array(new ArrayBuffer(7)); }, RangeError);
+	check(function() { new Int32Array(new ArrayBuffer(8), 4, 2); }, RangeError);
+	check(function() { new Uint8Array(-1); }, RangeError);
+	check(function() { Uint8Array(1); }, TypeError);
+	check(function() { Int8Array.prototype.fill.call([1], 0); }, TypeError);
+	check(function() { new DataView({}); }, TypeError);
+	check(function() { new DataView(new ArrayBuffer(4)).getInt32(1); }, RangeError);
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("true,true,true,true,true,true,true,true"), t)
+}
+
+func TestDataView(t *testing.T) {
+	const SCRIPT = `
+	var b = new ArrayBuffer(16);
+	var d = new DataView(b, 2, 12);
+	d.setUint16(0, 0x1234);
+	d.setUint16(2, 0x1234, true);
+	d.setFloat64(4, Math.PI, true);
+	var u = new Uint8Array(b);
+	[u[2], u[3], u[4], u[5], d.getUint16(0, true), d.getInt16(2), d.getFloat64(4, true) === Math.PI,
+	 d.getFloat64(4) === Math.PI, d.byteOffset, d.byteLength, d.buffer === b, d.getInt8(0)].join();
+	`
+
+	testScript1(SCRIPT, asciiString("18,52,52,18,13330,13330,true,false,2,12,true,18"), t)
+}
+
+func TestDataViewExport(t *testing.T) {
+	vm := New()
+	v, err := vm.RunString(`
+	var d = new DataView(new ArrayBuffer(8), 2, 4);
+	d.setUint8(1, 42);
+	d;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ok := v.Export().([]byte)
+	if !ok || len(b) != 4 || b[1] != 42 {
+		t.Fatalf("Unexpected export: %#v", v.Export())
+	}
+	b[2] = 7
+	if v, _ := vm.RunString(`d.getUint8(2)`); !v.SameAs(intToValue(7)) {
+		t.Fatalf("The exported slice does not share the buffer: %v", v)
+	}
+	var b1 []byte
+	if err := vm.ExportTo(v, &b1); err != nil {
+		t.Fatal(err)
+	}
+	if len(b1) != 4 || b1[1] != 42 {
+		t.Fatalf("Unexpected result: %v", b1)
+	}
+}
+
+func TestTypedArrayGoBytes(t *testing.T) {
+	vm := New()
+	data := []byte{1, 2, 3}
+	vm.Set("data", data)
+	v, err := vm.RunString(`
+	data[0] = 42;
+	data[1] = 300;
+	data[3] = 5;
+	data instanceof Uint8Array && data.length === 3 && data[3] === undefined;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.ToBoolean() {
+		t.Fatal("Unexpected result")
+	}
+	if data[0] != 42 || data[1] != 44 || data[2] != 3 {
+		t.Fatalf("Unexpected data: %v", data)
+	}
+	exp := vm.Get("data").Export()
+	if b, ok := exp.([]byte); !ok || &b[0] != &data[0] {
+		t.Fatalf("Unexpected export: %#v", exp)
+	}
+
+	v, err = vm.RunString(`new Uint8Array([4, 5, 6]).subarray(1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b []byte
+	if err := vm.ExportTo(v, &b); err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 2 || b[0] != 5 || b[1] != 6 {
+		t.Fatalf("Unexpected result: %v", b)
+	}
+}
+
+func TestTypedArrayGoFloats(t *testing.T) {
+	vm := New()
+	data := []float64{1.5, 2.5}
+	vm.Set("data", data)
+	v, err := vm.RunString(`
+	data[0] *= 2;
+	data instanceof Float64Array && data.byteLength === 16 && data[1] === 2.5;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.ToBoolean() || data[0] != 3 {
+		t.Fatalf("Unexpected result: %v, %v", v, data)
+	}
+	data[1] = 7
+	if v := vm.Get("data").ToObject(vm).Get("1"); !v.SameAs(intToValue(7)) {
+		t.Fatalf("Unexpected value: %v", v)
+	}
+
+	v, err = vm.RunString(`
+	var a = new Float64Array(new ArrayBuffer(24), 8);
+	a[0] = 0.25;
+	a;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, ok := v.Export().([]float64)
+	if !ok || len(exp) != 2 || exp[0] != 0.25 {
+		t.Fatalf("Unexpected export: %#v", v.Export())
+	}
+	exp[1] = 3
+	if v, _ := vm.RunString(`a[1]`); !v.SameAs(intToValue(3)) {
+		t.Fatalf("The exported slice does not share the buffer: %v", v)
+	}
+
+	v, err = vm.RunString(`new Int16Array([1, -2])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, ok := v.Export().([]int16); !ok || len(exp) != 2 || exp[1] != -2 {
+		t.Fatalf("Unexpected export: %#v", v.Export())
+	}
+	var ints []int
+	if err := vm.ExportTo(v, &ints); err != nil {
+		t.Fatal(err)
+	}
+	if len(ints) != 2 || ints[0] != 1 || ints[1] != -2 {
+		t.Fatalf("Unexpected result: %v", ints)
+	}
+}
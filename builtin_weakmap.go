@@ -0,0 +1,228 @@
+package goja
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Weak collections do not reference their keys. Instead, every object used as a key references the collections it
+// belongs to (see Object.weakColls), and the entries are indexed by a unique id of the key. When the key object
+// becomes unreachable, a finalizer removes its entries from the collections.
+//
+// Note that a value which references its own key keeps the key alive.
+
+var weakIdSeq uint64
+
+type weakCollections struct {
+	id    uint64
+	colls []*weakMap
+}
+
+// weakMap holds the data of a WeakMap or a WeakSet.
+type weakMap struct {
+	// the finalizers run in a separate goroutine
+	mu   sync.Mutex
+	data map[uint64]Value
+}
+
+func finalizeWeakCollections(wc *weakCollections) {
+	for _, m := range wc.colls {
+		m.removeId(wc.id)
+	}
+}
+
+func (o *Object) getWeakCollections() *weakCollections {
+	if o.weakColls == nil {
+		o.weakColls = &weakCollections{
+			id: atomic.AddUint64(&weakIdSeq, 1),
+		}
+		runtime.SetFinalizer(o.weakColls, finalizeWeakCollections)
+	}
+	return o.weakColls
+}
+
+func (wc *weakCollections) add(m *weakMap) {
+	for _, c := range wc.colls {
+		if c == m {
+			return
+		}
+	}
+	wc.colls = append(wc.colls, m)
+}
+
+func (wc *weakCollections) remove(m *weakMap) {
+	for i, c := range wc.colls {
+		if c == m {
+			copy(wc.colls[i:], wc.colls[i+1:])
+			wc.colls[len(wc.colls)-1] = nil
+			wc.colls = wc.colls[:len(wc.colls)-1]
+			return
+		}
+	}
+}
+
+func newWeakMap() *weakMap {
+	return &weakMap{
+		data: make(map[uint64]Value),
+	}
+}
+
+func (m *weakMap) removeId(id uint64) {
+	m.mu.Lock()
+	delete(m.data, id)
+	m.mu.Unlock()
+}
+
+func (m *weakMap) set(key *Object, value Value) {
+	wc := key.getWeakCollections()
+	wc.add(m)
+	m.mu.Lock()
+	m.data[wc.id] = value
+	m.mu.Unlock()
+}
+
+func (m *weakMap) get(key *Object) Value {
+	if key.weakColls == nil {
+		return nil
+	}
+	m.mu.Lock()
+	v := m.data[key.weakColls.id]
+	m.mu.Unlock()
+	return v
+}
+
+func (m *weakMap) has(key *Object) bool {
+	return m.get(key) != nil
+}
+
+func (m *weakMap) remove(key *Object) bool {
+	if key.weakColls == nil {
+		return false
+	}
+	id := key.weakColls.id
+	m.mu.Lock()
+	_, exists := m.data[id]
+	delete(m.data, id)
+	m.mu.Unlock()
+	if exists {
+		key.weakColls.remove(m)
+	}
+	return exists
+}
+
+type weakMapObject struct {
+	baseObject
+	m *weakMap
+}
+
+func (wmo *weakMapObject) init() {
+	wmo.baseObject.init()
+	wmo.m = newWeakMap()
+}
+
+func (r *Runtime) newWeakMapObject(proto *Object) *weakMapObject {
+	v := &Object{runtime: r}
+	wmo := &weakMapObject{}
+	wmo.class = classWeakMap
+	wmo.val = v
+	wmo.extensible = true
+	v.self = wmo
+	wmo.prototype = proto
+	wmo.init()
+	return wmo
+}
+
+func (r *Runtime) toWeakMapObject(v Value, method string) *weakMapObject {
+	if obj, ok := v.(*Object); ok {
+		if wmo, ok := obj.self.(*weakMapObject); ok {
+			return wmo
+		}
+	}
+	r.typeErrorResult(true, "Method WeakMap.prototype.%s called on incompatible receiver %s", method, v.String())
+	panic("unreachable")
+}
+
+func (r *Runtime) weakMapProto_delete(call FunctionCall) Value {
+	wmo := r.toWeakMapObject(call.This, "delete")
+	if key, ok := call.Argument(0).(*Object); ok {
+		return r.toBoolean(wmo.m.remove(key))
+	}
+	return valueFalse
+}
+
+func (r *Runtime) weakMapProto_get(call FunctionCall) Value {
+	wmo := r.toWeakMapObject(call.This, "get")
+	if key, ok := call.Argument(0).(*Object); ok {
+		return nilSafe(wmo.m.get(key))
+	}
+	return _undefined
+}
+
+func (r *Runtime) weakMapProto_has(call FunctionCall) Value {
+	wmo := r.toWeakMapObject(call.This, "has")
+	if key, ok := call.Argument(0).(*Object); ok {
+		return r.toBoolean(wmo.m.has(key))
+	}
+	return valueFalse
+}
+
+func (r *Runtime) weakMapProto_set(call FunctionCall) Value {
+	wmo := r.toWeakMapObject(call.This, "set")
+	key, ok := call.Argument(0).(*Object)
+	if !ok {
+		r.typeErrorResult(true, "Invalid value used as weak map key")
+	}
+	wmo.m.set(key, call.Argument(1))
+	return call.This
+}
+
+func (r *Runtime) builtin_newWeakMap(args []Value, proto *Object) *Object {
+	wmo := r.newWeakMapObject(proto)
+	if len(args) > 0 {
+		if arg := args[0]; arg != _undefined && arg != _null {
+			adder := r.toCallable(wmo.getStr("set"))
+			r.getIterator(arg).iterate(func(item Value) {
+				entry, ok := item.(*Object)
+				if !ok {
+					r.typeErrorResult(true, "Iterator value %s is not an entry object", item.String())
+				}
+				k := nilSafe(entry.self.get(intToValue(0)))
+				v := nilSafe(entry.self.get(intToValue(1)))
+				adder(FunctionCall{This: wmo.val, Arguments: []Value{k, v}})
+			})
+		}
+	}
+	return wmo.val
+}
+
+func (r *Runtime) createWeakMapProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.WeakMap, true, false, true)
+	o._putProp("delete", r.newNativeFunc(r.weakMapProto_delete, nil, "delete", nil, 1), true, false, true)
+	o._putProp("get", r.newNativeFunc(r.weakMapProto_get, nil, "get", nil, 1), true, false, true)
+	o._putProp("has", r.newNativeFunc(r.weakMapProto_has, nil, "has", nil, 1), true, false, true)
+	o._putProp("set", r.newNativeFunc(r.weakMapProto_set, nil, "set", nil, 2), true, false, true)
+	o._putSym(SymToStringTag, asciiString(classWeakMap), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) createWeakMap(val *Object) objectImpl {
+	return r.newNativeFuncObj(val, r.constructorRequiresNew("WeakMap"), func(args []Value) *Object {
+		return r.builtin_newWeakMap(args, r.global.WeakMapPrototype)
+	}, "WeakMap", r.global.WeakMapPrototype, 0)
+}
+
+func (r *Runtime) initWeakMap() {
+	r.global.WeakMapPrototype = r.newLazyObject(r.createWeakMapProto)
+	r.global.WeakMap = r.newLazyObject(r.createWeakMap)
+	r.addToGlobal("WeakMap", r.global.WeakMap)
+}
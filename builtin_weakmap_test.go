@@ -0,0 +1,84 @@
+package goja
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestWeakMapBasic(t *testing.T) {
+	const SCRIPT = `
+	var o = {}, o1 = {};
+	var m = new WeakMap([[o, 1]]);
+	var res = [m.get(o), m.has(o), m.has(o1), m.get(o1), m.has(1), m.delete(1)];
+	m.set(o1, 2);
+	res.push(m.get(o1), m.delete(o), m.has(o), m.delete(o));
+	res.push(Object.prototype.toString.call(m));
+	try {
+		m.set(1, 1);
+	} catch (e) {
+		res.push(e instanceof TypeError);
+	}
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("1,true,false,,false,false,2,true,false,false,[object WeakMap],true"), t)
+}
+
+func TestWeakSetBasic(t *testing.T) {
+	const SCRIPT = `
+	var o = {}, o1 = {};
+	var s = new WeakSet([o]);
+	var res = [s.has(o), s.has(o1), s.has(1)];
+	s.add(o1);
+	res.push(s.has(o1), s.delete(o), s.has(o), s.delete(o));
+	res.push(Object.prototype.toString.call(s));
+	try {
+		s.add("x");
+	} catch (e) {
+		res.push(e instanceof TypeError);
+	}
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("true,false,false,true,true,false,false,[object WeakSet],true"), t)
+}
+
+func TestWeakMapExpiry(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	var m = new WeakMap();
+	var key = {};
+	m.set(key, true);
+	(function() {
+		for (var i = 0; i < 10; i++) {
+			m.set({}, i);
+		}
+	})();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wm := vm.Get("m").(*Object).self.(*weakMapObject).m
+	var l int
+	for i := 0; i < 10; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+		wm.mu.Lock()
+		l = len(wm.data)
+		wm.mu.Unlock()
+		if l == 1 {
+			break
+		}
+	}
+	if l != 1 {
+		t.Fatalf("Unexpected number of entries: %d", l)
+	}
+	v, err := vm.RunString("m.get(key)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != valueTrue {
+		t.Fatalf("Unexpected value: %v", v)
+	}
+}
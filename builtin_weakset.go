@@ -0,0 +1,102 @@
+package goja
+
+type weakSetObject struct {
+	baseObject
+	s *weakMap
+}
+
+func (wso *weakSetObject) init() {
+	wso.baseObject.init()
+	wso.s = newWeakMap()
+}
+
+func (r *Runtime) newWeakSetObject(proto *Object) *weakSetObject {
+	v := &Object{runtime: r}
+	wso := &weakSetObject{}
+	wso.class = classWeakSet
+	wso.val = v
+	wso.extensible = true
+	v.self = wso
+	wso.prototype = proto
+	wso.init()
+	return wso
+}
+
+func (r *Runtime) toWeakSetObject(v Value, method string) *weakSetObject {
+	if obj, ok := v.(*Object); ok {
+		if wso, ok := obj.self.(*weakSetObject); ok {
+			return wso
+		}
+	}
+	r.typeErrorResult(true, "Method WeakSet.prototype.%s called on incompatible receiver %s", method, v.String())
+	panic("unreachable")
+}
+
+func (r *Runtime) weakSetProto_add(call FunctionCall) Value {
+	wso := r.toWeakSetObject(call.This, "add")
+	value, ok := call.Argument(0).(*Object)
+	if !ok {
+		r.typeErrorResult(true, "Invalid value used in weak set")
+	}
+	wso.s.set(value, valueTrue)
+	return call.This
+}
+
+func (r *Runtime) weakSetProto_delete(call FunctionCall) Value {
+	wso := r.toWeakSetObject(call.This, "delete")
+	if value, ok := call.Argument(0).(*Object); ok {
+		return r.toBoolean(wso.s.remove(value))
+	}
+	return valueFalse
+}
+
+func (r *Runtime) weakSetProto_has(call FunctionCall) Value {
+	wso := r.toWeakSetObject(call.This, "has")
+	if value, ok := call.Argument(0).(*Object); ok {
+		return r.toBoolean(wso.s.has(value))
+	}
+	return valueFalse
+}
+
+func (r *Runtime) builtin_newWeakSet(args []Value, proto *Object) *Object {
+	wso := r.newWeakSetObject(proto)
+	if len(args) > 0 {
+		if arg := args[0]; arg != _undefined && arg != _null {
+			adder := r.toCallable(wso.getStr("add"))
+			r.getIterator(arg).iterate(func(item Value) {
+				adder(FunctionCall{This: wso.val, Arguments: []Value{item}})
+			})
+		}
+	}
+	return wso.val
+}
+
+func (r *Runtime) createWeakSetProto(val *Object) objectImpl {
+	o := &baseObject{
+		class:      classObject,
+		val:        val,
+		extensible: true,
+		prototype:  r.global.ObjectPrototype,
+	}
+	o.init()
+
+	o._putProp("constructor", r.global.WeakSet, true, false, true)
+	o._putProp("add", r.newNativeFunc(r.weakSetProto_add, nil, "add", nil, 1), true, false, true)
+	o._putProp("delete", r.newNativeFunc(r.weakSetProto_delete, nil, "delete", nil, 1), true, false, true)
+	o._putProp("has", r.newNativeFunc(r.weakSetProto_has, nil, "has", nil, 1), true, false, true)
+	o._putSym(SymToStringTag, asciiString(classWeakSet), false, false, true)
+
+	return o
+}
+
+func (r *Runtime) createWeakSet(val *Object) objectImpl {
+	return r.newNativeFuncObj(val, r.constructorRequiresNew("WeakSet"), func(args []Value) *Object {
+		return r.builtin_newWeakSet(args, r.global.WeakSetPrototype)
+	}, "WeakSet", r.global.WeakSetPrototype, 0)
+}
+
+func (r *Runtime) initWeakSet() {
+	r.global.WeakSetPrototype = r.newLazyObject(r.createWeakSetProto)
+	r.global.WeakSet = r.newLazyObject(r.createWeakSet)
+	r.addToGlobal("WeakSet", r.global.WeakSet)
+}
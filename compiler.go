@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/dop251/goja/ast"
 	"github.com/dop251/goja/file"
+	"github.com/dop251/goja/token"
 	"sort"
 	"strconv"
 )
@@ -14,6 +15,7 @@ const (
 	blockBranch
 	blockSwitch
 	blockWith
+	blockScope
 )
 
 type CompilerError struct {
@@ -42,6 +44,14 @@ type Program struct {
 	funcName string
 	src      *SrcFile
 	srcMap   []srcMapItem
+	// the range of the function in the source, both are 0 for a script or a module
+	srcStart, srcEnd int
+
+	// stack slots of the local variables of a stashless function, see loadStack
+	stackNames map[string]int
+
+	// the program has been read by ReadProgram(), the consistency of its code is not guaranteed
+	unverified bool
 }
 
 type compiler struct {
@@ -65,9 +75,35 @@ type scope struct {
 	accessed   bool
 	argsNeeded bool
 	thisNeeded bool
+	// arrow function scopes have no own arguments object
+	arrow bool
+	// set for the methods and constructors of classes and the arrow functions created in them, which may
+	// reference super properties
+	home bool
+	// set for the constructor of a derived class, this is initialised by super()
+	derived bool
+	// set for the body of a generator function once its parameters are initialised, yield is not allowed in them
+	generator bool
+	// the same for the body of an async function and await
+	async bool
 
 	namesMap    map[string]string
 	lastFreeTmp int
+
+	// let and const bindings of a block scope, see openBlockScope
+	lexicals map[string]*lexicalBinding
+	// set if a binding of a block scope may be accessed in its temporal dead zone, such scopes keep their stash
+	tdz bool
+	// set for the scope of a switch statement, its bindings may be accessed uninitialised from any case
+	switchBlock bool
+	// function declarations that are created when the block that declares them is entered because the block
+	// has let or const bindings, see compileFunctions
+	blockFuncs map[ast.Node][]*ast.FunctionDeclaration
+}
+
+type lexicalBinding struct {
+	isConst     bool
+	initialized bool
 }
 
 type block struct {
@@ -78,6 +114,16 @@ type block struct {
 	breaks     []int
 	conts      []int
 	outer      *block
+
+	// for loops: whether the loop keeps an item on the iterator stack (for-in and for-of), which must be popped
+	// (and the iterator closed) when the loop is left by a break, a continue of an outer loop or a return
+	iterating bool
+
+	// for blockScope: positions of the instructions that enter, copy and leave the block stash (the first one
+	// is always enterBlockScope), and the state of the enclosing scope before the block, see closeBlockScope
+	stashInstrs []int
+	accessed    bool
+	dynamic     bool
 }
 
 func (c *compiler) leaveBlock() {
@@ -166,7 +212,7 @@ func (p *Program) sourceOffset(pc int) int {
 		return p.srcMap[i].srcPos
 	}
 
-	return 0
+	return p.srcStart
 }
 
 func (s *scope) isFunction() bool {
@@ -198,10 +244,11 @@ func (s *scope) lookupName(name string) (idx uint32, found, noDynamics bool) {
 				return
 			}
 		}
-		if name == "arguments" && !s.lexical && s.isFunction() {
-			s.argsNeeded = true
-			s.accessed = true
-			idx, _ = s.bindName(name)
+		if name == "arguments" && !curScope.lexical && !curScope.arrow && curScope.isFunction() {
+			curScope.argsNeeded = true
+			curScope.accessed = true
+			idx, _ = curScope.bindName(name)
+			idx |= level << 24
 			found = true
 			return
 		}
@@ -210,6 +257,45 @@ func (s *scope) lookupName(name string) (idx uint32, found, noDynamics bool) {
 	return
 }
 
+// lookupLexical returns the let or const binding the name resolves to (if any) and whether an access from the current
+// position may happen before the binding is initialised.
+func (s *scope) lookupLexical(name string) (b *lexicalBinding, check bool) {
+	crossedFunction := false
+	for curScope := s; curScope != nil; curScope = curScope.outer {
+		if !curScope.dynamic {
+			mapped := name
+			if m, exists := curScope.namesMap[name]; exists {
+				mapped = m
+			}
+			if _, exists := curScope.names[mapped]; exists {
+				if b = curScope.lexicals[mapped]; b != nil {
+					if crossedFunction || !b.initialized || curScope.switchBlock {
+						curScope.tdz = true
+						check = true
+					}
+				}
+				return
+			}
+		}
+		if !curScope.lexical {
+			crossedFunction = true
+		}
+	}
+	return
+}
+
+// bindTmp binds a temporary variable in the nearest function scope and returns its index relative to s.
+func (s *scope) bindTmp() uint32 {
+	var level uint32
+	for ; s.lexical; s = s.outer {
+		level++
+	}
+	name := " __tmp" + strconv.Itoa(s.lastFreeTmp)
+	s.lastFreeTmp++
+	idx, _ := s.bindName(name)
+	return level<<24 | idx
+}
+
 func (s *scope) bindName(name string) (uint32, bool) {
 	if s.lexical {
 		return s.outer.bindName(name)
@@ -248,6 +334,7 @@ func (c *compiler) markBlockStart() {
 
 func (c *compiler) compile(in *ast.Program) {
 	c.p.src = NewSrcFile(in.File.Name(), in.File.Source(), in.SourceMap)
+	c.p.src.sourceMapData = in.SourceMapData
 
 	if len(in.Body) > 0 {
 		if !c.scope.strict {
@@ -256,14 +343,36 @@ func (c *compiler) compile(in *ast.Program) {
 	}
 
 	c.compileDeclList(in.DeclarationList, false)
-	c.compileFunctions(in.DeclarationList)
 
-	c.markBlockStart()
-	c.compileStatements(in.Body, true)
+	// let and const declared at the top level of a script are shared between scripts, in eval code they are
+	// confined to the evaluated code
+	decls := lexicalDeclarations(in.Body)
+	var globalLexicals []instruction
+	if !c.scope.eval {
+		c.checkLexicalNames(decls, "")
+		for _, decl := range decls {
+			isConst := decl.Token == token.CONST
+			lexicalNames(decl, func(name string, _ file.Idx) {
+				globalLexicals = append(globalLexicals, bindGlobalLexical{name: name, isConst: isConst})
+			})
+		}
+	}
+
+	if c.scope.eval && len(decls) > 0 {
+		c.openBlockScope(nil, decls)
+		c.compileFunctions(in.DeclarationList, in.Body)
+		c.markBlockStart()
+		c.compileStatements(in.Body, true)
+		c.closeBlockScope()
+	} else {
+		c.compileFunctions(in.DeclarationList, in.Body)
+		c.markBlockStart()
+		c.compileStatements(in.Body, true)
+	}
 
 	c.p.code = append(c.p.code, halt)
 	code := c.p.code
-	c.p.code = make([]instruction, 0, len(code)+len(c.scope.names)+2)
+	c.p.code = make([]instruction, 0, len(code)+len(c.scope.names)+len(globalLexicals)+2)
 	if c.scope.eval {
 		if !c.scope.strict {
 			c.emit(jne(2), newStash)
@@ -276,12 +385,209 @@ func (c *compiler) compile(in *ast.Program) {
 	for name, nameIdx := range c.scope.names {
 		c.p.code[l+int(nameIdx)] = bindName(name)
 	}
+	c.p.code = append(c.p.code, globalLexicals...)
 
 	c.p.code = append(c.p.code, code...)
 	for i, _ := range c.p.srcMap {
-		c.p.srcMap[i].pc += len(c.scope.names)
+		c.p.srcMap[i].pc += len(c.scope.names) + len(globalLexicals)
+	}
+
+}
+
+// compileModule compiles the code of a module as a strict arrow generator function, which is what the program creates.
+// The function is called in the environment of the imported bindings (see linkModule), the names that are not
+// declared by the module are looked up dynamically in it. Once the functions declared by the module are created, the
+// generator yields the getters of the bindings exported by the module, which the importing modules use to read them;
+// the rest of the code is executed when it is resumed again.
+func (c *compiler) compileModule(in *ast.Program) *moduleInfo {
+	c.p.src = NewSrcFile(in.File.Name(), in.File.Source(), in.SourceMap)
+	c.p.src.sourceMapData = in.SourceMapData
+	c.scope.strict = true
+
+	info := &moduleInfo{
+		localExports:    make(map[string]string),
+		indirectExports: make(map[string]importEntry),
+	}
+	requested := make(map[string]bool)
+	addRequest := func(specifier string) {
+		if !requested[specifier] {
+			requested[specifier] = true
+			info.requests = append(info.requests, specifier)
+		}
+	}
+	exported := make(map[string]bool)
+	addExport := func(id *ast.Identifier) {
+		if exported[id.Name] {
+			c.throwSyntaxError(int(id.Idx)-1, "Duplicate export of '%s'", id.Name)
+		}
+		exported[id.Name] = true
+	}
+	imported := make(map[string]importEntry)
+	addImport := func(specifier, name string, local *ast.Identifier) {
+		c.checkIdentifierLName(local.Name, int(local.Idx)-1)
+		c.checkIdentifierName(local.Name, int(local.Idx)-1)
+		if _, exists := imported[local.Name]; exists {
+			c.throwSyntaxError(int(local.Idx)-1, "Identifier '%s' has already been declared", local.Name)
+		}
+		entry := importEntry{specifier: specifier, importName: name, localName: local.Name}
+		imported[local.Name] = entry
+		info.imports = append(info.imports, entry)
+	}
+	// the local bindings exported by the declarations, they may be imported bindings
+	var localExports []*ast.ExportSpecifier
+
+	var body []ast.Statement
+	for _, st := range in.Body {
+		switch st := st.(type) {
+		case *ast.ImportDeclaration:
+			specifier := st.ModuleSpecifier.Value
+			addRequest(specifier)
+			if st.DefaultBinding != nil {
+				addImport(specifier, "default", st.DefaultBinding)
+			}
+			if st.NamespaceImport != nil {
+				addImport(specifier, "*", st.NamespaceImport)
+			}
+			for _, item := range st.NamedImports {
+				addImport(specifier, item.Name.Name, item.Alias)
+			}
+		case *ast.ExportDeclaration:
+			if st.ModuleSpecifier != nil {
+				specifier := st.ModuleSpecifier.Value
+				addRequest(specifier)
+				switch {
+				case st.Namespace != nil:
+					addExport(st.Namespace)
+					info.indirectExports[st.Namespace.Name] = importEntry{specifier: specifier, importName: "*"}
+				case st.ExportAll:
+					info.starExports = append(info.starExports, specifier)
+				default:
+					for _, item := range st.NamedExports {
+						addExport(item.Alias)
+						info.indirectExports[item.Alias.Name] = importEntry{specifier: specifier, importName: item.Name.Name}
+					}
+				}
+				continue
+			}
+			localExports = append(localExports, st.NamedExports...)
+			var names []*ast.Identifier
+			if st.Function != nil {
+				names = append(names, st.Function.Name)
+			}
+			switch decl := st.Statement.(type) {
+			case *ast.VariableStatement:
+				for _, item := range decl.List {
+					if item, ok := item.(*ast.VariableExpression); ok {
+						if item.Target != nil {
+							ast.BoundNames(item.Target, func(id *ast.Identifier) {
+								names = append(names, id)
+							})
+						} else {
+							names = append(names, &ast.Identifier{Name: item.Name, Idx: item.Idx})
+						}
+					}
+				}
+			case *ast.LexicalDeclaration:
+				lexicalNames(decl, func(name string, idx file.Idx) {
+					names = append(names, &ast.Identifier{Name: name, Idx: idx})
+				})
+			case *ast.ClassDeclaration:
+				names = append(names, decl.Class.Name)
+			}
+			for _, name := range names {
+				alias := name
+				if st.Default {
+					alias = &ast.Identifier{Name: "default", Idx: name.Idx}
+				}
+				localExports = append(localExports, &ast.ExportSpecifier{Name: name, Alias: alias})
+			}
+			if st.Statement != nil {
+				body = append(body, st.Statement)
+			}
+		default:
+			body = append(body, st)
+		}
+	}
+
+	declared := make(map[string]bool)
+	declare := func(name string, idx file.Idx) {
+		if _, exists := imported[name]; exists {
+			c.throwSyntaxError(int(idx)-1, "Identifier '%s' has already been declared", name)
+		}
+		declared[name] = true
+	}
+	for _, decl := range in.DeclarationList {
+		switch decl := decl.(type) {
+		case *ast.FunctionDeclaration:
+			declare(decl.Function.Name.Name, decl.Function.Name.Idx)
+		case *ast.VariableDeclaration:
+			for _, item := range decl.List {
+				declare(item.Name, item.Idx)
+			}
+		}
+	}
+	for _, decl := range lexicalDeclarations(body) {
+		lexicalNames(decl, declare)
 	}
 
+	bound := make(map[string]bool)
+	for _, item := range localExports {
+		addExport(item.Alias)
+		local := item.Name.Name
+		if entry, exists := imported[local]; exists {
+			// an imported binding is exported by the module it is imported from
+			info.indirectExports[item.Alias.Name] = importEntry{specifier: entry.specifier, importName: entry.importName}
+			continue
+		}
+		if !declared[local] {
+			c.throwSyntaxError(int(item.Name.Idx)-1, "Export '%s' is not defined in module", local)
+		}
+		info.localExports[item.Alias.Name] = local
+		if !bound[local] {
+			bound[local] = true
+			info.bindings = append(info.bindings, local)
+		}
+	}
+
+	start := file.Idx(in.File.Base())
+	getters := make([]ast.Expression, len(info.bindings))
+	for i, name := range info.bindings {
+		getters[i] = &ast.ArrowFunctionLiteral{
+			Start:         start,
+			ParameterList: &ast.ParameterList{},
+			Body: &ast.ExpressionBody{
+				Expression: &ast.Identifier{Name: name, Idx: start},
+			},
+			End: start,
+		}
+	}
+	body = append([]ast.Statement{&ast.ExpressionStatement{
+		Expression: &ast.YieldExpression{
+			Yield: start,
+			Argument: &ast.ArrayLiteral{
+				LeftBracket:  start,
+				RightBracket: start,
+				Value:        getters,
+			},
+		},
+	}}, body...)
+
+	f := &compiledFunctionLiteral{
+		parameterList:   &ast.ParameterList{},
+		body:            body,
+		declarationList: in.DeclarationList,
+		start:           start,
+		end:             start + file.Idx(len(in.File.Source())),
+		isArrow:         true,
+		isGenerator:     true,
+	}
+	f.init(c, start)
+	f.emitGetter(true)
+	// this is undefined in a module, the arrow function captures the value loaded by the first instruction
+	c.p.code[0] = loadUndef
+	c.emit(halt)
+
+	return info
 }
 
 func (c *compiler) compileDeclList(v []ast.Declaration, inFunc bool) {
@@ -297,14 +603,87 @@ func (c *compiler) compileDeclList(v []ast.Declaration, inFunc bool) {
 	}
 }
 
-func (c *compiler) compileFunctions(v []ast.Declaration) {
+// compileFunctions creates the functions declared in the body. Functions declared inside a block with let or const
+// bindings are only created when the block is entered, so that they can access the bindings.
+func (c *compiler) compileFunctions(v []ast.Declaration, body []ast.Statement) {
+	blocks := collectLexicalBlocks(body, nil)
 	for _, value := range v {
 		if value, ok := value.(*ast.FunctionDeclaration); ok {
+			var node ast.Node
+			idx := value.Function.Idx0()
+			for _, b := range blocks {
+				if idx >= b.Idx0() && idx < b.Idx1() {
+					node = b
+				}
+			}
+			if node != nil {
+				s := nearestNonLexical(c.scope)
+				if s.blockFuncs == nil {
+					s.blockFuncs = make(map[ast.Node][]*ast.FunctionDeclaration)
+				}
+				s.blockFuncs[node] = append(s.blockFuncs[node], value)
+				continue
+			}
 			c.compileFunction(value)
 		}
 	}
 }
 
+// collectLexicalBlocks appends the blocks (and switch statements) that declare let or const bindings, outer
+// blocks precede the blocks nested in them.
+func collectLexicalBlocks(list []ast.Statement, blocks []ast.Node) []ast.Node {
+	for _, st := range list {
+		blocks = collectLexicalBlocksStmt(st, blocks)
+	}
+	return blocks
+}
+
+func collectLexicalBlocksStmt(st ast.Statement, blocks []ast.Node) []ast.Node {
+	switch st := st.(type) {
+	case *ast.BlockStatement:
+		if len(lexicalDeclarations(st.List)) > 0 {
+			blocks = append(blocks, st)
+		}
+		blocks = collectLexicalBlocks(st.List, blocks)
+	case *ast.SwitchStatement:
+		for _, cs := range st.Body {
+			if len(lexicalDeclarations(cs.Consequent)) > 0 {
+				blocks = append(blocks, st)
+				break
+			}
+		}
+		for _, cs := range st.Body {
+			blocks = collectLexicalBlocks(cs.Consequent, blocks)
+		}
+	case *ast.IfStatement:
+		blocks = collectLexicalBlocksStmt(st.Consequent, blocks)
+		if st.Alternate != nil {
+			blocks = collectLexicalBlocksStmt(st.Alternate, blocks)
+		}
+	case *ast.ForStatement:
+		blocks = collectLexicalBlocksStmt(st.Body, blocks)
+	case *ast.ForInStatement:
+		blocks = collectLexicalBlocksStmt(st.Body, blocks)
+	case *ast.WhileStatement:
+		blocks = collectLexicalBlocksStmt(st.Body, blocks)
+	case *ast.DoWhileStatement:
+		blocks = collectLexicalBlocksStmt(st.Body, blocks)
+	case *ast.LabelledStatement:
+		blocks = collectLexicalBlocksStmt(st.Statement, blocks)
+	case *ast.WithStatement:
+		blocks = collectLexicalBlocksStmt(st.Body, blocks)
+	case *ast.TryStatement:
+		blocks = collectLexicalBlocksStmt(st.Body, blocks)
+		if st.Catch != nil {
+			blocks = collectLexicalBlocksStmt(st.Catch.Body, blocks)
+		}
+		if st.Finally != nil {
+			blocks = collectLexicalBlocksStmt(st.Finally, blocks)
+		}
+	}
+	return blocks
+}
+
 func (c *compiler) compileVarDecl(v *ast.VariableDeclaration, inFunc bool) {
 	for _, item := range v.List {
 		if c.scope.strict {
@@ -374,6 +753,8 @@ func (c *compiler) convertFunctionToStashless(code []instruction, args int) {
 			} else {
 				code[pc] = setLocalP(newIdx)
 			}
+		case _createArgsRestStash:
+			code[pc] = createArgsRestStack(args)
 		case getVar:
 			level := instr.idx >> 24
 			idx := instr.idx & 0x00FFFFFF
@@ -5,7 +5,9 @@ import (
 	"github.com/dop251/goja/ast"
 	"github.com/dop251/goja/file"
 	"github.com/dop251/goja/token"
+	"math/big"
 	"regexp"
+	"strconv"
 )
 
 var (
@@ -47,6 +49,17 @@ type compiledRegexpLiteral struct {
 	expr *ast.RegExpLiteral
 }
 
+type compiledTemplateLiteral struct {
+	baseCompiledExpr
+	elements    []*ast.TemplateElement
+	expressions []compiledExpr
+}
+
+type compiledTemplateObject struct {
+	baseCompiledExpr
+	elements []*ast.TemplateElement
+}
+
 type compiledLiteral struct {
 	baseCompiledExpr
 	val Value
@@ -96,8 +109,54 @@ type compiledIdentifierExpr struct {
 
 type compiledFunctionLiteral struct {
 	baseCompiledExpr
-	expr   *ast.FunctionLiteral
-	isExpr bool
+	name            *ast.Identifier
+	parameterList   *ast.ParameterList
+	body            []ast.Statement
+	declarationList []ast.Declaration
+	start, end      file.Idx
+	isExpr          bool
+	isArrow         bool
+	// the method or the constructor of a class
+	isMethod    bool
+	derived     bool
+	isGenerator bool
+	isAsync     bool
+}
+
+type compiledAwaitExpr struct {
+	baseCompiledExpr
+	arg compiledExpr
+}
+
+type compiledImportCall struct {
+	baseCompiledExpr
+	arg compiledExpr
+}
+
+type compiledYieldExpr struct {
+	baseCompiledExpr
+	arg      compiledExpr
+	delegate bool
+}
+
+type compiledClassLiteral struct {
+	baseCompiledExpr
+	expr *ast.ClassLiteral
+}
+
+type compiledSuperCallExpr struct {
+	baseCompiledExpr
+	args []compiledExpr
+}
+
+type compiledSuperDotExpr struct {
+	baseCompiledExpr
+	name string
+}
+
+type compiledSuperBracketExpr struct {
+	baseCompiledExpr
+	member compiledExpr
 }
 
 type compiledBracketExpr struct {
@@ -151,6 +210,7 @@ type compiledBinaryExpr struct {
 type compiledVariableExpr struct {
 	baseCompiledExpr
 	name        string
+	pattern     ast.Expression
 	initializer compiledExpr
 	expr        *ast.VariableExpression
 }
@@ -159,6 +219,45 @@ type compiledEnumGetExpr struct {
 	baseCompiledExpr
 }
 
+type compiledPatternExpr struct {
+	baseCompiledExpr
+	pattern ast.Expression
+}
+
+type compiledSpreadExpr struct {
+	baseCompiledExpr
+	expr compiledExpr
+}
+
+// compiledDefaultExpr evaluates to value, or to def if value is undefined. It implements the default values of
+// parameters and pattern elements.
+type compiledDefaultExpr struct {
+	baseCompiledExpr
+	value, def compiledExpr
+}
+
+type compiledIterNextExpr struct {
+	baseCompiledExpr
+}
+
+type compiledIterRestExpr struct {
+	baseCompiledExpr
+}
+
+type compiledPatternPropExpr struct {
+	baseCompiledExpr
+	name string
+}
+
+type compiledPatternRestExpr struct {
+	baseCompiledExpr
+	excluded []string
+}
+
+type compiledArgsRestExpr struct {
+	baseCompiledExpr
+}
+
 type defaultDeleteExpr struct {
 	baseCompiledExpr
 	expr compiledExpr
@@ -182,6 +281,8 @@ func (c *compiler) compileExpression(v ast.Expression) compiledExpr {
 		return c.compileNumberLiteral(v)
 	case *ast.StringLiteral:
 		return c.compileStringLiteral(v)
+	case *ast.TemplateLiteral:
+		return c.compileTemplateLiteral(v)
 	case *ast.BooleanLiteral:
 		return c.compileBooleanLiteral(v)
 	case *ast.NullLiteral:
@@ -210,7 +311,18 @@ func (c *compiler) compileExpression(v ast.Expression) compiledExpr {
 		return c.compileConditionalExpression(v)
 	case *ast.FunctionLiteral:
 		return c.compileFunctionLiteral(v, true)
+	case *ast.ArrowFunctionLiteral:
+		return c.compileArrowFunctionLiteral(v)
+	case *ast.ClassLiteral:
+		return c.compileClassLiteral(v)
+	case *ast.SuperExpression:
+		// super() and super property references are compiled by the call and member expressions
+		c.throwSyntaxError(int(v.Idx)-1, "'super' keyword unexpected here")
+		return nil
 	case *ast.DotExpression:
+		if _, ok := v.Left.(*ast.SuperExpression); ok {
+			return c.compileSuperDotExpression(v)
+		}
 		r := &compiledDotExpr{
 			left: c.compileExpression(v.Left),
 			name: v.Identifier.Name,
@@ -218,6 +330,9 @@ func (c *compiler) compileExpression(v ast.Expression) compiledExpr {
 		r.init(c, v.Idx0())
 		return r
 	case *ast.BracketExpression:
+		if _, ok := v.Left.(*ast.SuperExpression); ok {
+			return c.compileSuperBracketExpression(v)
+		}
 		r := &compiledBracketExpr{
 			left:   c.compileExpression(v.Left),
 			member: c.compileExpression(v.Member),
@@ -232,6 +347,37 @@ func (c *compiler) compileExpression(v ast.Expression) compiledExpr {
 		return c.compileSequenceExpression(v)
 	case *ast.NewExpression:
 		return c.compileNewExpression(v)
+	case *ast.ArrayPattern, *ast.ObjectPattern:
+		r := &compiledPatternExpr{
+			pattern: v,
+		}
+		r.init(c, v.Idx0())
+		return r
+	case *ast.SpreadElement:
+		r := &compiledSpreadExpr{
+			expr: c.compileExpression(v.Expression),
+		}
+		r.init(c, v.Idx0())
+		return r
+	case *ast.AwaitExpression:
+		r := &compiledAwaitExpr{
+			arg: c.compileExpression(v.Argument),
+		}
+		r.init(c, v.Idx0())
+		return r
+	case *ast.ImportCall:
+		r := &compiledImportCall{
+			arg: c.compileExpression(v.Argument),
+		}
+		r.init(c, v.Idx0())
+		return r
+	case *ast.YieldExpression:
+		r := &compiledYieldExpr{
+			arg:      c.compileExpression(v.Argument),
+			delegate: v.Delegate,
+		}
+		r.init(c, v.Idx0())
+		return r
 	default:
 		panic(fmt.Errorf("Unknown expression type: %T", v))
 	}
@@ -279,7 +425,12 @@ func (e *compiledIdentifierExpr) emitGetter(putOnStack bool) {
 	e.addSrcMap()
 	if idx, found, noDynamics := e.c.scope.lookupName(e.name); noDynamics {
 		if found {
-			if putOnStack {
+			if _, check := e.c.scope.lookupLexical(e.name); check {
+				e.c.emit(getLocal(idx), checkInit(e.name))
+				if !putOnStack {
+					e.c.emit(pop)
+				}
+			} else if putOnStack {
 				e.c.emit(getLocal(idx))
 			}
 		} else {
@@ -288,6 +439,7 @@ func (e *compiledIdentifierExpr) emitGetter(putOnStack bool) {
 	} else {
 		if found {
 			e.c.emit(getVar{name: e.name, idx: idx})
+			e.emitCheckInit()
 		} else {
 			e.c.emit(getVar1(e.name))
 		}
@@ -297,17 +449,27 @@ func (e *compiledIdentifierExpr) emitGetter(putOnStack bool) {
 	}
 }
 
+// emitCheckInit emits a check for the temporal dead zone if the identifier resolves to a let or const binding
+// that may not have been initialised yet.
+func (e *compiledIdentifierExpr) emitCheckInit() {
+	if _, check := e.c.scope.lookupLexical(e.name); check {
+		e.c.emit(checkInit(e.name))
+	}
+}
+
 func (e *compiledIdentifierExpr) emitGetterOrRef() {
 	e.addSrcMap()
 	if idx, found, noDynamics := e.c.scope.lookupName(e.name); noDynamics {
 		if found {
 			e.c.emit(getLocal(idx))
+			e.emitCheckInit()
 		} else {
 			panic("No dynamics and not found")
 		}
 	} else {
 		if found {
 			e.c.emit(getVar{name: e.name, idx: idx, ref: true})
+			e.emitCheckInit()
 		} else {
 			e.c.emit(getVar1Callee(e.name))
 		}
@@ -322,7 +484,15 @@ func (c *compiler) emitVarSetter1(name string, offset int, emitRight func(isRef
 	if idx, found, noDynamics := c.scope.lookupName(name); noDynamics {
 		emitRight(false)
 		if found {
-			c.emit(setLocal(idx))
+			b, check := c.scope.lookupLexical(name)
+			if check {
+				c.emit(getLocal(idx), checkInit(name), pop)
+			}
+			if b != nil && b.isConst {
+				c.emit(throwConstAssignment)
+			} else {
+				c.emit(setLocal(idx))
+			}
 		} else {
 			if c.scope.strict {
 				c.emit(setGlobalStrict(name))
@@ -354,6 +524,12 @@ func (c *compiler) emitVarSetter(name string, offset int, valueExpr compiledExpr
 }
 
 func (e *compiledVariableExpr) emitSetter(valueExpr compiledExpr) {
+	if e.pattern != nil {
+		valueExpr.emitGetter(true)
+		e.c.emit(dup)
+		e.c.emitPattern(e.pattern, e.c.emitPatternAssign)
+		return
+	}
 	e.c.emitVarSetter(e.name, e.offset, valueExpr)
 }
 
@@ -720,52 +896,121 @@ func (e *compiledLiteral) constant() bool {
 }
 
 func (e *compiledFunctionLiteral) emitGetter(putOnStack bool) {
+	home := e.isMethod || e.isArrow && nearestNonLexical(e.c.scope).home
 	e.c.newScope()
 	savedBlockStart := e.c.blockStart
 	savedPrg := e.c.p
 	e.c.p = &Program{
-		src: e.c.p.src,
+		src:      e.c.p.src,
+		srcStart: int(e.start) - 1,
+		srcEnd:   int(e.end) - 1,
 	}
 	e.c.blockStart = 0
 
-	if e.expr.Name != nil {
-		e.c.p.funcName = e.expr.Name.Name
+	if e.name != nil {
+		e.c.p.funcName = e.name.Name
 	}
+	e.c.scope.arrow = e.isArrow
+	e.c.scope.home = home
+	e.c.scope.derived = e.derived
 	block := e.c.block
 	e.c.block = nil
 	defer func() {
 		e.c.block = block
 	}()
 
-	if !e.c.scope.strict {
-		e.c.scope.strict = e.c.isStrictStatement(e.expr.Body)
+	// a parameter list with default values, patterns or a rest parameter is not simple
+	simple := e.parameterList.Rest == nil
+	for _, item := range e.parameterList.List {
+		if _, ok := item.Target.(*ast.Identifier); !ok || item.Initializer != nil {
+			simple = false
+		}
+	}
+
+	if e.c.isStrict(e.body) {
+		if !simple {
+			e.c.throwSyntaxError(e.offset, "Illegal 'use strict' directive in function with non-simple parameter list")
+		}
+		e.c.scope.strict = true
+	}
+
+	var paramNames []*ast.Identifier
+	for _, item := range e.parameterList.List {
+		ast.BoundNames(item.Target, func(id *ast.Identifier) {
+			paramNames = append(paramNames, id)
+		})
+	}
+	if e.parameterList.Rest != nil {
+		ast.BoundNames(e.parameterList.Rest, func(id *ast.Identifier) {
+			paramNames = append(paramNames, id)
+		})
 	}
 
 	if e.c.scope.strict {
-		if e.expr.Name != nil {
-			e.c.checkIdentifierLName(e.expr.Name.Name, int(e.expr.Name.Idx)-1)
+		if e.name != nil && !e.isMethod {
+			e.c.checkIdentifierLName(e.name.Name, int(e.name.Idx)-1)
 		}
-		for _, item := range e.expr.ParameterList.List {
+		for _, item := range paramNames {
 			e.c.checkIdentifierName(item.Name, int(item.Idx)-1)
 			e.c.checkIdentifierLName(item.Name, int(item.Idx)-1)
 		}
 	}
 
-	length := len(e.expr.ParameterList.List)
+	length := len(e.parameterList.List)
 
-	for _, item := range e.expr.ParameterList.List {
-		_, unique := e.c.scope.bindNameShadow(item.Name)
-		if !unique && e.c.scope.strict {
-			e.c.throwSyntaxError(int(item.Idx)-1, "Strict mode function may not have duplicate parameter names (%s)", item.Name)
-			return
+	// the value of the length property: the number of parameters before the first one with a default value
+	funcLength := 0
+	for _, item := range e.parameterList.List {
+		if item.Initializer != nil {
+			break
 		}
+		funcLength++
 	}
-	paramsCount := len(e.c.scope.names)
-	e.c.compileDeclList(e.expr.DeclarationList, true)
+
+	if simple {
+		for _, item := range paramNames {
+			_, unique := e.c.scope.bindNameShadow(item.Name)
+			if !unique {
+				if e.c.scope.strict {
+					e.c.throwSyntaxError(int(item.Idx)-1, "Strict mode function may not have duplicate parameter names (%s)", item.Name)
+					return
+				}
+				if e.isArrow {
+					e.c.throwSyntaxError(int(item.Idx)-1, "Duplicate parameter name not allowed in this context")
+					return
+				}
+			}
+		}
+	} else {
+		bindParam := func(item *ast.Identifier) {
+			if _, unique := e.c.scope.bindName(item.Name); !unique {
+				e.c.throwSyntaxError(int(item.Idx)-1, "Duplicate parameter name not allowed in this context")
+			}
+		}
+		// a parameter which is a pattern occupies its position under a name that cannot clash with identifiers,
+		// the names bound by the pattern follow the positional parameters
+		for i, item := range e.parameterList.List {
+			if id, ok := item.Target.(*ast.Identifier); ok {
+				bindParam(id)
+			} else {
+				e.c.scope.bindName(strconv.Itoa(i))
+			}
+		}
+		for _, item := range e.parameterList.List {
+			if _, ok := item.Target.(*ast.Identifier); !ok {
+				ast.BoundNames(item.Target, bindParam)
+			}
+		}
+		if e.parameterList.Rest != nil {
+			ast.BoundNames(e.parameterList.Rest, bindParam)
+		}
+	}
+	paramsCount := length
+	e.c.compileDeclList(e.declarationList, true)
 	var needCallee bool
 	var calleeIdx uint32
-	if e.isExpr && e.expr.Name != nil {
-		if idx, ok := e.c.scope.bindName(e.expr.Name.Name); ok {
+	if e.isExpr && e.name != nil {
+		if idx, ok := e.c.scope.bindName(e.name.Name); ok {
 			calleeIdx = idx
 			needCallee = true
 		}
@@ -780,19 +1025,46 @@ func (e *compiledFunctionLiteral) emitGetter(putOnStack bool) {
 	if needCallee {
 		e.c.emit(loadCallee, setLocalP(calleeIdx))
 	}
+	if e.isAsync {
+		// the errors thrown by the initialisation of the parameters reject the promise
+		e.c.emit(startAsync)
+	}
+	if !simple {
+		e.emitParams()
+	}
+	if e.isGenerator {
+		e.c.emit(createGenerator)
+		e.c.scope.generator = true
+	}
+	e.c.scope.async = e.isAsync
 
-	e.c.compileFunctions(e.expr.DeclarationList)
-	e.c.markBlockStart()
-	e.c.compileStatement(e.expr.Body, false)
+	if decls := lexicalDeclarations(e.body); len(decls) > 0 {
+		e.c.checkLexicalNames(decls, e.c.p.funcName)
+		e.c.openBlockScope(nil, decls)
+		e.c.compileFunctions(e.declarationList, e.body)
+		e.c.markBlockStart()
+		e.c.compileStatements(e.body, false)
+		e.c.closeBlockScope()
+	} else {
+		e.c.compileFunctions(e.declarationList, e.body)
+		e.c.markBlockStart()
+		e.c.compileStatements(e.body, false)
+	}
 
 	if e.c.blockStart >= len(e.c.p.code)-1 || e.c.p.code[len(e.c.p.code)-1] != ret {
-		e.c.emit(loadUndef, ret)
+		if e.derived {
+			e.c.emit(loadDerivedThis, ret)
+		} else {
+			e.c.emit(loadUndef, ret)
+		}
 	}
 
-	if !e.c.scope.dynamic && !e.c.scope.accessed {
+	// arrow functions receive an already boxed this from the enclosing scope
+	needBoxThis := !e.c.scope.strict && e.c.scope.thisNeeded && !e.isArrow
+	if !e.c.scope.dynamic && !e.c.scope.accessed && !e.c.scope.argsNeeded {
 		// log.Printf("Function can use inline stash")
 		l := 0
-		if !e.c.scope.strict && e.c.scope.thisNeeded {
+		if needBoxThis {
 			l = 2
 			e.c.p.code = e.c.p.code[maxPreambleLen-2:]
 			e.c.p.code[1] = boxThis
@@ -801,6 +1073,10 @@ func (e *compiledFunctionLiteral) emitGetter(putOnStack bool) {
 			e.c.p.code = e.c.p.code[maxPreambleLen-1:]
 		}
 		e.c.convertFunctionToStashless(e.c.p.code, paramsCount)
+		e.c.p.stackNames = make(map[string]int, len(e.c.scope.names))
+		for name, idx := range e.c.scope.names {
+			e.c.p.stackNames[name], _ = e.c.convertInstrToStashless(idx, paramsCount)
+		}
 		for i, _ := range e.c.p.srcMap {
 			e.c.p.srcMap[i].pc -= maxPreambleLen - l
 		}
@@ -809,7 +1085,7 @@ func (e *compiledFunctionLiteral) emitGetter(putOnStack bool) {
 		if e.c.scope.argsNeeded {
 			l += 2
 		}
-		if !e.c.scope.strict && e.c.scope.thisNeeded {
+		if needBoxThis {
 			l++
 		}
 
@@ -820,13 +1096,13 @@ func (e *compiledFunctionLiteral) emitGetter(putOnStack bool) {
 		}
 		pos := 1 + len(e.c.scope.names)
 
-		if !e.c.scope.strict && e.c.scope.thisNeeded {
+		if needBoxThis {
 			code[pos] = boxThis
 			pos++
 		}
 
 		if e.c.scope.argsNeeded {
-			if e.c.scope.strict {
+			if e.c.scope.strict || !simple {
 				code[pos] = createArgsStrict(length)
 			} else {
 				code[pos] = createArgs(length)
@@ -848,33 +1124,289 @@ func (e *compiledFunctionLiteral) emitGetter(putOnStack bool) {
 	}
 
 	strict := e.c.scope.strict
+	thisNeeded := e.c.scope.thisNeeded
 	p := e.c.p
 	// e.c.p.dumpCode()
 	e.c.popScope()
 	e.c.p = savedPrg
 	e.c.blockStart = savedBlockStart
 	name := ""
-	if e.expr.Name != nil {
-		name = e.expr.Name.Name
+	if e.name != nil {
+		name = e.name.Name
+	}
+	if e.isArrow {
+		// the value of this the arrow function captures
+		if thisNeeded {
+			this := &compiledThisExpr{}
+			this.init(e.c, e.start)
+			this.emitGetter(true)
+		} else {
+			e.c.emit(loadUndef)
+		}
 	}
-	e.c.emit(&newFunc{prg: p, length: uint32(length), name: name, srcStart: uint32(e.expr.Idx0() - 1), srcEnd: uint32(e.expr.Idx1() - 1), strict: strict})
+	e.c.emit(&newFunc{prg: p, length: uint32(funcLength), name: name, srcStart: uint32(e.start - 1), srcEnd: uint32(e.end - 1), strict: strict, arrow: e.isArrow, home: e.isArrow && home, generator: e.isGenerator, async: e.isAsync})
 	if !putOnStack {
 		e.c.emit(pop)
 	}
 }
 
+// emitParams emits the initialisation of a parameter list which is not simple: the default values, the destructuring
+// of the patterns and the rest parameter.
+func (e *compiledFunctionLiteral) emitParams() {
+	for i, item := range e.parameterList.List {
+		var name string
+		if id, ok := item.Target.(*ast.Identifier); ok {
+			if item.Initializer == nil {
+				continue
+			}
+			name = id.Name
+		} else {
+			name = strconv.Itoa(i)
+		}
+		arg := &compiledIdentifierExpr{
+			name: name,
+		}
+		arg.init(e.c, item.Idx0())
+		var value compiledExpr = arg
+		if item.Initializer != nil {
+			def := &compiledDefaultExpr{
+				value: arg,
+				def:   e.c.compileExpression(item.Initializer),
+			}
+			def.init(e.c, item.Idx0())
+			value = def
+		}
+		e.c.emitPatternTarget(item.Target, value, e.c.emitPatternAssign)
+	}
+	if rest := e.parameterList.Rest; rest != nil {
+		value := &compiledArgsRestExpr{}
+		value.init(e.c, rest.Idx0())
+		e.c.emitPatternTarget(rest, value, e.c.emitPatternAssign)
+	}
+}
+
 func (c *compiler) compileFunctionLiteral(v *ast.FunctionLiteral, isExpr bool) compiledExpr {
 	if v.Name != nil && c.scope.strict {
 		c.checkIdentifierLName(v.Name.Name, int(v.Name.Idx)-1)
 	}
 	r := &compiledFunctionLiteral{
-		expr:   v,
-		isExpr: isExpr,
+		name:            v.Name,
+		parameterList:   v.ParameterList,
+		body:            v.Body.(*ast.BlockStatement).List,
+		declarationList: v.DeclarationList,
+		start:           v.Idx0(),
+		end:             v.Idx1(),
+		isExpr:          isExpr,
+		isGenerator:     v.Generator,
+		isAsync:         v.Async,
+	}
+	r.init(c, v.Idx0())
+	return r
+}
+
+func (c *compiler) compileArrowFunctionLiteral(v *ast.ArrowFunctionLiteral) compiledExpr {
+	var body []ast.Statement
+	switch b := v.Body.(type) {
+	case *ast.BlockStatement:
+		body = b.List
+	case *ast.ExpressionBody:
+		body = []ast.Statement{
+			&ast.ReturnStatement{
+				Return:   b.Idx0(),
+				Argument: b.Expression,
+			},
+		}
+	}
+	r := &compiledFunctionLiteral{
+		parameterList:   v.ParameterList,
+		body:            body,
+		declarationList: v.DeclarationList,
+		start:           v.Idx0(),
+		end:             v.Idx1(),
+		isExpr:          true,
+		isArrow:         true,
+		isAsync:         v.Async,
+	}
+	r.init(c, v.Idx0())
+	return r
+}
+
+func (c *compiler) compileClassLiteral(v *ast.ClassLiteral) compiledExpr {
+	r := &compiledClassLiteral{
+		expr: v,
 	}
 	r.init(c, v.Idx0())
 	return r
 }
 
+// emitGetter creates the constructor of the class, its prototype, which inherits from the prototype of the
+// parent class, and then defines the methods on them. Inside the class body (which is strict code) the class
+// name is bound to a constant.
+func (e *compiledClassLiteral) emitGetter(putOnStack bool) {
+	cls := e.expr
+	strict := e.c.scope.strict
+	e.c.scope.strict = true
+	if cls.Name != nil {
+		e.c.openBlockScope(nil, []*ast.LexicalDeclaration{{
+			Idx:   cls.Name.Idx,
+			Token: token.CONST,
+			List:  []*ast.VariableExpression{{Name: cls.Name.Name, Idx: cls.Name.Idx}},
+		}})
+	}
+
+	derived := cls.SuperClass != nil
+	if derived {
+		e.c.compileExpression(cls.SuperClass).emitGetter(true)
+	}
+
+	ctor := &compiledFunctionLiteral{
+		name:     cls.Name,
+		start:    cls.Idx0(),
+		end:      cls.Idx1(),
+		isMethod: true,
+		derived:  derived,
+	}
+	ctor.init(e.c, cls.Idx0())
+	for _, m := range cls.Body {
+		if m.Kind == "constructor" {
+			ctor.parameterList = m.Body.ParameterList
+			ctor.body = m.Body.Body.(*ast.BlockStatement).List
+			ctor.declarationList = m.Body.DeclarationList
+		}
+	}
+	if ctor.parameterList == nil {
+		// the default constructor of a derived class passes all its arguments to the parent constructor
+		ctor.parameterList = &ast.ParameterList{}
+		if derived {
+			args := &ast.Identifier{Name: "args", Idx: cls.Class}
+			ctor.parameterList.Rest = args
+			ctor.body = []ast.Statement{&ast.ExpressionStatement{
+				Expression: &ast.CallExpression{
+					Callee:          &ast.SuperExpression{Idx: cls.Class},
+					LeftParenthesis: cls.Class,
+					ArgumentList:    []ast.Expression{&ast.SpreadElement{Idx: cls.Class, Expression: args}},
+				},
+			}}
+		}
+	}
+	ctor.emitGetter(true)
+	if derived {
+		e.c.emit(newDerivedClass)
+	} else {
+		e.c.emit(newClass)
+	}
+
+	// the prototype is on top of the stack, the constructor is below it
+	e.emitMethods(false)
+	e.c.emit(pop)
+	e.emitMethods(true)
+
+	if cls.Name != nil {
+		e.c.emit(dup)
+		e.c.emitLexicalInit(cls.Name.Name)
+		e.c.closeBlockScope()
+	}
+	e.c.scope.strict = strict
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (e *compiledClassLiteral) emitMethods(static bool) {
+	for _, m := range e.expr.Body {
+		if m.Kind == "constructor" || m.Static != static {
+			continue
+		}
+		f := &compiledFunctionLiteral{
+			name:            &ast.Identifier{Name: m.Key, Idx: m.Body.Idx0()},
+			parameterList:   m.Body.ParameterList,
+			body:            m.Body.Body.(*ast.BlockStatement).List,
+			declarationList: m.Body.DeclarationList,
+			start:           m.Body.Idx0(),
+			end:             m.Body.Idx1(),
+			isMethod:        true,
+			isGenerator:     m.Body.Generator,
+			isAsync:         m.Body.Async,
+		}
+		f.init(e.c, m.Body.Idx0())
+		f.emitGetter(true)
+		switch m.Kind {
+		case "method":
+			e.c.emit(defineMethod(m.Key))
+		case "get":
+			e.c.emit(defineGetter(m.Key))
+		case "set":
+			e.c.emit(defineSetter(m.Key))
+		default:
+			panic(fmt.Errorf("Unknown method kind: %s", m.Kind))
+		}
+	}
+}
+
+func (e *compiledSuperCallExpr) emitGetter(putOnStack bool) {
+	nearestNonLexical(e.c.scope).thisNeeded = true
+	spread := e.c.emitArgs(e.args)
+	e.addSrcMap()
+	if spread {
+		e.c.emit(superCallSpread)
+	} else {
+		e.c.emit(superCall(len(e.args)))
+	}
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (c *compiler) checkSuperProperty(idx file.Idx) {
+	if !nearestNonLexical(c.scope).home {
+		c.throwSyntaxError(int(idx)-1, "'super' keyword unexpected here")
+	}
+}
+
+func (c *compiler) compileSuperDotExpression(v *ast.DotExpression) compiledExpr {
+	c.checkSuperProperty(v.Idx0())
+	r := &compiledSuperDotExpr{
+		name: v.Identifier.Name,
+	}
+	r.init(c, v.Idx0())
+	return r
+}
+
+func (c *compiler) compileSuperBracketExpression(v *ast.BracketExpression) compiledExpr {
+	c.checkSuperProperty(v.Idx0())
+	r := &compiledSuperBracketExpr{
+		member: c.compileExpression(v.Member),
+	}
+	r.init(c, v.Idx0())
+	return r
+}
+
+// emitThis pushes the value of this, the receiver of a super property reference.
+func (e *baseCompiledExpr) emitThis() {
+	this := &compiledThisExpr{}
+	this.init(e.c, file.Idx(e.offset+1))
+	this.emitGetter(true)
+}
+
+func (e *compiledSuperDotExpr) emitGetter(putOnStack bool) {
+	e.emitThis()
+	e.addSrcMap()
+	e.c.emit(getSuper(e.name))
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (e *compiledSuperBracketExpr) emitGetter(putOnStack bool) {
+	e.emitThis()
+	e.member.emitGetter(true)
+	e.addSrcMap()
+	e.c.emit(getSuperElem)
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
 func nearestNonLexical(s *scope) *scope {
 	for ; s != nil && s.lexical; s = s.outer {
 	}
@@ -885,8 +1417,13 @@ func (e *compiledThisExpr) emitGetter(putOnStack bool) {
 	if putOnStack {
 		e.addSrcMap()
 		if e.c.scope.eval || e.c.scope.isFunction() {
-			nearestNonLexical(e.c.scope).thisNeeded = true
-			e.c.emit(loadStack(0))
+			s := nearestNonLexical(e.c.scope)
+			s.thisNeeded = true
+			if s.derived {
+				e.c.emit(loadDerivedThis)
+			} else {
+				e.c.emit(loadStack(0))
+			}
 		} else {
 			e.c.emit(loadGlobalObject)
 		}
@@ -905,11 +1442,13 @@ func (e *compiledThisExpr) deleteExpr() compiledExpr {
 
 func (e *compiledNewExpr) emitGetter(putOnStack bool) {
 	e.callee.emitGetter(true)
-	for _, expr := range e.args {
-		expr.emitGetter(true)
-	}
+	spread := e.c.emitArgs(e.args)
 	e.addSrcMap()
-	e.c.emit(_new(len(e.args)))
+	if spread {
+		e.c.emit(newSpread)
+	} else {
+		e.c.emit(_new(len(e.args)))
+	}
 	if !putOnStack {
 		e.c.emit(pop)
 	}
@@ -957,7 +1496,7 @@ func (c *compiler) emitThrow(v Value) {
 	if o, ok := v.(*Object); ok {
 		t := o.self.getStr("name").String()
 		switch t {
-		case "TypeError":
+		case "TypeError", "RangeError":
 			c.emit(getVar1(t))
 			msg := o.self.getStr("message")
 			if msg != nil {
@@ -1310,6 +1849,14 @@ func (c *compiler) compileLogicalAnd(left, right ast.Expression, idx file.Idx) c
 }
 
 func (e *compiledVariableExpr) emitGetter(putOnStack bool) {
+	if e.pattern != nil {
+		e.initializer.emitGetter(true)
+		if putOnStack {
+			e.c.emit(dup)
+		}
+		e.c.emitPattern(e.pattern, e.c.emitPatternAssign)
+		return
+	}
 	if e.initializer != nil {
 		idExpr := &compiledIdentifierExpr{
 			name: e.name,
@@ -1329,6 +1876,7 @@ func (e *compiledVariableExpr) emitGetter(putOnStack bool) {
 func (c *compiler) compileVariableExpression(v *ast.VariableExpression) compiledExpr {
 	r := &compiledVariableExpr{
 		name:        v.Name,
+		pattern:     v.Target,
 		initializer: c.compileExpression(v.Initializer),
 	}
 	r.init(c, v.Idx0())
@@ -1339,8 +1887,17 @@ func (e *compiledObjectLiteral) emitGetter(putOnStack bool) {
 	e.addSrcMap()
 	e.c.emit(newObject)
 	for _, prop := range e.expr.Value {
+		if prop.Kind == "shorthand" {
+			if _, ok := prop.Value.(*ast.AssignExpression); ok {
+				e.c.throwSyntaxError(int(prop.Value.Idx0())-1, "Invalid shorthand property initializer")
+			}
+		}
 		e.c.compileExpression(prop.Value).emitGetter(true)
 		switch prop.Kind {
+		case "shorthand":
+			e.c.emit(setProp1(prop.Key))
+		case "spread":
+			e.c.emit(copySpread)
 		case "value":
 			if prop.Key == "__proto__" {
 				e.c.emit(setProto)
@@ -1370,19 +1927,60 @@ func (c *compiler) compileObjectLiteral(v *ast.ObjectLiteral) compiledExpr {
 
 func (e *compiledArrayLiteral) emitGetter(putOnStack bool) {
 	e.addSrcMap()
-	for _, v := range e.expr.Value {
-		if v != nil {
-			e.c.compileExpression(v).emitGetter(true)
-		} else {
-			e.c.emit(loadNil)
-		}
+	elements := make([]compiledExpr, len(e.expr.Value))
+	for i, v := range e.expr.Value {
+		elements[i] = e.c.compileExpression(v)
 	}
-	e.c.emit(newArray(len(e.expr.Value)))
+	e.c.emitArrayElements(elements)
 	if !putOnStack {
 		e.c.emit(pop)
 	}
 }
 
+// emitArrayElements emits an array literal with the given elements, nil elements are holes. The elements before the
+// first spread element are collected by newArray, the following ones are appended one by one.
+func (c *compiler) emitArrayElements(elements []compiledExpr) {
+	n := 0
+	for ; n < len(elements); n++ {
+		if _, ok := elements[n].(*compiledSpreadExpr); ok {
+			break
+		}
+		if elements[n] != nil {
+			elements[n].emitGetter(true)
+		} else {
+			c.emit(loadNil)
+		}
+	}
+	c.emit(newArray(n))
+	for _, elt := range elements[n:] {
+		switch elt := elt.(type) {
+		case nil:
+			c.emit(loadNil, appendArray)
+		case *compiledSpreadExpr:
+			elt.expr.emitGetter(true)
+			c.emit(appendArraySpread)
+		default:
+			elt.emitGetter(true)
+			c.emit(appendArray)
+		}
+	}
+}
+
+// emitArgs emits the arguments of a call. If some of them are spread, all the arguments are collected into an array
+// and true is returned.
+func (c *compiler) emitArgs(args []compiledExpr) bool {
+	for _, arg := range args {
+		if _, ok := arg.(*compiledSpreadExpr); ok {
+			c.emitArrayElements(args)
+			return true
+		}
+	}
+	for _, arg := range args {
+		arg.emitGetter(true)
+	}
+	return false
+}
+
 func (c *compiler) compileArrayLiteral(v *ast.ArrayLiteral) compiledExpr {
 	r := &compiledArrayLiteral{
 		expr: v,
@@ -1427,6 +2025,14 @@ func (e *compiledCallExpr) emitGetter(putOnStack bool) {
 		e.c.emit(dup)
 		callee.member.emitGetter(true)
 		e.c.emit(getElemCallee)
+	case *compiledSuperDotExpr:
+		callee.emitThis()
+		e.c.emit(dup, getSuper(callee.name))
+	case *compiledSuperBracketExpr:
+		callee.emitThis()
+		e.c.emit(dup)
+		callee.member.emitGetter(true)
+		e.c.emit(getSuperElem)
 	case *compiledIdentifierExpr:
 		e.c.emit(loadUndef)
 		calleeName = callee.name
@@ -1436,16 +2042,16 @@ func (e *compiledCallExpr) emitGetter(putOnStack bool) {
 		callee.emitGetter(true)
 	}
 
-	for _, expr := range e.args {
-		expr.emitGetter(true)
-	}
+	spread := e.c.emitArgs(e.args)
 
 	e.addSrcMap()
-	if calleeName == "eval" {
+	if spread {
+		e.c.emit(callSpread)
+	} else if calleeName == "eval" {
 		e.c.scope.dynamic = true
-		e.c.scope.thisNeeded = true
-		if e.c.scope.lexical {
-			e.c.scope.outer.dynamic = true
+		nearestNonLexical(e.c.scope).thisNeeded = true
+		for s := e.c.scope; s.lexical; s = s.outer {
+			s.outer.dynamic = true
 		}
 		e.c.scope.accessed = true
 		if e.c.scope.strict {
@@ -1477,6 +2083,17 @@ func (c *compiler) compileCallExpression(v *ast.CallExpression) compiledExpr {
 		args[i] = c.compileExpression(argExpr)
 	}
 
+	if callee, ok := v.Callee.(*ast.SuperExpression); ok {
+		if !nearestNonLexical(c.scope).derived {
+			c.throwSyntaxError(int(callee.Idx)-1, "'super' keyword unexpected here")
+		}
+		r := &compiledSuperCallExpr{
+			args: args,
+		}
+		r.init(c, v.LeftParenthesis)
+		return r
+	}
+
 	r := &compiledCallExpr{
 		args:   args,
 		callee: c.compileExpression(v.Callee),
@@ -1509,6 +2126,8 @@ func (c *compiler) compileNumberLiteral(v *ast.NumberLiteral) compiledExpr {
 		val = intToValue(num)
 	case float64:
 		val = floatToValue(num)
+	case *big.Int:
+		val = (*valueBigInt)(num)
 	default:
 		panic(fmt.Errorf("Unsupported number literal type: %T", v.Value))
 	}
@@ -1527,6 +2146,72 @@ func (c *compiler) compileStringLiteral(v *ast.StringLiteral) compiledExpr {
 	return r
 }
 
+func (e *compiledTemplateLiteral) emitGetter(putOnStack bool) {
+	n := 0
+	for i, elt := range e.elements {
+		if elt.Parsed != "" || len(e.expressions) == 0 {
+			e.c.emit(loadVal(e.c.p.defineLiteralValue(newStringValue(elt.Parsed))))
+			n++
+		}
+		if i < len(e.expressions) {
+			e.expressions[i].emitGetter(true)
+			n++
+		}
+	}
+	e.addSrcMap()
+	if n > 1 || len(e.expressions) > 0 {
+		e.c.emit(concatStrings(n))
+	}
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (e *compiledTemplateObject) emitGetter(putOnStack bool) {
+	cooked := make([]Value, len(e.elements))
+	raw := make([]Value, len(e.elements))
+	for i, elt := range e.elements {
+		if elt.Valid {
+			cooked[i] = newStringValue(elt.Parsed)
+		}
+		raw[i] = newStringValue(elt.Literal)
+	}
+	e.c.emit(newGetTemplateObject(cooked, raw))
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (c *compiler) compileTemplateLiteral(v *ast.TemplateLiteral) compiledExpr {
+	if v.Tag != nil {
+		// a tagged template is a call of the tag function with the strings array and the substitutions
+		obj := &compiledTemplateObject{
+			elements: v.Elements,
+		}
+		obj.init(c, v.OpenQuote)
+		args := make([]compiledExpr, 0, len(v.Expressions)+1)
+		args = append(args, obj)
+		for _, expr := range v.Expressions {
+			args = append(args, c.compileExpression(expr))
+		}
+		r := &compiledCallExpr{
+			args:   args,
+			callee: c.compileExpression(v.Tag),
+		}
+		r.init(c, v.OpenQuote)
+		return r
+	}
+	r := &compiledTemplateLiteral{
+		elements:    v.Elements,
+		expressions: make([]compiledExpr, len(v.Expressions)),
+	}
+	for i, expr := range v.Expressions {
+		r.expressions[i] = c.compileExpression(expr)
+	}
+	r.init(c, v.Idx0())
+	return r
+}
+
 func (c *compiler) compileBooleanLiteral(v *ast.BooleanLiteral) compiledExpr {
 	var val Value
 	if v.Value {
@@ -1560,3 +2245,205 @@ func (e *compiledEnumGetExpr) emitGetter(putOnStack bool) {
 		e.c.emit(pop)
 	}
 }
+
+func (e *compiledPatternExpr) emitGetter(putOnStack bool) {
+	e.c.throwSyntaxError(e.offset, "Invalid destructuring assignment target")
+}
+
+func (e *compiledPatternExpr) emitSetter(valueExpr compiledExpr) {
+	valueExpr.emitGetter(true)
+	e.c.emit(dup)
+	e.c.emitPattern(e.pattern, e.c.emitPatternAssign)
+}
+
+func (e *compiledSpreadExpr) emitGetter(putOnStack bool) {
+	e.c.throwSyntaxError(e.offset, "Unexpected token ...")
+}
+
+func (e *compiledDefaultExpr) emitGetter(putOnStack bool) {
+	e.value.emitGetter(true)
+	e.c.emit(dup, loadUndef, op_strict_eq)
+	j := len(e.c.p.code)
+	e.c.emit(nil, pop)
+	e.def.emitGetter(true)
+	e.c.p.code[j] = jne(len(e.c.p.code) - j)
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (e *compiledIterNextExpr) emitGetter(putOnStack bool) {
+	e.c.emit(iterGetNextOrUndef)
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (e *compiledIterRestExpr) emitGetter(putOnStack bool) {
+	e.c.emit(iterGetRest)
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (e *compiledPatternPropExpr) emitGetter(putOnStack bool) {
+	e.addSrcMap()
+	e.c.emit(enumGet, getProp(e.name))
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (e *compiledPatternRestExpr) emitGetter(putOnStack bool) {
+	for _, name := range e.excluded {
+		e.c.emit(loadVal(e.c.p.defineLiteralValue(newStringValue(name))))
+	}
+	e.c.emit(enumGet, copyRest(len(e.excluded)))
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (e *compiledArgsRestExpr) emitGetter(putOnStack bool) {
+	e.c.emit(createArgsRestStash)
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+// emitPattern emits the destructuring of the value on top of the stack (which is consumed) according to an array
+// or an object pattern. assign is called for each target which is not a pattern itself, with an expression
+// producing the value to assign.
+func (c *compiler) emitPattern(pattern ast.Expression, assign func(target ast.Expression, value compiledExpr)) {
+	switch pattern := pattern.(type) {
+	case *ast.ArrayPattern:
+		c.emit(iterate)
+		for _, elt := range pattern.Elements {
+			if elt == nil {
+				c.emit(iterGetNextOrUndef, pop)
+				continue
+			}
+			value := &compiledIterNextExpr{}
+			value.init(c, elt.Idx0())
+			c.emitPatternElement(elt, value, assign)
+		}
+		if pattern.Rest != nil {
+			value := &compiledIterRestExpr{}
+			value.init(c, pattern.Rest.Idx0())
+			c.emitPatternTarget(pattern.Rest, value, assign)
+		}
+		c.emit(enumPopClose)
+	case *ast.ObjectPattern:
+		c.emit(destructObj)
+		for _, prop := range pattern.Properties {
+			value := &compiledPatternPropExpr{
+				name: prop.Key,
+			}
+			value.init(c, prop.Value.Idx0())
+			c.emitPatternElement(prop.Value, value, assign)
+		}
+		if pattern.Rest != nil {
+			value := &compiledPatternRestExpr{
+				excluded: make([]string, len(pattern.Properties)),
+			}
+			for i, prop := range pattern.Properties {
+				value.excluded[i] = prop.Key
+			}
+			value.init(c, pattern.Rest.Idx0())
+			c.emitPatternTarget(pattern.Rest, value, assign)
+		}
+		c.emit(enumPop)
+	default:
+		panic(fmt.Errorf("Unknown pattern type: %T", pattern))
+	}
+}
+
+// emitPatternElement assigns the value to a pattern element, which may have a default value.
+func (c *compiler) emitPatternElement(elt ast.Expression, value compiledExpr, assign func(ast.Expression, compiledExpr)) {
+	if elt, ok := elt.(*ast.AssignExpression); ok {
+		def := &compiledDefaultExpr{
+			value: value,
+			def:   c.compileExpression(elt.Right),
+		}
+		def.init(c, elt.Idx0())
+		c.emitPatternTarget(elt.Left, def, assign)
+		return
+	}
+	c.emitPatternTarget(elt, value, assign)
+}
+
+// emitPatternTarget assigns the value to the target of a pattern element, destructuring it further if the target is
+// a pattern.
+func (c *compiler) emitPatternTarget(target ast.Expression, value compiledExpr, assign func(ast.Expression, compiledExpr)) {
+	switch target.(type) {
+	case *ast.ArrayPattern, *ast.ObjectPattern:
+		value.emitGetter(true)
+		c.emitPattern(target, assign)
+	default:
+		assign(target, value)
+	}
+}
+
+// emitPatternAssign is the assign function of emitPattern for destructuring assignments, var declarations and
+// parameters.
+func (c *compiler) emitPatternAssign(target ast.Expression, value compiledExpr) {
+	c.compileExpression(target).emitSetter(value)
+	c.emit(pop)
+}
+
+// A generator suspended by yield is resumed with a completion (see resumeNext) which is handled by yieldResume: a
+// normal completion continues after the yield expression, a return completion returns from the generator (running
+// the finally blocks on the way) and a throw completion throws at the position of the yield expression.
+func (e *compiledYieldExpr) emitGetter(putOnStack bool) {
+	if !nearestNonLexical(e.c.scope).generator {
+		e.c.throwSyntaxError(e.offset, "Yield expression not allowed in formal parameter")
+	}
+	if e.arg != nil {
+		e.arg.emitGetter(true)
+	} else {
+		e.c.emit(loadUndef)
+	}
+	e.addSrcMap()
+	if e.delegate {
+		// the iterator is kept on the iterator stack while the generator delegates to it
+		e.c.emit(yieldDelegateStart)
+		j := len(e.c.p.code)
+		e.c.emit(nil, enumPop)
+		e.c.emitReturn()
+		e.c.p.code[j] = yieldDelegate(len(e.c.p.code) - j)
+		e.c.emit(enumPop)
+	} else {
+		e.c.emit(yield)
+		j := len(e.c.p.code)
+		e.c.emit(nil)
+		e.c.emitReturn()
+		e.c.p.code[j] = yieldResume(len(e.c.p.code) - j)
+	}
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+// An async function suspended by await is resumed when the awaited value settles, either with a normal completion
+// or with a throw completion if it has been rejected.
+func (e *compiledAwaitExpr) emitGetter(putOnStack bool) {
+	if !nearestNonLexical(e.c.scope).async {
+		e.c.throwSyntaxError(e.offset, "Await expression not allowed in formal parameter")
+	}
+	e.arg.emitGetter(true)
+	e.addSrcMap()
+	// there are no return completions, the normal one continues with the next instruction
+	e.c.emit(await, yieldResume(1))
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
+
+func (e *compiledImportCall) emitGetter(putOnStack bool) {
+	e.arg.emitGetter(true)
+	e.addSrcMap()
+	e.c.emit(importCall)
+	if !putOnStack {
+		e.c.emit(pop)
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"github.com/dop251/goja/ast"
 	"github.com/dop251/goja/file"
 	"github.com/dop251/goja/token"
-	"strconv"
+	"sort"
 )
 
 func (c *compiler) compileStatement(v ast.Statement, needResult bool) {
@@ -18,6 +18,10 @@ func (c *compiler) compileStatement(v ast.Statement, needResult bool) {
 		c.compileExpressionStatement(v, needResult)
 	case *ast.VariableStatement:
 		c.compileVariableStatement(v, needResult)
+	case *ast.LexicalDeclaration:
+		c.compileLexicalDeclaration(v, needResult)
+	case *ast.ClassDeclaration:
+		c.compileClassDeclaration(v, needResult)
 	case *ast.ReturnStatement:
 		c.compileReturnStatement(v)
 	case *ast.IfStatement:
@@ -28,6 +32,8 @@ func (c *compiler) compileStatement(v ast.Statement, needResult bool) {
 		c.compileForStatement(v, needResult)
 	case *ast.ForInStatement:
 		c.compileForInStatement(v, needResult)
+	case *ast.ForOfStatement:
+		c.compileForOfStatement(v, needResult)
 	case *ast.WhileStatement:
 		c.compileWhileStatement(v, needResult)
 	case *ast.BranchStatement:
@@ -48,6 +54,7 @@ func (c *compiler) compileStatement(v ast.Statement, needResult bool) {
 	case *ast.WithStatement:
 		c.compileWithStatement(v, needResult)
 	case *ast.DebuggerStatement:
+		c.compileDebuggerStatement(v, needResult)
 	default:
 		panic(fmt.Errorf("Unknown statement type: %T", v))
 	}
@@ -63,6 +70,8 @@ func (c *compiler) compileLabeledStatement(v *ast.LabelledStatement, needResult
 	switch s := v.Statement.(type) {
 	case *ast.ForInStatement:
 		c.compileLabeledForInStatement(s, needResult, label)
+	case *ast.ForOfStatement:
+		c.compileLabeledForOfStatement(s, needResult, label)
 	case *ast.ForStatement:
 		c.compileLabeledForStatement(s, needResult, label)
 	case *ast.WhileStatement:
@@ -88,7 +97,7 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement) {
 	lbl := len(c.p.code)
 	c.emit(nil)
 	c.compileStatement(v.Body, false)
-	c.emit(halt)
+	c.emit(leaveTry)
 	lbl2 := len(c.p.code)
 	c.emit(nil)
 	var catchOffset int
@@ -100,7 +109,8 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement) {
 		c.scope.bindName(v.Catch.Parameter.Name)
 		c.scope.lexical = true
 		start := len(c.p.code)
-		c.emit(nil)
+		// replaced below if the catch variable can be kept on the stack, otherwise it is skipped by the try
+		c.emit(noop)
 		catchOffset = len(c.p.code) - lbl
 		c.emit(enterCatch(v.Catch.Parameter.Name))
 		c.compileStatement(v.Catch.Body, false)
@@ -122,9 +132,7 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement) {
 					// remap
 					newIdx, exists := m[idx]
 					if !exists {
-						exname := " __tmp" + strconv.Itoa(c.scope.lastFreeTmp)
-						c.scope.lastFreeTmp++
-						newIdx, _ = c.scope.bindName(exname)
+						newIdx = c.scope.bindTmp()
 						m[idx] = newIdx
 					}
 					return newIdx
@@ -145,8 +153,8 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement) {
 				c.p.code[start+1] = pop
 				catchOffset--
 			} else {
-				c.p.code[start+1] = nil
-				catchOffset++
+				c.p.code[start] = noop
+				c.p.code[start+1] = pop
 			}
 		} else {
 			c.scope.accessed = true
@@ -177,7 +185,7 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement) {
 				}
 				c.scope.lastFreeTmp--
 			}*/
-		c.emit(halt)
+		c.emit(leaveTry)
 	}
 	var finallyOffset int
 	if v.Finally != nil {
@@ -185,7 +193,7 @@ func (c *compiler) compileTryStatement(v *ast.TryStatement) {
 		c.emit(nil)
 		finallyOffset = len(c.p.code) - lbl
 		c.compileStatement(v.Finally, false)
-		c.emit(halt, retFinally)
+		c.emit(leaveFinally)
 		c.p.code[lbl1] = jump(len(c.p.code) - lbl1)
 	}
 	c.p.code[lbl] = try{catchOffset: int32(catchOffset), finallyOffset: int32(finallyOffset), dynamic: dynamicCatch}
@@ -231,6 +239,13 @@ func (c *compiler) compileForStatement(v *ast.ForStatement, needResult bool) {
 }
 
 func (c *compiler) compileLabeledForStatement(v *ast.ForStatement, needResult bool, label string) {
+	var scopeBlock *block
+	if v.Lexical != nil {
+		// the bindings are copied into a new scope before each iteration so that closures capture the value
+		// of the iteration they were created in
+		c.openBlockScope(nil, []*ast.LexicalDeclaration{v.Lexical})
+		scopeBlock = c.block
+	}
 	c.block = &block{
 		typ:        blockLoop,
 		outer:      c.block,
@@ -238,7 +253,10 @@ func (c *compiler) compileLabeledForStatement(v *ast.ForStatement, needResult bo
 		needResult: needResult,
 	}
 
-	if v.Initializer != nil {
+	if v.Lexical != nil {
+		c.compileLexicalDeclaration(v.Lexical, false)
+		c.emitBlockScopeInstr(scopeBlock, copyBlockScope)
+	} else if v.Initializer != nil {
 		c.compileExpression(v.Initializer).emitGetter(false)
 	}
 	if needResult {
@@ -284,6 +302,9 @@ func (c *compiler) compileLabeledForStatement(v *ast.ForStatement, needResult bo
 	c.markBlockStart()
 	c.compileStatement(v.Body, needResult)
 	c.block.cont = len(c.p.code)
+	if scopeBlock != nil {
+		c.emitBlockScopeInstr(scopeBlock, copyBlockScope)
+	}
 	if v.Update != nil {
 		c.compileExpression(v.Update).emitGetter(false)
 	}
@@ -295,6 +316,9 @@ func (c *compiler) compileLabeledForStatement(v *ast.ForStatement, needResult bo
 	}
 end:
 	c.leaveBlock()
+	if scopeBlock != nil {
+		c.closeBlockScope()
+	}
 	c.markBlockStart()
 }
 
@@ -303,15 +327,16 @@ func (c *compiler) compileForInStatement(v *ast.ForInStatement, needResult bool)
 }
 
 func (c *compiler) compileLabeledForInStatement(v *ast.ForInStatement, needResult bool, label string) {
+	// the source is evaluated outside of the loop, a return from a yield in it must not pop the iterator
+	c.compileExpression(v.Source).emitGetter(true)
+	c.emit(enumerate)
 	c.block = &block{
 		typ:        blockLoop,
 		outer:      c.block,
 		label:      label,
 		needResult: needResult,
+		iterating:  true,
 	}
-
-	c.compileExpression(v.Source).emitGetter(true)
-	c.emit(enumerate)
 	if needResult {
 		c.emit(loadUndef)
 	}
@@ -319,13 +344,22 @@ func (c *compiler) compileLabeledForInStatement(v *ast.ForInStatement, needResul
 	c.markBlockStart()
 	c.block.cont = start
 	c.emit(nil)
-	c.compileExpression(v.Into).emitSetter(&c.enumGetExpr)
-	c.emit(pop)
+	if v.Lexical != nil {
+		c.openBlockScope(nil, []*ast.LexicalDeclaration{v.Lexical})
+		c.enumGetExpr.emitGetter(true)
+		c.emitLexicalBinding(v.Lexical.List[0])
+	} else {
+		c.compileExpression(v.Into).emitSetter(&c.enumGetExpr)
+		c.emit(pop)
+	}
 	if needResult {
 		c.emit(pop) // remove last result
 	}
 	c.markBlockStart()
 	c.compileStatement(v.Body, needResult)
+	if v.Lexical != nil {
+		c.closeBlockScope()
+	}
 	c.emit(jump(start - len(c.p.code)))
 	c.p.code[start] = enumNext(len(c.p.code) - start)
 	c.leaveBlock()
@@ -333,6 +367,51 @@ func (c *compiler) compileLabeledForInStatement(v *ast.ForInStatement, needResul
 	c.emit(enumPop)
 }
 
+func (c *compiler) compileForOfStatement(v *ast.ForOfStatement, needResult bool) {
+	c.compileLabeledForOfStatement(v, needResult, "")
+}
+
+func (c *compiler) compileLabeledForOfStatement(v *ast.ForOfStatement, needResult bool, label string) {
+	// the source is evaluated outside of the loop, a return from a yield in it must not pop the iterator
+	c.compileExpression(v.Source).emitGetter(true)
+	c.emit(iterate)
+	c.block = &block{
+		typ:        blockLoop,
+		outer:      c.block,
+		label:      label,
+		needResult: needResult,
+		iterating:  true,
+	}
+	if needResult {
+		c.emit(loadUndef)
+	}
+	start := len(c.p.code)
+	c.markBlockStart()
+	c.block.cont = start
+	c.emit(nil)
+	if v.Lexical != nil {
+		c.openBlockScope(nil, []*ast.LexicalDeclaration{v.Lexical})
+		c.enumGetExpr.emitGetter(true)
+		c.emitLexicalBinding(v.Lexical.List[0])
+	} else {
+		c.compileExpression(v.Into).emitSetter(&c.enumGetExpr)
+		c.emit(pop)
+	}
+	if needResult {
+		c.emit(pop) // remove last result
+	}
+	c.markBlockStart()
+	c.compileStatement(v.Body, needResult)
+	if v.Lexical != nil {
+		c.closeBlockScope()
+	}
+	c.emit(jump(start - len(c.p.code)))
+	c.p.code[start] = iterNext(len(c.p.code) - start)
+	c.leaveBlock()
+	c.markBlockStart()
+	c.emit(enumPopClose)
+}
+
 func (c *compiler) compileWhileStatement(v *ast.WhileStatement, needResult bool) {
 	c.compileLabeledWhileStatement(v, needResult, "")
 }
@@ -397,6 +476,12 @@ func (c *compiler) compileEmptyStatement(needResult bool) {
 	}
 }
 
+func (c *compiler) compileDebuggerStatement(v *ast.DebuggerStatement, needResult bool) {
+	c.compileEmptyStatement(needResult)
+	c.p.srcMap = append(c.p.srcMap, srcMapItem{pc: len(c.p.code), srcPos: int(v.Debugger) - 1})
+	c.emit(debuggerStmt)
+}
+
 func (c *compiler) compileBranchStatement(v *ast.BranchStatement, needResult bool) {
 	switch v.Token {
 	case token.BREAK:
@@ -464,13 +549,20 @@ func (c *compiler) findBreakBlock(label *ast.Identifier) (block *block) {
 
 func (c *compiler) compileBreak(label *ast.Identifier, idx file.Idx) {
 	var block *block
+	first := len(c.p.code) == c.blockStart
 	if label != nil {
 		for b := c.block; b != nil; b = b.outer {
 			switch b.typ {
 			case blockTry:
-				c.emit(halt)
+				c.emit(leaveTry)
 			case blockWith:
 				c.emit(leaveWith)
+			case blockScope:
+				c.emitBlockScopeInstr(b, leaveBlockScope)
+			case blockLoop:
+				if b.iterating && b.label != label.Name {
+					c.emit(enumPopClose)
+				}
 			}
 			if b.label == label.Name {
 				block = b
@@ -483,9 +575,11 @@ func (c *compiler) compileBreak(label *ast.Identifier, idx file.Idx) {
 		for b := c.block; b != nil; b = b.outer {
 			switch b.typ {
 			case blockTry:
-				c.emit(halt)
+				c.emit(leaveTry)
 			case blockWith:
 				c.emit(leaveWith)
+			case blockScope:
+				c.emitBlockScopeInstr(b, leaveBlockScope)
 			case blockLoop, blockSwitch:
 				block = b
 				break L
@@ -494,7 +588,7 @@ func (c *compiler) compileBreak(label *ast.Identifier, idx file.Idx) {
 	}
 
 	if block != nil {
-		if len(c.p.code) == c.blockStart && block.needResult {
+		if first && block.needResult {
 			c.emit(loadUndef)
 		}
 		block.breaks = append(block.breaks, len(c.p.code))
@@ -506,20 +600,27 @@ func (c *compiler) compileBreak(label *ast.Identifier, idx file.Idx) {
 
 func (c *compiler) compileContinue(label *ast.Identifier, idx file.Idx) {
 	var block *block
+	first := len(c.p.code) == c.blockStart
 	if label != nil {
 		for b := c.block; b != nil; b = b.outer {
 			if b.typ == blockTry {
-				c.emit(halt)
+				c.emit(leaveTry)
+			} else if b.typ == blockScope {
+				c.emitBlockScopeInstr(b, leaveBlockScope)
 			} else if b.typ == blockLoop && b.label == label.Name {
 				block = b
 				break
+			} else if b.iterating {
+				c.emit(enumPopClose)
 			}
 		}
 	} else {
 		// find the nearest loop
 		for b := c.block; b != nil; b = b.outer {
 			if b.typ == blockTry {
-				c.emit(halt)
+				c.emit(leaveTry)
+			} else if b.typ == blockScope {
+				c.emitBlockScopeInstr(b, leaveBlockScope)
 			} else if b.typ == blockLoop {
 				block = b
 				break
@@ -528,7 +629,7 @@ func (c *compiler) compileContinue(label *ast.Identifier, idx file.Idx) {
 	}
 
 	if block != nil {
-		if len(c.p.code) == c.blockStart && block.needResult {
+		if first && block.needResult {
 			c.emit(loadUndef)
 		}
 		block.conts = append(block.conts, len(c.p.code))
@@ -602,9 +703,20 @@ func (c *compiler) compileReturnStatement(v *ast.ReturnStatement) {
 	} else {
 		c.emit(loadUndef)
 	}
+	if nearestNonLexical(c.scope).derived {
+		c.emit(checkDerivedRet)
+	}
+	c.emitReturn()
+}
+
+// emitReturn emits the instructions which leave the enclosing try blocks and iterations and return the value on top
+// of the stack.
+func (c *compiler) emitReturn() {
 	for b := c.block; b != nil; b = b.outer {
 		if b.typ == blockTry {
-			c.emit(halt)
+			c.emit(leaveTry)
+		} else if b.iterating {
+			c.emit(enumPopClose)
 		}
 	}
 	c.emit(ret)
@@ -692,9 +804,278 @@ func (c *compiler) compileGenericLabeledStatement(v ast.Statement, needResult bo
 }
 
 func (c *compiler) compileBlockStatement(v *ast.BlockStatement, needResult bool) {
+	if decls := lexicalDeclarations(v.List); len(decls) > 0 {
+		c.openBlockScope(v, decls)
+		c.compileStatements(v.List, needResult)
+		c.closeBlockScope()
+		return
+	}
 	c.compileStatements(v.List, needResult)
 }
 
+func lexicalDeclarations(list []ast.Statement) (decls []*ast.LexicalDeclaration) {
+	for _, st := range list {
+		switch decl := st.(type) {
+		case *ast.LexicalDeclaration:
+			decls = append(decls, decl)
+		case *ast.ClassDeclaration:
+			// the name of a class declaration is bound like a let declaration
+			name := decl.Class.Name
+			decls = append(decls, &ast.LexicalDeclaration{
+				Idx:   name.Idx,
+				Token: token.LET,
+				List:  []*ast.VariableExpression{{Name: name.Name, Idx: name.Idx}},
+			})
+		}
+	}
+	return
+}
+
+// checkLexicalNames throws a SyntaxError if a let or const declaration conflicts with a var, a function or a parameter
+// declared in the current (function or program) scope.
+func (c *compiler) checkLexicalNames(decls []*ast.LexicalDeclaration, exclude string) {
+	seen := make(map[string]bool)
+	for _, decl := range decls {
+		lexicalNames(decl, func(name string, idx file.Idx) {
+			if c.scope.strict {
+				c.checkIdentifierLName(name, int(idx)-1)
+				c.checkIdentifierName(name, int(idx)-1)
+			}
+			if _, exists := c.scope.names[name]; (exists && name != exclude) || seen[name] {
+				c.throwSyntaxError(int(idx)-1, "Identifier '%s' has already been declared", name)
+			}
+			seen[name] = true
+		})
+	}
+}
+
+// lexicalNames calls f for each name bound by the let or const declaration, including the names in destructuring
+// patterns.
+func lexicalNames(decl *ast.LexicalDeclaration, f func(name string, idx file.Idx)) {
+	for _, item := range decl.List {
+		if item.Target != nil {
+			ast.BoundNames(item.Target, func(id *ast.Identifier) {
+				f(id.Name, id.Idx)
+			})
+		} else {
+			f(item.Name, item.Idx)
+		}
+	}
+}
+
+// openBlockScope creates a scope for the let and const declarations of a block. Until the scope is closed it is not
+// known whether it needs a stash, so the instructions that manage it are recorded in a blockScope block and either
+// kept or removed by closeBlockScope.
+func (c *compiler) openBlockScope(node ast.Node, decls []*ast.LexicalDeclaration) {
+	c.block = &block{
+		typ:      blockScope,
+		outer:    c.block,
+		accessed: c.scope.accessed,
+		dynamic:  nearestNonLexical(c.scope).dynamic,
+	}
+	c.newScope()
+	c.scope.lexical = true
+	c.scope.lexicals = make(map[string]*lexicalBinding)
+	for _, decl := range decls {
+		isConst := decl.Token == token.CONST
+		lexicalNames(decl, func(name string, idx file.Idx) {
+			if c.scope.strict {
+				c.checkIdentifierLName(name, int(idx)-1)
+				c.checkIdentifierName(name, int(idx)-1)
+			}
+			if _, exists := c.scope.names[name]; exists {
+				c.throwSyntaxError(int(idx)-1, "Identifier '%s' has already been declared", name)
+			}
+			c.scope.names[name] = uint32(len(c.scope.names))
+			c.scope.lexicals[name] = &lexicalBinding{isConst: isConst}
+		})
+	}
+	c.emitBlockScopeInstr(c.block, enterBlockScope{})
+	if node != nil {
+		funcs := nearestNonLexical(c.scope).blockFuncs[node]
+		for _, f := range funcs {
+			if _, exists := c.scope.lexicals[f.Function.Name.Name]; exists {
+				c.throwSyntaxError(int(f.Function.Name.Idx)-1, "Identifier '%s' has already been declared", f.Function.Name.Name)
+			}
+		}
+		for _, f := range funcs {
+			c.compileFunction(f)
+		}
+	}
+	c.markBlockStart()
+}
+
+func (c *compiler) emitBlockScopeInstr(b *block, instr instruction) {
+	b.stashInstrs = append(b.stashInstrs, len(c.p.code))
+	c.emit(instr)
+}
+
+// closeBlockScope closes the scope created by openBlockScope. If the bindings are never accessed by name, from
+// a closure or in their temporal dead zone, they are moved to temporary variables of the enclosing function and the
+// block does not create a stash.
+func (c *compiler) closeBlockScope() {
+	b := c.block
+	c.emitBlockScopeInstr(b, leaveBlockScope)
+	s := c.scope
+	c.popScope()
+	c.block = b.outer
+	start := b.stashInstrs[0]
+	if !b.dynamic && !s.dynamic && !s.accessed && !s.tdz {
+		c.scope.accessed = b.accessed
+		code := c.p.code[start:]
+		m := make(map[uint32]uint32)
+		remap := func(instr uint32) uint32 {
+			level := instr >> 24
+			idx := instr & 0x00FFFFFF
+			if level > 0 {
+				level--
+				return (level << 24) | idx
+			}
+			newIdx, exists := m[idx]
+			if !exists {
+				newIdx = c.scope.bindTmp()
+				m[idx] = newIdx
+			}
+			return newIdx
+		}
+		for pc, instr := range code {
+			switch instr := instr.(type) {
+			case getLocal:
+				code[pc] = getLocal(remap(uint32(instr)))
+			case setLocal:
+				code[pc] = setLocal(remap(uint32(instr)))
+			case setLocalP:
+				code[pc] = setLocalP(remap(uint32(instr)))
+			}
+		}
+		c.removeInstructions(b.stashInstrs)
+	} else {
+		c.scope.accessed = true
+		consts := make(map[string]bool)
+		for name, b := range s.lexicals {
+			if b.isConst {
+				consts[name] = true
+			}
+		}
+		c.p.code[start] = enterBlockScope{names: s.names, consts: consts}
+	}
+}
+
+// removeInstructions deletes the instructions at the given (ascending) positions from the code of the current
+// function adjusting the relative jumps, the source map and the positions recorded in the pending blocks.
+func (c *compiler) removeInstructions(positions []int) {
+	if len(positions) == 0 {
+		return
+	}
+	newPos := func(pc int) int {
+		return pc - sort.SearchInts(positions, pc)
+	}
+	offset := func(pc int, offset int32) int32 {
+		return int32(newPos(pc+int(offset)) - newPos(pc))
+	}
+	code := c.p.code
+	for pc, instr := range code {
+		switch instr := instr.(type) {
+		case jump:
+			code[pc] = jump(offset(pc, int32(instr)))
+		case jne:
+			code[pc] = jne(offset(pc, int32(instr)))
+		case jeq:
+			code[pc] = jeq(offset(pc, int32(instr)))
+		case jeq1:
+			code[pc] = jeq1(offset(pc, int32(instr)))
+		case jneq1:
+			code[pc] = jneq1(offset(pc, int32(instr)))
+		case enumNext:
+			code[pc] = enumNext(offset(pc, int32(instr)))
+		case iterNext:
+			code[pc] = iterNext(offset(pc, int32(instr)))
+		case try:
+			if instr.catchOffset > 0 {
+				instr.catchOffset = offset(pc, instr.catchOffset)
+			}
+			if instr.finallyOffset > 0 {
+				instr.finallyOffset = offset(pc, instr.finallyOffset)
+			}
+			code[pc] = instr
+		}
+	}
+	j, k := 0, 0
+	for pc, instr := range code {
+		if k < len(positions) && positions[k] == pc {
+			k++
+			continue
+		}
+		code[j] = instr
+		j++
+	}
+	for i := j; i < len(code); i++ {
+		code[i] = nil
+	}
+	c.p.code = code[:j]
+	for i := range c.p.srcMap {
+		c.p.srcMap[i].pc = newPos(c.p.srcMap[i].pc)
+	}
+	adjust := func(list []int) {
+		for i, pc := range list {
+			list[i] = newPos(pc)
+		}
+	}
+	for b := c.block; b != nil; b = b.outer {
+		adjust(b.breaks)
+		adjust(b.conts)
+		adjust(b.stashInstrs)
+		b.cont = newPos(b.cont)
+	}
+	c.blockStart = newPos(c.blockStart)
+}
+
+func (c *compiler) compileClassDeclaration(v *ast.ClassDeclaration, needResult bool) {
+	c.compileClassLiteral(v.Class).emitGetter(true)
+	c.emitLexicalInit(v.Class.Name.Name)
+	if needResult {
+		c.emit(loadUndef)
+	}
+}
+
+func (c *compiler) compileLexicalDeclaration(v *ast.LexicalDeclaration, needResult bool) {
+	for _, item := range v.List {
+		if item.Initializer != nil {
+			c.emitExpr(c.compileExpression(item.Initializer), true)
+		} else {
+			c.emit(loadUndef)
+		}
+		c.emitLexicalBinding(item)
+	}
+	if needResult {
+		c.emit(loadUndef)
+	}
+}
+
+// emitLexicalBinding initialises the let or const bindings of a declaration item, which may be a destructuring
+// pattern, with the value on the stack and pops it.
+func (c *compiler) emitLexicalBinding(item *ast.VariableExpression) {
+	if item.Target == nil {
+		c.emitLexicalInit(item.Name)
+		return
+	}
+	c.emitPattern(item.Target, func(target ast.Expression, value compiledExpr) {
+		value.emitGetter(true)
+		c.emitLexicalInit(target.(*ast.Identifier).Name)
+	})
+}
+
+// emitLexicalInit initialises a let or const binding declared in the current block (or at the top level of
+// a script) with the value on the stack and pops it.
+func (c *compiler) emitLexicalInit(name string) {
+	if b := c.scope.lexicals[name]; b != nil {
+		c.emit(setLocalP(c.scope.names[name]))
+		b.initialized = true
+	} else {
+		c.emit(initGlobalLexical(name))
+	}
+}
+
 func (c *compiler) compileExpressionStatement(v *ast.ExpressionStatement, needResult bool) {
 	expr := c.compileExpression(v.Expression)
 	if expr.constant() {
@@ -734,6 +1115,15 @@ func (c *compiler) compileSwitchStatement(v *ast.SwitchStatement, needResult boo
 
 	c.compileExpression(v.Discriminant).emitGetter(true)
 
+	var decls []*ast.LexicalDeclaration
+	for _, s := range v.Body {
+		decls = append(decls, lexicalDeclarations(s.Consequent)...)
+	}
+	if len(decls) > 0 {
+		c.openBlockScope(v, decls)
+		c.scope.switchBlock = true
+	}
+
 	jumps := make([]int, len(v.Body))
 
 	for i, s := range v.Body {
@@ -788,6 +1178,9 @@ func (c *compiler) compileSwitchStatement(v *ast.SwitchStatement, needResult boo
 			c.emit(loadUndef)
 		}
 	}
+	if len(decls) > 0 {
+		c.closeBlockScope()
+	}
 	c.leaveBlock()
 	c.markBlockStart()
 }
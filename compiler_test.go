@@ -4,7 +4,10 @@ import (
 	"github.com/dop251/goja/parser"
 	"io/ioutil"
 	"os"
+	"runtime"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func testScript(script string, expectedResult Value, t *testing.T) {
@@ -1927,6 +1930,710 @@ func TestEmptyCodeError(t *testing.T) {
 	}
 }
 
+func TestLetBlockScope(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		let x = 1;
+		{
+			let x = 2;
+			x++;
+		}
+		return x;
+	}
+	f();
+	`
+	testScript1(SCRIPT, intToValue(1), t)
+}
+
+func TestLetClosuresInLoop(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		var fns = [];
+		for (let i = 0; i < 3; i++) {
+			fns.push(function() { return i; });
+		}
+		return fns.map(function(fn) { return fn(); }).join();
+	}
+	f();
+	`
+	testScript1(SCRIPT, asciiString("0,1,2"), t)
+}
+
+func TestLetForInClosures(t *testing.T) {
+	const SCRIPT = `
+	var fns = [];
+	for (let k in {a: 1, b: 2}) {
+		fns.push(function() { return k; });
+	}
+	fns[0]() + fns[1]();
+	`
+	testScript1(SCRIPT, asciiString("ab"), t)
+}
+
+func TestLetTDZ(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		var r = [];
+		function g() {
+			return x;
+		}
+		try {
+			g();
+		} catch (e) {
+			r.push(e.message);
+		}
+		let x = 1;
+		r.push(g());
+		return r.join();
+	}
+	var thrown = false;
+	try {
+		typeof y;
+		let y;
+	} catch (e) {
+		thrown = e instanceof ReferenceError;
+	}
+	thrown && f();
+	`
+	testScript1(SCRIPT, asciiString("Cannot access 'x' before initialization,1"), t)
+}
+
+func TestConstAssign(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		const c = {p: 1};
+		c.p = 2;
+		try {
+			c = 3;
+		} catch (e) {
+			if (e instanceof TypeError) {
+				return c.p;
+			}
+		}
+	}
+	f();
+	`
+	testScript1(SCRIPT, intToValue(2), t)
+}
+
+func TestLetSwitch(t *testing.T) {
+	const SCRIPT = `
+	function f(v) {
+		switch (v) {
+		case 0:
+			let x = "zero";
+		case 1:
+			try {
+				return x;
+			} catch (e) {
+				return e.name;
+			}
+		}
+	}
+	f(0) + f(1);
+	`
+	testScript1(SCRIPT, asciiString("zeroReferenceError"), t)
+}
+
+func TestLetBreakContinue(t *testing.T) {
+	const SCRIPT = `
+	var r = 0;
+	outer: for (let i = 0; i < 4; i++) {
+		for (let j = 0; j < 4; j++) {
+			let k = i * 10 + j;
+			if (j == 2) {
+				continue outer;
+			}
+			if (i == 2) {
+				break outer;
+			}
+			r += k;
+		}
+	}
+	r;
+	`
+	testScript1(SCRIPT, intToValue(22), t)
+}
+
+func TestLetEval(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		let x = 1;
+		{
+			let y = 2;
+			eval("x += y");
+		}
+		return x;
+	}
+	f();
+	`
+	testScript1(SCRIPT, intToValue(3), t)
+}
+
+func TestLetBlockFunction(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		{
+			let x = 1;
+			function g() {
+				return x;
+			}
+		}
+		return g();
+	}
+	f();
+	`
+	testScript1(SCRIPT, intToValue(1), t)
+}
+
+func TestLetRedeclaration(t *testing.T) {
+	for _, src := range []string{
+		"let x; let x;",
+		"var x; let x;",
+		"function f() { var x; let x; }",
+		"{ const x = 1; function x() {} }",
+	} {
+		if _, err := Compile("", src, false); err == nil {
+			t.Fatalf("%q: expected an error", src)
+		}
+	}
+}
+
+func TestGlobalLexicals(t *testing.T) {
+	r := New()
+	_, err := r.RunString(`
+	let x = 1;
+	const y = 2;
+	function f() {
+		return x + y;
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := r.RunString(`x++; f()`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 4 {
+		t.Fatalf("Unexpected value: %v", v)
+	}
+	if r.Get("x") != nil {
+		t.Fatal("let binding must not be a property of the global object")
+	}
+	if _, err = r.RunString(`y = 3`); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if _, err = r.RunString(`var x`); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
+func TestArrowFunctionThis(t *testing.T) {
+	const SCRIPT = `
+	var o = {
+		factor: 2,
+		scale: function(arr) {
+			return arr.map(v => v * this.factor);
+		}
+	};
+	var self = this;
+	[o.scale([1, 2, 3]).join(), (() => this === self)(), o.scale.call({factor: 3}, [1]).join(), (x => this).call(o) === self].join("|");
+	`
+
+	testScript1(SCRIPT, asciiString("2,4,6|true|3|true"), t)
+
+	testScript1(`
+	function F() {
+		this.v = 1;
+		this.get = () => this.v;
+	}
+	var get = new F().get;
+	get.call({v: 2})
+	`, intToValue(1), t)
+}
+
+func TestArrowFunctionArguments(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		var g = (a) => arguments[0] + a + arguments.length;
+		return g(10);
+	}
+	f(1, 2);
+	`
+
+	testScript1(SCRIPT, intToValue(13), t)
+}
+
+func TestArrowFunctionBodies(t *testing.T) {
+	const SCRIPT = `
+	var add = (a, b) => a + b;
+	var inc = x => x + 1;
+	var obj = () => ({v: 1});
+	var block = (x) => {
+		var y = x * 2;
+		return y;
+	};
+	var empty = () => {};
+	var curry = a => b => a - b;
+	[add(1, 2), inc(1), obj().v, block(3), empty(), curry(5)(1), add.length, inc.toString(), obj.toString()].join("|");
+	`
+
+	testScript1(SCRIPT, asciiString("3|2|1|6||4|2|x => x + 1|() => ({v: 1})"), t)
+}
+
+func TestArrowFunctionNotConstructor(t *testing.T) {
+	const SCRIPT = `
+	var f = () => 1;
+	var res;
+	try {
+		new f();
+	} catch (e) {
+		res = e instanceof TypeError;
+	}
+	res && !f.hasOwnProperty("prototype") && f.prototype === undefined;
+	`
+
+	testScript1(SCRIPT, valueTrue, t)
+}
+
+func TestArrowFunctionSyntaxErrors(t *testing.T) {
+	for _, src := range []string{
+		"(a, a) => 1",
+		"var f = (x)\n=> x",
+		"(() => arguments)()",
+	} {
+		if _, err := New().RunString(src); err == nil {
+			t.Fatalf("Expected an error for %q", src)
+		}
+	}
+}
+
+func TestArgumentsInBlockScope(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		let a = 1;
+		try {
+			throw a;
+		} catch (e) {
+			return arguments.length + arguments[0] + e;
+		}
+	}
+	f(10, 20);
+	`
+
+	testScript1(SCRIPT, intToValue(13), t)
+}
+
+func TestTemplateLiteral(t *testing.T) {
+	const SCRIPT = "var a = 1, o = {toString: function() { return 's'; }, valueOf: function() { return 2; }};\n" +
+		"[`plain`, `${a}`, `a${a + 1}b${'c'}${o}`, `x${`y${a}`}`, `\\u0041\\x42\\`\\${a}`, `line1\nline2`.length, typeof `${a}`].join('|');"
+
+	testScript1(SCRIPT, asciiString("plain|1|a2bcs|xy1|AB`${a}|11|string"), t)
+}
+
+func TestTaggedTemplate(t *testing.T) {
+	const SCRIPT = `
+	function tag(strings, x, y) {
+		return [strings.length, strings[0], strings[1], strings[2] === undefined, strings.raw[0], strings.raw[2], x, y,
+			Object.isFrozen(strings), Object.isFrozen(strings.raw), Object.keys(strings).join()].join("|");
+	}
+	var o = {
+		v: 7,
+		tag: function(strings, x) {
+			return this.v + x;
+		}
+	};
+	` + "tag`a\\n${1}b${2}\\unicode` + '|' + o.tag`${1}`;"
+
+	testScript1(SCRIPT, asciiString("3|a\n|b|true|a\\n|\\unicode|1|2|true|true|0,1,2|8"), t)
+}
+
+func TestTaggedTemplateCallSite(t *testing.T) {
+	const SCRIPT = `
+	function id(s) {
+		return s;
+	}
+	function g() {
+		return id` + "`x${1}`" + `;
+	}
+	function h() {
+		return id` + "`x${1}`" + `;
+	}
+	var a = [];
+	for (var i = 0; i < 2; i++) {
+		a.push(id` + "`x`" + `);
+	}
+	g() === g() && g() !== h() && a[0] === a[1] && Object.isFrozen(g());
+	`
+
+	prg := MustCompile("test.js", SCRIPT, false)
+	var objs [2]Value
+	for i := range objs {
+		r := New()
+		v, err := r.RunProgram(prg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !v.StrictEquals(valueTrue) {
+			t.Fatalf("Unexpected result: %v", v)
+		}
+		if objs[i], err = r.RunString("g()"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the Runtimes running the same program do not share the objects
+	if objs[0] == objs[1] {
+		t.Fatal("The template object is shared between Runtimes")
+	}
+}
+
+func TestTaggedTemplateCallSiteCollected(t *testing.T) {
+	r := New()
+	prg := MustCompile("test.js", "(function(s) { return s; })`x`", false)
+	live, err := r.RunProgram(prg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := r.RunString("(function(s) { return s; })`x`"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 100 && len(r.templateObjects) > 2; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+		r.removeDeadTemplateObjects()
+	}
+	if l := len(r.templateObjects); l > 2 {
+		t.Fatalf("The template objects of the collected code have not been removed: %d", l)
+	}
+	v, err := r.RunProgram(prg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != live {
+		t.Fatal("The template object of the live code has been removed")
+	}
+}
+
+func TestTaggedTemplateGoFunc(t *testing.T) {
+	r := New()
+	r.Set("sql", func(call FunctionCall) Value {
+		strs := call.Argument(0).ToObject(r)
+		var query string
+		for i := int64(0); i < strs.Get("length").ToInteger(); i++ {
+			if i > 0 {
+				query += "?"
+			}
+			query += strs.Get(strconv.FormatInt(i, 10)).String()
+		}
+		return r.ToValue(query + ";" + strconv.Itoa(len(call.Arguments)-1))
+	})
+	v, err := r.RunString("var id = 42; sql`SELECT * FROM t WHERE id = ${id} AND name = ${'x'}`")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "SELECT * FROM t WHERE id = ? AND name = ?;2" {
+		t.Fatalf("Unexpected value: %q", s)
+	}
+}
+
+func TestTemplateLiteralSyntaxErrors(t *testing.T) {
+	for _, src := range []string{
+		"`unterminated",
+		"`\\unicode`",
+		"`\\01`",
+		"`${1`",
+	} {
+		if _, err := Compile("", src, false); err == nil {
+			t.Fatalf("Expected an error for %q", src)
+		}
+	}
+}
+
+func TestForOf(t *testing.T) {
+	const SCRIPT = `
+	var res = [];
+	for (var x of [1, 2, 3]) {
+		if (x === 2) continue;
+		res.push(x);
+	}
+	for (let [k, v] of ["a", "b"].entries()) {
+		res.push(k + v);
+	}
+	for (const ch of "a\uD83D\uDE00") {
+		res.push(ch.length);
+	}
+	function sum() {
+		var s = 0;
+		for (var n of arguments) s += n;
+		return s;
+	}
+	res.push(sum(1, 2, 3));
+	var o = {};
+	for (o.p of [4]);
+	res.push(o.p);
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("1,3,0a,1b,1,2,6,4"), t)
+}
+
+func TestForOfIteratorClose(t *testing.T) {
+	const SCRIPT = `
+	var closed = 0;
+	var iterable = {};
+	iterable[Symbol.iterator] = function() {
+		var i = 0;
+		return {
+			next: function() {
+				return {value: i++, done: i > 10};
+			},
+			"return": function() {
+				closed++;
+				return {};
+			}
+		};
+	};
+	for (var x of iterable) {
+		if (x === 2) break;
+	}
+	outer: for (var y of [1, 2]) {
+		for (var z of iterable) {
+			continue outer;
+		}
+	}
+	(function() {
+		for (var x of iterable) {
+			return x;
+		}
+	})();
+	try {
+		for (var x of iterable) {
+			throw new Error();
+		}
+	} catch (e) {
+	}
+	var [a, b] = iterable;
+	for (var x of iterable);
+	closed;
+	`
+
+	testScript1(SCRIPT, intToValue(6), t)
+}
+
+func TestSpread(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		return Array.prototype.join.call(arguments);
+	}
+	function F(a, b) {
+		this.v = a + b;
+	}
+	var arr = [1, 2];
+	var o = {x: 1, y: 2};
+	var copy = {...o, y: 3, ...null};
+	[f(...arr, 3, ...[4]), [0, ...arr, , ...""].length, new F(...arr).v, Math.max(...arr), copy.x + copy.y].join("|");
+	`
+
+	testScript1(SCRIPT, asciiString("1,2,3,4|4|3|2|4"), t)
+}
+
+func TestDestructuring(t *testing.T) {
+	const SCRIPT = `
+	var a, b, rest, o = {};
+	[a, , b = 5, ...rest] = [1, 2, undefined, 4, 5];
+	var res = [a, b, rest.join()];
+	({a, b: o.b, c: [o.c] = [7], ...rest} = {a: 3, b: 4, d: 5});
+	res.push(a, o.b, o.c, Object.keys(rest).join());
+	[a, b] = [b, a];
+	res.push(a, b);
+	var {length} = "abc", [x, [y, z = 9]] = [1, [2]];
+	res.push(length, x + y + z);
+	let {p: {q}} = {p: {q: 10}};
+	const [first, ...others] = "xyz";
+	res.push(q, first, others.length);
+	res.join();
+	`
+
+	testScript1(SCRIPT, asciiString("1,5,4,5,3,4,7,d,5,3,3,12,10,x,2"), t)
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	const SCRIPT = `
+	function check(f) {
+		try {
+			f();
+		} catch (e) {
+			return e instanceof TypeError;
+		}
+		return false;
+	}
+	[check(function() { var [a] = 1; }), check(function() { var {a} = null; }), check(function() { for (var x of {}); })].join();
+	`
+
+	testScript1(SCRIPT, asciiString("true,true,true"), t)
+
+	for _, src := range []string{
+		"var [a];",
+		"({a = 1});",
+		"[...a, b] = [];",
+		"let [a, a] = [];",
+		"function f(a, [a]) {}",
+		"function f(a = 1) { 'use strict'; }",
+	} {
+		if _, err := Compile("", src, false); err == nil {
+			t.Fatalf("Expected an error for %q", src)
+		}
+	}
+}
+
+func TestDefaultAndRestParameters(t *testing.T) {
+	const SCRIPT = `
+	function f(a, b = a + 1, ...rest) {
+		return [a, b, rest.length, arguments.length].join();
+	}
+	function g({x, y} = {x: 1, y: 2}, [z] = [3]) {
+		return x + y + z;
+	}
+	function h(a, ...rest) {
+		eval("");
+		return function() {
+			return a + rest.join();
+		};
+	}
+	var arrow = (...args) => args.length;
+	[f(1), f(1, 5, 6, 7), f.length, g(), g({x: 10, y: 20}, [30]), g.length, h(1, 2, 3)(), arrow(1, 2)].join("|");
+	`
+
+	testScript1(SCRIPT, asciiString("1,2,0,1|1,5,2,4|1|6|60|0|12,3|2"), t)
+}
+
+func TestClass(t *testing.T) {
+	const SCRIPT = `
+	class A {
+		constructor(x) {
+			this.x = x;
+		}
+		get double() {
+			return this.x * 2;
+		}
+		m() {
+			return "A" + this.x;
+		}
+		static create(x) {
+			return new this(x);
+		}
+	}
+	class B extends A {
+		constructor(x) {
+			super(x + 1);
+		}
+		m() {
+			var f = () => super.m();
+			return "B" + f();
+		}
+		static create(x) {
+			return super.create(x * 10);
+		}
+	}
+	var C = class Named extends B {
+		self() {
+			return Named;
+		}
+	};
+	var b = B.create(1), c = new C(2);
+	[b.m(), b.double, b instanceof A, c.m(), c.self() === C, typeof A, Object.keys(A.prototype).length,
+		A.prototype.m.hasOwnProperty("prototype"), B.prototype.constructor === B, Object.getPrototypeOf(B) === A].join();
+	`
+
+	testScript1(SCRIPT, asciiString("BA11,22,true,BA3,true,function,0,false,true,true"), t)
+}
+
+func TestClassExtendsBuiltin(t *testing.T) {
+	const SCRIPT = `
+	class MyError extends Error {
+		constructor(msg) {
+			super(msg);
+			this.name = "MyError";
+		}
+	}
+	class MyArray extends Array {
+		sum() {
+			return this.reduce(function(a, b) { return a + b; }, 0);
+		}
+	}
+	class MyMap extends Map {
+		get(k) {
+			return "v" + super.get(k);
+		}
+	}
+	class Nothing extends null {
+	}
+	var e = new MyError("boom"), a = new MyArray(), m = new MyMap([[1, 2]]);
+	a.push(1, 2, 3);
+	[e instanceof Error, e.message, String(e), a.length, a.sum(), Array.isArray(a), m.get(1), m.size,
+		Object.getPrototypeOf(Nothing.prototype)].join();
+	`
+
+	testScript1(SCRIPT, asciiString("true,boom,MyError: boom,3,6,true,v2,1,"), t)
+}
+
+func TestClassErrors(t *testing.T) {
+	const SCRIPT = `
+	function check(f, ctor) {
+		try {
+			f();
+		} catch (e) {
+			return e instanceof ctor;
+		}
+		return false;
+	}
+	class A {
+		m() {}
+	}
+	class B extends A {
+		constructor(early, ret) {
+			if (early) {
+				this.x = 1;
+			}
+			if (ret !== undefined) {
+				return ret;
+			}
+			super();
+			super();
+		}
+	}
+	class C extends A {
+		constructor(ret) {
+			super();
+			return ret;
+		}
+	}
+	[check(function() { A(); }, TypeError), check(function() { new A.prototype.m(); }, TypeError),
+		check(function() { new B(true); }, ReferenceError), check(function() { new B(false); }, ReferenceError),
+		check(function() { new C(1); }, TypeError), check(function() { class D extends 1 {} }, TypeError),
+		new C({a: 1}).a, new C() instanceof C].join();
+	`
+
+	testScript1(SCRIPT, asciiString("true,true,true,true,true,true,1,true"), t)
+
+	for _, src := range []string{
+		"class A { constructor() {} constructor() {} }",
+		"class A { get constructor() {} }",
+		"class A { static prototype() {} }",
+		"class A { constructor() { super(); } }",
+		"function f() { super.x; }",
+		"var o = {m: function() { return super.x; }};",
+		"class A extends B { m() { super(); } }",
+	} {
+		if _, err := Compile("", src, false); err == nil {
+			t.Fatalf("Expected an error for %q", src)
+		}
+	}
+}
+
 // FIXME
 /*
 func TestDummyCompile(t *testing.T) {
@@ -0,0 +1,339 @@
+package goja
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Coverage is the code coverage collected by a Runtime, see Runtime.StartCoverage().
+type Coverage struct {
+	scripts []*coverageScript
+	bySrc   map[*SrcFile]*coverageScript
+	funcs   map[*Program]*coverageFunc
+
+	// the counters of the last executed program
+	lastPrg    *Program
+	lastCounts []uint64
+}
+
+type coverageScript struct {
+	src   *SrcFile
+	funcs []*coverageFunc
+}
+
+// coverageFunc holds the execution counts of the instructions of a function or of a script.
+type coverageFunc struct {
+	prg        *Program
+	name       string
+	script     bool
+	start, end int
+	counts     []uint64
+}
+
+// coverageRange is a range of the source and the number of times the code in it has been executed.
+type coverageRange struct {
+	start, end int
+	count      uint64
+}
+
+// StartCoverage enables the collection of the code coverage: the number of times each expression that has a source
+// position has been executed is counted until StopCoverage() is called. It has no effect if the collection is already
+// enabled.
+//
+// The functions which have not been called are only reported if the script they are defined in has been executed
+// while the collection was enabled.
+func (r *Runtime) StartCoverage() {
+	if r.vm.cov == nil {
+		r.vm.cov = &Coverage{
+			bySrc: make(map[*SrcFile]*coverageScript),
+			funcs: make(map[*Program]*coverageFunc),
+		}
+	}
+}
+
+// StopCoverage disables the collection of the code coverage and returns the result, or nil if it has not been
+// enabled.
+func (r *Runtime) StopCoverage() *Coverage {
+	c := r.vm.cov
+	r.vm.cov = nil
+	return c
+}
+
+// hit is called by the vm before each instruction.
+func (c *Coverage) hit(prg *Program, pc int) {
+	if prg != c.lastPrg {
+		f := c.funcs[prg]
+		if f == nil {
+			f = c.addProgram(prg, prg.funcName)
+		}
+		c.lastPrg, c.lastCounts = prg, f.counts
+	}
+	c.lastCounts[pc]++
+}
+
+func (c *Coverage) addProgram(prg *Program, name string) *coverageFunc {
+	f := &coverageFunc{
+		prg:    prg,
+		name:   name,
+		start:  prg.srcStart,
+		end:    prg.srcEnd,
+		counts: make([]uint64, len(prg.code)),
+	}
+	c.funcs[prg] = f
+	if src := prg.src; src != nil {
+		if f.start == 0 && f.end == 0 {
+			f.script = true
+			f.end = len(src.src)
+		}
+		s := c.bySrc[src]
+		if s == nil {
+			s = &coverageScript{src: src}
+			c.bySrc[src] = s
+			c.scripts = append(c.scripts, s)
+		}
+		s.funcs = append(s.funcs, f)
+	}
+	for _, ins := range prg.code {
+		if n, ok := ins.(*newFunc); ok && c.funcs[n.prg] == nil {
+			c.addProgram(n.prg, n.name)
+		}
+	}
+	return f
+}
+
+// blocks returns the ranges of the function which have been executed a different number of times than the function
+// itself, sorted by their start. The expressions of the function are assumed to extend to the next one or to the
+// start of a nested function.
+func (f *coverageFunc) blocks() []coverageRange {
+	type point struct {
+		pos   int
+		count uint64
+	}
+	var points []point
+	var nested []int
+	for _, item := range f.prg.srcMap {
+		if item.pc < len(f.counts) && item.srcPos >= f.start && item.srcPos < f.end {
+			points = append(points, point{pos: item.srcPos, count: f.counts[item.pc]})
+		}
+	}
+	for _, ins := range f.prg.code {
+		if n, ok := ins.(*newFunc); ok {
+			nested = append(nested, int(n.srcStart))
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].pos < points[j].pos
+	})
+	sort.Ints(nested)
+
+	var ranges []coverageRange
+	for i := 0; i < len(points); {
+		p := points[i]
+		for i++; i < len(points) && points[i].pos == p.pos; i++ {
+			if points[i].count > p.count {
+				p.count = points[i].count
+			}
+		}
+		end := f.end
+		if i < len(points) {
+			end = points[i].pos
+		}
+		if j := sort.SearchInts(nested, p.pos+1); j < len(nested) && nested[j] < end {
+			end = nested[j]
+		}
+		if p.count == f.counts[0] {
+			continue
+		}
+		if l := len(ranges); l > 0 && ranges[l-1].end == p.pos && ranges[l-1].count == p.count {
+			ranges[l-1].end = end
+			continue
+		}
+		ranges = append(ranges, coverageRange{start: p.pos, end: end, count: p.count})
+	}
+	return ranges
+}
+
+func (c *Coverage) sortedFuncs(s *coverageScript) []*coverageFunc {
+	funcs := append([]*coverageFunc(nil), s.funcs...)
+	sort.SliceStable(funcs, func(i, j int) bool {
+		if funcs[i].start != funcs[j].start {
+			return funcs[i].start < funcs[j].start
+		}
+		return funcs[i].end > funcs[j].end
+	})
+	return funcs
+}
+
+type lcovFunc struct {
+	name  string
+	line  int
+	count uint64
+}
+
+type lcovFile struct {
+	funcs []lcovFunc
+	lines map[int]uint64
+}
+
+// WriteLCOV writes the coverage in the LCOV tracefile format. If a script has a source map, the positions are those
+// of the original sources.
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	files := make(map[string]*lcovFile)
+	file := func(name string) *lcovFile {
+		f := files[name]
+		if f == nil {
+			f = &lcovFile{lines: make(map[int]uint64)}
+			files[name] = f
+		}
+		return f
+	}
+	for _, s := range c.scripts {
+		anonymous := 0
+		for _, f := range c.sortedFuncs(s) {
+			if !f.script {
+				name, _, pos := s.src.sourcePosition(f.start)
+				fnName := f.name
+				if fnName == "" {
+					fnName = "(anonymous_" + strconv.Itoa(anonymous) + ")"
+					anonymous++
+				}
+				lf := file(name)
+				lf.funcs = append(lf.funcs, lcovFunc{name: fnName, line: pos.Line, count: f.counts[0]})
+			}
+			for _, item := range f.prg.srcMap {
+				if item.pc >= len(f.counts) {
+					continue
+				}
+				name, _, pos := s.src.sourcePosition(item.srcPos)
+				lf := file(name)
+				if count, exists := lf.lines[pos.Line]; !exists || f.counts[item.pc] > count {
+					lf.lines[pos.Line] = f.counts[item.pc]
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := files[name]
+		bw.WriteString("TN:\nSF:" + name + "\n")
+		hit := 0
+		for _, fn := range f.funcs {
+			bw.WriteString("FN:" + strconv.Itoa(fn.line) + "," + fn.name + "\n")
+		}
+		for _, fn := range f.funcs {
+			bw.WriteString("FNDA:" + strconv.FormatUint(fn.count, 10) + "," + fn.name + "\n")
+			if fn.count > 0 {
+				hit++
+			}
+		}
+		bw.WriteString("FNF:" + strconv.Itoa(len(f.funcs)) + "\nFNH:" + strconv.Itoa(hit) + "\n")
+		lines := make([]int, 0, len(f.lines))
+		for line := range f.lines {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+		hit = 0
+		for _, line := range lines {
+			count := f.lines[line]
+			bw.WriteString("DA:" + strconv.Itoa(line) + "," + strconv.FormatUint(count, 10) + "\n")
+			if count > 0 {
+				hit++
+			}
+		}
+		bw.WriteString("LF:" + strconv.Itoa(len(lines)) + "\nLH:" + strconv.Itoa(hit) + "\nend_of_record\n")
+	}
+	return bw.Flush()
+}
+
+type v8CoverageRange struct {
+	StartOffset int    `json:"startOffset"`
+	EndOffset   int    `json:"endOffset"`
+	Count       uint64 `json:"count"`
+}
+
+type v8FunctionCoverage struct {
+	FunctionName    string            `json:"functionName"`
+	Ranges          []v8CoverageRange `json:"ranges"`
+	IsBlockCoverage bool              `json:"isBlockCoverage"`
+}
+
+type v8ScriptCoverage struct {
+	ScriptId  string               `json:"scriptId"`
+	URL       string               `json:"url"`
+	Functions []v8FunctionCoverage `json:"functions"`
+}
+
+// WriteV8 writes the coverage in the JSON format of the result of the Profiler.takePreciseCoverage method of the
+// Chrome DevTools Protocol, with block coverage. As in V8, the offsets are those of the UTF-16 code units of the
+// executed source.
+func (c *Coverage) WriteV8(w io.Writer) error {
+	result := make([]v8ScriptCoverage, 0, len(c.scripts))
+	for i, s := range c.scripts {
+		offset := utf16Offsets(s.src.src)
+		sc := v8ScriptCoverage{
+			ScriptId:  strconv.Itoa(i + 1),
+			URL:       s.src.name,
+			Functions: make([]v8FunctionCoverage, 0, len(s.funcs)),
+		}
+		for _, f := range c.sortedFuncs(s) {
+			fc := v8FunctionCoverage{
+				FunctionName:    f.name,
+				Ranges:          []v8CoverageRange{{StartOffset: offset(f.start), EndOffset: offset(f.end), Count: f.counts[0]}},
+				IsBlockCoverage: true,
+			}
+			for _, r := range f.blocks() {
+				fc.Ranges = append(fc.Ranges, v8CoverageRange{StartOffset: offset(r.start), EndOffset: offset(r.end), Count: r.count})
+			}
+			sc.Functions = append(sc.Functions, fc)
+		}
+		result = append(result, sc)
+	}
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"result": result,
+	})
+}
+
+// utf16Offsets returns a function which converts the byte offsets in s into UTF-16 offsets.
+func utf16Offsets(s string) func(int) int {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return func(offset int) int {
+			return offset
+		}
+	}
+	offsets := make([]int, len(s)+1)
+	u := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		for j := 0; j < size; j++ {
+			offsets[i+j] = u
+		}
+		if r >= 0x10000 {
+			u += 2
+		} else {
+			u++
+		}
+		i += size
+	}
+	offsets[len(s)] = u
+	return func(offset int) int {
+		return offsets[offset]
+	}
+}
@@ -0,0 +1,117 @@
+package goja
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCoverage(t *testing.T) {
+	const SCRIPT = `
+function covered(x) {
+	if (x > 0) {
+		return "pos";
+	} else {
+		return "neg";
+	}
+}
+function uncovered() {
+	return 1;
+}
+var f = function() {};
+covered(1);
+covered(2);
+f();
+`
+	r := New()
+	r.StartCoverage()
+	if _, err := r.RunScript("cov.js", SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	c := r.StopCoverage()
+	if r.StopCoverage() != nil {
+		t.Fatal("The collection has not been stopped")
+	}
+
+	var buf bytes.Buffer
+	if err := c.WriteLCOV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lcov := buf.String()
+	for _, s := range []string{"SF:cov.js\n", "FN:2,covered\n", "FNDA:2,covered\n", "FNDA:0,uncovered\n", "FNDA:1,(anonymous_0)\n",
+		"FNF:3\nFNH:2\n", "DA:3,2\n", "DA:4,2\n", "DA:6,0\n", "DA:10,0\n", "end_of_record\n"} {
+		if !strings.Contains(lcov, s) {
+			t.Fatalf("%q is missing from:\n%s", s, lcov)
+		}
+	}
+
+	buf.Reset()
+	if err := c.WriteV8(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var v8 struct {
+		Result []struct {
+			URL       string `json:"url"`
+			Functions []struct {
+				FunctionName string `json:"functionName"`
+				Ranges       []struct {
+					StartOffset int `json:"startOffset"`
+					EndOffset   int `json:"endOffset"`
+					Count       int `json:"count"`
+				} `json:"ranges"`
+			} `json:"functions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &v8); err != nil {
+		t.Fatal(err)
+	}
+	if len(v8.Result) != 1 || v8.Result[0].URL != "cov.js" || len(v8.Result[0].Functions) != 4 {
+		t.Fatalf("Unexpected result: %s", buf.String())
+	}
+	// the count of the innermost range containing the offset
+	countAt := func(offset int) int {
+		count := -1
+		for _, f := range v8.Result[0].Functions {
+			for _, r := range f.Ranges {
+				if r.StartOffset <= offset && offset < r.EndOffset {
+					count = r.Count
+				}
+			}
+		}
+		return count
+	}
+	for _, check := range []struct {
+		code  string
+		count int
+	}{{`var f`, 1}, {`"pos"`, 2}, {`"neg"`, 0}, {`return 1`, 0}} {
+		if count := countAt(strings.Index(SCRIPT, check.code)); count != check.count {
+			t.Fatalf("%s: %d, expected %d (%s)", check.code, count, check.count, buf.String())
+		}
+	}
+}
+
+func TestCoverageSourceMap(t *testing.T) {
+	// maps the generated lines 1-3 to the lines 11-13 of orig.ts
+	const SCRIPT = `function f(x) {
+	return x ? 1 : 2;
+}
+f(true);
+//# sourceMappingURL=data:application/json;base64,eyJ2ZXJzaW9uIjozLCJzb3VyY2VzIjpbIm9yaWcudHMiXSwibmFtZXMiOltdLCJtYXBwaW5ncyI6IkFBVUE7QUFDQTtBQUNBIn0=`
+
+	r := New()
+	r.StartCoverage()
+	if _, err := r.RunScript("gen.js", SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := r.StopCoverage().WriteLCOV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lcov := buf.String()
+	for _, s := range []string{"SF:orig.ts\n", "FN:11,f\n", "FNDA:1,f\n", "DA:12,1\n"} {
+		if !strings.Contains(lcov, s) {
+			t.Fatalf("%q is missing from:\n%s", s, lcov)
+		}
+	}
+}
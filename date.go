@@ -99,7 +99,10 @@ func dateFormat(t time.Time) string {
 }
 
 func (d *dateObject) toPrimitive() Value {
-	return d.toPrimitiveString()
+	if v := d.tryExoticToPrimitive("default"); v != nil {
+		return v
+	}
+	return d.ordinaryToPrimitiveString()
 }
 
 func (d *dateObject) export() interface{} {
@@ -1,57 +1,1735 @@
 package goja
 
 import (
-	"net/http"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net"
-	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
 	"github.com/apex/log"
+	"github.com/dop251/goja/debugger"
+	rtdomain "github.com/dop251/goja/debugger/runtime"
+)
+
+// DefaultDebuggerAddr is the address NewDebugger() listens on. It only accepts connections from the local host.
+const DefaultDebuggerAddr = "127.0.0.1:9922"
+
+const debuggerTargetId = "goja"
+
+var (
+	errNotPaused        = errors.New("Can only perform operation while paused.")
+	errNoScript         = errors.New("No script for id")
+	errNoBreakLocation  = errors.New("Could not resolve breakpoint")
+	errNoCallFrame      = errors.New("Could not find call frame with given id")
+	errNoObject         = errors.New("Could not find object with given id")
+	errAlreadyConnected = errors.New("Another debugger client is already connected")
+	errOriginNotAllowed = errors.New("Origin not allowed")
 )
 
+const (
+	stepNone = iota
+	stepInto
+	stepOver
+	stepOut
+)
+
+// Debugger exposes attached runtimes to clients speaking the Chrome DevTools Protocol (Chrome DevTools,
+// VS Code, etc.). Each attached Runtime is presented as a separate execution context.
+//
+// Only one client can be connected at a time. Breakpoints and debugger statements have no effect until a client
+// has connected and enabled the Debugger domain.
 type Debugger struct {
+	ln   net.Listener
 	http *http.Server
+
+	mu           sync.Mutex
+	client       *debugClient
+	contexts     []*debugContext
+	ctxSeq       int
+	scripts      map[string]*debugScript
+	scriptsBySrc map[debugScriptKey]*debugScript
+	scriptSeq    int
+	breakpoints  map[string]*debugBreakpoint
+	origins      []string
+	bpSeq        int
+
+	attached       uint32 // a client is connected and has enabled the Debugger domain
+	active         uint32 // breakpoints are active
+	skipPauses     uint32
+	pauseRequested uint32
+
+	locations atomic.Value // breakpointIndex
 }
 
-func (d *Debugger) AttachRuntime(r *Runtime) error {
-	return nil
+type breakpointIndex map[*Program]map[int][]string
+
+type debugScriptKey struct {
+	ctx *debugContext
+	src *SrcFile
+}
+
+type debugScript struct {
+	id    string
+	ctx   *debugContext
+	src   *SrcFile
+	progs []*Program
+	lines []int
+	hash  string
+}
+
+type debugBreakpoint struct {
+	id        string
+	url       string
+	urlRegex  *regexp.Regexp
+	scriptId  string
+	line, col int
+	condition string
+
+	locations []breakpointLocation
+}
+
+type breakpointLocation struct {
+	script *debugScript
+	prg    *Program
+	pc     int
+	srcPos int
+}
+
+// debugContext holds the debugging state of a single attached Runtime. Unless stated otherwise the fields
+// are only accessed from the goroutine running the Runtime.
+type debugContext struct {
+	d  *Debugger
+	r  *Runtime
+	id int
+
+	cmds   chan func() bool
+	paused bool // guarded by d.mu
+
+	evaluating bool
+	frames     []*debugFrame
+	objects    map[string]Value
+	objSeq     int
+	lines      map[*SrcFile][]int
+
+	stepMode  int
+	stepDepth int
+	stepPrg   *Program
+	stepLine  int
 }
 
+type debugFrame struct {
+	prg      *Program
+	pc       int
+	funcName string
+	stash    *stash
+	sb, args int
+	this     Value
+
+	local *Object
+}
+
+type debugRequest struct {
+	Id     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type debugResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type debugResponse struct {
+	Id     int                 `json:"id"`
+	Result interface{}         `json:"result,omitempty"`
+	Error  *debugResponseError `json:"error,omitempty"`
+}
+
+type debugEvent struct {
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+type debugTarget struct {
+	Description          string `json:"description"`
+	DevtoolsFrontendUrl  string `json:"devtoolsFrontendUrl"`
+	Id                   string `json:"id"`
+	Title                string `json:"title"`
+	Type                 string `json:"type"`
+	Url                  string `json:"url"`
+	WebSocketDebuggerUrl string `json:"webSocketDebuggerUrl"`
+}
+
+// NewDebugger starts a debugger server listening on DefaultDebuggerAddr.
 func NewDebugger() (*Debugger, error) {
-	debugger := &Debugger{}
-	if err := debugger.init(); err != nil {
+	return ListenDebugger(DefaultDebuggerAddr)
+}
+
+// ListenDebugger starts a debugger server listening on the given TCP address. The DevTools target list is served
+// at http://<addr>/json, the protocol endpoint is ws://<addr>/goja.
+//
+// A connected client can evaluate arbitrary code in the attached runtimes and the protocol has no authentication,
+// so anyone who can reach the address has full control over them. Listen on a loopback address (as NewDebugger()
+// does) unless the network is trusted. To protect against web pages connecting from the browser, websocket
+// handshakes carrying an Origin header are rejected unless it is the DevTools frontend or one of the origins set
+// with SetAllowedOrigins().
+func ListenDebugger(addr string) (*Debugger, error) {
+	d := &Debugger{
+		scripts:      make(map[string]*debugScript),
+		scriptsBySrc: make(map[debugScriptKey]*debugScript),
+		breakpoints:  make(map[string]*debugBreakpoint),
+		active:       1,
+	}
+	d.locations.Store(breakpointIndex(nil))
+	if err := d.init(addr); err != nil {
 		return nil, err
 	}
-	return debugger, nil
+	return d, nil
 }
 
-func (d *Debugger) init() (err error) {
-	ln, err := net.Listen("tcp", ":9922")
+func (d *Debugger) init(addr string) (err error) {
+	d.ln, err = net.Listen("tcp", addr)
 	if err != nil {
 		return err
 	}
-	f := func() {
-		d.http = &http.Server{Handler: http.HandlerFunc(d.handler)}
-		if err := d.http.Serve(ln); err != nil {
-			panic(err)
+	d.http = &http.Server{Handler: http.HandlerFunc(d.handler)}
+	go d.http.Serve(d.ln)
+	return
+}
+
+// SetAllowedOrigins sets the origins (e.g. "http://localhost:8080") of the web pages which are allowed to connect
+// in addition to the DevTools frontend. "*" allows any origin.
+func (d *Debugger) SetAllowedOrigins(origins ...string) {
+	d.mu.Lock()
+	d.origins = append([]string(nil), origins...)
+	d.mu.Unlock()
+}
+
+// originAllowed reports whether a client with the given Origin header can connect. The header is always sent by
+// browsers and is absent for the other clients.
+func (d *Debugger) originAllowed(origin string) bool {
+	if origin == "" || strings.HasPrefix(origin, "devtools://") || strings.HasPrefix(origin, "chrome-devtools://") {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, o := range d.origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
 		}
 	}
-	defer func() {
-		if x := recover(); x != nil {
-			switch e := x.(type) {
-			case error:
-				err = e
+	return false
+}
+
+// Addr returns the address the debugger is listening on.
+func (d *Debugger) Addr() net.Addr {
+	return d.ln.Addr()
+}
+
+// URL returns the websocket URL a DevTools client should connect to.
+func (d *Debugger) URL() string {
+	return "ws://" + d.ln.Addr().String() + "/" + debuggerTargetId
+}
+
+// Close stops the server and disconnects the client. Paused runtimes are resumed.
+func (d *Debugger) Close() error {
+	err := d.http.Close()
+	d.mu.Lock()
+	client := d.client
+	d.mu.Unlock()
+	if client != nil {
+		client.conn.Close()
+	}
+	return err
+}
+
+// AttachRuntime makes the runtime visible to the debugger clients. A Runtime can only be attached to one
+// Debugger and it must not be running at the time of the call.
+func (d *Debugger) AttachRuntime(r *Runtime) error {
+	if r.vm.dbg != nil {
+		if r.vm.dbg.d == d {
+			return nil
+		}
+		return errors.New("runtime is already attached to a debugger")
+	}
+	d.mu.Lock()
+	d.ctxSeq++
+	c := &debugContext{
+		d:     d,
+		r:     r,
+		id:    d.ctxSeq,
+		cmds:  make(chan func() bool),
+		lines: make(map[*SrcFile][]int),
+	}
+	d.contexts = append(d.contexts, c)
+	if d.client != nil {
+		d.sendEvent("Runtime.executionContextCreated", c.description())
+	}
+	d.mu.Unlock()
+	r.vm.dbg = c
+	return nil
+}
+
+// DetachRuntime removes the runtime from the debugger. The Runtime must not be running at the time of the call.
+func (d *Debugger) DetachRuntime(r *Runtime) {
+	c := r.vm.dbg
+	if c == nil || c.d != d {
+		return
+	}
+	r.vm.dbg = nil
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for i, ctx := range d.contexts {
+		if ctx == c {
+			d.contexts = append(d.contexts[:i], d.contexts[i+1:]...)
+			break
+		}
+	}
+	for key, s := range d.scriptsBySrc {
+		if key.ctx == c {
+			delete(d.scriptsBySrc, key)
+			delete(d.scripts, s.id)
+		}
+	}
+	for _, bp := range d.breakpoints {
+		locations := bp.locations[:0]
+		for _, loc := range bp.locations {
+			if loc.script.ctx != c {
+				locations = append(locations, loc)
 			}
 		}
-	}()
-	go f()
-	return
+		bp.locations = locations
+	}
+	d.updateLocations()
+	if d.client != nil {
+		id := rtdomain.ExecutionContextId(c.id)
+		d.sendEvent("Runtime.executionContextDestroyed", &rtdomain.EventExecutionContextDestroyed{ExecutionContextId: &id})
+	}
 }
 
 func (d *Debugger) handler(writer http.ResponseWriter, request *http.Request) {
-	data, err := ioutil.ReadAll(request.Body)
+	switch request.URL.Path {
+	case "/json", "/json/list":
+		host := request.Host
+		if host == "" {
+			host = d.ln.Addr().String()
+		}
+		writeJSON(writer, []*debugTarget{{
+			Description:          "goja instance",
+			DevtoolsFrontendUrl:  "chrome-devtools://devtools/bundled/js_app.html?experiments=true&v8only=true&ws=" + host + "/" + debuggerTargetId,
+			Id:                   debuggerTargetId,
+			Title:                "goja",
+			Type:                 "node",
+			Url:                  "file://",
+			WebSocketDebuggerUrl: "ws://" + host + "/" + debuggerTargetId,
+		}})
+	case "/json/version":
+		writeJSON(writer, map[string]string{
+			"Browser":          "goja",
+			"Protocol-Version": "1.3",
+		})
+	case "/" + debuggerTargetId:
+		d.serveClient(writer, request)
+	default:
+		http.NotFound(writer, request)
+	}
+}
+
+func writeJSON(writer http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Write(data)
+}
+
+func (d *Debugger) serveClient(writer http.ResponseWriter, request *http.Request) {
+	if !d.originAllowed(request.Header.Get("Origin")) {
+		http.Error(writer, errOriginNotAllowed.Error(), http.StatusForbidden)
+		return
+	}
+	d.mu.Lock()
+	busy := d.client != nil
+	d.mu.Unlock()
+	if busy {
+		http.Error(writer, errAlreadyConnected.Error(), http.StatusConflict)
+		return
+	}
+	conn, err := debugger.Upgrade(writer, request)
+	if err != nil {
+		return
+	}
+	d.mu.Lock()
+	if d.client != nil {
+		d.mu.Unlock()
+		conn.Close()
+		return
+	}
+	client := newDebugClient(conn)
+	d.client = client
+	d.mu.Unlock()
+
+	for {
+		msg, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		var req debugRequest
+		if err := json.Unmarshal(msg, &req); err != nil {
+			log.Infof("debugger: invalid request: %v", err)
+			continue
+		}
+		result, err := d.dispatch(req.Method, req.Params)
+		resp := &debugResponse{Id: req.Id}
+		if err != nil {
+			resp.Error = &debugResponseError{Code: -32000, Message: err.Error()}
+		} else if result == nil {
+			resp.Result = struct{}{}
+		} else {
+			resp.Result = result
+		}
+		if data, err := json.Marshal(resp); err == nil {
+			client.send(data)
+		}
+	}
+	client.close()
+	conn.Close()
+	d.disconnect()
+}
+
+func (d *Debugger) disconnect() {
+	d.mu.Lock()
+	d.client = nil
+	atomic.StoreUint32(&d.attached, 0)
+	atomic.StoreUint32(&d.active, 1)
+	atomic.StoreUint32(&d.skipPauses, 0)
+	atomic.StoreUint32(&d.pauseRequested, 0)
+	d.breakpoints = make(map[string]*debugBreakpoint)
+	d.updateLocations()
+	contexts := append([]*debugContext(nil), d.contexts...)
+	d.mu.Unlock()
+	for _, c := range contexts {
+		c.exec(func() bool {
+			c.stepMode = stepNone
+			return c.resume()
+		})
+	}
+}
+
+// sendEvent must be called with d.mu held. The event is marshalled under the lock, as the params may reference the
+// debugger state, and written by the client's writer goroutine.
+func (d *Debugger) sendEvent(method string, params interface{}) {
+	if d.client == nil {
+		return
+	}
+	data, err := json.Marshal(&debugEvent{Method: method, Params: params})
+	if err != nil {
+		log.Infof("debugger: could not marshal %s: %v", method, err)
+		return
+	}
+	d.client.send(data)
+}
+
+// debugClient is a connected client. The messages are queued and written by a separate goroutine, so that a slow
+// client does not block the runtimes, which send the events while holding Debugger.mu. The responses go through the
+// same queue to keep them ordered with the events.
+type debugClient struct {
+	conn *debugger.Conn
+
+	mu     sync.Mutex
+	queue  [][]byte
+	closed bool
+	ready  chan struct{}
+}
+
+func newDebugClient(conn *debugger.Conn) *debugClient {
+	c := &debugClient{
+		conn:  conn,
+		ready: make(chan struct{}, 1),
+	}
+	go c.writeLoop()
+	return c
+}
+
+func (c *debugClient) send(data []byte) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.queue = append(c.queue, data)
+	c.mu.Unlock()
+	c.signal()
+}
+
+// close stops the writer goroutine, the queued messages are discarded.
+func (c *debugClient) close() {
+	c.mu.Lock()
+	c.closed = true
+	c.queue = nil
+	c.mu.Unlock()
+	c.signal()
+}
+
+func (c *debugClient) signal() {
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (c *debugClient) writeLoop() {
+	for range c.ready {
+		c.mu.Lock()
+		queue, closed := c.queue, c.closed
+		c.queue = nil
+		c.mu.Unlock()
+		if closed {
+			return
+		}
+		for _, data := range queue {
+			if err := c.conn.WriteMessage(data); err != nil {
+				// makes the read loop of the connection fail, which disconnects the client
+				c.conn.Close()
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+func (d *Debugger) dispatch(method string, params json.RawMessage) (interface{}, error) {
+	unmarshal := func(v interface{}) error {
+		if len(params) == 0 {
+			return nil
+		}
+		return json.Unmarshal(params, v)
+	}
+	switch method {
+	case "Debugger.enable":
+		d.mu.Lock()
+		atomic.StoreUint32(&d.attached, 1)
+		ids := make([]string, 0, len(d.scripts))
+		for id := range d.scripts {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			a, _ := strconv.Atoi(ids[i])
+			b, _ := strconv.Atoi(ids[j])
+			return a < b
+		})
+		for _, id := range ids {
+			d.sendEvent("Debugger.scriptParsed", d.scripts[id].parsedEvent())
+		}
+		d.mu.Unlock()
+		id := rtdomain.UniqueDebuggerId(debuggerTargetId)
+		return &debugger.ResponseEnable{DebuggerId: &id}, nil
+	case "Debugger.disable":
+		atomic.StoreUint32(&d.attached, 0)
+		return nil, nil
+	case "Debugger.setBreakpointsActive":
+		var cmd debugger.CommandSetBreakpointsActive
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		atomic.StoreUint32(&d.active, boolToUint32(cmd.Active))
+		return nil, nil
+	case "Debugger.setSkipAllPauses":
+		var cmd debugger.CommandSetSkipAllPauses
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		atomic.StoreUint32(&d.skipPauses, boolToUint32(cmd.Skip))
+		return nil, nil
+	case "Debugger.setBreakpointByUrl":
+		var cmd debugger.CommandSetBreakpointByUrl
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		return d.setBreakpointByUrl(&cmd)
+	case "Debugger.setBreakpoint":
+		var cmd debugger.CommandSetBreakpoint
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		return d.setBreakpoint(&cmd)
+	case "Debugger.removeBreakpoint":
+		var cmd debugger.CommandRemoveBreakpoint
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		if cmd.BreakpointId != nil {
+			d.mu.Lock()
+			delete(d.breakpoints, string(*cmd.BreakpointId))
+			d.updateLocations()
+			d.mu.Unlock()
+		}
+		return nil, nil
+	case "Debugger.getPossibleBreakpoints":
+		var cmd debugger.CommandGetPossibleBreakpoints
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		return d.getPossibleBreakpoints(&cmd)
+	case "Debugger.getScriptSource":
+		var cmd debugger.CommandGetScriptSource
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		s := d.script(cmd.ScriptId)
+		if s == nil {
+			return nil, errNoScript
+		}
+		return &debugger.ResponseGetScriptSource{ScriptSource: s.src.src}, nil
+	case "Debugger.searchInContent":
+		var cmd debugger.CommandSearchInContent
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		return d.searchInContent(&cmd)
+	case "Debugger.pause":
+		atomic.StoreUint32(&d.pauseRequested, 1)
+		return nil, nil
+	case "Debugger.resume":
+		return nil, d.execPaused(func(c *debugContext) {
+			c.stepMode = stepNone
+		})
+	case "Debugger.stepInto":
+		return nil, d.execPaused(func(c *debugContext) {
+			c.startStep(stepInto)
+		})
+	case "Debugger.stepOver":
+		return nil, d.execPaused(func(c *debugContext) {
+			c.startStep(stepOver)
+		})
+	case "Debugger.stepOut":
+		return nil, d.execPaused(func(c *debugContext) {
+			c.startStep(stepOut)
+		})
+	case "Debugger.evaluateOnCallFrame":
+		var cmd debugger.CommandEvaluateOnCallFrame
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		return d.evaluateOnCallFrame(&cmd)
+	case "Runtime.enable":
+		d.mu.Lock()
+		for _, c := range d.contexts {
+			d.sendEvent("Runtime.executionContextCreated", c.description())
+		}
+		d.mu.Unlock()
+		return nil, nil
+	case "Runtime.evaluate":
+		var cmd rtdomain.CommandEvaluate
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		return d.evaluate(&cmd)
+	case "Runtime.getProperties":
+		var cmd rtdomain.CommandGetProperties
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		return d.getProperties(&cmd)
+	case "Runtime.releaseObject":
+		var cmd rtdomain.CommandReleaseObject
+		if err := unmarshal(&cmd); err != nil {
+			return nil, err
+		}
+		if cmd.ObjectId != nil {
+			if c := d.contextForId(string(*cmd.ObjectId)); c != nil {
+				c.exec(func() bool {
+					delete(c.objects, string(*cmd.ObjectId))
+					return false
+				})
+			}
+		}
+		return nil, nil
+	}
+	// Everything else (including the domains that don't apply to goja, e.g. Profiler or Network)
+	// is acknowledged with an empty result.
+	return nil, nil
+}
+
+func boolToUint32(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (d *Debugger) script(id *rtdomain.ScriptId) *debugScript {
+	if id == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.scripts[string(*id)]
+}
+
+func (d *Debugger) pausedContext() *debugContext {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, c := range d.contexts {
+		if c.paused {
+			return c
+		}
+	}
+	return nil
+}
+
+// contextForId returns the context encoded in a call frame or remote object id ("<context>:<n>").
+func (d *Debugger) contextForId(id string) *debugContext {
+	if i := strings.IndexByte(id, ':'); i > 0 {
+		if ctxId, err := strconv.Atoi(id[:i]); err == nil {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			for _, c := range d.contexts {
+				if c.id == ctxId {
+					return c
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Debugger) execPaused(f func(c *debugContext)) error {
+	c := d.pausedContext()
+	if c == nil {
+		return errNotPaused
+	}
+	if !c.exec(func() bool {
+		f(c)
+		return c.resume()
+	}) {
+		return errNotPaused
+	}
+	return nil
+}
+
+func (d *Debugger) setBreakpointByUrl(cmd *debugger.CommandSetBreakpointByUrl) (*debugger.ResponseSetBreakpointByUrl, error) {
+	bp := &debugBreakpoint{
+		url:       cmd.Url,
+		line:      cmd.LineNumber,
+		col:       cmd.ColumnNumber,
+		condition: cmd.Condition,
+	}
+	if cmd.UrlRegex != "" {
+		re, err := regexp.Compile(cmd.UrlRegex)
+		if err != nil {
+			return nil, err
+		}
+		bp.urlRegex = re
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.addBreakpoint(bp)
+	for _, s := range d.scripts {
+		if bp.matches(s) {
+			bp.resolve(s)
+		}
+	}
+	d.updateLocations()
+	id := debugger.BreakpointId(bp.id)
+	return &debugger.ResponseSetBreakpointByUrl{
+		BreakpointId: &id,
+		Locations:    bp.cdpLocations(),
+	}, nil
+}
+
+func (d *Debugger) setBreakpoint(cmd *debugger.CommandSetBreakpoint) (*debugger.ResponseSetBreakpoint, error) {
+	if cmd.Location == nil || cmd.Location.ScriptId == nil {
+		return nil, errNoScript
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.scripts[string(*cmd.Location.ScriptId)]
+	if s == nil {
+		return nil, errNoScript
+	}
+	bp := &debugBreakpoint{
+		scriptId:  s.id,
+		line:      cmd.Location.LineNumber,
+		col:       cmd.Location.ColumnNumber,
+		condition: cmd.Condition,
+	}
+	bp.resolve(s)
+	if len(bp.locations) == 0 {
+		return nil, errNoBreakLocation
+	}
+	d.addBreakpoint(bp)
+	d.updateLocations()
+	id := debugger.BreakpointId(bp.id)
+	return &debugger.ResponseSetBreakpoint{
+		BreakpointId:   &id,
+		ActualLocation: bp.cdpLocations()[0],
+	}, nil
+}
+
+func (d *Debugger) addBreakpoint(bp *debugBreakpoint) {
+	d.bpSeq++
+	bp.id = strconv.Itoa(d.bpSeq)
+	d.breakpoints[bp.id] = bp
+}
+
+// updateLocations must be called with d.mu held after the set of breakpoint locations has changed.
+func (d *Debugger) updateLocations() {
+	idx := make(breakpointIndex)
+	for _, bp := range d.breakpoints {
+		for _, loc := range bp.locations {
+			m := idx[loc.prg]
+			if m == nil {
+				m = make(map[int][]string)
+				idx[loc.prg] = m
+			}
+			ids := m[loc.pc]
+			if len(ids) == 0 || ids[len(ids)-1] != bp.id {
+				m[loc.pc] = append(ids, bp.id)
+			}
+		}
+	}
+	d.locations.Store(idx)
+}
+
+func (d *Debugger) getPossibleBreakpoints(cmd *debugger.CommandGetPossibleBreakpoints) (*debugger.ResponseGetPossibleBreakpoints, error) {
+	if cmd.Start == nil {
+		return nil, errNoScript
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.scripts[string(*cmd.Start.ScriptId)]
+	if s == nil {
+		return nil, errNoScript
+	}
+	start := s.offset(cmd.Start.LineNumber, cmd.Start.ColumnNumber)
+	end := len(s.src.src)
+	if cmd.End != nil {
+		end = s.offset(cmd.End.LineNumber, cmd.End.ColumnNumber)
+	}
+	seen := make(map[int]bool)
+	var offsets []int
+	for _, p := range s.progs {
+		for _, item := range p.srcMap {
+			if item.srcPos >= start && item.srcPos < end && !seen[item.srcPos] {
+				seen[item.srcPos] = true
+				offsets = append(offsets, item.srcPos)
+			}
+		}
+	}
+	sort.Ints(offsets)
+	res := &debugger.ResponseGetPossibleBreakpoints{
+		Locations: make([]*debugger.BreakLocation, 0, len(offsets)),
+	}
+	for _, offset := range offsets {
+		loc := s.location(offset)
+		res.Locations = append(res.Locations, &debugger.BreakLocation{
+			ScriptId:     loc.ScriptId,
+			LineNumber:   loc.LineNumber,
+			ColumnNumber: loc.ColumnNumber,
+		})
+	}
+	return res, nil
+}
+
+func (d *Debugger) searchInContent(cmd *debugger.CommandSearchInContent) (*debugger.ResponseSearchInContent, error) {
+	s := d.script(cmd.ScriptId)
+	if s == nil {
+		return nil, errNoScript
+	}
+	pattern := cmd.Query
+	if !cmd.IsRegex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !cmd.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	res := &debugger.ResponseSearchInContent{
+		Result: []*debugger.SearchMatch{},
+	}
+	for i, line := range strings.Split(s.src.src, "\n") {
+		if re.MatchString(line) {
+			res.Result = append(res.Result, &debugger.SearchMatch{
+				LineNumber:  i,
+				LineContent: line,
+			})
+		}
+	}
+	return res, nil
+}
+
+func (d *Debugger) evaluateOnCallFrame(cmd *debugger.CommandEvaluateOnCallFrame) (res *debugger.ResponseEvaluateOnCallFrame, err error) {
+	if cmd.CallFrameId == nil {
+		return nil, errNoCallFrame
+	}
+	frameId := string(*cmd.CallFrameId)
+	c := d.contextForId(frameId)
+	if c == nil {
+		return nil, errNoCallFrame
+	}
+	if !c.exec(func() bool {
+		idx, _ := strconv.Atoi(frameId[strings.IndexByte(frameId, ':')+1:])
+		if idx < 0 || idx >= len(c.frames) {
+			err = errNoCallFrame
+			return false
+		}
+		result, details := c.evaluate(c.frames[idx], cmd.Expression, cmd.ReturnByValue)
+		res = &debugger.ResponseEvaluateOnCallFrame{
+			Result:           result,
+			ExceptionDetails: details,
+		}
+		return false
+	}) {
+		return nil, errNotPaused
+	}
+	return
+}
+
+func (d *Debugger) evaluate(cmd *rtdomain.CommandEvaluate) (res *rtdomain.ResponseEvaluate, err error) {
+	var c *debugContext
+	if cmd.ContextId != nil {
+		d.mu.Lock()
+		for _, ctx := range d.contexts {
+			if ctx.id == int(*cmd.ContextId) {
+				c = ctx
+			}
+		}
+		d.mu.Unlock()
+	} else {
+		c = d.pausedContext()
+	}
+	if c == nil || !c.exec(func() bool {
+		result, details := c.evaluate(nil, cmd.Expression, cmd.ReturnByValue)
+		res = &rtdomain.ResponseEvaluate{
+			Result:           result,
+			ExceptionDetails: details,
+		}
+		return false
+	}) {
+		return nil, errNotPaused
+	}
+	return
+}
+
+func (d *Debugger) getProperties(cmd *rtdomain.CommandGetProperties) (res *rtdomain.ResponseGetProperties, err error) {
+	if cmd.ObjectId == nil {
+		return nil, errNoObject
+	}
+	id := string(*cmd.ObjectId)
+	c := d.contextForId(id)
+	if c == nil {
+		return nil, errNoObject
+	}
+	if !c.exec(func() bool {
+		v, exists := c.objects[id]
+		if !exists {
+			err = errNoObject
+			return false
+		}
+		res = &rtdomain.ResponseGetProperties{
+			Result: c.properties(v.(*Object), cmd.OwnProperties, cmd.AccessorPropertiesOnly),
+		}
+		return false
+	}) {
+		return nil, errNoObject
+	}
+	return
+}
+
+func (bp *debugBreakpoint) matches(s *debugScript) bool {
+	if bp.scriptId != "" {
+		return bp.scriptId == s.id
+	}
+	if bp.urlRegex != nil {
+		return bp.urlRegex.MatchString(s.src.name)
+	}
+	return bp.url == s.src.name
+}
+
+// resolve binds the breakpoint to the first statement at or after its position in every program of the script.
+func (bp *debugBreakpoint) resolve(s *debugScript) {
+	if bp.line >= len(s.lines) {
+		return
+	}
+	target := s.offset(bp.line, bp.col)
+	srcPos := -1
+	for _, p := range s.progs {
+		for _, item := range p.srcMap {
+			if item.srcPos >= target && (srcPos == -1 || item.srcPos < srcPos) {
+				srcPos = item.srcPos
+			}
+		}
+	}
+	if srcPos == -1 {
+		return
+	}
+	for _, p := range s.progs {
+		for _, item := range p.srcMap {
+			if item.srcPos == srcPos {
+				bp.locations = append(bp.locations, breakpointLocation{
+					script: s,
+					prg:    p,
+					pc:     item.pc,
+					srcPos: srcPos,
+				})
+				break
+			}
+		}
+	}
+}
+
+func (bp *debugBreakpoint) cdpLocations() []*debugger.Location {
+	locations := make([]*debugger.Location, 0, len(bp.locations))
+	seen := make(map[breakpointLocation]bool)
+	for _, loc := range bp.locations {
+		key := breakpointLocation{script: loc.script, srcPos: loc.srcPos}
+		if !seen[key] {
+			seen[key] = true
+			locations = append(locations, loc.script.location(loc.srcPos))
+		}
+	}
+	return locations
+}
+
+func lineOffsets(src string) []int {
+	lines := []int{0}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+func (s *debugScript) offset(line, col int) int {
+	if line < 0 {
+		return 0
+	}
+	if line >= len(s.lines) {
+		return len(s.src.src)
+	}
+	return s.lines[line] + col
+}
+
+func (s *debugScript) location(offset int) *debugger.Location {
+	line := sort.Search(len(s.lines), func(i int) bool {
+		return s.lines[i] > offset
+	}) - 1
+	if line < 0 {
+		line = 0
+	}
+	id := rtdomain.ScriptId(s.id)
+	return &debugger.Location{
+		ScriptId:     &id,
+		LineNumber:   line,
+		ColumnNumber: offset - s.lines[line],
+	}
+}
+
+func (s *debugScript) parsedEvent() *debugger.EventScriptParsed {
+	id := rtdomain.ScriptId(s.id)
+	ctxId := rtdomain.ExecutionContextId(s.ctx.id)
+	endLine := len(s.lines) - 1
+	return &debugger.EventScriptParsed{
+		ScriptId:           &id,
+		Url:                s.src.name,
+		EndLine:            endLine,
+		EndColumn:          len(s.src.src) - s.lines[endLine],
+		ExecutionContextId: &ctxId,
+		Hash:               s.hash,
+		Length:             len(s.src.src),
+	}
+}
+
+func (c *debugContext) description() *rtdomain.EventExecutionContextCreated {
+	id := rtdomain.ExecutionContextId(c.id)
+	return &rtdomain.EventExecutionContextCreated{
+		Context: &rtdomain.ExecutionContextDescription{
+			Id:   &id,
+			Name: "goja #" + strconv.Itoa(c.id),
+		},
+	}
+}
+
+// exec runs f on the goroutine of the paused runtime and waits for it to complete. f returns true to resume
+// execution. Returns false if the runtime is not paused. Called from the client goroutine.
+func (c *debugContext) exec(f func() bool) bool {
+	c.d.mu.Lock()
+	paused := c.paused
+	c.d.mu.Unlock()
+	if !paused {
+		return false
+	}
+	done := make(chan struct{})
+	c.cmds <- func() bool {
+		defer close(done)
+		return f()
+	}
+	<-done
+	return true
+}
+
+func (c *debugContext) resume() bool {
+	c.d.mu.Lock()
+	c.paused = false
+	c.d.mu.Unlock()
+	return true
+}
+
+func (c *debugContext) addProgram(p *Program) {
+	if p.src == nil {
+		return
+	}
+	d := c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := debugScriptKey{ctx: c, src: p.src}
+	s := d.scriptsBySrc[key]
+	if s != nil {
+		for _, prg := range s.progs {
+			if prg == p {
+				return
+			}
+		}
+	} else {
+		d.scriptSeq++
+		sum := sha1.Sum([]byte(p.src.src))
+		s = &debugScript{
+			id:    strconv.Itoa(d.scriptSeq),
+			ctx:   c,
+			src:   p.src,
+			lines: lineOffsets(p.src.src),
+			hash:  fmt.Sprintf("%x", sum),
+		}
+		d.scripts[s.id] = s
+		d.scriptsBySrc[key] = s
+	}
+	s.addProgram(p)
+	if atomic.LoadUint32(&d.attached) != 0 {
+		d.sendEvent("Debugger.scriptParsed", s.parsedEvent())
+	}
+	resolved := false
+	for _, bp := range d.breakpoints {
+		if bp.matches(s) {
+			n := len(bp.locations)
+			bp.resolve(s)
+			for _, loc := range bp.locations[n:] {
+				id := debugger.BreakpointId(bp.id)
+				d.sendEvent("Debugger.breakpointResolved", &debugger.EventBreakpointResolved{
+					BreakpointId: &id,
+					Location:     s.location(loc.srcPos),
+				})
+				resolved = true
+			}
+		}
+	}
+	if resolved {
+		d.updateLocations()
+	}
+}
+
+func (s *debugScript) addProgram(p *Program) {
+	s.progs = append(s.progs, p)
+	for _, instr := range p.code {
+		if f, ok := instr.(*newFunc); ok {
+			s.addProgram(f.prg)
+		}
+	}
+}
+
+func (c *debugContext) lineOf(prg *Program, srcPos int) int {
+	lines := c.lines[prg.src]
+	if lines == nil {
+		lines = lineOffsets(prg.src.src)
+		c.lines[prg.src] = lines
+	}
+	return sort.Search(len(lines), func(i int) bool {
+		return lines[i] > srcPos
+	}) - 1
+}
+
+// statementAt returns true if pc is at the start of an expression or a statement that has a source position.
+func statementAt(prg *Program, pc int) (srcPos int, ok bool) {
+	i := sort.Search(len(prg.srcMap), func(i int) bool {
+		return prg.srcMap[i].pc >= pc
+	})
+	if i < len(prg.srcMap) && prg.srcMap[i].pc == pc {
+		return prg.srcMap[i].srcPos, true
+	}
+	return 0, false
+}
+
+// step is called by the vm before every instruction.
+func (c *debugContext) step(vm *vm) {
+	d := c.d
+	if c.evaluating || atomic.LoadUint32(&d.attached) == 0 || atomic.LoadUint32(&d.skipPauses) != 0 {
+		return
+	}
+	if c.stepMode != stepNone || atomic.LoadUint32(&d.pauseRequested) != 0 {
+		if srcPos, ok := statementAt(vm.prg, vm.pc); ok && c.shouldStop(vm, srcPos) {
+			c.pause(vm, debugger.PausedReasonTypeOther, nil)
+			return
+		}
+	}
+	if atomic.LoadUint32(&d.active) != 0 {
+		if ids := d.locations.Load().(breakpointIndex)[vm.prg][vm.pc]; len(ids) > 0 {
+			hits := make([]string, 0, len(ids))
+			d.mu.Lock()
+			var conditions []string
+			for _, id := range ids {
+				if bp := d.breakpoints[id]; bp != nil {
+					hits = append(hits, id)
+					conditions = append(conditions, bp.condition)
+				}
+			}
+			d.mu.Unlock()
+			for i := 0; i < len(hits); i++ {
+				if conditions[i] != "" && !c.checkCondition(vm, conditions[i]) {
+					hits = append(hits[:i], hits[i+1:]...)
+					conditions = append(conditions[:i], conditions[i+1:]...)
+					i--
+				}
+			}
+			if len(hits) > 0 {
+				c.pause(vm, debugger.PausedReasonTypeOther, hits)
+			}
+		}
+	}
+}
+
+func (c *debugContext) debuggerStatement(vm *vm) {
+	d := c.d
+	if c.evaluating || atomic.LoadUint32(&d.attached) == 0 || atomic.LoadUint32(&d.skipPauses) != 0 {
+		return
+	}
+	c.pause(vm, debugger.PausedReasonTypeOther, nil)
+}
+
+func (c *debugContext) shouldStop(vm *vm, srcPos int) bool {
+	if atomic.LoadUint32(&c.d.pauseRequested) != 0 {
+		return true
+	}
+	depth := len(vm.callStack)
+	switch c.stepMode {
+	case stepInto:
+		return depth != c.stepDepth || vm.prg != c.stepPrg || c.lineOf(vm.prg, srcPos) != c.stepLine
+	case stepOver:
+		return depth < c.stepDepth || depth == c.stepDepth && (vm.prg != c.stepPrg || c.lineOf(vm.prg, srcPos) != c.stepLine)
+	case stepOut:
+		return depth < c.stepDepth
+	}
+	return false
+}
+
+func (c *debugContext) startStep(mode int) {
+	c.stepMode = mode
+	c.stepDepth = len(c.r.vm.callStack)
+	c.stepPrg = c.frames[0].prg
+	c.stepLine = c.lineOf(c.stepPrg, c.stepPrg.sourceOffset(c.frames[0].pc))
+}
+
+func (c *debugContext) checkCondition(vm *vm, condition string) bool {
+	v, ex := c.eval(c.newFrame(vm.prg, vm.pc, vm.stash, vm.sb, vm.args), condition)
+	return ex == nil && v.ToBoolean()
+}
+
+// pause blocks the runtime until the client resumes it. While paused the client commands that need access
+// to the runtime are executed on this goroutine.
+func (c *debugContext) pause(vm *vm, reason debugger.PausedReasonType, hits []string) {
+	d := c.d
+	atomic.StoreUint32(&d.pauseRequested, 0)
+	c.stepMode = stepNone
+	c.objects = make(map[string]Value)
+	c.frames = c.collectFrames(vm)
+
+	d.mu.Lock()
+	if d.client == nil {
+		d.mu.Unlock()
+		c.frames = nil
+		c.objects = nil
+		return
+	}
+	c.paused = true
+	d.sendEvent("Debugger.paused", &debugger.EventPaused{
+		CallFrames:     c.callFrames(),
+		Reason:         &reason,
+		HitBreakpoints: hits,
+	})
+	d.mu.Unlock()
+
+	for cmd := range c.cmds {
+		if cmd() {
+			break
+		}
+	}
+
+	c.frames = nil
+	c.objects = nil
+	d.mu.Lock()
+	d.sendEvent("Debugger.resumed", &debugger.EventResumed{})
+	d.mu.Unlock()
+}
+
+func (c *debugContext) newFrame(prg *Program, pc int, stash *stash, sb, args int) *debugFrame {
+	f := &debugFrame{
+		prg:      prg,
+		pc:       pc,
+		funcName: prg.funcName,
+		stash:    stash,
+		sb:       sb,
+		args:     args,
+		this:     c.r.globalObject,
+	}
+	if f.isFunction() {
+		f.this = c.r.vm.stack[sb]
+	}
+	return f
+}
+
+func (c *debugContext) collectFrames(vm *vm) []*debugFrame {
+	frames := []*debugFrame{c.newFrame(vm.prg, vm.pc, vm.stash, vm.sb, vm.args)}
+	for i := len(vm.callStack) - 1; i >= 0; i-- {
+		ctx := &vm.callStack[i]
+		if ctx.prg == nil || ctx.pc == -1 {
+			continue
+		}
+		frames = append(frames, c.newFrame(ctx.prg, ctx.pc-1, ctx.stash, ctx.sb, ctx.args))
+	}
+	return frames
+}
+
+func (f *debugFrame) isFunction() bool {
+	if len(f.prg.code) > 0 {
+		switch f.prg.code[0].(type) {
+		case enterFunc, enterFuncStashless:
+			return true
+		}
+	}
+	return false
+}
+
+func (f *debugFrame) isStashless() bool {
+	if len(f.prg.code) > 0 {
+		_, ok := f.prg.code[0].(enterFuncStashless)
+		return ok
+	}
+	return false
+}
+
+func isDebuggerVisibleName(name string) bool {
+	return name != "" && name[0] != ' ' && (name[0] < '0' || name[0] > '9')
+}
+
+// localScope returns a snapshot of the stack variables of a stashless function.
+func (c *debugContext) localScope(f *debugFrame) *Object {
+	if f.local != nil {
+		return f.local
+	}
+	r := c.r
+	stack := r.vm.stack
+	obj := r.CreateObject(nil)
+	names := make([]string, 0, len(f.prg.stackNames))
+	for name := range f.prg.stackNames {
+		if isDebuggerVisibleName(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var v Value
+		idx := f.prg.stackNames[name]
+		if idx < 0 {
+			if arg := -idx; arg <= f.args {
+				v = stack[f.sb+arg]
+			}
+		} else {
+			v = stack[f.sb+f.args+idx]
+		}
+		if v == nil {
+			v = _undefined
+		}
+		obj.self._putProp(name, v, true, true, true)
+	}
+	f.local = obj
+	return obj
+}
+
+func (c *debugContext) stashScope(s *stash) *Object {
+	obj := c.r.CreateObject(nil)
+	if s.obj != nil {
+		for item, next := s.obj.enumerate(false, false)(); next != nil; item, next = next() {
+			if p, ok := s.obj.getOwnProp(item.name).(*valueProperty); ok {
+				if !p.accessor {
+					obj.self._putProp(item.name, p.value, true, true, true)
+				}
+			} else if v := s.obj.getOwnProp(item.name); v != nil {
+				obj.self._putProp(item.name, v, true, true, true)
+			}
+		}
+		return obj
+	}
+	names := make([]string, 0, len(s.names))
+	for name := range s.names {
+		if isDebuggerVisibleName(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := s.values[s.names[name]]
+		if v == nil {
+			v = _undefined
+		}
+		obj.self._putProp(name, v, true, true, true)
+	}
+	return obj
+}
+
+func (c *debugContext) scopeChain(f *debugFrame) []*debugger.Scope {
+	var scopes []*debugger.Scope
+	add := func(typ debugger.ScopeType, obj *Object) {
+		scopes = append(scopes, &debugger.Scope{
+			Type:   &typ,
+			Object: c.remoteObject(obj, false),
+		})
+	}
+	local := f.isFunction()
+	if f.isStashless() {
+		add(debugger.ScopeTypeLocal, c.localScope(f))
+		local = false
+	}
+	for s := f.stash; s != nil; s = s.outer {
+		switch {
+		case s.obj != nil:
+			add(debugger.ScopeTypeWith, c.stashScope(s))
+		case s.lexical:
+			add(debugger.ScopeTypeBlock, c.stashScope(s))
+		case local:
+			add(debugger.ScopeTypeLocal, c.stashScope(s))
+			local = false
+		default:
+			add(debugger.ScopeTypeClosure, c.stashScope(s))
+		}
+	}
+	if len(c.r.globalLexicals.names) > 0 {
+		add(debugger.ScopeTypeScript, c.stashScope(c.r.globalLexicals))
+	}
+	add(debugger.ScopeTypeGlobal, c.r.globalObject)
+	return scopes
+}
+
+func (c *debugContext) callFrames() []*debugger.CallFrame {
+	frames := make([]*debugger.CallFrame, 0, len(c.frames))
+	for i, f := range c.frames {
+		s := c.d.scriptsBySrc[debugScriptKey{ctx: c, src: f.prg.src}]
+		if s == nil {
+			continue
+		}
+		id := debugger.CallFrameId(strconv.Itoa(c.id) + ":" + strconv.Itoa(i))
+		frames = append(frames, &debugger.CallFrame{
+			CallFrameId:  &id,
+			FunctionName: f.funcName,
+			Location:     s.location(f.prg.sourceOffset(f.pc)),
+			Url:          f.prg.src.name,
+			ScopeChain:   c.scopeChain(f),
+			This:         c.remoteObject(f.this, false),
+		})
+	}
+	return frames
+}
+
+// evalStash returns the stash in which expressions are evaluated. The local variables of stashless functions
+// are exposed through a with-like scope, modifications to them are not written back.
+func (c *debugContext) evalStash(f *debugFrame) *stash {
+	if f.isStashless() {
+		return &stash{
+			obj:   c.localScope(f).self,
+			outer: f.stash,
+		}
+	}
+	return f.stash
+}
+
+func (c *debugContext) eval(f *debugFrame, src string) (result Value, ex *Exception) {
+	r := c.r
+	p, err := r.compile("", src, false, true)
 	if err != nil {
-		log.Infof("json request: %s", string(data))
+		if e, ok := err.(*Exception); ok {
+			return nil, e
+		}
+		return nil, &Exception{val: newStringValue(err.Error())}
+	}
+	var st *stash
+	var this Value = r.globalObject
+	if f != nil {
+		st = c.evalStash(f)
+		this = f.this
+	}
+	vm := r.vm
+	c.evaluating = true
+	defer func() {
+		c.evaluating = false
+	}()
+	ex = vm.try(func() {
+		vm.pushCtx()
+		vm.prg = p
+		vm.pc = 0
+		vm.stash = st
+		vm.sb = vm.sp
+		vm.push(this)
+		vm.push(valueFalse)
+		vm.run()
+		result = vm.stack[vm.sp-1]
+		vm.popCtx()
+		vm.halt = false
+		vm.sp -= 2
+	})
+	return
+}
+
+func (c *debugContext) evaluate(f *debugFrame, src string, byValue bool) (*rtdomain.RemoteObject, *rtdomain.ExceptionDetails) {
+	v, ex := c.eval(f, src)
+	if ex != nil {
+		return c.remoteObject(ex.val, false), &rtdomain.ExceptionDetails{
+			ExceptionId: 1,
+			Text:        "Uncaught",
+			Exception:   c.remoteObject(ex.val, false),
+		}
+	}
+	return c.remoteObject(v, byValue), nil
+}
+
+func (c *debugContext) registerObject(o *Object) *rtdomain.RemoteObjectId {
+	c.objSeq++
+	id := rtdomain.RemoteObjectId(strconv.Itoa(c.id) + ":" + strconv.Itoa(c.objSeq))
+	c.objects[string(id)] = o
+	return &id
+}
+
+// dataProperty looks up a data property along the prototype chain without invoking getters or proxy traps.
+func dataProperty(o *Object, name string) Value {
+	for o != nil {
+		if _, isProxy := o.self.(*proxyObject); isProxy {
+			return nil
+		}
+		if v := o.self.getOwnProp(name); v != nil {
+			if p, ok := v.(*valueProperty); ok {
+				if p.accessor {
+					return nil
+				}
+				return p.value
+			}
+			return v
+		}
+		o = o.self.proto()
+	}
+	return nil
+}
+
+func dataPropertyString(o *Object, name string) string {
+	if v := dataProperty(o, name); v != nil {
+		if _, isObj := v.(*Object); !isObj && v != _undefined {
+			return v.String()
+		}
+	}
+	return ""
+}
+
+func (c *debugContext) remoteObject(v Value, byValue bool) *rtdomain.RemoteObject {
+	ro := &rtdomain.RemoteObject{}
+	setType := func(t rtdomain.RemoteObjectType) {
+		ro.Type = &t
+	}
+	setSubtype := func(t rtdomain.RemoteObjectSubtype) {
+		ro.Subtype = &t
+	}
+	switch v := v.(type) {
+	case nil, valueUndefined:
+		setType(rtdomain.RemoteObjectTypeUndefined)
+		ro.Description = "undefined"
+	case valueNull:
+		setType(rtdomain.RemoteObjectTypeObject)
+		setSubtype(rtdomain.RemoteObjectSubtypeNull)
+		ro.Description = "null"
+	case valueBool:
+		setType(rtdomain.RemoteObjectTypeBoolean)
+		ro.Value = bool(v)
+		ro.Description = v.String()
+	case valueInt:
+		setType(rtdomain.RemoteObjectTypeNumber)
+		ro.Value = int64(v)
+		ro.Description = v.String()
+	case valueFloat:
+		setType(rtdomain.RemoteObjectTypeNumber)
+		f := float64(v)
+		var u rtdomain.UnserializableValue
+		switch {
+		case math.IsNaN(f):
+			u = rtdomain.UnserializableValueNaN
+		case math.IsInf(f, 1):
+			u = rtdomain.UnserializableValueInfinity
+		case math.IsInf(f, -1):
+			u = rtdomain.UnserializableValueNegativeInfinity
+		case f == 0 && math.Signbit(f):
+			u = rtdomain.UnserializableValueNegativeZero
+		default:
+			ro.Value = f
+		}
+		if u != "" {
+			ro.UnserializableValue = &u
+		}
+		ro.Description = v.String()
+	case valueString:
+		setType(rtdomain.RemoteObjectTypeString)
+		ro.Value = v.String()
+		ro.Description = v.String()
+	case *Symbol:
+		setType(rtdomain.RemoteObjectTypeSymbol)
+		ro.Description = v.String()
+	case *valueBigInt:
+		setType(rtdomain.RemoteObjectTypeBigint)
+		u := rtdomain.UnserializableValue(v.String() + "n")
+		ro.UnserializableValue = &u
+		ro.Description = v.String() + "n"
+	case *Object:
+		setType(rtdomain.RemoteObjectTypeObject)
+		ro.ClassName = v.self.className()
+		switch o := v.self.(type) {
+		case *funcObject:
+			setType(rtdomain.RemoteObjectTypeFunction)
+			ro.Description = o.src
+		case *proxyObject:
+			setSubtype(rtdomain.RemoteObjectSubtypeProxy)
+			ro.Description = "Proxy"
+		case *regexpObject:
+			setSubtype(rtdomain.RemoteObjectSubtypeRegexp)
+			ro.Description = "/" + o.source.String() + "/"
+			if o.global {
+				ro.Description += "g"
+			}
+			if o.ignoreCase {
+				ro.Description += "i"
+			}
+			if o.multiline {
+				ro.Description += "m"
+			}
+		case *dateObject:
+			setSubtype(rtdomain.RemoteObjectSubtypeDate)
+			if o.isSet {
+				ro.Description = o.time.Format(dateTimeLayout)
+			} else {
+				ro.Description = "Invalid Date"
+			}
+		default:
+			if _, ok := o.assertCallable(); ok {
+				setType(rtdomain.RemoteObjectTypeFunction)
+				ro.Description = "function " + dataPropertyString(v, "name") + "() { [native code] }"
+				break
+			}
+			switch ro.ClassName {
+			case classArray:
+				setSubtype(rtdomain.RemoteObjectSubtypeArray)
+				ro.Description = fmt.Sprintf("Array(%d)", toLength(o.getStr("length")))
+			case classError:
+				setSubtype(rtdomain.RemoteObjectSubtypeError)
+				ro.Description = dataPropertyString(v, "name")
+				if msg := dataPropertyString(v, "message"); msg != "" {
+					ro.Description += ": " + msg
+				}
+			case classObject:
+				if ctor, ok := dataProperty(v, "constructor").(*Object); ok {
+					if name := dataPropertyString(ctor, "name"); name != "" {
+						ro.ClassName = name
+					}
+				}
+				ro.Description = ro.ClassName
+			default:
+				ro.Description = ro.ClassName
+			}
+		}
+		if byValue {
+			if data, err := json.Marshal(v.Export()); err == nil {
+				ro.Value = json.RawMessage(data)
+				break
+			}
+		}
+		ro.ObjectId = c.registerObject(v)
+	default:
+		setType(rtdomain.RemoteObjectTypeUndefined)
+		ro.Description = v.String()
+	}
+	return ro
+}
+
+func (c *debugContext) properties(o *Object, own, accessorsOnly bool) []*rtdomain.PropertyDescriptor {
+	result := make([]*rtdomain.PropertyDescriptor, 0)
+	if _, isProxy := o.self.(*proxyObject); isProxy {
+		return result
+	}
+	for item, next := o.self.enumerate(true, false)(); next != nil; item, next = next() {
+		desc := &rtdomain.PropertyDescriptor{
+			Name:  item.name,
+			IsOwn: true,
+		}
+		switch p := o.self.getOwnProp(item.name).(type) {
+		case nil:
+			continue
+		case *valueProperty:
+			desc.Configurable = p.configurable
+			desc.Enumerable = p.enumerable
+			if p.accessor {
+				desc.Get = c.remoteObject(propOrUndefined(p.getterFunc), false)
+				desc.Set = c.remoteObject(propOrUndefined(p.setterFunc), false)
+			} else {
+				if accessorsOnly {
+					continue
+				}
+				desc.Writable = p.writable
+				desc.Value = c.remoteObject(p.value, false)
+			}
+		default:
+			if accessorsOnly {
+				continue
+			}
+			desc.Writable = true
+			desc.Configurable = true
+			desc.Enumerable = item.enumerable != _ENUM_FALSE
+			desc.Value = c.remoteObject(p, false)
+		}
+		result = append(result, desc)
+	}
+	if own && !accessorsOnly {
+		if proto := o.self.proto(); proto != nil {
+			result = append(result, &rtdomain.PropertyDescriptor{
+				Name:         "__proto__",
+				Value:        c.remoteObject(proto, false),
+				Writable:     true,
+				Configurable: true,
+				IsOwn:        true,
+			})
+		}
+	}
+	return result
+}
 
-		writer.Header().Set("Content-Type", "application/json")
-		writer.Write([]byte("{}"))
+func propOrUndefined(o *Object) Value {
+	if o == nil {
+		return _undefined
 	}
+	return o
 }
@@ -7,7 +7,7 @@ type BreakpointId string
 type CallFrameId string
 
 type Location struct {
-	ScriptId     *runtime.ScriptId `json:"scriptId"`
+	ScriptId     *runtime.ScriptId `json:"scriptId,omitempty"`
 	LineNumber   int               `json:"lineNumber"`
 	ColumnNumber int               `json:"columnNumber"`
 }
@@ -18,14 +18,14 @@ type ScriptPosition struct {
 }
 
 type CallFrame struct {
-	CallFrameId      *CallFrameId          `json:"callFrameId"`
+	CallFrameId      *CallFrameId          `json:"callFrameId,omitempty"`
 	FunctionName     string                `json:"functionName"`
-	FunctionLocation *Location             `json:"functionLocation"`
-	Location         *Location             `json:"location"`
+	FunctionLocation *Location             `json:"functionLocation,omitempty"`
+	Location         *Location             `json:"location,omitempty"`
 	Url              string                `json:"url"`
 	ScopeChain       []*Scope              `json:"scopeChain"`
-	This             *runtime.RemoteObject `json:"this"`
-	ReturnValue      *runtime.RemoteObject `json:"returnValue"`
+	This             *runtime.RemoteObject `json:"this,omitempty"`
+	ReturnValue      *runtime.RemoteObject `json:"returnValue,omitempty"`
 }
 
 type ScopeType string
@@ -43,11 +43,11 @@ const (
 )
 
 type Scope struct {
-	Type          *ScopeType            `json:"type"`
-	Object        *runtime.RemoteObject `json:"object"`
+	Type          *ScopeType            `json:"type,omitempty"`
+	Object        *runtime.RemoteObject `json:"object,omitempty"`
 	Name          string                `json:"name"`
-	StartLocation *Location             `json:"startLocation"`
-	EndLocation   *Location             `json:"endLocation"`
+	StartLocation *Location             `json:"startLocation,omitempty"`
+	EndLocation   *Location             `json:"endLocation,omitempty"`
 }
 
 type SearchMatch struct {
@@ -64,25 +64,29 @@ const (
 )
 
 type BreakLocation struct {
-	ScriptId     *runtime.ScriptId  `json:"scriptId"`
+	ScriptId     *runtime.ScriptId  `json:"scriptId,omitempty"`
 	LineNumber   int                `json:"lineNumber"`
 	ColumnNumber int                `json:"columnNumber"`
-	Type         *BreakLocationType `json:"type"`
+	Type         *BreakLocationType `json:"type,omitempty"`
 }
 
 type CommandContinueToLocation struct {
 	Location         Location                      `json:"location"`
-	TargetCallFrames *runtime.TargetCallFramesType `json:"targetCallFrames"`
+	TargetCallFrames *runtime.TargetCallFramesType `json:"targetCallFrames,omitempty"`
 }
 
 type CommandDisable struct{}
 
 type CommandEnable struct {
-	DebuggerId *runtime.UniqueDebuggerId `json:"debuggerId"`
+	DebuggerId *runtime.UniqueDebuggerId `json:"debuggerId,omitempty"`
+}
+
+type ResponseEnable struct {
+	DebuggerId *runtime.UniqueDebuggerId `json:"debuggerId,omitempty"`
 }
 
 type CommandEvaluateOnCallFrame struct {
-	CallFrameId           *CallFrameId `json:"callFrameId"`
+	CallFrameId           *CallFrameId `json:"callFrameId,omitempty"`
 	Expression            string       `json:"expression"`
 	ObjectGroup           string       `json:"objectGroup"`
 	IncludeCommandLineAPI bool         `json:"includeCommandLineAPI"`
@@ -93,13 +97,13 @@ type CommandEvaluateOnCallFrame struct {
 }
 
 type ResponseEvaluateOnCallFrame struct {
-	Result           *runtime.RemoteObject     `json:"result"`
-	ExceptionDetails *runtime.ExceptionDetails `json:"exceptionDetails"`
+	Result           *runtime.RemoteObject     `json:"result,omitempty"`
+	ExceptionDetails *runtime.ExceptionDetails `json:"exceptionDetails,omitempty"`
 }
 
 type CommandGetPossibleBreakpoints struct {
-	Start              *Location `json:"start"`
-	End                *Location `json:"end"`
+	Start              *Location `json:"start,omitempty"`
+	End                *Location `json:"end,omitempty"`
 	RestrictToFunction bool      `json:"restrictToFunction"`
 }
 
@@ -108,7 +112,7 @@ type ResponseGetPossibleBreakpoints struct {
 }
 
 type CommandGetScriptSource struct {
-	ScriptId *runtime.ScriptId `json:"scriptId"`
+	ScriptId *runtime.ScriptId `json:"scriptId,omitempty"`
 }
 
 type ResponseGetScriptSource struct {
@@ -116,39 +120,39 @@ type ResponseGetScriptSource struct {
 }
 
 type CommandGetStackTrace struct {
-	StackTraceId *runtime.StackTraceId `json:"stackTraceId"`
+	StackTraceId *runtime.StackTraceId `json:"stackTraceId,omitempty"`
 }
 
 type ResponseGetStackTrace struct {
-	StackTrace *runtime.StackTrace `json:"stackTrace"`
+	StackTrace *runtime.StackTrace `json:"stackTrace,omitempty"`
 }
 
 type CommandPause struct {
 }
 
 type CommandPauseOnAsyncCall struct {
-	ParentStackTraceId *runtime.StackTraceId `json:"parentStackTraceId"`
+	ParentStackTraceId *runtime.StackTraceId `json:"parentStackTraceId,omitempty"`
 }
 
 type CommandRemoveBreakpoint struct {
-	breakpointId *BreakpointId `json:"breakpointId"`
+	BreakpointId *BreakpointId `json:"breakpointId,omitempty"`
 }
 
 type CommandRestartFrame struct {
-	callFrameId *CallFrameId `json:"callFrameId"`
+	CallFrameId *CallFrameId `json:"callFrameId,omitempty"`
 }
 
 type ResponseRestartFrame struct {
 	CallFrames        []*CallFrame          `json:"callFrames"`
-	AsyncStackTrace   *runtime.StackTrace   `json:"asyncStackTrace"`
-	AsyncStackTraceId *runtime.StackTraceId `json:"asyncStackTraceId"`
+	AsyncStackTrace   *runtime.StackTrace   `json:"asyncStackTrace,omitempty"`
+	AsyncStackTraceId *runtime.StackTraceId `json:"asyncStackTraceId,omitempty"`
 }
 
 type CommandResume struct {
 }
 
 type CommandSearchInContent struct {
-	ScriptId      *runtime.ScriptId `json:"scriptId"`
+	ScriptId      *runtime.ScriptId `json:"scriptId,omitempty"`
 	Query         string            `json:"query"`
 	CaseSensitive bool              `json:"caseSensitive"`
 	IsRegex       bool              `json:"isRegex"`
@@ -167,18 +171,18 @@ type CommandSetBlackboxPatterns struct {
 }
 
 type CommandSetBlackboxedRanges struct {
-	ScriptId  *runtime.ScriptId `json:"scriptId"`
+	ScriptId  *runtime.ScriptId `json:"scriptId,omitempty"`
 	Positions []*ScriptPosition `json:"positions"`
 }
 
 type CommandSetBreakpoint struct {
-	Location  *Location `json:"location"`
+	Location  *Location `json:"location,omitempty"`
 	Condition string    `json:"condition"`
 }
 
 type ResponseSetBreakpoint struct {
-	BreakpointId   *BreakpointId `json:"breakpointId"`
-	ActualLocation *Location     `json:"actualLocation"`
+	BreakpointId   *BreakpointId `json:"breakpointId,omitempty"`
+	ActualLocation *Location     `json:"actualLocation,omitempty"`
 }
 
 type CommandSetBreakpointByUrl struct {
@@ -191,7 +195,7 @@ type CommandSetBreakpointByUrl struct {
 }
 
 type ResponseSetBreakpointByUrl struct {
-	BreakpointId *BreakpointId `json:"breakpointId"`
+	BreakpointId *BreakpointId `json:"breakpointId,omitempty"`
 	Locations    []*Location   `json:"locations"`
 }
 
@@ -208,15 +212,15 @@ const (
 )
 
 type CommandSetPauseOnExceptions struct {
-	State *StateType `json:"state"`
+	State *StateType `json:"state,omitempty"`
 }
 
 type CommandSetReturnValue struct {
-	NewValue *runtime.CallArgument `json:"newValue"`
+	NewValue *runtime.CallArgument `json:"newValue,omitempty"`
 }
 
 type CommandSetScriptSource struct {
-	ScriptId     *runtime.ScriptId `json:"scriptId"`
+	ScriptId     *runtime.ScriptId `json:"scriptId,omitempty"`
 	ScriptSource string            `json:"scriptSource"`
 	DryRun       bool              `json:"dryRun"`
 }
@@ -224,9 +228,9 @@ type CommandSetScriptSource struct {
 type ResponseSetScriptSource struct {
 	CallFrames        []*CallFrame              `json:"callFrames"`
 	StateChanged      bool                      `json:"stackChanged"`
-	AsyncStackTrace   *runtime.StackTrace       `json:"asyncStackTrace"`
-	AsyncStackTraceId *runtime.StackTraceId     `json:"asyncStackTraceId"`
-	ExceptionDetails  *runtime.ExceptionDetails `json:"exceptionDetails"`
+	AsyncStackTrace   *runtime.StackTrace       `json:"asyncStackTrace,omitempty"`
+	AsyncStackTraceId *runtime.StackTraceId     `json:"asyncStackTraceId,omitempty"`
+	ExceptionDetails  *runtime.ExceptionDetails `json:"exceptionDetails,omitempty"`
 }
 
 type CommandSetSkipAllPauses struct {
@@ -236,8 +240,8 @@ type CommandSetSkipAllPauses struct {
 type CommandSetVariableValue struct {
 	ScopeNumber  int                   `json:"scopeNumber"`
 	VariableName string                `json:"variableName"`
-	NewValue     *runtime.CallArgument `json:"newValue"`
-	CallFrameId  *CallFrameId          `json:"callFrameId"`
+	NewValue     *runtime.CallArgument `json:"newValue,omitempty"`
+	CallFrameId  *CallFrameId          `json:"callFrameId,omitempty"`
 }
 
 type CommandStepInto struct {
@@ -251,8 +255,8 @@ type CommandStepOver struct {
 }
 
 type EventBreakpointResolved struct {
-	BreakpointId *BreakpointId `json:"breakpointId"`
-	Location     *Location     `json:"location"`
+	BreakpointId *BreakpointId `json:"breakpointId,omitempty"`
+	Location     *Location     `json:"location,omitempty"`
 }
 
 type PausedReasonType string
@@ -272,48 +276,48 @@ const (
 
 type EventPaused struct {
 	CallFrames            []*CallFrame          `json:"callFrames"`
-	Reason                *PausedReasonType     `json:"reason"`
-	Data                  interface{}           `json:"data"`
-	HitBreakpoints        []string              `json:"hitBreakpoints"`
-	AsyncStackTrace       *runtime.StackTrace   `json:"asyncStackTrace"`
-	AsyncStackTraceId     *runtime.StackTraceId `json:"asyncStackTraceId"`
-	AsyncCallStackTraceId *runtime.StackTraceId `json:"asyncCallStackTraceId"`
+	Reason                *PausedReasonType     `json:"reason,omitempty"`
+	Data                  interface{}           `json:"data,omitempty"`
+	HitBreakpoints        []string              `json:"hitBreakpoints,omitempty"`
+	AsyncStackTrace       *runtime.StackTrace   `json:"asyncStackTrace,omitempty"`
+	AsyncStackTraceId     *runtime.StackTraceId `json:"asyncStackTraceId,omitempty"`
+	AsyncCallStackTraceId *runtime.StackTraceId `json:"asyncCallStackTraceId,omitempty"`
 }
 
 type EventResumed struct {
 }
 
 type EventScriptFailedToParse struct {
-	ScriptId                *runtime.ScriptId           `json:"scriptId"`
+	ScriptId                *runtime.ScriptId           `json:"scriptId,omitempty"`
 	Url                     string                      `json:"url"`
 	StartLine               int                         `json:"startLine"`
 	StartColumn             int                         `json:"startColumn"`
 	EndLine                 int                         `json:"endLine"`
 	EndColumn               int                         `json:"endColumn"`
-	ExecutionContextId      *runtime.ExecutionContextId `json:"executionContextId"`
+	ExecutionContextId      *runtime.ExecutionContextId `json:"executionContextId,omitempty"`
 	Hash                    string                      `json:"hash"`
-	ExecutionContextAuxData interface{}                 `json:"executionContextAuxData"`
+	ExecutionContextAuxData interface{}                 `json:"executionContextAuxData,omitempty"`
 	SourceMapURL            string                      `json:"sourceMapURL"`
 	HasSourceURL            bool                        `json:"hasSourceURL"`
 	IsModule                bool                        `json:"isModule"`
 	Length                  int                         `json:"length"`
-	StackTrace              *runtime.StackTrace         `json:"stackTrace"`
+	StackTrace              *runtime.StackTrace         `json:"stackTrace,omitempty"`
 }
 
 type EventScriptParsed struct {
-	ScriptId                *runtime.ScriptId           `json:"scriptId"`
+	ScriptId                *runtime.ScriptId           `json:"scriptId,omitempty"`
 	Url                     string                      `json:"url"`
 	StartLine               int                         `json:"startLine"`
 	StartColumn             int                         `json:"startColumn"`
 	EndLine                 int                         `json:"endLine"`
 	EndColumn               int                         `json:"endColumn"`
-	ExecutionContextId      *runtime.ExecutionContextId `json:"executionContextId"`
+	ExecutionContextId      *runtime.ExecutionContextId `json:"executionContextId,omitempty"`
 	Hash                    string                      `json:"hash"`
-	ExecutionContextAuxData interface{}                 `json:"executionContextAuxData"`
+	ExecutionContextAuxData interface{}                 `json:"executionContextAuxData,omitempty"`
 	IsLiveEdit              bool                        `json:"isLiveEdit"`
 	SourceMapURL            string                      `json:"sourceMapURL"`
 	HasSourceURL            bool                        `json:"hasSourceURL"`
 	IsModule                bool                        `json:"isModule"`
 	Length                  int                         `json:"length"`
-	StackTrace              *runtime.StackTrace         `json:"stackTrace"`
+	StackTrace              *runtime.StackTrace         `json:"stackTrace,omitempty"`
 }
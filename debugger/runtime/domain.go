@@ -23,6 +23,7 @@ const (
 	RemoteObjectTypeNumber    RemoteObjectType = "number"
 	RemoteObjectTypeBoolean   RemoteObjectType = "boolean"
 	RemoteObjectTypeSymbol    RemoteObjectType = "symbol"
+	RemoteObjectTypeBigint    RemoteObjectType = "bigint"
 )
 
 type RemoteObjectSubtype string
@@ -46,23 +47,23 @@ const (
 )
 
 type RemoteObject struct {
-	Type                *RemoteObjectType    `json:"type"`
-	Subtype             *RemoteObjectSubtype `json:"subtype"`
+	Type                *RemoteObjectType    `json:"type,omitempty"`
+	Subtype             *RemoteObjectSubtype `json:"subtype,omitempty"`
 	ClassName           string               `json:"className"`
-	Value               interface{}          `json:"value"`
-	UnserializableValue *UnserializableValue `json:"unserializableValue"`
+	Value               interface{}          `json:"value,omitempty"`
+	UnserializableValue *UnserializableValue `json:"unserializableValue,omitempty"`
 	Description         string               `json:"description"`
-	ObjectId            *RemoteObjectId      `json:"objectId"`
-	Preview             *ObjectPreview       `json:"preview"`
-	CustomPreview       *CustomPreview       `json:"customPreview"`
+	ObjectId            *RemoteObjectId      `json:"objectId,omitempty"`
+	Preview             *ObjectPreview       `json:"preview,omitempty"`
+	CustomPreview       *CustomPreview       `json:"customPreview,omitempty"`
 }
 
 type CustomPreview struct {
 	Header                     string          `json:"header"`
 	HasBody                    bool            `json:"hasBody"`
-	FormatterObjectId          *RemoteObjectId `json:"formatterObjectId"`
-	BindRemoteObjectFunctionId *RemoteObjectId `json:"bindRemoteObjectFunctionId"`
-	ConfigObjectId             *RemoteObjectId `json:"configObjectId"`
+	FormatterObjectId          *RemoteObjectId `json:"formatterObjectId,omitempty"`
+	BindRemoteObjectFunctionId *RemoteObjectId `json:"bindRemoteObjectFunctionId,omitempty"`
+	ConfigObjectId             *RemoteObjectId `json:"configObjectId,omitempty"`
 }
 
 type ObjectPreviewType string
@@ -95,12 +96,12 @@ const (
 )
 
 type ObjectPreview struct {
-	Type        *ObjectPreviewType    `json:"type"`
-	Subtype     *ObjectPreviewSubtype `json:"subtype"`
+	Type        *ObjectPreviewType    `json:"type,omitempty"`
+	Subtype     *ObjectPreviewSubtype `json:"subtype,omitempty"`
 	Description string                `json:"description"`
 	Overflow    bool                  `json:"overflow"`
-	Properties  *PropertyPreview      `json:"properties"`
-	Entries     *EntryPreview         `json:"entries"`
+	Properties  *PropertyPreview      `json:"properties,omitempty"`
+	Entries     *EntryPreview         `json:"entries,omitempty"`
 }
 
 type PropertyPreviewType string
@@ -135,48 +136,48 @@ const (
 
 type PropertyPreview struct {
 	Name         string                  `json:"name"`
-	Type         *PropertyPreviewType    `json:"type"`
+	Type         *PropertyPreviewType    `json:"type,omitempty"`
 	Value        string                  `json:"value"`
-	ValuePreview *ObjectPreview          `json:"valuePreview"`
-	Subtype      *PropertyPreviewSubtype `json:"subtype"`
+	ValuePreview *ObjectPreview          `json:"valuePreview,omitempty"`
+	Subtype      *PropertyPreviewSubtype `json:"subtype,omitempty"`
 }
 
 type EntryPreview struct {
-	Key   *ObjectPreview `json:"key"`
-	Value *ObjectPreview `json:"value"`
+	Key   *ObjectPreview `json:"key,omitempty"`
+	Value *ObjectPreview `json:"value,omitempty"`
 }
 
 type PropertyDescriptor struct {
 	Name         string        `json:"name"`
-	Value        *RemoteObject `json:"value"`
+	Value        *RemoteObject `json:"value,omitempty"`
 	Writable     bool          `json:"writable"`
-	Get          *RemoteObject `json:"get"`
-	Set          *RemoteObject `json:"set"`
+	Get          *RemoteObject `json:"get,omitempty"`
+	Set          *RemoteObject `json:"set,omitempty"`
 	Configurable bool          `json:"configurable"`
 	Enumerable   bool          `json:"enumerable"`
 	WasThrown    bool          `json:"wasThrown"`
 	IsOwn        bool          `json:"isOwn"`
-	Symbol       *RemoteObject `json:"symbol"`
+	Symbol       *RemoteObject `json:"symbol,omitempty"`
 }
 
 type InternalPropertyDescriptor struct {
 	Name  string        `json:"name"`
-	Value *RemoteObject `json:"value"`
+	Value *RemoteObject `json:"value,omitempty"`
 }
 
 type CallArgument struct {
-	Value               interface{}          `json:"value"`
-	UnserializableValue *UnserializableValue `json:"unserializableValue"`
-	ObjectId            *RemoteObjectId      `json:"objectId"`
+	Value               interface{}          `json:"value,omitempty"`
+	UnserializableValue *UnserializableValue `json:"unserializableValue,omitempty"`
+	ObjectId            *RemoteObjectId      `json:"objectId,omitempty"`
 }
 
 type ExecutionContextId int
 
 type ExecutionContextDescription struct {
-	Id      *ExecutionContextId `json:"id"`
+	Id      *ExecutionContextId `json:"id,omitempty"`
 	Origin  string              `json:"origin"`
 	Name    string              `json:"name"`
-	AuxData interface{}         `json:"auxData"`
+	AuxData interface{}         `json:"auxData,omitempty"`
 }
 
 type ExceptionDetails struct {
@@ -184,18 +185,18 @@ type ExceptionDetails struct {
 	Text               string              `json:"text"`
 	LineNumber         int                 `json:"lineNumber"`
 	ColumnNumber       int                 `json:"columnNumber"`
-	ScriptId           *ScriptId           `json:"scriptId"`
+	ScriptId           *ScriptId           `json:"scriptId,omitempty"`
 	Url                string              `json:"url"`
-	StackTrace         *StackTrace         `json:"stackTrace"`
-	Exception          *RemoteObject       `json:"exception"`
-	ExecutionContextId *ExecutionContextId `json:"executionContextId"`
+	StackTrace         *StackTrace         `json:"stackTrace,omitempty"`
+	Exception          *RemoteObject       `json:"exception,omitempty"`
+	ExecutionContextId *ExecutionContextId `json:"executionContextId,omitempty"`
 }
 
 type Timestamp int64
 
 type CallFrame struct {
 	FunctionName string    `json:"functionName"`
-	ScriptId     *ScriptId `json:"scriptId"`
+	ScriptId     *ScriptId `json:"scriptId,omitempty"`
 	Url          string    `json:"url"`
 	LineNumber   int       `json:"lineNumber"`
 	ColumnNumber int       `json:"columnNumber"`
@@ -204,15 +205,15 @@ type CallFrame struct {
 type StackTrace struct {
 	Description string        `json:"description"`
 	CallFrames  []*CallFrame  `json:"callFrames"`
-	Parent      *StackTrace   `json:"parent"`
-	ParentId    *StackTraceId `json:"parentId"`
+	Parent      *StackTrace   `json:"parent,omitempty"`
+	ParentId    *StackTraceId `json:"parentId,omitempty"`
 }
 
 type UniqueDebuggerId string
 
 type StackTraceId struct {
 	Id         string            `json:"id"`
-	DebuggerId *UniqueDebuggerId `json:"debuggerId"`
+	DebuggerId *UniqueDebuggerId `json:"debuggerId,omitempty"`
 }
 
 type TargetCallFramesType string
@@ -223,44 +224,44 @@ const (
 )
 
 type CommandAwaitPromise struct {
-	PromiseObjectId *RemoteObjectId `json:"promiseObjectId"`
+	PromiseObjectId *RemoteObjectId `json:"promiseObjectId,omitempty"`
 	ReturnByValue   bool            `json:"returnByValue"`
 	GeneratePreview bool            `json:"generatePreview"`
 }
 
 type ResponseAwaitPromise struct {
-	Result           *RemoteObject     `json:"result"`
-	ExceptionDetails *ExceptionDetails `json:"exceptionDetails"`
+	Result           *RemoteObject     `json:"result,omitempty"`
+	ExceptionDetails *ExceptionDetails `json:"exceptionDetails,omitempty"`
 }
 
 type CommandCallFunctionOn struct {
 	FunctionDeclaration string              `json:"functionDeclaration"`
-	ObjectId            *RemoteObjectId     `json:"objectId"`
+	ObjectId            *RemoteObjectId     `json:"objectId,omitempty"`
 	Arguments           []*CallArgument     `json:"arguments"`
 	Silent              bool                `json:"silent"`
 	ReturnByValue       bool                `json:"returnByValue"`
 	GeneratePreview     bool                `json:"generatePreview"`
 	UserGesture         bool                `json:"userGesture"`
 	AwaitPromise        bool                `json:"awaitPromise"`
-	ExecutionContextId  *ExecutionContextId `json:"executionContextId"`
+	ExecutionContextId  *ExecutionContextId `json:"executionContextId,omitempty"`
 	ObjectGroup         string              `json:"objectGroup"`
 }
 
 type ResponseCallFunctionOn struct {
-	Result           *RemoteObject     `json:"result"`
-	ExceptionDetails *ExceptionDetails `json:"exceptionDetails"`
+	Result           *RemoteObject     `json:"result,omitempty"`
+	ExceptionDetails *ExceptionDetails `json:"exceptionDetails,omitempty"`
 }
 
 type CommandCompileScript struct {
 	Expression         string              `json:"expression"`
 	SourceURL          string              `json:"sourceURL"`
 	PersistScript      bool                `json:"persistScript"`
-	ExecutionContextId *ExecutionContextId `json:"executionContextId"`
+	ExecutionContextId *ExecutionContextId `json:"executionContextId,omitempty"`
 }
 
 type ResponseCompileScript struct {
-	ScriptId         *ScriptId         `json:"scriptId"`
-	ExceptionDetails *ExceptionDetails `json:"exceptionDetails"`
+	ScriptId         *ScriptId         `json:"scriptId,omitempty"`
+	ExceptionDetails *ExceptionDetails `json:"exceptionDetails,omitempty"`
 }
 
 type CommandRuntimeDisable struct {
@@ -277,7 +278,7 @@ type CommandEvaluate struct {
 	ObjectGroup           string              `json:"objectGroup"`
 	IncludeCommandLineAPI bool                `json:"includeCommandLineAPI"`
 	Silent                bool                `json:"silent"`
-	ContextId             *ExecutionContextId `json:"contextId"`
+	ContextId             *ExecutionContextId `json:"contextId,omitempty"`
 	ReturnByValue         bool                `json:"returnByValue"`
 	GeneratePreview       bool                `json:"generatePreview"`
 	UserGesture           bool                `json:"userGesture"`
@@ -285,32 +286,32 @@ type CommandEvaluate struct {
 }
 
 type ResponseEvaluate struct {
-	Result           *RemoteObject     `json:"result"`
-	ExceptionDetails *ExceptionDetails `json:"exceptionDetails"`
+	Result           *RemoteObject     `json:"result,omitempty"`
+	ExceptionDetails *ExceptionDetails `json:"exceptionDetails,omitempty"`
 }
 
 type CommandGetProperties struct {
-	ObjectId               *RemoteObjectId `json:"objectId"`
+	ObjectId               *RemoteObjectId `json:"objectId,omitempty"`
 	OwnProperties          bool            `json:"ownProperties"`
 	AccessorPropertiesOnly bool            `json:"accessorPropertiesOnly"`
 	GeneratePreview        bool            `json:"generatePreview"`
 }
 
 type ResponseGetProperties struct {
-	Result             *PropertyDescriptor         `json:"result"`
-	InternalProperties *InternalPropertyDescriptor `json:"internalProperties"`
-	ExceptionDetails   *ExceptionDetails           `json:"exceptionDetails"`
+	Result             []*PropertyDescriptor         `json:"result"`
+	InternalProperties []*InternalPropertyDescriptor `json:"internalProperties,omitempty"`
+	ExceptionDetails   *ExceptionDetails             `json:"exceptionDetails,omitempty"`
 }
 
 type CommandGlobalLexicalScopeNames struct {
-	ExecutionContextId *ExecutionContextId `json:"executionContextId"`
+	ExecutionContextId *ExecutionContextId `json:"executionContextId,omitempty"`
 }
 type ResponseGlobalLexicalScopeNames struct {
 	Names []string `json:"names"`
 }
 
 type CommandQueryObjects struct {
-	PrototypeObjectId *RemoteObjectId `json:"prototypeObjectId"`
+	PrototypeObjectId *RemoteObjectId `json:"prototypeObjectId,omitempty"`
 }
 
 type ResponseQueryObjects struct {
@@ -318,7 +319,7 @@ type ResponseQueryObjects struct {
 }
 
 type CommandReleaseObject struct {
-	ObjectId *RemoteObjectId `json:"objectId"`
+	ObjectId *RemoteObjectId `json:"objectId,omitempty"`
 }
 
 type CommandReleaseObjectGroup struct {
@@ -329,8 +330,8 @@ type CommandRunIfWaitingForDebugger struct {
 }
 
 type CommandRunScript struct {
-	ScriptId              *ScriptId           `json:"scriptId"`
-	ExecutionContextId    *ExecutionContextId `json:"executionContextId"`
+	ScriptId              *ScriptId           `json:"scriptId,omitempty"`
+	ExecutionContextId    *ExecutionContextId `json:"executionContextId,omitempty"`
 	ObjectGroup           string              `json:"objectGroup"`
 	Silent                bool                `json:"silent"`
 	IncludeCommandLineAPI bool                `json:"includeCommandLineAPI"`
@@ -340,8 +341,8 @@ type CommandRunScript struct {
 }
 
 type ResponseRunScript struct {
-	Result           *RemoteObject     `json:"result"`
-	ExceptionDetails *ExceptionDetails `json:"exceptionDetails"`
+	Result           *RemoteObject     `json:"result,omitempty"`
+	ExceptionDetails *ExceptionDetails `json:"exceptionDetails,omitempty"`
 }
 
 type CommandSetCustomObjectFormatterEnabled struct {
@@ -372,11 +373,11 @@ const (
 )
 
 type EventConsoleAPICalled struct {
-	Type               *EventConsoleAPICalledType `json:"type"`
+	Type               *EventConsoleAPICalledType `json:"type,omitempty"`
 	Args               []*RemoteObject            `json:"args"`
-	ExecutionContextId *ExecutionContextId        `json:"executionContextId"`
-	Timestamp          *Timestamp                 `json:"timestamp"`
-	StackTrace         *StackTrace                `json:"stackTrace"`
+	ExecutionContextId *ExecutionContextId        `json:"executionContextId,omitempty"`
+	Timestamp          *Timestamp                 `json:"timestamp,omitempty"`
+	StackTrace         *StackTrace                `json:"stackTrace,omitempty"`
 	Context            string                     `json:"context"`
 }
 
@@ -386,22 +387,22 @@ type EventExceptionRevoked struct {
 }
 
 type EventExceptionThrown struct {
-	Timestamp        *Timestamp        `json:"timestamp"`
-	ExceptionDetails *ExceptionDetails `json:"exceptionDetails"`
+	Timestamp        *Timestamp        `json:"timestamp,omitempty"`
+	ExceptionDetails *ExceptionDetails `json:"exceptionDetails,omitempty"`
 }
 
 type EventExecutionContextCreated struct {
-	Context *ExecutionContextDescription `json:"context"`
+	Context *ExecutionContextDescription `json:"context,omitempty"`
 }
 
 type EventExecutionContextDestroyed struct {
-	ExecutionContextId *ExecutionContextId `json:"executionContextId"`
+	ExecutionContextId *ExecutionContextId `json:"executionContextId,omitempty"`
 }
 
 type EventExecutionContextsCleared struct {
 }
 
 type EventInspectRequested struct {
-	Object *RemoteObject `json:"object"`
-	Hints  interface{}   `json:"hints"`
+	Object *RemoteObject `json:"object,omitempty"`
+	Hints  interface{}   `json:"hints,omitempty"`
 }
@@ -0,0 +1,371 @@
+package goja
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dop251/goja/debugger"
+)
+
+type testDebugClient struct {
+	t      *testing.T
+	conn   *debugger.Conn
+	seq    int
+	resps  map[int]chan json.RawMessage
+	events chan *testDebugEvent
+}
+
+type testDebugEvent struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type testDebugMessage struct {
+	Id     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newTestDebugClient(t *testing.T, d *Debugger) *testDebugClient {
+	conn, err := debugger.Dial(d.URL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &testDebugClient{
+		t:      t,
+		conn:   conn,
+		resps:  make(map[int]chan json.RawMessage),
+		events: make(chan *testDebugEvent, 100),
+	}
+	for i := 1; i < 100; i++ {
+		c.resps[i] = make(chan json.RawMessage, 1)
+	}
+	go func() {
+		for {
+			data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg testDebugMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Error(err)
+				return
+			}
+			if msg.Method != "" {
+				c.events <- &testDebugEvent{Method: msg.Method, Params: msg.Params}
+				continue
+			}
+			if msg.Error != nil {
+				t.Errorf("Request %d failed: %s", msg.Id, msg.Error.Message)
+			}
+			c.resps[msg.Id] <- msg.Result
+		}
+	}()
+	return c
+}
+
+func (c *testDebugClient) call(method string, params interface{}, result interface{}) {
+	c.seq++
+	data, err := json.Marshal(map[string]interface{}{
+		"id":     c.seq,
+		"method": method,
+		"params": params,
+	})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := c.conn.WriteMessage(data); err != nil {
+		c.t.Fatal(err)
+	}
+	select {
+	case res := <-c.resps[c.seq]:
+		if result != nil {
+			if err := json.Unmarshal(res, result); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("Timeout waiting for the response to %s", method)
+	}
+}
+
+func (c *testDebugClient) waitFor(method string, params interface{}) {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-c.events:
+			if ev.Method == method {
+				if params != nil {
+					if err := json.Unmarshal(ev.Params, params); err != nil {
+						c.t.Fatal(err)
+					}
+				}
+				return
+			}
+		case <-timeout:
+			c.t.Fatalf("Timeout waiting for %s", method)
+		}
+	}
+}
+
+func startTestDebugger(t *testing.T) (*Debugger, *Runtime, *testDebugClient) {
+	d, err := ListenDebugger("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	if err := d.AttachRuntime(r); err != nil {
+		t.Fatal(err)
+	}
+	c := newTestDebugClient(t, d)
+	c.call("Runtime.enable", nil, nil)
+	c.call("Debugger.enable", nil, nil)
+	return d, r, c
+}
+
+func runTestScript(r *Runtime, name, src string) chan Value {
+	ch := make(chan Value, 1)
+	go func() {
+		v, err := r.RunScript(name, src)
+		if err != nil {
+			ch <- newStringValue(err.Error())
+			return
+		}
+		ch <- v
+	}()
+	return ch
+}
+
+func TestDebuggerBreakpoint(t *testing.T) {
+	const SCRIPT = `function f(x) {
+	var y = x * 2;
+	return y + 1;
+}
+f(20);
+`
+	d, r, c := startTestDebugger(t)
+	defer d.Close()
+
+	var bp debugger.ResponseSetBreakpointByUrl
+	c.call("Debugger.setBreakpointByUrl", map[string]interface{}{"url": "test.js", "lineNumber": 1}, &bp)
+	if bp.BreakpointId == nil || len(bp.Locations) != 0 {
+		t.Fatalf("Unexpected response: %+v", bp)
+	}
+
+	done := runTestScript(r, "test.js", SCRIPT)
+
+	var resolved debugger.EventBreakpointResolved
+	c.waitFor("Debugger.breakpointResolved", &resolved)
+	if resolved.Location.LineNumber != 1 || resolved.Location.ColumnNumber != 9 {
+		t.Fatalf("Unexpected location: %+v", resolved.Location)
+	}
+
+	var paused debugger.EventPaused
+	c.waitFor("Debugger.paused", &paused)
+	if len(paused.HitBreakpoints) != 1 || paused.HitBreakpoints[0] != string(*bp.BreakpointId) {
+		t.Fatalf("Unexpected hit breakpoints: %v", paused.HitBreakpoints)
+	}
+	if len(paused.CallFrames) != 2 {
+		t.Fatalf("Unexpected number of call frames: %d", len(paused.CallFrames))
+	}
+	top := paused.CallFrames[0]
+	if top.FunctionName != "f" || top.Location.LineNumber != 1 || top.Url != "test.js" {
+		t.Fatalf("Unexpected call frame: %+v", top)
+	}
+	if paused.CallFrames[1].Location.LineNumber != 4 {
+		t.Fatalf("Unexpected caller location: %+v", paused.CallFrames[1].Location)
+	}
+
+	var props struct {
+		Result []struct {
+			Name  string `json:"name"`
+			Value struct {
+				Value interface{} `json:"value"`
+			} `json:"value"`
+		} `json:"result"`
+	}
+	if *top.ScopeChain[0].Type != debugger.ScopeTypeLocal {
+		t.Fatalf("Unexpected scope: %s", *top.ScopeChain[0].Type)
+	}
+	c.call("Runtime.getProperties", map[string]interface{}{"objectId": top.ScopeChain[0].Object.ObjectId}, &props)
+	vars := make(map[string]interface{})
+	for _, p := range props.Result {
+		vars[p.Name] = p.Value.Value
+	}
+	if vars["x"] != 20.0 {
+		t.Fatalf("Unexpected locals: %v", vars)
+	}
+	if _, exists := vars["y"]; !exists {
+		t.Fatalf("Unexpected locals: %v", vars)
+	}
+
+	var res debugger.ResponseEvaluateOnCallFrame
+	c.call("Debugger.evaluateOnCallFrame", map[string]interface{}{"callFrameId": top.CallFrameId, "expression": "x + 2"}, &res)
+	if res.ExceptionDetails != nil || res.Result.Value != 22.0 {
+		t.Fatalf("Unexpected evaluation result: %+v", res.Result)
+	}
+
+	c.call("Debugger.stepOver", nil, nil)
+	c.waitFor("Debugger.resumed", nil)
+	c.waitFor("Debugger.paused", &paused)
+	if paused.CallFrames[0].Location.LineNumber != 2 {
+		t.Fatalf("Unexpected location after step: %+v", paused.CallFrames[0].Location)
+	}
+	c.call("Debugger.evaluateOnCallFrame", map[string]interface{}{"callFrameId": paused.CallFrames[0].CallFrameId, "expression": "y"}, &res)
+	if res.Result.Value != 40.0 {
+		t.Fatalf("Unexpected evaluation result: %+v", res.Result)
+	}
+
+	c.call("Debugger.resume", nil, nil)
+	select {
+	case v := <-done:
+		if !v.SameAs(valueInt(41)) {
+			t.Fatalf("Unexpected result: %v", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the script to finish")
+	}
+}
+
+func TestDebuggerStatement(t *testing.T) {
+	const SCRIPT = `
+	var o = {a: 1};
+	function f() {
+		var self = this;
+		debugger;
+		return o.a;
+	}
+	f.call(o);
+	`
+	d, r, c := startTestDebugger(t)
+	defer d.Close()
+
+	done := runTestScript(r, "stmt.js", SCRIPT)
+
+	var paused debugger.EventPaused
+	c.waitFor("Debugger.paused", &paused)
+	top := paused.CallFrames[0]
+	if top.Location.LineNumber != 4 || top.This.ClassName != "Object" {
+		t.Fatalf("Unexpected call frame: %+v", top)
+	}
+	var res debugger.ResponseEvaluateOnCallFrame
+	c.call("Debugger.evaluateOnCallFrame", map[string]interface{}{"callFrameId": top.CallFrameId, "expression": "o.a = 42, self === this"}, &res)
+	if res.Result.Value != true {
+		t.Fatalf("Unexpected evaluation result: %+v", res.Result)
+	}
+	c.call("Debugger.evaluateOnCallFrame", map[string]interface{}{"callFrameId": top.CallFrameId, "expression": "undefinedVariable"}, &res)
+	if res.ExceptionDetails == nil || !strings.HasPrefix(res.Result.Description, "ReferenceError") {
+		t.Fatalf("Expected an exception, got: %+v", res.Result)
+	}
+
+	c.call("Debugger.resume", nil, nil)
+	select {
+	case v := <-done:
+		if !v.SameAs(valueInt(42)) {
+			t.Fatalf("Unexpected result: %v", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for the script to finish")
+	}
+}
+
+func TestDebuggerSlowClient(t *testing.T) {
+	d, r, c := startTestDebugger(t)
+	defer func() {
+		d.Close()
+		// unblock the reader of the client, it exits once it has read all that has been sent
+		go func() {
+			for {
+				select {
+				case <-c.events:
+				case <-time.After(time.Second):
+					return
+				}
+			}
+		}()
+	}()
+
+	// The client stops reading once its events are not consumed. The runtime must not block when the events
+	// (a scriptParsed for each script) no longer fit in the socket buffers.
+	done := make(chan struct{})
+	go func() {
+		name := strings.Repeat("x", 1<<16)
+		for i := 0; i < 400; i++ {
+			r.RunScript(name+strconv.Itoa(i)+".js", "1")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The runtime is blocked by the client")
+	}
+}
+
+func TestDebuggerTargetList(t *testing.T) {
+	d, err := ListenDebugger("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	resp, err := http.Get("http://" + d.Addr().String() + "/json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var targets []debugTarget
+	if err := json.Unmarshal(data, &targets); err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0].WebSocketDebuggerUrl != d.URL() {
+		t.Fatalf("Unexpected targets: %s", data)
+	}
+}
+
+func TestDebuggerOrigin(t *testing.T) {
+	d, err := ListenDebugger("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	handshake := func(origin string) int {
+		req, err := http.NewRequest("GET", "http://"+d.Addr().String()+"/"+debuggerTargetId, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-Websocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Sec-Websocket-Version", "13")
+		req.Header.Set("Origin", origin)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := handshake("http://example.com"); status != http.StatusForbidden {
+		t.Fatalf("Unexpected status: %d", status)
+	}
+	d.SetAllowedOrigins("http://example.com")
+	if status := handshake("http://example.com"); status != http.StatusSwitchingProtocols {
+		t.Fatalf("Unexpected status: %d", status)
+	}
+}
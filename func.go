@@ -14,6 +14,24 @@ type funcObject struct {
 	stash *stash
 	prg   *Program
 	src   string
+
+	// arrow functions are not constructors and use the value of this captured when they were created
+	arrow bool
+	this  Value
+
+	// class constructors cannot be called without new, a derived one leaves the creation of the instance to the
+	// constructor of the parent class (see superCall)
+	classCtor, derived bool
+	// methods are not constructors either, super property references in them (and in the arrow functions created
+	// in them) are resolved from the prototype of the home object
+	method     bool
+	homeObject *Object
+
+	// generator functions are not constructors, but they have a prototype which is inherited by the generator
+	// objects they create
+	generator bool
+	// async functions are neither
+	async bool
 }
 
 type nativeFuncObject struct {
@@ -38,7 +56,7 @@ func (f *nativeFuncObject) exportType() reflect.Type {
 func (f *funcObject) getPropStr(name string) Value {
 	switch name {
 	case "prototype":
-		if _, exists := f.values["prototype"]; !exists {
+		if _, exists := f.values["prototype"]; !exists && f.hasPrototype() {
 			return f.addPrototype()
 		}
 	}
@@ -47,12 +65,19 @@ func (f *funcObject) getPropStr(name string) Value {
 }
 
 func (f *funcObject) addPrototype() Value {
-	proto := f.val.runtime.NewObject()
+	r := f.val.runtime
+	if f.generator {
+		return f._putProp("prototype", r.newBaseObject(r.global.GeneratorPrototype, classObject).val, true, false, false)
+	}
+	proto := r.NewObject()
 	proto.self._putProp("constructor", f.val, true, false, true)
 	return f._putProp("prototype", proto, true, false, false)
 }
 
 func (f *funcObject) getProp(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return f.getPropSym(s)
+	}
 	return f.getPropStr(n.String())
 }
 
@@ -63,7 +88,7 @@ func (f *funcObject) hasOwnProperty(n Value) bool {
 
 	name := n.String()
 	if name == "prototype" {
-		return true
+		return f.hasPrototype()
 	}
 	return false
 }
@@ -74,24 +99,43 @@ func (f *funcObject) hasOwnPropertyStr(name string) bool {
 	}
 
 	if name == "prototype" {
-		return true
+		return f.hasPrototype()
 	}
 	return false
 }
 
+func (f *funcObject) isConstructor() bool {
+	return !f.arrow && !f.method && !f.generator && !f.async
+}
+
+func (f *funcObject) hasPrototype() bool {
+	return f.isConstructor() || f.generator
+}
+
 func (f *funcObject) construct(args []Value) *Object {
-	proto := f.getStr("prototype")
-	var protoObj *Object
-	if p, ok := proto.(*Object); ok {
-		protoObj = p
-	} else {
-		protoObj = f.val.runtime.global.ObjectPrototype
+	return f.constructWith(args, f.val)
+}
+
+// constructWith creates an instance taking its prototype from newTarget, which differs from the function itself
+// when it is called as the constructor of a parent class. The instance of a derived class is created by super().
+func (f *funcObject) constructWith(args []Value, newTarget *Object) *Object {
+	if !f.isConstructor() {
+		f.val.runtime.typeErrorResult(true, "Not a constructor")
 	}
-	obj := f.val.runtime.newBaseObject(protoObj, classObject).val
-	ret := f.Call(FunctionCall{
-		This:      obj,
-		Arguments: args,
-	})
+	var obj *Object
+	var this Value = _undefined
+	if !f.derived {
+		proto := newTarget.self.getStr("prototype")
+		var protoObj *Object
+		if p, ok := proto.(*Object); ok {
+			protoObj = p
+		} else {
+			protoObj = f.val.runtime.global.ObjectPrototype
+		}
+		obj = f.val.runtime.newBaseObject(protoObj, classObject).val
+		this = obj
+	}
+	ret := f.call(this, args, newTarget)
 
 	if ret, ok := ret.(*Object); ok {
 		return ret
@@ -99,20 +143,44 @@ func (f *funcObject) construct(args []Value) *Object {
 	return obj
 }
 
+// initClass makes the function the constructor of a class whose prototype and constructor inherit from protoParent
+// and ctorParent respectively, and returns the prototype.
+func (f *funcObject) initClass(protoParent, ctorParent *Object, derived bool) *Object {
+	proto := f.val.runtime.newBaseObject(protoParent, classObject)
+	proto._putProp("constructor", f.val, true, false, true)
+	f._putProp("prototype", proto.val, false, false, false)
+	f.prototype = ctorParent
+	f.classCtor = true
+	f.derived = derived
+	f.homeObject = proto.val
+	return proto.val
+}
+
 func (f *funcObject) Call(call FunctionCall) Value {
+	if f.classCtor {
+		f.val.runtime.typeErrorResult(true, "Class constructor %s cannot be invoked without 'new'", f.prg.funcName)
+	}
+	var this Value = _undefined
+	if call.This != nil {
+		this = call.This
+	}
+	return f.call(this, call.Arguments, nil)
+}
+
+func (f *funcObject) call(this Value, args []Value, newTarget *Object) Value {
 	vm := f.val.runtime.vm
 	pc := vm.pc
 
-	vm.stack.expand(vm.sp + len(call.Arguments) + 1)
+	vm.stack.expand(vm.sp + len(args) + 1)
 	vm.stack[vm.sp] = f.val
 	vm.sp++
-	if call.This != nil {
-		vm.stack[vm.sp] = call.This
+	if f.arrow {
+		vm.stack[vm.sp] = f.this
 	} else {
-		vm.stack[vm.sp] = _undefined
+		vm.stack[vm.sp] = this
 	}
 	vm.sp++
-	for _, arg := range call.Arguments {
+	for _, arg := range args {
 		if arg != nil {
 			vm.stack[vm.sp] = arg
 		} else {
@@ -123,7 +191,8 @@ func (f *funcObject) Call(call FunctionCall) Value {
 
 	vm.pc = -1
 	vm.pushCtx()
-	vm.args = len(call.Arguments)
+	vm.args = len(args)
+	vm.newTarget = newTarget
 	vm.prg = f.prg
 	vm.stash = f.stash
 	vm.pc = 0
@@ -200,12 +269,20 @@ func (f *nativeFuncObject) defaultConstruct(ccall func(ConstructorCall) *Object,
 
 func (f *nativeFuncObject) assertCallable() (func(FunctionCall) Value, bool) {
 	if f.f != nil {
-		return f.f, true
+		return f.call, true
 	}
 	return nil, false
 }
 
+func (f *nativeFuncObject) call(call FunctionCall) Value {
+	call.ctx = f.val.runtime.vm.ctx
+	return f.f(call)
+}
+
 func (f *boundFuncObject) getProp(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return f.getPropSym(s)
+	}
 	return f.getPropStr(n.String())
 }
 
@@ -218,6 +295,9 @@ func (f *boundFuncObject) getPropStr(name string) Value {
 }
 
 func (f *boundFuncObject) delete(n Value, throw bool) bool {
+	if s, ok := n.(*Symbol); ok {
+		return f.deleteSym(s, throw)
+	}
 	return f.deleteStr(n.String(), throw)
 }
 
@@ -236,5 +316,9 @@ func (f *boundFuncObject) putStr(name string, val Value, throw bool) {
 }
 
 func (f *boundFuncObject) put(n Value, val Value, throw bool) {
+	if s, ok := n.(*Symbol); ok {
+		f.putSym(s, val, throw)
+		return
+	}
 	f.putStr(n.String(), val, throw)
 }
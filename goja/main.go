@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	crand "crypto/rand"
 	"encoding/binary"
 	"flag"
@@ -9,8 +10,10 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"runtime/debug"
 	"runtime/pprof"
+	"strings"
 	"time"
 
 	"github.com/dop251/goja"
@@ -19,7 +22,9 @@ import (
 )
 
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
+var jsprofile = flag.String("jsprofile", "", "write JavaScript cpu profile to file (in the DevTools format if the name ends with .cpuprofile, pprof otherwise)")
 var timelimit = flag.Int("timelimit", 0, "max time to run (in seconds)")
+var module = flag.Bool("module", false, "run the file as an ES module, imports are resolved relative to its directory")
 
 func readSource(filename string) ([]byte, error) {
 	if filename == "" || filename == "-" {
@@ -49,6 +54,22 @@ func newRandSource() goja.RandSource {
 	return rand.New(rand.NewSource(seed)).Float64
 }
 
+func writeJSProfile(p *goja.CPUProfile) error {
+	f, err := os.Create(*jsprofile)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(*jsprofile, ".cpuprofile") {
+		_, err = p.WriteTo(f)
+	} else {
+		err = p.WritePprof(f)
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
 func run() error {
 	filename := flag.Arg(0)
 	src, err := readSource(filename)
@@ -78,10 +99,33 @@ func run() error {
 		return string(b), nil
 	})
 
+	if *jsprofile != "" {
+		if err := vm.StartCPUProfile(0); err != nil {
+			return err
+		}
+		defer func() {
+			if err := writeJSProfile(vm.StopCPUProfile()); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
+	ctx := context.Background()
 	if *timelimit > 0 {
-		time.AfterFunc(time.Duration(*timelimit)*time.Second, func() {
-			vm.Interrupt("timeout")
-		})
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(*timelimit)*time.Second)
+		defer cancel()
+	}
+
+	if *module {
+		if filename == "<stdin>" {
+			return fmt.Errorf("A module cannot be read from the standard input")
+		}
+		vm.SetModuleLoader(goja.NewFSModuleLoader(os.DirFS(filepath.Dir(filename))))
+		if _, err = vm.RunModuleContext(ctx, "./"+filepath.Base(filename)); err != nil {
+			return err
+		}
+		return vm.RunJobsContext(ctx)
 	}
 
 	//log.Println("Compiling...")
@@ -90,7 +134,7 @@ func run() error {
 		return err
 	}
 	//log.Println("Running...")
-	_, err = vm.RunProgram(prg)
+	_, err = vm.RunProgramContext(ctx, prg)
 	//log.Println("Finished.")
 	return err
 }
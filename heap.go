@@ -0,0 +1,854 @@
+package goja
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"unsafe"
+)
+
+type heapNodeType int
+
+// The node types of the V8 heap snapshot format.
+const (
+	heapNodeHidden heapNodeType = iota
+	heapNodeArray
+	heapNodeString
+	heapNodeObject
+	heapNodeCode
+	heapNodeClosure
+	heapNodeRegExp
+	heapNodeNumber
+	heapNodeNative
+	heapNodeSynthetic
+	heapNodeConcatenatedString
+	heapNodeSlicedString
+	heapNodeSymbol
+	heapNodeBigInt
+)
+
+var heapNodeTypeNames = []string{"hidden", "array", "string", "object", "code", "closure", "regexp", "number",
+	"native", "synthetic", "concatenated string", "sliced string", "symbol", "bigint"}
+
+type heapEdgeType int
+
+// The edge types of the V8 heap snapshot format.
+const (
+	heapEdgeContext heapEdgeType = iota
+	heapEdgeElement
+	heapEdgeProperty
+	heapEdgeInternal
+	heapEdgeHidden
+	heapEdgeShortcut
+	heapEdgeWeak
+)
+
+var heapEdgeTypeNames = []string{"context", "element", "property", "internal", "hidden", "shortcut", "weak"}
+
+// the maximum length of the strings stored as the names of the string nodes
+const heapMaxStringName = 1024
+
+var typeReflectTypeInfo = reflect.TypeOf(reflectTypeInfo{})
+
+type heapEdge struct {
+	typ   heapEdgeType
+	name  string
+	index int
+	to    int
+}
+
+type heapNode struct {
+	typ      heapNodeType
+	name     string
+	selfSize int64
+	edges    []heapEdge
+
+	// the groups of the node in HeapStats, empty for the synthetic nodes
+	class, constructor string
+}
+
+// HeapSnapshot is the graph of the values held by a Runtime, see Runtime.HeapSnapshot().
+type HeapSnapshot struct {
+	nodes []heapNode
+}
+
+type heapBuilder struct {
+	r     *Runtime
+	nodes []heapNode
+	index map[interface{}]int
+
+	// the objects and the stashes to expand
+	queue []interface{}
+}
+
+// HeapSnapshot walks the values held by the Runtime, starting from the global object, the built-ins, the global
+// lexical bindings, the loaded modules and the VM stack, and returns the resulting graph. The sizes are approximate:
+// they are computed from the Go structures the values are made of.
+//
+// The values held only by Go code (including the Go closures, e.g. the target of a bound function) are not found,
+// neither are the Go values wrapped into objects walked. It must not be called while the code is running, other than
+// from a native function.
+func (r *Runtime) HeapSnapshot() *HeapSnapshot {
+	b := &heapBuilder{
+		r:     r,
+		index: make(map[interface{}]int),
+	}
+	root := b.addSynthetic("")
+	gcRoots := b.addSynthetic("(GC roots)")
+	b.addEdge(root, heapEdgeElement, "", 1, gcRoots)
+	if r.globalObject != nil {
+		b.addValueEdge(root, heapEdgeShortcut, "global", r.globalObject)
+	}
+
+	builtins := b.addSynthetic("(Builtins)")
+	b.addEdge(gcRoots, heapEdgeElement, "", 1, builtins)
+	g := reflect.ValueOf(&r.global).Elem()
+	for i := 0; i < g.NumField(); i++ {
+		if o, ok := heapExported(g.Field(i)).Interface().(*Object); ok {
+			b.addValueEdge(builtins, heapEdgeInternal, g.Type().Field(i).Name, o)
+		}
+	}
+	b.addValueEdge(builtins, heapEdgeInternal, "globalObject", r.globalObject)
+
+	if r.globalLexicals != nil {
+		b.addEdge(gcRoots, heapEdgeInternal, "(Global lexicals)", 0, b.stashNode(r.globalLexicals))
+	}
+
+	if len(r.modules) > 0 {
+		modules := b.addSynthetic("(Modules)")
+		b.addEdge(gcRoots, heapEdgeElement, "", 2, modules)
+		names := make([]string, 0, len(r.modules))
+		for name := range r.modules {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			m := r.modules[name]
+			if m.namespace != nil {
+				b.addValueEdge(modules, heapEdgeInternal, name, m.namespace)
+			}
+			if m.gen != nil {
+				b.addValueEdge(modules, heapEdgeInternal, name+" (code)", m.gen.val)
+			}
+		}
+	}
+
+	vm := r.vm
+	stack := b.addSynthetic("(Stack)")
+	b.addEdge(gcRoots, heapEdgeElement, "", 3, stack)
+	for i := 0; i < vm.sp && i < len(vm.stack); i++ {
+		b.addValueEdge(stack, heapEdgeElement, "", vm.stack[i], i)
+	}
+	if vm.stash != nil {
+		b.addEdge(stack, heapEdgeContext, "stash", 0, b.stashNode(vm.stash))
+	}
+	for i := range vm.callStack {
+		if s := vm.callStack[i].stash; s != nil {
+			b.addEdge(stack, heapEdgeContext, "stash"+strconv.Itoa(i), 0, b.stashNode(s))
+		}
+	}
+
+	for len(b.queue) > 0 {
+		item := b.queue[0]
+		b.queue[0] = nil
+		b.queue = b.queue[1:]
+		switch item := item.(type) {
+		case *Object:
+			b.expandObject(item)
+		case *stash:
+			b.expandStash(item)
+		}
+	}
+
+	return &HeapSnapshot{
+		nodes: b.nodes,
+	}
+}
+
+func (b *heapBuilder) addNode(n heapNode) int {
+	b.nodes = append(b.nodes, n)
+	return len(b.nodes) - 1
+}
+
+func (b *heapBuilder) addSynthetic(name string) int {
+	return b.addNode(heapNode{typ: heapNodeSynthetic, name: name})
+}
+
+func (b *heapBuilder) addEdge(from int, typ heapEdgeType, name string, index, to int) {
+	b.nodes[from].edges = append(b.nodes[from].edges, heapEdge{typ: typ, name: name, index: index, to: to})
+}
+
+// addValueEdge adds an edge to the node of v, if it has one (i.e. if it is not a number, a boolean, null or
+// undefined). The index is used instead of the name for the element edges.
+func (b *heapBuilder) addValueEdge(from int, typ heapEdgeType, name string, v Value, index ...int) {
+	to := b.valueNode(v)
+	if to < 0 {
+		return
+	}
+	idx := 0
+	if len(index) > 0 {
+		idx = index[0]
+	}
+	b.addEdge(from, typ, name, idx, to)
+}
+
+func (b *heapBuilder) valueNode(v Value) int {
+	switch v := v.(type) {
+	case *Object:
+		if v == nil {
+			return -1
+		}
+		return b.objectNode(v)
+	case asciiString:
+		return b.stringNode(string(v), int64(len(v)))
+	case unicodeString:
+		return b.stringNode(v.String(), int64(len(v))*2)
+	case *Symbol:
+		if n, exists := b.index[v]; exists {
+			return n
+		}
+		n := b.addNode(heapNode{typ: heapNodeSymbol, name: "symbol", selfSize: int64(unsafe.Sizeof(*v)),
+			class: "(symbol)", constructor: "(symbol)"})
+		b.index[v] = n
+		if v.descriptor != nil {
+			b.addValueEdge(n, heapEdgeInternal, "name", v.descriptor)
+		}
+		return n
+	case *valueBigInt:
+		if n, exists := b.index[v]; exists {
+			return n
+		}
+		size := int64(unsafe.Sizeof(*v)) + int64(len(v.toBig().Bits()))*int64(unsafe.Sizeof(big.Word(0)))
+		n := b.addNode(heapNode{typ: heapNodeBigInt, name: "bigint", selfSize: size, class: "(bigint)",
+			constructor: "(bigint)"})
+		b.index[v] = n
+		return n
+	}
+	return -1
+}
+
+type heapStringKey string
+
+func (b *heapBuilder) stringNode(s string, size int64) int {
+	key := heapStringKey(s)
+	if n, exists := b.index[key]; exists {
+		return n
+	}
+	name := s
+	if len(name) > heapMaxStringName {
+		name = name[:heapMaxStringName]
+	}
+	n := b.addNode(heapNode{typ: heapNodeString, name: name, selfSize: size + 16, class: "(string)",
+		constructor: "(string)"})
+	b.index[key] = n
+	return n
+}
+
+// objectNode returns the node of o, it is expanded later. The built-ins which have not been used yet are skipped.
+func (b *heapBuilder) objectNode(o *Object) int {
+	if n, exists := b.index[o]; exists {
+		return n
+	}
+	if _, lazy := o.self.(*lazyObject); lazy {
+		return -1
+	}
+	base := heapBaseObject(o.self)
+	class := reflect.TypeOf(o.self).Elem().Name()
+	if base != nil {
+		class = base.class
+	}
+	typ := heapNodeObject
+	name := heapConstructorName(base)
+	constructor := name
+	if constructor == "" {
+		constructor = class
+	}
+	switch class {
+	case classFunction:
+		typ = heapNodeClosure
+		name = heapFunctionName(base)
+		constructor = classFunction
+	case classRegExp:
+		typ = heapNodeRegExp
+	}
+	if name == "" {
+		name = class
+	}
+	n := b.addNode(heapNode{typ: typ, name: name, class: class, constructor: constructor})
+	b.index[o] = n
+	b.queue = append(b.queue, o)
+	return n
+}
+
+func (b *heapBuilder) stashNode(s *stash) int {
+	if n, exists := b.index[s]; exists {
+		return n
+	}
+	n := b.addNode(heapNode{typ: heapNodeHidden, name: "system / Context", class: "(closure context)",
+		constructor: "(closure context)"})
+	b.index[s] = n
+	b.queue = append(b.queue, s)
+	return n
+}
+
+func (b *heapBuilder) expandStash(s *stash) {
+	n := b.index[s]
+	size := int64(unsafe.Sizeof(*s)) + int64(cap(s.values)+cap(s.extraArgs))*int64(unsafe.Sizeof(Value(nil)))
+	named := make([]bool, len(s.values))
+	for name, idx := range s.names {
+		size += int64(len(name)) + 8
+		if int(idx) < len(s.values) {
+			named[idx] = true
+			b.addValueEdge(n, heapEdgeContext, name, s.values[idx])
+		}
+	}
+	for i, v := range s.values {
+		if !named[i] {
+			b.addValueEdge(n, heapEdgeHidden, "", v, i)
+		}
+	}
+	for i, v := range s.extraArgs {
+		b.addValueEdge(n, heapEdgeHidden, "", v, len(s.values)+i)
+	}
+	if s.obj != nil {
+		if base := heapBaseObject(s.obj); base != nil && base.val != nil {
+			b.addValueEdge(n, heapEdgeInternal, "object", base.val)
+		}
+	}
+	if s.outer != nil {
+		b.addEdge(n, heapEdgeInternal, "previous", 0, b.stashNode(s.outer))
+	}
+	b.nodes[n].selfSize = size
+}
+
+func (b *heapBuilder) expandObject(o *Object) {
+	n := b.index[o]
+	w := &heapWalker{
+		b:       b,
+		from:    n,
+		self:    o,
+		visited: make(map[unsafe.Pointer]struct{}),
+	}
+	v := reflect.ValueOf(o.self)
+	w.size = int64(unsafe.Sizeof(*o)) + int64(v.Elem().Type().Size())
+	w.visited[unsafe.Pointer(v.Pointer())] = struct{}{}
+	w.walkStruct(v.Elem())
+	b.nodes[n].selfSize = w.size
+}
+
+// heapWalker finds the edges of an object by walking the Go structures of its implementation. The structures it
+// points to, other than the values, are considered to be part of the object.
+type heapWalker struct {
+	b       *heapBuilder
+	from    int
+	self    *Object
+	size    int64
+	visited map[unsafe.Pointer]struct{}
+}
+
+func (w *heapWalker) walkStruct(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		f := heapExported(v.Field(i))
+		if !f.CanInterface() {
+			continue
+		}
+		name := t.Field(i).Name
+		switch name {
+		case "val", "lengthProp", "nameProp", "lenProp":
+			// the object itself and the properties which are also in values
+			continue
+		case "prototype":
+			if proto, ok := f.Interface().(*Object); ok && proto != nil {
+				w.b.addValueEdge(w.from, heapEdgeInternal, "__proto__", proto)
+			}
+			continue
+		case "values":
+			switch values := f.Interface().(type) {
+			case map[string]Value:
+				w.size += int64(len(values)) * int64(unsafe.Sizeof(Value(nil))+unsafe.Sizeof("")+8)
+				for k, val := range values {
+					w.size += int64(len(k))
+					w.property(heapEdgeProperty, k, val)
+				}
+				continue
+			case []Value:
+				w.size += int64(cap(values)) * int64(unsafe.Sizeof(Value(nil)))
+				for idx, val := range values {
+					w.b.addValueEdge(w.from, heapEdgeElement, "", val, idx)
+				}
+				continue
+			}
+		case "items":
+			if items, ok := f.Interface().([]sparseArrayItem); ok {
+				w.size += int64(cap(items)) * int64(unsafe.Sizeof(sparseArrayItem{}))
+				for _, item := range items {
+					w.b.addValueEdge(w.from, heapEdgeElement, "", item.value, int(item.idx))
+				}
+				continue
+			}
+		case "symValues":
+			if values, ok := f.Interface().(map[*Symbol]Value); ok {
+				w.size += int64(len(values)) * int64(unsafe.Sizeof(Value(nil))+unsafe.Sizeof((*Symbol)(nil))+8)
+				for sym, val := range values {
+					w.property(heapEdgeProperty, sym.String(), val)
+				}
+				continue
+			}
+		case "hidden":
+			if values, ok := f.Interface().(map[string]valueProperty); ok {
+				w.size += int64(len(values)) * int64(unsafe.Sizeof(valueProperty{})+unsafe.Sizeof("")+8)
+				for k, prop := range values {
+					w.property(heapEdgeInternal, k, &prop)
+				}
+				continue
+			}
+		case "propNames":
+			if names, ok := f.Interface().([]string); ok {
+				w.size += int64(cap(names)) * int64(unsafe.Sizeof(""))
+				continue
+			}
+		case "symNames":
+			if names, ok := f.Interface().([]*Symbol); ok {
+				w.size += int64(cap(names)) * int64(unsafe.Sizeof((*Symbol)(nil)))
+				continue
+			}
+		}
+		w.walkValue(f, name)
+	}
+}
+
+// property adds the edges of a property, an accessor property has the edges to its getter and setter.
+func (w *heapWalker) property(typ heapEdgeType, name string, v Value) {
+	if prop, ok := v.(*valueProperty); ok {
+		w.size += int64(unsafe.Sizeof(*prop))
+		if prop.accessor {
+			if prop.getterFunc != nil {
+				w.b.addValueEdge(w.from, typ, "get "+name, prop.getterFunc)
+			}
+			if prop.setterFunc != nil {
+				w.b.addValueEdge(w.from, typ, "set "+name, prop.setterFunc)
+			}
+			return
+		}
+		v = prop.value
+	}
+	w.b.addValueEdge(w.from, typ, name, v)
+}
+
+func (w *heapWalker) walkValue(v reflect.Value, name string) {
+	switch v.Kind() {
+	case reflect.String:
+		w.size += int64(v.Len())
+	case reflect.Interface:
+		if v.IsNil() || !v.CanInterface() {
+			return
+		}
+		if val, ok := v.Interface().(Value); ok {
+			if w.b.valueNode(val) >= 0 {
+				if val != Value(w.self) {
+					w.b.addValueEdge(w.from, heapEdgeInternal, name, val)
+				}
+				return
+			}
+		}
+		w.walkValue(v.Elem(), name)
+	case reflect.Ptr:
+		if v.IsNil() || !v.CanInterface() {
+			return
+		}
+		switch p := v.Interface().(type) {
+		case *Object:
+			if p != w.self {
+				w.b.addValueEdge(w.from, heapEdgeInternal, name, p)
+			}
+			return
+		case *stash:
+			w.b.addEdge(w.from, heapEdgeContext, name, 0, w.b.stashNode(p))
+			return
+		case *Symbol, *valueBigInt:
+			w.b.addValueEdge(w.from, heapEdgeInternal, name, p.(Value))
+			return
+		case *valueProperty:
+			w.property(heapEdgeInternal, name, p)
+			return
+		case *weakMap:
+			p.mu.Lock()
+			for _, val := range p.data {
+				w.b.addValueEdge(w.from, heapEdgeWeak, name, val)
+			}
+			w.size += int64(len(p.data)) * int64(unsafe.Sizeof(Value(nil))+16)
+			p.mu.Unlock()
+			return
+		}
+		t := v.Type().Elem()
+		if t.Kind() != reflect.Struct || t.PkgPath() != gojaPkgPath || snapshotSkipTypes[t] || t == typeReflectTypeInfo {
+			return
+		}
+		ptr := unsafe.Pointer(v.Pointer())
+		if _, exists := w.visited[ptr]; exists {
+			return
+		}
+		w.visited[ptr] = struct{}{}
+		w.size += int64(t.Size())
+		w.walkStruct(v.Elem())
+	case reflect.Struct:
+		if v.Type().PkgPath() == gojaPkgPath {
+			w.walkStruct(v)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice {
+			w.size += int64(v.Cap()) * int64(v.Type().Elem().Size())
+		}
+		if mayContainPointers(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				w.walkValue(v.Index(i), name+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	case reflect.Map:
+		t := v.Type()
+		w.size += int64(v.Len()) * int64(t.Key().Size()+t.Elem().Size()+8)
+		if mayContainPointers(t.Elem()) {
+			iter := v.MapRange()
+			i := 0
+			for iter.Next() {
+				key := iter.Key()
+				keyName := strconv.Itoa(i)
+				if key.Kind() == reflect.String {
+					keyName = key.String()
+				}
+				w.walkValue(iter.Value(), name+"["+keyName+"]")
+				i++
+			}
+		}
+	}
+}
+
+// heapExported returns v so that it can be converted to an interface even if it has been obtained through an
+// unexported field, if possible.
+func heapExported(v reflect.Value) reflect.Value {
+	if !v.CanInterface() && v.CanAddr() {
+		return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+	}
+	return v
+}
+
+// heapBaseObject returns the baseObject embedded into the implementation of an object, if any.
+func heapBaseObject(impl objectImpl) *baseObject {
+	v := reflect.ValueOf(impl)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	if base, ok := impl.(*baseObject); ok {
+		return base
+	}
+	f := v.Elem().FieldByName("baseObject")
+	if !f.IsValid() || f.Type() != reflect.TypeOf(baseObject{}) {
+		return nil
+	}
+	return (*baseObject)(unsafe.Pointer(f.UnsafeAddr()))
+}
+
+// heapDataProperty returns the value of an own data property without calling any JavaScript code.
+func heapDataProperty(base *baseObject, name string) Value {
+	if base == nil {
+		return nil
+	}
+	v := base.values[name]
+	if prop, ok := v.(*valueProperty); ok {
+		if prop.accessor {
+			return nil
+		}
+		return prop.value
+	}
+	return v
+}
+
+func heapFunctionName(base *baseObject) string {
+	if name, ok := heapDataProperty(base, "name").(valueString); ok {
+		return name.String()
+	}
+	return ""
+}
+
+// heapConstructorName returns the name of the constructor of the prototype of the object.
+func heapConstructorName(base *baseObject) string {
+	if base == nil || base.prototype == nil {
+		return ""
+	}
+	if c, ok := heapDataProperty(heapBaseObject(base.prototype.self), "constructor").(*Object); ok {
+		return heapFunctionName(heapBaseObject(c.self))
+	}
+	return ""
+}
+
+// HeapGroupStats is the memory held by the objects of a class or a constructor.
+type HeapGroupStats struct {
+	Name  string
+	Count int
+	// the sum of the sizes of the values themselves
+	Size int64
+	// the sum of the sizes of the values which are only reachable through the values of the group, the values held
+	// by the other values of the same group are only counted once
+	RetainedSize int64
+}
+
+// HeapStats summarises a HeapSnapshot. The groups are sorted by retained size, the largest first. The objects are
+// grouped by their class (e.g. "Object", "Array", "Function") and by the name of their constructor, the other values
+// are grouped by their type, i.e. "(string)", "(symbol)", "(bigint)" and "(closure context)".
+type HeapStats struct {
+	Count int
+	Size  int64
+
+	ByClass       []HeapGroupStats
+	ByConstructor []HeapGroupStats
+}
+
+// Stats computes the object counts and the sizes of the snapshot.
+func (s *HeapSnapshot) Stats() HeapStats {
+	var stats HeapStats
+	idom, order := s.dominators()
+	retained := make([]int64, len(s.nodes))
+	for i := len(order) - 1; i >= 0; i-- {
+		n := order[i]
+		retained[n] += s.nodes[n].selfSize
+		if n != 0 {
+			retained[idom[n]] += retained[n]
+		}
+	}
+
+	byClass := make(map[string]*HeapGroupStats)
+	byConstructor := make(map[string]*HeapGroupStats)
+	add := func(groups map[string]*HeapGroupStats, name string, n int, nested bool) {
+		g := groups[name]
+		if g == nil {
+			g = &HeapGroupStats{Name: name}
+			groups[name] = g
+		}
+		g.Count++
+		g.Size += s.nodes[n].selfSize
+		if !nested {
+			g.RetainedSize += retained[n]
+		}
+	}
+	for _, n := range order {
+		node := &s.nodes[n]
+		if node.class == "" {
+			continue
+		}
+		stats.Count++
+		stats.Size += node.selfSize
+		dom := &s.nodes[idom[n]]
+		add(byClass, node.class, n, dom.class == node.class)
+		add(byConstructor, node.constructor, n, dom.constructor == node.constructor)
+	}
+	stats.ByClass = sortHeapGroups(byClass)
+	stats.ByConstructor = sortHeapGroups(byConstructor)
+	return stats
+}
+
+func sortHeapGroups(groups map[string]*HeapGroupStats) []HeapGroupStats {
+	res := make([]HeapGroupStats, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, *g)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].RetainedSize != res[j].RetainedSize {
+			return res[i].RetainedSize > res[j].RetainedSize
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// dominators returns the immediate dominators of the nodes reachable from the root and these nodes in reverse
+// postorder. It uses the iterative algorithm by Cooper, Harvey and Kennedy, the weak and the shortcut edges are
+// ignored.
+func (s *HeapSnapshot) dominators() (idom []int, order []int) {
+	n := len(s.nodes)
+	postNum := make([]int, n)
+	for i := range postNum {
+		postNum[i] = -1
+	}
+	visited := make([]bool, n)
+	preds := make([][]int, n)
+	type frame struct {
+		node, edge int
+	}
+	stack := []frame{{node: 0}}
+	visited[0] = true
+	post := make([]int, 0, n)
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		edges := s.nodes[top.node].edges
+		if top.edge < len(edges) {
+			e := edges[top.edge]
+			top.edge++
+			if e.typ == heapEdgeWeak || e.typ == heapEdgeShortcut {
+				continue
+			}
+			preds[e.to] = append(preds[e.to], top.node)
+			if !visited[e.to] {
+				visited[e.to] = true
+				stack = append(stack, frame{node: e.to})
+			}
+			continue
+		}
+		postNum[top.node] = len(post)
+		post = append(post, top.node)
+		stack = stack[:len(stack)-1]
+	}
+	order = make([]int, len(post))
+	for i, node := range post {
+		order[len(post)-1-i] = node
+	}
+
+	idom = make([]int, n)
+	for i := range idom {
+		idom[i] = -1
+	}
+	idom[0] = 0
+	intersect := func(a, b int) int {
+		for a != b {
+			for postNum[a] < postNum[b] {
+				a = idom[a]
+			}
+			for postNum[b] < postNum[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, node := range order[1:] {
+			newIdom := -1
+			for _, p := range preds[node] {
+				if idom[p] == -1 {
+					continue
+				}
+				if newIdom == -1 {
+					newIdom = p
+				} else {
+					newIdom = intersect(p, newIdom)
+				}
+			}
+			if idom[node] != newIdom {
+				idom[node] = newIdom
+				changed = true
+			}
+		}
+	}
+	return
+}
+
+// WriteTo writes the snapshot in the format of the .heapsnapshot files of the V8 engine which can be loaded into the
+// memory tools of the Chrome DevTools.
+func (s *HeapSnapshot) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	strs := make(map[string]int)
+	var strList []string
+	str := func(s string) int {
+		if idx, exists := strs[s]; exists {
+			return idx
+		}
+		idx := len(strList)
+		strs[s] = idx
+		strList = append(strList, s)
+		return idx
+	}
+
+	edgeCount := 0
+	for i := range s.nodes {
+		edgeCount += len(s.nodes[i].edges)
+	}
+
+	meta := map[string]interface{}{
+		"node_fields": []string{"type", "name", "id", "self_size", "edge_count", "trace_node_id"},
+		"node_types":  []interface{}{heapNodeTypeNames, "string", "number", "number", "number", "number"},
+		"edge_fields": []string{"type", "name_or_index", "to_node"},
+		"edge_types":  []interface{}{heapEdgeTypeNames, "string_or_number", "node"},
+		"trace_function_info_fields": []string{"function_id", "name", "script_name", "script_id", "line",
+			"column"},
+		"trace_node_fields": []string{"id", "function_info_index", "count", "size", "children"},
+		"sample_fields":     []string{"timestamp_us", "last_assigned_id"},
+		"location_fields":   []string{"object_index", "script_id", "line", "column"},
+	}
+	header, err := json.Marshal(map[string]interface{}{
+		"meta":                 meta,
+		"node_count":           len(s.nodes),
+		"edge_count":           edgeCount,
+		"trace_function_count": 0,
+	})
+	if err != nil {
+		return 0, err
+	}
+	bw.WriteString(`{"snapshot":`)
+	bw.Write(header)
+
+	const nodeFieldCount = 6
+	bw.WriteString(",\n\"nodes\":[")
+	for i := range s.nodes {
+		node := &s.nodes[i]
+		if i > 0 {
+			bw.WriteString(",\n")
+		}
+		writeInts(bw, int64(node.typ), int64(str(node.name)), int64(i*2+1), node.selfSize, int64(len(node.edges)), 0)
+	}
+	bw.WriteString("],\n\"edges\":[")
+	first := true
+	for i := range s.nodes {
+		for _, e := range s.nodes[i].edges {
+			if !first {
+				bw.WriteString(",\n")
+			}
+			first = false
+			nameOrIndex := int64(e.index)
+			if e.typ != heapEdgeElement && e.typ != heapEdgeHidden {
+				nameOrIndex = int64(str(e.name))
+			}
+			writeInts(bw, int64(e.typ), nameOrIndex, int64(e.to*nodeFieldCount))
+		}
+	}
+	bw.WriteString("],\n\"trace_function_infos\":[],\n\"trace_tree\":[],\n\"samples\":[],\n\"locations\":[],\n\"strings\":[")
+	for i, s := range strList {
+		if i > 0 {
+			bw.WriteString(",\n")
+		}
+		b, err := json.Marshal(s)
+		if err != nil {
+			return cw.n, err
+		}
+		bw.Write(b)
+	}
+	bw.WriteString("]}\n")
+	err = bw.Flush()
+	return cw.n, err
+}
+
+func writeInts(w *bufio.Writer, ints ...int64) {
+	var buf [20]byte
+	for i, n := range ints {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.Write(strconv.AppendInt(buf[:0], n, 10))
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,140 @@
+package goja
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func findHeapGroup(groups []HeapGroupStats, name string) *HeapGroupStats {
+	for i := range groups {
+		if groups[i].Name == name {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+func TestHeapStats(t *testing.T) {
+	const SCRIPT = `
+	function Item(n) {
+		this.n = n;
+		this.payload = "item".repeat(100) + n;
+	}
+	var items = [];
+	for (var i = 0; i < 100; i++) {
+		items.push(new Item(i));
+	}
+	const counter = (function() {
+		var count = 0;
+		return function() { return ++count; };
+	})();
+	`
+	r := New()
+	if _, err := r.RunString(SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	stats := r.HeapSnapshot().Stats()
+
+	item := findHeapGroup(stats.ByConstructor, "Item")
+	if item == nil {
+		t.Fatal("No Item group")
+	}
+	if item.Count != 100 {
+		t.Fatalf("Item count: %d", item.Count)
+	}
+	if item.RetainedSize < 100*400 {
+		t.Fatalf("Item retained size is too small: %d", item.RetainedSize)
+	}
+	if item.RetainedSize <= item.Size {
+		t.Fatalf("Item retained size (%d) is not larger than the size (%d)", item.RetainedSize, item.Size)
+	}
+	array := findHeapGroup(stats.ByClass, classArray)
+	if array == nil || array.RetainedSize < item.RetainedSize {
+		t.Fatalf("Array group: %+v", array)
+	}
+	if g := findHeapGroup(stats.ByClass, "(closure context)"); g == nil || g.Count == 0 {
+		t.Fatal("No closure contexts")
+	}
+	if stats.Count == 0 || stats.Size == 0 {
+		t.Fatalf("Stats: %d, %d", stats.Count, stats.Size)
+	}
+}
+
+func TestHeapSnapshotV8(t *testing.T) {
+	r := New()
+	_, err := r.RunString(`
+	var holder = {list: [{name: "leaked"}], sym: Symbol("s"), big: 12345678901234567890n};
+	holder.self = holder;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	n, err := r.HeapSnapshot().WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Fatalf("Written: %d, buffer: %d", n, buf.Len())
+	}
+
+	var snapshot struct {
+		Snapshot struct {
+			Meta struct {
+				NodeFields []string `json:"node_fields"`
+				EdgeFields []string `json:"edge_fields"`
+			} `json:"meta"`
+			NodeCount int `json:"node_count"`
+			EdgeCount int `json:"edge_count"`
+		} `json:"snapshot"`
+		Nodes   []int    `json:"nodes"`
+		Edges   []int    `json:"edges"`
+		Strings []string `json:"strings"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	nodeFields, edgeFields := len(snapshot.Snapshot.Meta.NodeFields), len(snapshot.Snapshot.Meta.EdgeFields)
+	if len(snapshot.Nodes) != snapshot.Snapshot.NodeCount*nodeFields {
+		t.Fatalf("Nodes: %d, node count: %d", len(snapshot.Nodes), snapshot.Snapshot.NodeCount)
+	}
+	if len(snapshot.Edges) != snapshot.Snapshot.EdgeCount*edgeFields {
+		t.Fatalf("Edges: %d, edge count: %d", len(snapshot.Edges), snapshot.Snapshot.EdgeCount)
+	}
+
+	// find the path global -> holder -> list -> [0] -> name
+	nodeName := func(node int) string {
+		return snapshot.Strings[snapshot.Nodes[node+1]]
+	}
+	edgesStart := func(node int) int {
+		start := 0
+		for i := 0; i < node; i += nodeFields {
+			start += snapshot.Nodes[i+4] * edgeFields
+		}
+		return start
+	}
+	child := func(node int, name string, element bool) int {
+		start := edgesStart(node)
+		for i := 0; i < snapshot.Nodes[node+4]; i++ {
+			e := snapshot.Edges[start+i*edgeFields:]
+			if element && e[0] == int(heapEdgeElement) && e[1] == 0 ||
+				!element && e[0] != int(heapEdgeElement) && snapshot.Strings[e[1]] == name {
+				return e[2]
+			}
+		}
+		t.Fatalf("No edge %q from %q", name, nodeName(node))
+		return -1
+	}
+	global := child(0, "global", false)
+	holder := child(global, "holder", false)
+	if self := child(holder, "self", false); self != holder {
+		t.Fatal("holder.self")
+	}
+	list := child(holder, "list", false)
+	if name := nodeName(child(child(list, "", true), "name", false)); name != "leaked" {
+		t.Fatalf("Unexpected name: %q", name)
+	}
+	child(holder, "sym", false)
+	child(holder, "big", false)
+}
@@ -0,0 +1,129 @@
+package goja
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Approximate sizes (in bytes) used to account for the allocations, see Limits.MaxAllocation.
+const (
+	objectAllocSize     = 128
+	propertyAllocSize   = 48
+	valueAllocSize      = 16
+	sparseItemAllocSize = 24
+	mapEntryAllocSize   = 64
+)
+
+// Limit identifies one of the limits of a Limits.
+type Limit int
+
+const (
+	LimitInstructions Limit = iota + 1
+	LimitCallStackDepth
+	LimitAllocation
+)
+
+func (l Limit) String() string {
+	switch l {
+	case LimitInstructions:
+		return "instruction limit exceeded"
+	case LimitCallStackDepth:
+		return "call stack depth limit exceeded"
+	case LimitAllocation:
+		return "allocation limit exceeded"
+	}
+	return fmt.Sprintf("Limit(%d)", int(l))
+}
+
+// Limits is the execution budget of a Runtime, see Runtime.SetLimits(). A zero field means there is no limit.
+type Limits struct {
+	// MaxInstructions is the maximum number of VM instructions executed.
+	MaxInstructions uint64
+	// MaxCallStackDepth is the maximum depth of nested calls of JavaScript and native functions.
+	MaxCallStackDepth int
+	// MaxAllocation is the maximum number of bytes allocated for objects, properties, array elements, strings,
+	// Map and Set entries and ArrayBuffer storage.
+	// The sizes are approximate and the allocations are counted cumulatively, i.e. the memory reclaimed by the
+	// garbage collector is not taken into account.
+	MaxAllocation int64
+}
+
+// LimitExceededError is returned when the code goes over one of the limits set with Runtime.SetLimits(). Like an
+// *InterruptedError it cannot be caught by JavaScript code.
+type LimitExceededError struct {
+	Exception
+	limit Limit
+}
+
+// Limit returns the limit that has been exceeded.
+func (e *LimitExceededError) Limit() Limit {
+	return e.limit
+}
+
+func (e *LimitExceededError) String() string {
+	if e == nil {
+		return "<nil>"
+	}
+	var b bytes.Buffer
+	b.WriteString(e.limit.String())
+	b.WriteByte('\n')
+	e.writeFullStack(&b)
+	return b.String()
+}
+
+func (e *LimitExceededError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	var b bytes.Buffer
+	b.WriteString(e.limit.String())
+	e.writeShortStack(&b)
+	return b.String()
+}
+
+func (e *LimitExceededError) exception() *Exception {
+	return &e.Exception
+}
+
+// SetLimits sets the execution budget of the Runtime and resets the instruction and allocation counters. The counters
+// are not reset between runs: once a limit has been exceeded any further execution fails with a *LimitExceededError
+// until SetLimits() is called again. It must not be called while the code is running.
+func (r *Runtime) SetLimits(limits Limits) {
+	r.vm.limits = limits
+	r.vm.instructions = 0
+	r.vm.allocated = 0
+}
+
+func (vm *vm) limitExceeded(l Limit) {
+	panic(&LimitExceededError{
+		limit: l,
+	})
+}
+
+func (vm *vm) checkCallStackDepth() {
+	if max := vm.limits.MaxCallStackDepth; max > 0 && len(vm.callStack) >= max {
+		vm.limitExceeded(LimitCallStackDepth)
+	}
+}
+
+// allocate accounts for an allocation of size bytes. The objects of a Runtime which has not been initialised have
+// no vm, hence vm can be nil.
+func (vm *vm) allocate(size int64) {
+	if vm == nil {
+		return
+	}
+	if max := vm.limits.MaxAllocation; max > 0 {
+		vm.allocated += size
+		if vm.allocated > max {
+			vm.limitExceeded(LimitAllocation)
+		}
+	}
+}
+
+// allocateString accounts for a string of the given length with the same character width as s.
+func (vm *vm) allocateString(s valueString, length int64) {
+	if _, ok := s.(unicodeString); ok {
+		length *= 2
+	}
+	vm.allocate(length)
+}
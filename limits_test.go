@@ -0,0 +1,153 @@
+package goja
+
+import (
+	"testing"
+)
+
+func runWithLimits(t *testing.T, limits Limits, script string, expected Limit) *Runtime {
+	t.Helper()
+	r := New()
+	r.SetLimits(limits)
+	_, err := r.RunString(script)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	lErr, ok := err.(*LimitExceededError)
+	if !ok {
+		t.Fatalf("Unexpected error type: %T (%v)", err, err)
+	}
+	if lErr.Limit() != expected {
+		t.Fatalf("Unexpected limit: %v", lErr.Limit())
+	}
+	if !IsUndefined(r.Get("caught")) {
+		t.Fatal("The error was caught by JavaScript code")
+	}
+	return r
+}
+
+func TestLimitInstructions(t *testing.T) {
+	const SCRIPT = `
+	var caught;
+	try {
+		for (;;) {}
+	} catch (e) {
+		caught = e;
+	} finally {
+		caught = "finally";
+	}
+	`
+	r := runWithLimits(t, Limits{MaxInstructions: 10000}, SCRIPT, LimitInstructions)
+
+	// the budget is exhausted until the limits are set again
+	if _, err := r.RunString("1"); err == nil {
+		t.Fatal("expected an error")
+	}
+	r.SetLimits(Limits{MaxInstructions: 10000})
+	v, err := r.RunString("var x = 0; for (var i = 0; i < 100; i++) { x += i; } x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 4950 {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+}
+
+func TestLimitCallStackDepth(t *testing.T) {
+	const SCRIPT = `
+	var caught;
+	function f(n) {
+		try {
+			return f(n + 1);
+		} catch (e) {
+			caught = e;
+		}
+	}
+	f(0);
+	`
+	runWithLimits(t, Limits{MaxCallStackDepth: 100}, SCRIPT, LimitCallStackDepth)
+
+	r := New()
+	r.SetLimits(Limits{MaxCallStackDepth: 100})
+	v, err := r.RunString("function fact(n) { return n <= 1 ? 1 : n * fact(n - 1); } fact(10)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 3628800 {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+}
+
+func TestLimitCallStackDepthNative(t *testing.T) {
+	const SCRIPT = `
+	var caught;
+	function f() {
+		try {
+			[1].forEach(f);
+		} catch (e) {
+			caught = e;
+		}
+	}
+	f();
+	`
+	runWithLimits(t, Limits{MaxCallStackDepth: 100}, SCRIPT, LimitCallStackDepth)
+}
+
+func TestLimitAllocation(t *testing.T) {
+	const limit = 1 << 20
+	scripts := []string{
+		`var caught; var s = "x"; try { for (;;) s += s; } catch (e) { caught = e; }`,
+		`var caught; var a = []; try { for (var i = 0;; i++) a.push(i); } catch (e) { caught = e; }`,
+		`var caught; var a = []; try { for (;;) a.push({}); } catch (e) { caught = e; }`,
+		`var caught; var o = {}; try { for (var i = 0;; i++) o["p" + i] = i; } catch (e) { caught = e; }`,
+		`var caught; try { "x".repeat(1 << 21); } catch (e) { caught = e; }`,
+		`var caught; try { "x".padStart(1 << 21); } catch (e) { caught = e; }`,
+		`var caught; try { Array.prototype.join.call({length: 1 << 21}); } catch (e) { caught = e; }`,
+		`var caught; var s = "x".repeat(1 << 16); try { new Array(32).fill(s).join(""); } catch (e) { caught = e; }`,
+		`var caught; var s = "x".repeat(1 << 19); try { s.concat(s, s); } catch (e) { caught = e; }`,
+		`var caught; var s = "x".repeat(1 << 16); try { JSON.stringify(new Array(32).fill(s)); } catch (e) { caught = e; }`,
+		`var caught; try { new ArrayBuffer(1 << 21); } catch (e) { caught = e; }`,
+		`var caught; try { new Float64Array(1 << 18); } catch (e) { caught = e; }`,
+		`var caught; var b = new ArrayBuffer(1 << 19); try { for (;;) b.slice(0); } catch (e) { caught = e; }`,
+		`var caught; var m = new Map(); try { for (var i = 0;; i++) m.set(i, i); } catch (e) { caught = e; }`,
+		`var caught; var set = new Set(); try { for (var i = 0;; i++) set.add(i); } catch (e) { caught = e; }`,
+		`var caught; var a = []; a.length = 3e7; try { a.slice(); } catch (e) { caught = e; }`,
+		`var caught; var a = []; a.length = 3e7; try { [].concat(a); } catch (e) { caught = e; }`,
+		`var caught; var a = []; a.length = 3e7; try { a.map(function(x) { return x; }); } catch (e) { caught = e; }`,
+		`var caught; try { new Array(3e7).join(""); } catch (e) { caught = e; }`,
+		`var caught; try { Array.apply(null, {length: 3e7}); } catch (e) { caught = e; }`,
+		`var caught; var a = []; a.length = 4294967295; try { Array.from(a); } catch (e) { caught = e; }`,
+		`var caught; try { Array.from({length: 4294967295}); } catch (e) { caught = e; }`,
+		`var caught; var a = new Array(1 << 15).fill(0); try { for (;;) Array.from(a); } catch (e) { caught = e; }`,
+		`var caught; var s = "x".repeat(1 << 16); try { s.replace(/x/g, "yyyyyyyyyyyyyyyyyyyy"); } catch (e) { caught = e; }`,
+	}
+	for _, script := range scripts {
+		runWithLimits(t, Limits{MaxAllocation: limit}, script, LimitAllocation)
+	}
+
+	r := New()
+	r.SetLimits(Limits{MaxAllocation: limit})
+	if _, err := r.RunString(`
+	var a = [], m = new Map();
+	for (var i = 0; i < 100; i++) a.push({x: "x" + i});
+	for (var i = 0; i < 100000; i++) m.set("key", i);
+	`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLimitExceededCallable(t *testing.T) {
+	r := New()
+	_, err := r.RunString("function f() { for (;;) {} }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, ok := AssertFunction(r.Get("f"))
+	if !ok {
+		t.Fatal("f is not a function")
+	}
+	r.SetLimits(Limits{MaxInstructions: 1000})
+	_, err = f(nil)
+	if err, ok := err.(*LimitExceededError); !ok || err.Limit() != LimitInstructions {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
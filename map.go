@@ -0,0 +1,183 @@
+package goja
+
+import (
+	"math"
+	"unicode/utf8"
+)
+
+// unicodeKey is the map key of a string that contains non-ASCII characters. It holds the UTF-16 code units of the
+// string, so that strings with unpaired surrogates remain distinct.
+type unicodeKey string
+
+type nanKey struct{}
+
+type bigIntKey string
+
+// mapKey returns a comparable Go value such that mapKey(x) == mapKey(y) if and only if x and y are SameValueZero.
+func mapKey(v Value) interface{} {
+	switch v := v.(type) {
+	case valueInt:
+		return float64(v)
+	case valueFloat:
+		f := float64(v)
+		if math.IsNaN(f) {
+			return nanKey{}
+		}
+		if f == 0 {
+			// -0 and +0 are the same key
+			return float64(0)
+		}
+		return f
+	case asciiString:
+		return string(v)
+	case unicodeString:
+		b := make([]byte, 0, len(v)*2)
+		ascii := true
+		for _, c := range v {
+			if c >= utf8.RuneSelf {
+				ascii = false
+			}
+			b = append(b, byte(c>>8), byte(c))
+		}
+		if ascii {
+			return v.String()
+		}
+		return unicodeKey(b)
+	case *valueBigInt:
+		return bigIntKey(v.String())
+	}
+	return v
+}
+
+// sameValueZero is the comparison used by Map, Set and Array.prototype.includes(): like ===, except that NaN equals
+// NaN.
+func sameValueZero(x, y Value) bool {
+	return mapKey(x) == mapKey(y)
+}
+
+type mapEntry struct {
+	key, value Value
+
+	prev, next *mapEntry
+	deleted    bool
+}
+
+// orderedMap keeps the entries in the insertion order. Entries can be added and deleted while the map is being
+// iterated, the iterators see the changes in the same way as specified for Map and Set.
+type orderedMap struct {
+	hash        map[interface{}]*mapEntry
+	first, last *mapEntry
+	size        int
+}
+
+type orderedMapIter struct {
+	m   *orderedMap
+	cur *mapEntry
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{
+		hash: make(map[interface{}]*mapEntry),
+	}
+}
+
+func (m *orderedMap) lookup(key Value) *mapEntry {
+	return m.hash[mapKey(key)]
+}
+
+func (m *orderedMap) get(key Value) Value {
+	if e := m.lookup(key); e != nil {
+		return e.value
+	}
+	return nil
+}
+
+func (m *orderedMap) has(key Value) bool {
+	return m.lookup(key) != nil
+}
+
+func (m *orderedMap) set(key, value Value) {
+	k := mapKey(key)
+	if e := m.hash[k]; e != nil {
+		e.value = value
+		return
+	}
+	if f, ok := key.(valueFloat); ok && f == 0 {
+		// normalise -0 to +0
+		key = intToValue(0)
+	}
+	e := &mapEntry{
+		key:   key,
+		value: value,
+		prev:  m.last,
+	}
+	if m.last != nil {
+		m.last.next = e
+	} else {
+		m.first = e
+	}
+	m.last = e
+	m.hash[k] = e
+	m.size++
+}
+
+func (m *orderedMap) remove(key Value) bool {
+	k := mapKey(key)
+	e := m.hash[k]
+	if e == nil {
+		return false
+	}
+	delete(m.hash, k)
+	// The prev pointer of a deleted entry is kept, so that an iterator positioned at the entry can find its way back.
+	e.deleted = true
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		m.first = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		m.last = e.prev
+	}
+	m.size--
+	return true
+}
+
+func (m *orderedMap) clear() {
+	for e := m.first; e != nil; e = e.next {
+		e.deleted = true
+	}
+	m.hash = make(map[interface{}]*mapEntry)
+	m.first = nil
+	m.last = nil
+	m.size = 0
+}
+
+func (m *orderedMap) newIter() *orderedMapIter {
+	return &orderedMapIter{
+		m: m,
+	}
+}
+
+// next returns the next entry or nil when the iteration is over. Once the iteration is over it does not resume
+// even if new entries are added.
+func (it *orderedMapIter) next() *mapEntry {
+	if it.m == nil {
+		return nil
+	}
+	cur := it.cur
+	for cur != nil && cur.deleted {
+		cur = cur.prev
+	}
+	if cur != nil {
+		cur = cur.next
+	} else {
+		cur = it.m.first
+	}
+	if cur == nil {
+		it.m = nil
+	}
+	it.cur = cur
+	return cur
+}
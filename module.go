@@ -0,0 +1,667 @@
+package goja
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja/parser"
+)
+
+// ModuleLoader provides the source code of the modules run by Runtime.RunModule() and imported by import
+// declarations and import().
+type ModuleLoader interface {
+	// Resolve returns the name of the module the specifier refers to when it is imported by the module (or the
+	// script) named referrer. The referrer of the module run by RunModule() is "". A module is only loaded and
+	// evaluated once for each name.
+	Resolve(referrer, specifier string) (string, error)
+	// Load returns the source code of the named module.
+	Load(name string) (string, error)
+}
+
+// MapModuleLoader is a ModuleLoader which serves the modules from a map of names to source code. The names are
+// slash-separated paths: a specifier starting with "./" or "../" is resolved relative to the name of the importing
+// module, any other specifier is the name of the module.
+type MapModuleLoader map[string]string
+
+func (l MapModuleLoader) Resolve(referrer, specifier string) (string, error) {
+	return resolveModulePath(referrer, specifier)
+}
+
+func (l MapModuleLoader) Load(name string) (string, error) {
+	if src, exists := l[name]; exists {
+		return src, nil
+	}
+	return "", fmt.Errorf("Cannot find module '%s'", name)
+}
+
+// FSModuleLoader is a ModuleLoader which loads the modules from a file system, such as an embed.FS or the one
+// returned by os.DirFS(). The names of the modules are paths in the file system, resolved like those of a
+// MapModuleLoader.
+type FSModuleLoader struct {
+	fsys fs.FS
+}
+
+func NewFSModuleLoader(fsys fs.FS) *FSModuleLoader {
+	return &FSModuleLoader{fsys: fsys}
+}
+
+func (l *FSModuleLoader) Resolve(referrer, specifier string) (string, error) {
+	return resolveModulePath(referrer, specifier)
+}
+
+func (l *FSModuleLoader) Load(name string) (string, error) {
+	b, err := fs.ReadFile(l.fsys, name)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func resolveModulePath(referrer, specifier string) (string, error) {
+	var name string
+	if strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../") {
+		name = path.Join(path.Dir(referrer), specifier)
+	} else {
+		name = path.Clean(strings.TrimPrefix(specifier, "/"))
+	}
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("Cannot find module '%s'", specifier)
+	}
+	return name, nil
+}
+
+var errNoModuleLoader = errors.New("No module loader is set, see Runtime.SetModuleLoader()")
+
+type importEntry struct {
+	specifier string
+	// the name of the export, "*" for the namespace of the module
+	importName string
+	localName  string
+}
+
+// moduleInfo is the static part of a module record, created by compileModule.
+type moduleInfo struct {
+	// the specifiers of the modules requested by the import and export declarations, in their order
+	requests []string
+	imports  []importEntry
+	// the exported local bindings by their export names
+	localExports map[string]string
+	// the re-exported exports of other modules by their export names
+	indirectExports map[string]importEntry
+	// the specifiers of the modules re-exported by export * declarations
+	starExports []string
+	// the local names of the exported bindings in the order of the getters yielded by the module code
+	bindings []string
+}
+
+type moduleStatus int
+
+const (
+	moduleUnlinked moduleStatus = iota
+	moduleLinking
+	moduleLinked
+	moduleEvaluating
+	moduleEvaluated
+)
+
+type module struct {
+	*moduleInfo
+	name   string
+	prg    *Program
+	deps   map[string]*module
+	status moduleStatus
+
+	// the suspended module code between the linking and the evaluation
+	gen *Generator
+	// the getters of the exported local bindings, see compileModule
+	getters map[string]func(FunctionCall) Value
+
+	namespace *Object
+	// the exception thrown by the evaluation
+	err *Exception
+}
+
+// moduleBinding is a binding resolved by resolveExport, the name "*" stands for the namespace object of the module.
+type moduleBinding struct {
+	m    *module
+	name string
+}
+
+// resolveExport returns the binding exported by the module as name, or nil if there is no such export. It also
+// returns nil if the name is exported by more than one module re-exported with export *, ambiguous is then set.
+// The resolve set holds the exports being resolved, to detect circular re-exports.
+func (m *module) resolveExport(name string, resolveSet []moduleBinding) (b *moduleBinding, ambiguous bool) {
+	for _, item := range resolveSet {
+		if item.m == m && item.name == name {
+			return nil, false
+		}
+	}
+	resolveSet = append(resolveSet, moduleBinding{m: m, name: name})
+	if local, exists := m.localExports[name]; exists {
+		return &moduleBinding{m: m, name: local}, false
+	}
+	if entry, exists := m.indirectExports[name]; exists {
+		dep := m.deps[entry.specifier]
+		if entry.importName == "*" {
+			return &moduleBinding{m: dep, name: "*"}, false
+		}
+		return dep.resolveExport(entry.importName, resolveSet)
+	}
+	if name == "default" {
+		// the default export is not re-exported by export *
+		return nil, false
+	}
+	for _, specifier := range m.starExports {
+		res, ambiguous := m.deps[specifier].resolveExport(name, resolveSet)
+		if ambiguous {
+			return nil, true
+		}
+		if res != nil {
+			if b == nil {
+				b = res
+			} else if *b != *res {
+				return nil, true
+			}
+		}
+	}
+	return b, false
+}
+
+// exportedNames appends the names exported by the module to names, the visited set prevents an endless loop over
+// circular export * declarations.
+func (m *module) exportedNames(names []string, visited map[*module]bool) []string {
+	if visited[m] {
+		return names
+	}
+	visited[m] = true
+	for name := range m.localExports {
+		names = append(names, name)
+	}
+	for name := range m.indirectExports {
+		names = append(names, name)
+	}
+	for _, specifier := range m.starExports {
+		for _, name := range m.deps[specifier].exportedNames(nil, visited) {
+			if name != "default" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func compileModule(name, src string, parserOptions ...parser.Option) (p *Program, info *moduleInfo, err error) {
+	prg, err1 := parser.ParseFile(nil, name, src, parser.Module, parserOptions...)
+	if err1 != nil {
+		err = &CompilerSyntaxError{
+			CompilerError: CompilerError{
+				Message: err1.Error(),
+			},
+		}
+		return
+	}
+
+	c := newCompiler()
+
+	defer func() {
+		if x := recover(); x != nil {
+			p, info = nil, nil
+			switch x1 := x.(type) {
+			case *CompilerSyntaxError:
+				err = x1
+			default:
+				panic(x)
+			}
+		}
+	}()
+
+	info = c.compileModule(prg)
+	p = c.p
+	return
+}
+
+// SetModuleLoader sets the loader of the modules run by RunModule() and imported by the import declarations of the
+// modules and by import(). The modules which have already been loaded are kept.
+func (r *Runtime) SetModuleLoader(loader ModuleLoader) {
+	r.moduleLoader = loader
+}
+
+// RunModule loads the module the specifier refers to along with the modules it imports, links and evaluates them
+// and returns its namespace object, whose properties are the exports of the module. A module is only evaluated
+// once, running it again returns the same namespace object, or the exception its evaluation has thrown.
+//
+// The errors returned by the ModuleLoader are returned as they are, a module with a syntax error or importing a
+// missing export results in a *CompilerSyntaxError or an *Exception respectively.
+func (r *Runtime) RunModule(specifier string) (ns *Object, err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			if intr, ok := x.(uncatchableError); ok {
+				err = intr
+			} else {
+				panic(x)
+			}
+		}
+	}()
+	ns, err = r.importModule("", specifier)
+	r.vm.clearStack()
+	return
+}
+
+// RunModuleContext is like RunModule() but the evaluation is interrupted when ctx is done, see RunProgramContext().
+func (r *Runtime) RunModuleContext(ctx gocontext.Context, specifier string) (*Object, error) {
+	defer r.withContext(ctx)()
+	return r.RunModule(specifier)
+}
+
+func (r *Runtime) importModule(referrer, specifier string) (*Object, error) {
+	if r.moduleLoader == nil {
+		return nil, errNoModuleLoader
+	}
+	var loaded []*module
+	m, err := r.loadModule(referrer, specifier, &loaded)
+	if err != nil {
+		// the modules loaded so far may be missing some of their dependencies
+		for _, m := range loaded {
+			delete(r.modules, m.name)
+		}
+		return nil, err
+	}
+
+	var linking []*module
+	if ex := r.vm.try(func() {
+		r.linkModule(m, &linking)
+	}); ex != nil {
+		// the modules may have been instantiated with the bindings of a module which failed to link
+		for _, m := range linking {
+			m.status = moduleUnlinked
+			m.gen, m.getters = nil, nil
+		}
+		return nil, ex
+	}
+	if ex := r.evaluateModule(m); ex != nil {
+		return nil, ex
+	}
+	return r.moduleNamespace(m), nil
+}
+
+// importModuleDynamically implements import(), the module is imported by a job so that the returned promise is
+// always settled asynchronously.
+func (r *Runtime) importModuleDynamically(referrer string, specifier Value) Value {
+	p := r.newPromise(r.global.PromisePrototype)
+	var s string
+	if ex := r.vm.try(func() {
+		s = specifier.String()
+	}); ex != nil {
+		p.reject(ex.val)
+		return p.val
+	}
+	r.enqueueJob(func() {
+		ns, err := r.importModule(referrer, s)
+		if err != nil {
+			p.reject(r.moduleErrorValue(err))
+			return
+		}
+		p.resolve(ns)
+	})
+	return p.val
+}
+
+func (r *Runtime) moduleErrorValue(err error) Value {
+	switch err := err.(type) {
+	case *Exception:
+		return err.val
+	case *CompilerSyntaxError:
+		return r.builtin_new(r.global.SyntaxError, []Value{newStringValue(err.Error())})
+	}
+	return r.NewGoError(err)
+}
+
+// loadModule loads the module the specifier refers to and the modules it requests (unless they have already been
+// loaded), the new modules are appended to loaded.
+func (r *Runtime) loadModule(referrer, specifier string, loaded *[]*module) (*module, error) {
+	name, err := r.moduleLoader.Resolve(referrer, specifier)
+	if err != nil {
+		return nil, err
+	}
+	if m := r.modules[name]; m != nil {
+		return m, nil
+	}
+	src, err := r.moduleLoader.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	prg, info, err := compileModule(name, src, r.parserOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &module{
+		moduleInfo: info,
+		name:       name,
+		prg:        prg,
+		deps:       make(map[string]*module, len(info.requests)),
+	}
+	if r.modules == nil {
+		r.modules = make(map[string]*module)
+	}
+	// the module is registered before its dependencies are loaded, they may import it
+	r.modules[name] = m
+	*loaded = append(*loaded, m)
+	for _, specifier := range info.requests {
+		dep, err := r.loadModule(name, specifier, loaded)
+		if err != nil {
+			return nil, err
+		}
+		m.deps[specifier] = dep
+	}
+	return m, nil
+}
+
+// linkModule resolves the imports of the module and of the modules it requests, and instantiates them. A module
+// which is part of a cycle may be instantiated before the modules it imports from, its imported bindings are
+// resolved when they are accessed. The modules are appended to linking when their linking starts.
+func (r *Runtime) linkModule(m *module, linking *[]*module) {
+	if m.status != moduleUnlinked {
+		return
+	}
+	m.status = moduleLinking
+	*linking = append(*linking, m)
+	for _, specifier := range m.requests {
+		r.linkModule(m.deps[specifier], linking)
+	}
+
+	for name, entry := range m.indirectExports {
+		if entry.importName != "*" {
+			if b, ambiguous := m.resolveExport(name, nil); b == nil {
+				r.throwUnresolvedExport(m.deps[entry.specifier], entry.importName, ambiguous)
+			}
+		}
+	}
+
+	env := r.newBaseObject(nil, classObject)
+	setter := r.newNativeFunc(func(FunctionCall) Value {
+		r.throwConstAssignmentError()
+		return nil
+	}, nil, "", nil, 1)
+	for _, entry := range m.imports {
+		dep := m.deps[entry.specifier]
+		b := &moduleBinding{m: dep, name: "*"}
+		if entry.importName != "*" {
+			var ambiguous bool
+			if b, ambiguous = dep.resolveExport(entry.importName, nil); b == nil {
+				r.throwUnresolvedExport(dep, entry.importName, ambiguous)
+			}
+		}
+		env._put(entry.localName, &valueProperty{
+			accessor:   true,
+			getterFunc: r.moduleBindingGetter(b),
+			setterFunc: setter,
+		})
+	}
+
+	r.instantiateModule(m, env)
+	m.status = moduleLinked
+}
+
+func (r *Runtime) throwUnresolvedExport(m *module, name string, ambiguous bool) {
+	if ambiguous {
+		panic(r.newError(r.global.SyntaxError, "The requested module '%s' contains conflicting star exports for name '%s'", m.name, name))
+	}
+	panic(r.newError(r.global.SyntaxError, "The requested module '%s' does not provide an export named '%s'", m.name, name))
+}
+
+// instantiateModule creates the function which executes the code of the module in the environment of its imported
+// bindings, and runs it until it yields the getters of the exported bindings (see compileModule).
+func (r *Runtime) instantiateModule(m *module, env *baseObject) {
+	vm := r.vm
+	if vm.dbg != nil {
+		vm.dbg.addProgram(m.prg)
+	}
+	vm.pushCtx()
+	vm.prg = m.prg
+	vm.pc = 0
+	vm.stash = &stash{obj: env}
+	vm.run()
+	f := vm.pop()
+	vm.popCtx()
+	vm.halt = false
+
+	m.gen = r.toCallable(f)(FunctionCall{This: _undefined}).(*Object).self.(*Generator)
+	getters := r.toObject(nilSafe(r.toObject(m.gen.resume(resumeNext, _undefined)).self.getStr("value")))
+	m.getters = make(map[string]func(FunctionCall) Value, len(m.bindings))
+	for i, name := range m.bindings {
+		m.getters[name] = r.toCallable(getters.self.getStr(strconv.Itoa(i)))
+	}
+}
+
+// evaluateModule evaluates the modules requested by the module and then the module itself, unless it has already
+// been evaluated or its evaluation is in progress (which is the case when the modules import each other).
+func (r *Runtime) evaluateModule(m *module) *Exception {
+	switch m.status {
+	case moduleEvaluating:
+		return nil
+	case moduleEvaluated:
+		return m.err
+	}
+	m.status = moduleEvaluating
+	for _, specifier := range m.requests {
+		if ex := r.evaluateModule(m.deps[specifier]); ex != nil {
+			m.status, m.err, m.gen = moduleEvaluated, ex, nil
+			return ex
+		}
+	}
+	m.err = r.vm.try(func() {
+		m.gen.resume(resumeNext, _undefined)
+	})
+	m.status, m.gen = moduleEvaluated, nil
+	return m.err
+}
+
+func (r *Runtime) moduleBindingGetter(b *moduleBinding) *Object {
+	return r.newNativeFunc(func(FunctionCall) Value {
+		return r.moduleBindingValue(b)
+	}, nil, "", nil, 0)
+}
+
+// moduleBindingValue returns the current value of the binding, it throws a ReferenceError if the binding has not
+// been initialised yet.
+func (r *Runtime) moduleBindingValue(b *moduleBinding) Value {
+	if b.name == "*" {
+		return r.moduleNamespace(b.m)
+	}
+	return b.m.getters[b.name](FunctionCall{This: _undefined})
+}
+
+// moduleNamespace returns the namespace object of the module, see namespaceObject.
+func (r *Runtime) moduleNamespace(m *module) *Object {
+	if m.namespace != nil {
+		return m.namespace
+	}
+	o := &namespaceObject{
+		exports: make(map[string]*moduleBinding),
+	}
+	o.class = classObject
+	o.val = &Object{runtime: r, self: o}
+	o.init()
+	names := m.exportedNames(nil, make(map[*module]bool))
+	sort.Strings(names)
+	for i, name := range names {
+		if i > 0 && names[i-1] == name {
+			continue
+		}
+		if b, _ := m.resolveExport(name, nil); b != nil {
+			o.names = append(o.names, name)
+			o.exports[name] = b
+		}
+	}
+	o._putSym(SymToStringTag, asciiString("Module"), false, false, false)
+	o.extensible = false
+	m.namespace = o.val
+	return m.namespace
+}
+
+// namespaceObject is the namespace object of a module. It is not extensible and has no prototype. Its properties are
+// the exports of the module in the order of their names: they are writable, enumerable and non-configurable data
+// properties which hold the current values of the exported bindings, but they can be neither assigned nor redefined.
+type namespaceObject struct {
+	baseObject
+	names   []string
+	exports map[string]*moduleBinding
+}
+
+func (o *namespaceObject) getExport(name string) Value {
+	if b := o.exports[name]; b != nil {
+		return o.val.runtime.moduleBindingValue(b)
+	}
+	return nil
+}
+
+func (o *namespaceObject) get(n Value) Value {
+	if _, ok := n.(*Symbol); ok {
+		return o.baseObject.get(n)
+	}
+	return o.getStr(n.String())
+}
+
+func (o *namespaceObject) getStr(name string) Value {
+	if v := o.getExport(name); v != nil {
+		return v
+	}
+	return o.baseObject.getStr(name)
+}
+
+func (o *namespaceObject) getProp(n Value) Value {
+	if _, ok := n.(*Symbol); ok {
+		return o.baseObject.getProp(n)
+	}
+	return o.getPropStr(n.String())
+}
+
+func (o *namespaceObject) getPropStr(name string) Value {
+	if v := o.getExport(name); v != nil {
+		return v
+	}
+	return o.baseObject.getPropStr(name)
+}
+
+func (o *namespaceObject) getOwnProp(name string) Value {
+	if v := o.getExport(name); v != nil {
+		return &valueProperty{
+			value:      v,
+			writable:   true,
+			enumerable: true,
+		}
+	}
+	return o.baseObject.getOwnProp(name)
+}
+
+func (o *namespaceObject) getOwnPropertyDescriptor(name string) Value {
+	return o.val.runtime.propToDescriptorObject(o.getOwnProp(name))
+}
+
+func (o *namespaceObject) put(n Value, val Value, throw bool) {
+	if _, ok := n.(*Symbol); ok {
+		o.baseObject.put(n, val, throw)
+		return
+	}
+	o.putStr(n.String(), val, throw)
+}
+
+func (o *namespaceObject) putStr(name string, val Value, throw bool) {
+	o.val.runtime.typeErrorResult(throw, "Cannot assign to read only property '%s' of module namespace", name)
+}
+
+func (o *namespaceObject) hasProperty(n Value) bool {
+	return o.hasOwnProperty(n)
+}
+
+func (o *namespaceObject) hasPropertyStr(name string) bool {
+	return o.hasOwnPropertyStr(name)
+}
+
+func (o *namespaceObject) hasOwnProperty(n Value) bool {
+	if _, ok := n.(*Symbol); ok {
+		return o.baseObject.hasOwnProperty(n)
+	}
+	return o.hasOwnPropertyStr(n.String())
+}
+
+func (o *namespaceObject) hasOwnPropertyStr(name string) bool {
+	return o.exports[name] != nil
+}
+
+func (o *namespaceObject) defineOwnProperty(n Value, descr PropertyDescriptor, throw bool) bool {
+	if _, ok := n.(*Symbol); ok {
+		return o.baseObject.defineOwnProperty(n, descr, throw)
+	}
+	name := n.String()
+	if o.exports[name] == nil {
+		o.val.runtime.typeErrorResult(throw, "Cannot define property %s, object is not extensible", name)
+		return false
+	}
+	// only the descriptors which do not change the property are allowed
+	if descr.Getter != nil || descr.Setter != nil || descr.Writable == FLAG_FALSE ||
+		descr.Enumerable == FLAG_FALSE || descr.Configurable == FLAG_TRUE ||
+		descr.Value != nil && !descr.Value.SameAs(o.getExport(name)) {
+		o.val.runtime.typeErrorResult(throw, "Cannot redefine property: %s", name)
+		return false
+	}
+	return true
+}
+
+func (o *namespaceObject) deleteStr(name string, throw bool) bool {
+	if o.exports[name] != nil {
+		o.val.runtime.typeErrorResult(throw, "Cannot delete property '%s' of %s", name, o.val.String())
+		return false
+	}
+	return true
+}
+
+func (o *namespaceObject) delete(n Value, throw bool) bool {
+	if _, ok := n.(*Symbol); ok {
+		return o.baseObject.delete(n, throw)
+	}
+	return o.deleteStr(n.String(), throw)
+}
+
+type namespacePropIter struct {
+	o   *namespaceObject
+	idx int
+}
+
+func (i *namespacePropIter) next() (propIterItem, iterNextFunc) {
+	if i.idx < len(i.o.names) {
+		name := i.o.names[i.idx]
+		i.idx++
+		return propIterItem{name: name, enumerable: _ENUM_TRUE}, i.next
+	}
+	return propIterItem{}, nil
+}
+
+func (o *namespaceObject) enumerate(all, recursive bool) iterNextFunc {
+	return (&propFilterIter{
+		wrapped: o._enumerate(recursive),
+		all:     all,
+		seen:    make(map[string]bool),
+	}).next
+}
+
+func (o *namespaceObject) _enumerate(recursive bool) iterNextFunc {
+	return (&namespacePropIter{
+		o: o,
+	}).next
+}
+
+func (o *namespaceObject) export() interface{} {
+	m := make(map[string]interface{}, len(o.names))
+	for _, name := range o.names {
+		m[name] = o.getExport(name).Export()
+	}
+	return m
+}
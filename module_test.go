@@ -0,0 +1,345 @@
+package goja
+
+import (
+	gocontext "context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func runTestModule(t *testing.T, modules MapModuleLoader, name string) (*Runtime, *Object) {
+	r := New()
+	r.SetModuleLoader(modules)
+	ns, err := r.RunModule(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, ns
+}
+
+func TestModuleLiveBindings(t *testing.T) {
+	_, ns := runTestModule(t, MapModuleLoader{
+		"main.js": `
+		import {count, inc as increment} from "./lib/counter.js";
+		import * as counter from "./lib/counter.js";
+		increment();
+		increment();
+		export const seen = count, seenNs = counter.count;
+		export const keys = Object.keys(counter).join();
+		export const tag = Object.prototype.toString.call(counter);
+		export const frozen = Object.isExtensible(counter) || Object.getPrototypeOf(counter) !== null;
+		`,
+		"lib/counter.js": `
+		export let count = 0;
+		export function inc() {
+			count++;
+		}
+		`,
+	}, "main.js")
+
+	if v := ns.Get("seen"); !v.SameAs(intToValue(2)) {
+		t.Fatalf("seen: %v", v)
+	}
+	if v := ns.Get("seenNs"); !v.SameAs(intToValue(2)) {
+		t.Fatalf("seenNs: %v", v)
+	}
+	if v := ns.Get("keys"); !v.SameAs(asciiString("count,inc")) {
+		t.Fatalf("keys: %v", v)
+	}
+	if v := ns.Get("tag"); !v.SameAs(asciiString("[object Module]")) {
+		t.Fatalf("tag: %v", v)
+	}
+	if v := ns.Get("frozen"); !v.SameAs(valueFalse) {
+		t.Fatalf("frozen: %v", v)
+	}
+}
+
+func TestModuleNamespaceDescriptors(t *testing.T) {
+	_, ns := runTestModule(t, MapModuleLoader{
+		"main.js": `
+		import * as lib from "./lib.js";
+		function throws(f) {
+			try {
+				f();
+				return false;
+			} catch (e) {
+				return e instanceof TypeError;
+			}
+		}
+		var d = Object.getOwnPropertyDescriptor(lib, "x");
+		export const descr = [d.value, d.writable, d.enumerable, d.configurable, "get" in d].join();
+		lib.inc();
+		export const live = Object.getOwnPropertyDescriptor(lib, "x").value;
+		export const rejected = [
+			throws(() => { lib.x = 5; }),
+			throws(() => { lib.y = 5; }),
+			throws(() => { delete lib.x; }),
+			throws(() => Object.defineProperty(lib, "x", {value: 5})),
+			throws(() => Object.defineProperty(lib, "x", {writable: false})),
+			throws(() => Object.defineProperty(lib, "x", {get: function() {}})),
+			(() => {
+				try {
+					Object.freeze(lib);
+				} catch (e) {}
+				return Object.getOwnPropertyDescriptor(lib, "x").writable && !Object.isFrozen(lib);
+			})(),
+			Reflect.set(lib, "x", 5),
+			Reflect.defineProperty(lib, "x", {configurable: true}),
+		].join();
+		export const allowed = Reflect.defineProperty(lib, "x", {value: 2, writable: true, enumerable: true}) &&
+			Reflect.deleteProperty(lib, "missing") && "x" in lib && !("missing" in lib) && lib.x === 2;
+		`,
+		"lib.js": `
+		export let x = 1;
+		export function inc() {
+			x++;
+		}
+		`,
+	}, "main.js")
+
+	if v := ns.Get("descr"); !v.SameAs(asciiString("1,true,true,false,false")) {
+		t.Fatalf("descr: %v", v)
+	}
+	if v := ns.Get("live"); !v.SameAs(intToValue(2)) {
+		t.Fatalf("live: %v", v)
+	}
+	if v := ns.Get("rejected"); !v.SameAs(asciiString("true,true,true,true,true,true,true,false,false")) {
+		t.Fatalf("rejected: %v", v)
+	}
+	if v := ns.Get("allowed"); !v.SameAs(valueTrue) {
+		t.Fatalf("allowed: %v", v)
+	}
+	if exp, ok := ns.Export().(map[string]interface{}); !ok || exp["live"] != int64(2) || len(exp) != 4 {
+		t.Fatalf("Unexpected export: %#v", ns.Export())
+	}
+}
+
+func TestModuleExports(t *testing.T) {
+	_, ns := runTestModule(t, MapModuleLoader{
+		"main.js": `
+		import f, {x as y} from "./def.js";
+		import v from "./expr.js";
+		export {y as x};
+		export * from "./star.js";
+		export * as all from "./star.js";
+		export {a as b, default as starDefault} from "./star.js";
+		export const name = f.name, value = f() + v;
+		`,
+		"def.js":  `export default function() { return 40; } export var x = 1;`,
+		"expr.js": `export default 1 + 1;`,
+		"star.js": `export var a = "a", c = "c"; export default "skipped";`,
+	}, "main.js")
+
+	if v := ns.Get("name"); !v.SameAs(asciiString("default")) {
+		t.Fatalf("name: %v", v)
+	}
+	if v := ns.Get("value"); !v.SameAs(intToValue(42)) {
+		t.Fatalf("value: %v", v)
+	}
+	if v := ns.Get("x"); !v.SameAs(intToValue(1)) {
+		t.Fatalf("x: %v", v)
+	}
+	if v := ns.Get("c"); !v.SameAs(asciiString("c")) {
+		t.Fatalf("c: %v", v)
+	}
+	if v := ns.Get("b"); !v.SameAs(asciiString("a")) {
+		t.Fatalf("b: %v", v)
+	}
+	if v := ns.Get("starDefault"); !v.SameAs(asciiString("skipped")) {
+		t.Fatalf("starDefault: %v", v)
+	}
+	if v := ns.Get("default"); v != nil {
+		t.Fatalf("default: %v", v)
+	}
+	if v := ns.Get("all").(*Object).Get("a"); !v.SameAs(asciiString("a")) {
+		t.Fatalf("all.a: %v", v)
+	}
+}
+
+func TestModuleCycle(t *testing.T) {
+	_, ns := runTestModule(t, MapModuleLoader{
+		"a.js": `
+		import {b, log} from "./b.js";
+		log.push("a");
+		export function a() {
+			return "a" + b();
+		}
+		export const result = a(), order = log.join();
+		`,
+		"b.js": `
+		import {a} from "./a.js";
+		export const log = ["b"];
+		export function b() {
+			return "b";
+		}
+		export function callA() {
+			return a();
+		}
+		`,
+	}, "a.js")
+
+	if v := ns.Get("result"); !v.SameAs(asciiString("ab")) {
+		t.Fatalf("result: %v", v)
+	}
+	if v := ns.Get("order"); !v.SameAs(asciiString("b,a")) {
+		t.Fatalf("order: %v", v)
+	}
+}
+
+func TestModuleEvaluatedOnce(t *testing.T) {
+	r := New()
+	evaluations := 0
+	r.Set("evaluate", func() int {
+		evaluations++
+		return evaluations
+	})
+	r.SetModuleLoader(MapModuleLoader{
+		"main.js":    `import "./counter.js"; import "./counter.js"; export {n} from "./counter.js";`,
+		"counter.js": `export var n = evaluate();`,
+	})
+	ns, err := r.RunModule("main.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns1, err := r.RunModule("./main.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ns1 != ns {
+		t.Fatal("the namespace objects differ")
+	}
+	if v := ns.Get("n"); !v.SameAs(intToValue(1)) {
+		t.Fatalf("n: %v", v)
+	}
+	if evaluations != 1 {
+		t.Fatalf("evaluations: %d", evaluations)
+	}
+}
+
+func TestModuleDynamicImport(t *testing.T) {
+	r, ns := runTestModule(t, MapModuleLoader{
+		"main.js": `
+		export let log = [];
+		import("./lib.js").then(ns => {
+			log.push(ns.value);
+			return import("./missing.js");
+		}).catch(e => {
+			log.push(e.message);
+		});
+		log.push("sync");
+		`,
+		"lib.js": `export const value = "lib";`,
+	}, "main.js")
+
+	r.RunJobs()
+	if v := ns.Get("log").String(); v != "sync,lib,Cannot find module 'missing.js'" {
+		t.Fatalf("log: %s", v)
+	}
+
+	r.SetModuleLoader(nil)
+	const SCRIPT = `
+	var result;
+	import("./lib.js").then(ns => {
+		result = ns.value;
+	});
+	`
+	if _, err := r.RunString(SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	r.SetModuleLoader(MapModuleLoader{"lib.js": `export const value = "other";`})
+	r.RunJobs()
+	if v := r.Get("result"); !v.SameAs(asciiString("lib")) {
+		t.Fatalf("result: %v", v)
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	r := New()
+	r.SetModuleLoader(MapModuleLoader{
+		"lib.js":       `export const a = 1; export * from "./x.js"; export * from "./y.js";`,
+		"x.js":         `export const dup = 1;`,
+		"y.js":         `export const dup = 2;`,
+		"missing.js":   `import {b} from "./lib.js";`,
+		"ambiguous.js": `import {dup} from "./lib.js";`,
+		"assign.js":    `import {a} from "./lib.js"; a = 2;`,
+		"syntax.js":    `export {undeclared};`,
+		"throws.js":    `throw new Error("boom");`,
+		"importer.js":  `import "./throws.js";`,
+	})
+
+	test := func(name, expected string) {
+		_, err := r.RunModule(name)
+		if err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+		if err.Error() != expected {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+	}
+
+	test("missing.js", "SyntaxError: The requested module 'lib.js' does not provide an export named 'b'")
+	test("ambiguous.js", "SyntaxError: The requested module 'lib.js' contains conflicting star exports for name 'dup'")
+	test("assign.js", "TypeError: Assignment to constant variable. at assign.js:1:29(9)")
+	test("syntax.js", "SyntaxError: Export 'undeclared' is not defined in module at 1:9")
+	test("nothing.js", "Cannot find module 'nothing.js'")
+	test("throws.js", "Error: boom at throws.js:1:7(10)")
+	test("importer.js", "Error: boom at throws.js:1:7(10)")
+
+	ns, err := r.RunModule("lib.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := r.modules["missing.js"]; !exists {
+		t.Fatal("the module which failed to link is not kept")
+	}
+	if v := ns.Get("dup"); v != nil {
+		t.Fatalf("dup: %v", v)
+	}
+}
+
+func TestRunModuleContext(t *testing.T) {
+	r := New()
+	r.SetModuleLoader(MapModuleLoader{
+		"loop.js": `for (;;) {}`,
+		"jobs.js": `export let done = false; Promise.resolve().then(() => { for (;;) {} }); done = true;`,
+	})
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := r.RunModuleContext(ctx, "loop.js"); !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ns, err := r.RunModuleContext(gocontext.Background(), "jobs.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := ns.Get("done"); !v.SameAs(valueTrue) {
+		t.Fatalf("done: %v", v)
+	}
+	ctx, cancel = gocontext.WithCancel(gocontext.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if err := r.RunJobsContext(ctx); !errors.Is(err, gocontext.Canceled) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestFSModuleLoader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/main.js":     {Data: []byte(`import {greet} from "../shared/greet.js"; export const msg = greet("world");`)},
+		"shared/greet.js": {Data: []byte(`export function greet(who) { return "hello " + who; }`)},
+	}
+	r := New()
+	r.SetModuleLoader(NewFSModuleLoader(fsys))
+	ns, err := r.RunModule("app/main.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := ns.Get("msg"); !v.SameAs(asciiString("hello world")) {
+		t.Fatalf("msg: %v", v)
+	}
+
+	if _, err := r.RunModule("../outside.js"); err == nil || err.Error() != "Cannot find module '../outside.js'" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"fmt"
 	"errors"
+	gocontext "context"
 )
 
 const (
@@ -17,11 +18,31 @@ const (
 	classRegExp   = "RegExp"
 	classDate     = "Date"
 	classProxy    = "Proxy"
+	classSymbol   = "Symbol"
+	classBigInt   = "BigInt"
+	classPromise  = "Promise"
+
+	classMap     = "Map"
+	classSet     = "Set"
+	classWeakMap = "WeakMap"
+	classWeakSet = "WeakSet"
+
+	classArrayBuffer = "ArrayBuffer"
+	classDataView    = "DataView"
+
+	classArrayIterator  = "Array Iterator"
+	classStringIterator = "String Iterator"
+	classMapIterator    = "Map Iterator"
+	classSetIterator    = "Set Iterator"
+	classGenerator      = "Generator"
 )
 
 type Object struct {
 	runtime *Runtime
 	self    objectImpl
+
+	// weak collections (WeakMap, WeakSet) this object is a key of
+	weakColls *weakCollections
 }
 
 type iterNextFunc func() (propIterItem, iterNextFunc)
@@ -66,6 +87,8 @@ type objectImpl interface {
 	getPropStr(string) Value
 	getStr(string) Value
 	getOwnProp(string) Value
+	getOwnPropSym(*Symbol) Value
+	ownSymbols() []*Symbol
 	put(Value, Value, bool)
 	putStr(string, Value, bool)
 	hasProperty(Value) bool
@@ -73,6 +96,7 @@ type objectImpl interface {
 	hasOwnProperty(Value) bool
 	hasOwnPropertyStr(string) bool
 	_putProp(name string, value Value, writable, enumerable, configurable bool) Value
+	_putSym(s *Symbol, value Value, writable, enumerable, configurable bool) Value
 	defineOwnProperty(name Value, descr PropertyDescriptor, throw bool) bool
 	toPrimitiveNumber() Value
 	toPrimitiveString() Value
@@ -81,6 +105,7 @@ type objectImpl interface {
 	deleteStr(name string, throw bool) bool
 	delete(name Value, throw bool) bool
 	proto() *Object
+	setProto(proto *Object)
 	hasInstance(v Value) bool
 	isExtensible() bool
 	preventExtensions()
@@ -104,6 +129,9 @@ type baseObject struct {
 	values    map[string]Value
 	propNames []string
 	hidden    map[string]valueProperty
+
+	symValues map[*Symbol]Value
+	symNames  []*Symbol
 }
 
 type primitiveValueObject struct {
@@ -122,6 +150,8 @@ func (o *primitiveValueObject) exportType() reflect.Type {
 type FunctionCall struct {
 	This      Value
 	Arguments []Value
+
+	ctx gocontext.Context
 }
 
 type ConstructorCall struct {
@@ -136,6 +166,15 @@ func (f FunctionCall) Argument(idx int) Value {
 	return _undefined
 }
 
+// Context returns the context passed to Runtime.RunProgramContext() or Runtime.CallContext(), or
+// context.Background() if the code is not running with a context.
+func (f FunctionCall) Context() gocontext.Context {
+	if f.ctx != nil {
+		return f.ctx
+	}
+	return gocontext.Background()
+}
+
 func (f ConstructorCall) Argument(idx int) Value {
 	if idx < len(f.Arguments) {
 		return f.Arguments[idx]
@@ -146,6 +185,7 @@ func (f ConstructorCall) Argument(idx int) Value {
 func (o *baseObject) init() {
 	o.values = make(map[string]Value)
 	o.hidden = make(map[string]valueProperty)
+	o.val.runtime.vm.allocate(objectAllocSize)
 }
 
 func (o *baseObject) className() string {
@@ -163,9 +203,22 @@ func (o *baseObject) getPropStr(name string) Value {
 }
 
 func (o *baseObject) getProp(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return o.getPropSym(s)
+	}
 	return o.val.self.getPropStr(n.String())
 }
 
+func (o *baseObject) getPropSym(s *Symbol) Value {
+	if val := o.symValues[s]; val != nil {
+		return val
+	}
+	if o.prototype != nil {
+		return o.prototype.self.getProp(s)
+	}
+	return nil
+}
+
 func (o *baseObject) hasProperty(n Value) bool {
 	return o.val.self.getProp(n) != nil
 }
@@ -198,9 +251,21 @@ func (o *baseObject) getStr(name string) Value {
 }
 
 func (o *baseObject) get(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return o.getSym(s)
+	}
 	return o.getStr(n.String())
 }
 
+func (o *baseObject) getSym(s *Symbol) Value {
+	p := o.val.self.getProp(s)
+	if p, ok := p.(*valueProperty); ok {
+		return p.get(o.val)
+	}
+
+	return p
+}
+
 func (o *baseObject) getHiddenProp(name string) Value {
 	prop := o.hidden[name]
 	return prop.get(o.val)
@@ -272,14 +337,79 @@ func (o *baseObject) deleteStr(name string, throw bool) bool {
 	return true
 }
 
+func (o *baseObject) deleteSym(s *Symbol, throw bool) bool {
+	if val, exists := o.symValues[s]; exists {
+		if !o.checkDelete(s.String(), val, throw) {
+			return false
+		}
+		delete(o.symValues, s)
+		for i, n := range o.symNames {
+			if n == s {
+				copy(o.symNames[i:], o.symNames[i+1:])
+				o.symNames[len(o.symNames)-1] = nil
+				o.symNames = o.symNames[:len(o.symNames)-1]
+				break
+			}
+		}
+	}
+	return true
+}
+
 func (o *baseObject) delete(n Value, throw bool) bool {
+	if s, ok := n.(*Symbol); ok {
+		return o.deleteSym(s, throw)
+	}
 	return o.deleteStr(n.String(), throw)
 }
 
 func (o *baseObject) put(n Value, val Value, throw bool) {
+	if s, ok := n.(*Symbol); ok {
+		o.putSym(s, val, throw)
+		return
+	}
 	o.putStr(n.String(), val, throw)
 }
 
+func (o *baseObject) putSym(s *Symbol, val Value, throw bool) {
+	if v, exists := o.symValues[s]; exists {
+		if prop, ok := v.(*valueProperty); ok {
+			if !prop.isWritable() {
+				o.val.runtime.typeErrorResult(throw, "Cannot assign to read only property '%s'", s.String())
+				return
+			}
+			prop.set(o.val, val)
+			return
+		}
+		o.symValues[s] = val
+		return
+	}
+
+	var pprop Value
+	if proto := o.prototype; proto != nil {
+		pprop = proto.self.getProp(s)
+	}
+
+	if pprop != nil {
+		if prop, ok := pprop.(*valueProperty); ok {
+			if !prop.isWritable() {
+				o.val.runtime.typeErrorResult(throw)
+				return
+			}
+			if prop.accessor {
+				prop.set(o.val, val)
+				return
+			}
+		}
+	} else {
+		if !o.extensible {
+			o.val.runtime.typeErrorResult(throw)
+			return
+		}
+	}
+
+	o._putSymValue(s, val)
+}
+
 func (o *baseObject) getOwnProp(name string) Value {
 	v := o.values[name]
 	if v == nil && name == "__proto" {
@@ -288,6 +418,15 @@ func (o *baseObject) getOwnProp(name string) Value {
 	return v
 }
 
+func (o *baseObject) getOwnPropSym(s *Symbol) Value {
+	return o.symValues[s]
+}
+
+// ownSymbols returns the symbol keys of the own properties in the order they were added.
+func (o *baseObject) ownSymbols() []*Symbol {
+	return append([]*Symbol(nil), o.symNames...)
+}
+
 func (o *baseObject) putStr(name string, val Value, throw bool) {
 	if v, exists := o.values[name]; exists {
 		if prop, ok := v.(*valueProperty); ok {
@@ -342,10 +481,14 @@ func (o *baseObject) putStr(name string, val Value, throw bool) {
 	}
 
 	o.values[name] = val
+	o.val.runtime.vm.allocate(propertyAllocSize)
 	o.propNames = append(o.propNames, name)
 }
 
 func (o *baseObject) hasOwnProperty(n Value) bool {
+	if s, ok := n.(*Symbol); ok {
+		return o.symValues[s] != nil
+	}
 	v := o.values[n.String()]
 	return v != nil
 }
@@ -356,7 +499,12 @@ func (o *baseObject) hasOwnPropertyStr(name string) bool {
 }
 
 func (o *baseObject) getOwnPropertyDescriptor(name string) Value {
-	desc := o.getOwnProp(name)
+	return o.val.runtime.propToDescriptorObject(o.getOwnProp(name))
+}
+
+// propToDescriptorObject converts an own property value (as returned by getOwnProp or getOwnPropSym) into a
+// property descriptor object. A nil value results in undefined.
+func (r *Runtime) propToDescriptorObject(desc Value) Value {
 	if desc == nil {
 		return _undefined
 	}
@@ -378,7 +526,6 @@ func (o *baseObject) getOwnPropertyDescriptor(name string) Value {
 		value = desc
 	}
 
-	r := o.val.runtime
 	ret := r.NewObject()
 	obj := ret.self
 	if !accessor {
@@ -506,11 +653,20 @@ Reject:
 }
 
 func (o *baseObject) defineOwnProperty(n Value, descr PropertyDescriptor, throw bool) bool {
+	if s, ok := n.(*Symbol); ok {
+		existingVal := o.symValues[s]
+		if v, ok := o._defineOwnProperty(n, existingVal, descr, throw); ok {
+			o._putSymValue(s, v)
+			return true
+		}
+		return false
+	}
 	name := n.String()
 	existingVal := o.values[name]
 	if v, ok := o._defineOwnProperty(n, existingVal, descr, throw); ok {
 		o.values[name] = v
 		if existingVal == nil {
+			o.val.runtime.vm.allocate(propertyAllocSize)
 			o.propNames = append(o.propNames, name)
 		}
 		return true
@@ -520,6 +676,7 @@ func (o *baseObject) defineOwnProperty(n Value, descr PropertyDescriptor, throw
 
 func (o *baseObject) _put(name string, v Value) {
 	if _, exists := o.values[name]; !exists {
+		o.val.runtime.vm.allocate(propertyAllocSize)
 		o.propNames = append(o.propNames, name)
 	}
 
@@ -542,6 +699,33 @@ func (o *baseObject) _putProp(name string, value Value, writable, enumerable, co
 	}
 }
 
+func (o *baseObject) _putSymValue(s *Symbol, v Value) {
+	if o.symValues == nil {
+		o.symValues = make(map[*Symbol]Value)
+	}
+	if _, exists := o.symValues[s]; !exists {
+		o.symNames = append(o.symNames, s)
+	}
+
+	o.symValues[s] = v
+}
+
+func (o *baseObject) _putSym(s *Symbol, value Value, writable, enumerable, configurable bool) Value {
+	if writable && enumerable && configurable {
+		o._putSymValue(s, value)
+		return value
+	} else {
+		p := &valueProperty{
+			value:        value,
+			writable:     writable,
+			enumerable:   enumerable,
+			configurable: configurable,
+		}
+		o._putSymValue(s, p)
+		return p
+	}
+}
+
 func (o *baseObject) tryPrimitive(methodName string) Value {
 	if method, ok := o.getStr(methodName).(*Object); ok {
 		if call, ok := method.self.assertCallable(); ok {
@@ -556,7 +740,33 @@ func (o *baseObject) tryPrimitive(methodName string) Value {
 	return nil
 }
 
+// tryExoticToPrimitive calls the object's @@toPrimitive method if there is one. It returns nil if the method is
+// not defined.
+func (o *baseObject) tryExoticToPrimitive(hint string) Value {
+	exoticToPrimitive := toMethod(o.getSym(SymToPrimitive))
+	if exoticToPrimitive != nil {
+		r := o.val.runtime
+		ret := exoticToPrimitive(FunctionCall{
+			This:      o.val,
+			Arguments: []Value{newStringValue(hint)},
+		})
+		if _, fail := ret.(*Object); !fail {
+			return ret
+		}
+		r.typeErrorResult(true, "Cannot convert object to primitive value")
+	}
+	return nil
+}
+
 func (o *baseObject) toPrimitiveNumber() Value {
+	if v := o.tryExoticToPrimitive("number"); v != nil {
+		return v
+	}
+
+	return o.ordinaryToPrimitiveNumber()
+}
+
+func (o *baseObject) ordinaryToPrimitiveNumber() Value {
 	if v := o.tryPrimitive("valueOf"); v != nil {
 		return v
 	}
@@ -570,6 +780,14 @@ func (o *baseObject) toPrimitiveNumber() Value {
 }
 
 func (o *baseObject) toPrimitiveString() Value {
+	if v := o.tryExoticToPrimitive("string"); v != nil {
+		return v
+	}
+
+	return o.ordinaryToPrimitiveString()
+}
+
+func (o *baseObject) ordinaryToPrimitiveString() Value {
 	if v := o.tryPrimitive("toString"); v != nil {
 		return v
 	}
@@ -583,7 +801,11 @@ func (o *baseObject) toPrimitiveString() Value {
 }
 
 func (o *baseObject) toPrimitive() Value {
-	return o.toPrimitiveNumber()
+	if v := o.tryExoticToPrimitive("default"); v != nil {
+		return v
+	}
+
+	return o.ordinaryToPrimitiveNumber()
 }
 
 func (o *baseObject) assertCallable() (func(FunctionCall) Value, bool) {
@@ -594,6 +816,10 @@ func (o *baseObject) proto() *Object {
 	return o.prototype
 }
 
+func (o *baseObject) setProto(proto *Object) {
+	o.prototype = proto
+}
+
 func (o *baseObject) isExtensible() bool {
 	return o.extensible
 }
@@ -18,6 +18,9 @@ func (a *argumentsObject) getPropStr(name string) Value {
 }
 
 func (a *argumentsObject) getProp(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return a.getPropSym(s)
+	}
 	return a.getPropStr(n.String())
 }
 
@@ -27,6 +30,10 @@ func (a *argumentsObject) init() {
 }
 
 func (a *argumentsObject) put(n Value, val Value, throw bool) {
+	if s, ok := n.(*Symbol); ok {
+		a.putSym(s, val, throw)
+		return
+	}
 	a.putStr(n.String(), val, throw)
 }
 
@@ -55,6 +62,9 @@ func (a *argumentsObject) deleteStr(name string, throw bool) bool {
 }
 
 func (a *argumentsObject) delete(n Value, throw bool) bool {
+	if s, ok := n.(*Symbol); ok {
+		return a.deleteSym(s, throw)
+	}
 	return a.deleteStr(n.String(), throw)
 }
 
@@ -30,10 +30,19 @@ func (o *objectGoMapSimple) _getStr(name string) Value {
 }
 
 func (o *objectGoMapSimple) get(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return o.baseObject.getSym(s)
+	}
 	return o.getStr(n.String())
 }
 
 func (o *objectGoMapSimple) getProp(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		if s == SymIterator {
+			return o.val.runtime.getGoMapIterator()
+		}
+		return o.baseObject.getPropSym(s)
+	}
 	return o.getPropStr(n.String())
 }
 
@@ -219,3 +228,30 @@ func (o *objectGoMapSimple) swap(i, j int64) {
 	o.putStr(ii, y, false)
 	o.putStr(jj, x, false)
 }
+
+// getGoMapIterator returns the @@iterator method of Go maps, it produces the [key, value] entries of the map (like an
+// Array Iterator over the entries would, but without copying them).
+func (r *Runtime) getGoMapIterator() *Object {
+	if r.global.goMapIterator == nil {
+		r.global.goMapIterator = r.newNativeFunc(r.gomap_iterator, nil, "[Symbol.iterator]", nil, 0)
+	}
+	return r.global.goMapIterator
+}
+
+func (r *Runtime) gomap_iterator(call FunctionCall) Value {
+	var it *reflect.MapIter
+	switch o := r.toObject(call.This).self.(type) {
+	case *objectGoMapSimple:
+		it = reflect.ValueOf(o.data).MapRange()
+	case *objectGoMapReflect:
+		it = o.value.MapRange()
+	default:
+		r.typeErrorResult(true, "Object is not a Go map: %s", call.This.String())
+	}
+	return r.newIteratorObject(r.global.ArrayIteratorPrototype, classArrayIterator, func() (Value, bool) {
+		if !it.Next() {
+			return nil, false
+		}
+		return r.newArrayValues([]Value{r.ToValue(it.Key().Interface()), r.ToValue(it.Value().Interface())}), true
+	})
+}
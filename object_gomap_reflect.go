@@ -47,6 +47,9 @@ func (o *objectGoMapReflect) _getStr(name string) Value {
 }
 
 func (o *objectGoMapReflect) get(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return o.objectGoReflect.get(s)
+	}
 	if v := o._get(n); v != nil {
 		return v
 	}
@@ -61,6 +64,12 @@ func (o *objectGoMapReflect) getStr(name string) Value {
 }
 
 func (o *objectGoMapReflect) getProp(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		if s == SymIterator {
+			return o.val.runtime.getGoMapIterator()
+		}
+		return o.objectGoReflect.getProp(s)
+	}
 	return o.get(n)
 }
 
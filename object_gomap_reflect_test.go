@@ -164,3 +164,23 @@ func TestGoMapReflectWithMethods(t *testing.T) {
 	}
 
 }
+
+func TestGoMapReflectIterate(t *testing.T) {
+	const SCRIPT = `
+	var sum = 0;
+	for (var [k, v] of m) {
+		sum += k * v;
+	}
+	sum;
+	`
+
+	vm := New()
+	vm.Set("m", map[int]int{1: 10, 2: 20})
+	v, err := vm.RunString(SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := v.ToInteger(); i != 50 {
+		t.Fatalf("Expected 50, got %v", v)
+	}
+}
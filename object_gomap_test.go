@@ -182,3 +182,24 @@ func TestGoMapExtensibility(t *testing.T) {
 	}
 
 }
+
+func TestGomapIterate(t *testing.T) {
+	const SCRIPT = `
+	var keys = [], sum = 0;
+	for (var [k, v] of m) {
+		keys.push(k);
+		sum += v;
+	}
+	keys.sort().join(",") + "|" + sum + "|" + [...m].length;
+	`
+
+	r := New()
+	r.Set("m", map[string]interface{}{"a": 1, "b": 2})
+	v, err := r.RunString(SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "a,b|3|2" {
+		t.Fatalf("Unexpected result: '%s'", s)
+	}
+}
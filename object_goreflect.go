@@ -83,6 +83,9 @@ func (o *objectGoReflect) valueOfFunc(call FunctionCall) Value {
 }
 
 func (o *objectGoReflect) get(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return o.baseObject.getSym(s)
+	}
 	return o.getStr(n.String())
 }
 
@@ -128,6 +131,9 @@ func (o *objectGoReflect) getStr(name string) Value {
 }
 
 func (o *objectGoReflect) getProp(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return o.baseObject.getPropSym(s)
+	}
 	name := n.String()
 	if p := o.getOwnProp(name); p != nil {
 		return p
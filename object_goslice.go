@@ -47,6 +47,9 @@ func (o *objectGoSlice) _getStr(name string) Value {
 }
 
 func (o *objectGoSlice) get(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return o.baseObject.getSym(s)
+	}
 	if v := o._get(n); v != nil {
 		return v
 	}
@@ -61,6 +64,9 @@ func (o *objectGoSlice) getStr(name string) Value {
 }
 
 func (o *objectGoSlice) getProp(n Value) Value {
+	if s, ok := n.(*Symbol); ok {
+		return o.baseObject.getPropSym(s)
+	}
 	if v := o._get(n); v != nil {
 		return v
 	}
@@ -85,3 +85,25 @@ func TestGoSliceProto(t *testing.T) {
 		t.Fatalf("Unexpected result: '%s'", s)
 	}
 }
+
+func TestGoSliceIterate(t *testing.T) {
+	const SCRIPT = `
+	var res = [];
+	for (var v of a) {
+		res.push(v);
+	}
+	var [first, ...rest] = a;
+	res.push(first, rest.length, Math.max(...a));
+	res.join(",")
+	`
+
+	r := New()
+	r.Set("a", []interface{}{1, 2, 3})
+	ret, err := r.RunString(SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := ret.String(); s != "1,2,3,1,2,3" {
+		t.Fatalf("Unexpected result: '%s'", s)
+	}
+}
@@ -61,6 +61,18 @@ func (o *lazyObject) getOwnProp(name string) Value {
 	return obj.getOwnProp(name)
 }
 
+func (o *lazyObject) getOwnPropSym(s *Symbol) Value {
+	obj := o.create(o.val)
+	o.val.self = obj
+	return obj.getOwnPropSym(s)
+}
+
+func (o *lazyObject) ownSymbols() []*Symbol {
+	obj := o.create(o.val)
+	o.val.self = obj
+	return obj.ownSymbols()
+}
+
 func (o *lazyObject) put(n Value, val Value, throw bool) {
 	obj := o.create(o.val)
 	o.val.self = obj
@@ -103,6 +115,12 @@ func (o *lazyObject) _putProp(name string, value Value, writable, enumerable, co
 	return obj._putProp(name, value, writable, enumerable, configurable)
 }
 
+func (o *lazyObject) _putSym(s *Symbol, value Value, writable, enumerable, configurable bool) Value {
+	obj := o.create(o.val)
+	o.val.self = obj
+	return obj._putSym(s, value, writable, enumerable, configurable)
+}
+
 func (o *lazyObject) defineOwnProperty(name Value, descr PropertyDescriptor, throw bool) bool {
 	obj := o.create(o.val)
 	o.val.self = obj
@@ -151,6 +169,12 @@ func (o *lazyObject) proto() *Object {
 	return obj.proto()
 }
 
+func (o *lazyObject) setProto(proto *Object) {
+	obj := o.create(o.val)
+	o.val.self = obj
+	obj.setProto(proto)
+}
+
 func (o *lazyObject) hasInstance(v Value) bool {
 	obj := o.create(o.val)
 	o.val.self = obj
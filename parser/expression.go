@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"math/big"
+
 	"github.com/dop251/goja/ast"
 	"github.com/dop251/goja/file"
 	"github.com/dop251/goja/token"
@@ -21,6 +23,9 @@ func (self *_parser) parsePrimaryExpression() ast.Expression {
 	idx := self.idx
 	switch self.token {
 	case token.IDENTIFIER:
+		if self.isAsyncFunction() {
+			return self.parseFunction(false)
+		}
 		self.next()
 		if len(literal) > 1 {
 			tkn, strict := token.IsKeyword(literal)
@@ -67,6 +72,8 @@ func (self *_parser) parsePrimaryExpression() ast.Expression {
 			Literal: literal,
 			Value:   value,
 		}
+	case token.BACKTICK:
+		return self.parseTemplateLiteral(false)
 	case token.NUMBER:
 		self.next()
 		value, err := parseNumberLiteral(literal)
@@ -97,6 +104,20 @@ func (self *_parser) parsePrimaryExpression() ast.Expression {
 		}
 	case token.FUNCTION:
 		return self.parseFunction(false)
+	case token.CLASS:
+		return self.parseClass(false)
+	case token.IMPORT:
+		return self.parseImportCall()
+	case token.SUPER:
+		self.next()
+		switch self.token {
+		case token.LEFT_PARENTHESIS, token.PERIOD, token.LEFT_BRACKET:
+		default:
+			self.error(idx, "'super' keyword unexpected here")
+		}
+		return &ast.SuperExpression{
+			Idx: idx,
+		}
 	}
 
 	self.errorUnexpectedToken(self.token)
@@ -145,20 +166,26 @@ func (self *_parser) parseRegExpLiteral() *ast.RegExpLiteral {
 
 func (self *_parser) parseVariableDeclaration(declarationList *[]*ast.VariableExpression) ast.Expression {
 
-	if self.token != token.IDENTIFIER {
+	var node *ast.VariableExpression
+	switch self.token {
+	case token.IDENTIFIER:
+		node = &ast.VariableExpression{
+			Name: self.literal,
+			Idx:  self.idx,
+		}
+		self.next()
+	case token.LEFT_BRACKET, token.LEFT_BRACE:
+		target := self.parseBindingTarget()
+		node = &ast.VariableExpression{
+			Idx:    target.Idx0(),
+			Target: target,
+		}
+	default:
 		idx := self.expect(token.IDENTIFIER)
 		self.nextStatement()
 		return &ast.BadExpression{From: idx, To: self.idx}
 	}
 
-	literal := self.literal
-	idx := self.idx
-	self.next()
-	node := &ast.VariableExpression{
-		Name: literal,
-		Idx:  idx,
-	}
-
 	if declarationList != nil {
 		*declarationList = append(*declarationList, node)
 	}
@@ -184,14 +211,128 @@ func (self *_parser) parseVariableDeclarationList(var_ file.Idx) []ast.Expressio
 		self.next()
 	}
 
+	// the names bound by destructuring declarations are hoisted as plain declarations
+	var declared []*ast.VariableExpression
+	for _, item := range declarationList {
+		if item.Target == nil {
+			declared = append(declared, item)
+			continue
+		}
+		ast.BoundNames(item.Target, func(id *ast.Identifier) {
+			declared = append(declared, &ast.VariableExpression{
+				Name: id.Name,
+				Idx:  id.Idx,
+			})
+		})
+	}
+
 	self.scope.declare(&ast.VariableDeclaration{
 		Var:  var_,
-		List: declarationList,
+		List: declared,
 	})
 
 	return list
 }
 
+// checkDestructuringInitializers reports an error for destructuring declarations without an initializer, which are
+// only allowed in the heads of for-in and for-of statements.
+func (self *_parser) checkDestructuringInitializers(list []ast.Expression) {
+	for _, item := range list {
+		if item, ok := item.(*ast.VariableExpression); ok && item.Target != nil && item.Initializer == nil {
+			self.error(item.Idx, "Missing initializer in destructuring declaration")
+		}
+	}
+}
+
+// parseBindingTarget parses the name or the pattern of a declaration or a formal parameter.
+func (self *_parser) parseBindingTarget() ast.Expression {
+	switch self.token {
+	case token.LEFT_BRACKET:
+		return self.toPattern(self.parseArrayLiteral(), true)
+	case token.LEFT_BRACE:
+		return self.toPattern(self.parseObjectLiteral(), true)
+	case token.IDENTIFIER:
+		return self.parseIdentifier()
+	}
+	idx := self.expect(token.IDENTIFIER)
+	self.nextStatement()
+	return &ast.BadExpression{From: idx, To: self.idx}
+}
+
+// toPattern converts an array or an object literal which turned out to be the target of a destructuring
+// assignment or binding into the corresponding pattern. In a binding pattern all targets must be identifiers.
+func (self *_parser) toPattern(expr ast.Expression, binding bool) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.ArrayLiteral:
+		pattern := &ast.ArrayPattern{
+			LeftBracket:  expr.LeftBracket,
+			RightBracket: expr.RightBracket,
+		}
+		for i, elt := range expr.Value {
+			if spread, ok := elt.(*ast.SpreadElement); ok {
+				if i != len(expr.Value)-1 {
+					self.error(spread.Idx, "Rest element must be last element")
+				}
+				pattern.Rest = self.toPatternTarget(spread.Expression, binding)
+				break
+			}
+			if elt != nil {
+				elt = self.toPatternElement(elt, binding)
+			}
+			pattern.Elements = append(pattern.Elements, elt)
+		}
+		return pattern
+	case *ast.ObjectLiteral:
+		pattern := &ast.ObjectPattern{
+			LeftBrace:  expr.LeftBrace,
+			RightBrace: expr.RightBrace,
+		}
+		for i, prop := range expr.Value {
+			switch prop.Kind {
+			case "value", "shorthand":
+				prop.Value = self.toPatternElement(prop.Value, binding)
+				pattern.Properties = append(pattern.Properties, prop)
+			case "spread":
+				if i != len(expr.Value)-1 {
+					self.error(prop.Value.Idx0(), "Rest element must be last element")
+				}
+				switch prop.Value.(type) {
+				case *ast.ArrayLiteral, *ast.ObjectLiteral:
+					self.error(prop.Value.Idx0(), "`...` must be followed by an assignable reference in assignment contexts")
+				}
+				pattern.Rest = self.toPatternTarget(prop.Value, binding)
+			default:
+				self.error(prop.Value.Idx0(), "Invalid destructuring assignment target")
+			}
+		}
+		return pattern
+	}
+	return expr
+}
+
+func (self *_parser) toPatternElement(expr ast.Expression, binding bool) ast.Expression {
+	if assign, ok := expr.(*ast.AssignExpression); ok && assign.Operator == token.ASSIGN {
+		assign.Left = self.toPatternTarget(assign.Left, binding)
+		return assign
+	}
+	return self.toPatternTarget(expr, binding)
+}
+
+func (self *_parser) toPatternTarget(expr ast.Expression, binding bool) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.Identifier, *ast.ArrayPattern, *ast.ObjectPattern:
+		return expr
+	case *ast.ArrayLiteral, *ast.ObjectLiteral:
+		return self.toPattern(expr, binding)
+	case *ast.DotExpression, *ast.BracketExpression:
+		if !binding {
+			return expr
+		}
+	}
+	self.error(expr.Idx0(), "Invalid destructuring assignment target")
+	return &ast.BadExpression{From: expr.Idx0(), To: expr.Idx1()}
+}
+
 func (self *_parser) parseObjectPropertyKey() (string, string) {
 	idx, tkn, literal := self.idx, self.token, self.literal
 	value := ""
@@ -201,9 +342,12 @@ func (self *_parser) parseObjectPropertyKey() (string, string) {
 		value = literal
 	case token.NUMBER:
 		var err error
-		_, err = parseNumberLiteral(literal)
+		var num interface{}
+		num, err = parseNumberLiteral(literal)
 		if err != nil {
 			self.error(idx, err.Error())
+		} else if b, ok := num.(*big.Int); ok {
+			value = b.String()
 		} else {
 			value = literal
 		}
@@ -224,7 +368,38 @@ func (self *_parser) parseObjectPropertyKey() (string, string) {
 
 func (self *_parser) parseObjectProperty() ast.Property {
 
+	if self.token == token.ELLIPSIS {
+		self.next()
+		return ast.Property{
+			Kind:  "spread",
+			Value: self.parseAssignmentExpression(),
+		}
+	}
+
+	keyToken, keyIdx := self.token, self.idx
 	literal, value := self.parseObjectPropertyKey()
+	if keyToken == token.IDENTIFIER {
+		switch self.token {
+		case token.COMMA, token.RIGHT_BRACE, token.ASSIGN:
+			var node ast.Expression = &ast.Identifier{
+				Name: literal,
+				Idx:  keyIdx,
+			}
+			if self.token == token.ASSIGN {
+				self.next()
+				node = &ast.AssignExpression{
+					Operator: token.ASSIGN,
+					Left:     node,
+					Right:    self.parseAssignmentExpression(),
+				}
+			}
+			return ast.Property{
+				Key:   value,
+				Kind:  "shorthand",
+				Value: node,
+			}
+		}
+	}
 	if literal == "get" && self.token != token.COLON {
 		idx := self.idx
 		_, value := self.parseObjectPropertyKey()
@@ -296,7 +471,7 @@ func (self *_parser) parseArrayLiteral() ast.Expression {
 			value = append(value, nil)
 			continue
 		}
-		value = append(value, self.parseAssignmentExpression())
+		value = append(value, self.parseSpreadOrAssignmentExpression())
 		if self.token != token.RIGHT_BRACKET {
 			self.expect(token.COMMA)
 		}
@@ -314,7 +489,7 @@ func (self *_parser) parseArgumentList() (argumentList []ast.Expression, idx0, i
 	idx0 = self.expect(token.LEFT_PARENTHESIS)
 	if self.token != token.RIGHT_PARENTHESIS {
 		for {
-			argumentList = append(argumentList, self.parseAssignmentExpression())
+			argumentList = append(argumentList, self.parseSpreadOrAssignmentExpression())
 			if self.token != token.COMMA {
 				break
 			}
@@ -325,6 +500,19 @@ func (self *_parser) parseArgumentList() (argumentList []ast.Expression, idx0, i
 	return
 }
 
+// parseSpreadOrAssignmentExpression parses an element of an array literal or an argument list.
+func (self *_parser) parseSpreadOrAssignmentExpression() ast.Expression {
+	if self.token == token.ELLIPSIS {
+		idx := self.idx
+		self.next()
+		return &ast.SpreadElement{
+			Idx:        idx,
+			Expression: self.parseAssignmentExpression(),
+		}
+	}
+	return self.parseAssignmentExpression()
+}
+
 func (self *_parser) parseCallExpression(left ast.Expression) ast.Expression {
 	argumentList, idx0, idx1 := self.parseArgumentList()
 	return &ast.CallExpression{
@@ -386,6 +574,82 @@ func (self *_parser) parseNewExpression() ast.Expression {
 	return node
 }
 
+// parseTemplateLiteral parses a template literal starting at the current backtick token. The characters
+// between the substitutions are scanned directly from the source rather than tokenised.
+// parseImportCall parses a dynamic import(). The import declarations of a module are parsed by parseModuleItem, an
+// import keyword found here in any other context is an error.
+func (self *_parser) parseImportCall() ast.Expression {
+	idx := self.expect(token.IMPORT)
+	if self.token != token.LEFT_PARENTHESIS {
+		if self.mode&Module != 0 {
+			self.error(idx, "Import declarations may only appear at the top level of a module")
+		} else {
+			self.error(idx, "Cannot use import statement outside a module")
+		}
+		self.nextStatement()
+		return &ast.BadExpression{From: idx, To: self.idx}
+	}
+	self.next()
+	node := &ast.ImportCall{
+		Import:   idx,
+		Argument: self.parseAssignmentExpression(),
+	}
+	node.RightParenthesis = self.expect(token.RIGHT_PARENTHESIS)
+	return node
+}
+
+func (self *_parser) parseTemplateLiteral(tagged bool) *ast.TemplateLiteral {
+	res := &ast.TemplateLiteral{
+		OpenQuote: self.idx,
+	}
+	allowIn := self.scope.allowIn
+	self.scope.allowIn = true
+	defer func() {
+		self.scope.allowIn = allowIn
+	}()
+	for {
+		start := self.idxOf(self.chrOffset)
+		raw, finished, err := self.scanTemplateCharacters()
+		if err != nil {
+			self.error(res.OpenQuote, err.Error())
+			self.next()
+			return res
+		}
+		elt := &ast.TemplateElement{
+			Idx:     start,
+			Literal: raw,
+			Valid:   true,
+		}
+		elt.Parsed, err = parseTemplateCharacters(raw)
+		if err != nil {
+			// invalid escape sequences result in an undefined cooked value in tagged templates
+			if !tagged {
+				self.error(start, err.Error())
+			}
+			elt.Valid = false
+		}
+		res.Elements = append(res.Elements, elt)
+		if finished {
+			res.CloseQuote = self.idxOf(self.chrOffset - 1)
+			self.insertSemicolon = true
+			self.next()
+			return res
+		}
+		self.next()
+		res.Expressions = append(res.Expressions, self.parseExpression())
+		if self.token != token.RIGHT_BRACE {
+			self.errorUnexpectedToken(self.token)
+			return res
+		}
+	}
+}
+
+func (self *_parser) parseTaggedTemplate(tag ast.Expression) *ast.TemplateLiteral {
+	res := self.parseTemplateLiteral(true)
+	res.Tag = tag
+	return res
+}
+
 func (self *_parser) parseLeftHandSideExpression() ast.Expression {
 
 	var left ast.Expression
@@ -402,6 +666,8 @@ func (self *_parser) parseLeftHandSideExpression() ast.Expression {
 			left = self.parseBracketMember(left)
 		} else if self.token == token.LEFT_BRACE {
 			left = self.parseBracketMember(left)
+		} else if self.token == token.BACKTICK {
+			left = self.parseTaggedTemplate(left)
 		} else {
 			break
 		}
@@ -432,6 +698,8 @@ func (self *_parser) parseLeftHandSideExpressionAllowCall() ast.Expression {
 			left = self.parseBracketMember(left)
 		} else if self.token == token.LEFT_PARENTHESIS {
 			left = self.parseCallExpression(left)
+		} else if self.token == token.BACKTICK {
+			left = self.parseTaggedTemplate(left)
 		} else {
 			break
 		}
@@ -484,6 +752,15 @@ func (self *_parser) parseUnaryExpression() ast.Expression {
 			Idx:      idx,
 			Operand:  self.parseUnaryExpression(),
 		}
+	case token.IDENTIFIER:
+		if self.scope.inAsync && self.literal == "await" {
+			idx := self.idx
+			self.next()
+			return &ast.AwaitExpression{
+				Await:    idx,
+				Argument: self.parseUnaryExpression(),
+			}
+		}
 	case token.INCREMENT, token.DECREMENT:
 		tkn := self.token
 		idx := self.idx
@@ -724,7 +1001,95 @@ func (self *_parser) parseConditionlExpression() ast.Expression {
 	return left
 }
 
+// isArrowFunction looks ahead to check whether the current token starts an arrow function parameter list,
+// i.e. a single identifier or a parenthesised list of parameters followed by "=>".
+func (self *_parser) isArrowFunction() bool {
+	if self.token != token.IDENTIFIER && self.token != token.LEFT_PARENTHESIS {
+		return false
+	}
+	state := self.mark()
+	defer self.restore(state)
+	if self.token == token.LEFT_PARENTHESIS {
+		// skip to the matching closing parenthesis, the parameters may contain patterns and default values
+		depth := 0
+	loop:
+		for {
+			switch self.token {
+			case token.LEFT_PARENTHESIS, token.LEFT_BRACKET, token.LEFT_BRACE:
+				depth++
+			case token.RIGHT_PARENTHESIS, token.RIGHT_BRACKET, token.RIGHT_BRACE:
+				depth--
+				if depth == 0 {
+					break loop
+				}
+			case token.EOF:
+				return false
+			}
+			self.next()
+		}
+		if self.token != token.RIGHT_PARENTHESIS {
+			return false
+		}
+	}
+	self.next()
+	// no line terminator is allowed between the parameters and the arrow
+	return self.token == token.ARROW && !self.implicitSemicolon
+}
+
+func (self *_parser) parseArrowFunction(start file.Idx, async bool) *ast.ArrowFunctionLiteral {
+	node := &ast.ArrowFunctionLiteral{
+		Start: start,
+		Async: async,
+	}
+	if self.token == token.IDENTIFIER {
+		param := self.parseIdentifier()
+		node.ParameterList = &ast.ParameterList{
+			Opening: param.Idx,
+			List:    []*ast.Binding{{Target: param}},
+			Closing: param.Idx1(),
+		}
+	} else {
+		node.ParameterList = self.parseFunctionParameterList()
+	}
+	self.expect(token.ARROW)
+
+	allowIn := self.scope.allowIn
+	self.openScope()
+	self.scope.inFunction = true
+	self.scope.inAsync = async
+	if self.token == token.LEFT_BRACE {
+		node.Body = self.parseBlockStatement()
+	} else {
+		self.scope.allowIn = allowIn
+		node.Body = &ast.ExpressionBody{
+			Expression: self.parseAssignmentExpression(),
+		}
+	}
+	node.End = self.prevEnd
+	node.DeclarationList = self.scope.declarationList
+	self.closeScope()
+	node.Source = self.slice(node.Idx0(), node.Idx1())
+
+	return node
+}
+
 func (self *_parser) parseAssignmentExpression() ast.Expression {
+	if self.isArrowFunction() {
+		return self.parseArrowFunction(self.idx, false)
+	}
+	if self.token == token.IDENTIFIER && self.literal == "async" {
+		state := self.mark()
+		start := self.idx
+		self.next()
+		// no line terminator is allowed between async and the parameters
+		if !self.implicitSemicolon && self.isArrowFunction() {
+			return self.parseArrowFunction(start, true)
+		}
+		self.restore(state)
+	}
+	if self.scope.inGenerator && self.token == token.IDENTIFIER && self.literal == "yield" {
+		return self.parseYieldExpression()
+	}
 	left := self.parseConditionlExpression()
 	var operator token.Token
 	switch self.token {
@@ -761,6 +1126,13 @@ func (self *_parser) parseAssignmentExpression() ast.Expression {
 		self.next()
 		switch left.(type) {
 		case *ast.Identifier, *ast.DotExpression, *ast.BracketExpression:
+		case *ast.ArrayLiteral, *ast.ObjectLiteral:
+			if operator != token.ASSIGN {
+				self.error(left.Idx0(), "Invalid left-hand side in assignment")
+				self.nextStatement()
+				return &ast.BadExpression{From: idx, To: self.idx}
+			}
+			left = self.toPattern(left, false)
 		default:
 			self.error(left.Idx0(), "Invalid left-hand side in assignment")
 			self.nextStatement()
@@ -796,3 +1168,27 @@ func (self *_parser) parseExpression() ast.Expression {
 
 	return left
 }
+
+// parseYieldExpression parses a yield expression. Like return, it takes an optional argument which must start on the
+// same line.
+func (self *_parser) parseYieldExpression() ast.Expression {
+	node := &ast.YieldExpression{
+		Yield: self.idx,
+	}
+	self.next()
+	if self.implicitSemicolon {
+		return node
+	}
+	if self.token == token.MULTIPLY {
+		node.Delegate = true
+		self.next()
+	} else {
+		switch self.token {
+		case token.RIGHT_PARENTHESIS, token.RIGHT_BRACKET, token.RIGHT_BRACE, token.COMMA, token.SEMICOLON,
+			token.COLON, token.EOF:
+			return node
+		}
+	}
+	node.Argument = self.parseAssignmentExpression()
+	return node
+}
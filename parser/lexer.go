@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math/big"
 	"regexp"
 	"strconv"
 	"strings"
@@ -203,6 +204,10 @@ func (self *_parser) scan() (tkn token.Token, literal string, idx file.Idx) {
 				if digitValue(self.chr) < 10 {
 					insertSemicolon = true
 					tkn, literal = self.scanNumericLiteral(true)
+				} else if self.chr == '.' && self.offset < self.length && self.str[self.offset] == '.' {
+					self.read()
+					self.read()
+					tkn = token.ELLIPSIS
 				} else {
 					tkn = token.PERIOD
 				}
@@ -258,10 +263,15 @@ func (self *_parser) scan() (tkn token.Token, literal string, idx file.Idx) {
 			case '>':
 				tkn = self.switch6(token.GREATER, token.GREATER_OR_EQUAL, '>', token.SHIFT_RIGHT, token.SHIFT_RIGHT_ASSIGN, '>', token.UNSIGNED_SHIFT_RIGHT, token.UNSIGNED_SHIFT_RIGHT_ASSIGN)
 			case '=':
-				tkn = self.switch2(token.ASSIGN, token.EQUAL)
-				if tkn == token.EQUAL && self.chr == '=' {
+				if self.chr == '>' {
 					self.read()
-					tkn = token.STRICT_EQUAL
+					tkn = token.ARROW
+				} else {
+					tkn = self.switch2(token.ASSIGN, token.EQUAL)
+					if tkn == token.EQUAL && self.chr == '=' {
+						self.read()
+						tkn = token.STRICT_EQUAL
+					}
 				}
 			case '!':
 				tkn = self.switch2(token.NOT, token.NOT_EQUAL)
@@ -282,6 +292,9 @@ func (self *_parser) scan() (tkn token.Token, literal string, idx file.Idx) {
 				tkn = token.BITWISE_NOT
 			case '?':
 				tkn = token.QUESTION_MARK
+			case '`':
+				// the characters of a template literal are scanned by the parser, see parseTemplateLiteral
+				tkn = token.BACKTICK
 			case '"', '\'':
 				insertSemicolon = true
 				tkn = token.STRING
@@ -553,6 +566,49 @@ newline:
 	return "", errors.New(err)
 }
 
+// scanTemplateCharacters scans the characters of a template literal starting at the current character up to and
+// including either the closing backtick (finished is true) or the "${" that starts a substitution. The raw text is
+// returned with line terminator sequences normalised to \n.
+func (self *_parser) scanTemplateCharacters() (raw string, finished bool, err error) {
+	offset := self.chrOffset
+	var end int
+	for {
+		switch self.chr {
+		case '`':
+			end = self.chrOffset
+			self.read()
+			finished = true
+			goto done
+		case '$':
+			if self.offset < self.length && self.str[self.offset] == '{' {
+				end = self.chrOffset
+				self.read()
+				self.read()
+				goto done
+			}
+		case '\\':
+			self.read()
+			if self.chr == '\r' {
+				self.read()
+				if self.chr == '\n' {
+					self.read()
+				}
+				continue
+			}
+		case -1:
+			return "", false, errors.New("Unterminated template literal")
+		}
+		self.read()
+	}
+done:
+	raw = self.str[offset:end]
+	if strings.ContainsRune(raw, '\r') {
+		raw = strings.Replace(raw, "\r\n", "\n", -1)
+		raw = strings.Replace(raw, "\r", "\n", -1)
+	}
+	return
+}
+
 func (self *_parser) scanNewline() {
 	if self.chr == '\r' {
 		self.read()
@@ -579,6 +635,14 @@ func hex2decimal(chr byte) (value rune, ok bool) {
 }
 
 func parseNumberLiteral(literal string) (value interface{}, err error) {
+	if strings.HasSuffix(literal, "n") {
+		// BigInt literal, the lexer only allows decimal and hexadecimal integers
+		if b, ok := new(big.Int).SetString(literal[:len(literal)-1], 0); ok {
+			return b, nil
+		}
+		return nil, errors.New("Illegal numeric literal")
+	}
+
 	// TODO Is Uint okay? What about -MAX_UINT
 	value, err = strconv.ParseInt(literal, 0, 64)
 	if err == nil {
@@ -752,6 +816,20 @@ func parseStringLiteral(literal string) (string, error) {
 	return buffer.String(), nil
 }
 
+// parseTemplateCharacters returns the value of the raw text of a template literal part. Unlike string literals,
+// templates do not allow octal escape sequences.
+func parseTemplateCharacters(raw string) (string, error) {
+	for i := 0; i < len(raw)-1; i++ {
+		if raw[i] == '\\' {
+			i++
+			if chr := raw[i]; '1' <= chr && chr <= '9' || chr == '0' && i+1 < len(raw) && isDecimalDigit(rune(raw[i+1])) {
+				return "", errors.New("Octal escape sequences are not allowed in template strings")
+			}
+		}
+	}
+	return parseStringLiteral(raw)
+}
+
 func (self *_parser) scanNumericLiteral(decimalPoint bool) (token.Token, string) {
 
 	offset := self.chrOffset
@@ -781,7 +859,14 @@ func (self *_parser) scanNumericLiteral(decimalPoint bool) (token.Token, string)
 				self.error(0, "Illegal hexadecimal number")
 			}
 
+			if self.chr == 'n' {
+				self.read()
+			}
 			goto hexadecimal
+		} else if self.chr == 'n' {
+			// 0n
+			self.read()
+			goto bigint
 		} else if self.chr == '.' {
 			// Float
 			goto float
@@ -800,6 +885,11 @@ func (self *_parser) scanNumericLiteral(decimalPoint bool) (token.Token, string)
 
 	self.scanMantissa(10)
 
+	if self.chr == 'n' {
+		self.read()
+		goto bigint
+	}
+
 float:
 	if self.chr == '.' {
 		self.read()
@@ -822,6 +912,7 @@ exponent:
 
 hexadecimal:
 octal:
+bigint:
 	if isIdentifierStart(self.chr) || isDecimalDigit(self.chr) {
 		return token.ILLEGAL, self.str[offset:self.chrOffset]
 	}
@@ -199,7 +199,7 @@ Second line \
 			token.VAR, "var", 1,
 			token.IF, "if", 5,
 			token.VAR, "var", 8,
-			token.KEYWORD, "class", 12,
+			token.CLASS, "class", 12,
 			token.EOF, "", 17,
 		)
 
@@ -339,8 +339,21 @@ Second line \
 			token.RIGHT_BRACKET, "", 6,
 		)
 
+		test("0n 12n 0x1Fn",
+			token.NUMBER, "0n", 1,
+			token.NUMBER, "12n", 4,
+			token.NUMBER, "0x1Fn", 8,
+			token.EOF, "", 13,
+		)
+
 		// ILLEGAL
 
+		test(`1.5n`,
+			token.ILLEGAL, "1.5", 1,
+			token.IDENTIFIER, "n", 4,
+			token.EOF, "", 5,
+		)
+
 		test(`3ea`,
 			token.ILLEGAL, "3e", 1,
 			token.IDENTIFIER, "a", 3,
@@ -49,8 +49,29 @@ type Mode uint
 
 const (
 	IgnoreRegExpErrors Mode = 1 << iota // Ignore RegExp compatibility errors (allow backtracking)
+	Module                              // Parse the source as a module, which may contain import and export declarations
 )
 
+// An Option changes the behaviour of the parser, see ParseFile().
+type Option func(*_parser)
+
+// WithDisableSourceMaps disables the loading of the source map referenced by the sourceMappingURL comment at the end
+// of the source.
+func WithDisableSourceMaps(p *_parser) {
+	p.disableSourceMaps = true
+}
+
+// WithSourceMapLoader sets the function which loads the source map referenced by the sourceMappingURL comment at
+// the end of the source, instead of reading it from the file system. The inline data: URLs are decoded by the parser,
+// the other URLs are resolved relatively to the name of the parsed file and passed to the loader.
+//
+// If the loader returns an error the parsing fails, if it returns no data the source has no source map.
+func WithSourceMapLoader(loader func(url string) ([]byte, error)) Option {
+	return func(p *_parser) {
+		p.sourceMapLoader = loader
+	}
+}
+
 type _parser struct {
 	str      string
 	length   int
@@ -63,6 +84,7 @@ type _parser struct {
 	idx     file.Idx    // The index of token
 	token   token.Token // The token
 	literal string      // The literal of the token, if any
+	prevEnd file.Idx    // The index immediately after the previous token
 
 	scope             *_scope
 	insertSemicolon   bool // If we see a newline, then insert an implicit semicolon
@@ -78,6 +100,9 @@ type _parser struct {
 
 	mode Mode
 
+	disableSourceMaps bool
+	sourceMapLoader   func(url string) ([]byte, error)
+
 	file *file.File
 }
 
@@ -131,7 +156,9 @@ func ReadSource(filename string, src interface{}) ([]byte, error) {
 //      // Parse some JavaScript, yielding a *ast.Program and/or an ErrorList
 //      program, err := parser.ParseFile(nil, "", `if (abc > 1) {}`, 0)
 //
-func ParseFile(fileSet *file.FileSet, filename string, src interface{}, mode Mode) (*ast.Program, error) {
+// The options, if any, are applied in order (see WithSourceMapLoader() and WithDisableSourceMaps()).
+//
+func ParseFile(fileSet *file.FileSet, filename string, src interface{}, mode Mode, options ...Option) (*ast.Program, error) {
 	str, err := ReadSource(filename, src)
 	if err != nil {
 		return nil, err
@@ -146,6 +173,9 @@ func ParseFile(fileSet *file.FileSet, filename string, src interface{}, mode Mod
 
 		parser := _newParser(filename, str, base)
 		parser.mode = mode
+		for _, opt := range options {
+			opt(parser)
+		}
 		return parser.parse()
 	}
 }
@@ -188,9 +218,44 @@ func (self *_parser) parse() (*ast.Program, error) {
 }
 
 func (self *_parser) next() {
+	self.prevEnd = self.idxOf(self.chrOffset)
 	self.token, self.literal, self.idx = self.scan()
 }
 
+// _parserState is a snapshot of the scanner used to look ahead and then return to the marked position.
+type _parserState struct {
+	chr               rune
+	chrOffset, offset int
+	idx, prevEnd      file.Idx
+	token             token.Token
+	literal           string
+	insertSemicolon   bool
+	implicitSemicolon bool
+	errors            int
+}
+
+func (self *_parser) mark() _parserState {
+	return _parserState{
+		chr:               self.chr,
+		chrOffset:         self.chrOffset,
+		offset:            self.offset,
+		idx:               self.idx,
+		prevEnd:           self.prevEnd,
+		token:             self.token,
+		literal:           self.literal,
+		insertSemicolon:   self.insertSemicolon,
+		implicitSemicolon: self.implicitSemicolon,
+		errors:            len(self.errors),
+	}
+}
+
+func (self *_parser) restore(state _parserState) {
+	self.chr, self.chrOffset, self.offset = state.chr, state.chrOffset, state.offset
+	self.idx, self.prevEnd, self.token, self.literal = state.idx, state.prevEnd, state.token, state.literal
+	self.insertSemicolon, self.implicitSemicolon = state.insertSemicolon, state.implicitSemicolon
+	self.errors = self.errors[:state.errors]
+}
+
 func (self *_parser) optionalSemicolon() {
 	if self.token == token.SEMICOLON {
 		self.next()
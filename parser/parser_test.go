@@ -8,6 +8,7 @@ import (
 
 	"github.com/dop251/goja/ast"
 	"github.com/dop251/goja/file"
+	"github.com/dop251/goja/token"
 )
 
 func firstErr(err error) error {
@@ -225,7 +226,7 @@ func TestParserErr(t *testing.T) {
 
 		test("a if", "(anonymous): Line 1:3 Unexpected token if")
 
-		test("a class", "(anonymous): Line 1:3 Unexpected reserved word")
+		test("a class", "(anonymous): Line 1:3 Unexpected token class")
 
 		test("break\n", "(anonymous): Line 1:1 Illegal break statement")
 
@@ -273,12 +274,19 @@ func TestParserErr(t *testing.T) {
 
 		test("\u203f = 1", "(anonymous): Line 1:1 Unexpected token ILLEGAL")
 
-		// TODO
-		// const x = 12, y;
-		// const x, y = 12;
-		// const x;
-		// if(true) let a = 1;
-		// if(true) const  a = 1;
+		test("const x = 12, y;", "(anonymous): Line 1:15 Missing initializer in const declaration")
+
+		test("const x, y = 12;", "(anonymous): Line 1:7 Missing initializer in const declaration")
+
+		test("const x;", "(anonymous): Line 1:7 Missing initializer in const declaration")
+
+		test("for (const x; ;);", "(anonymous): Line 1:12 Missing initializer in const declaration")
+
+		test("if(true) let a = 1;", "(anonymous): Line 1:14 Unexpected identifier")
+
+		test("if(true) const  a = 1;", "(anonymous): Line 1:10 Lexical declaration cannot appear in a single-statement context")
+
+		test("let let = 1;", "(anonymous): Line 1:5 let is disallowed as a lexically bound name")
 
 		test(`new abc()."def"`, "(anonymous): Line 1:11 Unexpected string")
 
@@ -373,7 +381,7 @@ func TestParserErr(t *testing.T) {
 
 		test("/*/.source", "(anonymous): Line 1:11 Unexpected end of input")
 
-		test("var class", "(anonymous): Line 1:5 Unexpected reserved word")
+		test("var class", "(anonymous): Line 1:5 Unexpected token class")
 
 		test("var if", "(anonymous): Line 1:5 Unexpected token if")
 
@@ -407,33 +415,33 @@ func TestParserErr(t *testing.T) {
 
 		{ // Reserved words
 
-			test("class", "(anonymous): Line 1:1 Unexpected reserved word")
+			test("class", "(anonymous): Line 1:6 Unexpected end of input")
 			test("abc.class = 1", nil)
-			test("var class;", "(anonymous): Line 1:5 Unexpected reserved word")
+			test("var class;", "(anonymous): Line 1:5 Unexpected token class")
 
-			test("const", "(anonymous): Line 1:1 Unexpected reserved word")
+			test("const", "(anonymous): Line 1:6 Unexpected end of input")
 			test("abc.const = 1", nil)
-			test("var const;", "(anonymous): Line 1:5 Unexpected reserved word")
+			test("var const;", "(anonymous): Line 1:5 Unexpected token const")
 
 			test("enum", "(anonymous): Line 1:1 Unexpected reserved word")
 			test("abc.enum = 1", nil)
 			test("var enum;", "(anonymous): Line 1:5 Unexpected reserved word")
 
-			test("export", "(anonymous): Line 1:1 Unexpected reserved word")
+			test("export", "(anonymous): Line 1:1 Unexpected token export")
 			test("abc.export = 1", nil)
-			test("var export;", "(anonymous): Line 1:5 Unexpected reserved word")
+			test("var export;", "(anonymous): Line 1:5 Unexpected token export")
 
-			test("extends", "(anonymous): Line 1:1 Unexpected reserved word")
+			test("extends", "(anonymous): Line 1:1 Unexpected token extends")
 			test("abc.extends = 1", nil)
-			test("var extends;", "(anonymous): Line 1:5 Unexpected reserved word")
+			test("var extends;", "(anonymous): Line 1:5 Unexpected token extends")
 
-			test("import", "(anonymous): Line 1:1 Unexpected reserved word")
+			test("import", "(anonymous): Line 1:1 Cannot use import statement outside a module")
 			test("abc.import = 1", nil)
-			test("var import;", "(anonymous): Line 1:5 Unexpected reserved word")
+			test("var import;", "(anonymous): Line 1:5 Unexpected token import")
 
-			test("super", "(anonymous): Line 1:1 Unexpected reserved word")
+			test("super", "(anonymous): Line 1:1 'super' keyword unexpected here")
 			test("abc.super = 1", nil)
-			test("var super;", "(anonymous): Line 1:5 Unexpected reserved word")
+			test("var super;", "(anonymous): Line 1:5 Unexpected token super")
 		}
 
 		{ // Reserved words (strict)
@@ -866,6 +874,180 @@ func TestParser(t *testing.T) {
                 2
             debugger
         `, nil)
+
+		program = test(`
+            let a = 1, b;
+            const c = a;
+            for (let i = 0; i < 2; i++) {}
+            for (const k in {}) {}
+            var let = 1;
+            let
+            x = let;
+        `, nil)
+		is(len(program.Body), 6)
+		is(program.Body[0].(*ast.LexicalDeclaration).Token, token.LET)
+		is(program.Body[1].(*ast.LexicalDeclaration).Token, token.CONST)
+		is(program.Body[2].(*ast.ForStatement).Lexical.List[0].Name, "i")
+		is(program.Body[3].(*ast.ForInStatement).Lexical.Token, token.CONST)
+		is(program.Body[5].(*ast.LexicalDeclaration).List[0].Name, "x")
+		is(len(program.DeclarationList), 1)
+
+		program = test(`
+            for (const [a, {b}] of c) {}
+            var {d, e: [f = 1], ...g} = h;
+            [i, ...j] = k;
+            l(...m);
+            function n(o = 1, [p], ...q) {}
+        `, nil)
+		is(len(program.Body), 5)
+		is(len(program.Body[0].(*ast.ForOfStatement).Lexical.List[0].Target.(*ast.ArrayPattern).Elements), 2)
+		is(program.Body[1].(*ast.VariableStatement).List[0].(*ast.VariableExpression).Target.(*ast.ObjectPattern).Rest.(*ast.Identifier).Name, "g")
+		is(program.Body[2].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression).Left.(*ast.ArrayPattern).Rest.(*ast.Identifier).Name, "j")
+		_, ok := program.Body[3].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).ArgumentList[0].(*ast.SpreadElement)
+		is(ok, true)
+		is(program.DeclarationList[1].(*ast.FunctionDeclaration).Function.ParameterList.Rest.(*ast.Identifier).Name, "q")
+		is(len(program.DeclarationList), 2)
+		is(len(program.DeclarationList[0].(*ast.VariableDeclaration).List), 3)
+
+		test("var [a];", "(anonymous): Line 1:5 Missing initializer in destructuring declaration")
+		test("[...a, b] = c", "(anonymous): Line 1:2 Rest element must be last element")
+		test("function f(...a, b) {}", "(anonymous): Line 1:16 Rest parameter must be last formal parameter")
+		test("for ([a] of b, c) {}", "(anonymous): Line 1:14 Unexpected token ,")
+
+		program = test(`
+            class A extends B.C {
+                constructor(x) { super(x); }
+                get x() { return super.x; }
+                static set y(v) {}
+                static() {};
+            }
+            var D = class {};
+        `, nil)
+		is(len(program.Body), 2)
+		class := program.Body[0].(*ast.ClassDeclaration).Class
+		is(class.Name.Name, "A")
+		_, ok = class.SuperClass.(*ast.DotExpression)
+		is(ok, true)
+		is(len(class.Body), 4)
+		is(class.Body[0].Kind, "constructor")
+		is(class.Body[1].Kind, "get")
+		is(class.Body[2].Static, true)
+		is(class.Body[2].Kind, "set")
+		is(class.Body[3].Key, "static")
+		is(class.Body[3].Static, false)
+		_, ok = class.Body[1].Body.Body.(*ast.BlockStatement).List[0].(*ast.ReturnStatement).Argument.(*ast.DotExpression).Left.(*ast.SuperExpression)
+		is(ok, true)
+		is(program.Body[1].(*ast.VariableStatement).List[0].(*ast.VariableExpression).Initializer.(*ast.ClassLiteral).Name, nil)
+
+		test("class A { constructor() {} constructor() {} }", "(anonymous): Line 1:28 A class may only have one constructor")
+		test("class A { get constructor() {} }", "(anonymous): Line 1:11 Class constructor may not be an accessor")
+		test("class A { static prototype() {} }", "(anonymous): Line 1:18 Classes may not have a static property named 'prototype'")
+		test("if (a) class A {}", "(anonymous): Line 1:8 Unexpected token class")
+		test("class A { *constructor() {} }", "(anonymous): Line 1:12 Class constructor may not be a generator")
+
+		program = test(`
+            function* g() {
+                yield
+                a;
+                var b = yield* c, d = yield;
+            }
+            function f(yield) { yield = 1 }
+            class E { static *h() {} }
+        `, nil)
+		fn := program.DeclarationList[0].(*ast.FunctionDeclaration).Function
+		is(fn.Generator, true)
+		list := fn.Body.(*ast.BlockStatement).List
+		is(list[0].(*ast.ExpressionStatement).Expression.(*ast.YieldExpression).Argument, nil)
+		vars := list[2].(*ast.VariableStatement).List
+		is(vars[0].(*ast.VariableExpression).Initializer.(*ast.YieldExpression).Delegate, true)
+		is(vars[1].(*ast.VariableExpression).Initializer.(*ast.YieldExpression).Argument, nil)
+		is(program.DeclarationList[1].(*ast.FunctionDeclaration).Function.Generator, false)
+		method := program.Body[2].(*ast.ClassDeclaration).Class.Body[0]
+		is(method.Static, true)
+		is(method.Key, "h")
+		is(method.Body.Generator, true)
+
+		program = test(`
+            async function f() {
+                await a;
+                var async = b => async(b);
+            }
+            var g = async (x) => await x, h = async x => x, i = async(y);
+            class K { async m() {} static async() {} }
+        `, nil)
+		fn = program.DeclarationList[0].(*ast.FunctionDeclaration).Function
+		is(fn.Async, true)
+		list = fn.Body.(*ast.BlockStatement).List
+		_, ok = list[0].(*ast.ExpressionStatement).Expression.(*ast.AwaitExpression)
+		is(ok, true)
+		_, ok = list[1].(*ast.VariableStatement).List[0].(*ast.VariableExpression).Initializer.(*ast.ArrowFunctionLiteral).Body.(*ast.ExpressionBody).Expression.(*ast.CallExpression).ArgumentList[0].(*ast.Identifier)
+		is(ok, true)
+		vars = program.Body[1].(*ast.VariableStatement).List
+		is(vars[0].(*ast.VariableExpression).Initializer.(*ast.ArrowFunctionLiteral).Async, true)
+		is(vars[1].(*ast.VariableExpression).Initializer.(*ast.ArrowFunctionLiteral).Async, true)
+		_, ok = vars[2].(*ast.VariableExpression).Initializer.(*ast.CallExpression)
+		is(ok, true)
+		class = program.Body[2].(*ast.ClassDeclaration).Class
+		is(class.Body[0].Body.Async, true)
+		is(class.Body[1].Key, "async")
+		is(class.Body[1].Body.Async, false)
+
+		test("async function* f() {}", "(anonymous): Line 1:15 Async generators are not supported")
+		test("class A { async constructor() {} }", "(anonymous): Line 1:17 Class constructor may not be an async method")
+
+		testModule := func(source string, chk interface{}) *ast.Program {
+			parser := newParser("", source)
+			parser.mode |= Module
+			program, err := parser.parse()
+			is(firstErr(err), chk)
+			return program
+		}
+
+		program = testModule(`
+            import d, {a, b as c, default as e} from "./m.js";
+            import * as ns from "./n.js";
+            import "./side.js";
+            export {d as default, a};
+            export * from "./o.js";
+            export * as o from "./o.js";
+            export {x as y} from "./p.js";
+            export const k = 1;
+            export function f() { return import("./q.js"); }
+        `, nil)
+		imp := program.Body[0].(*ast.ImportDeclaration)
+		is(imp.DefaultBinding.Name, "d")
+		is(len(imp.NamedImports), 3)
+		is(imp.NamedImports[1].Name.Name, "b")
+		is(imp.NamedImports[1].Alias.Name, "c")
+		is(imp.NamedImports[2].Name.Name, "default")
+		is(imp.ModuleSpecifier.Value, "./m.js")
+		is(program.Body[1].(*ast.ImportDeclaration).NamespaceImport.Name, "ns")
+		imp = program.Body[2].(*ast.ImportDeclaration)
+		is(imp.DefaultBinding == nil && imp.NamespaceImport == nil && imp.NamedImports == nil, true)
+		exp := program.Body[3].(*ast.ExportDeclaration)
+		is(exp.NamedExports[0].Alias.Name, "default")
+		is(exp.ModuleSpecifier == nil, true)
+		exp = program.Body[4].(*ast.ExportDeclaration)
+		is(exp.ExportAll, true)
+		is(exp.Namespace == nil, true)
+		is(program.Body[5].(*ast.ExportDeclaration).Namespace.Name, "o")
+		is(program.Body[6].(*ast.ExportDeclaration).ModuleSpecifier.Value, "./p.js")
+		_, ok = program.Body[7].(*ast.ExportDeclaration).Statement.(*ast.LexicalDeclaration)
+		is(ok, true)
+		fn = program.Body[8].(*ast.ExportDeclaration).Function
+		is(fn.Name.Name, "f")
+		_, ok = fn.Body.(*ast.BlockStatement).List[0].(*ast.ReturnStatement).Argument.(*ast.ImportCall)
+		is(ok, true)
+
+		program = testModule("export default function() {}", nil)
+		is(program.Body[0].(*ast.ExportDeclaration).Function.Name.Name, "default")
+		program = testModule("export default 1 + 2;", nil)
+		is(program.Body[0].(*ast.ExportDeclaration).Statement.(*ast.LexicalDeclaration).List[0].Name, "default")
+
+		testModule("function f() { import x from 'm'; }", "(anonymous): Line 1:16 Import declarations may only appear at the top level of a module")
+		testModule("export {if};", "(anonymous): Line 1:9 Unexpected token if")
+		test("import x from 'm';", "(anonymous): Line 1:1 Cannot use import statement outside a module")
+		test("super", "(anonymous): Line 1:1 'super' keyword unexpected here")
 	})
 }
 
@@ -996,5 +1178,68 @@ func TestPosition(t *testing.T) {
 		is(err, nil)
 		node = program.Body[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
 		is(node.(*ast.FunctionLiteral).Source, "function(){ return abc; }")
+
+		parser = newParser("", "[].map(x => ({v: x}), this)")
+		program, err = parser.parse()
+		is(err, nil)
+		node = program.Body[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).ArgumentList[0]
+		is(node.Idx0(), file.Idx(8))
+		is(node.Idx1(), file.Idx(21))
+		is(node.(*ast.ArrowFunctionLiteral).Source, "x => ({v: x})")
+		is(len(node.(*ast.ArrowFunctionLiteral).ParameterList.List), 1)
+		_, ok := node.(*ast.ArrowFunctionLiteral).Body.(*ast.ExpressionBody)
+		is(ok, true)
+
+		parser = newParser("", "var f = (a, b) => { return a; }")
+		program, err = parser.parse()
+		is(err, nil)
+		node = program.Body[0].(*ast.VariableStatement).List[0].(*ast.VariableExpression).Initializer
+		is(node.(*ast.ArrowFunctionLiteral).Source, "(a, b) => { return a; }")
+		is(len(node.(*ast.ArrowFunctionLiteral).ParameterList.List), 2)
+
+		parser = newParser("", "x = tag`a\\n${b}c`")
+		program, err = parser.parse()
+		is(err, nil)
+		node = program.Body[0].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression).Right
+		is(node.Idx0(), file.Idx(5))
+		is(node.Idx1(), file.Idx(18))
+		tmpl := node.(*ast.TemplateLiteral)
+		is(tmpl.Tag.(*ast.Identifier).Name, "tag")
+		is(len(tmpl.Elements), 2)
+		is(tmpl.Elements[0].Literal, "a\\n")
+		is(tmpl.Elements[0].Parsed, "a\n")
+		is(tmpl.Elements[1].Literal, "c")
+		is(tmpl.Expressions[0].(*ast.Identifier).Name, "b")
+	})
+}
+
+func TestParseSourceMap(t *testing.T) {
+	tt(t, func() {
+		const MAP = `{"version":3,"sources":["orig.ts"],"names":[],"mappings":"AAAA"}`
+
+		// an inline map, which is followed by a new line
+		program, err := ParseFile(nil, "", "x\n//# sourceMappingURL=data:application/json,"+strings.Replace(MAP, " ", "%20", -1)+"\n", 0)
+		is(err, nil)
+		is(program.SourceMap != nil, true)
+
+		var loaded []string
+		loader := func(url string) ([]byte, error) {
+			loaded = append(loaded, url)
+			return []byte(MAP), nil
+		}
+		program, err = ParseFile(nil, "dist/app.js", "x\n//# sourceMappingURL=app.js.map", 0, WithSourceMapLoader(loader))
+		is(err, nil)
+		is(program.SourceMap != nil, true)
+		is(strings.Join(loaded, ","), "dist/app.js.map")
+
+		program, err = ParseFile(nil, "dist/app.js", "x\n//# sourceMappingURL=app.js.map", 0, WithSourceMapLoader(loader), WithDisableSourceMaps)
+		is(err, nil)
+		is(program.SourceMap == nil, true)
+		is(len(loaded), 1)
+
+		_, err = ParseFile(nil, "app.js", "x\n//# sourceMappingURL=app.js.map", 0, WithSourceMapLoader(func(string) ([]byte, error) {
+			return nil, errors.New("not found")
+		}))
+		is(err, "app.js: Line 2:1 Could not load source map: not found")
 	})
 }
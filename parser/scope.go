@@ -10,6 +10,8 @@ type _scope struct {
 	inIteration     bool
 	inSwitch        bool
 	inFunction      bool
+	inGenerator     bool
+	inAsync         bool
 	declarationList []ast.Declaration
 
 	labels []string
@@ -7,9 +7,9 @@ import (
 	"github.com/go-sourcemap/sourcemap"
 	"encoding/base64"
 	"strings"
-	"os"
 	"io/ioutil"
 	"net/url"
+	"path"
 )
 
 func (self *_parser) parseBlockStatement() *ast.BlockStatement {
@@ -28,7 +28,7 @@ func (self *_parser) parseEmptyStatement() ast.Statement {
 
 func (self *_parser) parseStatementList() (list []ast.Statement) {
 	for self.token != token.RIGHT_BRACE && self.token != token.EOF {
-		list = append(list, self.parseStatement())
+		list = append(list, self.parseSourceElement())
 	}
 
 	return
@@ -76,6 +76,22 @@ func (self *_parser) parseStatement() ast.Statement {
 		return self.parseThrowStatement()
 	case token.TRY:
 		return self.parseTryStatement()
+	case token.CONST:
+		idx := self.idx
+		self.error(idx, "Lexical declaration cannot appear in a single-statement context")
+		self.nextStatement()
+		return &ast.BadStatement{From: idx, To: self.idx}
+	case token.CLASS:
+		idx := self.idx
+		self.errorUnexpectedToken(self.token)
+		self.nextStatement()
+		return &ast.BadStatement{From: idx, To: self.idx}
+	}
+
+	if self.isAsyncFunction() {
+		// like any function declaration it is added to the scope by parseFunction() and hoisted
+		self.parseFunction(true)
+		return &ast.EmptyStatement{}
 	}
 
 	expression := self.parseExpression()
@@ -148,13 +164,26 @@ func (self *_parser) parseTryStatement() ast.Statement {
 
 func (self *_parser) parseFunctionParameterList() *ast.ParameterList {
 	opening := self.expect(token.LEFT_PARENTHESIS)
-	var list []*ast.Identifier
+	var list []*ast.Binding
+	var rest ast.Expression
 	for self.token != token.RIGHT_PARENTHESIS && self.token != token.EOF {
-		if self.token != token.IDENTIFIER {
-			self.expect(token.IDENTIFIER)
-		} else {
-			list = append(list, self.parseIdentifier())
+		if self.token == token.ELLIPSIS {
+			self.next()
+			rest = self.parseBindingTarget()
+			if self.token != token.RIGHT_PARENTHESIS {
+				self.error(self.idx, "Rest parameter must be last formal parameter")
+				self.expect(token.RIGHT_PARENTHESIS)
+			}
+			break
 		}
+		item := &ast.Binding{
+			Target: self.parseBindingTarget(),
+		}
+		if self.token == token.ASSIGN {
+			self.next()
+			item.Initializer = self.parseAssignmentExpression()
+		}
+		list = append(list, item)
 		if self.token != token.RIGHT_PARENTHESIS {
 			self.expect(token.COMMA)
 		}
@@ -164,6 +193,7 @@ func (self *_parser) parseFunctionParameterList() *ast.ParameterList {
 	return &ast.ParameterList{
 		Opening: opening,
 		List:    list,
+		Rest:    rest,
 		Closing: closing,
 	}
 }
@@ -185,7 +215,20 @@ func (self *_parser) parseParameterList() (list []string) {
 func (self *_parser) parseFunction(declaration bool) *ast.FunctionLiteral {
 
 	node := &ast.FunctionLiteral{
-		Function: self.expect(token.FUNCTION),
+		Function: self.idx,
+	}
+	if self.token == token.IDENTIFIER {
+		// async, see isAsyncFunction
+		node.Async = true
+		self.next()
+	}
+	self.expect(token.FUNCTION)
+	if self.token == token.MULTIPLY {
+		if node.Async {
+			self.error(self.idx, "Async generators are not supported")
+		}
+		node.Generator = true
+		self.next()
 	}
 
 	var name *ast.Identifier
@@ -201,7 +244,7 @@ func (self *_parser) parseFunction(declaration bool) *ast.FunctionLiteral {
 		self.expect(token.IDENTIFIER)
 	}
 	node.Name = name
-	node.ParameterList = self.parseFunctionParameterList()
+	node.ParameterList = self.parseFunctionParameters(node)
 	self.parseFunctionBlock(node)
 	node.Source = self.slice(node.Idx0(), node.Idx1())
 
@@ -213,6 +256,8 @@ func (self *_parser) parseFunctionBlock(node *ast.FunctionLiteral) {
 		self.openScope()
 		inFunction := self.scope.inFunction
 		self.scope.inFunction = true
+		self.scope.inGenerator = node.Generator
+		self.scope.inAsync = node.Async
 		defer func() {
 			self.scope.inFunction = inFunction
 			self.closeScope()
@@ -222,6 +267,133 @@ func (self *_parser) parseFunctionBlock(node *ast.FunctionLiteral) {
 	}
 }
 
+// parseFunctionParameters parses the parameters of a function. Those of a generator or an async function are parsed
+// as if they were in its body, so that the compiler can reject a yield or an await expression in them.
+func (self *_parser) parseFunctionParameters(node *ast.FunctionLiteral) *ast.ParameterList {
+	inGenerator, inAsync := self.scope.inGenerator, self.scope.inAsync
+	self.scope.inGenerator, self.scope.inAsync = node.Generator, node.Async
+	defer func() {
+		self.scope.inGenerator, self.scope.inAsync = inGenerator, inAsync
+	}()
+	return self.parseFunctionParameterList()
+}
+
+// isAsyncFunction reports whether the current token is the async modifier of a function declaration or expression.
+func (self *_parser) isAsyncFunction() bool {
+	if self.token != token.IDENTIFIER || self.literal != "async" {
+		return false
+	}
+	state := self.mark()
+	defer self.restore(state)
+	self.next()
+	// no line terminator is allowed between async and function
+	return self.token == token.FUNCTION && !self.implicitSemicolon
+}
+
+func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
+	node := &ast.ClassLiteral{
+		Class: self.expect(token.CLASS),
+	}
+
+	if self.token == token.IDENTIFIER {
+		node.Name = self.parseIdentifier()
+	} else if declaration {
+		// Use expect error handling
+		self.expect(token.IDENTIFIER)
+	}
+
+	if self.token == token.EXTENDS {
+		self.next()
+		node.SuperClass = self.parseLeftHandSideExpressionAllowCall()
+	}
+
+	self.expect(token.LEFT_BRACE)
+	hasConstructor := false
+	for self.token != token.RIGHT_BRACE && self.token != token.EOF {
+		if self.token == token.SEMICOLON {
+			self.next()
+			continue
+		}
+		idx := self.idx
+		method := self.parseMethodDefinition()
+		if method.Kind == "constructor" {
+			if hasConstructor {
+				self.error(idx, "A class may only have one constructor")
+			}
+			hasConstructor = true
+		}
+		node.Body = append(node.Body, method)
+	}
+	node.RightBrace = self.expect(token.RIGHT_BRACE)
+	node.Source = self.slice(node.Idx0(), node.Idx1())
+
+	return node
+}
+
+// parseMethodDefinition parses a method of a class body, including the static, get, set and async prefixes and the *
+// of a generator method. Like in
+// object literals only identifiers, strings and numbers are supported as keys.
+func (self *_parser) parseMethodDefinition() *ast.MethodDefinition {
+	method := &ast.MethodDefinition{
+		Kind: "method",
+	}
+
+	idx, keyToken := self.idx, self.token
+	literal, value := self.parseObjectPropertyKey()
+	if keyToken == token.IDENTIFIER && literal == "static" && self.token != token.LEFT_PARENTHESIS {
+		method.Static = true
+		idx, keyToken = self.idx, self.token
+		literal, value = self.parseObjectPropertyKey()
+	}
+	async := false
+	if keyToken == token.IDENTIFIER && literal == "async" && self.token != token.LEFT_PARENTHESIS && !self.implicitSemicolon {
+		async = true
+		idx, keyToken = self.idx, self.token
+		literal, value = self.parseObjectPropertyKey()
+	}
+	generator := false
+	if keyToken == token.MULTIPLY {
+		if async {
+			self.error(idx, "Async generators are not supported")
+		}
+		generator = true
+		idx = self.idx
+		literal, value = self.parseObjectPropertyKey()
+	} else if keyToken == token.IDENTIFIER && (literal == "get" || literal == "set") && self.token != token.LEFT_PARENTHESIS {
+		method.Kind = literal
+		literal, value = self.parseObjectPropertyKey()
+	}
+	method.Key = value
+
+	if !method.Static && value == "constructor" {
+		if method.Kind != "method" {
+			self.error(idx, "Class constructor may not be an accessor")
+		}
+		if generator {
+			self.error(idx, "Class constructor may not be a generator")
+		}
+		if async {
+			self.error(idx, "Class constructor may not be an async method")
+		}
+		method.Kind = "constructor"
+	}
+	if method.Static && value == "prototype" {
+		self.error(idx, "Classes may not have a static property named 'prototype'")
+	}
+
+	node := &ast.FunctionLiteral{
+		Function:  idx,
+		Generator: generator,
+		Async:     async,
+	}
+	node.ParameterList = self.parseFunctionParameters(node)
+	self.parseFunctionBlock(node)
+	node.Source = self.slice(node.Idx0(), node.Idx1())
+	method.Body = node
+
+	return method
+}
+
 func (self *_parser) parseDebuggerStatement() ast.Statement {
 	idx := self.expect(token.DEBUGGER)
 
@@ -347,7 +519,7 @@ func (self *_parser) parseCaseStatement() *ast.CaseStatement {
 			self.token == token.DEFAULT {
 			break
 		}
-		node.Consequent = append(node.Consequent, self.parseStatement())
+		node.Consequent = append(node.Consequent, self.parseSourceElement())
 
 	}
 
@@ -378,6 +550,26 @@ func (self *_parser) parseForIn(idx file.Idx, into ast.Expression) *ast.ForInSta
 	}
 }
 
+func (self *_parser) parseForOf(idx file.Idx, into ast.Expression) *ast.ForOfStatement {
+
+	// Already have consumed "<into> of"
+
+	source := self.parseAssignmentExpression()
+	self.expect(token.RIGHT_PARENTHESIS)
+
+	return &ast.ForOfStatement{
+		For:    idx,
+		Into:   into,
+		Source: source,
+		Body:   self.parseIterationStatement(),
+	}
+}
+
+// isOf reports whether the current token is the contextual keyword "of".
+func (self *_parser) isOf() bool {
+	return self.token == token.IDENTIFIER && self.literal == "of"
+}
+
 func (self *_parser) parseFor(idx file.Idx, initializer ast.Expression) *ast.ForStatement {
 
 	// Already have consumed "<initializer> ;"
@@ -409,12 +601,32 @@ func (self *_parser) parseForOrForInStatement() ast.Statement {
 
 	var left []ast.Expression
 
-	forIn := false
+	forIn, forOf := false, false
 	if self.token != token.SEMICOLON {
 
 		allowIn := self.scope.allowIn
 		self.scope.allowIn = false
-		if self.token == token.VAR {
+		if self.token == token.CONST || self.isLetDeclaration() {
+			decl := self.parseLexicalBindingList()
+			self.scope.allowIn = allowIn
+			if len(decl.List) == 1 && self.token == token.IN {
+				self.next() // in
+				node := self.parseForIn(idx, decl.List[0])
+				node.Lexical = decl
+				return node
+			}
+			if len(decl.List) == 1 && self.isOf() {
+				self.next() // of
+				node := self.parseForOf(idx, decl.List[0])
+				node.Lexical = decl
+				return node
+			}
+			self.checkConstInitializers(decl)
+			self.expect(token.SEMICOLON)
+			node := self.parseFor(idx, nil)
+			node.Lexical = decl
+			return node
+		} else if self.token == token.VAR {
 			var_ := self.idx
 			self.next()
 			list := self.parseVariableDeclarationList(var_)
@@ -422,28 +634,47 @@ func (self *_parser) parseForOrForInStatement() ast.Statement {
 				self.next() // in
 				forIn = true
 				left = []ast.Expression{list[0]} // There is only one declaration
+			} else if len(list) == 1 && self.isOf() {
+				self.next() // of
+				forOf = true
+				left = []ast.Expression{list[0]}
 			} else {
+				self.checkDestructuringInitializers(list)
 				left = list
 			}
 		} else {
-			left = append(left, self.parseExpression())
-			if self.token == token.IN {
+			expr := self.parseExpression()
+			if self.token == token.IN || self.isOf() {
+				forIn = self.token == token.IN
+				forOf = !forIn
 				self.next()
-				forIn = true
+				switch expr.(type) {
+				case *ast.ArrayLiteral, *ast.ObjectLiteral:
+					expr = self.toPattern(expr, false)
+				}
 			}
+			left = append(left, expr)
 		}
 		self.scope.allowIn = allowIn
 	}
 
-	if forIn {
+	if forIn || forOf {
 		switch left[0].(type) {
-		case *ast.Identifier, *ast.DotExpression, *ast.BracketExpression, *ast.VariableExpression:
+		case *ast.Identifier, *ast.DotExpression, *ast.BracketExpression, *ast.VariableExpression,
+			*ast.ArrayPattern, *ast.ObjectPattern:
 			// These are all acceptable
 		default:
-			self.error(idx, "Invalid left-hand side in for-in")
+			if forIn {
+				self.error(idx, "Invalid left-hand side in for-in")
+			} else {
+				self.error(idx, "Invalid left-hand side in for-of")
+			}
 			self.nextStatement()
 			return &ast.BadStatement{From: idx, To: self.idx}
 		}
+		if forOf {
+			return self.parseForOf(idx, left[0])
+		}
 		return self.parseForIn(idx, left[0])
 	}
 
@@ -456,6 +687,7 @@ func (self *_parser) parseVariableStatement() *ast.VariableStatement {
 	idx := self.expect(token.VAR)
 
 	list := self.parseVariableDeclarationList(idx)
+	self.checkDestructuringInitializers(list)
 	self.semicolon()
 
 	return &ast.VariableStatement{
@@ -464,6 +696,67 @@ func (self *_parser) parseVariableStatement() *ast.VariableStatement {
 	}
 }
 
+// isLetDeclaration reports whether the current "let" identifier starts a lexical declaration rather than
+// being used as an identifier (which is allowed in non-strict code).
+func (self *_parser) isLetDeclaration() bool {
+	if self.token != token.IDENTIFIER || self.literal != "let" {
+		return false
+	}
+	state := self.mark()
+	self.next()
+	res := self.token == token.IDENTIFIER || self.token == token.LEFT_BRACKET || self.token == token.LEFT_BRACE
+	self.restore(state)
+	return res
+}
+
+func (self *_parser) parseLexicalBindingList() *ast.LexicalDeclaration {
+	node := &ast.LexicalDeclaration{
+		Idx:   self.idx,
+		Token: token.LET,
+	}
+	if self.token == token.CONST {
+		node.Token = token.CONST
+	}
+	self.next()
+
+	for {
+		if self.token == token.IDENTIFIER && self.literal == "let" {
+			self.error(self.idx, "let is disallowed as a lexically bound name")
+		}
+		self.parseVariableDeclaration(&node.List)
+		if self.token != token.COMMA {
+			break
+		}
+		self.next()
+	}
+	if len(node.List) == 0 {
+		node.List = append(node.List, &ast.VariableExpression{Idx: node.Idx})
+	}
+
+	return node
+}
+
+func (self *_parser) checkConstInitializers(node *ast.LexicalDeclaration) {
+	for _, item := range node.List {
+		if item.Initializer != nil {
+			continue
+		}
+		if item.Target != nil {
+			self.error(item.Idx, "Missing initializer in destructuring declaration")
+		} else if node.Token == token.CONST {
+			self.error(item.Idx, "Missing initializer in const declaration")
+		}
+	}
+}
+
+func (self *_parser) parseLexicalDeclaration() *ast.LexicalDeclaration {
+	node := self.parseLexicalBindingList()
+	self.checkConstInitializers(node)
+	self.semicolon()
+
+	return node
+}
+
 func (self *_parser) parseDoWhileStatement() ast.Statement {
 	inIteration := self.scope.inIteration
 	self.scope.inIteration = true
@@ -522,73 +815,356 @@ func (self *_parser) parseIfStatement() ast.Statement {
 }
 
 func (self *_parser) parseSourceElement() ast.Statement {
+	if self.token == token.CONST || self.isLetDeclaration() {
+		return self.parseLexicalDeclaration()
+	}
+	if self.token == token.CLASS {
+		return &ast.ClassDeclaration{
+			Class: self.parseClass(true),
+		}
+	}
 	return self.parseStatement()
 }
 
 func (self *_parser) parseSourceElements() []ast.Statement {
 	body := []ast.Statement(nil)
+	parseElement := self.parseSourceElement
+	if self.mode&Module != 0 {
+		parseElement = self.parseModuleItem
+	}
 
 	for {
 		if self.token != token.STRING {
 			break
 		}
 
-		body = append(body, self.parseSourceElement())
+		body = append(body, parseElement())
 	}
 
 	for self.token != token.EOF {
-		body = append(body, self.parseSourceElement())
+		body = append(body, parseElement())
 	}
 
 	return body
 }
 
+// parseModuleItem parses a statement at the top level of a module, which may also be an import or an export
+// declaration.
+func (self *_parser) parseModuleItem() ast.Statement {
+	switch self.token {
+	case token.IMPORT:
+		state := self.mark()
+		self.next()
+		isCall := self.token == token.LEFT_PARENTHESIS
+		self.restore(state)
+		if !isCall {
+			return self.parseImportDeclaration()
+		}
+	case token.EXPORT:
+		return self.parseExportDeclaration()
+	}
+	return self.parseSourceElement()
+}
+
+func (self *_parser) parseImportDeclaration() *ast.ImportDeclaration {
+	node := &ast.ImportDeclaration{
+		Import: self.expect(token.IMPORT),
+	}
+
+	if self.token != token.STRING {
+		if self.token == token.IDENTIFIER {
+			node.DefaultBinding = self.parseIdentifier()
+			if self.token == token.COMMA {
+				self.next()
+				self.parseImportClause(node)
+			}
+		} else {
+			self.parseImportClause(node)
+		}
+		self.expectContextual("from")
+	}
+	node.ModuleSpecifier = self.parseModuleSpecifier()
+	node.End = self.prevEnd
+	self.semicolon()
+
+	return node
+}
+
+// parseImportClause parses the namespace import or the named imports of an import declaration.
+func (self *_parser) parseImportClause(node *ast.ImportDeclaration) {
+	switch self.token {
+	case token.MULTIPLY:
+		self.next()
+		self.expectContextual("as")
+		node.NamespaceImport = self.parseBindingIdentifier()
+	case token.LEFT_BRACE:
+		self.next()
+		for self.token != token.RIGHT_BRACE && self.token != token.EOF {
+			item := &ast.ImportSpecifier{
+				Name: self.parseModuleExportName(),
+			}
+			if self.isContextual("as") {
+				self.next()
+				item.Alias = self.parseBindingIdentifier()
+			} else {
+				if tkn, _ := token.IsKeyword(item.Name.Name); tkn != 0 {
+					self.error(item.Name.Idx, "Unexpected token %s", item.Name.Name)
+				}
+				item.Alias = item.Name
+			}
+			node.NamedImports = append(node.NamedImports, item)
+			if self.token != token.RIGHT_BRACE {
+				self.expect(token.COMMA)
+			}
+		}
+		self.expect(token.RIGHT_BRACE)
+	default:
+		self.errorUnexpectedToken(self.token)
+		self.next()
+	}
+}
+
+func (self *_parser) parseExportDeclaration() ast.Statement {
+	node := &ast.ExportDeclaration{
+		Export: self.expect(token.EXPORT),
+	}
+
+	switch self.token {
+	case token.MULTIPLY:
+		self.next()
+		node.ExportAll = true
+		if self.isContextual("as") {
+			self.next()
+			node.Namespace = self.parseModuleExportName()
+		}
+		self.expectContextual("from")
+		node.ModuleSpecifier = self.parseModuleSpecifier()
+		node.End = self.prevEnd
+		self.semicolon()
+	case token.LEFT_BRACE:
+		self.next()
+		for self.token != token.RIGHT_BRACE && self.token != token.EOF {
+			item := &ast.ExportSpecifier{
+				Name: self.parseModuleExportName(),
+			}
+			item.Alias = item.Name
+			if self.isContextual("as") {
+				self.next()
+				item.Alias = self.parseModuleExportName()
+			}
+			node.NamedExports = append(node.NamedExports, item)
+			if self.token != token.RIGHT_BRACE {
+				self.expect(token.COMMA)
+			}
+		}
+		self.expect(token.RIGHT_BRACE)
+		if self.isContextual("from") {
+			self.next()
+			node.ModuleSpecifier = self.parseModuleSpecifier()
+		} else {
+			// without a from clause the names refer to the bindings of the module
+			for _, item := range node.NamedExports {
+				if tkn, _ := token.IsKeyword(item.Name.Name); tkn != 0 {
+					self.error(item.Name.Idx, "Unexpected token %s", item.Name.Name)
+				}
+			}
+		}
+		node.End = self.prevEnd
+		self.semicolon()
+	case token.DEFAULT:
+		self.next()
+		node.Default = true
+		switch {
+		case self.token == token.FUNCTION || self.isAsyncFunction():
+			node.Function = self.parseFunction(false)
+			if node.Function.Name == nil {
+				node.Function.Name = &ast.Identifier{Name: "default", Idx: node.Function.Idx0()}
+			}
+			self.scope.declare(&ast.FunctionDeclaration{
+				Function: node.Function,
+			})
+			node.End = node.Function.Idx1()
+		case self.token == token.CLASS:
+			class := self.parseClass(false)
+			if class.Name == nil {
+				class.Name = &ast.Identifier{Name: "default", Idx: class.Class}
+			}
+			node.Statement = &ast.ClassDeclaration{
+				Class: class,
+			}
+			node.End = class.Idx1()
+		default:
+			idx := self.idx
+			node.Statement = &ast.LexicalDeclaration{
+				Idx:   idx,
+				Token: token.LET,
+				List: []*ast.VariableExpression{{
+					Name:        "default",
+					Idx:         idx,
+					Initializer: self.parseAssignmentExpression(),
+				}},
+			}
+			node.End = self.prevEnd
+			self.semicolon()
+		}
+	case token.VAR:
+		node.Statement = self.parseVariableStatement()
+		node.End = self.prevEnd
+	case token.CONST:
+		node.Statement = self.parseLexicalDeclaration()
+		node.End = self.prevEnd
+	case token.CLASS:
+		node.Statement = &ast.ClassDeclaration{
+			Class: self.parseClass(true),
+		}
+		node.End = node.Statement.Idx1()
+	case token.FUNCTION:
+		node.Function = self.parseFunction(true)
+		node.End = node.Function.Idx1()
+	default:
+		if self.isLetDeclaration() {
+			node.Statement = self.parseLexicalDeclaration()
+			node.End = self.prevEnd
+		} else if self.isAsyncFunction() {
+			node.Function = self.parseFunction(true)
+			node.End = node.Function.Idx1()
+		} else {
+			self.errorUnexpectedToken(self.token)
+			self.nextStatement()
+			return &ast.BadStatement{From: node.Export, To: self.idx}
+		}
+	}
+
+	return node
+}
+
+// parseModuleExportName parses the name of an export in an import or export specifier, which may be a keyword.
+func (self *_parser) parseModuleExportName() *ast.Identifier {
+	if !matchIdentifier.MatchString(self.literal) {
+		self.errorUnexpectedToken(self.token)
+	}
+	return self.parseIdentifier()
+}
+
+func (self *_parser) parseBindingIdentifier() *ast.Identifier {
+	if self.token != token.IDENTIFIER {
+		self.errorUnexpectedToken(self.token)
+	}
+	return self.parseIdentifier()
+}
+
+func (self *_parser) parseModuleSpecifier() *ast.StringLiteral {
+	if self.token != token.STRING {
+		idx := self.expect(token.STRING)
+		return &ast.StringLiteral{Idx: idx}
+	}
+	return self.parsePrimaryExpression().(*ast.StringLiteral)
+}
+
+// isContextual reports whether the current token is the identifier name, which has a special meaning in some
+// contexts but is not a keyword (e.g. as and from).
+func (self *_parser) isContextual(name string) bool {
+	return self.token == token.IDENTIFIER && self.literal == name
+}
+
+func (self *_parser) expectContextual(name string) {
+	if !self.isContextual(name) {
+		self.errorUnexpectedToken(self.token)
+	}
+	self.next()
+}
+
 func (self *_parser) parseProgram() *ast.Program {
 	self.openScope()
 	defer self.closeScope()
-	return &ast.Program{
+	prg := &ast.Program{
 		Body:            self.parseSourceElements(),
 		DeclarationList: self.scope.declarationList,
 		File:            self.file,
-		SourceMap:       self.parseSourceMap(),
 	}
+	prg.SourceMap, prg.SourceMapData = self.parseSourceMap()
+	return prg
 }
 
-func (self *_parser) parseSourceMap() *sourcemap.Consumer {
-	lastLine := self.str[strings.LastIndexByte(self.str, '\n') + 1:]
-	if strings.HasPrefix(lastLine, "//# sourceMappingURL") {
-		urlIndex := strings.Index(lastLine, "=")
-		urlStr := lastLine[urlIndex+1:]
-
-		var data []byte
-		if strings.HasPrefix(urlStr, "data:application/json") {
-			b64Index := strings.Index(urlStr, ",")
-			b64 := urlStr[b64Index+1:]
-			if d, err := base64.StdEncoding.DecodeString(b64); err == nil {
-				data = d
+func (self *_parser) parseSourceMap() (*sourcemap.Consumer, []byte) {
+	if self.disableSourceMaps {
+		return nil, nil
+	}
+	src := strings.TrimRight(self.str, " \t\r\n")
+	lineStart := strings.LastIndexByte(src, '\n') + 1
+	lastLine := src[lineStart:]
+	if !strings.HasPrefix(lastLine, "//# sourceMappingURL=") && !strings.HasPrefix(lastLine, "//@ sourceMappingURL=") {
+		return nil, nil
+	}
+	urlStr := strings.TrimSpace(lastLine[len("//# sourceMappingURL="):])
+
+	var data []byte
+	if strings.HasPrefix(urlStr, "data:") {
+		data = decodeDataURL(urlStr)
+	} else if smUrl := resolveSourceMapURL(self.file.Name(), urlStr); smUrl != nil {
+		if self.sourceMapLoader != nil {
+			d, err := self.sourceMapLoader(smUrl.String())
+			if err != nil {
+				self.error(self.idxOf(lineStart), "Could not load source map: %v", err)
+				return nil, nil
 			}
-		} else {
-			if smUrl, err := url.Parse(urlStr); err == nil {
-				if smUrl.Scheme == "" || smUrl.Scheme == "file" {
-					if f, err := os.Open(smUrl.Path); err == nil {
-						if d, err := ioutil.ReadAll(f); err == nil {
-							data = d
-						}
-					}
-				} else {
-					// Not implemented - compile error?
-					return nil
-				}
+			data = d
+		} else if smUrl.Scheme == "" || smUrl.Scheme == "file" {
+			if d, err := ioutil.ReadFile(smUrl.Path); err == nil {
+				data = d
 			}
 		}
+	}
 
-		if data == nil {
-			return nil
-		}
+	if data == nil {
+		return nil, nil
+	}
+
+	if sm, err := sourcemap.Parse(self.file.Name(), data); err == nil {
+		return sm, data
+	}
+	return nil, nil
+}
+
+// resolveSourceMapURL resolves the URL of a sourceMappingURL comment relatively to the name of the source file.
+func resolveSourceMapURL(filename, ref string) *url.URL {
+	smUrl, err := url.Parse(ref)
+	if err != nil {
+		return nil
+	}
+	if smUrl.IsAbs() || path.IsAbs(smUrl.Path) {
+		return smUrl
+	}
+	base, err := url.Parse(filename)
+	if err != nil {
+		return smUrl
+	}
+	base.Path = path.Join(path.Dir(base.Path), smUrl.Path)
+	base.RawPath = ""
+	base.RawQuery = smUrl.RawQuery
+	base.Fragment = ""
+	return base
+}
 
-		if sm, err := sourcemap.Parse(self.file.Name(), data); err == nil {
-			return sm
+// decodeDataURL returns the data of a JSON data: URL, or nil if it is not one or it is malformed.
+func decodeDataURL(urlStr string) []byte {
+	comma := strings.IndexByte(urlStr, ',')
+	if comma == -1 {
+		return nil
+	}
+	header, payload := urlStr[len("data:"):comma], urlStr[comma+1:]
+	params := strings.Split(header, ";")
+	if params[0] != "application/json" {
+		return nil
+	}
+	if params[len(params)-1] == "base64" {
+		if d, err := base64.StdEncoding.DecodeString(payload); err == nil {
+			return d
 		}
+		return nil
+	}
+	if d, err := url.PathUnescape(payload); err == nil {
+		return []byte(d)
 	}
 	return nil
 }
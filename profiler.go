@@ -0,0 +1,450 @@
+package goja
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// DefaultCPUProfileInterval is the sampling interval used by Runtime.StartCPUProfile() if none is specified.
+const DefaultCPUProfileInterval = time.Millisecond
+
+const profileProgramName = "(program)"
+
+// profileCheckInstructions is the number of instructions executed between the checks of the time by the profiler.
+const profileCheckInstructions = 128
+
+var errCPUProfileInUse = errors.New("CPU profiling is already enabled")
+
+type profileFuncKey struct {
+	prg  *Program
+	name string
+}
+
+type profileFunc struct {
+	id   int
+	name string
+	src  *SrcFile
+	// the position of the function in the source, 1-based, 0 if unknown
+	line, col int
+}
+
+type profileNodeKey struct {
+	fn   *profileFunc
+	line int
+}
+
+// profileNode is a node of the call tree. A function has a node for each of the lines it has been sampled at, so
+// that the callers' nodes point to the lines of the calls.
+type profileNode struct {
+	id       int
+	fn       *profileFunc
+	line     int
+	hits     int
+	children map[profileNodeKey]*profileNode
+	order    []*profileNode
+}
+
+// CPUProfile is the result of the sampling of a Runtime, see Runtime.StartCPUProfile().
+type CPUProfile struct {
+	interval   time.Duration
+	start, end time.Time
+
+	funcs []*profileFunc
+	nodes []*profileNode // nodes[0] is the root
+
+	samples []*profileNode
+	times   []time.Duration // since start
+}
+
+type cpuProfiler struct {
+	// the time the next sample is due and the number of instructions until the time is checked again
+	next      time.Time
+	countdown int
+
+	profile *CPUProfile
+	funcs   map[profileFuncKey]*profileFunc
+	program *profileNode
+	frames  []StackFrame
+}
+
+// StartCPUProfile starts sampling the call stack of the JavaScript code executed by the Runtime every interval
+// (DefaultCPUProfileInterval if it is 0) until StopCPUProfile() is called.
+//
+// The samples are taken by the goroutine executing the code, between the instructions and when the native functions
+// return, each sample standing for one interval. The time spent in a native function is attributed to it, the time
+// during which no JavaScript code is executed is attributed to the "(program)" node.
+//
+// An error is returned if the profiling is already enabled. This method is not safe for concurrent use and should
+// only be called from the goroutine that runs the Runtime or while it is not running.
+func (r *Runtime) StartCPUProfile(interval time.Duration) error {
+	if r.vm.prof != nil {
+		return errCPUProfileInUse
+	}
+	if interval <= 0 {
+		interval = DefaultCPUProfileInterval
+	}
+	start := time.Now()
+	p := &cpuProfiler{
+		next: start.Add(interval),
+		profile: &CPUProfile{
+			interval: interval,
+			start:    start,
+			nodes: []*profileNode{{
+				id: 1,
+				fn: &profileFunc{name: "(root)"},
+			}},
+		},
+		funcs: make(map[profileFuncKey]*profileFunc),
+	}
+	p.program = p.child(p.profile.nodes[0], p.function(profileFuncKey{name: profileProgramName}, nil), 0)
+	r.vm.prof = p
+	return nil
+}
+
+// StopCPUProfile stops the profiling started by StartCPUProfile() and returns the result, or nil if the profiling
+// is not enabled.
+func (r *Runtime) StopCPUProfile() *CPUProfile {
+	p := r.vm.prof
+	if p == nil {
+		return nil
+	}
+	r.vm.prof = nil
+	now := time.Now()
+	p.fill(p.program, now)
+	if !now.Before(p.next) {
+		p.add(p.program, p.next)
+	}
+	p.profile.end = now
+	return p.profile
+}
+
+// step is called by the vm before each instruction.
+func (p *cpuProfiler) step(vm *vm) {
+	if p.countdown > 0 {
+		p.countdown--
+		return
+	}
+	p.countdown = profileCheckInstructions
+	now := time.Now()
+	if now.Before(p.next) {
+		return
+	}
+	// the samples which were due before were missed while the vm was not executing JavaScript code
+	p.fill(p.program, now)
+	p.add(p.stackNode(vm), now)
+	p.next = now.Add(p.profile.interval)
+}
+
+// leaveNative is called by the vm when a native function returns, while its frame is still on the stack. The samples
+// missed during the call are attributed to the function.
+func (p *cpuProfiler) leaveNative(vm *vm) {
+	now := time.Now()
+	if now.Before(p.next) {
+		return
+	}
+	node := p.stackNode(vm)
+	p.fill(node, now)
+	p.add(node, now)
+	p.next = now.Add(p.profile.interval)
+}
+
+// fill adds a sample of node for each of the intervals which have fully elapsed since a sample was due.
+func (p *cpuProfiler) fill(node *profileNode, now time.Time) {
+	for now.Sub(p.next) >= p.profile.interval {
+		p.add(node, p.next)
+		p.next = p.next.Add(p.profile.interval)
+	}
+}
+
+// stackNode returns the node of the current call stack.
+func (p *cpuProfiler) stackNode(vm *vm) *profileNode {
+	p.frames = vm.captureStack(p.frames[:0], 0)
+	node := p.profile.nodes[0]
+	for i := len(p.frames) - 1; i >= 0; i-- {
+		f := &p.frames[i]
+		key := profileFuncKey{prg: f.prg}
+		if f.prg == nil {
+			key.name = f.funcName
+		}
+		fn := p.function(key, f)
+		node = p.child(node, fn, f.Position().Line)
+	}
+	return node
+}
+
+func (p *cpuProfiler) add(node *profileNode, t time.Time) {
+	node.hits++
+	p.profile.samples = append(p.profile.samples, node)
+	p.profile.times = append(p.profile.times, t.Sub(p.profile.start))
+}
+
+func (p *cpuProfiler) function(key profileFuncKey, f *StackFrame) *profileFunc {
+	if fn := p.funcs[key]; fn != nil {
+		return fn
+	}
+	fn := &profileFunc{
+		id:   len(p.profile.funcs) + 1,
+		name: key.name,
+	}
+	if f != nil {
+		fn.name = f.funcName
+		if prg := f.prg; prg != nil {
+			// the frame's name is only reliable for the native functions
+			fn.name = prg.funcName
+			fn.src = prg.src
+			if prg.src != nil {
+				pos := prg.src.Position(prg.srcStart)
+				fn.line, fn.col = pos.Line, pos.Col
+			}
+		}
+	}
+	p.funcs[key] = fn
+	p.profile.funcs = append(p.profile.funcs, fn)
+	return fn
+}
+
+func (p *cpuProfiler) child(parent *profileNode, fn *profileFunc, line int) *profileNode {
+	key := profileNodeKey{fn: fn, line: line}
+	if node := parent.children[key]; node != nil {
+		return node
+	}
+	node := &profileNode{
+		id:   len(p.profile.nodes) + 1,
+		fn:   fn,
+		line: line,
+	}
+	if parent.children == nil {
+		parent.children = make(map[profileNodeKey]*profileNode)
+	}
+	parent.children[key] = node
+	parent.order = append(parent.order, node)
+	p.profile.nodes = append(p.profile.nodes, node)
+	return node
+}
+
+// SampleCount returns the number of samples in the profile.
+func (p *CPUProfile) SampleCount() int {
+	return len(p.samples)
+}
+
+type devtoolsCallFrame struct {
+	FunctionName string `json:"functionName"`
+	ScriptId     string `json:"scriptId"`
+	URL          string `json:"url"`
+	LineNumber   int    `json:"lineNumber"`
+	ColumnNumber int    `json:"columnNumber"`
+}
+
+type devtoolsPositionTicks struct {
+	Line  int `json:"line"`
+	Ticks int `json:"ticks"`
+}
+
+type devtoolsProfileNode struct {
+	Id            int                     `json:"id"`
+	CallFrame     devtoolsCallFrame       `json:"callFrame"`
+	HitCount      int                     `json:"hitCount"`
+	Children      []int                   `json:"children,omitempty"`
+	PositionTicks []devtoolsPositionTicks `json:"positionTicks,omitempty"`
+}
+
+type devtoolsProfile struct {
+	Nodes      []devtoolsProfileNode `json:"nodes"`
+	StartTime  int64                 `json:"startTime"`
+	EndTime    int64                 `json:"endTime"`
+	Samples    []int                 `json:"samples"`
+	TimeDeltas []int64               `json:"timeDeltas"`
+}
+
+// WriteTo writes the profile in the format of the .cpuprofile files which can be loaded into the Performance panel
+// of the Chrome DevTools.
+func (p *CPUProfile) WriteTo(w io.Writer) (int64, error) {
+	scripts := make(map[*SrcFile]string)
+	out := devtoolsProfile{
+		Nodes:      make([]devtoolsProfileNode, 0, len(p.nodes)),
+		StartTime:  p.start.UnixNano() / int64(time.Microsecond),
+		EndTime:    p.end.UnixNano() / int64(time.Microsecond),
+		Samples:    make([]int, 0, len(p.samples)),
+		TimeDeltas: make([]int64, 0, len(p.times)),
+	}
+	for _, node := range p.nodes {
+		frame := devtoolsCallFrame{
+			FunctionName: node.fn.name,
+			ScriptId:     "0",
+			LineNumber:   node.fn.line - 1,
+			ColumnNumber: node.fn.col - 1,
+		}
+		if src := node.fn.src; src != nil {
+			id, exists := scripts[src]
+			if !exists {
+				id = strconv.Itoa(len(scripts) + 1)
+				scripts[src] = id
+			}
+			frame.ScriptId = id
+			frame.URL = src.name
+		}
+		n := devtoolsProfileNode{
+			Id:        node.id,
+			CallFrame: frame,
+			HitCount:  node.hits,
+		}
+		for _, child := range node.order {
+			n.Children = append(n.Children, child.id)
+		}
+		if node.hits > 0 && node.line > 0 {
+			n.PositionTicks = []devtoolsPositionTicks{{Line: node.line, Ticks: node.hits}}
+		}
+		out.Nodes = append(out.Nodes, n)
+	}
+	var last time.Duration
+	for i, node := range p.samples {
+		out.Samples = append(out.Samples, node.id)
+		out.TimeDeltas = append(out.TimeDeltas, int64((p.times[i]-last)/time.Microsecond))
+		last = p.times[i]
+	}
+	cw := &countingWriter{w: w}
+	err := json.NewEncoder(cw).Encode(&out)
+	return cw.n, err
+}
+
+// WritePprof writes the profile in the gzip-compressed protocol buffer format of pprof, so that it can be analysed
+// with 'go tool pprof'.
+func (p *CPUProfile) WritePprof(w io.Writer) error {
+	strs := map[string]int{"": 0}
+	strList := []string{""}
+	str := func(s string) uint64 {
+		if idx, exists := strs[s]; exists {
+			return uint64(idx)
+		}
+		idx := len(strList)
+		strs[s] = idx
+		strList = append(strList, s)
+		return uint64(idx)
+	}
+
+	var b, m protoBuffer
+	valueType := func(field int, typ, unit string) {
+		m = m[:0]
+		m.uint64Field(1, str(typ))
+		m.uint64Field(2, str(unit))
+		b.messageField(field, m)
+	}
+	// sample_type
+	valueType(1, "samples", "count")
+	valueType(1, "cpu", "nanoseconds")
+
+	// sample, the location ids are the node ids
+	var stack []uint64
+	interval := int64(p.interval)
+	var walk func(node *profileNode)
+	walk = func(node *profileNode) {
+		stack = append(stack, uint64(node.id))
+		if node.hits > 0 {
+			m = m[:0]
+			ids := make([]uint64, len(stack))
+			for i, id := range stack {
+				ids[len(stack)-1-i] = id
+			}
+			m.packedField(1, ids)
+			m.packedField(2, []uint64{uint64(node.hits), uint64(int64(node.hits) * interval)})
+			b.messageField(2, m)
+		}
+		for _, child := range node.order {
+			walk(child)
+		}
+		stack = stack[:len(stack)-1]
+	}
+	for _, child := range p.nodes[0].order {
+		walk(child)
+	}
+
+	// location
+	var line protoBuffer
+	for _, node := range p.nodes[1:] {
+		m = m[:0]
+		m.uint64Field(1, uint64(node.id))
+		line = line[:0]
+		line.uint64Field(1, uint64(node.fn.id))
+		line.uint64Field(2, uint64(node.line))
+		m.messageField(4, line)
+		b.messageField(4, m)
+	}
+
+	// function
+	for _, fn := range p.funcs {
+		m = m[:0]
+		name := fn.name
+		if name == "" {
+			name = "(anonymous)"
+		}
+		m.uint64Field(1, uint64(fn.id))
+		m.uint64Field(2, str(name))
+		m.uint64Field(3, str(name))
+		if fn.src != nil {
+			m.uint64Field(4, str(fn.src.name))
+		}
+		m.uint64Field(5, uint64(fn.line))
+		b.messageField(5, m)
+	}
+
+	// time_nanos, duration_nanos, period_type, period
+	b.uint64Field(9, uint64(p.start.UnixNano()))
+	b.uint64Field(10, uint64(p.end.Sub(p.start)))
+	valueType(11, "cpu", "nanoseconds")
+	b.uint64Field(12, uint64(interval))
+
+	// string_table
+	for _, s := range strList {
+		b.bytesField(6, []byte(s))
+	}
+
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+	bw.Write(b)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// protoBuffer is a minimal encoder of the protocol buffers wire format.
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		*b = append(*b, byte(x)|0x80)
+		x >>= 7
+	}
+	*b = append(*b, byte(x))
+}
+
+func (b *protoBuffer) uint64Field(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.varint(uint64(field) << 3)
+	b.varint(x)
+}
+
+func (b *protoBuffer) bytesField(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+func (b *protoBuffer) messageField(field int, m protoBuffer) {
+	b.bytesField(field, m)
+}
+
+func (b *protoBuffer) packedField(field int, xs []uint64) {
+	var data protoBuffer
+	for _, x := range xs {
+		data.varint(x)
+	}
+	b.bytesField(field, data)
+}
@@ -0,0 +1,154 @@
+package goja
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestCPUProfile(t *testing.T) {
+	const SCRIPT = `
+	function hot(n) {
+		var x = 0;
+		for (var i = 0; i < n; i++) {
+			x += i % 7;
+		}
+		return x;
+	}
+	function run(ms) {
+		var start = Date.now();
+		while (Date.now() - start < ms) {
+			hot(1000);
+		}
+	}
+	`
+	r := New()
+	if _, err := r.RunScript("profiled.js", SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	run, _ := AssertFunction(r.Get("run"))
+
+	if err := r.StartCPUProfile(100 * time.Microsecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.StartCPUProfile(0); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := run(nil, r.ToValue(50)); err != nil {
+		t.Fatal(err)
+	}
+	p := r.StopCPUProfile()
+	if r.StopCPUProfile() != nil {
+		t.Fatal("The profiling has not been stopped")
+	}
+	if p.SampleCount() == 0 {
+		t.Fatal("No samples")
+	}
+
+	var buf bytes.Buffer
+	if _, err := p.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var profile struct {
+		Nodes []struct {
+			Id        int `json:"id"`
+			CallFrame struct {
+				FunctionName string `json:"functionName"`
+				URL          string `json:"url"`
+				LineNumber   int    `json:"lineNumber"`
+			} `json:"callFrame"`
+			HitCount      int   `json:"hitCount"`
+			Children      []int `json:"children"`
+			PositionTicks []struct {
+				Line  int `json:"line"`
+				Ticks int `json:"ticks"`
+			} `json:"positionTicks"`
+		} `json:"nodes"`
+		Samples    []int   `json:"samples"`
+		TimeDeltas []int64 `json:"timeDeltas"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &profile); err != nil {
+		t.Fatal(err)
+	}
+	if len(profile.Samples) != p.SampleCount() || len(profile.TimeDeltas) != len(profile.Samples) {
+		t.Fatalf("Samples: %d, time deltas: %d", len(profile.Samples), len(profile.TimeDeltas))
+	}
+	hits := make(map[string]int)
+	for _, node := range profile.Nodes {
+		hits[node.CallFrame.FunctionName] += node.HitCount
+		if node.CallFrame.FunctionName == "hot" {
+			if node.CallFrame.URL != "profiled.js" || node.CallFrame.LineNumber != 1 {
+				t.Fatalf("Unexpected call frame: %+v", node.CallFrame)
+			}
+			for _, ticks := range node.PositionTicks {
+				if ticks.Line < 2 || ticks.Line > 7 {
+					t.Fatalf("Unexpected line: %d", ticks.Line)
+				}
+			}
+		}
+	}
+	if hits["hot"] == 0 || hits["hot"] < hits["run"] {
+		t.Fatalf("Unexpected hits: %v", hits)
+	}
+
+	buf.Reset()
+	if err := p.WritePprof(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"hot", "run", "profiled.js", "nanoseconds"} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Fatalf("%q is missing from the pprof profile", s)
+		}
+	}
+}
+
+func TestCPUProfileNativeCall(t *testing.T) {
+	const interval = time.Millisecond
+	r := New()
+	r.Set("block", func(ms int) {
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+	})
+	if _, err := r.RunString("function f() { block(40); }"); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := AssertFunction(r.Get("f"))
+
+	if err := r.StartCPUProfile(interval); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f(nil); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	p := r.StopCPUProfile()
+
+	hits := make(map[string]int)
+	total := 0
+	for _, node := range p.nodes {
+		name := node.fn.name
+		if node.fn.src == nil && name != profileProgramName && name != "(root)" {
+			name = "native"
+		}
+		hits[name] += node.hits
+		total += node.hits
+	}
+	// a sample stands for an interval, the Go function and the time after the call get their share. The sleeps
+	// may only last longer on a loaded machine, so only the lower bounds are checked.
+	if hits["native"] < 35 || hits[profileProgramName] < 15 || hits["f"] >= hits["native"] {
+		t.Fatalf("Unexpected hits: %v", hits)
+	}
+	if n := p.SampleCount(); n != total || n > int(p.end.Sub(p.start)/interval)+1 {
+		t.Fatalf("Unexpected sample count %d for %v", n, p.end.Sub(p.start))
+	}
+}
@@ -5,36 +5,71 @@ import (
 	"bytes"
 	"io"
 	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/go-sourcemap/sourcemap"
 )
 
 const prgMagic uint32 = 0xFFEEDD00
 
+// prgFormatVersion must be incremented whenever the serialized representation changes, including changes
+// to the opcode numbering or to the instruction operands.
+const prgFormatVersion uint16 = 10
+
 var ErrNoCacheFile = errors.New("magic number is not a goja cache file")
 var ErrVersionNotMatching = errors.New("requested version of the cache file is not matching")
+var ErrCorruptedProgram = errors.New("cache file is corrupted")
 
 type opcode uint8
 
 const (
 	op_add              opcode = iota
 	op_and
+	op_appendArray
+	op_appendArraySpread
+	op_await
 	op_bnot
 	op_boxThis
+	op_callSpread
+	op_checkDerivedRet
+	op_copyBlockScope
+	op_copySpread
+	op_createArgsRestStash
+	op_createGenerator
+	op_debugger
 	op_dec
 	op_deleteElem
 	op_deleteElemStrict
+	op_destructObj
 	op_div
 	op_dup
 	op_enterWith
 	op_enumGet
 	op_enumPop
+	op_enumPopClose
 	op_enumerate
 	op_getElem
 	op_getElemCallee
+	op_getSuperElem
 	op_getValue
 	op_halt
+	op_importCall
 	op_inc
+	op_iterGetNextOrUndef
+	op_iterGetRest
+	op_iterate
+	op_leaveBlockScope
+	op_leaveFinally
+	op_leaveTry
 	op_leaveWith
 	op_loadCallee
+	op_loadDerivedThis
 	op_loadGlobalObject
 	op_loadNil
 	op_loadUndef
@@ -42,7 +77,10 @@ const (
 	op_mul
 	op_neg
 	op_new
+	op_newClass
+	op_newDerivedClass
 	op_newObject
+	op_newSpread
 	op_newStash
 	op_noop
 	op_not
@@ -61,7 +99,6 @@ const (
 	op_pop
 	op_putValue
 	op_ret
-	op_retFinally
 	op_retStashless
 	op_sal
 	op_sar
@@ -69,24 +106,38 @@ const (
 	op_setElemStrict
 	op_setProto
 	op_shr
+	op_startAsync
 	op_sub
+	op_superCallSpread
 	op_swap
 	op_throw
+	op_throwConstAssignment
 	op_toNumber
 	op_typeof
 	op_xor
+	op_yield
+	op_yieldDelegateStart
 
+	opBindGlobalLexical
 	opBindName
 	opCall
 	opCallEval
 	opCallEvalStrict
+	opCheckInit
+	opConcatStrings
+	opCopyRest
 	opCreateArgs
 	opCreateArgsStrict
+	opCreateArgsRestStack
+	opDefineGetter
+	opDefineMethod
+	opDefineSetter
 	opDeleteGlobal
 	opDeleteProp
 	opDeletePropStrict
 	opDeleteVar
 	opDupN
+	opEnterBlockScope
 	opEnterCatch
 	opEnterFunc
 	opEnterFuncStashless
@@ -94,9 +145,13 @@ const (
 	opGetLocal
 	opGetProp
 	opGetPropCallee
+	opGetSuper
+	opGetTemplateObject
 	opGetVar
 	opGetVar1
 	opGetVar1Callee
+	opInitGlobalLexical
+	opIterNext
 	opJeq
 	opJeq1
 	opJne
@@ -126,7 +181,10 @@ const (
 	opSetVarStrict
 	opStoreStack
 	opStoreStackP
+	opSuperCall
 	opTry
+	opYieldDelegate
+	opYieldResume
 )
 
 func (_newStash) opcode() opcode {
@@ -245,6 +303,10 @@ func (_halt) opcode() opcode {
 	return op_halt
 }
 
+func (_debugger) opcode() opcode {
+	return op_debugger
+}
+
 func (jump) opcode() opcode {
 	return opJump
 }
@@ -521,8 +583,12 @@ func (t try) opcode() opcode {
 	return opTry
 }
 
-func (_retFinally) opcode() opcode {
-	return op_retFinally
+func (_leaveTry) opcode() opcode {
+	return op_leaveTry
+}
+
+func (_leaveFinally) opcode() opcode {
+	return op_leaveFinally
 }
 
 func (enterCatch) opcode() opcode {
@@ -573,16 +639,370 @@ func (_enumPop) opcode() opcode {
 	return op_enumPop
 }
 
+func (_enumPopClose) opcode() opcode {
+	return op_enumPopClose
+}
+
+func (_iterate) opcode() opcode {
+	return op_iterate
+}
+
+func (iterNext) opcode() opcode {
+	return opIterNext
+}
+
+func (_iterGetNextOrUndef) opcode() opcode {
+	return op_iterGetNextOrUndef
+}
+
+func (_iterGetRest) opcode() opcode {
+	return op_iterGetRest
+}
+
+func (_destructObj) opcode() opcode {
+	return op_destructObj
+}
+
+func (copyRest) opcode() opcode {
+	return opCopyRest
+}
+
+func (_copySpread) opcode() opcode {
+	return op_copySpread
+}
+
+func (_appendArray) opcode() opcode {
+	return op_appendArray
+}
+
+func (_appendArraySpread) opcode() opcode {
+	return op_appendArraySpread
+}
+
+func (_callSpread) opcode() opcode {
+	return op_callSpread
+}
+
+func (_newSpread) opcode() opcode {
+	return op_newSpread
+}
+
+func (_createArgsRestStash) opcode() opcode {
+	return op_createArgsRestStash
+}
+
+func (createArgsRestStack) opcode() opcode {
+	return opCreateArgsRestStack
+}
+
+func (bindGlobalLexical) opcode() opcode {
+	return opBindGlobalLexical
+}
+
+func (initGlobalLexical) opcode() opcode {
+	return opInitGlobalLexical
+}
+
+func (enterBlockScope) opcode() opcode {
+	return opEnterBlockScope
+}
+
+func (_leaveBlockScope) opcode() opcode {
+	return op_leaveBlockScope
+}
+
+func (_copyBlockScope) opcode() opcode {
+	return op_copyBlockScope
+}
+
+func (concatStrings) opcode() opcode {
+	return opConcatStrings
+}
+
+func (*getTemplateObject) opcode() opcode {
+	return opGetTemplateObject
+}
+
+func (checkInit) opcode() opcode {
+	return opCheckInit
+}
+
+func (_throwConstAssignment) opcode() opcode {
+	return op_throwConstAssignment
+}
+
+func (_newClass) opcode() opcode {
+	return op_newClass
+}
+
+func (_newDerivedClass) opcode() opcode {
+	return op_newDerivedClass
+}
+
+func (defineMethod) opcode() opcode {
+	return opDefineMethod
+}
+
+func (defineGetter) opcode() opcode {
+	return opDefineGetter
+}
+
+func (defineSetter) opcode() opcode {
+	return opDefineSetter
+}
+
+func (_loadDerivedThis) opcode() opcode {
+	return op_loadDerivedThis
+}
+
+func (_checkDerivedRet) opcode() opcode {
+	return op_checkDerivedRet
+}
+
+func (superCall) opcode() opcode {
+	return opSuperCall
+}
+
+func (_superCallSpread) opcode() opcode {
+	return op_superCallSpread
+}
+
+func (getSuper) opcode() opcode {
+	return opGetSuper
+}
+
+func (_getSuperElem) opcode() opcode {
+	return op_getSuperElem
+}
+
+func (_createGenerator) opcode() opcode {
+	return op_createGenerator
+}
+
+func (_yield) opcode() opcode {
+	return op_yield
+}
+
+func (yieldResume) opcode() opcode {
+	return opYieldResume
+}
+
+func (_yieldDelegateStart) opcode() opcode {
+	return op_yieldDelegateStart
+}
+
+func (yieldDelegate) opcode() opcode {
+	return opYieldDelegate
+}
+
+func (_startAsync) opcode() opcode {
+	return op_startAsync
+}
+
+func (_await) opcode() opcode {
+	return op_await
+}
+
+func (_importCall) opcode() opcode {
+	return op_importCall
+}
+
+// instructionPrototypes maps every opcode to an instance of its instruction. Instructions without operands
+// are used as is when a program is read, for the others a new value of the same type is decoded.
+var instructionPrototypes = [...]instruction{
+	op_add:                  add,
+	op_and:                  and,
+	op_appendArray:          appendArray,
+	op_appendArraySpread:    appendArraySpread,
+	op_await:                await,
+	op_bnot:                 bnot,
+	op_boxThis:              boxThis,
+	op_callSpread:           callSpread,
+	op_checkDerivedRet:      checkDerivedRet,
+	op_copyBlockScope:       copyBlockScope,
+	op_copySpread:           copySpread,
+	op_createArgsRestStash:  createArgsRestStash,
+	op_createGenerator:      createGenerator,
+	op_debugger:             debuggerStmt,
+	op_dec:                  dec,
+	op_deleteElem:           deleteElem,
+	op_deleteElemStrict:     deleteElemStrict,
+	op_destructObj:          destructObj,
+	op_div:                  div,
+	op_dup:                  dup,
+	op_enterWith:            enterWith,
+	op_enumGet:              enumGet,
+	op_enumPop:              enumPop,
+	op_enumPopClose:         enumPopClose,
+	op_enumerate:            enumerate,
+	op_getElem:              getElem,
+	op_getElemCallee:        getElemCallee,
+	op_getSuperElem:         getSuperElem,
+	op_getValue:             getValue,
+	op_halt:                 halt,
+	op_importCall:           importCall,
+	op_inc:                  inc,
+	op_iterGetNextOrUndef:   iterGetNextOrUndef,
+	op_iterGetRest:          iterGetRest,
+	op_iterate:              iterate,
+	op_leaveBlockScope:      leaveBlockScope,
+	op_leaveFinally:         leaveFinally,
+	op_leaveTry:             leaveTry,
+	op_leaveWith:            leaveWith,
+	op_loadCallee:           loadCallee,
+	op_loadDerivedThis:      loadDerivedThis,
+	op_loadGlobalObject:     loadGlobalObject,
+	op_loadNil:              loadNil,
+	op_loadUndef:            loadUndef,
+	op_mod:                  mod,
+	op_mul:                  mul,
+	op_neg:                  neg,
+	op_new:                  _new(0),
+	op_newClass:             newClass,
+	op_newDerivedClass:      newDerivedClass,
+	op_newObject:            newObject,
+	op_newSpread:            newSpread,
+	op_newStash:             newStash,
+	op_noop:                 noop,
+	op_not:                  not,
+	op_op_eq:                op_eq,
+	op_op_gt:                op_gt,
+	op_op_gte:               op_gte,
+	op_op_in:                op_in,
+	op_op_instanceof:        op_instanceof,
+	op_op_lt:                op_lt,
+	op_op_lte:               op_lte,
+	op_op_neq:               op_neq,
+	op_op_strict_eq:         op_strict_eq,
+	op_op_strict_neq:        op_strict_neq,
+	op_or:                   or,
+	op_plus:                 plus,
+	op_pop:                  pop,
+	op_putValue:             putValue,
+	op_ret:                  ret,
+	op_retStashless:         retStashless,
+	op_sal:                  sal,
+	op_sar:                  sar,
+	op_setElem:              setElem,
+	op_setElemStrict:        setElemStrict,
+	op_setProto:             setProto,
+	op_shr:                  shr,
+	op_startAsync:           startAsync,
+	op_sub:                  sub,
+	op_superCallSpread:      superCallSpread,
+	op_swap:                 swap,
+	op_throw:                throw,
+	op_throwConstAssignment: throwConstAssignment,
+	op_toNumber:             toNumber,
+	op_typeof:               typeof,
+	op_xor:                  xor,
+	op_yield:                yield,
+	op_yieldDelegateStart:   yieldDelegateStart,
+	opBindGlobalLexical:     bindGlobalLexical{},
+	opBindName:              bindName(""),
+	opCall:                  call(0),
+	opCallEval:              callEval(0),
+	opCallEvalStrict:        callEvalStrict(0),
+	opCheckInit:             checkInit(""),
+	opConcatStrings:         concatStrings(0),
+	opCopyRest:              copyRest(0),
+	opCreateArgs:            createArgs(0),
+	opCreateArgsStrict:      createArgsStrict(0),
+	opCreateArgsRestStack:   createArgsRestStack(0),
+	opDefineGetter:          defineGetter(""),
+	opDefineMethod:          defineMethod(""),
+	opDefineSetter:          defineSetter(""),
+	opDeleteGlobal:          deleteGlobal(""),
+	opDeleteProp:            deleteProp(""),
+	opDeletePropStrict:      deletePropStrict(""),
+	opDeleteVar:             deleteVar(""),
+	opDupN:                  dupN(0),
+	opEnterBlockScope:       enterBlockScope{},
+	opEnterCatch:            enterCatch(""),
+	opEnterFunc:             enterFunc(0),
+	opEnterFuncStashless:    enterFuncStashless{},
+	opEnumNext:              enumNext(0),
+	opGetLocal:              getLocal(0),
+	opGetProp:               getProp(""),
+	opGetPropCallee:         getPropCallee(""),
+	opGetSuper:              getSuper(""),
+	opGetTemplateObject:     (*getTemplateObject)(nil),
+	opGetVar:                getVar{},
+	opGetVar1:               getVar1(""),
+	opGetVar1Callee:         getVar1Callee(""),
+	opInitGlobalLexical:     initGlobalLexical(""),
+	opIterNext:              iterNext(0),
+	opJeq:                   jeq(0),
+	opJeq1:                  jeq1(0),
+	opJne:                   jne(0),
+	opJneq1:                 jneq1(0),
+	opJump:                  jump(0),
+	opLoadStack:             loadStack(0),
+	opLoadVal:               loadVal(0),
+	opLoadVal1:              (*loadVal1)(nil),
+	opNewArray:              newArray(0),
+	opNewFunc:               (*newFunc)(nil),
+	opNewRegexp:             (*newRegexp)(nil),
+	opRdupN:                 rdupN(0),
+	opResolveVar:            resolveVar{},
+	opResolveVar1:           resolveVar1(""),
+	opResolveVar1Strict:     resolveVar1Strict(""),
+	opSetGlobal:             setGlobal(""),
+	opSetGlobalStrict:       setGlobalStrict(""),
+	opSetLocal:              setLocal(0),
+	opSetLocalP:             setLocalP(0),
+	opSetProp:               setProp(""),
+	opSetProp1:              setProp1(""),
+	opSetPropGetter:         setPropGetter(""),
+	opSetPropSetter:         setPropSetter(""),
+	opSetPropStrict:         setPropStrict(""),
+	opSetVar:                setVar{},
+	opSetVar1Strict:         setVar1Strict(""),
+	opSetVarStrict:          setVarStrict{},
+	opStoreStack:            storeStack(0),
+	opStoreStackP:           storeStackP(0),
+	opSuperCall:             superCall(0),
+	opTry:                   try{},
+	opYieldDelegate:         yieldDelegate(0),
+	opYieldResume:           yieldResume(0),
+}
+
+const (
+	valueTagUndefined uint8 = iota
+	valueTagNull
+	valueTagBool
+	valueTagInt
+	valueTagFloat
+	valueTagAscii
+	valueTagUnicode
+	valueTagBigInt
+)
+
+const noSource = 0xFFFFFFFF
+
+// maxFuncNesting limits the depth of the nested functions in the data read by ReadProgram, so that corrupted input
+// cannot exhaust the stack.
+const maxFuncNesting = 1000
+
 type progReader struct {
-	io.Reader
+	*bytes.Reader
+	// the number of the enclosing functions of the program being read
+	depth int
 }
 
 func (r *progReader) readUint8() (uint8, error) {
-	var val uint8
-	if err := binary.Read(r, binary.BigEndian, &val); err != nil {
-		return 0, err
+	return r.ReadByte()
+}
+
+func (r *progReader) readBool() (bool, error) {
+	val, err := r.readUint8()
+	if err != nil {
+		return false, err
 	}
-	return val, nil
+	if val > 1 {
+		return false, ErrCorruptedProgram
+	}
+	return val == 1, nil
 }
 
 func (r *progReader) readUint16() (uint16, error) {
@@ -601,34 +1021,65 @@ func (r *progReader) readUint32() (uint32, error) {
 	return val, nil
 }
 
-func (r *progReader) readString() (string, error) {
-	size, err := r.readUint32()
+func (r *progReader) readUint64() (uint64, error) {
+	var val uint64
+	if err := binary.Read(r, binary.BigEndian, &val); err != nil {
+		return 0, err
+	}
+	return val, nil
+}
+
+// readLength reads a length prefix and makes sure that at least size*length bytes remain, so that
+// corrupted input cannot cause huge allocations.
+func (r *progReader) readLength(size int) (int, error) {
+	l, err := r.readUint32()
 	if err != nil {
-		return "", err
+		return 0, err
 	}
-	data := make([]byte, size)
-	if _, err := r.Read(data); err != nil {
-		return "", err
+	if uint64(l)*uint64(size) > uint64(r.Len()) {
+		return 0, ErrCorruptedProgram
 	}
-	return string(data), nil
+	return int(l), nil
+}
+
+// checkCount checks an operand which is a number (or an index) of variables, arguments, array elements, etc. Each
+// of them takes at least a byte of the source or of the code, so a valid count cannot exceed the size of the data.
+// This prevents corrupted input from causing huge allocations when the program runs.
+func (r *progReader) checkCount(n uint32) error {
+	if int64(n) > r.Size() {
+		return ErrCorruptedProgram
+	}
+	return nil
 }
 
-func (r *progReader) readIntArray() ([]int, error) {
-	size, err := r.readUint32()
+// checkVarIdx checks a variable index which has the stash level in the upper 8 bits.
+func (r *progReader) checkVarIdx(idx uint32) error {
+	if err := r.checkCount(idx >> 24); err != nil {
+		return err
+	}
+	return r.checkCount(idx & 0x00FFFFFF)
+}
+
+func (r *progReader) readBytes() ([]byte, error) {
+	size, err := r.readLength(1)
 	if err != nil {
 		return nil, err
 	}
-	data := make([]int, size)
-	for i := 0; i < int(size); i++ {
-		val, err := r.readUint32()
-		if err != nil {
-			return nil, err
-		}
-		data[i] = int(val)
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
 	}
 	return data, nil
 }
 
+func (r *progReader) readString() (string, error) {
+	data, err := r.readBytes()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
 type progWriter struct {
 	io.Writer
 }
@@ -637,6 +1088,13 @@ func (w *progWriter) writeUint8(val uint8) error {
 	return binary.Write(w, binary.BigEndian, val)
 }
 
+func (w *progWriter) writeBool(val bool) error {
+	if val {
+		return w.writeUint8(1)
+	}
+	return w.writeUint8(0)
+}
+
 func (w *progWriter) writeUint16(val uint16) error {
 	return binary.Write(w, binary.BigEndian, val)
 }
@@ -645,8 +1103,11 @@ func (w *progWriter) writeUint32(val uint32) error {
 	return binary.Write(w, binary.BigEndian, val)
 }
 
-func (w *progWriter) writeString(val string) error {
-	data := []byte(val)
+func (w *progWriter) writeUint64(val uint64) error {
+	return binary.Write(w, binary.BigEndian, val)
+}
+
+func (w *progWriter) writeBytes(data []byte) error {
 	if err := w.writeUint32(uint32(len(data))); err != nil {
 		return err
 	}
@@ -656,134 +1117,911 @@ func (w *progWriter) writeString(val string) error {
 	return nil
 }
 
-func (w *progWriter) writeIntArray(val []int) error {
-	if err := w.writeUint32(uint32(len(val))); err != nil {
-		return err
+func (w *progWriter) writeString(val string) error {
+	return w.writeBytes([]byte(val))
+}
+
+// ExportProgram serializes a compiled program, including the nested functions, the constants, the source code
+// and the source map, so that it can be loaded later using ReadProgram() instead of being compiled again.
+// The version is an arbitrary number chosen by the caller, ReadProgram() rejects data written with a different one.
+func ExportProgram(prg *Program, version uint16) ([]byte, error) {
+	payload := new(bytes.Buffer)
+	pw := &progWriter{payload}
+	sources := make(map[*SrcFile]uint32)
+	var srcList []*SrcFile
+	collectSources(prg, sources, &srcList)
+	if err := pw.writeUint32(uint32(len(srcList))); err != nil {
+		return nil, err
 	}
-	for i := 0; i < len(val); i++ {
-		if err := w.writeUint32(uint32(val[i])); err != nil {
-			return err
+	for _, src := range srcList {
+		if err := pw.writeString(src.name); err != nil {
+			return nil, err
+		}
+		if err := pw.writeString(src.src); err != nil {
+			return nil, err
+		}
+		if err := pw.writeBytes(src.sourceMapData); err != nil {
+			return nil, err
 		}
 	}
-	return nil
-}
+	if err := pw.writeProgram(prg, sources); err != nil {
+		return nil, err
+	}
 
-func ExportProgram(prg *Program, version uint16) ([]byte, error) {
 	buffer := new(bytes.Buffer)
 	writer := &progWriter{buffer}
 
 	if err := writer.writeUint32(prgMagic); err != nil {
 		return nil, err
 	}
+	if err := writer.writeUint16(prgFormatVersion); err != nil {
+		return nil, err
+	}
 	if err := writer.writeUint16(version); err != nil {
 		return nil, err
 	}
-
-	if err := writer.writeString(prg.funcName); err != nil {
+	if err := writer.writeUint32(crc32.ChecksumIEEE(payload.Bytes())); err != nil {
 		return nil, err
 	}
-
-	if err := writer.writeString(prg.src.name); err != nil {
+	if err := writer.writeBytes(payload.Bytes()); err != nil {
 		return nil, err
 	}
 
-	if err := writer.writeString(prg.src.src); err != nil {
-		return nil, err
+	return buffer.Bytes(), nil
+}
+
+func collectSources(prg *Program, sources map[*SrcFile]uint32, list *[]*SrcFile) {
+	if prg.src != nil {
+		if _, exists := sources[prg.src]; !exists {
+			sources[prg.src] = uint32(len(*list))
+			*list = append(*list, prg.src)
+		}
+	}
+	for _, ins := range prg.code {
+		if f, ok := ins.(*newFunc); ok {
+			collectSources(f.prg, sources, list)
+		}
 	}
+}
 
-	if err := writer.writeUint32(uint32(prg.src.lastScannedOffset)); err != nil {
-		return nil, err
+func (w *progWriter) writeProgram(prg *Program, sources map[*SrcFile]uint32) error {
+	if err := w.writeString(prg.funcName); err != nil {
+		return err
 	}
-	if err := writer.writeIntArray(prg.src.lineOffsets); err != nil {
-		return nil, err
+	srcIdx := uint32(noSource)
+	if prg.src != nil {
+		srcIdx = sources[prg.src]
+	}
+	if err := w.writeUint32(srcIdx); err != nil {
+		return err
 	}
 
-	length := len(prg.code)
-	if err := writer.writeUint32(uint32(length)); err != nil {
-		return nil, err
+	if err := w.writeUint32(uint32(len(prg.values))); err != nil {
+		return err
+	}
+	for _, v := range prg.values {
+		if err := w.writeValue(v); err != nil {
+			return err
+		}
 	}
 
+	if err := w.writeUint32(uint32(len(prg.code))); err != nil {
+		return err
+	}
 	for _, ins := range prg.code {
-		if err := writer.writeUint8(uint8(ins.opcode())); err != nil {
-			return nil, err
+		if err := w.writeInstruction(ins, sources); err != nil {
+			return err
 		}
 	}
 
-	//fmt.Println(buffer.Bytes())
+	if err := w.writeUint32(uint32(len(prg.srcMap))); err != nil {
+		return err
+	}
+	for _, item := range prg.srcMap {
+		if err := w.writeUint32(uint32(item.pc)); err != nil {
+			return err
+		}
+		if err := w.writeUint32(uint32(item.srcPos)); err != nil {
+			return err
+		}
+	}
 
-	return buffer.Bytes(), nil
+	names := make([]string, 0, len(prg.stackNames))
+	for name := range prg.stackNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if err := w.writeUint32(uint32(len(names))); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := w.writeString(name); err != nil {
+			return err
+		}
+		if err := w.writeUint32(uint32(int32(prg.stackNames[name]))); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func ReadProgram(r io.Reader, version uint16) (*Program, error) {
-	reader := &progReader{r}
+func (w *progWriter) writeValue(v Value) error {
+	switch v := v.(type) {
+	case valueUndefined:
+		return w.writeUint8(valueTagUndefined)
+	case valueNull:
+		return w.writeUint8(valueTagNull)
+	case valueBool:
+		if err := w.writeUint8(valueTagBool); err != nil {
+			return err
+		}
+		return w.writeBool(bool(v))
+	case valueInt:
+		if err := w.writeUint8(valueTagInt); err != nil {
+			return err
+		}
+		return w.writeUint64(uint64(v))
+	case valueFloat:
+		if err := w.writeUint8(valueTagFloat); err != nil {
+			return err
+		}
+		return w.writeUint64(math.Float64bits(float64(v)))
+	case asciiString:
+		if err := w.writeUint8(valueTagAscii); err != nil {
+			return err
+		}
+		return w.writeString(string(v))
+	case unicodeString:
+		if err := w.writeUint8(valueTagUnicode); err != nil {
+			return err
+		}
+		if err := w.writeUint32(uint32(len(v))); err != nil {
+			return err
+		}
+		for _, c := range v {
+			if err := w.writeUint16(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *valueBigInt:
+		if err := w.writeUint8(valueTagBigInt); err != nil {
+			return err
+		}
+		return w.writeString(v.toBig().Text(16))
+	}
+	return fmt.Errorf("cannot serialize a value of type %T", v)
+}
 
-	prg := &Program{
-		code:   make([]instruction, 0),
-		values: make([]Value, 0),
+func (w *progWriter) writeInstruction(ins instruction, sources map[*SrcFile]uint32) error {
+	if ins == nil {
+		return errors.New("cannot serialize an incomplete program")
+	}
+	if err := w.writeUint8(uint8(ins.opcode())); err != nil {
+		return err
+	}
+	switch ins := ins.(type) {
+	case *loadVal1:
+		return w.writeUint32(uint32(*ins))
+	case setVar:
+		if err := w.writeString(ins.name); err != nil {
+			return err
+		}
+		return w.writeUint32(ins.idx)
+	case setVarStrict:
+		if err := w.writeString(ins.name); err != nil {
+			return err
+		}
+		return w.writeUint32(ins.idx)
+	case getVar:
+		if err := w.writeString(ins.name); err != nil {
+			return err
+		}
+		if err := w.writeUint32(ins.idx); err != nil {
+			return err
+		}
+		return w.writeBool(ins.ref)
+	case resolveVar:
+		if err := w.writeString(ins.name); err != nil {
+			return err
+		}
+		if err := w.writeUint32(ins.idx); err != nil {
+			return err
+		}
+		return w.writeBool(ins.strict)
+	case bindGlobalLexical:
+		if err := w.writeString(ins.name); err != nil {
+			return err
+		}
+		return w.writeBool(ins.isConst)
+	case enterBlockScope:
+		// the names are written in the order of their indexes
+		names := make([]string, len(ins.names))
+		for name, idx := range ins.names {
+			names[idx] = name
+		}
+		if err := w.writeUint32(uint32(len(names))); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := w.writeString(name); err != nil {
+				return err
+			}
+			if err := w.writeBool(ins.consts[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case enterFuncStashless:
+		if err := w.writeUint32(ins.stackSize); err != nil {
+			return err
+		}
+		return w.writeUint32(ins.args)
+	case try:
+		if err := w.writeUint32(uint32(ins.catchOffset)); err != nil {
+			return err
+		}
+		if err := w.writeUint32(uint32(ins.finallyOffset)); err != nil {
+			return err
+		}
+		return w.writeBool(ins.dynamic)
+	case *newRegexp:
+		if err := w.writeString(ins.src.String()); err != nil {
+			return err
+		}
+		flags := ""
+		if ins.global {
+			flags += "g"
+		}
+		if ins.ignoreCase {
+			flags += "i"
+		}
+		if ins.multiline {
+			flags += "m"
+		}
+		return w.writeString(flags)
+	case *newFunc:
+		if err := w.writeProgram(ins.prg, sources); err != nil {
+			return err
+		}
+		if err := w.writeString(ins.name); err != nil {
+			return err
+		}
+		if err := w.writeUint32(ins.length); err != nil {
+			return err
+		}
+		if err := w.writeBool(ins.strict); err != nil {
+			return err
+		}
+		if err := w.writeBool(ins.arrow); err != nil {
+			return err
+		}
+		if err := w.writeBool(ins.home); err != nil {
+			return err
+		}
+		if err := w.writeBool(ins.generator); err != nil {
+			return err
+		}
+		if err := w.writeBool(ins.async); err != nil {
+			return err
+		}
+		if err := w.writeUint32(ins.srcStart); err != nil {
+			return err
+		}
+		return w.writeUint32(ins.srcEnd)
+	case *getTemplateObject:
+		if err := w.writeUint32(uint32(len(ins.raw))); err != nil {
+			return err
+		}
+		for i, raw := range ins.raw {
+			cooked := ins.cooked[i]
+			if err := w.writeBool(cooked != nil); err != nil {
+				return err
+			}
+			if cooked != nil {
+				if err := w.writeValue(cooked); err != nil {
+					return err
+				}
+			}
+			if err := w.writeValue(raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 
-		src:    &SrcFile{},
-		srcMap: make([]srcMapItem, 0),
+	// the rest are either singletons or have a single string or integer operand
+	v := reflect.ValueOf(ins)
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.NumField() == 0 {
+			return nil
+		}
+	case reflect.String:
+		return w.writeString(v.String())
+	case reflect.Uint32:
+		return w.writeUint32(uint32(v.Uint()))
+	case reflect.Int, reflect.Int32:
+		return w.writeUint32(uint32(int32(v.Int())))
 	}
+	return fmt.Errorf("cannot serialize instruction %T", ins)
+}
 
-	magic, err := reader.readUint32()
-	if err != nil {
+// ReadProgram loads a program written by ExportProgram(). The data is checked for the format version, the
+// version supplied by the caller and the checksum; it is also checked for structural consistency (constant
+// indexes, jump targets, source positions), so that corrupted input results in an error rather than a panic.
+// The checksum only detects accidental damage. The operands are checked against the size of the data, so that
+// corrupted input cannot cause huge allocations, but the code cannot be verified completely: if it turns out to be
+// inconsistent while running, the execution is aborted with an *InterruptedError wrapping ErrCorruptedProgram.
+func ReadProgram(r io.Reader, version uint16) (*Program, error) {
+	reader := &progReader{}
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNoCacheFile
+		}
 		return nil, err
 	}
-	if magic != prgMagic {
+	if binary.BigEndian.Uint32(header[0:]) != prgMagic {
 		return nil, ErrNoCacheFile
 	}
+	if binary.BigEndian.Uint16(header[4:]) != prgFormatVersion || binary.BigEndian.Uint16(header[6:]) != version {
+		return nil, ErrVersionNotMatching
+	}
+	checksum := binary.BigEndian.Uint32(header[8:])
 
-	v, err := reader.readUint16()
+	var sizeBuf [4]byte
+	if _, err := io.ReadFull(r, sizeBuf[:]); err != nil {
+		return nil, ErrCorruptedProgram
+	}
+	size := int64(binary.BigEndian.Uint32(sizeBuf[:]))
+	payload, err := ioutil.ReadAll(io.LimitReader(r, size))
 	if err != nil {
 		return nil, err
 	}
-	if v != version {
-		return nil, ErrVersionNotMatching
+	if int64(len(payload)) != size || crc32.ChecksumIEEE(payload) != checksum {
+		return nil, ErrCorruptedProgram
 	}
+	reader.Reader = bytes.NewReader(payload)
 
-	funcName, err := reader.readString()
+	prg, err := reader.readProgramData()
 	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrCorruptedProgram
+		}
 		return nil, err
 	}
-	prg.funcName = funcName
+	if reader.Len() != 0 {
+		return nil, ErrCorruptedProgram
+	}
+	return prg, nil
+}
 
-	name, err := reader.readString()
+func (r *progReader) readProgramData() (*Program, error) {
+	count, err := r.readLength(12)
 	if err != nil {
 		return nil, err
 	}
-	prg.src.name = name
+	sources := make([]*SrcFile, count)
+	for i := range sources {
+		name, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		src, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		sourceMapData, err := r.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		sources[i] = NewSrcFile(name, src, nil)
+		if len(sourceMapData) > 0 {
+			sm, err := sourcemap.Parse(name, sourceMapData)
+			if err != nil {
+				return nil, ErrCorruptedProgram
+			}
+			sources[i].sourceMap = sm
+			sources[i].sourceMapData = sourceMapData
+		}
+	}
+	return r.readProgram(sources)
+}
 
-	src, err := reader.readString()
+func (r *progReader) readProgram(sources []*SrcFile) (*Program, error) {
+	prg := &Program{unverified: true}
+	var err error
+	if prg.funcName, err = r.readString(); err != nil {
+		return nil, err
+	}
+	srcIdx, err := r.readUint32()
 	if err != nil {
 		return nil, err
 	}
-	prg.src.src = src
+	if srcIdx != noSource {
+		if srcIdx >= uint32(len(sources)) {
+			return nil, ErrCorruptedProgram
+		}
+		prg.src = sources[srcIdx]
+	}
 
-	lastScannedOffset, err := reader.readUint32()
+	count, err := r.readLength(1)
 	if err != nil {
 		return nil, err
 	}
-	prg.src.lastScannedOffset = int(lastScannedOffset)
+	prg.values = make([]Value, count)
+	for i := range prg.values {
+		if prg.values[i], err = r.readValue(); err != nil {
+			return nil, err
+		}
+	}
+
+	if count, err = r.readLength(1); err != nil {
+		return nil, err
+	}
+	prg.code = make([]instruction, count)
+	for i := range prg.code {
+		if prg.code[i], err = r.readInstruction(sources); err != nil {
+			return nil, err
+		}
+	}
 
-	lineOffsets, err := reader.readIntArray()
+	if count, err = r.readLength(8); err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		prg.srcMap = make([]srcMapItem, count)
+	}
+	for i := range prg.srcMap {
+		pc, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		srcPos, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		prg.srcMap[i] = srcMapItem{pc: int(pc), srcPos: int(srcPos)}
+	}
+
+	if count, err = r.readLength(8); err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		prg.stackNames = make(map[string]int, count)
+	}
+	for i := 0; i < count; i++ {
+		name, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		idx, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		prg.stackNames[name] = int(int32(idx))
+	}
+
+	if err := prg.validate(); err != nil {
+		return nil, err
+	}
+	return prg, nil
+}
+
+func (r *progReader) readValue() (Value, error) {
+	tag, err := r.readUint8()
 	if err != nil {
 		return nil, err
 	}
-	prg.src.lineOffsets = lineOffsets
+	switch tag {
+	case valueTagUndefined:
+		return _undefined, nil
+	case valueTagNull:
+		return _null, nil
+	case valueTagBool:
+		b, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		return valueBool(b), nil
+	case valueTagInt:
+		i, err := r.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		return valueInt(int64(i)), nil
+	case valueTagFloat:
+		f, err := r.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		return valueFloat(math.Float64frombits(f)), nil
+	case valueTagAscii:
+		s, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		return asciiString(s), nil
+	case valueTagUnicode:
+		l, err := r.readLength(2)
+		if err != nil {
+			return nil, err
+		}
+		s := make(unicodeString, l)
+		for i := range s {
+			if s[i], err = r.readUint16(); err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	case valueTagBigInt:
+		s, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		b, ok := new(big.Int).SetString(s, 16)
+		if !ok {
+			return nil, ErrCorruptedProgram
+		}
+		return (*valueBigInt)(b), nil
+	}
+	return nil, ErrCorruptedProgram
+}
+
+// corruptedProgramError is the error which aborts the execution of a program read by ReadProgram() when its code
+// turns out to be inconsistent, x is the value of the resulting panic.
+func corruptedProgramError(x interface{}) *InterruptedError {
+	return &InterruptedError{iface: fmt.Errorf("%w: %v", ErrCorruptedProgram, x)}
+}
 
-	length, err := reader.readUint32()
+func (r *progReader) readInstruction(sources []*SrcFile) (instruction, error) {
+	op, err := r.readUint8()
 	if err != nil {
 		return nil, err
 	}
-	prg.code = make([]instruction, length)
+	if int(op) >= len(instructionPrototypes) || instructionPrototypes[op] == nil {
+		return nil, ErrCorruptedProgram
+	}
+	proto := instructionPrototypes[op]
+	switch proto.(type) {
+	case *loadVal1:
+		idx, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		ins := loadVal1(idx)
+		return &ins, nil
+	case setVar:
+		name, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		idx, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.checkVarIdx(idx); err != nil {
+			return nil, err
+		}
+		return setVar{name: name, idx: idx}, nil
+	case setVarStrict:
+		name, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		idx, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.checkVarIdx(idx); err != nil {
+			return nil, err
+		}
+		return setVarStrict{name: name, idx: idx}, nil
+	case getVar:
+		name, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		idx, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		ref, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.checkVarIdx(idx); err != nil {
+			return nil, err
+		}
+		return getVar{name: name, idx: idx, ref: ref}, nil
+	case resolveVar:
+		name, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		idx, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		strict, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.checkVarIdx(idx); err != nil {
+			return nil, err
+		}
+		return resolveVar{name: name, idx: idx, strict: strict}, nil
+	case bindGlobalLexical:
+		name, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		isConst, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		return bindGlobalLexical{name: name, isConst: isConst}, nil
+	case enterBlockScope:
+		n, err := r.readLength(5)
+		if err != nil {
+			return nil, err
+		}
+		ins := enterBlockScope{names: make(map[string]uint32, n), consts: make(map[string]bool)}
+		for i := 0; i < n; i++ {
+			name, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			isConst, err := r.readBool()
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := ins.names[name]; exists {
+				return nil, ErrCorruptedProgram
+			}
+			ins.names[name] = uint32(i)
+			if isConst {
+				ins.consts[name] = true
+			}
+		}
+		return ins, nil
+	case enterFuncStashless:
+		stackSize, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		args, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		if err := r.checkCount(stackSize); err != nil {
+			return nil, err
+		}
+		if err := r.checkCount(args); err != nil {
+			return nil, err
+		}
+		return enterFuncStashless{stackSize: stackSize, args: args}, nil
+	case try:
+		catchOffset, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		finallyOffset, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		dynamic, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		return try{catchOffset: int32(catchOffset), finallyOffset: int32(finallyOffset), dynamic: dynamic}, nil
+	case *newRegexp:
+		src, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		flags, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		pattern, global, ignoreCase, multiline, err := compileRegexp(src, flags)
+		if err != nil {
+			return nil, ErrCorruptedProgram
+		}
+		return &newRegexp{
+			pattern:    pattern,
+			src:        newStringValue(src),
+			global:     global,
+			ignoreCase: ignoreCase,
+			multiline:  multiline,
+		}, nil
+	case *newFunc:
+		if r.depth >= maxFuncNesting {
+			return nil, ErrCorruptedProgram
+		}
+		r.depth++
+		prg, err := r.readProgram(sources)
+		r.depth--
+		if err != nil {
+			return nil, err
+		}
+		name, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		length, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		strict, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		arrow, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		home, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		generator, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		async, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		srcStart, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		srcEnd, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		if prg.src == nil || srcStart > srcEnd || int(srcEnd) > len(prg.src.src) {
+			return nil, ErrCorruptedProgram
+		}
+		prg.srcStart, prg.srcEnd = int(srcStart), int(srcEnd)
+		return &newFunc{
+			prg:       prg,
+			name:      name,
+			length:    length,
+			strict:    strict,
+			arrow:     arrow,
+			home:      home,
+			generator: generator,
+			async:     async,
+			srcStart:  srcStart,
+			srcEnd:    srcEnd,
+		}, nil
+	case *getTemplateObject:
+		n, err := r.readLength(3)
+		if err != nil {
+			return nil, err
+		}
+		ins := newGetTemplateObject(make([]Value, n), make([]Value, n))
+		for i := 0; i < n; i++ {
+			valid, err := r.readBool()
+			if err != nil {
+				return nil, err
+			}
+			if valid {
+				if ins.cooked[i], err = r.readValue(); err != nil {
+					return nil, err
+				}
+			}
+			if ins.raw[i], err = r.readValue(); err != nil {
+				return nil, err
+			}
+		}
+		return ins, nil
+	}
 
-	for i := 0; i < int(length); i++ {
-		// TODO deserialize actual instruction
-		_, err := reader.readUint8()
+	t := reflect.TypeOf(proto)
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.NumField() == 0 {
+			return proto, nil
+		}
+	case reflect.String:
+		s, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(s).Convert(t).Interface().(instruction), nil
+	case reflect.Uint32:
+		v, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+		switch proto.(type) {
+		case getLocal, setLocal, setLocalP:
+			err = r.checkVarIdx(v)
+		default:
+			err = r.checkCount(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(v).Convert(t).Interface().(instruction), nil
+	case reflect.Int, reflect.Int32:
+		v, err := r.readUint32()
 		if err != nil {
 			return nil, err
 		}
+		// stack indexes and jump offsets, both can be negative
+		n := int32(v)
+		if n < 0 {
+			n = -n
+		}
+		if n < 0 {
+			return nil, ErrCorruptedProgram
+		}
+		if err := r.checkCount(uint32(n)); err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(int32(v)).Convert(t).Interface().(instruction), nil
 	}
+	return nil, ErrCorruptedProgram
+}
 
-	return prg, nil
+// validate checks that the operands referring to other parts of the program are within range.
+func (p *Program) validate() error {
+	l := len(p.code)
+	if l == 0 {
+		return ErrCorruptedProgram
+	}
+	checkJump := func(pc int, offset int32) bool {
+		target := pc + int(offset)
+		return target >= 0 && target < l
+	}
+	for pc, ins := range p.code {
+		ok := true
+		switch ins := ins.(type) {
+		case loadVal:
+			ok = int(ins) < len(p.values)
+		case *loadVal1:
+			ok = int(*ins) < len(p.values)
+		case jump:
+			ok = checkJump(pc, int32(ins))
+		case jne:
+			ok = checkJump(pc, int32(ins))
+		case jeq:
+			ok = checkJump(pc, int32(ins))
+		case jeq1:
+			ok = checkJump(pc, int32(ins))
+		case jneq1:
+			ok = checkJump(pc, int32(ins))
+		case enumNext:
+			ok = checkJump(pc, int32(ins))
+		case iterNext:
+			ok = checkJump(pc, int32(ins))
+		case yieldDelegate:
+			ok = checkJump(pc, int32(ins))
+		case yieldResume:
+			ok = checkJump(pc, int32(ins))
+		case try:
+			ok = (ins.catchOffset == 0 || checkJump(pc, ins.catchOffset)) &&
+				(ins.finallyOffset == 0 || checkJump(pc, ins.finallyOffset))
+		}
+		if !ok {
+			return ErrCorruptedProgram
+		}
+	}
+	srcLen := 0
+	if p.src != nil {
+		srcLen = len(p.src.src)
+	}
+	lastPc := 0
+	for _, item := range p.srcMap {
+		if item.pc < lastPc || item.pc > l || item.srcPos > srcLen {
+			return ErrCorruptedProgram
+		}
+		lastPc = item.pc
+	}
+	return nil
 }
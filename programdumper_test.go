@@ -0,0 +1,318 @@
+package goja
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testExportScript = `
+"use strict";
+var s = "ünïcödé";
+var re = /a(b+)c/gi;
+function f(x, y) {
+	var z = x * 1.5;
+	return function(w) {
+		return z + w + y;
+	};
+}
+function g() {
+	try {
+		throw new Error("boom");
+	} catch (e) {
+		return e.message;
+	} finally {
+		s += "!";
+	}
+}
+var o = {a: 1, get b() { return this.a + 1; }};
+var arr = [];
+for (var k in o) {
+	arr.push(k);
+}
+var i = 0;
+do {
+	i++;
+} while (i < 3 && typeof arr === "object");
+const fns = [];
+for (let j = 0; j < 2; j++) {
+	fns.push(function() { return j; });
+}
+var sq = [1, 2].map(x => x * x);
+function tag(strs, v) { return strs.raw[0] + strs[1] + v; }
+var tmpl = ` + "`${sq[1]}\\t${tag`a\\n${1}b`}`;" + `
+class Base {
+	constructor(v) {
+		this.v = v;
+	}
+	get twice() {
+		return this.v * 2;
+	}
+}
+class Derived extends Base {
+	name() {
+		return super.constructor.name + (() => this.twice)();
+	}
+	static of(v) {
+		return new this(v);
+	}
+}
+function* gen(n) {
+	try {
+		for (var x of [n, n + 1]) {
+			yield x;
+		}
+		yield* "ab";
+	} finally {
+		yield "f";
+	}
+}
+var caught;
+try {
+	undefinedFn();
+} catch (e) {
+	caught = e.name;
+}
+var captured;
+try {
+	throw "captured";
+} catch (e) {
+	captured = function() { return e; };
+}
+[f(2, 10)(0.5), g(), s, caught, captured(), re.exec("xABBc")[1], o.b, arr.join(), i, null, undefined, -0, 1e100, fns[1](), sq.join(), tmpl, Derived.of(3).name(), [...gen(1)].join(""), (async x => await x)(1) instanceof Promise, 0x1fffffffffffffffn * -3n].join("|");
+`
+
+func exportAndRead(t *testing.T, prg *Program) *Program {
+	data, err := ExportProgram(prg, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prg1, err := ReadProgram(bytes.NewReader(data), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prg1
+}
+
+func TestExportProgram(t *testing.T) {
+	prg, err := Compile("test.js", testExportScript, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := New().RunProgram(prg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prg1 := exportAndRead(t, prg)
+	res, err := New().RunProgram(prg1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.SameAs(expected) {
+		t.Fatalf("Unexpected result: %v, expected: %v", res, expected)
+	}
+	if prg1.src.name != "test.js" || prg1.src.src != testExportScript {
+		t.Fatal("Source was not preserved")
+	}
+	if len(prg1.srcMap) != len(prg.srcMap) {
+		t.Fatal("Source positions were not preserved")
+	}
+}
+
+func TestExportProgramStackTrace(t *testing.T) {
+	const SCRIPT = `
+	function thrower() {
+		throw new Error("test");
+	}
+	thrower();
+	`
+	prg := MustCompile("trace.js", SCRIPT, false)
+	_, expected := New().RunProgram(prg)
+	_, err := New().RunProgram(exportAndRead(t, prg))
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if err.Error() != expected.Error() || err.Error() != "Error: test at thrower (trace.js:3:9(4))" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestExportProgramFuncStart(t *testing.T) {
+	const SCRIPT = `
+	var f = function() {
+		return function inner() {};
+	};
+	`
+	prg := MustCompile("test.js", SCRIPT, false)
+	expected := funcStarts(prg, nil)
+	if len(expected) != 2 {
+		t.Fatalf("Unexpected functions: %v", expected)
+	}
+	if starts := funcStarts(exportAndRead(t, prg), nil); !reflect.DeepEqual(starts, expected) {
+		t.Fatalf("Function start offsets were not preserved: %v, expected: %v", starts, expected)
+	}
+}
+
+// funcStarts appends the start offsets of the functions nested in prg (depth first).
+func funcStarts(prg *Program, starts []int) []int {
+	for _, ins := range prg.code {
+		if n, ok := ins.(*newFunc); ok {
+			starts = append(starts, n.prg.srcStart)
+			starts = funcStarts(n.prg, starts)
+		}
+	}
+	return starts
+}
+
+func TestExportProgramSourceMap(t *testing.T) {
+	const SCRIPT = `function f() {
+throw new Error("test");
+}
+f();
+//# sourceMappingURL=data:application/json;base64,eyJ2ZXJzaW9uIjozLCJzb3VyY2VzIjpbIm9yaWcudHMiXSwibmFtZXMiOltdLCJtYXBwaW5ncyI6IkFBQUE7QUFDQTtBQUNBO0FBQ0EifQ==`
+
+	prg := MustCompile("mapped.js", SCRIPT, false)
+	if prg.src.sourceMap == nil {
+		t.Fatal("Source map was not parsed")
+	}
+	prg1 := exportAndRead(t, prg)
+	if prg1.src.sourceMap == nil || !bytes.Equal(prg1.src.sourceMapData, prg.src.sourceMapData) {
+		t.Fatal("Source map was not preserved")
+	}
+	if p, p1 := prg.src.Position(20), prg1.src.Position(20); p != p1 {
+		t.Fatalf("Positions differ: %v, %v", p, p1)
+	}
+}
+
+func TestReadProgramErrors(t *testing.T) {
+	data, err := ExportProgram(MustCompile("test.js", testExportScript, false), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadProgram(bytes.NewReader(data), 2); err != ErrVersionNotMatching {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := ReadProgram(bytes.NewReader([]byte("not a program")), 1); err != ErrNoCacheFile {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := ReadProgram(bytes.NewReader(data[:len(data)-10]), 1); err != ErrCorruptedProgram {
+		t.Fatalf("Unexpected error for truncated data: %v", err)
+	}
+
+	for _, i := range []int{16, 40, len(data) / 2, len(data) - 1} {
+		corrupted := append([]byte(nil), data...)
+		corrupted[i] ^= 0x55
+		if _, err := ReadProgram(bytes.NewReader(corrupted), 1); err != ErrCorruptedProgram {
+			t.Fatalf("Unexpected error for corrupted byte %d: %v", i, err)
+		}
+	}
+
+	// Damaged payloads with a valid checksum must not cause a panic
+	for i := 16; i < len(data); i++ {
+		corrupted := append([]byte(nil), data...)
+		corrupted[i] ^= 0xFF
+		binary.BigEndian.PutUint32(corrupted[8:], crc32.ChecksumIEEE(corrupted[16:]))
+		ReadProgram(bytes.NewReader(corrupted), 1)
+	}
+}
+
+func TestReadProgramOperandLimits(t *testing.T) {
+	prg := MustCompile("test.js", "function f(a) { var x = a; return x; } f(1);", false)
+	found := false
+	for _, ins := range prg.code {
+		if f, ok := ins.(*newFunc); ok {
+			for i, ins := range f.prg.code {
+				if e, ok := ins.(enterFuncStashless); ok {
+					e.stackSize = 1 << 30
+					f.prg.code[i] = e
+					found = true
+				}
+			}
+		}
+	}
+	if !found {
+		t.Fatal("enterFuncStashless not found")
+	}
+	data, err := ExportProgram(prg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProgram(bytes.NewReader(data), 1); err != ErrCorruptedProgram {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestReadProgramFuncNesting(t *testing.T) {
+	nested := func(depth int) []byte {
+		src := strings.Repeat("function f() {", depth) + strings.Repeat("}", depth)
+		data, err := ExportProgram(MustCompile("test.js", src, false), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	if _, err := ReadProgram(bytes.NewReader(nested(maxFuncNesting)), 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProgram(bytes.NewReader(nested(maxFuncNesting+1)), 1); err != ErrCorruptedProgram {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestRunCorruptedProgram(t *testing.T) {
+	prg := MustCompile("test.js", "var a = 1; a", false)
+	// pop instead of getting the result
+	for i, ins := range prg.code {
+		if _, ok := ins.(getVar1); ok {
+			prg.code[i] = pop
+		}
+	}
+	data, err := ExportProgram(prg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prg1, err := ReadProgram(bytes.NewReader(data), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = New().RunProgram(prg1)
+	if _, ok := err.(*InterruptedError); !ok || !errors.Is(err, ErrCorruptedProgram) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func FuzzReadProgram(f *testing.F) {
+	for _, src := range []string{testExportScript, "function f(a) { var x = a; return x; } f(1);"} {
+		data, err := ExportProgram(MustCompile("test.js", src, false), 1)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// a valid checksum and size can be produced by anyone, so they must not be relied upon
+		if len(data) >= 16 {
+			binary.BigEndian.PutUint32(data[8:], crc32.ChecksumIEEE(data[16:]))
+			binary.BigEndian.PutUint32(data[12:], uint32(len(data)-16))
+		}
+		prg, err := ReadProgram(bytes.NewReader(data), 1)
+		if err != nil {
+			return
+		}
+		r := New()
+		r.SetLimits(Limits{
+			MaxInstructions:   100000,
+			MaxCallStackDepth: 100,
+			MaxAllocation:     1 << 24,
+		})
+		r.RunProgram(prg)
+	})
+}
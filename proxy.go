@@ -107,6 +107,9 @@ func (r *Runtime) proxyproto_nativehandler_getOwnPropertyDescriptor(native func(
 					if p, ok := call.Argument(1).assertString(); ok {
 						return native(t, p.String()).toValue(r)
 					}
+					if s, ok := call.Argument(1).(*Symbol); ok {
+						return r.propToDescriptorObject(t.self.getOwnPropSym(s))
+					}
 				}
 			}
 			r.typeErrorResult(true, "getOwnPropertyDescriptor needs to be called with target as Object and prop as string")
@@ -125,6 +128,9 @@ func (r *Runtime) proxyproto_nativehandler_defineProperty(native func(*Object, s
 						s := native(t, k.String(), propertyDescriptor)
 						return r.ToValue(s)
 					}
+					if s, ok := call.Argument(1).(*Symbol); ok {
+						return r.toBoolean(t.self.defineOwnProperty(s, r.toPropertyDescriptor(call.Argument(2)), false))
+					}
 				}
 			}
 			r.typeErrorResult(true, "defineProperty needs to be called with target as Object and propertyDescriptor as string and key as string")
@@ -142,6 +148,9 @@ func (r *Runtime) proxyproto_nativehandler_has(native func(*Object, string) bool
 						o := native(t, p.String())
 						return r.ToValue(o)
 					}
+					if s, ok := call.Argument(1).(*Symbol); ok {
+						return r.toBoolean(t.self.hasProperty(s))
+					}
 				}
 			}
 			r.typeErrorResult(true, "has needs to be called with target as Object and property as string")
@@ -160,6 +169,9 @@ func (r *Runtime) proxyproto_nativehandler_get(native func(*Object, string, *Obj
 							return native(t, p.String(), r)
 						}
 					}
+					if s, ok := call.Argument(1).(*Symbol); ok {
+						return t.self.get(s)
+					}
 				}
 			}
 			r.typeErrorResult(true, "get needs to be called with target and receiver as Object and property as string")
@@ -180,6 +192,10 @@ func (r *Runtime) proxyproto_nativehandler_set(native func(*Object, string, Valu
 							return r.ToValue(s)
 						}
 					}
+					if s, ok := call.Argument(1).(*Symbol); ok {
+						t.self.put(s, call.Argument(2), false)
+						return valueTrue
+					}
 				}
 			}
 			r.typeErrorResult(true, "set needs to be called with target and receiver as Object, property as string and value as a legal javascript value")
@@ -197,6 +213,9 @@ func (r *Runtime) proxyproto_nativehandler_deleteProperty(native func(*Object, s
 						o := native(t, p.String())
 						return r.ToValue(o)
 					}
+					if s, ok := call.Argument(1).(*Symbol); ok {
+						return r.toBoolean(t.self.delete(s, false))
+					}
 				}
 			}
 			r.typeErrorResult(true, "deleteProperty needs to be called with target as Object and property as string")
@@ -260,6 +279,8 @@ func (r *Runtime) proxyproto_nativehandler_construct(native func(*Object, []Valu
 	}
 }
 
+// ProxyTrapConfig holds the native traps of a proxy. The traps only receive string keys; operations on
+// Symbol-keyed properties are forwarded to the target.
 type ProxyTrapConfig struct {
 	// A trap for Object.getPrototypeOf, Reflect.getPrototypeOf, __proto__, Object.prototype.isPrototypeOf, instanceof
 	GetPrototypeOf func(target *Object) (prototype *Object)
@@ -528,7 +549,7 @@ func (p *proxyObject) getProp(n Value) (ret Value) {
 			panic(errors.New("illegal return type from proxy trap"))
 		}
 	}, func(target *Object) {
-		propFound = target.self.hasPropertyStr(n.String())
+		propFound = target.self.hasProperty(n)
 	})
 	if ex != nil {
 		panic(ex)
@@ -659,6 +680,14 @@ func (p *proxyObject) getOwnProp(name string) (ret Value) {
 	return
 }
 
+func (p *proxyObject) getOwnPropSym(s *Symbol) Value {
+	return p.target.self.getOwnPropSym(s)
+}
+
+func (p *proxyObject) ownSymbols() []*Symbol {
+	return p.target.self.ownSymbols()
+}
+
 func (p *proxyObject) proto() (ret *Object) {
 	ex := p.handleProxyRequest(proxy_trap_getPrototypeOf, func(proxyFunction func(FunctionCall) Value, this Value) {
 		ret = proxyFunction(FunctionCall{
@@ -681,7 +710,7 @@ func (p *proxyObject) put(n Value, val Value, throw bool) {
 			Arguments: []Value{p.target, n, val, p.val},
 		})
 	}, func(target *Object) {
-		target.self.putStr(n.String(), val, throw)
+		target.self.put(n, val, throw)
 	})
 	if ex != nil {
 		panic(ex)
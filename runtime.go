@@ -2,13 +2,17 @@ package goja
 
 import (
 	"bytes"
+	gocontext "context"
 	"errors"
 	"fmt"
 	"go/ast"
 	"math"
+	"math/big"
 	"math/rand"
 	"reflect"
 	"strconv"
+	"sync"
+	"sync/atomic"
 
 	js_ast "github.com/dop251/goja/ast"
 	"github.com/dop251/goja/parser"
@@ -34,8 +38,26 @@ type global struct {
 	RegExp   *Object
 	Date     *Object
 	Proxy    *Object
-
-	ArrayBuffer *Object
+	Symbol   *Object
+	BigInt   *Object
+	Map      *Object
+	Set      *Object
+	WeakMap  *Object
+	WeakSet  *Object
+	Promise  *Object
+
+	ArrayBuffer       *Object
+	DataView          *Object
+	TypedArray        *Object
+	Int8Array         *Object
+	Uint8Array        *Object
+	Uint8ClampedArray *Object
+	Int16Array        *Object
+	Uint16Array       *Object
+	Int32Array        *Object
+	Uint32Array       *Object
+	Float32Array      *Object
+	Float64Array      *Object
 
 	Error          *Object
 	TypeError      *Object
@@ -56,8 +78,39 @@ type global struct {
 	RegExpPrototype   *Object
 	DatePrototype     *Object
 	ProxyPrototype    *Object
-
-	ArrayBufferPrototype *Object
+	SymbolPrototype   *Object
+	BigIntPrototype   *Object
+	MapPrototype      *Object
+	SetPrototype      *Object
+	WeakMapPrototype  *Object
+	WeakSetPrototype  *Object
+	PromisePrototype  *Object
+
+	IteratorPrototype       *Object
+	ArrayIteratorPrototype  *Object
+	StringIteratorPrototype *Object
+	MapIteratorPrototype    *Object
+	SetIteratorPrototype    *Object
+
+	GeneratorFunction          *Object
+	GeneratorFunctionPrototype *Object
+	GeneratorPrototype         *Object
+
+	AsyncFunction          *Object
+	AsyncFunctionPrototype *Object
+
+	ArrayBufferPrototype       *Object
+	DataViewPrototype          *Object
+	TypedArrayPrototype        *Object
+	Int8ArrayPrototype         *Object
+	Uint8ArrayPrototype        *Object
+	Uint8ClampedArrayPrototype *Object
+	Int16ArrayPrototype        *Object
+	Uint16ArrayPrototype       *Object
+	Int32ArrayPrototype        *Object
+	Uint32ArrayPrototype       *Object
+	Float32ArrayPrototype      *Object
+	Float64ArrayPrototype      *Object
 
 	ErrorPrototype          *Object
 	TypeErrorPrototype      *Object
@@ -73,6 +126,11 @@ type global struct {
 
 	thrower         *Object
 	throwerProperty Value
+
+	arrayValues   *Object
+	goMapIterator *Object
+	parseFloat    *Object
+	parseInt      *Object
 }
 
 type Flag int
@@ -105,6 +163,36 @@ type Runtime struct {
 	typeInfoCache   map[reflect.Type]*reflectTypeInfo
 	fieldNameMapper FieldNameMapper
 
+	// ToValue copies Go maps into Map instances instead of wrapping them
+	goMapsAsMaps bool
+
+	// let and const bindings declared at the top level of scripts
+	globalLexicals *stash
+
+	// symbols created by Symbol.for(), keyed by their description
+	symbolRegistry map[string]*Symbol
+
+	// the strings arrays of the tagged templates by their call sites, see getTemplateObject
+	templateObjects map[*templateSite]*Object
+	// the size of templateObjects at which the entries of the dead call sites are removed
+	templateObjectsPurgeAt int
+
+	jobQueue                []func()
+	promiseRejectionTracker PromiseRejectionTracker
+
+	// the callbacks scheduled by the operations running outside of the Runtime, see RegisterCallback
+	callbackLock     sync.Mutex
+	callbackQueue    []func()
+	pendingCallbacks int
+	callbackWakeup   chan struct{}
+
+	// the options of the parser used by the methods which compile code, see SetParserOptions
+	parserOptions []parser.Option
+
+	moduleLoader ModuleLoader
+	// the loaded modules by their resolved names
+	modules map[string]*module
+
 	vm *vm
 }
 
@@ -112,16 +200,25 @@ type StackFrame struct {
 	prg      *Program
 	funcName string
 	pc       int
+	// the name of the function in the original source if the caller's code has a source map, see mapFuncNames
+	srcFuncName string
 }
 
+// SrcName returns the name of the source file. If it has a source map, this is the name of the original source.
 func (f *StackFrame) SrcName() string {
 	if f.prg == nil {
 		return "<native>"
 	}
-	return f.prg.src.name
+	name, _, _ := f.sourcePosition()
+	return name
 }
 
+// FuncName returns the name of the function. If the code it has been called from has a source map which maps a name
+// at the position of the call, this is the name in the original source.
 func (f *StackFrame) FuncName() string {
+	if f.srcFuncName != "" {
+		return f.srcFuncName
+	}
 	if f.funcName == "" && f.prg == nil {
 		return "<native>"
 	}
@@ -141,23 +238,35 @@ func (f *StackFrame) Position() Position {
 	return f.prg.src.Position(f.prg.sourceOffset(f.pc))
 }
 
+func (f *StackFrame) sourcePosition() (source, symbol string, pos Position) {
+	if f.prg.src == nil {
+		return "", "", Position{}
+	}
+	return f.prg.src.sourcePosition(f.prg.sourceOffset(f.pc))
+}
+
 func (f *StackFrame) Write(b *bytes.Buffer) {
 	if f.prg != nil {
-		if n := f.prg.funcName; n != "" {
-			b.WriteString(n)
+		funcName := f.srcFuncName
+		if funcName == "" {
+			funcName = f.prg.funcName
+		}
+		if funcName != "" {
+			b.WriteString(funcName)
 			b.WriteString(" (")
 		}
-		if n := f.prg.src.name; n != "" {
-			b.WriteString(n)
+		srcName, _, pos := f.sourcePosition()
+		if srcName != "" {
+			b.WriteString(srcName)
 		} else {
 			b.WriteString("<eval>")
 		}
 		b.WriteByte(':')
-		b.WriteString(f.Position().String())
+		b.WriteString(pos.String())
 		b.WriteByte('(')
 		b.WriteString(strconv.Itoa(f.pc))
 		b.WriteByte(')')
-		if f.prg.funcName != "" {
+		if funcName != "" {
 			b.WriteByte(')')
 		}
 	} else {
@@ -182,6 +291,16 @@ type InterruptedError struct {
 	iface interface{}
 }
 
+// uncatchableError is implemented by the errors which abort the execution and cannot be caught by JavaScript code.
+type uncatchableError interface {
+	error
+	exception() *Exception
+}
+
+func (e *InterruptedError) exception() *Exception {
+	return &e.Exception
+}
+
 func (e *InterruptedError) Value() interface{} {
 	return e.iface
 }
@@ -199,6 +318,15 @@ func (e *InterruptedError) String() string {
 	return b.String()
 }
 
+// Unwrap returns the value passed to Interrupt() if it is an error, e.g. the error of the context of
+// RunProgramContext() or CallContext(), so that errors.Is(err, context.DeadlineExceeded) works.
+func (e *InterruptedError) Unwrap() error {
+	if err, ok := e.iface.(error); ok {
+		return err
+	}
+	return nil
+}
+
 func (e *InterruptedError) Error() string {
 	if e == nil || e.iface == nil {
 		return "<nil>"
@@ -257,17 +385,23 @@ func (r *Runtime) addToGlobal(name string, value Value) {
 
 func (r *Runtime) init() {
 	r.rand = rand.Float64
-	r.global.ObjectPrototype = r.newBaseObject(nil, classObject).val
-	r.globalObject = r.NewObject()
-
 	r.vm = &vm{
 		r: r,
 	}
 	r.vm.init()
+	r.global.ObjectPrototype = r.newBaseObject(nil, classObject).val
+	r.globalObject = r.NewObject()
+	r.globalLexicals = &stash{
+		lexical: true,
+	}
+
+	r.callbackWakeup = make(chan struct{}, 1)
 
 	r.global.FunctionPrototype = r.newNativeFunc(nil, nil, "Empty", nil, 0)
 	r.initObject()
 	r.initFunction()
+	r.initIterators()
+	r.initGenerator()
 	r.initArray()
 	r.initString()
 	r.initNumber()
@@ -275,6 +409,15 @@ func (r *Runtime) init() {
 	r.initDate()
 	r.initBoolean()
 	r.initProxy()
+	r.initReflect()
+	r.initSymbol()
+	r.initBigInt()
+	r.initMap()
+	r.initSet()
+	r.initWeakMap()
+	r.initWeakSet()
+	r.initPromise()
+	r.initAsync()
 
 	r.initErrors()
 
@@ -286,7 +429,7 @@ func (r *Runtime) init() {
 	r.initMath()
 	r.initJSON()
 
-	//r.initTypedArrays()
+	r.initTypedArrays()
 
 	r.global.thrower = r.newNativeFunc(r.builtin_thrower, nil, "thrower", nil, 0)
 	r.global.throwerProperty = &valueProperty{
@@ -296,6 +439,14 @@ func (r *Runtime) init() {
 	}
 }
 
+// typeError is raised (via panic) by code that has no access to a Runtime, such as conversions of Symbol values.
+// It is converted into a TypeError when it reaches the vm.
+type typeError string
+
+func (e typeError) Error() string {
+	return string(e)
+}
+
 func (r *Runtime) typeErrorResult(throw bool, args ...interface{}) {
 	if throw {
 		panic(r.NewTypeError(args...))
@@ -311,6 +462,18 @@ func (r *Runtime) throwReferenceError(name string) {
 	panic(r.newError(r.global.ReferenceError, "%s is not defined", name))
 }
 
+func (r *Runtime) throwUninitializedError(name string) {
+	panic(r.newError(r.global.ReferenceError, "Cannot access '%s' before initialization", name))
+}
+
+func (r *Runtime) throwConstAssignmentError() {
+	panic(r.NewTypeError("Assignment to constant variable."))
+}
+
+func (r *Runtime) throwRedeclarationError(name string) {
+	panic(r.newError(r.global.SyntaxError, "Identifier '%s' has already been declared", name))
+}
+
 func (r *Runtime) newSyntaxError(msg string, offset int) Value {
 	return r.builtin_new((r.global.SyntaxError), []Value{newStringValue(msg)})
 }
@@ -342,13 +505,58 @@ func (r *Runtime) newArrayValues(values []Value) *Object {
 	v.self = a
 	a.prototype = r.global.ArrayPrototype
 	a.init()
+	r.vm.allocate(int64(len(values)) * valueAllocSize)
 	a.values = values
 	a.length = int64(len(values))
 	a.objCount = a.length
 	return v
 }
 
+// addTemplateObject caches the strings array of a tagged template call site. The entries of the call sites whose code
+// has been collected are removed each time the size of the cache doubles.
+func (r *Runtime) addTemplateObject(site *templateSite, obj *Object) {
+	if r.templateObjects == nil {
+		r.templateObjects = make(map[*templateSite]*Object)
+	}
+	if len(r.templateObjects) >= r.templateObjectsPurgeAt {
+		r.removeDeadTemplateObjects()
+	}
+	r.templateObjects[site] = obj
+}
+
+func (r *Runtime) removeDeadTemplateObjects() {
+	removeDeadTemplateObjects(r.templateObjects)
+	r.templateObjectsPurgeAt = 2*len(r.templateObjects) + 64
+}
+
+func removeDeadTemplateObjects(m map[*templateSite]*Object) {
+	for site := range m {
+		if atomic.LoadInt32(&site.dead) != 0 {
+			delete(m, site)
+		}
+	}
+}
+
+// newTemplateObject creates the frozen strings array (with the frozen raw strings array as its "raw" property)
+// that is passed as the first argument to a template tag function.
+func (r *Runtime) newTemplateObject(cooked, raw []Value) *Object {
+	values := make([]Value, len(cooked))
+	for i, v := range cooked {
+		if v == nil {
+			v = _undefined
+		}
+		values[i] = v
+	}
+	obj := r.newArrayValues(values)
+	rawObj := r.newArrayValues(append([]Value(nil), raw...))
+	r.object_freeze(FunctionCall{Arguments: []Value{rawObj}})
+	obj.self._putProp("raw", rawObj, false, false, false)
+	r.object_freeze(FunctionCall{Arguments: []Value{obj}})
+	return obj
+}
+
 func (r *Runtime) newArrayLength(l int64) *Object {
+	r.vm.allocate(l * valueAllocSize)
 	a := r.newArrayValues(nil)
 	a.self.putStr("length", intToValue(l), true)
 	return a
@@ -547,9 +755,19 @@ func (r *Runtime) newPrimitiveObject(value Value, proto *Object, class string) *
 	return v
 }
 
+// toNumberConversion implements the conversion done by Number(): unlike ToNumber it accepts BigInt values.
+func toNumberConversion(v Value) Value {
+	v = toNumeric(v)
+	if b, ok := v.(*valueBigInt); ok {
+		f, _ := new(big.Float).SetInt(b.toBig()).Float64()
+		return floatToValue(f)
+	}
+	return v
+}
+
 func (r *Runtime) builtin_Number(call FunctionCall) Value {
 	if len(call.Arguments) > 0 {
-		return call.Arguments[0].ToNumber()
+		return toNumberConversion(call.Arguments[0])
 	} else {
 		return intToValue(0)
 	}
@@ -558,7 +776,7 @@ func (r *Runtime) builtin_Number(call FunctionCall) Value {
 func (r *Runtime) builtin_newNumber(args []Value) *Object {
 	var v Value
 	if len(args) > 0 {
-		v = args[0].ToNumber()
+		v = toNumberConversion(args[0])
 	} else {
 		v = intToValue(0)
 	}
@@ -614,11 +832,99 @@ func (r *Runtime) error_toString(call FunctionCall) Value {
 }
 
 func (r *Runtime) builtin_Error(args []Value, proto *Object) *Object {
-	obj := r.newBaseObject(proto, classError)
+	v := &Object{runtime: r}
+	obj := &errorObject{}
+	obj.class = classError
+	obj.val = v
+	obj.extensible = true
+	obj.prototype = proto
+	v.self = obj
+	obj.init()
 	if len(args) > 0 && args[0] != _undefined {
 		obj._putProp("message", args[0], true, false, true)
 	}
-	return obj.val
+	r.captureErrorStack(obj)
+	return v
+}
+
+// captureErrorStack defines the stack property of an error created while code is running. As in V8, it holds the
+// result of toString() followed by the call stack. The frames are captured now but only formatted when the property
+// is first read, until then it holds the result of toString().
+func (r *Runtime) captureErrorStack(obj *errorObject) {
+	vm := r.vm
+	if vm.prg == nil && len(vm.callStack) == 0 {
+		return
+	}
+	obj.stackProp = obj._putProp("stack", r.error_toString(FunctionCall{This: obj.val}), true, false, true).(*valueProperty)
+	obj.stackFrames = vm.captureStack(nil, 0)
+}
+
+// errorObject is an instance of Error or of one of the native error types.
+type errorObject struct {
+	baseObject
+	stackProp   *valueProperty
+	stackFrames []StackFrame
+}
+
+// formatStack formats the stack property if name refers to it and it has not been formatted yet.
+func (e *errorObject) formatStack(name string) {
+	if e.stackFrames == nil || name != "stack" {
+		return
+	}
+	var b bytes.Buffer
+	b.WriteString(e.stackProp.value.String())
+	for _, frame := range e.stackFrames {
+		b.WriteString("\n    at ")
+		frame.Write(&b)
+	}
+	e.stackProp.value = newStringValue(b.String())
+	e.stackFrames = nil
+}
+
+func (e *errorObject) formatStackValue(n Value) {
+	if _, ok := n.(*Symbol); !ok {
+		e.formatStack(n.String())
+	}
+}
+
+func (e *errorObject) getPropStr(name string) Value {
+	e.formatStack(name)
+	return e.baseObject.getPropStr(name)
+}
+
+func (e *errorObject) getOwnProp(name string) Value {
+	e.formatStack(name)
+	return e.baseObject.getOwnProp(name)
+}
+
+func (e *errorObject) getOwnPropertyDescriptor(name string) Value {
+	e.formatStack(name)
+	return e.baseObject.getOwnPropertyDescriptor(name)
+}
+
+func (e *errorObject) put(n Value, val Value, throw bool) {
+	e.formatStackValue(n)
+	e.baseObject.put(n, val, throw)
+}
+
+func (e *errorObject) putStr(name string, val Value, throw bool) {
+	e.formatStack(name)
+	e.baseObject.putStr(name, val, throw)
+}
+
+func (e *errorObject) defineOwnProperty(n Value, descr PropertyDescriptor, throw bool) bool {
+	e.formatStackValue(n)
+	return e.baseObject.defineOwnProperty(n, descr, throw)
+}
+
+func (e *errorObject) deleteStr(name string, throw bool) bool {
+	e.formatStack(name)
+	return e.baseObject.deleteStr(name, throw)
+}
+
+func (e *errorObject) delete(n Value, throw bool) bool {
+	e.formatStackValue(n)
+	return e.baseObject.delete(n, throw)
 }
 
 func (r *Runtime) builtin_new(construct *Object, args []Value) *Object {
@@ -664,6 +970,9 @@ func (r *Runtime) eval(src string, direct, strict bool, this Value) Value {
 	}
 
 	vm := r.vm
+	if vm.dbg != nil {
+		vm.dbg.addProgram(p)
+	}
 
 	vm.pushCtx()
 	vm.prg = p
@@ -702,6 +1011,14 @@ func (r *Runtime) constructWrap(construct func(args []Value, proto *Object) *Obj
 	}
 }
 
+// constructorRequiresNew returns the call function of a constructor that cannot be called without 'new'.
+func (r *Runtime) constructorRequiresNew(name string) func(call FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		r.typeErrorResult(true, "Constructor %s requires 'new'", name)
+		return nil
+	}
+}
+
 func (r *Runtime) toCallable(v Value) func(FunctionCall) Value {
 	if call, ok := r.toObject(v).self.assertCallable(); ok {
 		return call
@@ -710,6 +1027,95 @@ func (r *Runtime) toCallable(v Value) func(FunctionCall) Value {
 	return nil
 }
 
+// toMethod returns the call function of v, or nil if v is undefined or null. It panics if v is not callable.
+func toMethod(v Value) func(FunctionCall) Value {
+	if v == nil || IsUndefined(v) || IsNull(v) {
+		return nil
+	}
+	if obj, ok := v.(*Object); ok {
+		if call, ok := obj.self.assertCallable(); ok {
+			return call
+		}
+	}
+	panic(typeError(fmt.Sprintf("%s is not a method", v.String())))
+}
+
+// assertCallable returns the call function of v if v is a callable object.
+func assertCallable(v Value) (func(FunctionCall) Value, bool) {
+	if obj, ok := v.(*Object); ok {
+		return obj.self.assertCallable()
+	}
+	return nil, false
+}
+
+// toConstructor returns the construct function of v, or nil if v is not a constructor.
+func (r *Runtime) toConstructor(v Value) func(args []Value) *Object {
+	obj, ok := v.(*Object)
+	if !ok {
+		return nil
+	}
+repeat:
+	switch f := obj.self.(type) {
+	case *funcObject:
+		if !f.arrow {
+			return f.construct
+		}
+	case *nativeFuncObject:
+		return f.construct
+	case *boundFuncObject:
+		return f.construct
+	case *proxyObject:
+		return func(args []Value) *Object {
+			return r.toObject(f.construct(args))
+		}
+	case *lazyObject:
+		obj.self = f.create(obj)
+		goto repeat
+	}
+	return nil
+}
+
+// construct calls the constructor ctor on behalf of newTarget, which is a derived class when it is called by
+// super(). Constructors that are not compiled functions create the instance with their own prototype, it is
+// replaced by the prototype of newTarget afterwards.
+func (r *Runtime) construct(ctor *Object, args []Value, newTarget *Object) *Object {
+	c := r.toConstructor(ctor)
+	if c == nil {
+		r.typeErrorResult(true, "%s is not a constructor", ctor.String())
+	}
+	if f, ok := ctor.self.(*funcObject); ok {
+		return f.constructWith(args, newTarget)
+	}
+	obj := c(args)
+	if newTarget != ctor {
+		if proto, ok := newTarget.self.getStr("prototype").(*Object); ok {
+			obj.self.setProto(proto)
+		}
+	}
+	return obj
+}
+
+// toPropertyKey converts an object used as a property key into a primitive value (a string, number or Symbol).
+// Other values are returned unchanged.
+func toPropertyKey(key Value) Value {
+	if o, ok := key.(*Object); ok {
+		return o.self.toPrimitiveString()
+	}
+	return key
+}
+
+// instanceOf implements the instanceof operator. The @@hasInstance method of o takes precedence over the
+// ordinary prototype chain check.
+func (r *Runtime) instanceOf(v Value, o *Object) bool {
+	if instOfHandler := toMethod(o.self.get(SymHasInstance)); instOfHandler != nil {
+		return instOfHandler(FunctionCall{
+			This:      o,
+			Arguments: []Value{v},
+		}).ToBoolean()
+	}
+	return o.self.hasInstance(v)
+}
+
 func (r *Runtime) checkObjectCoercible(v Value) {
 	switch v.(type) {
 	case valueUndefined, valueNull:
@@ -796,6 +1202,22 @@ func Compile(name, src string, strict bool) (*Program, error) {
 	return compile(name, src, strict, false)
 }
 
+// Parse parses the JavaScript code, the result can be compiled with CompileAST(). Unlike Compile() it accepts the
+// options of the parser, e.g.:
+//
+//	prg, err := Parse("app.js", src, parser.WithSourceMapLoader(loader))
+//	if err != nil {
+//	    // ...
+//	}
+//	p, err := CompileAST(prg, false)
+func Parse(name, src string, options ...parser.Option) (*js_ast.Program, error) {
+	prg, err := parser.ParseFile(nil, name, src, 0, options...)
+	if err != nil {
+		return nil, parserError(err)
+	}
+	return prg, nil
+}
+
 // CompileAST creates an internal representation of the JavaScript code that can be later run using the Runtime.RunProgram()
 // method. This representation is not linked to a runtime in any way and can be run in multiple runtimes (possibly
 // at the same time).
@@ -814,26 +1236,10 @@ func MustCompile(name, src string, strict bool) *Program {
 	return prg
 }
 
-func compile(name, src string, strict, eval bool) (p *Program, err error) {
-	prg, err1 := parser.ParseFile(nil, name, src, 0)
+func compile(name, src string, strict, eval bool, parserOptions ...parser.Option) (p *Program, err error) {
+	prg, err1 := parser.ParseFile(nil, name, src, 0, parserOptions...)
 	if err1 != nil {
-		switch err1 := err1.(type) {
-		case parser.ErrorList:
-			if len(err1) > 0 && err1[0].Message == "Invalid left-hand side in assignment" {
-				err = &CompilerReferenceError{
-					CompilerError: CompilerError{
-						Message: err1.Error(),
-					},
-				}
-				return
-			}
-		}
-		// FIXME offset
-		err = &CompilerSyntaxError{
-			CompilerError: CompilerError{
-				Message: err1.Error(),
-			},
-		}
+		err = parserError(err1)
 		return
 	}
 
@@ -842,6 +1248,27 @@ func compile(name, src string, strict, eval bool) (p *Program, err error) {
 	return
 }
 
+// parserError converts an error returned by the parser into a *CompilerSyntaxError or a *CompilerReferenceError. The
+// message of a parser.ErrorList includes the file name and the position of the first error.
+func parserError(err error) error {
+	switch err1 := err.(type) {
+	case parser.ErrorList:
+		if len(err1) > 0 && err1[0].Message == "Invalid left-hand side in assignment" {
+			return &CompilerReferenceError{
+				CompilerError: CompilerError{
+					Message: err1.Error(),
+				},
+			}
+		}
+	}
+	// FIXME offset
+	return &CompilerSyntaxError{
+		CompilerError: CompilerError{
+			Message: err.Error(),
+		},
+	}
+}
+
 func compileAST(prg *js_ast.Program, strict, eval bool) (p *Program, err error) {
 	c := newCompiler()
 	c.scope.strict = strict
@@ -865,7 +1292,7 @@ func compileAST(prg *js_ast.Program, strict, eval bool) (p *Program, err error)
 }
 
 func (r *Runtime) compile(name, src string, strict, eval bool) (p *Program, err error) {
-	p, err = compile(name, src, strict, eval)
+	p, err = compile(name, src, strict, eval, r.parserOptions...)
 	if err != nil {
 		switch x1 := err.(type) {
 		case *CompilerSyntaxError:
@@ -888,7 +1315,7 @@ func (r *Runtime) RunString(str string) (Value, error) {
 
 // RunScript executes the given string in the global context.
 func (r *Runtime) RunScript(name, src string) (Value, error) {
-	p, err := Compile(name, src, false)
+	p, err := compile(name, src, false, false, r.parserOptions...)
 
 	if err != nil {
 		return nil, err
@@ -901,8 +1328,10 @@ func (r *Runtime) RunScript(name, src string) (Value, error) {
 func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
 	defer func() {
 		if x := recover(); x != nil {
-			if intr, ok := x.(*InterruptedError); ok {
+			if intr, ok := x.(uncatchableError); ok {
 				err = intr
+			} else if p.unverified {
+				err = corruptedProgramError(x)
 			} else {
 				panic(x)
 			}
@@ -913,6 +1342,9 @@ func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
 		recursive = true
 		r.vm.pushCtx()
 	}
+	if r.vm.dbg != nil {
+		r.vm.dbg.addProgram(p)
+	}
 	r.vm.prg = p
 	r.vm.pc = 0
 	ex := r.vm.runTry()
@@ -931,6 +1363,65 @@ func (r *Runtime) RunProgram(p *Program) (result Value, err error) {
 	return
 }
 
+// RunProgramContext is like RunProgram() but the execution is interrupted when ctx is done, in which case the
+// returned error is an *InterruptedError wrapping ctx.Err(). The context is available to the native functions
+// through FunctionCall.Context().
+func (r *Runtime) RunProgramContext(ctx gocontext.Context, p *Program) (Value, error) {
+	defer r.withContext(ctx)()
+	return r.RunProgram(p)
+}
+
+// CallContext calls fn (which must have been obtained from this Runtime with AssertFunction()) in the same way as
+// RunProgramContext() runs a program.
+func (r *Runtime) CallContext(ctx gocontext.Context, fn Callable, this Value, args ...Value) (Value, error) {
+	defer r.withContext(ctx)()
+	return fn(this, args...)
+}
+
+// withContext makes ctx the context of the execution and interrupts it when ctx is done. The returned function
+// restores the previous context, it also clears the interrupt if it has been caused by ctx but not observed before
+// the execution has finished.
+func (r *Runtime) withContext(ctx gocontext.Context) func() {
+	vm := r.vm
+	oldCtx := vm.ctx
+	vm.ctx = ctx
+	done := ctx.Done()
+	if done == nil {
+		return func() {
+			vm.ctx = oldCtx
+		}
+	}
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	var err error
+	interrupt := func() {
+		err = ctx.Err()
+		vm.Interrupt(err)
+	}
+	select {
+	case <-done:
+		interrupt()
+		close(stopped)
+	default:
+		go func() {
+			select {
+			case <-done:
+				interrupt()
+			case <-stop:
+			}
+			close(stopped)
+		}()
+	}
+	return func() {
+		close(stop)
+		<-stopped
+		if err != nil {
+			vm.clearInterrupt(err)
+		}
+		vm.ctx = oldCtx
+	}
+}
+
 func (r *Runtime) CaptureCallStack(n int) []StackFrame {
 	m := len(r.vm.callStack)
 	if n > 0 {
@@ -949,6 +1440,13 @@ func (r *Runtime) Interrupt(v interface{}) {
 	r.vm.Interrupt(v)
 }
 
+// ClearInterrupt cancels an Interrupt() that has not been observed yet, i.e. which has been called while no
+// JavaScript code was running or after it has finished. Otherwise the next execution would be interrupted
+// immediately.
+func (r *Runtime) ClearInterrupt() {
+	r.vm.ClearInterrupt()
+}
+
 func (r *Runtime) CreateFunctionProxy(call func(FunctionCall) Value, construct func([]Value) *Object) Value {
 	return r.newNativeFunc(call, construct, "", nil, 0)
 }
@@ -958,6 +1456,9 @@ ToValue converts a Go value into JavaScript value.
 
 Primitive types (ints and uints, floats, string, bool) are converted to the corresponding JavaScript primitives.
 
+*big.Int and big.Int are converted into BigInt primitives. The value is copied, Export() on a BigInt returns a new
+*big.Int.
+
 func(FunctionCall) Value is treated as a native JavaScript function.
 
 func(ConstructorCall) *Object is treated as a proxy interceptor call for a constructor. Useful to implement the ECMA6 Proxy pattern
@@ -969,11 +1470,17 @@ because extending it can change the pointer so it becomes detached from the orig
 
 *[]interface{} same as above, but the array becomes extensible.
 
+[]byte is converted into a Uint8Array and []float64 into a Float64Array. Both share the memory with the original slice,
+so the changes made on either side are visible on the other. Export() on a typed array returns a Go slice of the
+corresponding type ([]int8, []byte, []int16, etc.) which shares the memory with the array's buffer where possible.
+
 A function is wrapped within a native JavaScript function. When called the arguments are automatically converted to
 the appropriate Go types. If conversion is not possible, a TypeError is thrown.
 
 A slice type is converted into a generic reflect based host object that behaves similar to an unexpandable Array.
 
+If SetGoMapsAsMaps(true) has been called, maps (including map[string]interface{}) are copied into new Map instances.
+
 Any other type is converted to a generic reflect based host object. Depending on the underlying type it behaves similar
 to a Number, String, Boolean or Object.
 
@@ -1007,6 +1514,8 @@ func (r *Runtime) ToValue(i interface{}) Value {
 			r.typeErrorResult(true, "Illegal runtime transition for proxy")
 		}
 		return proxy
+	case *Promise:
+		return i.val
 	case int:
 		return intToValue(int64(i))
 	case int8:
@@ -1038,7 +1547,17 @@ func (r *Runtime) ToValue(i interface{}) Value {
 		return floatToValue(float64(i))
 	case float64:
 		return floatToValue(i)
+	case *big.Int:
+		if i == nil {
+			return _null
+		}
+		return (*valueBigInt)(new(big.Int).Set(i))
+	case big.Int:
+		return (*valueBigInt)(new(big.Int).Set(&i))
 	case map[string]interface{}:
+		if r.goMapsAsMaps {
+			return r.newMapFromGo(reflect.ValueOf(i))
+		}
 		obj := &Object{runtime: r}
 		m := &objectGoMapSimple{
 			baseObject: baseObject{
@@ -1075,6 +1594,12 @@ func (r *Runtime) ToValue(i interface{}) Value {
 		return obj
 	case []Value:
 		return r.newArrayValues(i)
+	case []byte:
+		return r.newTypedArrayObject(typedArrayUint8, r.newArrayBufferData(i), 0, len(i), r.global.Uint8ArrayPrototype).val
+	case []float64:
+		if len(i) <= maxArrayBufferLength/8 {
+			return r.newTypedArrayObject(typedArrayFloat64, r.newArrayBufferData(float64SliceBytes(i)), 0, len(i), r.global.Float64ArrayPrototype).val
+		}
 	}
 
 	origValue := reflect.ValueOf(i)
@@ -1089,6 +1614,9 @@ func (r *Runtime) ToValue(i interface{}) Value {
 
 	switch value.Kind() {
 	case reflect.Map:
+		if r.goMapsAsMaps {
+			return r.newMapFromGo(value)
+		}
 		if value.Type().NumMethod() == 0 {
 			switch value.Type().Key().Kind() {
 			case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
@@ -1267,6 +1795,21 @@ func (r *Runtime) wrapReflectFunc(value reflect.Value) func(FunctionCall) Value
 }
 
 func (r *Runtime) toReflectValue(v Value, typ reflect.Type) (reflect.Value, error) {
+	if b, ok := v.(*valueBigInt); ok {
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			// the value is truncated like by BigInt.asIntN()
+			return reflect.ValueOf(int64(bigIntToUint64(b.toBig()))).Convert(typ), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return reflect.ValueOf(bigIntToUint64(b.toBig())).Convert(typ), nil
+		case reflect.Float32, reflect.Float64:
+			f, _ := new(big.Float).SetInt(b.toBig()).Float64()
+			return reflect.ValueOf(f).Convert(typ), nil
+		}
+		if typ == reflectTypeBigInt.Elem() {
+			return reflect.ValueOf(b.Export()).Elem(), nil
+		}
+	}
 	switch typ.Kind() {
 	case reflect.String:
 		return reflect.ValueOf(v.String()).Convert(typ), nil
@@ -1330,7 +1873,21 @@ func (r *Runtime) toReflectValue(v Value, typ reflect.Type) (reflect.Value, erro
 	switch typ.Kind() {
 	case reflect.Slice:
 		if o, ok := v.(*Object); ok {
-			if o.self.className() == classArray {
+			switch o := o.self.(type) {
+			case *mapObject:
+				return r.mapToPairs(o, typ)
+			case *setObject:
+				s := reflect.MakeSlice(typ, 0, o.m.size)
+				for e := o.m.first; e != nil; e = e.next {
+					item, err := r.toReflectValue(e.key, typ.Elem())
+					if err != nil {
+						return reflect.Value{}, fmt.Errorf("Could not convert set value %v to %v", e.key, typ)
+					}
+					s = reflect.Append(s, item)
+				}
+				return s, nil
+			}
+			if _, isTypedArray := o.self.(*typedArrayObject); isTypedArray || o.self.className() == classArray {
 				l := int(toLength(o.self.getStr("length")))
 				s := reflect.MakeSlice(typ, l, l)
 				elemTyp := typ.Elem()
@@ -1347,6 +1904,9 @@ func (r *Runtime) toReflectValue(v Value, typ reflect.Type) (reflect.Value, erro
 		}
 	case reflect.Map:
 		if o, ok := v.(*Object); ok {
+			if mo, ok := o.self.(*mapObject); ok {
+				return r.mapToGoMap(mo, typ)
+			}
 			m := reflect.MakeMap(typ)
 			keyTyp := typ.Key()
 			elemTyp := typ.Elem()
@@ -1407,6 +1967,53 @@ func (r *Runtime) toReflectValue(v Value, typ reflect.Type) (reflect.Value, erro
 	return reflect.Value{}, fmt.Errorf("Could not convert %v to %v", v, typ)
 }
 
+// mapToGoMap converts the entries of a Map into a Go map of the specified type.
+func (r *Runtime) mapToGoMap(mo *mapObject, typ reflect.Type) (reflect.Value, error) {
+	m := reflect.MakeMapWithSize(typ, mo.m.size)
+	keyTyp := typ.Key()
+	elemTyp := typ.Elem()
+	for e := mo.m.first; e != nil; e = e.next {
+		kv, err := r.toReflectValue(e.key, keyTyp)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("Could not convert map key %v to %v", e.key, typ)
+		}
+		vv, err := r.toReflectValue(e.value, elemTyp)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("Could not convert map value %v to %v at key %v", e.value, typ, e.key)
+		}
+		m.SetMapIndex(kv, vv)
+	}
+	return m, nil
+}
+
+// mapToPairs converts the entries of a Map into a slice of key-value pairs, preserving the order. The element type
+// of the slice must be an array of length 2 or a slice, for example [][2]interface{}.
+func (r *Runtime) mapToPairs(mo *mapObject, typ reflect.Type) (reflect.Value, error) {
+	pairTyp := typ.Elem()
+	switch {
+	case pairTyp.Kind() == reflect.Array && pairTyp.Len() == 2, pairTyp.Kind() == reflect.Slice:
+	default:
+		return reflect.Value{}, fmt.Errorf("Could not convert Map to %v", typ)
+	}
+	s := reflect.MakeSlice(typ, mo.m.size, mo.m.size)
+	i := 0
+	for e := mo.m.first; e != nil; e = e.next {
+		pair := s.Index(i)
+		if pairTyp.Kind() == reflect.Slice {
+			pair.Set(reflect.MakeSlice(pairTyp, 2, 2))
+		}
+		for j, item := range [2]Value{e.key, e.value} {
+			vv, err := r.toReflectValue(item, pairTyp.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("Could not convert map entry %v to %v at %d", item, typ, i)
+			}
+			pair.Index(j).Set(vv)
+		}
+		i++
+	}
+	return s, nil
+}
+
 func (r *Runtime) wrapJSFunc(fn Callable, typ reflect.Type) func(args []reflect.Value) (results []reflect.Value) {
 	return func(args []reflect.Value) (results []reflect.Value) {
 		jsArgs := make([]Value, len(args))
@@ -1471,11 +2078,25 @@ func (r *Runtime) Get(name string) Value {
 	return r.globalObject.self.getStr(name)
 }
 
+// SetGoMapsAsMaps controls how ToValue converts Go maps. By default a Go map is wrapped into a host object whose
+// properties reflect the map contents. If enabled, the entries are copied into a new Map instance instead (converting
+// the keys and the values with ToValue), so that keys of any type are supported. Later changes of the Go map are not
+// reflected by the copy.
+func (r *Runtime) SetGoMapsAsMaps(enabled bool) {
+	r.goMapsAsMaps = enabled
+}
+
 // SetRandSource sets random source for this Runtime. If not called, the default math/rand is used.
 func (r *Runtime) SetRandSource(source RandSource) {
 	r.rand = source
 }
 
+// SetParserOptions sets the options of the parser used by RunString(), RunScript(), eval(), the Function constructor
+// and the module loading, e.g. parser.WithSourceMapLoader(). They have no effect on Compile().
+func (r *Runtime) SetParserOptions(options ...parser.Option) {
+	r.parserOptions = options
+}
+
 // Callable represents a JavaScript function that can be called from Go.
 type Callable func(this Value, args ...Value) (Value, error)
 
@@ -1486,7 +2107,7 @@ func AssertFunction(v Value) (Callable, bool) {
 			return func(this Value, args ...Value) (ret Value, err error) {
 				defer func() {
 					if x := recover(); x != nil {
-						if ex, ok := x.(*InterruptedError); ok {
+						if ex, ok := x.(uncatchableError); ok {
 							err = ex
 						} else {
 							panic(x)
@@ -1537,12 +2158,14 @@ func tryFunc(f func()) (err error) {
 			switch x := x.(type) {
 			case *Exception:
 				err = x
-			case *InterruptedError:
+			case uncatchableError:
 				err = x
 			case Value:
 				err = &Exception{
 					val: x,
 				}
+			case typeError:
+				err = x
 			default:
 				panic(x)
 			}
@@ -1,9 +1,11 @@
 package goja
 
 import (
+	gocontext "context"
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -1013,6 +1015,39 @@ func TestCreateObject(t *testing.T) {
 	}
 }
 
+func TestNativeConstructorSubclass(t *testing.T) {
+	const SCRIPT = `
+	class G extends F {
+		constructor(x) {
+			super(x * 2);
+			this.own = true;
+		}
+		extra() {
+			return this.method() + this.base;
+		}
+	}
+	var g = new G(20);
+	[g instanceof G, g instanceof F, g.extra(), g.own].join();
+	`
+
+	r := New()
+	r.Set("F", func(call ConstructorCall) *Object {
+		call.This.Set("base", call.Argument(0))
+		call.This.Set("method", func(FunctionCall) Value {
+			return r.ToValue(42)
+		})
+		return nil
+	})
+
+	res, err := r.RunString(SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.String() != "true,true,82,true" {
+		t.Fatalf("Unexpected result: %v", res)
+	}
+}
+
 func TestInterruptInWrappedFunction(t *testing.T) {
 	rt := New()
 	v, err := rt.RunString(`
@@ -1042,6 +1077,86 @@ func TestInterruptInWrappedFunction(t *testing.T) {
 	}
 }
 
+func TestRunProgramContext(t *testing.T) {
+	vm := New()
+	p := MustCompile("", "for (;;) {}", false)
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := vm.RunProgramContext(ctx, p)
+	if _, ok := err.(*InterruptedError); !ok {
+		t.Fatalf("Wrong error type: %T", err)
+	}
+	if !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// the context is done already
+	_, err = vm.RunProgramContext(ctx, MustCompile("", "1", false))
+	if !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	v, err := vm.RunProgramContext(gocontext.Background(), MustCompile("", "1 + 1", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 2 {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	type ctxKey struct{}
+	vm := New()
+	vm.Set("getValue", func(call FunctionCall) Value {
+		return vm.ToValue(call.Context().Value(ctxKey{}))
+	})
+	_, err := vm.RunString(`
+	function f() {
+		return [getValue(), [0].map(getValue)[0]];
+	}
+	function loop() {
+		for (;;) {}
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, _ := AssertFunction(vm.Get("f"))
+	ctx := gocontext.WithValue(gocontext.Background(), ctxKey{}, "value")
+	v, err := vm.CallContext(ctx, f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "value,value" {
+		t.Fatalf("Unexpected result: %q", s)
+	}
+	if v, _ := f(nil); v.String() != "," {
+		t.Fatalf("Unexpected result without a context: %v", v)
+	}
+
+	loop, _ := AssertFunction(vm.Get("loop"))
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = vm.CallContext(ctx, loop, nil)
+	if !errors.Is(err, gocontext.Canceled) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestClearInterrupt(t *testing.T) {
+	vm := New()
+	vm.Interrupt("halt")
+	vm.ClearInterrupt()
+	v, err := vm.RunString("1 + 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ToInteger() != 2 {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+}
+
 /*
 func TestArrayConcatSparse(t *testing.T) {
 function foo(a,b,c)
@@ -1092,3 +1207,62 @@ func BenchmarkCallNative(b *testing.B) {
 		vm.RunProgram(prg)
 	}
 }
+
+func TestErrorStack(t *testing.T) {
+	const SCRIPT = `
+	function f() {
+		return new TypeError("msg");
+	}
+	var e = f();
+	var desc = Object.getOwnPropertyDescriptor(e, "stack");
+	assert(desc.writable && !desc.enumerable && desc.configurable, "descriptor");
+	var lines = e.stack.split("\n");
+	assert.sameValue(lines[0], "TypeError: msg");
+	assert(/^    at f \(test\.js:\d+:\d+\(\d+\)\)$/.test(lines[1]), lines[1]);
+	assert.sameValue(lines.length, 3, "lines");
+	assert.sameValue(Object.prototype.hasOwnProperty.call(TypeError.prototype, "stack"), false, "prototype");
+	`
+	testScript1(TESTLIB+SCRIPT, _undefined, t)
+}
+
+func TestErrorStackLazy(t *testing.T) {
+	vm := New()
+	v, err := vm.RunScript("test.js", `
+	function f() {
+		return [new Error("msg"), new RangeError("range")];
+	}
+	var errs = f();
+	errs[0].message = "changed";
+	errs[1].stack = "custom";
+	errs;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arr := v.(*Object)
+	e0 := arr.Get("0").(*Object)
+	e1 := arr.Get("1").(*Object)
+	if e := e0.self.(*errorObject); e.stackFrames == nil {
+		t.Fatal("The stack has been formatted before it is read")
+	}
+	if s := e0.Get("stack").String(); !strings.HasPrefix(s, "Error: msg\n    at f (test.js:3:11(3))") {
+		t.Fatalf("Unexpected stack: %q", s)
+	}
+	if e := e0.self.(*errorObject); e.stackFrames != nil {
+		t.Fatal("The frames have not been released")
+	}
+	if s := e1.Get("stack").String(); s != "custom" {
+		t.Fatalf("Unexpected stack: %q", s)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := Parse("test.js", "var x = 1;\nx +;")
+	if err, ok := err.(*CompilerSyntaxError); !ok || !strings.Contains(err.Error(), "test.js: Line 2:4") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err = Parse("test.js", "1 = 2")
+	if _, ok := err.(*CompilerReferenceError); !ok {
+		t.Fatalf("Unexpected error: %T (%v)", err, err)
+	}
+}
@@ -0,0 +1,572 @@
+package goja
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+var (
+	gojaPkgPath = reflect.TypeOf(Object{}).PkgPath()
+
+	typeWeakMap = reflect.TypeOf(weakMap{})
+
+	// the structures which are not part of the state of a Runtime or which are never modified once created
+	snapshotSkipTypes = map[reflect.Type]bool{
+		reflect.TypeOf(Runtime{}):      true,
+		reflect.TypeOf(vm{}):           true,
+		reflect.TypeOf(Program{}):      true,
+		reflect.TypeOf(Symbol{}):       true,
+		reflect.TypeOf(valueBigInt{}):  true,
+		reflect.TypeOf(moduleInfo{}):   true,
+		reflect.TypeOf(templateSite{}): true,
+	}
+)
+
+// snapshotEntry is the saved state of a structure. The image is a copy of the structure as it has been left by the
+// last restore (or the snapshot), it is used to skip the structures which have not been modified since.
+type snapshotEntry struct {
+	ptr          unsafe.Pointer
+	saved, image reflect.Value
+	size         uintptr
+	fields       []containerField
+}
+
+type weakMapSnapshot struct {
+	m    *weakMap
+	data map[uint64]Value
+}
+
+// Snapshot is the state of a Runtime taken by Runtime.Snapshot(), see Runtime.Reset().
+type Snapshot struct {
+	r *Runtime
+
+	global          global
+	symbolRegistry  map[string]*Symbol
+	templateObjects map[*templateSite]*Object
+	modules         map[string]*module
+
+	entries  []snapshotEntry
+	weakMaps []weakMapSnapshot
+}
+
+type snapshotter struct {
+	s       *Snapshot
+	visited map[snapshotKey]struct{}
+}
+
+type snapshotKey struct {
+	t reflect.Type
+	p unsafe.Pointer
+}
+
+// Snapshot takes a snapshot of the current state of the Runtime, i.e. of the global object, the global lexical
+// bindings, the loaded modules and of all the objects reachable from them, including the built-ins and the globals
+// set by the host. The Runtime can later be returned to this state with Reset(), which is cheaper than creating and
+// setting up a new Runtime, as only the objects which have been modified are restored.
+//
+// Only the state held by the Runtime is recorded: the Go values wrapped into JavaScript objects (see ToValue()) and
+// the values captured by Go closures (such as the ones passed to Set()) are not restored by Reset(). The built-in
+// objects are created on first use, those which have not been used before the snapshot are re-created after a Reset()
+// as well.
+//
+// It must not be called while the code is running.
+func (r *Runtime) Snapshot() *Snapshot {
+	r.removeDeadTemplateObjects()
+	s := &Snapshot{
+		r:               r,
+		global:          r.global,
+		symbolRegistry:  copySymbolRegistry(r.symbolRegistry),
+		templateObjects: copyTemplateObjects(r.templateObjects),
+		modules:         copyModules(r.modules),
+	}
+	sn := &snapshotter{
+		s:       s,
+		visited: make(map[snapshotKey]struct{}),
+	}
+	sn.walk(reflect.ValueOf(&r.global).Elem())
+	sn.walk(reflect.ValueOf(r.globalObject))
+	sn.walk(reflect.ValueOf(r.stringSingleton))
+	sn.walk(reflect.ValueOf(r.globalLexicals))
+	sn.walk(reflect.ValueOf(r.templateObjects))
+	sn.walk(reflect.ValueOf(r.modules))
+	return s
+}
+
+// Reset returns the Runtime to the state recorded by s (see Snapshot()). The promise jobs which have not run yet are
+// discarded, a pending interrupt is cleared and the counters of the execution limits (see SetLimits()) are reset.
+// A snapshot can be used any number of times but only with the Runtime it has been taken from.
+//
+// It must not be called while the code is running.
+func (r *Runtime) Reset(s *Snapshot) {
+	if s.r != r {
+		panic(errors.New("the snapshot has been taken from a different Runtime"))
+	}
+	r.global = s.global
+	r.symbolRegistry = copySymbolRegistry(s.symbolRegistry)
+	removeDeadTemplateObjects(s.templateObjects)
+	r.templateObjects = copyTemplateObjects(s.templateObjects)
+	r.removeDeadTemplateObjects()
+	r.modules = copyModules(s.modules)
+	for i := range s.entries {
+		e := &s.entries[i]
+		if e.unchanged() {
+			continue
+		}
+		live := reflect.NewAt(e.saved.Type(), e.ptr).Elem()
+		live.Set(e.saved)
+		copyContainers(e.ptr, e.fields)
+		e.image.Set(live)
+	}
+	for _, w := range s.weakMaps {
+		w.m.mu.Lock()
+		w.m.data = copyWeakMapData(w.data)
+		w.m.mu.Unlock()
+	}
+	r.jobQueue = nil
+	r.vm.clearStack()
+	r.vm.ClearInterrupt()
+	r.vm.instructions = 0
+	r.vm.allocated = 0
+}
+
+// unchanged reports whether the structure is in the state it has been left by the last restore and its maps and
+// slices hold the saved elements.
+func (e *snapshotEntry) unchanged() bool {
+	if !bytes.Equal(memory(e.ptr, e.size), memory(e.image.Addr().UnsafePointer(), e.size)) {
+		return false
+	}
+	saved := e.saved.Addr().UnsafePointer()
+	for _, f := range e.fields {
+		if !f.equal(unsafe.Add(e.ptr, f.offset), unsafe.Add(saved, f.offset)) {
+			return false
+		}
+	}
+	return true
+}
+
+// memory returns the size bytes at p, which must be the address of a live value of at least that size. It is the
+// only place where the snapshot code reinterprets memory: the structures, the elements of their slices and the
+// interface values are compared byte by byte to find the ones which have not been modified. The result must only be
+// read, and only while the value at p is kept alive by the caller.
+func memory(p unsafe.Pointer, size uintptr) []byte {
+	if size == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(p), size)
+}
+
+// sameValueIdentity reports whether a and b hold the same value in the same memory, i.e. whether one is a copy of the
+// other. Unlike == it does not panic for the values of uncomparable types.
+func sameValueIdentity(a, b Value) bool {
+	return bytes.Equal(memory(unsafe.Pointer(&a), unsafe.Sizeof(a)), memory(unsafe.Pointer(&b), unsafe.Sizeof(b)))
+}
+
+func samePropertyIdentity(a, b valueProperty) bool {
+	return sameValueIdentity(a.value, b.value) && a.writable == b.writable && a.configurable == b.configurable &&
+		a.enumerable == b.enumerable && a.accessor == b.accessor && a.getterFunc == b.getterFunc &&
+		a.setterFunc == b.setterFunc
+}
+
+func copySymbolRegistry(m map[string]*Symbol) map[string]*Symbol {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]*Symbol, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func copyTemplateObjects(m map[*templateSite]*Object) map[*templateSite]*Object {
+	if m == nil {
+		return nil
+	}
+	c := make(map[*templateSite]*Object, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func copyModules(m map[string]*module) map[string]*module {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]*module, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func copyWeakMapData(m map[uint64]Value) map[uint64]Value {
+	c := make(map[uint64]Value, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// mayContainPointers reports whether a value of type t can refer to a structure that is part of the state.
+func mayContainPointers(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return true
+	case reflect.Array:
+		return mayContainPointers(t.Elem())
+	case reflect.Struct:
+		return t.PkgPath() == gojaPkgPath
+	}
+	return false
+}
+
+// walk records the state of the structures reachable from v. The values obtained through unexported fields cannot
+// be set using reflection, therefore the pointers are re-created with unsafe.
+func (sn *snapshotter) walk(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		t := v.Type().Elem()
+		if t.Kind() != reflect.Struct || t.PkgPath() != gojaPkgPath || snapshotSkipTypes[t] {
+			return
+		}
+		key := snapshotKey{t: t, p: v.UnsafePointer()}
+		if _, exists := sn.visited[key]; exists {
+			return
+		}
+		sn.visited[key] = struct{}{}
+		if t == typeWeakMap {
+			m := (*weakMap)(key.p)
+			m.mu.Lock()
+			sn.s.weakMaps = append(sn.s.weakMaps, weakMapSnapshot{m: m, data: copyWeakMapData(m.data)})
+			m.mu.Unlock()
+			return
+		}
+		live := reflect.NewAt(t, key.p).Elem()
+		saved, image := reflect.New(t).Elem(), reflect.New(t).Elem()
+		saved.Set(live)
+		image.Set(live)
+		fields := containerFields(t)
+		copyContainers(saved.Addr().UnsafePointer(), fields)
+		sn.s.entries = append(sn.s.entries, snapshotEntry{
+			ptr:    key.p,
+			saved:  saved,
+			image:  image,
+			size:   t.Size(),
+			fields: fields,
+		})
+		sn.walk(live)
+	case reflect.Interface:
+		if !v.IsNil() {
+			sn.walk(v.Elem())
+		}
+	case reflect.Struct:
+		if v.Type().PkgPath() != gojaPkgPath {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); mayContainPointers(f.Type()) {
+				sn.walk(f)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if mayContainPointers(v.Type().Elem()) {
+			for i := 0; i < v.Len(); i++ {
+				sn.walk(v.Index(i))
+			}
+		}
+	case reflect.Map:
+		t := v.Type()
+		walkKeys, walkValues := mayContainPointers(t.Key()), mayContainPointers(t.Elem())
+		if walkKeys || walkValues {
+			iter := v.MapRange()
+			for iter.Next() {
+				if walkKeys {
+					sn.walk(iter.Key())
+				}
+				if walkValues {
+					sn.walk(iter.Value())
+				}
+			}
+		}
+	}
+}
+
+// containerField is a map or a slice held by a structure.
+type containerField struct {
+	offset uintptr
+	// copy replaces the container at p with its copy
+	copy func(p unsafe.Pointer)
+	// equal reports whether the containers at p1 and p2 hold the same elements
+	equal func(p1, p2 unsafe.Pointer) bool
+}
+
+// containerFieldsCache holds the []containerField of the types, see containerFields()
+var containerFieldsCache sync.Map
+
+// containerFields returns the maps and the slices held by a value of type t.
+func containerFields(t reflect.Type) []containerField {
+	if fields, ok := containerFieldsCache.Load(t); ok {
+		return fields.([]containerField)
+	}
+	fields := collectContainerFields(t, 0, nil)
+	containerFieldsCache.Store(t, fields)
+	return fields
+}
+
+func collectContainerFields(t reflect.Type, offset uintptr, fields []containerField) []containerField {
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.PkgPath() == gojaPkgPath {
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				fields = collectContainerFields(f.Type, offset+f.Offset, fields)
+			}
+		}
+	case reflect.Array:
+		elem := t.Elem()
+		for i := 0; i < t.Len(); i++ {
+			fields = collectContainerFields(elem, offset+uintptr(i)*elem.Size(), fields)
+		}
+	case reflect.Slice:
+		fields = append(fields, containerField{offset: offset, copy: sliceCopier(t), equal: sliceComparer(t)})
+	case reflect.Map:
+		fields = append(fields, mapField(t, offset))
+	}
+	return fields
+}
+
+func sliceCopier(t reflect.Type) func(p unsafe.Pointer) {
+	switch t {
+	case reflect.TypeOf([]string(nil)):
+		return func(p unsafe.Pointer) {
+			if s := (*[]string)(p); *s != nil {
+				*s = append([]string(nil), *s...)
+			}
+		}
+	case reflect.TypeOf([]*Symbol(nil)):
+		return func(p unsafe.Pointer) {
+			if s := (*[]*Symbol)(p); *s != nil {
+				*s = append([]*Symbol(nil), *s...)
+			}
+		}
+	case reflect.TypeOf([]Value(nil)), reflect.TypeOf(valueStack(nil)):
+		return func(p unsafe.Pointer) {
+			if s := (*[]Value)(p); *s != nil {
+				*s = append([]Value(nil), *s...)
+			}
+		}
+	}
+	return func(p unsafe.Pointer) {
+		v := reflect.NewAt(t, p).Elem()
+		if !v.IsNil() {
+			c := reflect.MakeSlice(t, v.Len(), v.Len())
+			reflect.Copy(c, v)
+			v.Set(c)
+		}
+	}
+}
+
+// sliceComparer compares the memory of the elements, the slices which have been copied hold the same bytes.
+func sliceComparer(t reflect.Type) func(p1, p2 unsafe.Pointer) bool {
+	elemSize := t.Elem().Size()
+	return func(p1, p2 unsafe.Pointer) bool {
+		s1, s2 := reflect.NewAt(t, p1).Elem(), reflect.NewAt(t, p2).Elem()
+		if s1.Len() != s2.Len() || s1.IsNil() != s2.IsNil() {
+			return false
+		}
+		data1, data2 := s1.UnsafePointer(), s2.UnsafePointer()
+		if s1.Len() == 0 || data1 == data2 {
+			return true
+		}
+		size := uintptr(s1.Len()) * elemSize
+		return bytes.Equal(memory(data1, size), memory(data2, size))
+	}
+}
+
+func mapField(t reflect.Type, offset uintptr) containerField {
+	switch t {
+	case reflect.TypeOf(map[string]Value(nil)):
+		return containerField{
+			offset: offset,
+			copy: func(p unsafe.Pointer) {
+				m := (*map[string]Value)(p)
+				if *m != nil {
+					c := make(map[string]Value, len(*m))
+					for k, v := range *m {
+						c[k] = v
+					}
+					*m = c
+				}
+			},
+			equal: func(p1, p2 unsafe.Pointer) bool {
+				m1, m2 := *(*map[string]Value)(p1), *(*map[string]Value)(p2)
+				if len(m1) != len(m2) || (m1 == nil) != (m2 == nil) {
+					return false
+				}
+				for k, v1 := range m1 {
+					if v2, exists := m2[k]; !exists || !sameValueIdentity(v1, v2) {
+						return false
+					}
+				}
+				return true
+			},
+		}
+	case reflect.TypeOf(map[string]valueProperty(nil)):
+		return containerField{
+			offset: offset,
+			copy: func(p unsafe.Pointer) {
+				m := (*map[string]valueProperty)(p)
+				if *m != nil {
+					c := make(map[string]valueProperty, len(*m))
+					for k, v := range *m {
+						c[k] = v
+					}
+					*m = c
+				}
+			},
+			equal: func(p1, p2 unsafe.Pointer) bool {
+				m1, m2 := *(*map[string]valueProperty)(p1), *(*map[string]valueProperty)(p2)
+				if len(m1) != len(m2) || (m1 == nil) != (m2 == nil) {
+					return false
+				}
+				for k, v1 := range m1 {
+					if v2, exists := m2[k]; !exists || !samePropertyIdentity(v1, v2) {
+						return false
+					}
+				}
+				return true
+			},
+		}
+	case reflect.TypeOf(map[*Symbol]Value(nil)):
+		return containerField{
+			offset: offset,
+			copy: func(p unsafe.Pointer) {
+				m := (*map[*Symbol]Value)(p)
+				if *m != nil {
+					c := make(map[*Symbol]Value, len(*m))
+					for k, v := range *m {
+						c[k] = v
+					}
+					*m = c
+				}
+			},
+			equal: func(p1, p2 unsafe.Pointer) bool {
+				m1, m2 := *(*map[*Symbol]Value)(p1), *(*map[*Symbol]Value)(p2)
+				if len(m1) != len(m2) || (m1 == nil) != (m2 == nil) {
+					return false
+				}
+				for k, v1 := range m1 {
+					if v2, exists := m2[k]; !exists || !sameValueIdentity(v1, v2) {
+						return false
+					}
+				}
+				return true
+			},
+		}
+	}
+	return containerField{
+		offset: offset,
+		copy: func(p unsafe.Pointer) {
+			v := reflect.NewAt(t, p).Elem()
+			if !v.IsNil() {
+				c := reflect.MakeMapWithSize(t, v.Len())
+				iter := v.MapRange()
+				for iter.Next() {
+					c.SetMapIndex(iter.Key(), iter.Value())
+				}
+				v.Set(c)
+			}
+		},
+		equal: func(p1, p2 unsafe.Pointer) bool {
+			// the other maps are always restored
+			return false
+		},
+	}
+}
+
+// copyContainers replaces the maps and the slices of the structure at p with their copies, so that the saved and the
+// live state do not share them.
+func copyContainers(p unsafe.Pointer, fields []containerField) {
+	for _, f := range fields {
+		f.copy(unsafe.Add(p, f.offset))
+	}
+}
+
+type pooledRuntime struct {
+	r *Runtime
+	s *Snapshot
+}
+
+// RuntimePool hands out Runtimes which have been set up in the same way. The Runtimes are created and set up on
+// demand and are reset (see Runtime.Reset()) to the state they had after the setup when they are put back, so that
+// nothing done with a Runtime is visible to the next user. The Runtimes do not share any mutable state.
+//
+// A new Runtime cannot be cloned from the Snapshot of another one: the native functions, including the built-ins and
+// the functions installed by the setup, are Go closures bound to the Runtime they have been created for. The cost of
+// New() and of the setup is therefore paid once for every Runtime the pool grows to, not for every Get().
+//
+// A RuntimePool is safe for concurrent use, the Runtimes themselves are not.
+type RuntimePool struct {
+	setup func(*Runtime) error
+
+	mu   sync.Mutex
+	free []pooledRuntime
+	used map[*Runtime]*Snapshot
+}
+
+// NewRuntimePool creates a RuntimePool, setup (if not nil) is called for every new Runtime to install the globals
+// and run the initialisation scripts.
+func NewRuntimePool(setup func(*Runtime) error) *RuntimePool {
+	return &RuntimePool{
+		setup: setup,
+		used:  make(map[*Runtime]*Snapshot),
+	}
+}
+
+// Get returns a Runtime from the pool or creates a new one. It returns the error returned by the setup function.
+func (p *RuntimePool) Get() (*Runtime, error) {
+	p.mu.Lock()
+	if n := len(p.free); n > 0 {
+		pr := p.free[n-1]
+		p.free[n-1] = pooledRuntime{}
+		p.free = p.free[:n-1]
+		p.used[pr.r] = pr.s
+		p.mu.Unlock()
+		return pr.r, nil
+	}
+	p.mu.Unlock()
+
+	r := New()
+	if p.setup != nil {
+		if err := p.setup(r); err != nil {
+			return nil, err
+		}
+	}
+	s := r.Snapshot()
+	p.mu.Lock()
+	p.used[r] = s
+	p.mu.Unlock()
+	return r, nil
+}
+
+// Put resets the Runtime obtained with Get() and returns it to the pool. The Runtime must not be used after that.
+func (p *RuntimePool) Put(r *Runtime) {
+	p.mu.Lock()
+	s := p.used[r]
+	delete(p.used, r)
+	p.mu.Unlock()
+	if s == nil {
+		panic(errors.New("the Runtime does not belong to the pool"))
+	}
+	r.Reset(s)
+	p.mu.Lock()
+	p.free = append(p.free, pooledRuntime{r: r, s: s})
+	p.mu.Unlock()
+}
@@ -0,0 +1,123 @@
+package goja
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRuntimeReset(t *testing.T) {
+	const SETUP = `
+	var counter = 0;
+	function inc() {
+		return ++counter;
+	}
+	const lex = {a: 1};
+	let arr = [1, 2, 3];
+	var m = new Map([[1, "a"]]);
+	var sym = Symbol.for("registered");
+	`
+
+	const MODIFY = `
+	inc(); inc();
+	lex.a = 2;
+	lex.b = 3;
+	arr.push(4);
+	arr[0] = 10;
+	m.set(2, "b");
+	Array.prototype.foo = 1;
+	Object.prototype.bar = 2;
+	delete Math.max;
+	String.prototype.trim = null;
+	Set.prototype.add = null;
+	var newGlobal = 1;
+	let newLexical = 2;
+	Symbol.for("new");
+	`
+
+	const CHECK = `
+	assert.sameValue(counter, 0, "counter");
+	assert.sameValue(inc(), 1, "inc()");
+	assert.sameValue(lex.a, 1, "lex.a");
+	assert.sameValue("b" in lex, false, "lex.b");
+	assert.sameValue(arr.join(), "1,2,3", "arr");
+	assert.sameValue(m.size, 1, "m.size");
+	assert.sameValue([].foo, undefined, "Array.prototype.foo");
+	assert.sameValue({}.bar, undefined, "Object.prototype.bar");
+	assert.sameValue(Math.max(1, 2), 2, "Math.max");
+	assert.sameValue(" x ".trim(), "x", "trim");
+	assert.sameValue(new Set().add(1).size, 1, "Set.prototype.add");
+	assert.sameValue(typeof newGlobal, "undefined", "newGlobal");
+	assert.sameValue(typeof newLexical, "undefined", "newLexical");
+	assert.sameValue(Symbol.keyFor(sym), "registered", "registered symbol");
+	assert.sameValue(Symbol.for("registered"), sym, "Symbol.for()");
+	`
+
+	r := New()
+	if _, err := r.RunString(TESTLIB + SETUP); err != nil {
+		t.Fatal(err)
+	}
+	s := r.Snapshot()
+	for i := 0; i < 2; i++ {
+		if _, err := r.RunString(MODIFY); err != nil {
+			t.Fatal(err)
+		}
+		r.Reset(s)
+		if _, err := r.RunString(CHECK); err != nil {
+			t.Fatal(err)
+		}
+		r.Reset(s)
+	}
+}
+
+func TestRuntimeResetWrongSnapshot(t *testing.T) {
+	s := New().Snapshot()
+	defer func() {
+		if x := recover(); x == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	New().Reset(s)
+}
+
+func TestRuntimePool(t *testing.T) {
+	setups := 0
+	var mu sync.Mutex
+	pool := NewRuntimePool(func(r *Runtime) error {
+		mu.Lock()
+		setups++
+		mu.Unlock()
+		_, err := r.RunString("var requests = 0; function handle(n) { return ++requests + n; }")
+		return err
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				r, err := pool.Get()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				handle, _ := AssertFunction(r.Get("handle"))
+				for k := 0; k < 2; k++ {
+					v, err := handle(nil, r.ToValue(j))
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if v.ToInteger() != int64(k+1+j) {
+						t.Errorf("Unexpected result: %v", v)
+					}
+				}
+				pool.Put(r)
+			}
+		}()
+	}
+	wg.Wait()
+	if setups > 4 {
+		t.Fatalf("Too many runtimes have been set up: %d", setups)
+	}
+}
@@ -18,6 +18,7 @@ type SrcFile struct {
 	lineOffsets       []int
 	lastScannedOffset int
 	sourceMap         *sourcemap.Consumer
+	sourceMapData     []byte
 }
 
 func NewSrcFile(name, src string, sourceMap *sourcemap.Consumer) *SrcFile {
@@ -29,6 +30,14 @@ func NewSrcFile(name, src string, sourceMap *sourcemap.Consumer) *SrcFile {
 }
 
 func (f *SrcFile) Position(offset int) Position {
+	_, _, pos := f.sourcePosition(offset)
+	return pos
+}
+
+// sourcePosition returns the name of the source file and the position in it of the offset. If there is a source
+// map, they are those of the original source and the name of the symbol mapped at the offset, if any, is returned
+// as well.
+func (f *SrcFile) sourcePosition(offset int) (source, symbol string, pos Position) {
 	var line int
 	if offset > f.lastScannedOffset {
 		line = f.scanTo(offset)
@@ -45,15 +54,16 @@ func (f *SrcFile) Position(offset int) Position {
 	col := offset - lineStart + 1
 
 	if f.sourceMap != nil {
-		if _, _, row, col, ok := f.sourceMap.Source(row, col); ok {
-			return Position{
+		// the columns are 0-based in the source maps
+		if source, symbol, row, col, ok := f.sourceMap.Source(row, col-1); ok && source != "" {
+			return source, symbol, Position{
 				Line: row,
-				Col:  col,
+				Col:  col + 1,
 			}
 		}
 	}
 
-	return Position{
+	return f.name, "", Position{
 		Line: row,
 		Col:  col,
 	}
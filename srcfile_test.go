@@ -1,6 +1,11 @@
 package goja
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/dop251/goja/parser"
+)
 
 func TestPosition(t *testing.T) {
 	const SRC = `line1
@@ -32,3 +37,80 @@ line3`
 		}
 	}
 }
+
+// maps gen.js to orig.ts:
+//
+//	function thrower() { throw new Error("boom"); }
+//	function caller() { thrower(); }
+//	caller();
+const testSourceMap = `{"version":3,"sources":["orig.ts"],"names":["thrower","caller"],"mappings":"AAAA,aAAqB;AACrB,aAAoBA;AACpBC"}`
+
+const testMappedScript = `function a(){throw new Error("boom")}
+function b(){a()}
+b()
+`
+
+func TestSourceMapStackTrace(t *testing.T) {
+	const inline = "//# sourceMappingURL=data:application/json;base64," +
+		"eyJ2ZXJzaW9uIjozLCJzb3VyY2VzIjpbIm9yaWcudHMiXSwibmFtZXMiOlsidGhyb3dlciIsImNhbGxlciJdLCJtYXBwaW5ncyI6IkFBQUEsYUFBcUI7QUFDckIsYUFBb0JBO0FBQ3BCQyJ9"
+
+	r := New()
+	_, err := r.RunScript("gen.js", testMappedScript+inline)
+	ex, ok := err.(*Exception)
+	if !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	const expected = "Error: boom\n\tat thrower (orig.ts:1:22(4))\n\tat caller (orig.ts:2:21(3))\n\tat orig.ts:3:1(12)\n"
+	if s := ex.String(); s != expected {
+		t.Fatalf("Unexpected stack: %q", s)
+	}
+	if s := ex.Error(); s != "Error: boom at thrower (orig.ts:1:22(4))" {
+		t.Fatalf("Unexpected error: %q", s)
+	}
+
+	v, err := r.RunString("var stack; try { b(); } catch (e) { stack = e.stack; } stack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// b() is called from code without a source map, so its name is not mapped
+	if s := v.String(); !strings.HasPrefix(s, "Error: boom\n    at thrower (orig.ts:1:22(3))\n    at b (orig.ts:2:21(3))\n") {
+		t.Fatalf("Unexpected stack: %q", s)
+	}
+
+	var frames []StackFrame
+	r.Set("capture", func() {
+		frames = r.CaptureCallStack(0)
+	})
+	_, err = r.RunScript("gen2.js", "function a(){capture()}\nfunction b(){a()}\nb()\n"+inline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 4 {
+		t.Fatalf("Unexpected frames: %v", frames)
+	}
+	if name := frames[1].FuncName(); name != "thrower" {
+		t.Fatalf("Unexpected function name: %q", name)
+	}
+	if name := frames[1].SrcName(); name != "orig.ts" {
+		t.Fatalf("Unexpected source name: %q", name)
+	}
+	if name := frames[2].FuncName(); name != "caller" {
+		t.Fatalf("Unexpected function name: %q", name)
+	}
+}
+
+func TestSourceMapLoader(t *testing.T) {
+	var loaded []string
+	r := New()
+	r.SetParserOptions(parser.WithSourceMapLoader(func(url string) ([]byte, error) {
+		loaded = append(loaded, url)
+		return []byte(testSourceMap), nil
+	}))
+	_, err := r.RunScript("dist/gen.js", testMappedScript+"//# sourceMappingURL=gen.js.map\n")
+	if ex, ok := err.(*Exception); !ok || !strings.HasPrefix(ex.String(), "Error: boom\n\tat thrower (orig.ts:1:22(4))\n") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0] != "dist/gen.js.map" {
+		t.Fatalf("Unexpected loads: %v", loaded)
+	}
+}
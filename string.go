@@ -17,6 +17,8 @@ var (
 	stringBoolean      valueString = asciiString("boolean")
 	stringString       valueString = asciiString("string")
 	stringNumber       valueString = asciiString("number")
+	stringSymbol       valueString = asciiString("symbol")
+	stringBigInt       valueString = asciiString("bigint")
 	stringNaN          valueString = asciiString("NaN")
 	stringInfinity                 = asciiString("Infinity")
 	stringPlusInfinity             = asciiString("+Infinity")
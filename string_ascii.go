@@ -196,6 +196,10 @@ func (s asciiString) Equals(other Value) bool {
 	if o, ok := other.(*Object); ok {
 		return s.Equals(o.self.toPrimitive())
 	}
+
+	if o, ok := other.(*valueBigInt); ok {
+		return o.Equals(s)
+	}
 	return false
 }
 
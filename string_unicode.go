@@ -147,6 +147,10 @@ func (s unicodeString) Equals(other Value) bool {
 	if o, ok := other.(*Object); ok {
 		return s.Equals(o.self.toPrimitive())
 	}
+
+	if o, ok := other.(*valueBigInt); ok {
+		return o.Equals(s)
+	}
 	return false
 }
 
@@ -79,14 +79,13 @@ type _keyword struct {
 }
 
 // IsKeyword returns the keyword token if literal is a keyword, a KEYWORD token
-// if the literal is a future keyword (const, let, class, super, ...), or 0 if the literal is not a keyword.
+// if the literal is a future keyword (let, class, super, ...), or 0 if the literal is not a keyword.
 //
 // If the literal is a keyword, IsKeyword returns a second value indicating if the literal
 // is considered a future keyword in strict-mode only.
 //
 // 7.6.1.2 Future Reserved Words:
 //
-//       const
 //       class
 //       enum
 //       export
@@ -105,6 +104,8 @@ type _keyword struct {
 //       public
 //       static
 //
+// const is a regular keyword since ES2015. let remains a future keyword in strict mode only, the parser
+// recognises it as the start of a lexical declaration from the context, see the LET token.
 func IsKeyword(literal string) (Token, bool) {
 	if keyword, exists := keywordTable[literal]; exists {
 		if keyword.futureKeyword {
@@ -73,6 +73,11 @@ const (
 	SEMICOLON         // ;
 	COLON             // :
 	QUESTION_MARK     // ?
+	ARROW             // =>
+	BACKTICK          // `
+	ELLIPSIS          // ...
+
+	LET
 
 	firstKeyword
 	IF
@@ -94,14 +99,20 @@ const (
 	BREAK
 	CATCH
 	THROW
+	CONST
+	CLASS
+	SUPER
 
 	RETURN
 	TYPEOF
 	DELETE
 	SWITCH
+	IMPORT
+	EXPORT
 
 	DEFAULT
 	FINALLY
+	EXTENDS
 
 	FUNCTION
 	CONTINUE
@@ -171,6 +182,10 @@ var token2string = [...]string{
 	SEMICOLON:                   ";",
 	COLON:                       ":",
 	QUESTION_MARK:               "?",
+	ARROW:                       "=>",
+	BACKTICK:                    "`",
+	ELLIPSIS:                    "...",
+	LET:                         "let",
 	IF:                          "if",
 	IN:                          "in",
 	DO:                          "do",
@@ -187,12 +202,18 @@ var token2string = [...]string{
 	BREAK:                       "break",
 	CATCH:                       "catch",
 	THROW:                       "throw",
+	CONST:                       "const",
+	CLASS:                       "class",
+	SUPER:                       "super",
 	RETURN:                      "return",
 	TYPEOF:                      "typeof",
 	DELETE:                      "delete",
 	SWITCH:                      "switch",
+	IMPORT:                      "import",
+	EXPORT:                      "export",
 	DEFAULT:                     "default",
 	FINALLY:                     "finally",
+	EXTENDS:                     "extends",
 	FUNCTION:                    "function",
 	CONTINUE:                    "continue",
 	DEBUGGER:                    "debugger",
@@ -248,6 +269,15 @@ var keywordTable = map[string]_keyword{
 	"throw": _keyword{
 		token: THROW,
 	},
+	"const": _keyword{
+		token: CONST,
+	},
+	"class": _keyword{
+		token: CLASS,
+	},
+	"super": _keyword{
+		token: SUPER,
+	},
 	"return": _keyword{
 		token: RETURN,
 	},
@@ -260,12 +290,21 @@ var keywordTable = map[string]_keyword{
 	"switch": _keyword{
 		token: SWITCH,
 	},
+	"import": _keyword{
+		token: IMPORT,
+	},
+	"export": _keyword{
+		token: EXPORT,
+	},
 	"default": _keyword{
 		token: DEFAULT,
 	},
 	"finally": _keyword{
 		token: FINALLY,
 	},
+	"extends": _keyword{
+		token: EXTENDS,
+	},
 	"function": _keyword{
 		token: FUNCTION,
 	},
@@ -278,34 +317,10 @@ var keywordTable = map[string]_keyword{
 	"instanceof": _keyword{
 		token: INSTANCEOF,
 	},
-	"const": _keyword{
-		token:         KEYWORD,
-		futureKeyword: true,
-	},
-	"class": _keyword{
-		token:         KEYWORD,
-		futureKeyword: true,
-	},
 	"enum": _keyword{
 		token:         KEYWORD,
 		futureKeyword: true,
 	},
-	"export": _keyword{
-		token:         KEYWORD,
-		futureKeyword: true,
-	},
-	"extends": _keyword{
-		token:         KEYWORD,
-		futureKeyword: true,
-	},
-	"import": _keyword{
-		token:         KEYWORD,
-		futureKeyword: true,
-	},
-	"super": _keyword{
-		token:         KEYWORD,
-		futureKeyword: true,
-	},
 	"implements": _keyword{
 		token:         KEYWORD,
 		futureKeyword: true,
@@ -2,6 +2,7 @@ package goja
 
 import (
 	"math"
+	"math/big"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -28,6 +29,8 @@ var (
 	reflectTypeMap    = reflect.TypeOf(map[string]interface{}{})
 	reflectTypeArray  = reflect.TypeOf([]interface{}{})
 	reflectTypeString = reflect.TypeOf("")
+	reflectTypeSymbol = reflect.TypeOf((*Symbol)(nil))
+	reflectTypeBigInt = reflect.TypeOf((*big.Int)(nil))
 )
 
 var intCache [256]Value
@@ -55,6 +58,10 @@ type Value interface {
 
 type valueInt int64
 type valueFloat float64
+
+// valueBigInt is an arbitrary-precision integer (a BigInt primitive). It is immutable, operations always allocate a
+// new value.
+type valueBigInt big.Int
 type valueBool bool
 type valueNull struct{}
 type valueUndefined struct {
@@ -70,6 +77,25 @@ type memberUnresolved struct {
 	valueUnresolved
 }
 
+// Symbol is a unique primitive value that can be used as a property key. Symbols are not bound to a Runtime, so
+// the well-known symbols below can be shared between runtimes.
+type Symbol struct {
+	descriptor valueString
+}
+
+var (
+	SymHasInstance        = newSymbol(asciiString("Symbol.hasInstance"))
+	SymIsConcatSpreadable = newSymbol(asciiString("Symbol.isConcatSpreadable"))
+	SymIterator           = newSymbol(asciiString("Symbol.iterator"))
+	SymMatch              = newSymbol(asciiString("Symbol.match"))
+	SymReplace            = newSymbol(asciiString("Symbol.replace"))
+	SymSearch             = newSymbol(asciiString("Symbol.search"))
+	SymSplit              = newSymbol(asciiString("Symbol.split"))
+	SymToPrimitive        = newSymbol(asciiString("Symbol.toPrimitive"))
+	SymToStringTag        = newSymbol(asciiString("Symbol.toStringTag"))
+	SymUnscopables        = newSymbol(asciiString("Symbol.unscopables"))
+)
+
 type valueProperty struct {
 	value        Value
 	writable     bool
@@ -157,6 +183,9 @@ func (i valueInt) Equals(other Value) bool {
 	if o, ok := other.(*Object); ok {
 		return i.Equals(o.self.toPrimitiveNumber())
 	}
+	if o, ok := other.(*valueBigInt); ok {
+		return o.Equals(i)
+	}
 	return false
 }
 
@@ -579,6 +608,10 @@ func (f valueFloat) Equals(other Value) bool {
 		return f.Equals(o.self.toPrimitiveNumber())
 	}
 
+	if o, ok := other.(*valueBigInt); ok {
+		return o.Equals(f)
+	}
+
 	return false
 }
 
@@ -670,6 +703,14 @@ func (o *Object) Equals(other Value) bool {
 	if _, ok := other.assertString(); ok {
 		return o.self.toPrimitive().Equals(other)
 	}
+
+	if _, ok := other.(*Symbol); ok {
+		return o.self.toPrimitive().Equals(other)
+	}
+
+	if _, ok := other.(*valueBigInt); ok {
+		return o.self.toPrimitive().Equals(other)
+	}
 	return false
 }
 
@@ -877,6 +918,199 @@ func (o valueUnresolved) ExportType() reflect.Type {
 	return nil
 }
 
+func newSymbol(s valueString) *Symbol {
+	return &Symbol{
+		descriptor: s,
+	}
+}
+
+// NewSymbol creates a new unique Symbol with the given description, equivalent to Symbol(s) in JavaScript.
+func NewSymbol(s string) *Symbol {
+	return newSymbol(newStringValue(s))
+}
+
+func (s *Symbol) ToInteger() int64 {
+	panic(typeError("Cannot convert a Symbol value to a number"))
+}
+
+func (s *Symbol) ToString() valueString {
+	panic(typeError("Cannot convert a Symbol value to a string"))
+}
+
+func (s *Symbol) String() string {
+	return s.descString().String()
+}
+
+func (s *Symbol) ToFloat() float64 {
+	panic(typeError("Cannot convert a Symbol value to a number"))
+}
+
+func (s *Symbol) ToNumber() Value {
+	panic(typeError("Cannot convert a Symbol value to a number"))
+}
+
+func (s *Symbol) ToBoolean() bool {
+	return true
+}
+
+func (s *Symbol) ToObject(r *Runtime) *Object {
+	return r.newPrimitiveObject(s, r.global.SymbolPrototype, classSymbol)
+}
+
+func (s *Symbol) SameAs(other Value) bool {
+	if s1, ok := other.(*Symbol); ok {
+		return s == s1
+	}
+	return false
+}
+
+func (s *Symbol) Equals(other Value) bool {
+	if o, ok := other.(*Object); ok {
+		return s.SameAs(o.self.toPrimitive())
+	}
+	return s.SameAs(other)
+}
+
+func (s *Symbol) StrictEquals(other Value) bool {
+	return s.SameAs(other)
+}
+
+func (s *Symbol) Export() interface{} {
+	return s
+}
+
+func (s *Symbol) ExportType() reflect.Type {
+	return reflectTypeSymbol
+}
+
+func (s *Symbol) assertInt() (int64, bool) {
+	return 0, false
+}
+
+func (s *Symbol) assertString() (valueString, bool) {
+	return nil, false
+}
+
+func (s *Symbol) assertFloat() (float64, bool) {
+	return 0, false
+}
+
+func (s *Symbol) baseObject(r *Runtime) *Object {
+	return r.global.SymbolPrototype
+}
+
+// descString returns the "Symbol(description)" form used by Symbol.prototype.toString.
+func (s *Symbol) descString() valueString {
+	desc := s.descriptor
+	if desc == nil {
+		desc = stringEmpty
+	}
+	return asciiString("Symbol(").concat(desc).concat(asciiString(")"))
+}
+
+func (b *valueBigInt) toBig() *big.Int {
+	return (*big.Int)(b)
+}
+
+func (b *valueBigInt) ToInteger() int64 {
+	panic(typeError("Cannot convert a BigInt value to a number"))
+}
+
+func (b *valueBigInt) ToString() valueString {
+	return asciiString(b.String())
+}
+
+func (b *valueBigInt) String() string {
+	return b.toBig().String()
+}
+
+func (b *valueBigInt) ToFloat() float64 {
+	panic(typeError("Cannot convert a BigInt value to a number"))
+}
+
+func (b *valueBigInt) ToNumber() Value {
+	panic(typeError("Cannot convert a BigInt value to a number"))
+}
+
+func (b *valueBigInt) ToBoolean() bool {
+	return b.toBig().Sign() != 0
+}
+
+func (b *valueBigInt) ToObject(r *Runtime) *Object {
+	return r.newPrimitiveObject(b, r.global.BigIntPrototype, classBigInt)
+}
+
+func (b *valueBigInt) SameAs(other Value) bool {
+	if o, ok := other.(*valueBigInt); ok {
+		return b.toBig().Cmp(o.toBig()) == 0
+	}
+	return false
+}
+
+func (b *valueBigInt) Equals(other Value) bool {
+	switch o := other.(type) {
+	case *valueBigInt:
+		return b.toBig().Cmp(o.toBig()) == 0
+	case valueInt:
+		return b.toBig().IsInt64() && b.toBig().Int64() == int64(o)
+	case valueFloat:
+		return compareBigIntFloat(b.toBig(), float64(o)) == 0
+	case valueString:
+		if o, ok := stringToBigInt(o); ok {
+			return b.toBig().Cmp(o) == 0
+		}
+		return false
+	case valueBool:
+		return b.Equals(o.ToNumber())
+	case *Object:
+		return b.Equals(o.self.toPrimitive())
+	}
+	return false
+}
+
+func (b *valueBigInt) StrictEquals(other Value) bool {
+	return b.SameAs(other)
+}
+
+// Export returns a copy of the value as *big.Int.
+func (b *valueBigInt) Export() interface{} {
+	return new(big.Int).Set(b.toBig())
+}
+
+func (b *valueBigInt) ExportType() reflect.Type {
+	return reflectTypeBigInt
+}
+
+func (b *valueBigInt) assertInt() (int64, bool) {
+	return 0, false
+}
+
+func (b *valueBigInt) assertString() (valueString, bool) {
+	return nil, false
+}
+
+func (b *valueBigInt) assertFloat() (float64, bool) {
+	return 0, false
+}
+
+func (b *valueBigInt) baseObject(r *Runtime) *Object {
+	return r.global.BigIntPrototype
+}
+
+// compareBigIntFloat returns -1, 0 or 1 depending on whether x is less than, equal to or greater than y. If y is NaN
+// the result is 2.
+func compareBigIntFloat(x *big.Int, y float64) int {
+	switch {
+	case math.IsNaN(y):
+		return 2
+	case math.IsInf(y, 1):
+		return -1
+	case math.IsInf(y, -1):
+		return 1
+	}
+	return new(big.Float).SetInt(x).Cmp(big.NewFloat(y))
+}
+
 func init() {
 	for i := 0; i < 256; i++ {
 		intCache[i] = valueInt(i - 128)
@@ -1,9 +1,12 @@
 package goja
 
 import (
+	gocontext "context"
 	"fmt"
 	"log"
 	"math"
+	"math/big"
+	"runtime"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -21,22 +24,62 @@ type stash struct {
 	names     map[string]uint32
 	obj       objectImpl
 
+	// lexical is set for the stashes of block scopes and for the global lexical scope. Their uninitialised
+	// bindings (the temporal dead zone) hold nil, consts contains the names of the const bindings.
+	lexical bool
+	consts  map[string]bool
+
 	outer *stash
 }
 
 type context struct {
-	prg      *Program
-	funcName string
-	stash    *stash
-	pc, sb   int
-	args     int
+	prg       *Program
+	funcName  string
+	stash     *stash
+	pc, sb    int
+	args      int
+	newTarget *Object
 }
 
 type iterStackItem struct {
-	val Value
-	f   iterNextFunc
+	val  Value
+	f    iterNextFunc
+	iter *iteratorRecord
+}
+
+// tryFrame is pushed by the try instruction. When an exception is thrown, the state is unwound to the innermost
+// frame and the execution continues in its catch or finally block (see handleThrow).
+type tryFrame struct {
+	// the exception to rethrow when the finally block completes, if it has been entered because of one
+	exception *Exception
+
+	callStackLen, iterLen, refLen int
+	sp                            int
+	stash                         *stash
+
+	// the positions of the catch and finally blocks, -1 if there is none or it has already been entered
+	catchPos, finallyPos int
+	// where to continue when the finally block completes normally
+	finallyRet int
+	dynamic    bool
+}
+
+// suspendedFrame is a function call removed from the stacks while it is suspended, see saveFrame and resumeFrame.
+type suspendedFrame struct {
+	ctx       context
+	stack     valueStack // from the callee to the top of the stack
+	iterStack []iterStackItem
+	refStack  []ref
+	tryStack  []tryFrame
 }
 
+// The kinds of completion a suspended frame is resumed with. The kind is pushed on the stack above the value.
+const (
+	resumeNext = iota
+	resumeReturn
+	resumeThrow
+)
+
 type ref interface {
 	get() Value
 	set(Value)
@@ -96,6 +139,34 @@ func (r *unresolvedRef) refname() string {
 	return r.name
 }
 
+type lexicalRef struct {
+	runtime *Runtime
+	v       *Value
+	name    string
+	isConst bool
+}
+
+func (r *lexicalRef) get() Value {
+	if *r.v == nil {
+		r.runtime.throwUninitializedError(r.name)
+	}
+	return *r.v
+}
+
+func (r *lexicalRef) set(v Value) {
+	if *r.v == nil {
+		r.runtime.throwUninitializedError(r.name)
+	}
+	if r.isConst {
+		r.runtime.throwConstAssignmentError()
+	}
+	*r.v = v
+}
+
+func (r *lexicalRef) refname() string {
+	return r.name
+}
+
 type vm struct {
 	r            *Runtime
 	prg          *Program
@@ -103,18 +174,35 @@ type vm struct {
 	pc           int
 	stack        valueStack
 	sp, sb, args int
+	// the constructor new was applied to if the current function is called as a constructor, see superCall
+	newTarget *Object
 
 	stash     *stash
 	callStack []context
 	iterStack []iterStackItem
 	refStack  []ref
+	tryStack  []tryFrame
+
+	// set by the instructions which suspend a generator, the value on top of the stack is the result of resumeFrame
+	suspended bool
 
 	stashAllocs int
 	halt        bool
 
+	dbg  *debugContext
+	prof *cpuProfiler
+	cov  *Coverage
+
 	interrupted   uint32
 	interruptVal  interface{}
 	interruptLock sync.Mutex
+
+	// the context of the current execution, see Runtime.RunProgramContext()
+	ctx gocontext.Context
+
+	limits       Limits
+	instructions uint64
+	allocated    int64
 }
 
 type instruction interface {
@@ -199,7 +287,7 @@ func (s *valueStack) expand(idx int) {
 	}
 }
 
-func (s *stash) put(name string, v Value) bool {
+func (s *stash) put(name string, v Value, vm *vm) bool {
 	if s.obj != nil {
 		if found := s.obj.getStr(name); found != nil {
 			s.obj.putStr(name, v, false)
@@ -209,6 +297,9 @@ func (s *stash) put(name string, v Value) bool {
 	} else {
 		if idx, found := s.names[name]; found {
 			s.values.expand(int(idx))
+			if s.lexical {
+				s.checkPut(idx, name, vm)
+			}
 			s.values[idx] = v
 			return true
 		}
@@ -216,6 +307,15 @@ func (s *stash) put(name string, v Value) bool {
 	}
 }
 
+func (s *stash) checkPut(idx uint32, name string, vm *vm) {
+	if s.values[idx] == nil {
+		vm.r.throwUninitializedError(name)
+	}
+	if s.consts[name] {
+		vm.r.throwConstAssignmentError()
+	}
+}
+
 func (s *stash) putByIdx(idx uint32, v Value) {
 	if s.obj != nil {
 		panic("Attempt to put by idx into an object scope")
@@ -241,12 +341,31 @@ func (s *stash) getByName(name string, vm *vm) (v Value, exists bool) {
 		return v, true
 	}
 	if idx, exists := s.names[name]; exists {
-		return s.values[idx], true
+		v = s.values[idx]
+		if v == nil {
+			vm.r.throwUninitializedError(name)
+		}
+		return v, true
 	}
 	return nil, false
 	//return valueUnresolved{r: vm.r, ref: name}, false
 }
 
+// getRef returns a reference to the binding with the given index.
+func (s *stash) getRef(idx uint32, name string, vm *vm) ref {
+	if s.lexical {
+		return &lexicalRef{
+			runtime: vm.r,
+			v:       &s.values[idx],
+			name:    name,
+			isConst: s.consts[name],
+		}
+	}
+	return &stashRef{
+		v: &s.values[idx],
+	}
+}
+
 func (s *stash) createBinding(name string) {
 	if s.names == nil {
 		s.names = make(map[string]uint32)
@@ -280,12 +399,45 @@ func (vm *vm) init() {
 }
 
 func (vm *vm) run() {
+	tryStackLen := len(vm.tryStack)
+	for !vm.runLoop(tryStackLen) {
+	}
+}
+
+// runLoop executes the instructions until halt. If an exception is thrown which can be handled by a try frame pushed
+// after tryStackLen (i.e. during the current run), the state is unwound to it and false is returned.
+func (vm *vm) runLoop(tryStackLen int) (done bool) {
+	defer func() {
+		if len(vm.tryStack) > tryStackLen {
+			if x := recover(); x != nil {
+				if ex := vm.toException(x); ex == nil || !vm.handleThrow(ex, tryStackLen) {
+					panic(x)
+				}
+			}
+		}
+	}()
+
 	vm.halt = false
 	interrupted := false
 	for !vm.halt {
 		if interrupted = atomic.LoadUint32(&vm.interrupted) != 0; interrupted {
 			break
 		}
+		if vm.limits.MaxInstructions != 0 {
+			if vm.instructions >= vm.limits.MaxInstructions {
+				vm.limitExceeded(LimitInstructions)
+			}
+			vm.instructions++
+		}
+		if vm.dbg != nil {
+			vm.dbg.step(vm)
+		}
+		if vm.prof != nil {
+			vm.prof.step(vm)
+		}
+		if vm.cov != nil {
+			vm.cov.hit(vm.prg, vm.pc)
+		}
 		vm.prg.code[vm.pc].exec(vm)
 	}
 
@@ -299,6 +451,96 @@ func (vm *vm) run() {
 		vm.interruptLock.Unlock()
 		panic(v)
 	}
+	return true
+}
+
+// toException converts a value the code has panicked with into an exception, it returns nil if it is not one
+// JavaScript code can catch.
+func (vm *vm) toException(x interface{}) *Exception {
+	switch x1 := x.(type) {
+	case Value:
+		return &Exception{
+			val: x1,
+		}
+	case *Exception:
+		return x1
+	case typeError:
+		return &Exception{
+			val: vm.r.NewTypeError(string(x1)),
+		}
+	}
+	return nil
+}
+
+// handleThrow unwinds the state to the innermost try frame pushed after tryStackLen and continues the execution in
+// its catch block or, if there is none, in its finally block. Frames whose finally block has already been entered
+// are discarded. It returns false if there is no frame to handle the exception.
+func (vm *vm) handleThrow(ex *Exception, tryStackLen int) bool {
+	for len(vm.tryStack) > tryStackLen {
+		l := len(vm.tryStack) - 1
+		frame := &vm.tryStack[l]
+		if frame.catchPos >= 0 || frame.finallyPos >= 0 {
+			if frame.catchPos < 0 && len(vm.callStack) > frame.callStackLen {
+				// the stack trace is lost when the state is unwound, keep the part above the function with the try
+				// statement for when the exception is rethrown after the finally block
+				ex.stack = vm.captureStack(ex.stack, frame.callStackLen+1)
+			}
+			if len(vm.callStack) > frame.callStackLen {
+				vm.restoreCtx(&vm.callStack[frame.callStackLen])
+			}
+			vm.sp = frame.sp
+			vm.stash = frame.stash
+			vm.unwind(frame.callStackLen, frame.iterLen, frame.refLen, true)
+			if frame.catchPos >= 0 {
+				vm.pc = frame.catchPos
+				frame.catchPos = -1
+				if frame.dynamic {
+					vm.newStash()
+					vm.stash.putByIdx(0, ex.val)
+				} else {
+					vm.push(ex.val)
+				}
+			} else {
+				vm.pc = frame.finallyPos
+				frame.finallyPos = -1
+				frame.exception = ex
+			}
+			return true
+		}
+		vm.tryStack[l] = tryFrame{}
+		vm.tryStack = vm.tryStack[:l]
+	}
+	return false
+}
+
+// unwind truncates the call, the iterator and the reference stacks to the given lengths. If close is set, the
+// iterators abandoned on the iterator stack are closed (innermost first), the errors thrown by their return()
+// methods are discarded in favour of the original exception.
+func (vm *vm) unwind(callStackLen, iterLen, refLen int, close bool) {
+	callTail := vm.callStack[callStackLen:]
+	for i := range callTail {
+		callTail[i] = context{}
+	}
+	vm.callStack = vm.callStack[:callStackLen]
+
+	iterTail := vm.iterStack[iterLen:]
+	var iters []*iteratorRecord
+	for i := range iterTail {
+		if iter := iterTail[i].iter; iter != nil && close {
+			iters = append(iters, iter)
+		}
+		iterTail[i] = iterStackItem{}
+	}
+	vm.iterStack = vm.iterStack[:iterLen]
+	refTail := vm.refStack[refLen:]
+	for i := range refTail {
+		refTail[i] = nil
+	}
+	vm.refStack = vm.refStack[:refLen]
+
+	for i := len(iters) - 1; i >= 0; i-- {
+		vm.try(iters[i].close)
+	}
 }
 
 func (vm *vm) Interrupt(v interface{}) {
@@ -308,7 +550,25 @@ func (vm *vm) Interrupt(v interface{}) {
 	vm.interruptLock.Unlock()
 }
 
+func (vm *vm) ClearInterrupt() {
+	vm.interruptLock.Lock()
+	atomic.StoreUint32(&vm.interrupted, 0)
+	vm.interruptVal = nil
+	vm.interruptLock.Unlock()
+}
+
+// clearInterrupt clears the interrupt only if it is pending with the value v.
+func (vm *vm) clearInterrupt(v interface{}) {
+	vm.interruptLock.Lock()
+	if atomic.LoadUint32(&vm.interrupted) != 0 && vm.interruptVal == v {
+		atomic.StoreUint32(&vm.interrupted, 0)
+		vm.interruptVal = nil
+	}
+	vm.interruptLock.Unlock()
+}
+
 func (vm *vm) captureStack(stack []StackFrame, ctxOffset int) []StackFrame {
+	start := len(stack)
 	// Unroll the context stack
 	stack = append(stack, StackFrame{prg: vm.prg, pc: vm.pc, funcName: vm.funcName})
 	for i := len(vm.callStack) - 1; i > ctxOffset-1; i-- {
@@ -316,9 +576,24 @@ func (vm *vm) captureStack(stack []StackFrame, ctxOffset int) []StackFrame {
 			stack = append(stack, StackFrame{prg: vm.callStack[i].prg, pc: vm.callStack[i].pc - 1, funcName: vm.callStack[i].funcName})
 		}
 	}
+	mapFuncNames(stack[start:])
 	return stack
 }
 
+// mapFuncNames sets the names the functions have in the original source, which are the names the source maps of the
+// callers' code map at the positions of the calls.
+func mapFuncNames(frames []StackFrame) {
+	for i := 0; i < len(frames)-1; i++ {
+		caller := &frames[i+1]
+		if frames[i].prg == nil || caller.prg == nil || caller.prg.src == nil || caller.prg.src.sourceMap == nil {
+			continue
+		}
+		if _, symbol, _ := caller.sourcePosition(); symbol != "" {
+			frames[i].srcFuncName = symbol
+		}
+	}
+}
+
 func (vm *vm) try(f func()) (ex *Exception) {
 	var ctx context
 	vm.saveCtx(&ctx)
@@ -327,42 +602,40 @@ func (vm *vm) try(f func()) (ex *Exception) {
 	sp := vm.sp
 	iterLen := len(vm.iterStack)
 	refLen := len(vm.refStack)
+	tryLen := len(vm.tryStack)
 
 	defer func() {
 		if x := recover(); x != nil {
 			defer func() {
-				vm.callStack = vm.callStack[:ctxOffset]
 				vm.restoreCtx(&ctx)
 				vm.sp = sp
+				tryTail := vm.tryStack[tryLen:]
+				for i := range tryTail {
+					tryTail[i] = tryFrame{}
+				}
+				vm.tryStack = vm.tryStack[:tryLen]
 
 				// Restore other stacks
-				iterTail := vm.iterStack[iterLen:]
-				for i, _ := range iterTail {
-					iterTail[i] = iterStackItem{}
-				}
-				vm.iterStack = vm.iterStack[:iterLen]
-				refTail := vm.refStack[refLen:]
-				for i, _ := range refTail {
-					refTail[i] = nil
-				}
-				vm.refStack = vm.refStack[:refLen]
+				vm.unwind(ctxOffset, iterLen, refLen, ex != nil)
 			}()
-			switch x1 := x.(type) {
-			case Value:
-				ex = &Exception{
-					val: x1,
-				}
-			case *InterruptedError:
-				x1.stack = vm.captureStack(x1.stack, ctxOffset)
-				panic(x1)
-			case *Exception:
-				ex = x1
-			default:
-				if vm.prg != nil {
-					vm.prg.dumpCode(log.Printf)
+			if ex = vm.toException(x); ex == nil {
+				switch x1 := x.(type) {
+				case uncatchableError:
+					e := x1.exception()
+					e.stack = vm.captureStack(e.stack, ctxOffset)
+					panic(x1)
+				default:
+					if vm.prg != nil {
+						if vm.prg.unverified {
+							// the code read by ReadProgram() cannot be fully checked, a corrupted program
+							// aborts the execution rather than crashing the process
+							panic(corruptedProgramError(x))
+						}
+						vm.prg.dumpCode(log.Printf)
+					}
+					//log.Print("Stack: ", string(debug.Stack()))
+					panic(fmt.Errorf("Panic at %d: %v", vm.pc, x))
 				}
-				//log.Print("Stack: ", string(debug.Stack()))
-				panic(fmt.Errorf("Panic at %d: %v", vm.pc, x))
 			}
 			ex.stack = vm.captureStack(ex.stack, ctxOffset)
 		}
@@ -395,13 +668,14 @@ func (vm *vm) saveCtx(ctx *context) {
 	ctx.prg = vm.prg
 	if vm.funcName != "" {
 		ctx.funcName = vm.funcName
-	} else if ctx.prg.funcName != "" {
+	} else if ctx.prg != nil && ctx.prg.funcName != "" {
 		ctx.funcName = ctx.prg.funcName
 	}
 	ctx.stash = vm.stash
 	ctx.pc = vm.pc
 	ctx.sb = vm.sb
 	ctx.args = vm.args
+	ctx.newTarget = vm.newTarget
 }
 
 func (vm *vm) pushCtx() {
@@ -413,6 +687,7 @@ func (vm *vm) pushCtx() {
 			sb: vm.sb,
 			args: vm.args,
 		})*/
+	vm.checkCallStackDepth()
 	vm.callStack = append(vm.callStack, context{})
 	vm.saveCtx(&vm.callStack[len(vm.callStack)-1])
 }
@@ -424,6 +699,7 @@ func (vm *vm) restoreCtx(ctx *context) {
 	vm.stash = ctx.stash
 	vm.sb = ctx.sb
 	vm.args = ctx.args
+	vm.newTarget = ctx.newTarget
 }
 
 func (vm *vm) popCtx() {
@@ -436,6 +712,8 @@ func (vm *vm) popCtx() {
 	vm.callStack[l].stash = nil
 	vm.sb = vm.callStack[l].sb
 	vm.args = vm.callStack[l].args
+	vm.newTarget = vm.callStack[l].newTarget
+	vm.callStack[l].newTarget = nil
 
 	vm.callStack = vm.callStack[:l]
 }
@@ -591,10 +869,63 @@ type _toNumber struct{}
 var toNumber _toNumber
 
 func (_toNumber) exec(vm *vm) {
-	vm.stack[vm.sp-1] = vm.stack[vm.sp-1].ToNumber()
+	vm.stack[vm.sp-1] = toNumeric(vm.stack[vm.sp-1])
 	vm.pc++
 }
 
+// toNumeric implements ToNumeric: the result is either a Number or a BigInt.
+func toNumeric(v Value) Value {
+	if o, ok := v.(*Object); ok {
+		v = o.self.toPrimitiveNumber()
+	}
+	if b, ok := v.(*valueBigInt); ok {
+		return b
+	}
+	return v.ToNumber()
+}
+
+// bigIntOperands returns the operands of a binary numeric operator (already converted by toNumeric) if either of
+// them is a BigInt. Mixing a BigInt and a Number is a TypeError.
+func bigIntOperands(left, right Value) (*big.Int, *big.Int, bool) {
+	l, lok := left.(*valueBigInt)
+	r, rok := right.(*valueBigInt)
+	if lok && rok {
+		return l.toBig(), r.toBig(), true
+	}
+	if lok || rok {
+		panic(typeError("Cannot mix BigInt and other types, use explicit conversions"))
+	}
+	return nil, nil, false
+}
+
+func (vm *vm) bigIntDivisor(d *big.Int) *big.Int {
+	if d.Sign() == 0 {
+		panic(vm.r.newError(vm.r.global.RangeError, "Division by zero"))
+	}
+	return d
+}
+
+// bigIntShift returns x << n, or x >> -n if n is negative.
+func (vm *vm) bigIntShift(x, n *big.Int) *big.Int {
+	if n.Sign() < 0 {
+		n = new(big.Int).Neg(n)
+		if !n.IsInt64() || n.Int64() > int64(x.BitLen()) {
+			if x.Sign() < 0 {
+				return big.NewInt(-1)
+			}
+			return new(big.Int)
+		}
+		return new(big.Int).Rsh(x, uint(n.Int64()))
+	}
+	if x.Sign() == 0 {
+		return x
+	}
+	if !n.IsInt64() || n.Int64() > maxBigIntBits {
+		panic(vm.r.newError(vm.r.global.RangeError, "Maximum BigInt size exceeded"))
+	}
+	return new(big.Int).Lsh(x, uint(n.Int64()))
+}
+
 type _add struct{}
 
 var add _add
@@ -623,7 +954,10 @@ func (_add) exec(vm *vm) {
 		if !isRightString {
 			rightString = right.ToString()
 		}
+		vm.allocateString(leftString, leftString.length()+rightString.length())
 		ret = leftString.concat(rightString)
+	} else if l, r, ok := bigIntOperands(left, right); ok {
+		ret = (*valueBigInt)(new(big.Int).Add(l, r))
 	} else {
 		if leftInt, ok := left.assertInt(); ok {
 			if rightInt, ok := right.assertInt(); ok {
@@ -641,6 +975,23 @@ func (_add) exec(vm *vm) {
 	vm.pc++
 }
 
+// concatStrings replaces the top n values on the stack with the concatenation of their string values, it is used
+// to evaluate template literals.
+type concatStrings uint32
+
+func (n concatStrings) exec(vm *vm) {
+	args := vm.stack[vm.sp-int(n) : vm.sp]
+	res := args[0].ToString()
+	for _, arg := range args[1:] {
+		s := arg.ToString()
+		vm.allocateString(res, res.length()+s.length())
+		res = res.concat(s)
+	}
+	vm.sp -= int(n) - 1
+	vm.stack[vm.sp-1] = res
+	vm.pc++
+}
+
 type _sub struct{}
 
 var sub _sub
@@ -658,6 +1009,12 @@ func (_sub) exec(vm *vm) {
 		}
 	}
 
+	left, right = toNumeric(left), toNumeric(right)
+	if l, r, ok := bigIntOperands(left, right); ok {
+		result = (*valueBigInt)(new(big.Int).Sub(l, r))
+		goto end
+	}
+
 	result = floatToValue(left.ToFloat() - right.ToFloat())
 end:
 	vm.sp--
@@ -670,11 +1027,16 @@ type _mul struct{}
 var mul _mul
 
 func (_mul) exec(vm *vm) {
-	left := vm.stack[vm.sp-2]
-	right := vm.stack[vm.sp-1]
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
 
+	if l, r, ok := bigIntOperands(left, right); ok {
+		result = (*valueBigInt)(new(big.Int).Mul(l, r))
+		goto end
+	}
+
 	if left, ok := toInt(left); ok {
 		if right, ok := toInt(right); ok {
 			if left == 0 && right == -1 || left == -1 && right == 0 {
@@ -704,10 +1066,20 @@ type _div struct{}
 var div _div
 
 func (_div) exec(vm *vm) {
-	left := vm.stack[vm.sp-2].ToFloat()
-	right := vm.stack[vm.sp-1].ToFloat()
+	leftNum := toNumeric(vm.stack[vm.sp-2])
+	rightNum := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
+	var left, right float64
+
+	if l, r, ok := bigIntOperands(leftNum, rightNum); ok {
+		// the result is rounded towards zero
+		result = (*valueBigInt)(new(big.Int).Quo(l, vm.bigIntDivisor(r)))
+		goto end
+	}
+
+	left = leftNum.ToFloat()
+	right = rightNum.ToFloat()
 
 	if math.IsNaN(left) || math.IsNaN(right) {
 		result = _NaN
@@ -763,11 +1135,17 @@ type _mod struct{}
 var mod _mod
 
 func (_mod) exec(vm *vm) {
-	left := vm.stack[vm.sp-2]
-	right := vm.stack[vm.sp-1]
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
 
+	if l, r, ok := bigIntOperands(left, right); ok {
+		// the sign of the result is the sign of the dividend
+		result = (*valueBigInt)(new(big.Int).Rem(l, vm.bigIntDivisor(r)))
+		goto end
+	}
+
 	if leftInt, ok := toInt(left); ok {
 		if rightInt, ok := toInt(right); ok {
 			if rightInt == 0 {
@@ -796,11 +1174,13 @@ type _neg struct{}
 var neg _neg
 
 func (_neg) exec(vm *vm) {
-	operand := vm.stack[vm.sp-1]
+	operand := toNumeric(vm.stack[vm.sp-1])
 
 	var result Value
 
-	if i, ok := toInt(operand); ok {
+	if b, ok := operand.(*valueBigInt); ok {
+		result = (*valueBigInt)(new(big.Int).Neg(b.toBig()))
+	} else if i, ok := toInt(operand); ok {
 		if i == 0 {
 			result = _negativeZero
 		} else {
@@ -834,6 +1214,11 @@ var inc _inc
 func (_inc) exec(vm *vm) {
 	v := vm.stack[vm.sp-1]
 
+	if b, ok := v.(*valueBigInt); ok {
+		v = (*valueBigInt)(new(big.Int).Add(b.toBig(), big.NewInt(1)))
+		goto end
+	}
+
 	if i, ok := toInt(v); ok {
 		v = intToValue(i + 1)
 		goto end
@@ -853,6 +1238,11 @@ var dec _dec
 func (_dec) exec(vm *vm) {
 	v := vm.stack[vm.sp-1]
 
+	if b, ok := v.(*valueBigInt); ok {
+		v = (*valueBigInt)(new(big.Int).Sub(b.toBig(), big.NewInt(1)))
+		goto end
+	}
+
 	if i, ok := toInt(v); ok {
 		v = intToValue(i - 1)
 		goto end
@@ -870,9 +1260,13 @@ type _and struct{}
 var and _and
 
 func (_and) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left & right))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if l, r, ok := bigIntOperands(left, right); ok {
+		vm.stack[vm.sp-2] = (*valueBigInt)(new(big.Int).And(l, r))
+	} else {
+		vm.stack[vm.sp-2] = intToValue(int64(toInt32(left) & toInt32(right)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -882,9 +1276,13 @@ type _or struct{}
 var or _or
 
 func (_or) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left | right))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if l, r, ok := bigIntOperands(left, right); ok {
+		vm.stack[vm.sp-2] = (*valueBigInt)(new(big.Int).Or(l, r))
+	} else {
+		vm.stack[vm.sp-2] = intToValue(int64(toInt32(left) | toInt32(right)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -894,9 +1292,13 @@ type _xor struct{}
 var xor _xor
 
 func (_xor) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left ^ right))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if l, r, ok := bigIntOperands(left, right); ok {
+		vm.stack[vm.sp-2] = (*valueBigInt)(new(big.Int).Xor(l, r))
+	} else {
+		vm.stack[vm.sp-2] = intToValue(int64(toInt32(left) ^ toInt32(right)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -906,8 +1308,12 @@ type _bnot struct{}
 var bnot _bnot
 
 func (_bnot) exec(vm *vm) {
-	op := toInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-1] = intToValue(int64(^op))
+	op := toNumeric(vm.stack[vm.sp-1])
+	if b, ok := op.(*valueBigInt); ok {
+		vm.stack[vm.sp-1] = (*valueBigInt)(new(big.Int).Not(b.toBig()))
+	} else {
+		vm.stack[vm.sp-1] = intToValue(int64(^toInt32(op)))
+	}
 	vm.pc++
 }
 
@@ -916,9 +1322,13 @@ type _sal struct{}
 var sal _sal
 
 func (_sal) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toUInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left << (right & 0x1F)))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if l, r, ok := bigIntOperands(left, right); ok {
+		vm.stack[vm.sp-2] = (*valueBigInt)(vm.bigIntShift(l, r))
+	} else {
+		vm.stack[vm.sp-2] = intToValue(int64(toInt32(left) << (toUInt32(right) & 0x1F)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -928,9 +1338,13 @@ type _sar struct{}
 var sar _sar
 
 func (_sar) exec(vm *vm) {
-	left := toInt32(vm.stack[vm.sp-2])
-	right := toUInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left >> (right & 0x1F)))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if l, r, ok := bigIntOperands(left, right); ok {
+		vm.stack[vm.sp-2] = (*valueBigInt)(vm.bigIntShift(l, new(big.Int).Neg(r)))
+	} else {
+		vm.stack[vm.sp-2] = intToValue(int64(toInt32(left) >> (toUInt32(right) & 0x1F)))
+	}
 	vm.sp--
 	vm.pc++
 }
@@ -940,9 +1354,12 @@ type _shr struct{}
 var shr _shr
 
 func (_shr) exec(vm *vm) {
-	left := toUInt32(vm.stack[vm.sp-2])
-	right := toUInt32(vm.stack[vm.sp-1])
-	vm.stack[vm.sp-2] = intToValue(int64(left >> (right & 0x1F)))
+	left := toNumeric(vm.stack[vm.sp-2])
+	right := toNumeric(vm.stack[vm.sp-1])
+	if _, _, ok := bigIntOperands(left, right); ok {
+		panic(typeError("BigInts have no unsigned right shift, use >> instead"))
+	}
+	vm.stack[vm.sp-2] = intToValue(int64(toUInt32(left) >> (toUInt32(right) & 0x1F)))
 	vm.sp--
 	vm.pc++
 }
@@ -956,6 +1373,17 @@ func (_halt) exec(vm *vm) {
 	vm.pc++
 }
 
+type _debugger struct{}
+
+var debuggerStmt _debugger
+
+func (_debugger) exec(vm *vm) {
+	if vm.dbg != nil {
+		vm.dbg.debuggerStatement(vm)
+	}
+	vm.pc++
+}
+
 type jump int32
 
 func (j jump) exec(vm *vm) {
@@ -968,7 +1396,7 @@ var setElem _setElem
 
 func (_setElem) exec(vm *vm) {
 	obj := vm.r.toObject(vm.stack[vm.sp-3])
-	propName := vm.stack[vm.sp-2]
+	propName := toPropertyKey(vm.stack[vm.sp-2])
 	val := vm.stack[vm.sp-1]
 
 	obj.self.put(propName, val, false)
@@ -984,7 +1412,7 @@ var setElemStrict _setElemStrict
 
 func (_setElemStrict) exec(vm *vm) {
 	obj := vm.r.toObject(vm.stack[vm.sp-3])
-	propName := vm.stack[vm.sp-2]
+	propName := toPropertyKey(vm.stack[vm.sp-2])
 	val := vm.stack[vm.sp-1]
 
 	obj.self.put(propName, val, true)
@@ -1000,7 +1428,7 @@ var deleteElem _deleteElem
 
 func (_deleteElem) exec(vm *vm) {
 	obj := vm.r.toObject(vm.stack[vm.sp-2])
-	propName := vm.stack[vm.sp-1]
+	propName := toPropertyKey(vm.stack[vm.sp-1])
 	if !obj.self.hasProperty(propName) || obj.self.delete(propName, false) {
 		vm.stack[vm.sp-2] = valueTrue
 	} else {
@@ -1016,7 +1444,7 @@ var deleteElemStrict _deleteElemStrict
 
 func (_deleteElemStrict) exec(vm *vm) {
 	obj := vm.r.toObject(vm.stack[vm.sp-2])
-	propName := vm.stack[vm.sp-1]
+	propName := toPropertyKey(vm.stack[vm.sp-1])
 	obj.self.delete(propName, true)
 	vm.stack[vm.sp-2] = valueTrue
 	vm.sp--
@@ -1173,7 +1601,7 @@ var getElem _getElem
 func (_getElem) exec(vm *vm) {
 	v := vm.stack[vm.sp-2]
 	obj := v.baseObject(vm.r)
-	propName := vm.stack[vm.sp-1]
+	propName := toPropertyKey(vm.stack[vm.sp-1])
 	if obj == nil {
 		vm.r.typeErrorResult(true, "Cannot read property '%s' of undefined", propName.String())
 	}
@@ -1199,7 +1627,7 @@ var getElemCallee _getElemCallee
 func (_getElemCallee) exec(vm *vm) {
 	v := vm.stack[vm.sp-2]
 	obj := v.baseObject(vm.r)
-	propName := vm.stack[vm.sp-1]
+	propName := toPropertyKey(vm.stack[vm.sp-1])
 	if obj == nil {
 		vm.r.typeErrorResult(true, "Cannot read property '%s' of undefined", propName.String())
 		panic("Unreachable")
@@ -1318,7 +1746,7 @@ func (s setVar) exec(vm *vm) {
 	stash := vm.stash
 	name := s.name
 	for i := 0; i < level; i++ {
-		if stash.put(name, v) {
+		if stash.put(name, v, vm) {
 			goto end
 		}
 		stash = stash.outer
@@ -1350,14 +1778,17 @@ func (s resolveVar1) exec(vm *vm) {
 			}
 		} else {
 			if idx, exists := stash.names[name]; exists {
-				ref = &stashRef{
-					v: &stash.values[idx],
-				}
+				ref = stash.getRef(idx, name, vm)
 				goto end
 			}
 		}
 	}
 
+	if idx, exists := vm.r.globalLexicals.names[name]; exists {
+		ref = vm.r.globalLexicals.getRef(idx, name, vm)
+		goto end
+	}
+
 	ref = &objRef{
 		base: vm.r.globalObject.self,
 		name: name,
@@ -1387,6 +1818,11 @@ func (d deleteVar) exec(vm *vm) {
 		}
 	}
 
+	if _, exists := vm.r.globalLexicals.names[name]; exists {
+		ret = false
+		goto end
+	}
+
 	if vm.r.globalObject.self.hasPropertyStr(name) {
 		ret = vm.r.globalObject.self.deleteStr(name, false)
 	}
@@ -1435,14 +1871,17 @@ func (s resolveVar1Strict) exec(vm *vm) {
 			}
 		} else {
 			if idx, exists := stash.names[name]; exists {
-				ref = &stashRef{
-					v: &stash.values[idx],
-				}
+				ref = stash.getRef(idx, name, vm)
 				goto end
 			}
 		}
 	}
 
+	if idx, exists := vm.r.globalLexicals.names[name]; exists {
+		ref = vm.r.globalLexicals.getRef(idx, name, vm)
+		goto end
+	}
+
 	if vm.r.globalObject.self.hasPropertyStr(name) {
 		ref = &objRef{
 			base:   vm.r.globalObject.self,
@@ -1484,7 +1923,7 @@ func (s setVarStrict) exec(vm *vm) {
 	stash := vm.stash
 	name := s.name
 	for i := 0; i < level; i++ {
-		if stash.put(name, v) {
+		if stash.put(name, v, vm) {
 			goto end
 		}
 		stash = stash.outer
@@ -1513,10 +1952,13 @@ func (s setVar1Strict) exec(vm *vm) {
 
 	name := string(s)
 	for stash := vm.stash; stash != nil; stash = stash.outer {
-		if stash.put(name, v) {
+		if stash.put(name, v, vm) {
 			goto end
 		}
 	}
+	if vm.r.globalLexicals.put(name, v, vm) {
+		goto end
+	}
 	o = vm.r.globalObject.self
 	if o.hasOwnPropertyStr(name) {
 		o.putStr(name, v, true)
@@ -1614,9 +2056,7 @@ func (r resolveVar) exec(vm *vm) {
 			}
 		} else {
 			if idx, exists := stash.names[r.name]; exists {
-				ref = &stashRef{
-					v: &stash.values[idx],
-				}
+				ref = stash.getRef(idx, r.name, vm)
 				goto end
 			}
 		}
@@ -1624,9 +2064,7 @@ func (r resolveVar) exec(vm *vm) {
 	}
 
 	if stash != nil {
-		ref = &stashRef{
-			v: &stash.values[idx],
-		}
+		ref = stash.getRef(idx, r.name, vm)
 		goto end
 	} /*else {
 		if vm.r.globalObject.self.hasProperty(nameVal) {
@@ -1688,9 +2126,13 @@ func (n getVar1) exec(vm *vm) {
 		}
 	}
 	if val == nil {
-		val = vm.r.globalObject.self.getStr(name)
-		if val == nil {
-			vm.r.throwReferenceError(name)
+		if v, exists := vm.r.globalLexicals.getByName(name, vm); exists {
+			val = v
+		} else {
+			val = vm.r.globalObject.self.getStr(name)
+			if val == nil {
+				vm.r.throwReferenceError(name)
+			}
 		}
 	}
 	vm.push(val)
@@ -1709,9 +2151,13 @@ func (n getVar1Callee) exec(vm *vm) {
 		}
 	}
 	if val == nil {
-		val = vm.r.globalObject.self.getStr(name)
-		if val == nil {
-			val = valueUnresolved{r: vm.r, ref: name}
+		if v, exists := vm.r.globalLexicals.getByName(name, vm); exists {
+			val = v
+		} else {
+			val = vm.r.globalObject.self.getStr(name)
+			if val == nil {
+				val = valueUnresolved{r: vm.r, ref: name}
+			}
 		}
 	}
 	vm.push(val)
@@ -1803,13 +2249,20 @@ func (numargs call) exec(vm *vm) {
 repeat:
 	switch f := obj.self.(type) {
 	case *funcObject:
+		if f.classCtor {
+			vm.r.typeErrorResult(true, "Class constructor %s cannot be invoked without 'new'", f.prg.funcName)
+		}
 		vm.pc++
 		vm.pushCtx()
 		vm.args = n
+		vm.newTarget = nil
 		vm.prg = f.prg
 		vm.stash = f.stash
 		vm.pc = 0
 		vm.stack[vm.sp-n-1], vm.stack[vm.sp-n-2] = vm.stack[vm.sp-n-2], vm.stack[vm.sp-n-1]
+		if f.arrow {
+			vm.stack[vm.sp-n-1] = f.this
+		}
 		return
 	case *nativeFuncObject:
 		vm._nativeCall(f, n)
@@ -1845,10 +2298,14 @@ func (vm *vm) _nativeCall(f *nativeFuncObject, n int) {
 		ret := f.f(FunctionCall{
 			Arguments: vm.stack[vm.sp-n : vm.sp],
 			This:      vm.stack[vm.sp-n-2],
+			ctx:       vm.ctx,
 		})
 		if ret == nil {
 			ret = _undefined
 		}
+		if vm.prof != nil {
+			vm.prof.leaveNative(vm)
+		}
 		vm.stack[vm.sp-n-2] = ret
 		vm.popCtx()
 	} else {
@@ -1908,11 +2365,11 @@ var ret _ret
 
 func (_ret) exec(vm *vm) {
 	// callee -3
-	// this -2
+	// this -2 <- sb
 	// retval -1
 
-	vm.stack[vm.sp-3] = vm.stack[vm.sp-1]
-	vm.sp -= 2
+	vm.stack[vm.sb-1] = vm.stack[vm.sp-1]
+	vm.sp = vm.sb
 	vm.popCtx()
 	if vm.pc < 0 {
 		vm.halt = true
@@ -1965,6 +2422,14 @@ type newFunc struct {
 	name   string
 	length uint32
 	strict bool
+	// an arrow function captures the value of this, which is on top of the stack
+	arrow bool
+	// set for an arrow function created in a method, it inherits the home object of the method
+	home bool
+	// calling a generator function creates a generator object, see createGenerator
+	generator bool
+	// an async function returns a promise, see startAsync
+	async bool
 
 	srcStart, srcEnd uint32
 }
@@ -1974,68 +2439,611 @@ func (n *newFunc) exec(vm *vm) {
 	obj.prg = n.prg
 	obj.stash = vm.stash
 	obj.src = n.prg.src.src[n.srcStart:n.srcEnd]
+	if n.arrow {
+		obj.arrow = true
+		obj.this = vm.stack[vm.sp-1]
+		vm.sp--
+		if n.home {
+			obj.homeObject = vm.callee().homeObject
+		}
+	}
+	if n.generator {
+		obj.generator = true
+		obj.prototype = vm.r.global.GeneratorFunctionPrototype
+	}
+	if n.async {
+		obj.async = true
+		obj.prototype = vm.r.global.AsyncFunctionPrototype
+	}
 	vm.push(obj.val)
 	vm.pc++
 }
 
-type bindName string
+// callee returns the function being executed, the compiler only emits the instructions that use it in the code
+// of functions.
+func (vm *vm) callee() *funcObject {
+	return vm.stack[vm.sb-1].(*Object).self.(*funcObject)
+}
 
-func (d bindName) exec(vm *vm) {
-	if vm.stash != nil {
-		vm.stash.createBinding(string(d))
-	} else {
-		vm.r.globalObject.self._putProp(string(d), _undefined, true, true, false)
-	}
+type _newClass struct{}
+
+// newClass makes the function on top of the stack the constructor of a class without an extends clause and
+// pushes the prototype of the class.
+var newClass _newClass
+
+func (_newClass) exec(vm *vm) {
+	f := vm.stack[vm.sp-1].(*Object).self.(*funcObject)
+	vm.push(f.initClass(vm.r.global.ObjectPrototype, vm.r.global.FunctionPrototype, false))
 	vm.pc++
 }
 
-type jne int32
+type _newDerivedClass struct{}
 
-func (j jne) exec(vm *vm) {
-	vm.sp--
-	if !vm.stack[vm.sp].ToBoolean() {
-		vm.pc += int(j)
-	} else {
-		vm.pc++
+// newDerivedClass is newClass for a class with an extends clause, the value of the clause is below the function.
+var newDerivedClass _newDerivedClass
+
+func (_newDerivedClass) exec(vm *vm) {
+	parent := vm.stack[vm.sp-2]
+	f := vm.stack[vm.sp-1].(*Object).self.(*funcObject)
+	protoParent, ctorParent := (*Object)(nil), vm.r.global.FunctionPrototype
+	if parent != _null {
+		if vm.r.toConstructor(parent) == nil {
+			vm.r.typeErrorResult(true, "Class extends value %s is not a constructor or null", parent.String())
+		}
+		ctorParent = parent.(*Object)
+		switch proto := ctorParent.self.getStr("prototype").(type) {
+		case *Object:
+			protoParent = proto
+		case valueNull:
+		default:
+			vm.r.typeErrorResult(true, "Class extends value does not have valid prototype property %s", proto.String())
+		}
 	}
+	vm.stack[vm.sp-2] = f.val
+	vm.stack[vm.sp-1] = f.initClass(protoParent, ctorParent, true)
+	vm.pc++
 }
 
-type jeq int32
+// defineMethod defines a method of a class on the object below it on the stack, which is either the prototype
+// or (for a static method) the constructor of the class. The object becomes the home object of the method.
+type defineMethod string
 
-func (j jeq) exec(vm *vm) {
+func (d defineMethod) exec(vm *vm) {
+	obj := vm.stack[vm.sp-2].(*Object)
+	obj.self.defineOwnProperty(newStringValue(string(d)), PropertyDescriptor{
+		Value:        vm.initMethod(obj),
+		Writable:     FLAG_TRUE,
+		Configurable: FLAG_TRUE,
+		Enumerable:   FLAG_FALSE,
+	}, true)
 	vm.sp--
-	if vm.stack[vm.sp].ToBoolean() {
-		vm.pc += int(j)
-	} else {
-		vm.pc++
-	}
+	vm.pc++
 }
 
-type jeq1 int32
+// defineGetter is defineMethod for a getter.
+type defineGetter string
 
-func (j jeq1) exec(vm *vm) {
-	if vm.stack[vm.sp-1].ToBoolean() {
-		vm.pc += int(j)
-	} else {
-		vm.pc++
-	}
+func (d defineGetter) exec(vm *vm) {
+	obj := vm.stack[vm.sp-2].(*Object)
+	obj.self.defineOwnProperty(newStringValue(string(d)), PropertyDescriptor{
+		Getter:       vm.initMethod(obj),
+		Configurable: FLAG_TRUE,
+		Enumerable:   FLAG_FALSE,
+	}, true)
+	vm.sp--
+	vm.pc++
 }
 
-type jneq1 int32
+// defineSetter is defineMethod for a setter.
+type defineSetter string
 
-func (j jneq1) exec(vm *vm) {
-	if !vm.stack[vm.sp-1].ToBoolean() {
-		vm.pc += int(j)
-	} else {
-		vm.pc++
-	}
+func (d defineSetter) exec(vm *vm) {
+	obj := vm.stack[vm.sp-2].(*Object)
+	obj.self.defineOwnProperty(newStringValue(string(d)), PropertyDescriptor{
+		Setter:       vm.initMethod(obj),
+		Configurable: FLAG_TRUE,
+		Enumerable:   FLAG_FALSE,
+	}, true)
+	vm.sp--
+	vm.pc++
 }
 
-type _not struct{}
+func (vm *vm) initMethod(home *Object) *Object {
+	f := vm.stack[vm.sp-1].(*Object)
+	m := f.self.(*funcObject)
+	m.method = true
+	m.homeObject = home
+	return f
+}
 
-var not _not
+type _loadDerivedThis struct{}
 
-func (_not) exec(vm *vm) {
+// loadDerivedThis is the value of this in a derived constructor, it throws a ReferenceError until super() is called.
+var loadDerivedThis _loadDerivedThis
+
+func (_loadDerivedThis) exec(vm *vm) {
+	this := vm.stack[vm.sb]
+	if this == _undefined {
+		panic(vm.r.newError(vm.r.global.ReferenceError, "Must call super constructor in derived class before accessing 'this' or returning from derived constructor"))
+	}
+	vm.push(this)
+	vm.pc++
+}
+
+type _checkDerivedRet struct{}
+
+// checkDerivedRet replaces the value returned from a derived constructor with this unless it is an object.
+var checkDerivedRet _checkDerivedRet
+
+func (_checkDerivedRet) exec(vm *vm) {
+	switch vm.stack[vm.sp-1].(type) {
+	case *Object:
+		vm.pc++
+	case valueUndefined:
+		vm.sp--
+		loadDerivedThis.exec(vm)
+	default:
+		vm.r.typeErrorResult(true, "Derived constructors may only return object or undefined")
+	}
+}
+
+// superCall creates the instance in a derived constructor by calling the constructor of the parent class, which
+// is the prototype of the derived one. The instance initialises this and is pushed as the result of super().
+type superCall uint32
+
+func (n superCall) exec(vm *vm) {
+	args := make([]Value, n)
+	copy(args, vm.stack[vm.sp-int(n):])
+	vm.sp -= int(n)
+	vm.push(vm.superCall(args))
+	vm.pc++
+}
+
+type _superCallSpread struct{}
+
+var superCallSpread _superCallSpread
+
+func (_superCallSpread) exec(vm *vm) {
+	superCall(vm.pushSpreadArgs()).exec(vm)
+}
+
+func (vm *vm) superCall(args []Value) *Object {
+	f := vm.callee()
+	parent := f.proto()
+	if parent == nil {
+		vm.r.typeErrorResult(true, "Super constructor null is not a constructor")
+	}
+	if vm.r.toConstructor(parent) == nil {
+		vm.r.typeErrorResult(true, "Super constructor %s is not a constructor", parent.String())
+	}
+	obj := vm.r.construct(parent, args, vm.newTarget)
+	if vm.stack[vm.sb] != _undefined {
+		panic(vm.r.newError(vm.r.global.ReferenceError, "Super constructor may only be called once"))
+	}
+	vm.stack[vm.sb] = obj
+	return obj
+}
+
+// getSuper replaces this on top of the stack with the value of a property of the prototype of the home object
+// of the current function, getters are called with this.
+type getSuper string
+
+func (g getSuper) exec(vm *vm) {
+	vm.stack[vm.sp-1] = vm.getSuper(vm.stack[vm.sp-1], newStringValue(string(g)))
+	vm.pc++
+}
+
+type _getSuperElem struct{}
+
+// getSuperElem is getSuper with the property name on the stack.
+var getSuperElem _getSuperElem
+
+func (_getSuperElem) exec(vm *vm) {
+	vm.stack[vm.sp-2] = vm.getSuper(vm.stack[vm.sp-2], toPropertyKey(vm.stack[vm.sp-1]))
+	vm.sp--
+	vm.pc++
+}
+
+func (vm *vm) getSuper(this Value, name Value) Value {
+	home := vm.callee().homeObject
+	proto := home.self.proto()
+	if proto == nil {
+		vm.r.typeErrorResult(true, "Cannot read property '%s' of null", name.String())
+	}
+	switch prop := proto.self.getProp(name).(type) {
+	case nil:
+		return _undefined
+	case *valueProperty:
+		return prop.get(this)
+	default:
+		return prop
+	}
+}
+
+// saveFrame removes the frame of the current function from the stacks. The items of the iterator, the reference
+// and the try stacks above the given lengths belong to the frame.
+func (vm *vm) saveFrame(iterLen, refLen, tryLen int) *suspendedFrame {
+	f := &suspendedFrame{}
+	vm.saveCtx(&f.ctx)
+	base := vm.sb - 1
+	f.stack = make(valueStack, vm.sp-base)
+	copy(f.stack, vm.stack[base:vm.sp])
+	stackTail := vm.stack[base:vm.sp]
+	for i := range stackTail {
+		stackTail[i] = nil
+	}
+	vm.sp = base
+
+	if len(vm.iterStack) > iterLen {
+		f.iterStack = make([]iterStackItem, len(vm.iterStack)-iterLen)
+		copy(f.iterStack, vm.iterStack[iterLen:])
+	}
+	if len(vm.refStack) > refLen {
+		f.refStack = make([]ref, len(vm.refStack)-refLen)
+		copy(f.refStack, vm.refStack[refLen:])
+	}
+	if len(vm.tryStack) > tryLen {
+		f.tryStack = make([]tryFrame, len(vm.tryStack)-tryLen)
+		for i, frame := range vm.tryStack[tryLen:] {
+			// the positions are kept relative to the frame
+			frame.callStackLen -= len(vm.callStack)
+			frame.iterLen -= iterLen
+			frame.refLen -= refLen
+			frame.sp -= base
+			f.tryStack[i] = frame
+		}
+	}
+	vm.unwind(len(vm.callStack), iterLen, refLen, false)
+	tryTail := vm.tryStack[tryLen:]
+	for i := range tryTail {
+		tryTail[i] = tryFrame{}
+	}
+	vm.tryStack = vm.tryStack[:tryLen]
+	return f
+}
+
+// resumeFrame continues the execution of a suspended frame, the values are pushed on its stack first. It returns the
+// value on top of the stack when the execution is suspended again, along with the new frame, or the value the
+// function has returned.
+func (vm *vm) resumeFrame(f *suspendedFrame, values ...Value) (Value, *suspendedFrame) {
+	pc := vm.pc
+	vm.pc = -1
+	vm.pushCtx()
+	callStackLen := len(vm.callStack)
+	iterLen, refLen, tryLen := len(vm.iterStack), len(vm.refStack), len(vm.tryStack)
+	base := vm.sp
+
+	vm.stack.expand(base + len(f.stack) + len(values))
+	copy(vm.stack[base:], f.stack)
+	vm.sp = base + len(f.stack)
+	vm.restoreCtx(&f.ctx)
+	vm.sb = base + 1
+	vm.iterStack = append(vm.iterStack, f.iterStack...)
+	vm.refStack = append(vm.refStack, f.refStack...)
+	for _, frame := range f.tryStack {
+		frame.callStackLen += callStackLen
+		frame.iterLen += iterLen
+		frame.refLen += refLen
+		frame.sp += base
+		vm.tryStack = append(vm.tryStack, frame)
+	}
+	for _, v := range values {
+		vm.push(v)
+	}
+
+	// the try frames of the generator must be handled during this run
+	for !vm.runLoop(tryLen) {
+	}
+	vm.halt = false
+	if vm.suspended {
+		vm.suspended = false
+		v := vm.pop()
+		f = vm.saveFrame(iterLen, refLen, tryLen)
+		vm.popCtx()
+		vm.pc = pc
+		return v, f
+	}
+	// a return from the middle of a destructuring may leave items on the iterator stack
+	vm.unwind(len(vm.callStack), iterLen, refLen, false)
+	vm.pc = pc
+	return vm.pop(), nil
+}
+
+type _createGenerator struct{}
+
+// createGenerator suspends a generator function once its parameters are initialised and returns a generator object
+// which resumes it.
+var createGenerator _createGenerator
+
+func (_createGenerator) exec(vm *vm) {
+	vm.pc++
+	f := vm.saveFrame(len(vm.iterStack), len(vm.refStack), len(vm.tryStack))
+	proto, ok := f.stack[0].(*Object).self.getStr("prototype").(*Object)
+	if !ok {
+		proto = vm.r.global.GeneratorPrototype
+	}
+	vm.push(vm.r.newGenerator(proto, f).val)
+	vm.popCtx()
+	if vm.pc < 0 {
+		vm.halt = true
+	}
+}
+
+type _yield struct{}
+
+var yield _yield
+
+// yield suspends the generator, the value on top of the stack is wrapped into an iterator result.
+func (_yield) exec(vm *vm) {
+	vm.stack[vm.sp-1] = vm.r.createIterResultObject(vm.stack[vm.sp-1], false)
+	vm.suspended = true
+	vm.halt = true
+	vm.pc++
+}
+
+// yieldResume continues a generator resumed after a yield. The value it has been resumed with is on the stack, below
+// the kind of completion. After a normal completion it jumps to the given offset, a return completion continues with
+// the next instruction (which returns the value) and a throw completion throws the value.
+type yieldResume int32
+
+func (j yieldResume) exec(vm *vm) {
+	kind := vm.pop()
+	switch toInt32(kind) {
+	case resumeNext:
+		vm.pc += int(j)
+	case resumeReturn:
+		vm.pc++
+	default:
+		panic(vm.pop())
+	}
+}
+
+type _yieldDelegateStart struct{}
+
+var yieldDelegateStart _yieldDelegateStart
+
+// yieldDelegateStart moves the iterator of the value on top of the stack to the iterator stack, where yieldDelegate
+// finds it, and starts the delegation with a normal completion.
+func (_yieldDelegateStart) exec(vm *vm) {
+	iter := vm.r.getIterator(vm.stack[vm.sp-1])
+	// the iterator must not be closed if it throws
+	vm.iterStack = append(vm.iterStack, iterStackItem{val: iter.iterator})
+	vm.stack[vm.sp-1] = _undefined
+	vm.push(intToValue(resumeNext))
+	vm.pc++
+}
+
+// yieldDelegate passes the completion on top of the stack to the iterator the generator delegates to. While the
+// iterator is not done, the generator is suspended with its results as they are and the instruction is executed
+// again when it is resumed. Once the iterator is done it jumps to the given offset with its value on the stack,
+// unless the completion was a return, in which case it continues with the next instruction.
+type yieldDelegate int32
+
+func (j yieldDelegate) exec(vm *vm) {
+	r := vm.r
+	kind := toInt32(vm.stack[vm.sp-1])
+	received := vm.stack[vm.sp-2]
+	vm.sp -= 2
+	iter := vm.iterStack[len(vm.iterStack)-1].val
+	var res Value
+	switch kind {
+	case resumeNext:
+		res = r.invoke(iter, "next", received)
+	case resumeReturn:
+		method := toMethod(r.getV(iter, asciiString("return")))
+		if method == nil {
+			vm.push(received)
+			vm.pc++
+			return
+		}
+		res = method(FunctionCall{This: iter, Arguments: []Value{received}})
+	default:
+		method := toMethod(r.getV(iter, asciiString("throw")))
+		if method == nil {
+			(&iteratorRecord{iterator: iter.(*Object)}).close()
+			r.typeErrorResult(true, "The iterator does not provide a 'throw' method")
+		}
+		res = method(FunctionCall{This: iter, Arguments: []Value{received}})
+	}
+	resObj, ok := res.(*Object)
+	if !ok {
+		r.typeErrorResult(true, "Iterator result %s is not an object", res.String())
+	}
+	if nilSafe(resObj.self.getStr("done")).ToBoolean() {
+		vm.push(nilSafe(resObj.self.getStr("value")))
+		if kind == resumeReturn {
+			vm.pc++
+		} else {
+			vm.pc += int(j)
+		}
+		return
+	}
+	vm.push(resObj)
+	vm.suspended = true
+	vm.halt = true
+}
+
+type _startAsync struct{}
+
+// startAsync runs the rest of an async function until it awaits for the first time and returns a promise which is
+// settled when the function completes.
+var startAsync _startAsync
+
+func (_startAsync) exec(vm *vm) {
+	vm.pc++
+	f := vm.saveFrame(len(vm.iterStack), len(vm.refStack), len(vm.tryStack))
+	p := vm.r.newPromise(vm.r.global.PromisePrototype)
+	vm.r.asyncStep(p, f)
+	vm.push(p.val)
+	vm.popCtx()
+	if vm.pc < 0 {
+		vm.halt = true
+	}
+}
+
+type _await struct{}
+
+// await suspends the async function until the value on top of the stack settles. The function is resumed like a
+// generator, see yieldResume.
+var await _await
+
+func (_await) exec(vm *vm) {
+	vm.suspended = true
+	vm.halt = true
+	vm.pc++
+}
+
+type _importCall struct{}
+
+// importCall replaces the specifier on top of the stack with a promise of the namespace of the module it refers to,
+// which is loaded relative to the program being executed.
+var importCall _importCall
+
+func (_importCall) exec(vm *vm) {
+	vm.stack[vm.sp-1] = vm.r.importModuleDynamically(vm.prg.src.name, vm.stack[vm.sp-1])
+	vm.pc++
+}
+
+// getTemplateObject pushes the strings array passed to the tag function of a tagged template. Undefined cooked
+// values are represented by nil. The array is created once per Runtime for each call site and the same one is passed
+// each time the template is evaluated. The Runtimes cache the arrays by the templateSite which, unlike the
+// instruction, does not keep the code alive (see Runtime.addTemplateObject).
+type getTemplateObject struct {
+	cooked []Value
+	raw    []Value
+	site   *templateSite
+}
+
+// templateSite identifies the call site of a tagged template. It is marked dead by a finalizer once the code holding
+// the getTemplateObject instruction becomes unreachable.
+type templateSite struct {
+	// accessed atomically as the finalizers run in a separate goroutine
+	dead int32
+}
+
+func newGetTemplateObject(cooked, raw []Value) *getTemplateObject {
+	t := &getTemplateObject{cooked: cooked, raw: raw, site: &templateSite{}}
+	runtime.SetFinalizer(t, finalizeGetTemplateObject)
+	return t
+}
+
+func finalizeGetTemplateObject(t *getTemplateObject) {
+	atomic.StoreInt32(&t.site.dead, 1)
+}
+
+func (t *getTemplateObject) exec(vm *vm) {
+	r := vm.r
+	obj := r.templateObjects[t.site]
+	if obj == nil {
+		obj = r.newTemplateObject(t.cooked, t.raw)
+		r.addTemplateObject(t.site, obj)
+	}
+	vm.push(obj)
+	vm.pc++
+}
+
+type bindName string
+
+func (d bindName) exec(vm *vm) {
+	stash := vm.stash
+	for stash != nil && stash.lexical {
+		// var declarations (from a non-strict eval) are not scoped to blocks
+		stash = stash.outer
+	}
+	if stash != nil {
+		stash.createBinding(string(d))
+	} else {
+		if _, exists := vm.r.globalLexicals.names[string(d)]; exists {
+			vm.r.throwRedeclarationError(string(d))
+		}
+		vm.r.globalObject.self._putProp(string(d), _undefined, true, true, false)
+	}
+	vm.pc++
+}
+
+// bindGlobalLexical declares a let or const binding of a script in the global lexical scope, which is shared
+// by all scripts run by the Runtime.
+type bindGlobalLexical struct {
+	name    string
+	isConst bool
+}
+
+func (b bindGlobalLexical) exec(vm *vm) {
+	s := vm.r.globalLexicals
+	if _, exists := s.names[b.name]; exists {
+		vm.r.throwRedeclarationError(b.name)
+	}
+	if prop, ok := vm.r.globalObject.self.getOwnProp(b.name).(*valueProperty); ok && !prop.configurable {
+		vm.r.throwRedeclarationError(b.name)
+	}
+	s.createBinding(b.name)
+	s.values[s.names[b.name]] = nil
+	if b.isConst {
+		if s.consts == nil {
+			s.consts = make(map[string]bool)
+		}
+		s.consts[b.name] = true
+	}
+	vm.pc++
+}
+
+// initGlobalLexical initialises a global let or const binding with the value on top of the stack and pops it.
+type initGlobalLexical string
+
+func (n initGlobalLexical) exec(vm *vm) {
+	s := vm.r.globalLexicals
+	s.values[s.names[string(n)]] = vm.stack[vm.sp-1]
+	vm.sp--
+	vm.pc++
+}
+
+type jne int32
+
+func (j jne) exec(vm *vm) {
+	vm.sp--
+	if !vm.stack[vm.sp].ToBoolean() {
+		vm.pc += int(j)
+	} else {
+		vm.pc++
+	}
+}
+
+type jeq int32
+
+func (j jeq) exec(vm *vm) {
+	vm.sp--
+	if vm.stack[vm.sp].ToBoolean() {
+		vm.pc += int(j)
+	} else {
+		vm.pc++
+	}
+}
+
+type jeq1 int32
+
+func (j jeq1) exec(vm *vm) {
+	if vm.stack[vm.sp-1].ToBoolean() {
+		vm.pc += int(j)
+	} else {
+		vm.pc++
+	}
+}
+
+type jneq1 int32
+
+func (j jneq1) exec(vm *vm) {
+	if !vm.stack[vm.sp-1].ToBoolean() {
+		vm.pc += int(j)
+	} else {
+		vm.pc++
+	}
+}
+
+type _not struct{}
+
+var not _not
+
+func (_not) exec(vm *vm) {
 	if vm.stack[vm.sp-1].ToBoolean() {
 		vm.stack[vm.sp-1] = valueFalse
 	} else {
@@ -2051,6 +3059,25 @@ func toPrimitiveNumber(v Value) Value {
 	return v
 }
 
+// cmpBigInt compares a BigInt with a primitive value. It returns -1, 0 or 1 if b is respectively less than, equal to
+// or greater than the value, or 2 if they cannot be compared.
+func cmpBigInt(b *big.Int, other Value) int {
+	switch o := other.(type) {
+	case *valueBigInt:
+		return b.Cmp(o.toBig())
+	case valueString:
+		if ob, ok := stringToBigInt(o); ok {
+			return b.Cmp(ob)
+		}
+		return 2
+	}
+	num := other.ToNumber()
+	if i, ok := num.assertInt(); ok {
+		return b.Cmp(big.NewInt(i))
+	}
+	return compareBigIntFloat(b, num.ToFloat())
+}
+
 func cmp(px, py Value) Value {
 	var ret bool
 	var nx, ny float64
@@ -2069,6 +3096,24 @@ func cmp(px, py Value) Value {
 		}
 	}
 
+	if xb, ok := px.(*valueBigInt); ok {
+		c := cmpBigInt(xb.toBig(), py)
+		if c == 2 {
+			return _undefined
+		}
+		ret = c < 0
+		goto end
+	}
+
+	if yb, ok := py.(*valueBigInt); ok {
+		c := cmpBigInt(yb.toBig(), px)
+		if c == 2 {
+			return _undefined
+		}
+		ret = c > 0
+		goto end
+	}
+
 	nx = px.ToFloat()
 	ny = py.ToFloat()
 
@@ -2224,7 +3269,7 @@ func (_op_instanceof) exec(vm *vm) {
 	left := vm.stack[vm.sp-2]
 	right := vm.r.toObject(vm.stack[vm.sp-1])
 
-	if right.self.hasInstance(left) {
+	if vm.r.instanceOf(left, right) {
 		vm.stack[vm.sp-2] = valueTrue
 	} else {
 		vm.stack[vm.sp-2] = valueFalse
@@ -2239,7 +3284,7 @@ type _op_in struct{}
 var op_in _op_in
 
 func (_op_in) exec(vm *vm) {
-	left := vm.stack[vm.sp-2]
+	left := toPropertyKey(vm.stack[vm.sp-2])
 	right := vm.r.toObject(vm.stack[vm.sp-1])
 
 	if right.self.hasProperty(left) {
@@ -2259,51 +3304,63 @@ type try struct {
 }
 
 func (t try) exec(vm *vm) {
-	o := vm.pc
-	vm.pc++
-	ex := vm.runTry()
-	if ex != nil && t.catchOffset > 0 {
-		// run the catch block (in try)
-		vm.pc = o + int(t.catchOffset)
-		// TODO: if ex.val is an Error, set the stack property
-		if t.dynamic {
-			vm.newStash()
-			vm.stash.putByIdx(0, ex.val)
-		} else {
-			vm.push(ex.val)
-		}
-		ex = vm.runTry()
-		if t.dynamic {
-			vm.stash = vm.stash.outer
-		}
+	frame := tryFrame{
+		callStackLen: len(vm.callStack),
+		iterLen:      len(vm.iterStack),
+		refLen:       len(vm.refStack),
+		sp:           vm.sp,
+		stash:        vm.stash,
+		catchPos:     -1,
+		finallyPos:   -1,
+		dynamic:      t.dynamic,
+	}
+	if t.catchOffset > 0 {
+		frame.catchPos = vm.pc + int(t.catchOffset)
 	}
-
 	if t.finallyOffset > 0 {
-		pc := vm.pc
-		// Run finally
-		vm.pc = o + int(t.finallyOffset)
-		vm.run()
-		if vm.prg.code[vm.pc] == retFinally {
-			vm.pc = pc
-		} else {
-			// break or continue out of finally, dropping exception
-			ex = nil
-		}
+		frame.finallyPos = vm.pc + int(t.finallyOffset)
 	}
+	vm.tryStack = append(vm.tryStack, frame)
+	vm.pc++
+}
 
-	vm.halt = false
+type _leaveTry struct{}
 
-	if ex != nil {
-		panic(ex)
+var leaveTry _leaveTry
+
+// leaveTry completes the try or the catch block of the innermost try frame, it is also emitted when a break,
+// continue or return statement leaves the block. If there is a finally block, it runs before the execution
+// continues with the next instruction.
+func (_leaveTry) exec(vm *vm) {
+	l := len(vm.tryStack) - 1
+	frame := &vm.tryStack[l]
+	vm.stash = frame.stash
+	if frame.finallyPos >= 0 {
+		frame.finallyRet = vm.pc + 1
+		vm.pc = frame.finallyPos
+		frame.catchPos, frame.finallyPos = -1, -1
+		return
 	}
+	vm.tryStack[l] = tryFrame{}
+	vm.tryStack = vm.tryStack[:l]
+	vm.pc++
 }
 
-type _retFinally struct{}
+type _leaveFinally struct{}
 
-var retFinally _retFinally
+var leaveFinally _leaveFinally
 
-func (_retFinally) exec(vm *vm) {
-	vm.pc++
+// leaveFinally completes the finally block of the innermost try frame. The exception which caused the block to be
+// entered is rethrown, otherwise the execution continues where leaveTry has left off.
+func (_leaveFinally) exec(vm *vm) {
+	l := len(vm.tryStack) - 1
+	frame := vm.tryStack[l]
+	vm.tryStack[l] = tryFrame{}
+	vm.tryStack = vm.tryStack[:l]
+	if frame.exception != nil {
+		panic(frame.exception)
+	}
+	vm.pc = frame.finallyRet
 }
 
 type enterCatch string
@@ -2392,6 +3449,10 @@ func (_typeof) exec(vm *vm) {
 		r = stringString
 	case valueInt, valueFloat:
 		r = stringNumber
+	case *Symbol:
+		r = stringSymbol
+	case *valueBigInt:
+		r = stringBigInt
 	default:
 		panic(fmt.Errorf("Unknown type: %T", v))
 	}
@@ -2433,6 +3494,7 @@ func (formalArgs createArgs) exec(vm *vm) {
 	}
 
 	args._putProp("callee", vm.stack[vm.sb-1], true, false, true)
+	args._putSym(SymIterator, vm.r.global.arrayValues, true, false, true)
 	vm.push(v)
 	vm.pc++
 }
@@ -2459,6 +3521,7 @@ func (formalArgs createArgsStrict) exec(vm *vm) {
 	args._putProp("length", intToValue(int64(vm.args)), true, false, true)
 	args._put("callee", vm.r.global.throwerProperty)
 	args._put("caller", vm.r.global.throwerProperty)
+	args._putSym(SymIterator, vm.r.global.arrayValues, true, false, true)
 	vm.push(args.val)
 	vm.pc++
 }
@@ -2483,6 +3546,69 @@ func (_leaveWith) exec(vm *vm) {
 	vm.pc++
 }
 
+// enterBlockScope creates the stash for the let and const bindings of a block. All bindings start uninitialised.
+type enterBlockScope struct {
+	names  map[string]uint32
+	consts map[string]bool
+}
+
+func (e enterBlockScope) exec(vm *vm) {
+	vm.newStash()
+	vm.stash.lexical = true
+	vm.stash.names = e.names
+	vm.stash.consts = e.consts
+	vm.stash.values = make([]Value, len(e.names))
+	vm.pc++
+}
+
+type _leaveBlockScope struct{}
+
+var leaveBlockScope _leaveBlockScope
+
+func (_leaveBlockScope) exec(vm *vm) {
+	vm.stash = vm.stash.outer
+	vm.pc++
+}
+
+// copyBlockScope replaces the current block stash with a copy so that closures created during a loop iteration
+// keep the values of that iteration.
+type _copyBlockScope struct{}
+
+var copyBlockScope _copyBlockScope
+
+func (_copyBlockScope) exec(vm *vm) {
+	s := vm.stash
+	values := make(valueStack, len(s.values))
+	copy(values, s.values)
+	vm.stash = &stash{
+		values:  values,
+		names:   s.names,
+		lexical: true,
+		consts:  s.consts,
+		outer:   s.outer,
+	}
+	vm.stashAllocs++
+	vm.pc++
+}
+
+// checkInit throws a ReferenceError if the value on top of the stack is an uninitialised let or const binding.
+type checkInit string
+
+func (n checkInit) exec(vm *vm) {
+	if vm.stack[vm.sp-1] == nil {
+		vm.r.throwUninitializedError(string(n))
+	}
+	vm.pc++
+}
+
+type _throwConstAssignment struct{}
+
+var throwConstAssignment _throwConstAssignment
+
+func (_throwConstAssignment) exec(vm *vm) {
+	vm.r.throwConstAssignmentError()
+}
+
 func emptyIter() (propIterItem, iterNextFunc) {
 	return propIterItem{}, nil
 }
@@ -2536,3 +3662,222 @@ func (_enumPop) exec(vm *vm) {
 	vm.iterStack = vm.iterStack[:l]
 	vm.pc++
 }
+
+type _iterate struct{}
+
+var iterate _iterate
+
+// iterate replaces the value on top of the stack by its iterator, which is pushed on the iterator stack.
+func (_iterate) exec(vm *vm) {
+	iter := vm.r.getIterator(vm.stack[vm.sp-1])
+	vm.iterStack = append(vm.iterStack, iterStackItem{iter: iter})
+	vm.sp--
+	vm.pc++
+}
+
+// iterNext advances the iterator on top of the iterator stack and makes the value available to enumGet. When the
+// iterator is done it jumps to the given offset.
+type iterNext int32
+
+func (jmp iterNext) exec(vm *vm) {
+	l := len(vm.iterStack) - 1
+	iter := vm.iterStack[l].iter
+	// an iterator which throws from next() must not be closed
+	vm.iterStack[l].iter = nil
+	value, ok := iter.step()
+	if ok {
+		vm.iterStack[l].val = value
+		vm.iterStack[l].iter = iter
+		vm.pc++
+	} else {
+		vm.pc += int(jmp)
+	}
+}
+
+type _iterGetNextOrUndef struct{}
+
+var iterGetNextOrUndef _iterGetNextOrUndef
+
+// iterGetNextOrUndef pushes the next value of the iterator on top of the iterator stack, or undefined if the
+// iterator is done.
+func (_iterGetNextOrUndef) exec(vm *vm) {
+	l := len(vm.iterStack) - 1
+	var value Value = _undefined
+	if iter := vm.iterStack[l].iter; iter != nil {
+		vm.iterStack[l].iter = nil
+		if v, ok := iter.step(); ok {
+			vm.iterStack[l].iter = iter
+			value = v
+		}
+	}
+	vm.push(value)
+	vm.pc++
+}
+
+type _iterGetRest struct{}
+
+var iterGetRest _iterGetRest
+
+// iterGetRest pushes an array with the remaining values of the iterator on top of the iterator stack.
+func (_iterGetRest) exec(vm *vm) {
+	l := len(vm.iterStack) - 1
+	var values []Value
+	if iter := vm.iterStack[l].iter; iter != nil {
+		vm.iterStack[l].iter = nil
+		for {
+			v, ok := iter.step()
+			if !ok {
+				break
+			}
+			values = append(values, v)
+		}
+	}
+	vm.push(vm.r.newArrayValues(values))
+	vm.pc++
+}
+
+type _enumPopClose struct{}
+
+var enumPopClose _enumPopClose
+
+// enumPopClose pops the iterator stack. If the item holds an iterator which is not done yet, the iterator is closed.
+func (_enumPopClose) exec(vm *vm) {
+	l := len(vm.iterStack) - 1
+	iter := vm.iterStack[l].iter
+	vm.iterStack[l] = iterStackItem{}
+	vm.iterStack = vm.iterStack[:l]
+	if iter != nil {
+		iter.close()
+	}
+	vm.pc++
+}
+
+type _destructObj struct{}
+
+var destructObj _destructObj
+
+// destructObj moves the source of an object destructuring from the stack to the iterator stack, where enumGet
+// finds it while the properties are extracted.
+func (_destructObj) exec(vm *vm) {
+	v := vm.stack[vm.sp-1]
+	if v == _undefined || v == _null {
+		vm.r.typeErrorResult(true, "Cannot destructure '%s' as it is %s.", v.String(), v.String())
+	}
+	vm.iterStack = append(vm.iterStack, iterStackItem{val: v})
+	vm.sp--
+	vm.pc++
+}
+
+// copyRest replaces the source object on top of the stack and the n property names below it by a new object
+// with the remaining own enumerable properties of the source.
+type copyRest uint32
+
+func (n copyRest) exec(vm *vm) {
+	excluded := make(map[string]bool, n)
+	for _, name := range vm.stack[vm.sp-1-int(n) : vm.sp-1] {
+		excluded[name.String()] = true
+	}
+	obj := vm.r.NewObject()
+	vm.r.copyDataProperties(obj, vm.stack[vm.sp-1], excluded)
+	vm.sp -= int(n)
+	vm.stack[vm.sp-1] = obj
+	vm.pc++
+}
+
+type _copySpread struct{}
+
+var copySpread _copySpread
+
+// copySpread copies the own enumerable properties of the value on top of the stack to the object literal below
+// it and pops the value.
+func (_copySpread) exec(vm *vm) {
+	vm.r.copyDataProperties(vm.r.toObject(vm.stack[vm.sp-2]), vm.stack[vm.sp-1], nil)
+	vm.sp--
+	vm.pc++
+}
+
+type _appendArray struct{}
+
+var appendArray _appendArray
+
+// appendArray appends the value on top of the stack (nil for a hole) to the array literal below it.
+func (_appendArray) exec(vm *vm) {
+	arr := vm.stack[vm.sp-2].(*Object).self.(*arrayObject)
+	v := vm.stack[vm.sp-1]
+	arr.values = append(arr.values, v)
+	arr.length++
+	if v != nil {
+		arr.objCount++
+	}
+	vm.sp--
+	vm.pc++
+}
+
+type _appendArraySpread struct{}
+
+var appendArraySpread _appendArraySpread
+
+// appendArraySpread appends all the values of the iterable on top of the stack to the array literal below it.
+func (_appendArraySpread) exec(vm *vm) {
+	arr := vm.stack[vm.sp-2].(*Object).self.(*arrayObject)
+	iter := vm.r.getIterator(vm.stack[vm.sp-1])
+	for {
+		v, ok := iter.step()
+		if !ok {
+			break
+		}
+		arr.values = append(arr.values, v)
+		arr.length++
+		arr.objCount++
+	}
+	vm.sp--
+	vm.pc++
+}
+
+// pushSpreadArgs replaces the arguments array built by a call with spread elements by its values.
+func (vm *vm) pushSpreadArgs() int {
+	arr := vm.pop().(*Object).self.(*arrayObject)
+	for _, v := range arr.values {
+		vm.push(v)
+	}
+	return len(arr.values)
+}
+
+type _callSpread struct{}
+
+var callSpread _callSpread
+
+func (_callSpread) exec(vm *vm) {
+	call(vm.pushSpreadArgs()).exec(vm)
+}
+
+type _newSpread struct{}
+
+var newSpread _newSpread
+
+func (_newSpread) exec(vm *vm) {
+	_new(vm.pushSpreadArgs()).exec(vm)
+}
+
+type _createArgsRestStash struct{}
+
+var createArgsRestStash _createArgsRestStash
+
+// createArgsRestStash pushes an array with the arguments that follow the formal parameters, in a function which
+// keeps its parameters in a stash.
+func (_createArgsRestStash) exec(vm *vm) {
+	vm.push(vm.r.newArrayValues(append([]Value(nil), vm.stash.extraArgs...)))
+	vm.pc++
+}
+
+// createArgsRestStack is createArgsRestStash for a function which keeps its n parameters on the stack.
+type createArgsRestStack uint32
+
+func (n createArgsRestStack) exec(vm *vm) {
+	var values []Value
+	if vm.args > int(n) {
+		values = append(values, vm.stack[vm.sb+1+int(n):vm.sb+1+vm.args]...)
+	}
+	vm.push(vm.r.newArrayValues(values))
+	vm.pc++
+}